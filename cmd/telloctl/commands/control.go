@@ -42,7 +42,7 @@ func EmergencyCmd(drone tello.TelloCommander) *cobra.Command {
 		Use:   "emergency",
 		Short: "Stop all motors immediately",
 		RunE: func(cmd *cobra.Command, args []string) error {
-			err := drone.Emergency()
+			err := drone.EmergencyNow()
 			if err != nil {
 				return fmt.Errorf("emergency command failed: %w", err)
 			}
@@ -1,10 +1,12 @@
 package commands
 
 import (
+	"context"
 	"errors"
 	"fmt"
 	"os"
 	"os/signal"
+	"sync"
 	"syscall"
 	"time"
 
@@ -17,16 +19,35 @@ import (
 // GamepadCmd creates the gamepad control command
 func GamepadCmd(drone tello.TelloCommander) *cobra.Command {
 	var preset string
-	var listGamepads bool
+	var listGamepads, feedback bool
+	var input, device, replayPath, recordPath string
 
 	cmd := &cobra.Command{
 		Use:   "gamepad",
 		Short: "Control the drone using a gamepad",
 		Long: `Control the DJI Tello drone using a gamepad controller.
-Supports Xbox, PlayStation, and generic USB controllers with customizable button mappings.`,
+Supports Xbox, PlayStation, and generic USB controllers with customizable button mappings.
+
+Input is read through SDL2 by default. --input evdev reads a Linux
+/dev/input device directly, without SDL2 or a display, and --input replay
+plays back a recording made with --record.`,
 		RunE: func(cmd *cobra.Command, args []string) error {
 			if listGamepads {
-				return listAvailableGamepads()
+				return listAvailableGamepads(input)
+			}
+
+			source, err := newInputSource(input, device, replayPath)
+			if err != nil {
+				return err
+			}
+			if recordPath != "" {
+				f, err := os.Create(recordPath)
+				if err != nil {
+					return fmt.Errorf("failed to create recording: %w", err)
+				}
+				defer f.Close()
+				source = gamepad.NewRecordingSource(source, f)
+				cmd.Printf("Recording gamepad input to %s\n", recordPath)
 			}
 
 			// Load configuration
@@ -40,11 +61,18 @@ Supports Xbox, PlayStation, and generic USB controllers with customizable button
 				return fmt.Errorf("SDK mode handshake failed: %w", err)
 			}
 
+			mapper, stopFeed := newStateAwareMapper(drone, config)
+			defer stopFeed()
+			macros := newMacroRunner(drone, mapper.Model())
+			defer macros.Stop()
+
 			// Create gamepad handler
 			handler, err := gamepad.NewHandler(gamepad.HandlerOptions{
 				Config: config,
+				Mapper: mapper,
+				Source: source,
 				OnCommand: func(command gamepad.Command) {
-					handleDroneCommand(drone, command)
+					handleDroneCommand(drone, mapper.Model(), macros, command)
 				},
 				OnError: func(err error) {
 					utils.Logger.Errorf("Gamepad error: %v", err)
@@ -60,6 +88,16 @@ Supports Xbox, PlayStation, and generic USB controllers with customizable button
 			}
 			defer handler.Stop()
 
+			if feedback && handler.HasFeedback() {
+				stopFeedback, err := startSafetyFeedback(drone, handler)
+				if err != nil {
+					utils.Logger.Warnf("Gamepad will not signal safety events: %v", err)
+				} else {
+					defer stopFeedback()
+					cmd.Println("The controller rumbles on safety events.")
+				}
+			}
+
 			// Set up signal handling for graceful shutdown
 			sigChan := make(chan os.Signal, 1)
 			signal.Notify(sigChan, syscall.SIGINT, syscall.SIGTERM)
@@ -67,6 +105,9 @@ Supports Xbox, PlayStation, and generic USB controllers with customizable button
 			cmd.Println("Gamepad control started. Press Ctrl+C to stop.")
 			cmd.Println("Use left stick for movement, right stick for altitude/yaw.")
 			cmd.Println("Press A for takeoff/land, B for emergency stop.")
+			if mode := mapper.FlightMode(); mode != "" {
+				cmd.Printf("Flight mode: %s (hold the cycle combo, both bumpers by default, to switch)\n", mode)
+			}
 
 			// Main loop for processing events
 			ticker := time.NewTicker(time.Second / time.Duration(config.Controller.UpdateRate))
@@ -77,12 +118,21 @@ Supports Xbox, PlayStation, and generic USB controllers with customizable button
 				case <-sigChan:
 					cmd.Println("\nShutting down gamepad control...")
 					// Land the drone before exiting
+					macros.Stop()
 					if err := drone.Land(); err != nil {
 						utils.Logger.Errorf("Failed to land drone: %v", err)
 					}
 					return nil
 				case <-ticker.C:
 					handler.ProcessEvents()
+					if handler.Ended() {
+						cmd.Println("Gamepad input ended, landing...")
+						macros.Stop()
+						if err := drone.Land(); err != nil {
+							utils.Logger.Errorf("Failed to land drone: %v", err)
+						}
+						return nil
+					}
 				}
 			}
 		},
@@ -91,10 +141,42 @@ Supports Xbox, PlayStation, and generic USB controllers with customizable button
 	// Add flags
 	cmd.Flags().StringVarP(&preset, "preset", "p", "default", "Use preset configuration (default, xbox, playstation)")
 	cmd.Flags().BoolVarP(&listGamepads, "list", "l", false, "List available gamepads and exit")
+	cmd.Flags().StringVarP(&input, "input", "i", "sdl", "Input backend (sdl, evdev, replay)")
+	cmd.Flags().StringVar(&device, "device", "", "Input device for evdev (default: first joystick in /dev/input/by-id)")
+	cmd.Flags().StringVar(&replayPath, "replay", "", "Recording to play back with --input replay")
+	cmd.Flags().StringVar(&recordPath, "record", "", "Record the gamepad input to this file for replay")
+	cmd.Flags().BoolVar(&feedback, "feedback", true, "Rumble the controller and set its light bar on safety events")
 
 	return cmd
 }
 
+// newInputSource creates the gamepad input backend named by input
+func newInputSource(input, device, replayPath string) (gamepad.InputSource, error) {
+	switch input {
+	case "", "sdl":
+		source, err := gamepad.NewSDLSource()
+		if err != nil {
+			return nil, err
+		}
+		return source, nil
+	case "evdev":
+		return gamepad.NewEvdevSource(device), nil
+	case "replay":
+		if replayPath == "" {
+			return nil, fmt.Errorf("--input replay needs a recording: pass --replay <file>")
+		}
+		source, err := gamepad.LoadReplayFile(replayPath)
+		if err != nil {
+			return nil, err
+		}
+		return source, nil
+	case "keyboard":
+		return nil, fmt.Errorf("keyboard input is read by the TUI: use telloctl tui --input keyboard")
+	default:
+		return nil, fmt.Errorf("unknown input backend %q (sdl, evdev, keyboard, replay)", input)
+	}
+}
+
 // loadGamepadConfig loads the gamepad configuration using auto-discovery when no preset override is supplied.
 func loadGamepadConfig(preset string, presetExplicit bool) (*gamepad.Config, error) {
 	if !presetExplicit {
@@ -124,8 +206,43 @@ func loadGamepadConfig(preset string, presetExplicit bool) (*gamepad.Config, err
 	return config, nil
 }
 
+// newStateAwareMapper creates a mapper whose drone model follows the drone's
+// states, so toggles and flips follow the drone. The returned function stops
+// feeding it. Without telemetry the model only follows the mapper's actions.
+func newStateAwareMapper(drone tello.TelloCommander, config *gamepad.Config) (*gamepad.DefaultMapper, func()) {
+	mapper := gamepad.NewDefaultMapper(config)
+	hub := drone.GetTelemetryHub()
+	if hub == nil {
+		return mapper, func() {}
+	}
+	sub, err := hub.Subscribe()
+	if err != nil {
+		utils.Logger.Warnf("Gamepad toggles will not follow the drone's state: %v", err)
+		return mapper, func() {}
+	}
+	go func() {
+		for state := range sub.C {
+			mapper.Model().UpdateState(state)
+		}
+	}()
+	return mapper, sub.Close
+}
+
+// startSafetyFeedback watches the drone's states with a safety manager and
+// plays its events on the controller. The manager also takes its configured
+// actions, e.g. landing when the battery runs out. The returned function
+// stops it.
+func startSafetyFeedback(drone tello.TelloCommander, handler *gamepad.Handler) (func(), error) {
+	manager, stop, err := startSafetyManager(drone, nil)
+	if err != nil {
+		return nil, err
+	}
+	manager.AddEventListener(handler.HandleSafetyEvent)
+	return stop, nil
+}
+
 // handleDroneCommand handles drone commands from the gamepad
-func handleDroneCommand(drone tello.TelloCommander, command gamepad.Command) {
+func handleDroneCommand(drone tello.TelloCommander, model *gamepad.DroneModel, macros *macroRunner, command gamepad.Command) {
 	switch command.Type {
 	case gamepad.CommandRC:
 		if rcValues, ok := command.Data.(gamepad.RCValues); ok {
@@ -138,76 +255,253 @@ func handleDroneCommand(drone tello.TelloCommander, command gamepad.Command) {
 
 	case gamepad.CommandAction:
 		if action, ok := command.Data.(gamepad.DroneAction); ok {
-			handleDroneAction(drone, action)
+			switch action {
+			case gamepad.ActionEmergency:
+				// Skips the queue and is answered at once, so it runs here
+				macros.Stop()
+				runDroneAction(drone, model, action)
+			case gamepad.ActionPhoto:
+				// Waiting for a keyframe would hold up the sticks
+				macros.Run(gamepad.Macro{{Command: gamepad.MacroPhoto}})
+			default:
+				// The drone replies once it has finished, e.g. a takeoff, so
+				// wait in the background to keep the sticks live
+				go runDroneAction(drone, model, action)
+			}
 		} else {
 			utils.Logger.Errorf("Invalid action command data type")
 		}
 
+	case gamepad.CommandFlightMode:
+		// The mapper already applies the mode to the sticks
+		utils.Logger.Infof("Flight mode: %s", command.Data)
+
+	case gamepad.CommandMacro:
+		if macro, ok := command.Data.(gamepad.Macro); ok {
+			macros.Run(macro)
+		} else {
+			utils.Logger.Errorf("Invalid macro command data type")
+		}
+
 	default:
 		utils.Logger.Warnf("Unknown command type: %s", command.Type)
 	}
 }
 
+// gamepadActionTimeout is how long a button's action waits for the drone to
+// finish it
+const gamepadActionTimeout = 20 * time.Second
+
+// runDroneAction runs an action and tells the model when the drone refuses
+// it, so that the next press tries it again
+func runDroneAction(drone tello.TelloCommander, model *gamepad.DroneModel, action gamepad.DroneAction) {
+	ctx, cancel := context.WithTimeout(context.Background(), gamepadActionTimeout)
+	defer cancel()
+	if err := handleDroneAction(ctx, drone, action); err != nil {
+		utils.Logger.Errorf("%s failed: %v", action, err)
+		model.ActionFailed(action)
+	}
+}
+
 // handleDroneAction handles drone actions triggered by gamepad buttons
-func handleDroneAction(drone tello.TelloCommander, action gamepad.DroneAction) {
+func handleDroneAction(ctx context.Context, drone tello.TelloCommander, action gamepad.DroneAction) error {
 	switch action {
 	case gamepad.ActionTakeoff:
 		utils.Logger.Info("Takeoff triggered")
-		if err := drone.TakeOff(); err != nil {
-			utils.Logger.Errorf("Takeoff failed: %v", err)
-		}
+		return drone.TakeOffCtx(ctx)
 
 	case gamepad.ActionLand:
 		utils.Logger.Info("Land triggered")
-		if err := drone.Land(); err != nil {
-			utils.Logger.Errorf("Land failed: %v", err)
-		}
+		return drone.LandCtx(ctx)
 
 	case gamepad.ActionEmergency:
 		utils.Logger.Warn("Emergency stop triggered")
-		if err := drone.Emergency(); err != nil {
-			utils.Logger.Errorf("Emergency stop failed: %v", err)
-		}
+		return drone.EmergencyNow()
 
 	case gamepad.ActionFlipForward:
 		utils.Logger.Info("Flip forward triggered")
-		if err := drone.Flip(tello.FlipForward); err != nil {
-			utils.Logger.Errorf("Flip forward failed: %v", err)
-		}
+		return drone.FlipCtx(ctx, tello.FlipForward)
 
 	case gamepad.ActionFlipBackward:
 		utils.Logger.Info("Flip backward triggered")
-		if err := drone.Flip(tello.FlipBackward); err != nil {
-			utils.Logger.Errorf("Flip backward failed: %v", err)
-		}
+		return drone.FlipCtx(ctx, tello.FlipBackward)
 
 	case gamepad.ActionFlipLeft:
 		utils.Logger.Info("Flip left triggered")
-		if err := drone.Flip(tello.FlipLeft); err != nil {
-			utils.Logger.Errorf("Flip left failed: %v", err)
-		}
+		return drone.FlipCtx(ctx, tello.FlipLeft)
 
 	case gamepad.ActionFlipRight:
 		utils.Logger.Info("Flip right triggered")
-		if err := drone.Flip(tello.FlipRight); err != nil {
-			utils.Logger.Errorf("Flip right failed: %v", err)
-		}
+		return drone.FlipCtx(ctx, tello.FlipRight)
 
 	case gamepad.ActionStreamOn:
-		utils.Logger.Info("Toggle video stream triggered")
-		// This would need state tracking to toggle properly
-		// For now, just turn on the stream
-		if err := drone.StreamOn(); err != nil {
-			utils.Logger.Errorf("Stream on failed: %v", err)
-		}
+		utils.Logger.Info("Video stream on triggered")
+		return drone.StreamOnCtx(ctx)
+
+	case gamepad.ActionStreamOff:
+		utils.Logger.Info("Video stream off triggered")
+		return drone.StreamOffCtx(ctx)
 
 	default:
 		utils.Logger.Warnf("Unknown drone action: %s", action)
+		return nil
 	}
 }
 
+// gamepadPhotoTimeout is how long a photo waits for a keyframe
+const gamepadPhotoTimeout = 10 * time.Second
+
+// macroRunner runs gamepad macros one at a time, in the background so the
+// sticks stay live while it waits for each step to finish
+type macroRunner struct {
+	drone tello.TelloCommander
+	model *gamepad.DroneModel
+
+	mu     sync.Mutex
+	cancel context.CancelFunc // Stops the running macro; nil when none runs
+}
+
+func newMacroRunner(drone tello.TelloCommander, model *gamepad.DroneModel) *macroRunner {
+	return &macroRunner{drone: drone, model: model}
+}
+
+// Run starts a macro, unless another is still running
+func (r *macroRunner) Run(macro gamepad.Macro) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.cancel != nil {
+		utils.Logger.Warnf("Ignoring macro %s: another is still running", macro)
+		return
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	r.cancel = cancel
+	go func() {
+		err := r.run(ctx, macro)
+
+		r.mu.Lock()
+		r.cancel = nil
+		r.mu.Unlock()
+		cancel()
+
+		switch {
+		case err == nil:
+			utils.Logger.Infof("Macro finished: %s", macro)
+		case errors.Is(err, context.Canceled):
+			utils.Logger.Warnf("Macro stopped: %s", macro)
+		default:
+			utils.Logger.Errorf("Macro %s failed: %v", macro, err)
+		}
+	}()
+}
+
+// Stop stops the running macro
+func (r *macroRunner) Stop() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.cancel != nil {
+		r.cancel()
+	}
+}
+
+// run runs the steps in turn, each once the drone has finished the last
+func (r *macroRunner) run(ctx context.Context, macro gamepad.Macro) error {
+	utils.Logger.Infof("Macro started: %s", macro)
+	for _, step := range macro {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		if err := r.runStep(ctx, step); err != nil {
+			return fmt.Errorf("%s: %w", step, err)
+		}
+	}
+	return nil
+}
+
+func (r *macroRunner) runStep(ctx context.Context, step gamepad.MacroStep) error {
+	drone, args := r.drone, step.Args
+	switch step.Command {
+	case "takeoff":
+		return drone.TakeOffCtx(ctx)
+	case "land":
+		return drone.LandCtx(ctx)
+	case "streamon":
+		if err := drone.StreamOnCtx(ctx); err != nil {
+			return err
+		}
+		r.model.SetStreaming(true)
+		return nil
+	case "streamoff":
+		if err := drone.StreamOffCtx(ctx); err != nil {
+			return err
+		}
+		r.model.SetStreaming(false)
+		return nil
+	case "up":
+		return drone.UpCtx(ctx, args[0])
+	case "down":
+		return drone.DownCtx(ctx, args[0])
+	case "left":
+		return drone.LeftCtx(ctx, args[0])
+	case "right":
+		return drone.RightCtx(ctx, args[0])
+	case "forward":
+		return drone.ForwardCtx(ctx, args[0])
+	case "back":
+		return drone.BackwardCtx(ctx, args[0])
+	case "cw":
+		return drone.ClockwiseCtx(ctx, args[0])
+	case "ccw":
+		return drone.CounterClockwiseCtx(ctx, args[0])
+	case "speed":
+		return drone.SetSpeedCtx(ctx, args[0])
+	case "go":
+		return drone.GoCtx(ctx, args[0], args[1], args[2], args[3])
+	case "curve":
+		return drone.CurveCtx(ctx, args[0], args[1], args[2], args[3], args[4], args[5], args[6])
+	case gamepad.MacroFlip:
+		return drone.FlipCtx(ctx, tello.FlipDirection(step.Flip))
+	case gamepad.MacroPhoto:
+		return r.photo(ctx)
+	case gamepad.MacroWait:
+		timer := time.NewTimer(step.Wait)
+		defer timer.Stop()
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-timer.C:
+			return nil
+		}
+	default:
+		return fmt.Errorf("unknown macro step")
+	}
+}
+
+// photo saves the next keyframe, starting the video stream if it is off
+func (r *macroRunner) photo(ctx context.Context) error {
+	if !r.model.Streaming() {
+		if err := r.drone.StreamOnCtx(ctx); err != nil {
+			return fmt.Errorf("failed to start video stream: %w", err)
+		}
+		r.model.SetStreaming(true)
+	}
+
+	path := fmt.Sprintf("photo-%s.jpg", time.Now().Format("20060102-150405.000"))
+	ctx, cancel := context.WithTimeout(ctx, gamepadPhotoTimeout)
+	defer cancel()
+	if err := r.drone.TakePhoto(ctx, path); err != nil {
+		return fmt.Errorf("failed to take photo: %w", err)
+	}
+	utils.Logger.Infof("Photo saved to %s", path)
+	return nil
+}
+
 // listAvailableGamepads lists all connected gamepads
-func listAvailableGamepads() error {
+func listAvailableGamepads(input string) error {
+	if input == "evdev" {
+		return listEvdevDevices()
+	}
+
 	gamepads := gamepad.ListGamepads()
 
 	if len(gamepads) == 0 {
@@ -228,3 +522,28 @@ func listAvailableGamepads() error {
 
 	return nil
 }
+
+// listEvdevDevices lists the joysticks under /dev/input
+func listEvdevDevices() error {
+	devices, err := gamepad.ListEvdevDevices()
+	if err != nil {
+		return fmt.Errorf("failed to list input devices: %w", err)
+	}
+
+	if len(devices) == 0 {
+		fmt.Println("No joysticks found in /dev/input/by-id.")
+		fmt.Println("Make sure your controller is connected and try again.")
+		return nil
+	}
+
+	fmt.Printf("Found %d joystick(s):\n", len(devices))
+	for i, device := range devices {
+		name := device.Name
+		if name == "" {
+			name = "unreadable, check permissions"
+		}
+		fmt.Printf("%d. %s (%s)\n", i+1, device.Path, name)
+	}
+
+	return nil
+}
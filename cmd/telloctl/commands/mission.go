@@ -0,0 +1,255 @@
+package commands
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"strings"
+	"syscall"
+	"text/tabwriter"
+	"time"
+
+	"github.com/conceptcodes/dji-tello-sdk-go/pkg/mission"
+	"github.com/conceptcodes/dji-tello-sdk-go/pkg/navigation"
+	"github.com/conceptcodes/dji-tello-sdk-go/pkg/tello"
+	"github.com/spf13/cobra"
+)
+
+// MissionCmd creates the mission command. validate and dry-run work without
+// a drone, in which case drone is nil.
+func MissionCmd(drone tello.TelloCommander, odometry *navigation.Odometry) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "mission",
+		Short: "Validate, preview and fly scripted missions",
+		Long: `Missions are YAML or JSON files listing steps for the drone to fly: takeoff, go,
+curve, rotate, wait, wait_until (battery or height), photo, loop and land.
+They are validated against configs/schemas/mission-schema.json.
+
+Examples:
+  telloctl mission validate square.yaml   # Check a mission without flying
+  telloctl mission dry-run square.yaml    # Print the predicted path and flight time
+  telloctl mission run square.yaml        # Fly the mission`,
+	}
+
+	cmd.AddCommand(
+		missionValidateCmd(),
+		missionDryRunCmd(),
+		missionRunCmd(drone, odometry),
+	)
+	return cmd
+}
+
+func missionValidateCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:   "validate [mission-file]",
+		Short: "Validate a mission file",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			m, err := mission.Load(args[0])
+			if err != nil {
+				return err
+			}
+			fmt.Printf("✓ Mission '%s' in %s is valid\n", m.Name, args[0])
+			return nil
+		},
+	}
+}
+
+func missionDryRunCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:   "dry-run [mission-file]",
+		Short: "Print the predicted path and flight time of a mission",
+		Long: `Print the position after each step and the predicted flight time without
+connecting to the drone. Positions are in cm from the takeoff point: X forward
+and Y right along the heading at takeoff, Z up. Yaw is clockwise in degrees.`,
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			m, err := mission.Load(args[0])
+			if err != nil {
+				return err
+			}
+			printPlan(m)
+			return nil
+		},
+	}
+}
+
+func printPlan(m *mission.Mission) {
+	plan := m.Plan()
+
+	fmt.Printf("🗺️  Mission: %s\n", m.Name)
+	if m.Description != "" {
+		fmt.Printf("   %s\n", m.Description)
+	}
+	fmt.Println()
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "STEP\tACTION\tX\tY\tZ\tYAW\tTIME")
+	fmt.Fprintln(w, "----\t------\t-\t-\t-\t---\t----")
+	for _, step := range plan.Steps {
+		fmt.Fprintf(w, "%s\t%s\t%.0f\t%.0f\t%.0f\t%.0f\t%s\n",
+			step.Path, step.Step, roundZero(step.X), roundZero(step.Y), roundZero(step.Z), roundZero(step.Yaw), formatElapsed(step.Elapsed))
+	}
+	w.Flush()
+
+	fmt.Println()
+	fmt.Printf("Flight time: %s\n", formatElapsed(plan.Duration))
+	fmt.Printf("Distance:    %.1f m\n", plan.Distance/100)
+	fmt.Printf("Max height:  %.1f m\n", plan.MaxHeight/100)
+	if plan.ConditionalWaits > 0 {
+		fmt.Printf("⚠️ Flight time excludes %d wait_until step(s), which depend on the drone\n", plan.ConditionalWaits)
+	}
+}
+
+// roundZero rounds v to a whole number without a sign on zero
+func roundZero(v float64) float64 {
+	return math.Round(v) + 0
+}
+
+func formatElapsed(d time.Duration) string {
+	return d.Round(100 * time.Millisecond).String()
+}
+
+func missionRunCmd(drone tello.TelloCommander, odometry *navigation.Odometry) *cobra.Command {
+	var photoDir string
+	var useSafety bool
+
+	cmd := &cobra.Command{
+		Use:   "run [mission-file]",
+		Short: "Fly a mission",
+		Long: `Fly a mission, waiting for the drone to finish each step before the next.
+
+While the mission runs, enter p to pause before the next step, r to resume
+and a to abort. Ctrl+C also aborts. An aborted or failed mission lands the
+drone if it is flying.
+
+Moves are checked by a safety manager with the auto-loaded safety
+configuration, including its geofence, unless --safety=false is given.`,
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if drone == nil {
+				return fmt.Errorf("drone is not connected")
+			}
+			m, err := mission.Load(args[0])
+			if err != nil {
+				return err
+			}
+
+			if err := drone.Init(); err != nil {
+				return fmt.Errorf("SDK mode handshake failed: %w", err)
+			}
+
+			var commander mission.Commander = drone
+			if useSafety {
+				manager, stopSafety, err := startSafetyManager(drone, odometry)
+				if err != nil {
+					return fmt.Errorf("failed to start safety manager: %w", err)
+				}
+				defer stopSafety()
+				commander = manager
+			} else {
+				fmt.Println("⚠️ Flying without safety checks")
+			}
+
+			opts := []mission.ExecutorOption{
+				mission.WithCompletionWaiter(odometry),
+				mission.WithEventHandler(printMissionEvent),
+			}
+			if m.HasPhotos() {
+				if err := os.MkdirAll(photoDir, 0o755); err != nil {
+					return fmt.Errorf("failed to create photo directory: %w", err)
+				}
+				if err := drone.StreamOn(); err != nil {
+					return fmt.Errorf("failed to start video stream: %w", err)
+				}
+				fmt.Printf("📷 Saving photos to %s\n", photoDir)
+				opts = append(opts, mission.WithPhotoCapturer(&missionCamera{drone: drone, dir: photoDir}))
+			}
+
+			executor := mission.NewExecutor(commander, m, opts...)
+
+			interrupt := make(chan os.Signal, 1)
+			signal.Notify(interrupt, os.Interrupt, syscall.SIGTERM)
+			defer signal.Stop(interrupt)
+			go func() {
+				if _, ok := <-interrupt; ok {
+					fmt.Println("\nReceived interrupt signal, aborting mission...")
+					executor.Abort()
+				}
+			}()
+			go readMissionControls(executor)
+
+			plan := m.Plan()
+			fmt.Printf("🚀 Flying mission '%s' (%d steps, about %s)\n", m.Name, len(plan.Steps), formatElapsed(plan.Duration))
+			fmt.Println("Enter p to pause, r to resume, a to abort")
+
+			err = executor.Run(context.Background())
+			switch {
+			case errors.Is(err, mission.ErrAborted):
+				fmt.Println("🛑 Mission aborted")
+				return nil
+			case err != nil:
+				return err
+			}
+			fmt.Println("✅ Mission completed")
+			return nil
+		},
+	}
+
+	cmd.Flags().StringVar(&photoDir, "photos", "photos", "Directory photo steps are saved to")
+	cmd.Flags().BoolVar(&useSafety, "safety", true, "Check moves against the safety configuration")
+	return cmd
+}
+
+// missionPhotoTimeout is how long a photo step waits for a keyframe
+const missionPhotoTimeout = 10 * time.Second
+
+// missionCamera takes the photos of photo steps with the drone's camera
+type missionCamera struct {
+	drone tello.TelloCommander
+	dir   string
+}
+
+// CapturePhoto saves the next keyframe as name in the photo directory
+func (c *missionCamera) CapturePhoto(name string) (string, error) {
+	path := filepath.Join(c.dir, name)
+	ctx, cancel := context.WithTimeout(context.Background(), missionPhotoTimeout)
+	defer cancel()
+	if err := c.drone.TakePhoto(ctx, path); err != nil {
+		return "", fmt.Errorf("failed to take photo: %w", err)
+	}
+	return path, nil
+}
+
+// readMissionControls pauses, resumes and aborts the mission from stdin
+func readMissionControls(executor *mission.Executor) {
+	scanner := bufio.NewScanner(os.Stdin)
+	for scanner.Scan() {
+		switch strings.ToLower(strings.TrimSpace(scanner.Text())) {
+		case "p":
+			executor.Pause()
+			fmt.Println("⏸️  Pausing after the current step (r to resume)")
+		case "r":
+			executor.Resume()
+			fmt.Println("▶️  Resuming")
+		case "a":
+			executor.Abort()
+		}
+	}
+}
+
+func printMissionEvent(event mission.Event) {
+	switch {
+	case !event.Done:
+		fmt.Printf("▶ [%s] %s\n", event.Path, event.Step)
+	case event.Err != nil:
+		fmt.Printf("✗ [%s] %s: %v\n", event.Path, event.Step, event.Err)
+	case event.Photo != "":
+		fmt.Printf("✓ [%s] saved %s\n", event.Path, event.Photo)
+	}
+}
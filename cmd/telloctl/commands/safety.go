@@ -5,7 +5,9 @@ import (
 	"os"
 	"text/tabwriter"
 
+	"github.com/conceptcodes/dji-tello-sdk-go/pkg/navigation"
 	"github.com/conceptcodes/dji-tello-sdk-go/pkg/safety"
+	"github.com/conceptcodes/dji-tello-sdk-go/pkg/tello"
 	"github.com/conceptcodes/dji-tello-sdk-go/pkg/utils"
 	"github.com/spf13/cobra"
 )
@@ -131,11 +133,62 @@ var safetyShowCmd = &cobra.Command{
 		fmt.Fprintf(w, "  Min Flip Height:\t%d cm\n", config.Behavioral.MinFlipHeight)
 		fmt.Fprintf(w, "  Max Flight Time:\t%d seconds\n", config.Behavioral.MaxFlightTime)
 		fmt.Fprintf(w, "  Max Command Rate:\t%d cmd/s\n", config.Behavioral.MaxCommandRate)
+		fmt.Fprintln(w, "")
+
+		fmt.Fprintln(w, "GEOFENCE")
+		fmt.Fprintf(w, "  Enabled:\t%t\n", config.Geofence.Enabled)
+		if config.Geofence.Shape == safety.GeofenceShapePolygon {
+			fmt.Fprintf(w, "  Shape:\tpolygon (%d vertices)\n", len(config.Geofence.Polygon))
+		} else {
+			fmt.Fprintf(w, "  Shape:\tcylinder (%d cm radius)\n", config.Geofence.Radius)
+		}
+		fmt.Fprintf(w, "  Keep-Out Zones:\t%d\n", len(config.Geofence.KeepOut))
+		fmt.Fprintf(w, "  Breach Action:\t%s\n", config.Geofence.BreachAction)
 
 		w.Flush()
 	},
 }
 
+// safetyEventListeners are added to every safety manager the commands start
+var safetyEventListeners []func(*safety.SafetyEvent)
+
+// AddSafetyEventListener adds a listener to every safety manager the commands
+// start, e.g. a flight log's RecordSafetyEvent. Call it before running them.
+func AddSafetyEventListener(listener func(*safety.SafetyEvent)) {
+	safetyEventListeners = append(safetyEventListeners, listener)
+}
+
+// startSafetyManager wraps drone in a safety manager with the auto-loaded
+// configuration. It watches the drone's states, so it takes its configured
+// actions, e.g. landing when the battery runs out, and checks the geofence
+// against odometry when that is not nil. The returned function stops it.
+func startSafetyManager(drone tello.TelloCommander, odometry *navigation.Odometry) (safety.Manager, func(), error) {
+	hub := drone.GetTelemetryHub()
+	if hub == nil {
+		return nil, nil, fmt.Errorf("no telemetry to watch")
+	}
+	manager, err := safety.NewManager(drone, nil)
+	if err != nil {
+		return nil, nil, err
+	}
+	if odometry != nil {
+		manager.SetPositionSource(odometry)
+	}
+	for _, listener := range safetyEventListeners {
+		manager.AddEventListener(listener)
+	}
+	sub, err := hub.Subscribe()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	manager.StartTelemetryProcessing(sub.C)
+	return manager, func() {
+		manager.StopTelemetryProcessing()
+		sub.Close()
+	}, nil
+}
+
 func init() {
 	SafetyCmd.AddCommand(safetyListCmd)
 	SafetyCmd.AddCommand(safetyValidateCmd)
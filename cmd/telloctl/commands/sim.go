@@ -0,0 +1,77 @@
+package commands
+
+import (
+	"fmt"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/conceptcodes/dji-tello-sdk-go/pkg/sim"
+	"github.com/spf13/cobra"
+)
+
+func SimCmd() *cobra.Command {
+	cfg := sim.DefaultConfig()
+
+	cmd := &cobra.Command{
+		Use:   "sim",
+		Short: "Run a simulated Tello drone",
+		Long: `Run a UDP simulator that speaks the Tello SDK protocol.
+The simulator answers commands with ok/error/value replies and, once SDK mode
+has been entered with "command", sends state packets at 10 Hz to the
+controller's state port. Position, yaw, battery drain and flight time are modelled.
+
+The command port is bound like the real drone's, so run the simulator on another
+host or network namespace, or bind it to a different port and point telloctl at
+it with TELLO_DRONE_HOST.
+
+Examples:
+  telloctl sim                                   # Listen on 0.0.0.0:8889
+  telloctl sim --addr 127.0.0.1:9889             # Listen on a local test port
+  TELLO_DRONE_HOST=127.0.0.1:9889 telloctl takeoff
+  telloctl sim --speedup 10 --battery 30         # Fast-forward a low battery flight`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			simulator, err := sim.New(cfg)
+			if err != nil {
+				return fmt.Errorf("invalid simulator configuration: %w", err)
+			}
+
+			if err := simulator.Start(); err != nil {
+				return fmt.Errorf("failed to start simulator: %w", err)
+			}
+			defer simulator.Stop()
+
+			cmd.Printf("Tello simulator listening on %s (state to port %d)\n", simulator.Addr(), cfg.StatePort)
+			cmd.Println("Press Ctrl+C to stop.")
+
+			interrupt := make(chan os.Signal, 1)
+			signal.Notify(interrupt, os.Interrupt, syscall.SIGTERM)
+
+			ticker := time.NewTicker(5 * time.Second)
+			defer ticker.Stop()
+
+			for {
+				select {
+				case <-ticker.C:
+					state := simulator.State()
+					x, y, z := simulator.Position()
+					cmd.Printf("pos=(%.0f, %.0f, %.0f) yaw=%d bat=%d%% time=%ds flying=%v\n",
+						x, y, z, state.Yaw, state.Bat, state.Time, simulator.IsFlying())
+				case <-interrupt:
+					cmd.Println("\nReceived interrupt signal, stopping simulator...")
+					return nil
+				}
+			}
+		},
+	}
+
+	cmd.Flags().StringVar(&cfg.Addr, "addr", cfg.Addr, "Address to accept commands on")
+	cmd.Flags().IntVar(&cfg.StatePort, "state-port", cfg.StatePort, "Controller port to send state packets to")
+	cmd.Flags().DurationVar(&cfg.StateInterval, "state-interval", cfg.StateInterval, "Interval between state packets")
+	cmd.Flags().Float64Var(&cfg.Speedup, "speedup", cfg.Speedup, "Simulated seconds per real second")
+	cmd.Flags().IntVar(&cfg.Battery, "battery", cfg.Battery, "Initial battery percentage")
+	cmd.Flags().StringVar(&cfg.SerialNumber, "serial", cfg.SerialNumber, "Serial number reported by sn?")
+
+	return cmd
+}
@@ -6,6 +6,7 @@ import (
 
 	tea "github.com/charmbracelet/bubbletea"
 	"github.com/conceptcodes/dji-tello-sdk-go/pkg/gamepad"
+	"github.com/conceptcodes/dji-tello-sdk-go/pkg/navigation"
 	"github.com/conceptcodes/dji-tello-sdk-go/pkg/tello"
 	"github.com/conceptcodes/dji-tello-sdk-go/pkg/ui"
 	"github.com/conceptcodes/dji-tello-sdk-go/pkg/utils"
@@ -14,8 +15,8 @@ import (
 )
 
 // TuiCmd creates the TUI command
-func TuiCmd(drone tello.TelloCommander) *cobra.Command {
-	var preset string
+func TuiCmd(drone tello.TelloCommander, odometry *navigation.Odometry) *cobra.Command {
+	var preset, input, device, replayPath string
 
 	cmd := &cobra.Command{
 		Use:   "tui",
@@ -25,39 +26,66 @@ func TuiCmd(drone tello.TelloCommander) *cobra.Command {
 Features:
 - Real-time telemetry dashboard
 - Keyboard flight controls (WASD)
-- Gamepad support
+- Gamepad support, or gamepad-style keyboard control with --input keyboard
 - Command REPL
 - Mission logs`,
 		RunE: func(cmd *cobra.Command, args []string) error {
 			// Ensure SDL runs on main thread for gamepad support
 			var runErr error
 			sdl.Main(func() {
-				runErr = runTui(drone, preset)
+				runErr = runTui(drone, odometry, preset, input, device, replayPath)
 			})
 			return runErr
 		},
 	}
 
 	cmd.Flags().StringVarP(&preset, "preset", "p", "default", "Gamepad mapping preset (default, xbox, playstation)")
+	cmd.Flags().StringVarP(&input, "input", "i", "sdl", "Gamepad input backend (sdl, evdev, keyboard, replay)")
+	cmd.Flags().StringVar(&device, "device", "", "Input device for evdev (default: first joystick in /dev/input/by-id)")
+	cmd.Flags().StringVar(&replayPath, "replay", "", "Recording to play back with --input replay")
 
 	return cmd
 }
 
-func runTui(drone tello.TelloCommander, preset string) error {
+func runTui(drone tello.TelloCommander, odometry *navigation.Odometry, preset, input, device, replayPath string) error {
 	// Create TUI model
 	model := ui.NewTuiModel(drone)
+	model.SetOdometry(odometry)
+
+	// Pick the gamepad input; the keyboard is fed by the TUI's key presses
+	var source gamepad.InputSource
+	if input == "keyboard" {
+		keyboard := gamepad.NewKeyboardSource(nil)
+		model.SetKeyInput(keyboard.Key)
+		source = keyboard
+	} else {
+		var err error
+		if source, err = newInputSource(input, device, replayPath); err != nil {
+			if input != "" && input != "sdl" {
+				return err
+			}
+			utils.Logger.Warnf("Gamepad unavailable: %v", err)
+		}
+	}
 
 	// Start Bubble Tea program
 	p := tea.NewProgram(model, tea.WithAltScreen())
 
 	// Initialize gamepad
 	config, err := loadGamepadConfig(preset, preset != "default")
-	if err == nil {
+	if err == nil && source != nil {
+		mapper, stopFeed := newStateAwareMapper(drone, config)
+		defer stopFeed()
+		macros := newMacroRunner(drone, mapper.Model())
+		defer macros.Stop()
+
 		handler, err := gamepad.NewHandler(gamepad.HandlerOptions{
 			Config: config,
+			Mapper: mapper,
+			Source: source,
 			OnCommand: func(command gamepad.Command) {
 				// Handle drone command
-				handleDroneCommand(drone, command)
+				handleDroneCommand(drone, mapper.Model(), macros, command)
 
 				// Send log message to TUI
 				var msg string
@@ -66,6 +94,10 @@ func runTui(drone tello.TelloCommander, preset string) error {
 					if action, ok := command.Data.(gamepad.DroneAction); ok {
 						msg = fmt.Sprintf("Gamepad: %s", action)
 					}
+				case gamepad.CommandFlightMode:
+					msg = fmt.Sprintf("Flight mode: %s", command.Data)
+				case gamepad.CommandMacro:
+					msg = fmt.Sprintf("Gamepad macro: %s", command.Data)
 				case gamepad.CommandRC:
 					// Don't log every RC update, too spammy
 					return
@@ -84,10 +116,18 @@ func runTui(drone tello.TelloCommander, preset string) error {
 			if err := handler.Start(); err == nil {
 				defer handler.Stop()
 
+				if handler.HasFeedback() {
+					if stopFeedback, err := startSafetyFeedback(drone, handler); err != nil {
+						utils.Logger.Warnf("Gamepad will not signal safety events: %v", err)
+					} else {
+						defer stopFeedback()
+					}
+				}
+
 				// Start gamepad polling loop in a goroutine?
 				// No, SDL polling must be on main thread.
 				// But p.Run() blocks.
-				// So we run p.Run() in a goroutine and poll SDL in main thread.
+				// So we run p.Run() in a goroutine and poll the input in main thread.
 
 				done := make(chan struct{})
 				go func() {
@@ -97,16 +137,21 @@ func runTui(drone tello.TelloCommander, preset string) error {
 					close(done)
 				}()
 
-				// Main loop for SDL polling
+				// Main loop for input polling
 				ticker := time.NewTicker(time.Millisecond * 20) // 50Hz
 				defer ticker.Stop()
 
+				ended := false
 				for {
 					select {
 					case <-done:
 						return nil
 					case <-ticker.C:
 						handler.ProcessEvents()
+						if !ended && handler.Ended() {
+							ended = true
+							p.Send(ui.GamepadMsg{Message: "Input ended"})
+						}
 					}
 				}
 			}
@@ -1,7 +1,9 @@
 package commands
 
 import (
+	"context"
 	"fmt"
+	"net"
 	"os"
 	"os/signal"
 	"syscall"
@@ -59,19 +61,24 @@ func StreamCmd(drone tello.TelloCommander) *cobra.Command {
 	var duration int
 	var saveFile string
 	var format string
+	var muxer string
+	var telemetry bool
 
 	cmd := &cobra.Command{
 		Use:   "stream",
 		Short: "Monitor and optionally save video stream from the drone",
 		Long: `Monitor video stream from the drone in real-time.
 This command displays video frame information and can optionally save frames to a file.
+Recordings come with a SubRip (.srt) telemetry track of the same name, holding the
+drone's height, speed, attitude and battery for every frame.
 
 Examples:
   telloctl stream                    # Monitor video stream indefinitely
   telloctl stream -d 30              # Monitor for 30 seconds
   telloctl stream -s video.h264      # Save video stream to H.264 file
   telloctl stream -s video.mp4 -f mp4 # Save video stream to MP4 file
-  telloctl stream -d 60 -s video.mp4 -f mp4 # Monitor for 60 seconds and save to MP4 file`,
+  telloctl stream -d 60 -s video.mp4 -f mp4 # Monitor for 60 seconds and save to MP4 file
+  telloctl stream -s video.mp4 -f mp4 --muxer ffmpeg # Mux the MP4 file with FFmpeg`,
 		RunE: func(cmd *cobra.Command, args []string) error {
 			// Start video stream
 			if err := drone.StreamOn(); err != nil {
@@ -93,14 +100,26 @@ Examples:
 
 			// Setup video recorder if saving
 			var recorder *transport.VideoRecorder
+			var recorderChan chan transport.VideoFrame
 			var err error
 
 			if saveFile != "" {
-				// Use existing frame channel from drone instead of creating duplicate listener
-				recorder, err = transport.NewVideoRecorderFromChannel(frameChan, saveFile)
+				// Frames are passed on from the loop below, so that the recorder
+				// gets all of them rather than competing with it for frames
+				recorderChan = make(chan transport.VideoFrame, 100)
+				defer close(recorderChan)
+				recorder, err = transport.NewVideoRecorderWithFormatAndChannel(recorderChan, saveFile, videoFormat)
 				if err != nil {
 					return fmt.Errorf("failed to create video recorder: %w", err)
 				}
+				if videoFormat == transport.FormatMP4 {
+					if err := recorder.SetMuxer(muxer); err != nil {
+						return err
+					}
+				}
+				if telemetry {
+					recorder.SetTelemetry(drone.GetTelemetryHub())
+				}
 
 				if err := recorder.StartRecording(); err != nil {
 					return fmt.Errorf("failed to start recording: %w", err)
@@ -133,6 +152,13 @@ Examples:
 					}
 
 					frameCount++
+					if recorderChan != nil {
+						select {
+						case recorderChan <- frame:
+						default:
+							fmt.Printf("Recorder is falling behind, dropped frame %d\n", frame.SeqNum)
+						}
+					}
 
 					// Log frame info every 30 frames
 					if frameCount%30 == 0 {
@@ -159,6 +185,170 @@ Examples:
 	cmd.Flags().IntVarP(&duration, "duration", "d", 0, "Duration in seconds to monitor the stream (0 = indefinite)")
 	cmd.Flags().StringVarP(&saveFile, "save", "s", "", "Save video stream to file")
 	cmd.Flags().StringVarP(&format, "format", "f", "h264", "Video format (h264 or mp4)")
+	cmd.Flags().StringVar(&muxer, "muxer", transport.MP4MuxerGo, "MP4 muxer (go or ffmpeg)")
+	cmd.Flags().BoolVar(&telemetry, "telemetry", true, "Save a .srt telemetry track next to the video")
+
+	return cmd
+}
+
+func PhotoCmd(drone tello.TelloCommander) *cobra.Command {
+	var output string
+	var timeout int
+
+	cmd := &cobra.Command{
+		Use:   "photo",
+		Short: "Take a photo from the drone's video stream",
+		Long: `Take a photo from the drone's video stream.
+This command starts the video stream, waits for the next keyframe and saves it
+as a JPEG or PNG, chosen by the file extension, tagged with the drone's height,
+yaw and battery.
+
+Examples:
+  telloctl photo                     # Save to photo-<date>-<time>.jpg
+  telloctl photo -o tower.png        # Save as PNG
+  telloctl photo -t 20               # Wait up to 20 seconds for a keyframe`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if output == "" {
+				output = fmt.Sprintf("photo-%s.jpg", time.Now().Format("20060102-150405"))
+			}
+			if _, err := transport.PhotoFormatFromPath(output); err != nil {
+				return err
+			}
+
+			if err := drone.StreamOn(); err != nil {
+				return fmt.Errorf("failed to start video stream: %w", err)
+			}
+			defer drone.StreamOff()
+
+			fmt.Println("Waiting for a keyframe...")
+			ctx, cancel := context.WithTimeout(context.Background(), time.Duration(timeout)*time.Second)
+			defer cancel()
+			if err := drone.TakePhoto(ctx, output); err != nil {
+				return fmt.Errorf("failed to take photo: %w", err)
+			}
+
+			fmt.Printf("Photo saved to: %s\n", output)
+			return nil
+		},
+	}
+
+	cmd.Flags().StringVarP(&output, "output", "o", "", "Photo file, .jpg, .jpeg or .png (default photo-<date>-<time>.jpg)")
+	cmd.Flags().IntVarP(&timeout, "timeout", "t", 10, "Seconds to wait for a keyframe")
+
+	return cmd
+}
+
+func ServeRTSPCmd(drone tello.TelloCommander) *cobra.Command {
+	var addr string
+
+	cmd := &cobra.Command{
+		Use:   "serve-rtsp",
+		Short: "Re-stream the drone's video over RTSP",
+		Long: `Re-stream the drone's video over RTSP, for players and libraries such as VLC,
+GStreamer, FFmpeg or OpenCV on the local network. The H.264 stream is passed on
+as is, so it costs little CPU, and any number of viewers can watch at once.
+
+Examples:
+  telloctl video serve-rtsp                  # Serve on rtsp://<this machine>:8554/tello
+  telloctl video serve-rtsp --addr :9554     # Serve on another port
+  vlc rtsp://192.168.10.2:8554/tello         # Watch from another machine
+  ffplay -rtsp_transport tcp rtsp://192.168.10.2:8554/tello`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if err := drone.StreamOn(); err != nil {
+				return fmt.Errorf("failed to start video stream: %w", err)
+			}
+			defer drone.StreamOff()
+
+			frameChan := drone.GetVideoFrameChannel()
+			if frameChan == nil {
+				return fmt.Errorf("failed to get video frame channel")
+			}
+
+			server := transport.NewRTSPServer(addr, frameChan)
+			if err := server.Start(); err != nil {
+				return fmt.Errorf("failed to start RTSP server: %w", err)
+			}
+			defer server.Stop()
+
+			_, port, _ := net.SplitHostPort(server.Addr().String())
+			for _, host := range lanAddresses() {
+				fmt.Printf("📡 Serving video on rtsp://%s%s\n", net.JoinHostPort(host, port), transport.RTSPStreamPath)
+			}
+			fmt.Println("Press Ctrl+C to stop")
+
+			interrupt := make(chan os.Signal, 1)
+			signal.Notify(interrupt, os.Interrupt, syscall.SIGTERM)
+			ticker := time.NewTicker(10 * time.Second)
+			defer ticker.Stop()
+
+			viewers := 0
+			for {
+				select {
+				case <-interrupt:
+					fmt.Println("\nReceived interrupt signal, stopping RTSP server...")
+					return nil
+				case <-ticker.C:
+					if current := server.Viewers(); current != viewers {
+						viewers = current
+						fmt.Printf("Viewers: %d\n", viewers)
+					}
+				}
+			}
+		},
+	}
+
+	cmd.Flags().StringVar(&addr, "addr", transport.DefaultRTSPAddr, "Address to listen on for RTSP clients")
 
 	return cmd
 }
+
+func RepairVideoCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:   "repair [file]",
+		Short: "Trim the unfinished fragment from an MP4 recording",
+		Long: `Trim the unfinished fragment from an MP4 recording that was cut short, e.g.
+by a crash or power loss. Recordings are written in fragments of at most a
+second, so the footage up to the last complete fragment is kept. Players that
+stop at the broken fragment play the whole repaired file.
+
+Examples:
+  telloctl video repair flight.mp4`,
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			path := args[0]
+			before, err := os.Stat(path)
+			if err != nil {
+				return err
+			}
+			if err := transport.RepairFMP4(path); err != nil {
+				return err
+			}
+			after, err := os.Stat(path)
+			if err != nil {
+				return err
+			}
+			if after.Size() == before.Size() {
+				fmt.Printf("✅ %s has no unfinished fragment\n", path)
+				return nil
+			}
+			fmt.Printf("✅ Trimmed %d bytes from %s\n", before.Size()-after.Size(), path)
+			return nil
+		},
+	}
+}
+
+// lanAddresses returns the IPv4 addresses other machines can reach this one
+// on, or "localhost" if there are none
+func lanAddresses() []string {
+	var hosts []string
+	addrs, _ := net.InterfaceAddrs()
+	for _, addr := range addrs {
+		if ipNet, ok := addr.(*net.IPNet); ok && !ipNet.IP.IsLoopback() && ipNet.IP.To4() != nil {
+			hosts = append(hosts, ipNet.IP.String())
+		}
+	}
+	if len(hosts) == 0 {
+		hosts = append(hosts, "localhost")
+	}
+	return hosts
+}
@@ -12,6 +12,7 @@ import (
 	"github.com/conceptcodes/dji-tello-sdk-go/pkg/ml/config"
 	"github.com/conceptcodes/dji-tello-sdk-go/pkg/ml/models"
 	"github.com/conceptcodes/dji-tello-sdk-go/pkg/ml/pipeline"
+	"github.com/conceptcodes/dji-tello-sdk-go/pkg/navigation"
 	"github.com/conceptcodes/dji-tello-sdk-go/pkg/tello"
 	"github.com/conceptcodes/dji-tello-sdk-go/pkg/transport"
 	"github.com/conceptcodes/dji-tello-sdk-go/pkg/web"
@@ -19,10 +20,11 @@ import (
 )
 
 // WebCmd creates the web interface command
-func WebCmd(drone tello.TelloCommander) *cobra.Command {
+func WebCmd(drone tello.TelloCommander, odometry *navigation.Odometry) *cobra.Command {
 	var webPort int
 	var enableML bool
 	var configDir string
+	var photoDir string
 
 	cmd := &cobra.Command{
 		Use:   "web",
@@ -124,9 +126,25 @@ Examples:
 			recorder, err := transport.NewVideoRecorderWithFormatAndChannel(recorderChan, recordPath, transport.FormatMP4)
 			if err != nil {
 				fmt.Printf("⚠️ Failed to initialize video recorder: %v\n", err)
+			} else {
+				recorder.SetTelemetry(drone.GetTelemetryHub())
 			}
 
 			webServer := web.NewWebServer(drone, recorder, mlPipeline, mlResultChan)
+			webServer.SetOdometry(odometry)
+			webServer.SetPhotoDir(photoDir)
+			if drone != nil {
+				// Waypoints are flown through the safety manager, whose events
+				// show in the event log
+				manager, stopSafety, err := startSafetyManager(drone, odometry)
+				if err != nil {
+					fmt.Printf("⚠️ Click-to-fly and safety events disabled without a safety manager: %v\n", err)
+				} else {
+					defer stopSafety()
+					webServer.SetSafetyManager(manager)
+					manager.AddEventListener(webServer.PublishSafetyEvent)
+				}
+			}
 
 			// Create web video display with enhanced features
 			display := transport.NewVideoDisplay(transport.DisplayTypeWeb)
@@ -243,6 +261,7 @@ Examples:
 	cmd.Flags().IntVarP(&webPort, "port", "p", 8080, "Web server port")
 	cmd.Flags().BoolVar(&enableML, "ml", false, "Enable Machine Learning pipeline")
 	cmd.Flags().StringVar(&configDir, "config-dir", "configs", "Configuration directory")
+	cmd.Flags().StringVar(&photoDir, "photo-dir", web.DefaultPhotoDir, "Directory the shutter button saves photos to")
 
 	return cmd
 }
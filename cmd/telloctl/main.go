@@ -7,6 +7,9 @@ import (
 	"github.com/spf13/cobra"
 
 	"github.com/conceptcodes/dji-tello-sdk-go/cmd/telloctl/commands"
+	"github.com/conceptcodes/dji-tello-sdk-go/pkg/config"
+	"github.com/conceptcodes/dji-tello-sdk-go/pkg/flightlog"
+	"github.com/conceptcodes/dji-tello-sdk-go/pkg/navigation"
 	"github.com/conceptcodes/dji-tello-sdk-go/pkg/tello"
 	"github.com/conceptcodes/dji-tello-sdk-go/pkg/utils"
 )
@@ -46,14 +49,90 @@ func newSetCmd(drone tello.TelloCommander) *cobra.Command {
 	return setCmd
 }
 
+func newVideoCmd(drone tello.TelloCommander) *cobra.Command {
+	videoCmd := &cobra.Command{
+		Use:   "video",
+		Short: "Serve the drone's video stream to other applications and repair recordings",
+	}
+
+	videoCmd.AddCommand(
+		commands.ServeRTSPCmd(drone),
+		commands.RepairVideoCmd(),
+	)
+
+	return videoCmd
+}
+
 func isWebCommand(args []string) bool {
+	return isCommand(args, "web")
+}
+
+func isSimCommand(args []string) bool {
+	return isCommand(args, "sim")
+}
+
+// isOfflineCommand reports whether args run a subcommand that only reads
+// files: checking a mission or repairing a recording
+func isOfflineCommand(args []string) bool {
+	positional := positionalArgs(args)
+	if len(positional) < 2 {
+		return false
+	}
+	switch positional[0] {
+	case "mission":
+		return positional[1] == "validate" || positional[1] == "dry-run"
+	case "video":
+		return positional[1] == "repair"
+	}
+	return false
+}
+
+func isCommand(args []string, name string) bool {
+	positional := positionalArgs(args)
+	return len(positional) > 0 && positional[0] == name
+}
+
+func positionalArgs(args []string) []string {
+	var positional []string
 	for _, arg := range args {
-		if strings.HasPrefix(arg, "-") {
-			continue
+		if !strings.HasPrefix(arg, "-") {
+			positional = append(positional, arg)
 		}
-		return arg == "web"
 	}
-	return false
+	return positional
+}
+
+// transportConfig returns the default transport configuration with the drone
+// address overridden by TELLO_DRONE_HOST, e.g. to point at a simulator.
+func transportConfig() config.TransportConfig {
+	cfg := config.DefaultTransportConfig()
+	if host := os.Getenv("TELLO_DRONE_HOST"); host != "" {
+		cfg = cfg.WithDroneHost(host)
+	}
+	return cfg
+}
+
+// initOptions returns the commander options for the current environment.
+// The odometry is always fed with the drone's states and commands.
+// TELLO_FLIGHTLOG names a flight log file that states, commands and the
+// events of the commands' safety managers are appended to; the returned
+// recorder is nil when it is unset.
+func initOptions(odometry *navigation.Odometry) ([]func(*tello.InitializeOptions), *flightlog.Recorder, error) {
+	opts := []func(*tello.InitializeOptions){
+		tello.WithTransportConfig(transportConfig()),
+		tello.WithFlightRecorder(odometry),
+	}
+	path := os.Getenv("TELLO_FLIGHTLOG")
+	if path == "" {
+		return opts, nil, nil
+	}
+	recorder, err := flightlog.Create(path)
+	if err != nil {
+		return nil, nil, err
+	}
+	utils.Logger.Infof("Recording flight log to %s", path)
+	commands.AddSafetyEventListener(recorder.RecordSafetyEvent)
+	return append(opts, tello.WithFlightRecorder(recorder)), recorder, nil
 }
 
 func main() {
@@ -62,8 +141,20 @@ func main() {
 		Short: "CLI for controlling the DJI Tello drone",
 	}
 
-	// Create drone commander with default configuration
-	drone, err := tello.InitializeWithOptions()
+	// Create drone commander with default configuration. The simulator plays
+	// the drone itself, so it must not claim the controller's ports, and
+	// checking a mission file or repairing a recording needs no drone.
+	var drone tello.TelloCommander
+	var err error
+	var recorder *flightlog.Recorder
+	odometry := navigation.NewOdometry(navigation.DefaultOdometryConfig())
+	if !isSimCommand(os.Args[1:]) && !isOfflineCommand(os.Args[1:]) {
+		var opts []func(*tello.InitializeOptions)
+		opts, recorder, err = initOptions(odometry)
+		if err == nil {
+			drone, err = tello.InitializeWithOptions(opts...)
+		}
+	}
 	if err != nil {
 		if isWebCommand(os.Args[1:]) {
 			utils.Logger.Warnf("Web interface starting without drone connection: %v", err)
@@ -74,7 +165,15 @@ func main() {
 		}
 	}
 
-	// Add shutdown handling
+	// Add shutdown handling. Deferred calls run in reverse order, so the
+	// flight log is closed after the commander has stopped recording.
+	if recorder != nil {
+		defer func() {
+			if err := recorder.Close(); err != nil {
+				utils.Logger.Errorf("Error closing flight log: %v", err)
+			}
+		}()
+	}
 	if drone != nil {
 		defer func() {
 			if err := drone.Shutdown(); err != nil {
@@ -102,12 +201,16 @@ func main() {
 		commands.StreamOnCmd(drone),
 		commands.StreamOffCmd(drone),
 		commands.StreamCmd(drone),
+		commands.PhotoCmd(drone),
+		newVideoCmd(drone),
 		commands.VideoGUICmd(drone),
-		commands.WebCmd(drone),
+		commands.WebCmd(drone, odometry),
 		commands.GamepadCmd(drone),
 		commands.MLCmd(),
 		commands.SafetyCmd,
-		commands.TuiCmd(drone),
+		commands.TuiCmd(drone, odometry),
+		commands.MissionCmd(drone, odometry),
+		commands.SimCmd(),
 	)
 
 	if err := rootCmd.Execute(); err != nil {
@@ -14,4 +14,8 @@ var (
 	// SafetySchema contains the validation schema for safety configs.
 	//go:embed schemas/safety-schema.json
 	SafetySchema []byte
+
+	// MissionSchema contains the validation schema for mission scripts.
+	//go:embed schemas/mission-schema.json
+	MissionSchema []byte
 )
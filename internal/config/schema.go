@@ -16,8 +16,11 @@ func CompileSchema(embedded []byte, fallbackPaths []string, schemaID string) (*j
 	var source string
 
 	if len(embedded) > 0 {
-		if err := compiler.AddResource(schemaID, bytes.NewReader(embedded)); err == nil {
-			source = schemaID
+		// AddResource takes a decoded document, not raw JSON
+		if doc, err := jsonschema.UnmarshalJSON(bytes.NewReader(embedded)); err == nil {
+			if err := compiler.AddResource(schemaID, doc); err == nil {
+				source = schemaID
+			}
 		}
 	}
 
@@ -0,0 +1,244 @@
+// Package flightlog records a flight into a black-box log and replays it.
+//
+// A log is an append-only JSON Lines file. Each line is one timestamped Entry
+// holding a telemetry state, a command with its response and latency, or a
+// safety event. Lines are written whole by a background goroutine, so
+// recording never holds up the drone's state listener, and a crash or power
+// loss costs at most the entries still queued. Close flushes the queue.
+//
+// Recording is wired in with tello.WithFlightRecorder and, when a safety
+// manager is used, SafetyManager.AddEventListener:
+//
+//	rec, err := flightlog.Create("flight.jsonl")
+//	drone, err := tello.InitializeWithOptions(tello.WithFlightRecorder(rec))
+//	manager.AddEventListener(rec.RecordSafetyEvent)
+//	defer rec.Close()
+//
+// A Replayer feeds the recorded states back into a channel, so anything that
+// consumes a state channel, such as SafetyManager.StartTelemetryProcessing,
+// can be run offline against a real flight:
+//
+//	f, err := os.Open("flight.jsonl")
+//	replayer := flightlog.NewReplayer(f, flightlog.WithSpeed(1))
+//	manager.StartTelemetryProcessing(replayer.States(ctx))
+package flightlog
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/conceptcodes/dji-tello-sdk-go/pkg/safety"
+	"github.com/conceptcodes/dji-tello-sdk-go/pkg/tello"
+	"github.com/conceptcodes/dji-tello-sdk-go/pkg/types"
+	"github.com/conceptcodes/dji-tello-sdk-go/pkg/utils"
+)
+
+// EntryType identifies what an Entry holds
+type EntryType string
+
+const (
+	EntryState   EntryType = "state"   // A telemetry state from the state listener
+	EntryCommand EntryType = "command" // A command sent to the drone
+	EntrySafety  EntryType = "safety"  // A safety event raised by the safety manager
+)
+
+// Entry is one line of a flight log. Exactly one of State, Command and
+// Event is set, according to Type.
+type Entry struct {
+	Time    time.Time           `json:"time"`
+	Type    EntryType           `json:"type"`
+	State   *types.State        `json:"state,omitempty"`
+	Command *CommandEntry       `json:"command,omitempty"`
+	Event   *safety.SafetyEvent `json:"event,omitempty"`
+}
+
+// CommandEntry records a command and how the drone answered it
+type CommandEntry struct {
+	Command  string        `json:"command"`
+	Response string        `json:"response,omitempty"`
+	Error    string        `json:"error,omitempty"` // Transport error, e.g. a timeout
+	Latency  time.Duration `json:"latency"`         // Round trip in nanoseconds
+}
+
+// ErrClosed is returned by Recorder.Write after the recorder has been closed
+var ErrClosed = errors.New("flight log is closed")
+
+// recordQueueSize is how many entries wait for the writer before new ones
+// are dropped: half a minute of states at the drone's 10Hz
+const recordQueueSize = 300
+
+// Recorder appends entries to a flight log. It is safe for concurrent use
+// and implements tello.FlightRecorder. The Record methods queue their entry
+// and return at once; a writer goroutine appends the queue to the log.
+type Recorder struct {
+	mu      sync.Mutex
+	w       io.Writer
+	closer  io.Closer
+	err     error // First write error
+	dropped int   // Entries dropped because the queue was full
+	now     func() time.Time
+
+	queueMu sync.RWMutex // Guards sending on queue against Close
+	queue   chan *Entry  // nil once closed
+	done    chan struct{}
+}
+
+var _ tello.FlightRecorder = (*Recorder)(nil)
+
+// Create opens the log file at path for appending, creating it if needed
+func Create(path string) (*Recorder, error) {
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0o644)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open flight log %s: %w", path, err)
+	}
+	rec := NewRecorder(f)
+	rec.closer = f
+	return rec, nil
+}
+
+// NewRecorder creates a recorder that writes entries to w
+func NewRecorder(w io.Writer) *Recorder {
+	r := &Recorder{
+		w:     w,
+		now:   time.Now,
+		queue: make(chan *Entry, recordQueueSize),
+		done:  make(chan struct{}),
+	}
+	go r.writeLoop(r.queue)
+	return r
+}
+
+// writeLoop writes the queued entries until the queue is closed
+func (r *Recorder) writeLoop(queue <-chan *Entry) {
+	defer close(r.done)
+	for entry := range queue {
+		r.write(entry)
+	}
+}
+
+// Write appends an entry to the log at once, ahead of any queued entries.
+// A zero Time is set to the current time.
+func (r *Recorder) Write(entry *Entry) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if entry.Time.IsZero() {
+		entry.Time = r.now()
+	}
+	line, err := json.Marshal(entry)
+	if err != nil {
+		return fmt.Errorf("failed to encode %s entry: %w", entry.Type, err)
+	}
+	if r.w == nil {
+		return ErrClosed
+	}
+	if _, err := r.w.Write(append(line, '\n')); err != nil {
+		return fmt.Errorf("failed to write %s entry: %w", entry.Type, err)
+	}
+	return nil
+}
+
+// RecordState appends a telemetry state
+func (r *Recorder) RecordState(state *types.State) {
+	r.record(&Entry{Type: EntryState, State: state})
+}
+
+// RecordCommand appends a command with its response, error and latency
+func (r *Recorder) RecordCommand(command, response string, err error, latency time.Duration) {
+	entry := &CommandEntry{Command: command, Response: response, Latency: latency}
+	if err != nil {
+		entry.Error = err.Error()
+	}
+	r.record(&Entry{Type: EntryCommand, Command: entry})
+}
+
+// RecordSafetyEvent appends a safety event. It can be passed directly to
+// SafetyManager.AddEventListener.
+func (r *Recorder) RecordSafetyEvent(event *safety.SafetyEvent) {
+	entry := &Entry{Type: EntrySafety, Event: event}
+	if event != nil {
+		entry.Time = event.Timestamp
+	}
+	r.record(entry)
+}
+
+// record queues an entry for the writer, dropping it when the queue is full
+// so a slow disk cannot stall the caller. The time is taken now rather than
+// when the entry is written.
+func (r *Recorder) record(entry *Entry) {
+	r.queueMu.RLock()
+	defer r.queueMu.RUnlock()
+	if r.queue == nil {
+		return
+	}
+	if entry.Time.IsZero() {
+		entry.Time = r.now()
+	}
+	select {
+	case r.queue <- entry:
+	default:
+		r.mu.Lock()
+		r.dropped++
+		if r.dropped == 1 {
+			utils.Logger.Warnf("Flight log is falling behind, dropping %s entries", entry.Type)
+		}
+		r.mu.Unlock()
+	}
+}
+
+// write writes an entry for callers that cannot return an error. Only the
+// first failure is logged so a full disk does not flood the log.
+func (r *Recorder) write(entry *Entry) {
+	err := r.Write(entry)
+	if err == nil || errors.Is(err, ErrClosed) {
+		return
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.err == nil {
+		r.err = err
+		utils.Logger.Errorf("Flight log recording failed: %v", err)
+	}
+}
+
+// Err returns the first error that occurred while recording, if any
+func (r *Recorder) Err() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.err
+}
+
+// Dropped returns how many entries were dropped because the writer fell
+// behind
+func (r *Recorder) Dropped() int {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.dropped
+}
+
+// Close writes the queued entries, then closes the underlying file when the
+// recorder was created with Create. Entries recorded after Close are dropped.
+func (r *Recorder) Close() error {
+	r.queueMu.Lock()
+	if r.queue != nil {
+		close(r.queue)
+		r.queue = nil
+	}
+	r.queueMu.Unlock()
+	<-r.done
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.w = nil
+	if r.closer == nil {
+		return nil
+	}
+	closer := r.closer
+	r.closer = nil
+	return closer.Close()
+}
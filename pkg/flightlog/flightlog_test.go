@@ -0,0 +1,269 @@
+package flightlog
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/conceptcodes/dji-tello-sdk-go/pkg/safety"
+	"github.com/conceptcodes/dji-tello-sdk-go/pkg/types"
+)
+
+// testLog records a short flight with entries 100ms apart
+func testLog(t *testing.T) *bytes.Buffer {
+	t.Helper()
+	var buf bytes.Buffer
+	rec := NewRecorder(&buf)
+	base := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	tick := 0
+	rec.now = func() time.Time {
+		tick++
+		return base.Add(time.Duration(tick-1) * 100 * time.Millisecond)
+	}
+
+	rec.RecordCommand("takeoff", "ok", nil, 1500*time.Millisecond)
+	rec.RecordState(&types.State{H: 50, Bat: 90})
+	rec.RecordState(&types.State{H: 80, Bat: 89, Received: base.Add(150 * time.Millisecond)})
+	rec.RecordSafetyEvent(&safety.SafetyEvent{
+		Timestamp: base.Add(300 * time.Millisecond),
+		Level:     "warning",
+		Type:      "battery",
+		Message:   "battery low",
+	})
+	rec.RecordCommand("battery?", "", errors.New("timeout"), 10*time.Second)
+	rec.RecordState(&types.State{H: 100, Bat: 88})
+
+	if err := rec.Close(); err != nil {
+		t.Fatalf("Expected no error on close, got %v", err)
+	}
+	if err := rec.Err(); err != nil {
+		t.Fatalf("Expected no recording error, got %v", err)
+	}
+	return &buf
+}
+
+func TestRecorderRoundTrip(t *testing.T) {
+	reader := NewReader(testLog(t))
+
+	var entries []*Entry
+	for {
+		entry, err := reader.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+		entries = append(entries, entry)
+	}
+
+	wantTypes := []EntryType{EntryCommand, EntryState, EntryState, EntrySafety, EntryCommand, EntryState}
+	if len(entries) != len(wantTypes) {
+		t.Fatalf("Expected %d entries, got %d", len(wantTypes), len(entries))
+	}
+	for i, want := range wantTypes {
+		if entries[i].Type != want {
+			t.Errorf("Entry %d: expected type %s, got %s", i, want, entries[i].Type)
+		}
+	}
+
+	if cmd := entries[0].Command; cmd.Command != "takeoff" || cmd.Response != "ok" || cmd.Latency != 1500*time.Millisecond {
+		t.Errorf("Expected takeoff command entry, got %+v", cmd)
+	}
+	if cmd := entries[4].Command; cmd.Error != "timeout" {
+		t.Errorf("Expected command error to be recorded, got %+v", cmd)
+	}
+	if entries[2].State.H != 80 || entries[2].State.Bat != 89 {
+		t.Errorf("Expected state to round trip, got %+v", entries[2].State)
+	}
+	if received := entries[2].State.Received; !received.Equal(entries[0].Time.Add(150 * time.Millisecond)) {
+		t.Errorf("Expected the state's receive time to round trip, got %v", received)
+	}
+	if entries[3].Event.Message != "battery low" || !entries[3].Time.Equal(entries[3].Event.Timestamp) {
+		t.Errorf("Expected safety event with its own timestamp, got %+v at %v", entries[3].Event, entries[3].Time)
+	}
+	if !entries[1].Time.After(entries[0].Time) {
+		t.Errorf("Expected increasing timestamps, got %v then %v", entries[0].Time, entries[1].Time)
+	}
+}
+
+func TestReaderTruncatedLastLine(t *testing.T) {
+	buf := testLog(t)
+	data := buf.String()
+	truncated := data + `{"time":"2024-05-01T12:00:01Z","type":"sta`
+
+	reader := NewReader(strings.NewReader(truncated))
+	count := 0
+	for {
+		_, err := reader.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatalf("Expected truncated line to end the log, got %v", err)
+		}
+		count++
+	}
+	if count != 6 {
+		t.Errorf("Expected 6 complete entries, got %d", count)
+	}
+}
+
+func TestReaderInvalidLine(t *testing.T) {
+	reader := NewReader(strings.NewReader("not json\n"))
+	if _, err := reader.Next(); err == nil || err == io.EOF {
+		t.Errorf("Expected an error for an invalid line, got %v", err)
+	}
+}
+
+func TestCreateAppends(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "flight.jsonl")
+
+	for i := 0; i < 2; i++ {
+		rec, err := Create(path)
+		if err != nil {
+			t.Fatalf("Failed to create recorder: %v", err)
+		}
+		rec.RecordState(&types.State{Bat: 100 - i})
+		if err := rec.Close(); err != nil {
+			t.Errorf("Expected no error on close, got %v", err)
+		}
+		// Entries after Close are dropped without an error
+		rec.RecordState(&types.State{})
+		if err := rec.Err(); err != nil {
+			t.Errorf("Expected no error after close, got %v", err)
+		}
+	}
+
+	entries, err := ReadFile(path)
+	if err != nil {
+		t.Fatalf("Failed to read log: %v", err)
+	}
+	if len(entries) != 2 || entries[0].State.Bat != 100 || entries[1].State.Bat != 99 {
+		t.Errorf("Expected both sessions to be appended, got %d entries", len(entries))
+	}
+}
+
+// blockingWriter holds every write until release is closed
+type blockingWriter struct {
+	release chan struct{}
+	buf     bytes.Buffer
+}
+
+func (w *blockingWriter) Write(p []byte) (int, error) {
+	<-w.release
+	return w.buf.Write(p)
+}
+
+func TestRecorderDoesNotBlock(t *testing.T) {
+	w := &blockingWriter{release: make(chan struct{})}
+	rec := NewRecorder(w)
+
+	const states = recordQueueSize * 2
+	recorded := make(chan struct{})
+	go func() {
+		for i := 0; i < states; i++ {
+			rec.RecordState(&types.State{Bat: i % 100})
+		}
+		close(recorded)
+	}()
+	select {
+	case <-recorded:
+	case <-time.After(2 * time.Second):
+		t.Fatal("Expected RecordState to return while the writer is stuck")
+	}
+
+	close(w.release)
+	if err := rec.Close(); err != nil {
+		t.Fatalf("Expected no error on close, got %v", err)
+	}
+	count := 0
+	reader := NewReader(&w.buf)
+	for {
+		if _, err := reader.Next(); err == io.EOF {
+			break
+		} else if err != nil {
+			t.Fatalf("Failed to read log: %v", err)
+		}
+		count++
+	}
+	if count < recordQueueSize || count+rec.Dropped() != states {
+		t.Errorf("Expected the queue to be flushed and the rest dropped, got %d written and %d dropped", count, rec.Dropped())
+	}
+}
+
+func TestReplayerStates(t *testing.T) {
+	var commands []string
+	var events []string
+	replayer := NewReplayer(testLog(t),
+		WithSpeed(0),
+		WithCommandHandler(func(at time.Time, command *CommandEntry) {
+			commands = append(commands, command.Command)
+		}),
+		WithSafetyEventHandler(func(event *safety.SafetyEvent) {
+			events = append(events, event.Message)
+		}),
+	)
+
+	var heights []int
+	for state := range replayer.States(context.Background()) {
+		heights = append(heights, state.H)
+	}
+
+	if err := replayer.Err(); err != nil {
+		t.Errorf("Expected no replay error, got %v", err)
+	}
+	if len(heights) != 3 || heights[0] != 50 || heights[2] != 100 {
+		t.Errorf("Expected heights [50 80 100], got %v", heights)
+	}
+	if len(commands) != 2 || commands[0] != "takeoff" {
+		t.Errorf("Expected both commands to be replayed, got %v", commands)
+	}
+	if len(events) != 1 {
+		t.Errorf("Expected one safety event, got %v", events)
+	}
+}
+
+func TestReplayerTiming(t *testing.T) {
+	// The log spans 400ms; at 5x it should take about 80ms
+	replayer := NewReplayer(testLog(t), WithSpeed(5))
+
+	start := time.Now()
+	count := 0
+	for range replayer.States(context.Background()) {
+		count++
+	}
+	elapsed := time.Since(start)
+
+	if count != 3 {
+		t.Errorf("Expected 3 states, got %d", count)
+	}
+	if elapsed < 70*time.Millisecond || elapsed > time.Second {
+		t.Errorf("Expected replay to take about 80ms, took %v", elapsed)
+	}
+}
+
+func TestReplayerCancel(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	// At 1% speed the first state is due after 10s
+	replayer := NewReplayer(testLog(t), WithSpeed(0.01))
+	states := replayer.States(ctx)
+	cancel()
+
+	select {
+	case _, ok := <-states:
+		if ok {
+			t.Error("Expected no states after cancellation")
+		}
+	case <-time.After(time.Second):
+		t.Fatal("Expected the state channel to close after cancellation")
+	}
+	if !errors.Is(replayer.Err(), context.Canceled) {
+		t.Errorf("Expected context.Canceled, got %v", replayer.Err())
+	}
+}
@@ -0,0 +1,203 @@
+package flightlog
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/conceptcodes/dji-tello-sdk-go/pkg/safety"
+	"github.com/conceptcodes/dji-tello-sdk-go/pkg/types"
+	"github.com/conceptcodes/dji-tello-sdk-go/pkg/utils"
+)
+
+// Reader reads entries from a flight log
+type Reader struct {
+	r    *bufio.Reader
+	line int
+}
+
+// NewReader creates a reader for the log in r
+func NewReader(r io.Reader) *Reader {
+	return &Reader{r: bufio.NewReader(r)}
+}
+
+// Next returns the next entry, or io.EOF at the end of the log. A truncated
+// last line, as left behind by a crash mid-write, is treated as the end of
+// the log rather than an error.
+func (lr *Reader) Next() (*Entry, error) {
+	for {
+		line, err := lr.r.ReadBytes('\n')
+		if err != nil && err != io.EOF {
+			return nil, fmt.Errorf("failed to read flight log: %w", err)
+		}
+		if len(line) == 0 {
+			return nil, io.EOF
+		}
+		lr.line++
+		truncated := err == io.EOF
+
+		if len(line) == 1 && line[0] == '\n' {
+			continue
+		}
+		var entry Entry
+		if jsonErr := json.Unmarshal(line, &entry); jsonErr != nil {
+			if truncated {
+				utils.Logger.Warnf("Ignoring truncated entry at line %d of flight log", lr.line)
+				return nil, io.EOF
+			}
+			return nil, fmt.Errorf("invalid flight log entry at line %d: %w", lr.line, jsonErr)
+		}
+		return &entry, nil
+	}
+}
+
+// ReadFile reads all entries of the log file at path
+func ReadFile(path string) ([]*Entry, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open flight log %s: %w", path, err)
+	}
+	defer f.Close()
+
+	var entries []*Entry
+	reader := NewReader(f)
+	for {
+		entry, err := reader.Next()
+		if err == io.EOF {
+			return entries, nil
+		}
+		if err != nil {
+			return entries, err
+		}
+		entries = append(entries, entry)
+	}
+}
+
+// ReplayerOption configures a Replayer
+type ReplayerOption func(*Replayer)
+
+// WithSpeed sets the playback speed relative to the recording, e.g. 2 plays
+// twice as fast. A speed of 0 or less replays without pauses (default: 1).
+func WithSpeed(speed float64) ReplayerOption {
+	return func(rp *Replayer) {
+		rp.speed = speed
+	}
+}
+
+// WithCommandHandler calls handler for every recorded command in sequence
+// with the states
+func WithCommandHandler(handler func(at time.Time, command *CommandEntry)) ReplayerOption {
+	return func(rp *Replayer) {
+		rp.onCommand = handler
+	}
+}
+
+// WithSafetyEventHandler calls handler for every recorded safety event in
+// sequence with the states
+func WithSafetyEventHandler(handler func(event *safety.SafetyEvent)) ReplayerOption {
+	return func(rp *Replayer) {
+		rp.onSafetyEvent = handler
+	}
+}
+
+// Replayer plays a flight log back with its original timing
+type Replayer struct {
+	reader        *Reader
+	speed         float64
+	onCommand     func(time.Time, *CommandEntry)
+	onSafetyEvent func(*safety.SafetyEvent)
+
+	mu  sync.Mutex
+	err error
+}
+
+// NewReplayer creates a replayer for the log in r
+func NewReplayer(r io.Reader, opts ...ReplayerOption) *Replayer {
+	rp := &Replayer{
+		reader: NewReader(r),
+		speed:  1,
+	}
+	for _, opt := range opts {
+		opt(rp)
+	}
+	return rp
+}
+
+// States starts the replay and returns the channel the recorded states are
+// sent on. The channel is closed when the log ends or ctx is cancelled;
+// Err reports whether the log could be read completely. States must only be
+// called once.
+func (rp *Replayer) States(ctx context.Context) <-chan *types.State {
+	stateChan := make(chan *types.State, 100)
+	go func() {
+		defer close(stateChan)
+		if err := rp.run(ctx, stateChan); err != nil {
+			rp.mu.Lock()
+			rp.err = err
+			rp.mu.Unlock()
+		}
+	}()
+	return stateChan
+}
+
+// Err returns the error that stopped the replay, if any. It is only
+// meaningful once the state channel has been closed.
+func (rp *Replayer) Err() error {
+	rp.mu.Lock()
+	defer rp.mu.Unlock()
+	return rp.err
+}
+
+func (rp *Replayer) run(ctx context.Context, stateChan chan<- *types.State) error {
+	var first time.Time
+	start := time.Now()
+	for {
+		entry, err := rp.reader.Next()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+
+		if first.IsZero() {
+			first = entry.Time
+		} else if rp.speed > 0 {
+			offset := time.Duration(float64(entry.Time.Sub(first)) / rp.speed)
+			if wait := time.Until(start.Add(offset)); wait > 0 {
+				timer := time.NewTimer(wait)
+				select {
+				case <-ctx.Done():
+					timer.Stop()
+					return ctx.Err()
+				case <-timer.C:
+				}
+			}
+		}
+
+		switch entry.Type {
+		case EntryState:
+			if entry.State == nil {
+				continue
+			}
+			select {
+			case stateChan <- entry.State:
+			case <-ctx.Done():
+				return ctx.Err()
+			}
+		case EntryCommand:
+			if rp.onCommand != nil && entry.Command != nil {
+				rp.onCommand(entry.Time, entry.Command)
+			}
+		case EntrySafety:
+			if rp.onSafetyEvent != nil && entry.Event != nil {
+				rp.onSafetyEvent(entry.Event)
+			}
+		}
+	}
+}
@@ -0,0 +1,183 @@
+package gamepad
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+)
+
+// key identifies what triggers a binding: its buttons, hold time and
+// double tap
+func (b Binding) key() string {
+	key := comboKey(b.Buttons)
+	if b.HoldTime > 0 {
+		key += fmt.Sprintf(" held %dms", b.HoldTime)
+	}
+	if b.DoubleTap {
+		key += " double tapped"
+	}
+	return key
+}
+
+// isCombo reports whether the binding needs buttons held together
+func (b Binding) isCombo() bool {
+	return len(b.Buttons) > 1
+}
+
+// mapping returns the press requirements of the binding
+func (b Binding) mapping() ButtonMapping {
+	mapping := ButtonMapping{HoldTime: b.HoldTime, DoubleTap: b.DoubleTap}
+	if len(b.Buttons) > 0 {
+		mapping.Button = b.Buttons[0]
+	}
+	return mapping
+}
+
+// bindings converts the fixed button mappings of older profiles
+func (b ButtonsMapping) bindings() []Binding {
+	var bindings []Binding
+	for _, legacy := range []struct {
+		mapping *ButtonMapping
+		action  DroneAction
+	}{
+		{&b.TakeoffLand, ActionTakeoffLand},
+		{&b.Emergency, ActionEmergency},
+		{&b.FlipForward, ActionFlipForward},
+		{&b.FlipBackward, ActionFlipBackward},
+		{b.FlipLeft, ActionFlipLeft},
+		{b.FlipRight, ActionFlipRight},
+		{b.StreamToggle, ActionStreamToggle},
+	} {
+		if legacy.mapping == nil || legacy.mapping.Button == "" {
+			continue
+		}
+		bindings = append(bindings, Binding{
+			Buttons:   []ButtonType{legacy.mapping.Button},
+			HoldTime:  legacy.mapping.HoldTime,
+			DoubleTap: legacy.mapping.DoubleTap,
+			Action:    legacy.action,
+		})
+	}
+	return bindings
+}
+
+// configBindings returns every binding of a config: the fixed button
+// mappings, less those a binding with the same trigger replaces, then the
+// bindings
+func configBindings(config *Config) []Binding {
+	replaced := make(map[string]bool, len(config.Mappings.Bindings))
+	for _, binding := range config.Mappings.Bindings {
+		replaced[binding.key()] = true
+	}
+
+	var bindings []Binding
+	for _, binding := range config.Mappings.Buttons.bindings() {
+		if !replaced[binding.key()] {
+			bindings = append(bindings, binding)
+		}
+	}
+	for _, binding := range config.Mappings.Bindings {
+		if len(binding.Buttons) > 0 {
+			bindings = append(bindings, binding)
+		}
+	}
+	return bindings
+}
+
+// validateBindings checks what the schema cannot: that each binding does
+// one thing, its macro parses, no two share a trigger and none takes the
+// flight mode cycle combo
+func validateBindings(config *Config) error {
+	var cycle string
+	if config.FlightModes != nil && len(config.FlightModes.Modes) > 1 {
+		combo := config.FlightModes.CycleCombo
+		if len(combo) == 0 {
+			combo = defaultCycleCombo
+		}
+		cycle = comboKey(combo)
+	}
+
+	seen := make(map[string]bool, len(config.Mappings.Bindings))
+	for i, binding := range config.Mappings.Bindings {
+		name := fmt.Sprintf("binding %d (%s)", i+1, joinButtons(binding.Buttons))
+		if len(binding.Buttons) == 0 {
+			return fmt.Errorf("binding %d has no buttons", i+1)
+		}
+		if (binding.Action == "") == (len(binding.Macro) == 0) {
+			return fmt.Errorf("%s needs either an action or a macro", name)
+		}
+		if len(binding.Macro) > 0 {
+			if _, err := ParseMacro(binding.Macro); err != nil {
+				return fmt.Errorf("%s: %w", name, err)
+			}
+		}
+		if seen[binding.key()] {
+			return fmt.Errorf("%s: %s is bound twice", name, binding.key())
+		}
+		seen[binding.key()] = true
+		if binding.isCombo() && comboKey(binding.Buttons) == cycle {
+			return fmt.Errorf("%s: the buttons cycle the flight mode", name)
+		}
+	}
+	return nil
+}
+
+// bindingState returns the state of a binding's button, or for a combo the
+// state of the button pressed last, once all of them are held
+func bindingState(state *GamepadState, binding Binding) (*ButtonState, bool) {
+	if !binding.isCombo() {
+		button, ok := state.Buttons[binding.Buttons[0]]
+		return button, ok
+	}
+
+	var last *ButtonState
+	for _, b := range binding.Buttons {
+		button, ok := state.Buttons[b]
+		if !ok || !button.Pressed {
+			return nil, false
+		}
+		if last == nil || button.PressTime.After(last.PressTime) {
+			last = button
+		}
+	}
+	return last, true
+}
+
+// inCombo reports whether button is part of the mode cycle combo or of a
+// combo binding, so it acts on its own only on release
+func (m *DefaultMapper) inCombo(button ButtonType, bindings []Binding) bool {
+	if m.inModeCombo(button) {
+		return true
+	}
+	for _, binding := range bindings {
+		if binding.isCombo() && slices.Contains(binding.Buttons, button) {
+			return true
+		}
+	}
+	return false
+}
+
+// holdCombo remembers the presses of a held combo binding so its buttons'
+// own bindings stay quiet
+func (m *DefaultMapper) holdCombo(state *GamepadState, binding Binding) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for _, button := range binding.Buttons {
+		m.comboUsed[button] = state.Buttons[button].PressTime
+	}
+}
+
+// comboKey identifies a set of buttons regardless of their order
+func comboKey(buttons []ButtonType) string {
+	sorted := slices.Clone(buttons)
+	slices.Sort(sorted)
+	return joinButtons(sorted)
+}
+
+func joinButtons(buttons []ButtonType) string {
+	names := make([]string, len(buttons))
+	for i, button := range buttons {
+		names[i] = string(button)
+	}
+	return strings.Join(names, "+")
+}
@@ -0,0 +1,204 @@
+package gamepad
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseMacro(t *testing.T) {
+	t.Run("SDK commands, photo and wait", func(t *testing.T) {
+		macro, err := ParseMacro([]string{"up 50", "CW 360", "flip f", "go 100 -50 0 60", "wait 1.5", "photo"})
+		require.NoError(t, err)
+		assert.Equal(t, Macro{
+			{Command: "up", Args: []int{50}},
+			{Command: "cw", Args: []int{360}},
+			{Command: MacroFlip, Flip: "f"},
+			{Command: "go", Args: []int{100, -50, 0, 60}},
+			{Command: MacroWait, Wait: 1500 * time.Millisecond},
+			{Command: MacroPhoto},
+		}, macro)
+		assert.Equal(t, "up 50, cw 360, flip f, go 100 -50 0 60, wait 1.5, photo", macro.String())
+	})
+
+	for _, tc := range []struct {
+		steps []string
+		err   string
+	}{
+		{nil, "macro has no steps"},
+		{[]string{"  "}, "step 1: empty step"},
+		{[]string{"land", "hover 5"}, `step 2: unknown command "hover"`},
+		{[]string{"up"}, "up takes 1 argument(s), got 0"},
+		{[]string{"up 10"}, "up: 10 is outside 20 to 500"},
+		{[]string{"speed fast"}, `speed: "fast" is not a whole number`},
+		{[]string{"curve 0 0 0 100 100 0 80"}, "curve: 80 is outside 10 to 60"},
+		{[]string{"flip x"}, "usage: flip <l|r|f|b>"},
+		{[]string{"wait -1"}, "wait needs a positive number of seconds"},
+	} {
+		_, err := ParseMacro(tc.steps)
+		assert.ErrorContains(t, err, tc.err, "%q", tc.steps)
+	}
+}
+
+func TestConfigBindings(t *testing.T) {
+	t.Run("fixed buttons become bindings", func(t *testing.T) {
+		config := DefaultConfig()
+		bindings := configBindings(config)
+		require.Len(t, bindings, 7)
+		assert.Equal(t, Binding{
+			Buttons:  []ButtonType{config.Mappings.Buttons.TakeoffLand.Button},
+			HoldTime: config.Mappings.Buttons.TakeoffLand.HoldTime,
+			Action:   ActionTakeoffLand,
+		}, bindings[0])
+		assert.Equal(t, ActionStreamToggle, bindings[6].Action)
+	})
+
+	t.Run("a binding replaces the fixed button with its trigger", func(t *testing.T) {
+		config := DefaultConfig()
+		config.Mappings.Bindings = []Binding{
+			{Buttons: []ButtonType{ButtonX}, Macro: []string{"up 50"}},
+			{Buttons: []ButtonType{ButtonY}, HoldTime: 500, Action: ActionPhoto},
+		}
+		bindings := configBindings(config)
+		require.Len(t, bindings, 8)
+		for _, binding := range bindings {
+			assert.NotEqual(t, ActionFlipForward, binding.Action, "Flip forward on X is replaced")
+		}
+		assert.Contains(t, bindings, Binding{Buttons: []ButtonType{ButtonY}, Action: ActionFlipBackward}, "A held Y does not replace a tapped one")
+	})
+}
+
+func TestDefaultMapper_Bindings(t *testing.T) {
+	pressed := func(state *GamepadState, button ButtonType, at time.Time) {
+		state.Buttons[button] = &ButtonState{Pressed: true, PressTime: at, TapCount: 1}
+	}
+	released := func(state *GamepadState, button ButtonType, at time.Time) {
+		state.Buttons[button].Pressed = false
+		state.Buttons[button].LastRelease = at
+	}
+	start := time.Now().Add(-time.Minute)
+
+	t.Run("a macro triggers on press", func(t *testing.T) {
+		config := DefaultConfig()
+		config.Mappings.Bindings = []Binding{{Buttons: []ButtonType{DPadUp}, Macro: []string{"speed 50"}}}
+		mapper := NewDefaultMapper(config)
+
+		commands, err := mapper.MapEvent(Event{Type: EventButtonPress, Input: string(DPadUp), Value: 1, Timestamp: start})
+		require.NoError(t, err)
+		assert.Equal(t, []Command{{Type: CommandMacro, Data: Macro{{Command: "speed", Args: []int{50}}}}}, commands)
+
+		state := NewGamepadState()
+		pressed(state, DPadUp, start)
+		assert.Empty(t, mapper.processButtons(state), "The press already triggered")
+	})
+
+	t.Run("a combo runs its macro and keeps its buttons quiet", func(t *testing.T) {
+		config := DefaultConfig()
+		config.Mappings.Bindings = []Binding{{
+			Buttons:  []ButtonType{ButtonX, ButtonY},
+			HoldTime: 500,
+			Macro:    []string{"up 50", "cw 360", "photo"},
+		}}
+		mapper := NewDefaultMapper(config)
+		state := NewGamepadState()
+
+		commands, _ := mapper.MapEvent(Event{Type: EventButtonPress, Input: string(ButtonX), Value: 1, Timestamp: start})
+		assert.Empty(t, commands, "X waits for its release")
+
+		pressed(state, ButtonX, start)
+		pressed(state, ButtonY, time.Now().Add(-200*time.Millisecond))
+		assert.Empty(t, mapper.processButtons(state), "The combo is not held long enough yet")
+
+		state.Buttons[ButtonY].PressTime = time.Now().Add(-600 * time.Millisecond)
+		commands = mapper.processButtons(state)
+		require.Len(t, commands, 1)
+		assert.Equal(t, CommandMacro, commands[0].Type)
+		assert.Equal(t, "up 50, cw 360, photo", commands[0].Data.(Macro).String())
+
+		released(state, ButtonX, time.Now())
+		released(state, ButtonY, time.Now())
+		assert.Empty(t, mapper.processButtons(state), "Combo presses do not flip")
+
+		pressed(state, ButtonX, time.Now().Add(-100*time.Millisecond))
+		released(state, ButtonX, time.Now())
+		assert.Equal(t, actionCommands(ActionFlipForward), mapper.processButtons(state), "A lone X flips on release")
+	})
+
+	t.Run("toggle actions resolve from the drone model", func(t *testing.T) {
+		config := DefaultConfig()
+		config.Mappings.Bindings = []Binding{{Buttons: []ButtonType{DPadDown}, Action: ActionStreamToggle}}
+		mapper := NewDefaultMapper(config)
+
+		assert.Equal(t, actionCommands(ActionStreamOn), mapper.processButtons(heldButton(DPadDown, 0)))
+		rewind(mapper, time.Minute)
+		assert.Equal(t, actionCommands(ActionStreamOff), mapper.processButtons(heldButton(DPadDown, 0)))
+		assert.Equal(t, ActionTakeoff, mapper.mapButtonToAction(ButtonA))
+	})
+}
+
+func TestValidateBindings(t *testing.T) {
+	assert.NoError(t, validateBindings(DefaultConfig()))
+
+	for _, tc := range []struct {
+		binding Binding
+		err     string
+	}{
+		{Binding{Action: ActionPhoto}, "binding 2 has no buttons"},
+		{Binding{Buttons: []ButtonType{ButtonStart}}, "needs either an action or a macro"},
+		{Binding{Buttons: []ButtonType{ButtonStart}, Action: ActionPhoto, Macro: []string{"photo"}}, "needs either an action or a macro"},
+		{Binding{Buttons: []ButtonType{ButtonStart}, Macro: []string{"up 1000"}}, "binding 2 (button_start): step 1: up: 1000 is outside 20 to 500"},
+		{Binding{Buttons: []ButtonType{DPadUp}, Action: ActionPhoto}, "dpad_up is bound twice"},
+		{Binding{Buttons: []ButtonType{RightBumper, LeftBumper}, Action: ActionPhoto}, "the buttons cycle the flight mode"},
+	} {
+		config := DefaultConfig()
+		config.Mappings.Bindings = []Binding{{Buttons: []ButtonType{DPadUp}, Macro: []string{"speed 50"}}, tc.binding}
+		assert.ErrorContains(t, validateBindings(config), tc.err)
+	}
+}
+
+func TestLoadConfig_Bindings(t *testing.T) {
+	loader, err := NewConfigLoader()
+	if err != nil {
+		t.Skipf("Skipping test due to schema compilation error: %v", err)
+	}
+
+	t.Run("bindings alone", func(t *testing.T) {
+		configPath := filepath.Join(t.TempDir(), "config.json")
+		require.NoError(t, os.WriteFile(configPath, []byte(`{
+  "version": "1.0.0",
+  "controller": {"deadzone": 0.1, "sensitivity": 1.0, "update_rate": 60, "auto_detect": true},
+  "safety": {
+    "rc_limits": {"horizontal": 80, "vertical": 60, "yaw": 100},
+    "emergency_actions": {"connection_timeout": 3000, "low_battery_threshold": 20, "enable_auto_land": true}
+  },
+  "mappings": {
+    "axes": {
+      "movement_x": {"axis": "left_stick_x", "invert": false},
+      "movement_y": {"axis": "left_stick_y", "invert": false},
+      "altitude": {"axis": "right_stick_y", "invert": true},
+      "yaw": {"axis": "right_stick_x", "invert": false}
+    },
+    "bindings": [
+      {"buttons": ["button_a"], "hold_time": 500, "action": "takeoff_land"},
+      {"buttons": ["left_bumper", "button_y"], "macro": ["up 50", "cw 360", "photo"]}
+    ]
+  }
+}`), 0o644))
+
+		config, err := loader.LoadConfig(configPath)
+		require.NoError(t, err)
+		assert.Nil(t, config.Mappings.Buttons.FlipLeft, "No fixed buttons are filled in")
+		assert.Len(t, configBindings(config), 2)
+		assert.NoError(t, loader.ValidateConfig(config))
+	})
+
+	t.Run("the example profile loads", func(t *testing.T) {
+		config, err := loader.LoadConfig(filepath.Join("..", "..", "configs", "gamepad-config.json"))
+		require.NoError(t, err)
+		assert.NotEmpty(t, config.Mappings.Bindings)
+	})
+}
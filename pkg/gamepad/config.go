@@ -58,6 +58,12 @@ func (cl *ConfigLoader) LoadConfig(configPath string) (*Config, error) {
 	if err := cl.validateJSONData(data); err != nil {
 		return nil, fmt.Errorf("configuration validation failed: %w", err)
 	}
+	if err := validateFlightModes(config.FlightModes); err != nil {
+		return nil, fmt.Errorf("configuration validation failed: %w", err)
+	}
+	if err := validateBindings(&config); err != nil {
+		return nil, fmt.Errorf("configuration validation failed: %w", err)
+	}
 
 	// Apply defaults for optional fields
 	cl.applyDefaults(&config)
@@ -116,7 +122,13 @@ func (cl *ConfigLoader) ValidateConfig(config *Config) error {
 		return fmt.Errorf("failed to marshal config for validation: %w", err)
 	}
 
-	return cl.validateJSONData(configData)
+	if err := cl.validateJSONData(configData); err != nil {
+		return err
+	}
+	if err := validateFlightModes(config.FlightModes); err != nil {
+		return err
+	}
+	return validateBindings(config)
 }
 
 // applyDefaults applies default values for optional fields
@@ -126,28 +138,31 @@ func (cl *ConfigLoader) applyDefaults(config *Config) {
 		config.Version = "1.0.0"
 	}
 
-	// Apply defaults for optional button mappings
-	if config.Mappings.Buttons.FlipLeft == nil {
-		config.Mappings.Buttons.FlipLeft = &ButtonMapping{
-			Button:    LeftBumper,
-			HoldTime:  0,
-			DoubleTap: false,
+	// Apply defaults for optional button mappings, unless the profile
+	// binds its buttons with bindings alone
+	if config.Mappings.Buttons.TakeoffLand.Button != "" {
+		if config.Mappings.Buttons.FlipLeft == nil {
+			config.Mappings.Buttons.FlipLeft = &ButtonMapping{
+				Button:    LeftBumper,
+				HoldTime:  0,
+				DoubleTap: false,
+			}
 		}
-	}
 
-	if config.Mappings.Buttons.FlipRight == nil {
-		config.Mappings.Buttons.FlipRight = &ButtonMapping{
-			Button:    RightBumper,
-			HoldTime:  0,
-			DoubleTap: false,
+		if config.Mappings.Buttons.FlipRight == nil {
+			config.Mappings.Buttons.FlipRight = &ButtonMapping{
+				Button:    RightBumper,
+				HoldTime:  0,
+				DoubleTap: false,
+			}
 		}
-	}
 
-	if config.Mappings.Buttons.StreamToggle == nil {
-		config.Mappings.Buttons.StreamToggle = &ButtonMapping{
-			Button:    ButtonSelect,
-			HoldTime:  0,
-			DoubleTap: false,
+		if config.Mappings.Buttons.StreamToggle == nil {
+			config.Mappings.Buttons.StreamToggle = &ButtonMapping{
+				Button:    ButtonSelect,
+				HoldTime:  0,
+				DoubleTap: false,
+			}
 		}
 	}
 
@@ -195,7 +210,7 @@ func getSchemaFallbackPaths() ([]string, error) {
 	var locations []string
 	locations = append(locations,
 		filepath.Join(wd, "configs", "gamepad-schema.json"),
-		filepath.Join(wd, "pkg", "gamepad", "..", "..", "configs", "gamepad-schema.json"),
+		filepath.Join(wd, "..", "..", "configs", "gamepad-schema.json"),
 	)
 
 	if home, err := os.UserHomeDir(); err == nil {
@@ -220,8 +235,8 @@ func getDefaultConfigPath() (string, error) {
 		return configPath, nil
 	}
 
-	// Try relative to the package directory
-	pkgPath := filepath.Join(wd, "pkg", "gamepad", "..", "..", "configs", "gamepad-default.json")
+	// Try from the package directory, where tests run
+	pkgPath := filepath.Join(wd, "..", "..", "configs", "gamepad-default.json")
 	if _, err := os.Stat(pkgPath); err == nil {
 		return pkgPath, nil
 	}
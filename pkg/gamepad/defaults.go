@@ -92,6 +92,104 @@ func DefaultConfig() *Config {
 				},
 			},
 		},
+		FlightModes: DefaultFlightModes(),
+		Feedback:    DefaultFeedback(),
+	}
+}
+
+// DefaultFeedback returns rumble patterns that tell the safety events apart
+// by feel: the worse the battery, the more pulses; a long buzz when the link
+// drops and a short one when it comes back. Light bars turn amber, then red.
+func DefaultFeedback() *FeedbackConfig {
+	return &FeedbackConfig{
+		LED: "#0040ff",
+		Patterns: []FeedbackPattern{
+			{
+				Event:  "battery",
+				Level:  "warning",
+				Rumble: []RumblePulse{{Low: 0.4, High: 0.2, Duration: 200}},
+				LED:    "#ffa000",
+			},
+			{
+				Event: "battery",
+				Level: "critical",
+				Rumble: []RumblePulse{
+					{Low: 0.7, High: 0.4, Duration: 200, Pause: 150},
+					{Low: 0.7, High: 0.4, Duration: 200},
+				},
+				LED:      "#ff2000",
+				Cooldown: 5000,
+			},
+			{
+				Event: "battery",
+				Level: "emergency",
+				Rumble: []RumblePulse{
+					{Low: 1.0, High: 0.6, Duration: 200, Pause: 100},
+					{Low: 1.0, High: 0.6, Duration: 200, Pause: 100},
+					{Low: 1.0, High: 0.6, Duration: 200},
+				},
+				LED:      "#ff0000",
+				Cooldown: 3000,
+			},
+			{
+				Event:  "altitude",
+				Rumble: []RumblePulse{{Low: 0, High: 0.6, Duration: 120, Pause: 80}, {Low: 0, High: 0.6, Duration: 120}},
+				LED:    "#ffff00",
+				Hold:   3000,
+			},
+			{
+				Event:    "sensor",
+				Rumble:   []RumblePulse{{Low: 0, High: 0.8, Duration: 300}},
+				LED:      "#c000ff",
+				Hold:     3000,
+				Cooldown: 5000,
+			},
+			{
+				Event:  "geofence",
+				Rumble: []RumblePulse{{Low: 0.8, High: 0.8, Duration: 400}},
+				LED:    "#ff00a0",
+				Hold:   3000,
+			},
+			{
+				Event:  "connection",
+				Level:  "critical",
+				Rumble: []RumblePulse{{Low: 1.0, High: 1.0, Duration: 1000}},
+				LED:    "#ff0000",
+			},
+			{
+				Event:  "connection",
+				Level:  "info",
+				Rumble: []RumblePulse{{Low: 0.3, High: 0, Duration: 150}},
+				LED:    "#0040ff",
+			},
+		},
+	}
+}
+
+// DefaultFlightModes returns the cinematic, normal and sport modes, cycled
+// by holding both bumpers. Normal flies like a config without modes.
+func DefaultFlightModes() *FlightModes {
+	cinematicExpo := 0.4
+	return &FlightModes{
+		Modes: []FlightMode{
+			{
+				Name:      "cinematic",
+				RCLimits:  RCLimits{Horizontal: 30, Vertical: 25, Yaw: 40},
+				Expo:      &cinematicExpo,
+				Smoothing: 0.85, // Ease in and out
+				SlewRate:  60,   // Full cinematic stick takes half a second
+			},
+			{
+				Name:     "normal",
+				RCLimits: RCLimits{Horizontal: 80, Vertical: 60, Yaw: 100},
+			},
+			{
+				Name:     "sport",
+				RCLimits: RCLimits{Horizontal: 100, Vertical: 100, Yaw: 100},
+			},
+		},
+		Default:    "normal",
+		CycleCombo: []ButtonType{LeftBumper, RightBumper},
 	}
 }
 
@@ -0,0 +1,162 @@
+package gamepad
+
+import (
+	"sync"
+	"time"
+
+	"github.com/conceptcodes/dji-tello-sdk-go/pkg/types"
+)
+
+// FlightState represents what the mapper knows about the drone being airborne
+type FlightState string
+
+const (
+	FlightUnknown   FlightState = "unknown" // Nothing known yet, every action is allowed
+	FlightLanded    FlightState = "landed"
+	FlightTakingOff FlightState = "taking_off"
+	FlightFlying    FlightState = "flying"
+	FlightLanding   FlightState = "landing"
+)
+
+const (
+	// airborneHeight is the height (cm) above which a state puts the drone in the air
+	airborneHeight = 10
+
+	// transitionTimeout is how long a takeoff or landing may go unconfirmed
+	// by the drone's states before it is taken to have failed
+	transitionTimeout = 10 * time.Second
+)
+
+// DroneModel tracks whether the drone is flying and streaming video, so the
+// mapper can resolve toggle buttons and refuse actions that do not fit. It
+// follows the actions the mapper issues and, once fed with UpdateState, the
+// states the drone reports. It is safe for concurrent use.
+type DroneModel struct {
+	now func() time.Time
+
+	mu        sync.Mutex
+	flight    FlightState
+	since     time.Time // When flight last changed
+	hasState  bool      // States arrive, so takeoffs and landings wait for them
+	streaming bool
+}
+
+// NewDroneModel creates a model that knows nothing about the drone yet
+func NewDroneModel() *DroneModel {
+	return &DroneModel{
+		now:    time.Now,
+		flight: FlightUnknown,
+	}
+}
+
+// Flight returns the current flight state
+func (d *DroneModel) Flight() FlightState {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.expireLocked()
+	return d.flight
+}
+
+// Streaming reports whether the video stream is on
+func (d *DroneModel) Streaming() bool {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	return d.streaming
+}
+
+// SetStreaming records whether the video stream is on, e.g. when it was
+// started without the gamepad
+func (d *DroneModel) SetStreaming(on bool) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.streaming = on
+}
+
+// UpdateState moves the flight state to what the drone reports. A takeoff
+// or landing completes once the height confirms it; outside of one, the
+// drone is taken to be wherever the state puts it.
+func (d *DroneModel) UpdateState(state *types.State) {
+	if state == nil {
+		return
+	}
+	airborne := state.H > airborneHeight
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.hasState = true
+	d.expireLocked()
+	switch {
+	case airborne && d.flight != FlightFlying && d.flight != FlightLanding:
+		d.setLocked(FlightFlying)
+	case !airborne && d.flight != FlightLanded && d.flight != FlightTakingOff:
+		d.setLocked(FlightLanded)
+	}
+}
+
+// ActionFailed undoes the change an issued action made, for when the drone
+// refused it
+func (d *DroneModel) ActionFailed(action DroneAction) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	switch action {
+	case ActionTakeoff:
+		if d.flight == FlightTakingOff || (!d.hasState && d.flight == FlightFlying) {
+			d.setLocked(FlightLanded)
+		}
+	case ActionLand:
+		if d.flight == FlightLanding || (!d.hasState && d.flight == FlightLanded) {
+			d.setLocked(FlightFlying)
+		}
+	case ActionStreamOn:
+		d.streaming = false
+	case ActionStreamOff:
+		d.streaming = true
+	}
+}
+
+// apply records an action the mapper issued
+func (d *DroneModel) apply(action DroneAction) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	switch action {
+	case ActionTakeoff:
+		d.transitionLocked(FlightTakingOff, FlightFlying)
+	case ActionLand:
+		d.transitionLocked(FlightLanding, FlightLanded)
+	case ActionEmergency:
+		// The motors stop at once
+		d.setLocked(FlightLanded)
+	case ActionStreamOn:
+		d.streaming = true
+	case ActionStreamOff:
+		d.streaming = false
+	}
+}
+
+// transitionLocked starts a takeoff or landing, which completes at once
+// when no states arrive to confirm it
+func (d *DroneModel) transitionLocked(during, after FlightState) {
+	if d.hasState {
+		d.setLocked(during)
+	} else {
+		d.setLocked(after)
+	}
+}
+
+// expireLocked gives up on a takeoff or landing the states never confirmed
+func (d *DroneModel) expireLocked() {
+	if d.now().Sub(d.since) < transitionTimeout {
+		return
+	}
+	switch d.flight {
+	case FlightTakingOff:
+		d.setLocked(FlightLanded)
+	case FlightLanding:
+		d.setLocked(FlightFlying)
+	}
+}
+
+func (d *DroneModel) setLocked(flight FlightState) {
+	d.flight = flight
+	d.since = d.now()
+}
@@ -0,0 +1,105 @@
+package gamepad
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/conceptcodes/dji-tello-sdk-go/pkg/types"
+)
+
+func TestDroneModel_WithoutStates(t *testing.T) {
+	model := NewDroneModel()
+	assert.Equal(t, FlightUnknown, model.Flight())
+
+	// Nothing can confirm a takeoff, so it is taken to succeed
+	model.apply(ActionTakeoff)
+	assert.Equal(t, FlightFlying, model.Flight())
+
+	model.apply(ActionLand)
+	assert.Equal(t, FlightLanded, model.Flight())
+
+	t.Run("failed actions are undone", func(t *testing.T) {
+		model.apply(ActionTakeoff)
+		model.ActionFailed(ActionTakeoff)
+		assert.Equal(t, FlightLanded, model.Flight())
+
+		model.apply(ActionTakeoff)
+		model.apply(ActionLand)
+		model.ActionFailed(ActionLand)
+		assert.Equal(t, FlightFlying, model.Flight())
+	})
+
+	t.Run("emergency lands at once", func(t *testing.T) {
+		model.apply(ActionEmergency)
+		assert.Equal(t, FlightLanded, model.Flight())
+	})
+}
+
+func TestDroneModel_WithStates(t *testing.T) {
+	model := NewDroneModel()
+	model.UpdateState(&types.State{H: 0})
+	assert.Equal(t, FlightLanded, model.Flight())
+
+	model.apply(ActionTakeoff)
+	assert.Equal(t, FlightTakingOff, model.Flight())
+	model.UpdateState(&types.State{H: 0}) // Still spinning up
+	assert.Equal(t, FlightTakingOff, model.Flight())
+	model.UpdateState(&types.State{H: 80})
+	assert.Equal(t, FlightFlying, model.Flight())
+
+	model.apply(ActionLand)
+	assert.Equal(t, FlightLanding, model.Flight())
+	model.UpdateState(&types.State{H: 40}) // Descending
+	assert.Equal(t, FlightLanding, model.Flight())
+	model.UpdateState(&types.State{H: 0})
+	assert.Equal(t, FlightLanded, model.Flight())
+
+	t.Run("states outside a transition win", func(t *testing.T) {
+		// E.g. a takeoff from another client
+		model.UpdateState(&types.State{H: 120})
+		assert.Equal(t, FlightFlying, model.Flight())
+		model.UpdateState(&types.State{H: 0})
+		assert.Equal(t, FlightLanded, model.Flight())
+	})
+
+	t.Run("confirmed takeoff is not undone", func(t *testing.T) {
+		model.apply(ActionTakeoff)
+		model.UpdateState(&types.State{H: 80})
+		model.ActionFailed(ActionTakeoff)
+		assert.Equal(t, FlightFlying, model.Flight())
+	})
+}
+
+func TestDroneModel_TransitionTimeout(t *testing.T) {
+	now := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	model := NewDroneModel()
+	model.now = func() time.Time { return now }
+	model.UpdateState(&types.State{H: 0})
+
+	model.apply(ActionTakeoff)
+	now = now.Add(transitionTimeout - time.Second)
+	assert.Equal(t, FlightTakingOff, model.Flight())
+	now = now.Add(time.Second)
+	assert.Equal(t, FlightLanded, model.Flight(), "An unconfirmed takeoff should fail")
+
+	model.UpdateState(&types.State{H: 80})
+	model.apply(ActionLand)
+	now = now.Add(transitionTimeout)
+	assert.Equal(t, FlightFlying, model.Flight(), "An unconfirmed landing should fail")
+}
+
+func TestDroneModel_Streaming(t *testing.T) {
+	model := NewDroneModel()
+	assert.False(t, model.Streaming())
+
+	model.apply(ActionStreamOn)
+	assert.True(t, model.Streaming())
+	model.ActionFailed(ActionStreamOn)
+	assert.False(t, model.Streaming())
+
+	model.SetStreaming(true)
+	model.apply(ActionStreamOff)
+	assert.False(t, model.Streaming())
+}
@@ -0,0 +1,215 @@
+package gamepad
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/conceptcodes/dji-tello-sdk-go/pkg/safety"
+	"github.com/conceptcodes/dji-tello-sdk-go/pkg/utils"
+)
+
+// Feedback is implemented by input sources that can rumble the controller
+// or set its light bar, as SDLSource does
+type Feedback interface {
+	// Rumble runs the low and high frequency motors, from 0.0 to 1.0, for
+	// duration
+	Rumble(low, high float64, duration time.Duration) error
+
+	// SetLED sets the light bar color, e.g. of a DualShock 4 or DualSense
+	SetLED(red, green, blue uint8) error
+}
+
+// defaultFeedbackCooldown is how long a pattern's rumble waits before it
+// plays again, as safety events repeat with every state the drone sends
+const defaultFeedbackCooldown = 10 * time.Second
+
+// eventLevels ranks the levels of safety events, so a worse event can cut
+// a milder one short
+var eventLevels = map[string]int{
+	string(safety.SafetyEventLevelInfo):      0,
+	string(safety.SafetyEventLevelWarning):   1,
+	string(safety.SafetyEventLevelCritical):  2,
+	string(safety.SafetyEventLevelEmergency): 3,
+}
+
+// feedbackOf returns the feedback of a source, looking through a recording
+func feedbackOf(source InputSource) (Feedback, bool) {
+	if recording, ok := source.(*RecordingSource); ok {
+		source = recording.InputSource
+	}
+	feedback, ok := source.(Feedback)
+	return feedback, ok
+}
+
+// ledColor is a light bar color
+type ledColor struct {
+	red, green, blue uint8
+}
+
+// parseColor parses a "#rrggbb" color
+func parseColor(text string) (ledColor, error) {
+	hex, ok := strings.CutPrefix(text, "#")
+	if !ok || len(hex) != 6 {
+		return ledColor{}, fmt.Errorf("invalid color %q: use #rrggbb", text)
+	}
+	value, err := strconv.ParseUint(hex, 16, 32)
+	if err != nil {
+		return ledColor{}, fmt.Errorf("invalid color %q: use #rrggbb", text)
+	}
+	return ledColor{uint8(value >> 16), uint8(value >> 8), uint8(value)}, nil
+}
+
+// feedbackPlayer plays the pattern of each safety event on a controller.
+// Events arrive from the safety manager's goroutines; the patterns play
+// when the handler polls, as SDL2 wants its calls from the main thread.
+type feedbackPlayer struct {
+	config *FeedbackConfig
+	device Feedback
+
+	mu         sync.Mutex
+	lastPlayed map[int]time.Time // When each pattern's rumble last started
+
+	// The rumble playing
+	rumble  *FeedbackPattern
+	level   int
+	started time.Time
+	pulse   int // Next pulse to start
+
+	// The light bar color wanted, and the one set
+	led      string
+	ledEvent string    // Event type that set the color, "" for the base color
+	ledLevel int       // Level of that event
+	ledUntil time.Time // When the color returns to the base (zero: never)
+	shown    string
+
+	rumbleFailed, ledFailed bool // The controller cannot, so stop trying
+}
+
+// newFeedbackPlayer creates a player for config's patterns
+func newFeedbackPlayer(config *FeedbackConfig, device Feedback) *feedbackPlayer {
+	return &feedbackPlayer{
+		config:     config,
+		device:     device,
+		lastPlayed: make(map[int]time.Time),
+		led:        config.LED,
+	}
+}
+
+// match returns the first pattern for an event and its index
+func (p *feedbackPlayer) match(eventType, level string) (*FeedbackPattern, int) {
+	for i := range p.config.Patterns {
+		pattern := &p.config.Patterns[i]
+		if pattern.Event == eventType && (pattern.Level == "" || pattern.Level == level) {
+			return pattern, i
+		}
+	}
+	return nil, -1
+}
+
+// trigger queues the pattern for an event. A pattern's rumble plays at most
+// once per cooldown and only cuts short a rumble of the same or a milder
+// level. Its color replaces that of the same event type or a milder one.
+func (p *feedbackPlayer) trigger(eventType, level string, now time.Time) {
+	pattern, index := p.match(eventType, level)
+	if pattern == nil {
+		return
+	}
+	rank := eventLevels[level]
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if pattern.LED != "" && (p.ledEvent == "" || p.ledEvent == eventType || rank >= p.ledLevel) {
+		p.led = pattern.LED
+		p.ledEvent = eventType
+		p.ledLevel = rank
+		p.ledUntil = time.Time{}
+		if pattern.Hold > 0 {
+			p.ledUntil = now.Add(time.Duration(pattern.Hold) * time.Millisecond)
+		}
+	}
+
+	if len(pattern.Rumble) == 0 || (p.playing(now) && rank < p.level) {
+		return
+	}
+	cooldown := defaultFeedbackCooldown
+	if pattern.Cooldown > 0 {
+		cooldown = time.Duration(pattern.Cooldown) * time.Millisecond
+	}
+	if last, ok := p.lastPlayed[index]; ok && now.Sub(last) < cooldown {
+		return
+	}
+	p.lastPlayed[index] = now
+	p.rumble = pattern
+	p.level = rank
+	p.started = now
+	p.pulse = 0
+}
+
+// play starts the pulses that are due and sets the light bar color
+func (p *feedbackPlayer) play(now time.Time) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if !p.ledUntil.IsZero() && now.After(p.ledUntil) {
+		p.led = p.config.LED
+		p.ledEvent = ""
+		p.ledUntil = time.Time{}
+	}
+	if p.led != p.shown && !p.ledFailed {
+		p.setLED(p.led)
+	}
+
+	for p.rumble != nil && !now.Before(p.pulseStart(p.pulse)) {
+		if p.pulse == len(p.rumble.Rumble) {
+			// The last pulse is over
+			p.rumble = nil
+			break
+		}
+
+		pulse := p.rumble.Rumble[p.pulse]
+		p.pulse++
+		if p.rumbleFailed {
+			continue
+		}
+		if err := p.device.Rumble(pulse.Low, pulse.High, time.Duration(pulse.Duration)*time.Millisecond); err != nil {
+			utils.Logger.Warnf("Gamepad cannot rumble: %v", err)
+			p.rumbleFailed = true
+		}
+	}
+}
+
+// playing reports whether a rumble is still playing at now
+func (p *feedbackPlayer) playing(now time.Time) bool {
+	return p.rumble != nil && now.Before(p.pulseStart(len(p.rumble.Rumble)))
+}
+
+// pulseStart returns when pulse i of the playing rumble starts: once every
+// earlier pulse and pause is over
+func (p *feedbackPlayer) pulseStart(i int) time.Time {
+	start := p.started
+	for _, pulse := range p.rumble.Rumble[:i] {
+		start = start.Add(time.Duration(pulse.Duration+pulse.Pause) * time.Millisecond)
+	}
+	return start
+}
+
+// setLED sets the light bar to a color, or turns it off for ""
+func (p *feedbackPlayer) setLED(text string) {
+	p.shown = text
+	var color ledColor
+	if text != "" {
+		var err error
+		if color, err = parseColor(text); err != nil {
+			utils.Logger.Warnf("Gamepad light bar: %v", err)
+			return
+		}
+	}
+	if err := p.device.SetLED(color.red, color.green, color.blue); err != nil {
+		utils.Logger.Warnf("Gamepad cannot set its light bar: %v", err)
+		p.ledFailed = true
+	}
+}
@@ -0,0 +1,188 @@
+package gamepad
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/conceptcodes/dji-tello-sdk-go/pkg/safety"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeFeedback records what a controller was told to do
+type fakeFeedback struct {
+	*ReplaySource
+	rumbles []RumblePulse
+	leds    []string
+	ledErr  error
+}
+
+func (f *fakeFeedback) Rumble(low, high float64, duration time.Duration) error {
+	f.rumbles = append(f.rumbles, RumblePulse{Low: low, High: high, Duration: int(duration.Milliseconds())})
+	return nil
+}
+
+func (f *fakeFeedback) SetLED(red, green, blue uint8) error {
+	if f.ledErr != nil {
+		return f.ledErr
+	}
+	f.leds = append(f.leds, fmt.Sprintf("#%02x%02x%02x", red, green, blue))
+	return nil
+}
+
+func testFeedbackConfig() *FeedbackConfig {
+	return &FeedbackConfig{
+		LED: "#0000ff",
+		Patterns: []FeedbackPattern{
+			{
+				Event: "battery",
+				Level: "warning",
+				Rumble: []RumblePulse{
+					{Low: 0.5, High: 0.2, Duration: 200, Pause: 100},
+					{Low: 0.5, High: 0.2, Duration: 200},
+				},
+				LED: "#ffa000",
+			},
+			{Event: "battery", Level: "critical", Rumble: []RumblePulse{{Low: 1, High: 1, Duration: 500}}, LED: "#ff0000"},
+			{Event: "sensor", Rumble: []RumblePulse{{High: 0.8, Duration: 300}}, LED: "#c000ff", Hold: 1000, Cooldown: 2000},
+		},
+	}
+}
+
+func TestParseColor(t *testing.T) {
+	color, err := parseColor("#FFa010")
+	require.NoError(t, err)
+	assert.Equal(t, ledColor{0xff, 0xa0, 0x10}, color)
+
+	for _, text := range []string{"ffa010", "#ffa01", "#ggaa00", ""} {
+		_, err := parseColor(text)
+		assert.Error(t, err, text)
+	}
+}
+
+func TestFeedbackPlayer(t *testing.T) {
+	start := time.Now()
+
+	t.Run("pulses play in turn and the pattern cools down", func(t *testing.T) {
+		device := &fakeFeedback{}
+		player := newFeedbackPlayer(testFeedbackConfig(), device)
+
+		player.play(start)
+		assert.Equal(t, []string{"#0000ff"}, device.leds, "The base color is set first")
+
+		player.trigger("battery", "warning", start)
+		player.play(start)
+		assert.Equal(t, []RumblePulse{{Low: 0.5, High: 0.2, Duration: 200}}, device.rumbles)
+		assert.Equal(t, []string{"#0000ff", "#ffa000"}, device.leds)
+
+		player.play(start.Add(250 * time.Millisecond))
+		assert.Len(t, device.rumbles, 1, "The pause is not over")
+		player.play(start.Add(300 * time.Millisecond))
+		assert.Len(t, device.rumbles, 2)
+
+		player.trigger("battery", "warning", start.Add(time.Second))
+		player.play(start.Add(time.Second))
+		assert.Len(t, device.rumbles, 2, "Repeated events wait for the cooldown")
+
+		player.trigger("battery", "warning", start.Add(11*time.Second))
+		player.play(start.Add(11 * time.Second))
+		assert.Len(t, device.rumbles, 3)
+	})
+
+	t.Run("worse events cut milder ones short", func(t *testing.T) {
+		device := &fakeFeedback{}
+		player := newFeedbackPlayer(testFeedbackConfig(), device)
+
+		player.trigger("battery", "critical", start)
+		player.play(start)
+		player.trigger("sensor", "warning", start.Add(100*time.Millisecond))
+		player.play(start.Add(100 * time.Millisecond))
+		assert.Equal(t, []RumblePulse{{Low: 1, High: 1, Duration: 500}}, device.rumbles, "A tilt waits for the battery pattern")
+		assert.Equal(t, []string{"#ff0000"}, device.leds, "The battery's color stays")
+
+		player.trigger("sensor", "warning", start.Add(time.Second))
+		player.play(start.Add(time.Second))
+		assert.Len(t, device.rumbles, 2, "Once it is over the tilt plays")
+	})
+
+	t.Run("held colors return to the base", func(t *testing.T) {
+		device := &fakeFeedback{}
+		player := newFeedbackPlayer(testFeedbackConfig(), device)
+
+		player.trigger("sensor", "warning", start)
+		player.play(start)
+		player.play(start.Add(900 * time.Millisecond))
+		assert.Equal(t, []string{"#c000ff"}, device.leds)
+		player.play(start.Add(1100 * time.Millisecond))
+		assert.Equal(t, []string{"#c000ff", "#0000ff"}, device.leds)
+	})
+
+	t.Run("unmatched events and failing light bars are ignored", func(t *testing.T) {
+		device := &fakeFeedback{ledErr: errors.New("no light bar")}
+		player := newFeedbackPlayer(testFeedbackConfig(), device)
+
+		player.trigger("altitude", "warning", start)
+		player.trigger("battery", "info", start)
+		player.play(start)
+		assert.Empty(t, device.rumbles)
+
+		player.trigger("battery", "critical", start)
+		player.play(start)
+		assert.Len(t, device.rumbles, 1)
+		assert.True(t, player.ledFailed)
+	})
+}
+
+func TestHandler_HandleSafetyEvent(t *testing.T) {
+	// Input that lasts an hour, so the handler keeps polling
+	now := time.Now()
+	device := &fakeFeedback{ReplaySource: NewReplaySource("script", []Event{
+		axisEvent(AxisLeftStickX, 0, now),
+		axisEvent(AxisLeftStickX, 0, now.Add(time.Hour)),
+	})}
+	config := DefaultConfig()
+	config.Feedback = testFeedbackConfig()
+
+	handler, err := NewHandler(HandlerOptions{Config: config, Source: NewRecordingSource(device, &bytes.Buffer{})})
+	require.NoError(t, err)
+	assert.True(t, handler.HasFeedback(), "Feedback is found through the recording")
+	require.NoError(t, handler.Start())
+	defer handler.Stop()
+
+	handler.HandleSafetyEvent(safety.NewSafetyEvent(safety.SafetyEventBattery, safety.SafetyEventLevelCritical, "Critical battery level", nil))
+	assert.Empty(t, device.rumbles, "Patterns play when the handler polls")
+	handler.ProcessEvents()
+	assert.Equal(t, []RumblePulse{{Low: 1, High: 1, Duration: 500}}, device.rumbles)
+	assert.Equal(t, []string{"#ff0000"}, device.leds)
+
+	config.Feedback = nil
+	handler, err = NewHandler(HandlerOptions{Config: config, Source: device})
+	require.NoError(t, err)
+	assert.False(t, handler.HasFeedback())
+	handler.HandleSafetyEvent(safety.NewSafetyEvent(safety.SafetyEventBattery, safety.SafetyEventLevelCritical, "Critical battery level", nil))
+}
+
+func TestLoadConfig_Feedback(t *testing.T) {
+	loader, err := NewConfigLoader()
+	if err != nil {
+		t.Skipf("Skipping test due to schema compilation error: %v", err)
+	}
+
+	for _, name := range []string{"gamepad-config.json", "gamepad-default.json"} {
+		config, err := loader.LoadConfig(filepath.Join("..", "..", "configs", name))
+		require.NoError(t, err, name)
+		assert.Equal(t, DefaultFeedback(), config.Feedback, "%s plays the default patterns", name)
+	}
+
+	config := DefaultConfig()
+	assert.NoError(t, loader.ValidateConfig(config))
+	config.Feedback.Patterns[0].LED = "red"
+	assert.Error(t, loader.ValidateConfig(config), "Colors are #rrggbb")
+	config.Feedback.Patterns[0].LED = ""
+	config.Feedback.Patterns[0].Event = "tilt"
+	assert.Error(t, loader.ValidateConfig(config), "Tilt warnings are sensor events")
+}
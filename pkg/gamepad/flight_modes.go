@@ -0,0 +1,160 @@
+package gamepad
+
+import (
+	"fmt"
+	"time"
+)
+
+// defaultCycleCombo is held to cycle flight modes when none is configured
+var defaultCycleCombo = []ButtonType{LeftBumper, RightBumper}
+
+// defaultModeIndex returns the index of the mode a config starts in
+func defaultModeIndex(config *Config) int {
+	if config == nil || config.FlightModes == nil {
+		return 0
+	}
+	for i, mode := range config.FlightModes.Modes {
+		if mode.Name == config.FlightModes.Default {
+			return i
+		}
+	}
+	return 0
+}
+
+// validateFlightModes checks what the schema cannot: that mode names are
+// unique and the default names one of them
+func validateFlightModes(modes *FlightModes) error {
+	if modes == nil {
+		return nil
+	}
+	names := make(map[string]bool, len(modes.Modes))
+	for _, mode := range modes.Modes {
+		if names[mode.Name] {
+			return fmt.Errorf("flight mode %q is defined twice", mode.Name)
+		}
+		names[mode.Name] = true
+	}
+	if modes.Default != "" && !names[modes.Default] {
+		return fmt.Errorf("default flight mode %q is not defined", modes.Default)
+	}
+	return nil
+}
+
+// FlightMode returns the name of the active flight mode, or "" when none
+// are configured
+func (m *DefaultMapper) FlightMode() string {
+	if mode := m.activeMode(); mode != nil {
+		return mode.Name
+	}
+	return ""
+}
+
+// SetFlightMode switches to the named flight mode
+func (m *DefaultMapper) SetFlightMode(name string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.config.FlightModes == nil {
+		return fmt.Errorf("no flight modes configured")
+	}
+	for i, mode := range m.config.FlightModes.Modes {
+		if mode.Name == name {
+			m.mode = i
+			return nil
+		}
+	}
+	return fmt.Errorf("unknown flight mode: %s", name)
+}
+
+// CycleFlightMode switches to the next flight mode and returns its name
+func (m *DefaultMapper) CycleFlightMode() string {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.cycleLocked()
+}
+
+func (m *DefaultMapper) cycleLocked() string {
+	if m.config.FlightModes == nil || len(m.config.FlightModes.Modes) == 0 {
+		return ""
+	}
+	modes := m.config.FlightModes.Modes
+	m.mode = (m.mode + 1) % len(modes)
+	return modes[m.mode].Name
+}
+
+// activeMode returns a copy of the active flight mode, or nil when none
+// are configured
+func (m *DefaultMapper) activeMode() *FlightMode {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.config.FlightModes == nil || m.mode >= len(m.config.FlightModes.Modes) {
+		return nil
+	}
+	mode := m.config.FlightModes.Modes[m.mode]
+	return &mode
+}
+
+// modeCombo returns the buttons that cycle the flight mode, or nil when
+// there is nothing to cycle through
+func (m *DefaultMapper) modeCombo() []ButtonType {
+	modes := m.config.FlightModes
+	if modes == nil || len(modes.Modes) < 2 {
+		return nil
+	}
+	if len(modes.CycleCombo) == 0 {
+		return defaultCycleCombo
+	}
+	return modes.CycleCombo
+}
+
+// inModeCombo reports whether button is part of the mode cycle combo
+func (m *DefaultMapper) inModeCombo(button ButtonType) bool {
+	for _, b := range m.modeCombo() {
+		if b == button {
+			return true
+		}
+	}
+	return false
+}
+
+// processModeCombo cycles the flight mode once each time every combo
+// button is down, and remembers the presses so their own actions stay quiet
+func (m *DefaultMapper) processModeCombo(state *GamepadState) string {
+	combo := m.modeCombo()
+	if len(combo) == 0 {
+		return ""
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	for _, button := range combo {
+		if b, ok := state.Buttons[button]; !ok || !b.Pressed {
+			m.comboHeld = false
+			return ""
+		}
+	}
+	if m.comboHeld {
+		return ""
+	}
+
+	m.comboHeld = true
+	for _, button := range combo {
+		m.comboUsed[button] = state.Buttons[button].PressTime
+	}
+	return m.cycleLocked()
+}
+
+// releasedOutsideCombo reports whether a combo button was pressed and
+// released on its own, for long enough to meet its hold time
+func (m *DefaultMapper) releasedOutsideCombo(button *ButtonState, mapping ButtonMapping) bool {
+	if button.Pressed || button.PressTime.IsZero() || !button.LastRelease.After(button.PressTime) {
+		return false
+	}
+	if button.LastRelease.Sub(button.PressTime) < time.Duration(mapping.HoldTime)*time.Millisecond {
+		return false
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return !m.comboUsed[mapping.Button].Equal(button.PressTime)
+}
@@ -0,0 +1,190 @@
+package gamepad
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestApplyCurves(t *testing.T) {
+	expo, superRate := 0.5, 0.5
+
+	t.Run("expo softens the center", func(t *testing.T) {
+		mapping := AxisMapping{Expo: &expo}
+		assert.InDelta(t, 0.3125, applyCurves(0.5, mapping), 1e-9)
+		assert.InDelta(t, -0.3125, applyCurves(-0.5, mapping), 1e-9)
+		assert.InDelta(t, 1.0, applyCurves(1.0, mapping), 1e-9)
+	})
+
+	t.Run("super rate steepens the outer stick", func(t *testing.T) {
+		mapping := AxisMapping{SuperRate: &superRate}
+		assert.InDelta(t, 1.0/3, applyCurves(0.5, mapping), 1e-9)
+		assert.InDelta(t, -1.0, applyCurves(-1.0, mapping), 1e-9)
+	})
+
+	t.Run("linear without curves", func(t *testing.T) {
+		assert.Equal(t, 0.5, applyCurves(0.5, AxisMapping{}))
+	})
+}
+
+func TestDefaultMapper_FlightModes(t *testing.T) {
+	stick := func(x float64) *GamepadState {
+		state := NewGamepadState()
+		state.Axes[AxisLeftStickX].Value = x
+		return state
+	}
+
+	t.Run("modes set the RC limits", func(t *testing.T) {
+		mapper := NewDefaultMapper(DefaultConfig())
+		assert.Equal(t, "normal", mapper.FlightMode())
+		assert.Equal(t, 80, mapper.processAxes(stick(1)).A)
+
+		require.NoError(t, mapper.SetFlightMode("sport"))
+		assert.Equal(t, 100, mapper.processAxes(stick(1)).A)
+
+		require.NoError(t, mapper.SetFlightMode("cinematic"))
+		assert.Equal(t, 30, mapper.processAxes(stick(1)).A)
+		assert.Equal(t, 10, mapper.processAxes(stick(0.5)).A, "Cinematic expo applies to every axis")
+
+		assert.Error(t, mapper.SetFlightMode("ludicrous"))
+		assert.Equal(t, "cinematic", mapper.FlightMode())
+
+		require.NoError(t, mapper.UpdateConfig(DefaultConfig()))
+		assert.Equal(t, "normal", mapper.FlightMode(), "A new config starts in its default mode")
+	})
+
+	t.Run("without modes the safety limits apply", func(t *testing.T) {
+		config := DefaultConfig()
+		config.FlightModes = nil
+		mapper := NewDefaultMapper(config)
+		assert.Equal(t, "", mapper.FlightMode())
+		assert.Equal(t, "", mapper.CycleFlightMode())
+		assert.Equal(t, 80, mapper.processAxes(stick(1)).A)
+	})
+
+	t.Run("slew rate limits RC changes", func(t *testing.T) {
+		config := DefaultConfig()
+		config.FlightModes.Modes[1].SlewRate = 120
+		mapper := NewDefaultMapper(config)
+		now := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+		mapper.now = func() time.Time { return now }
+
+		rcA := func(state *GamepadState) int {
+			commands, err := mapper.MapState(state)
+			require.NoError(t, err)
+			require.Len(t, commands, 1)
+			return commands[0].Data.(RCValues).A
+		}
+
+		assert.Equal(t, 2, rcA(stick(1)), "The first update moves one tick's worth")
+		now = now.Add(250 * time.Millisecond)
+		assert.Equal(t, 32, rcA(stick(1)))
+		now = now.Add(time.Second)
+		assert.Equal(t, 80, rcA(stick(1)))
+
+		// Back to center gets there gradually, then sends zeros once
+		now = now.Add(500 * time.Millisecond)
+		assert.Equal(t, 20, rcA(stick(0)))
+		now = now.Add(500 * time.Millisecond)
+		assert.Equal(t, 0, rcA(stick(0)))
+		commands, _ := mapper.MapState(stick(0))
+		assert.Empty(t, commands)
+	})
+
+	t.Run("smoothing eases toward the sticks", func(t *testing.T) {
+		config := DefaultConfig()
+		config.FlightModes.Modes[1].Smoothing = 0.5
+		mapper := NewDefaultMapper(config)
+		now := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+		mapper.now = func() time.Time { return now }
+
+		rc, send := mapper.shapeRC(mapper.axisTargets(stick(1)))
+		assert.True(t, send)
+		assert.Equal(t, 40, rc.A, "Half the way in 1/60 s")
+		now = now.Add(time.Second / 60)
+		mapper.shapeRC(mapper.axisTargets(stick(1)))
+		assert.InDelta(t, 60, mapper.rcOut[0], 0.01)
+		now = now.Add(time.Second)
+		rc, _ = mapper.shapeRC(mapper.axisTargets(stick(1)))
+		assert.Equal(t, 80, rc.A)
+	})
+}
+
+func TestDefaultMapper_ModeCombo(t *testing.T) {
+	pressed := func(state *GamepadState, button ButtonType, at time.Time) {
+		state.Buttons[button] = &ButtonState{Pressed: true, PressTime: at, TapCount: 1}
+	}
+	released := func(state *GamepadState, button ButtonType, at time.Time) {
+		state.Buttons[button].Pressed = false
+		state.Buttons[button].LastRelease = at
+	}
+	start := time.Now().Add(-time.Minute)
+
+	t.Run("both bumpers cycle the mode", func(t *testing.T) {
+		mapper := NewDefaultMapper(DefaultConfig())
+		state := NewGamepadState()
+
+		pressed(state, LeftBumper, start)
+		commands, err := mapper.MapEvent(Event{Type: EventButtonPress, Input: string(LeftBumper), Value: 1, Timestamp: start})
+		require.NoError(t, err)
+		assert.Empty(t, commands, "A combo button waits for its release")
+		commands, _ = mapper.MapState(state)
+		assert.Empty(t, commands)
+
+		pressed(state, RightBumper, start.Add(100*time.Millisecond))
+		commands, _ = mapper.MapState(state)
+		assert.Equal(t, []Command{{Type: CommandFlightMode, Data: FlightModeName("sport")}}, commands)
+		commands, _ = mapper.MapState(state)
+		assert.Empty(t, commands, "Holding the combo cycles once")
+
+		released(state, LeftBumper, start.Add(time.Second))
+		released(state, RightBumper, start.Add(time.Second))
+		commands, _ = mapper.MapState(state)
+		assert.Empty(t, commands, "Combo presses do not flip")
+
+		pressed(state, LeftBumper, start.Add(2*time.Second))
+		pressed(state, RightBumper, start.Add(2*time.Second))
+		commands, _ = mapper.MapState(state)
+		assert.Equal(t, []Command{{Type: CommandFlightMode, Data: FlightModeName("cinematic")}}, commands, "Cycling wraps around")
+	})
+
+	t.Run("a lone combo button acts on release", func(t *testing.T) {
+		mapper := NewDefaultMapper(DefaultConfig())
+		state := NewGamepadState()
+
+		pressed(state, RightBumper, start)
+		actions := mapper.processButtons(state)
+		assert.Empty(t, actions)
+
+		released(state, RightBumper, start.Add(200*time.Millisecond))
+		assert.Equal(t, actionCommands(ActionFlipRight), mapper.processButtons(state))
+		assert.Empty(t, mapper.processButtons(state), "A release triggers once")
+	})
+
+	t.Run("custom combo", func(t *testing.T) {
+		config := DefaultConfig()
+		config.FlightModes.CycleCombo = []ButtonType{ButtonStart}
+		mapper := NewDefaultMapper(config)
+		state := NewGamepadState()
+
+		pressed(state, ButtonStart, start)
+		commands, _ := mapper.MapState(state)
+		assert.Equal(t, []Command{{Type: CommandFlightMode, Data: FlightModeName("sport")}}, commands)
+		assert.False(t, mapper.inModeCombo(LeftBumper), "Bumpers flip at once again")
+	})
+}
+
+func TestValidateFlightModes(t *testing.T) {
+	assert.NoError(t, validateFlightModes(nil))
+	assert.NoError(t, validateFlightModes(DefaultFlightModes()))
+
+	modes := DefaultFlightModes()
+	modes.Default = "turbo"
+	assert.ErrorContains(t, validateFlightModes(modes), `default flight mode "turbo" is not defined`)
+
+	modes = DefaultFlightModes()
+	modes.Modes = append(modes.Modes, FlightMode{Name: "sport"})
+	assert.ErrorContains(t, validateFlightModes(modes), `flight mode "sport" is defined twice`)
+}
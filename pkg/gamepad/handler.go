@@ -1,12 +1,13 @@
 package gamepad
 
 import (
+	"errors"
 	"fmt"
 	"sync"
 	"time"
 
+	"github.com/conceptcodes/dji-tello-sdk-go/pkg/safety"
 	"github.com/conceptcodes/dji-tello-sdk-go/pkg/utils"
-	"github.com/veandco/go-sdl2/sdl"
 )
 
 // Handler manages gamepad input and converts it to drone commands
@@ -14,13 +15,12 @@ type Handler struct {
 	config    *Config
 	state     *GamepadState
 	mapper    Mapper
+	source    InputSource
 	isRunning bool
+	ended     bool            // The source ran out of input
+	feedback  *feedbackPlayer // Plays safety events on the controller, nil when it cannot
 	mu        sync.RWMutex
 
-	// SDL2 specific
-	gamepad  *sdl.GameController
-	joystick *sdl.Joystick
-
 	// Callbacks
 	onCommand func(Command)
 	onError   func(error)
@@ -34,6 +34,7 @@ type Handler struct {
 type HandlerOptions struct {
 	Config    *Config
 	Mapper    Mapper
+	Source    InputSource // Where input comes from (default: the first SDL2 game controller)
 	OnCommand func(Command)
 	OnError   func(error)
 }
@@ -50,15 +51,13 @@ func NewHandler(opts HandlerOptions) (*Handler, error) {
 		mapper = NewDefaultMapper(opts.Config)
 	}
 
-	// Initialize SDL2
-	// Note: SDL2 functions should generally be called from the main thread.
-	if err := sdl.Init(sdl.INIT_GAMECONTROLLER); err != nil {
-		return nil, fmt.Errorf("failed to initialize SDL2: %w", err)
-	}
-
-	// Load game controller mappings from database
-	if err := loadControllerMappings(); err != nil {
-		utils.Logger.Warnf("Failed to load controller mappings: %v", err)
+	source := opts.Source
+	if source == nil {
+		sdlSource, err := NewSDLSource()
+		if err != nil {
+			return nil, err
+		}
+		source = sdlSource
 	}
 
 	// Calculate update interval
@@ -68,17 +67,22 @@ func NewHandler(opts HandlerOptions) (*Handler, error) {
 		config:         opts.Config,
 		state:          NewGamepadState(),
 		mapper:         mapper,
+		source:         source,
 		updateInterval: updateInterval,
 		onCommand:      opts.OnCommand,
 		onError:        opts.OnError,
 	}
+	if device, ok := feedbackOf(source); ok && opts.Config.Feedback != nil {
+		handler.feedback = newFeedbackPlayer(opts.Config.Feedback, device)
+	}
 
-	utils.Logger.Info("Gamepad handler created with SDL2 support")
+	utils.Logger.Infof("Gamepad handler created for %s", source.Name())
 	return handler, nil
 }
 
-// Start initializes the gamepad connection.
-// Note: This does NOT start a background loop. You must call ProcessEvents() periodically from the main thread.
+// Start opens the input source.
+// Note: This does NOT start a background loop. You must call ProcessEvents() periodically,
+// from the main thread when reading an SDL2 controller.
 func (h *Handler) Start() error {
 	h.mu.Lock()
 	defer h.mu.Unlock()
@@ -87,19 +91,15 @@ func (h *Handler) Start() error {
 		return fmt.Errorf("handler is already running")
 	}
 
-	// Try to open the first available gamepad
-	var err error
-	sdl.Do(func() {
-		err = h.openGamepad()
-	})
-	if err != nil {
+	if err := h.source.Open(); err != nil {
 		return fmt.Errorf("failed to open gamepad: %w", err)
 	}
 
 	h.isRunning = true
+	h.ended = false
 
 	utils.Logger.Infof("Starting gamepad handler with %d Hz update rate", h.config.Controller.UpdateRate)
-	utils.Logger.Infof("Connected to gamepad: %s", h.gamepad.Name())
+	utils.Logger.Infof("Reading input from: %s", h.source.Name())
 
 	return nil
 }
@@ -115,17 +115,9 @@ func (h *Handler) Stop() error {
 
 	h.isRunning = false
 
-	// Close gamepad and cleanup SDL2
-	sdl.Do(func() {
-		if h.gamepad != nil {
-			h.gamepad.Close()
-			h.gamepad = nil
-		}
-		if h.joystick != nil {
-			h.joystick.Close()
-			h.joystick = nil
-		}
-	})
+	if err := h.source.Close(); err != nil {
+		utils.Logger.Warnf("Failed to close gamepad input: %v", err)
+	}
 
 	utils.Logger.Info("Gamepad handler stopped")
 	return nil
@@ -165,330 +157,133 @@ func (h *Handler) GetState() *GamepadState {
 	return stateCopy
 }
 
+// Ended reports whether the input source ran out of input, e.g. at the end
+// of a replay. ProcessEvents does nothing once it has.
+func (h *Handler) Ended() bool {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+	return h.ended
+}
+
 // ProcessEvents processes gamepad input events.
-// This MUST be called from the main thread.
+// This MUST be called from the main thread when reading an SDL2 controller.
 func (h *Handler) ProcessEvents() {
-	if h.gamepad == nil {
+	if !h.IsRunning() || h.Ended() {
 		return
 	}
 
-	sdl.Do(func() {
-		// Update SDL2 events
-		for event := sdl.PollEvent(); event != nil; event = sdl.PollEvent() {
-			switch e := event.(type) {
-			case *sdl.ControllerAxisEvent:
-				h.handleAxisEvent(e)
-			case *sdl.ControllerButtonEvent:
-				h.handleButtonEvent(e)
-			case *sdl.ControllerDeviceEvent:
-				h.handleDeviceEvent(e)
-			}
-		}
-
-		// Process continuous axes (like joysticks)
-		h.processAxes()
-	})
+	events, err := h.source.Poll()
+	for _, event := range events {
+		h.handleEvent(event)
+	}
+	if errors.Is(err, ErrInputEnded) {
+		utils.Logger.Infof("Stopped reading gamepad input: %v", err)
+		h.mu.Lock()
+		h.ended = true
+		// Let go of everything, so nothing stays held
+		h.state = NewGamepadState()
+		h.mu.Unlock()
+		return
+	} else if err != nil {
+		h.reportError(fmt.Errorf("failed to read gamepad input: %w", err))
+	}
 
 	// Generate commands from current state
+	h.mu.RLock()
 	commands, err := h.mapper.MapState(h.state)
+	h.mu.RUnlock()
 	if err != nil {
-		h.onError(fmt.Errorf("failed to map state to commands: %w", err))
+		h.reportError(fmt.Errorf("failed to map state to commands: %w", err))
 	} else {
 		for _, cmd := range commands {
 			h.triggerCommand(cmd)
 		}
 	}
 
-	h.state.LastUpdate = time.Now()
-	h.lastUpdate = time.Now()
-}
-
-// triggerCommand calls the command callback
-func (h *Handler) triggerCommand(command Command) {
-	if h.onCommand != nil {
-		utils.Logger.Debugf("Triggering command: %v", command)
-		h.onCommand(command)
-	}
-}
-
-// ListGamepads returns a list of available gamepads
-func ListGamepads() []string {
-	var gamepads []string
-
-	numJoysticks := sdl.NumJoysticks()
-	for i := 0; i < numJoysticks; i++ {
-		if sdl.IsGameController(i) {
-			name := sdl.GameControllerNameForIndex(i)
-			gamepads = append(gamepads, fmt.Sprintf("%d: %s", i, name))
-		}
-	}
-
-	return gamepads
-}
-
-// GetGamepadInfo returns information about available gamepads
-func GetGamepadInfo() []map[string]string {
-	var gamepads []map[string]string
-
-	numJoysticks := sdl.NumJoysticks()
-	for i := 0; i < numJoysticks; i++ {
-		info := map[string]string{
-			"id":   fmt.Sprintf("%d", i),
-			"name": sdl.JoystickNameForIndex(i),
-		}
-
-		if sdl.IsGameController(i) {
-			info["type"] = "GameController"
-			info["mapping"] = sdl.GameControllerMappingForDeviceIndex(i)
-		} else {
-			info["type"] = "Joystick"
-		}
-
-		gamepads = append(gamepads, info)
-	}
-
-	return gamepads
-}
-
-// openGamepad opens the first available gamepad
-func (h *Handler) openGamepad() error {
-	numJoysticks := sdl.NumJoysticks()
-	if numJoysticks == 0 {
-		return fmt.Errorf("no gamepads connected")
+	if h.feedback != nil {
+		h.feedback.play(time.Now())
 	}
 
-	// Try to open the first available game controller
-	for i := 0; i < numJoysticks; i++ {
-		if sdl.IsGameController(i) {
-			gamepad := sdl.GameControllerOpen(i)
-			if gamepad == nil {
-				utils.Logger.Warnf("Failed to open gamepad %d", i)
-				continue
-			}
-
-			h.gamepad = gamepad
-			h.joystick = gamepad.Joystick()
-
-			utils.Logger.Infof("Connected to gamepad: %s", gamepad.Name())
-			return nil
-		}
-	}
-
-	return fmt.Errorf("no compatible gamepads found")
+	h.mu.Lock()
+	h.state.LastUpdate = time.Now()
+	h.lastUpdate = time.Now()
+	h.mu.Unlock()
 }
 
-// loadControllerMappings loads game controller database mappings
-func loadControllerMappings() error {
-	// SDL2 automatically loads system game controller database
-	// Additional mappings can be loaded here if needed
-	return nil
+// HasFeedback reports whether the controller can signal safety events, i.e.
+// the source can rumble or light it and the config has feedback patterns
+func (h *Handler) HasFeedback() bool {
+	return h.feedback != nil
 }
 
-// handleAxisEvent processes controller axis movement
-func (h *Handler) handleAxisEvent(event *sdl.ControllerAxisEvent) {
-	axisName := h.getAxisName(sdl.GameControllerAxis(event.Axis))
-	if axisName == "" {
+// HandleSafetyEvent plays the feedback pattern configured for a safety
+// event on the next ProcessEvents. It can be passed directly to
+// SafetyManager.AddEventListener.
+func (h *Handler) HandleSafetyEvent(event *safety.SafetyEvent) {
+	if h.feedback == nil || event == nil {
 		return
 	}
-
-	// Normalize axis value to [-1.0, 1.0]
-	value := float32(event.Value) / 32767.0
-	if value < -1.0 {
-		value = -1.0
-	} else if value > 1.0 {
-		value = 1.0
-	}
-
-	h.updateAxis(axisName, value)
-
-	// Generate event for mapper
-	gamepadEvent := Event{
-		Type:      EventAxisChange,
-		Input:     axisName,
-		Value:     float64(value),
-		Timestamp: time.Now(),
-	}
-
-	commands, err := h.mapper.MapEvent(gamepadEvent)
-	if err != nil {
-		h.onError(fmt.Errorf("failed to map axis event: %w", err))
-	} else {
-		for _, cmd := range commands {
-			h.triggerCommand(cmd)
-		}
-	}
+	h.feedback.trigger(event.Type, event.Level, time.Now())
 }
 
-// handleButtonEvent processes controller button presses
-func (h *Handler) handleButtonEvent(event *sdl.ControllerButtonEvent) {
-	buttonName := h.getButtonName(sdl.GameControllerButton(event.Button))
-	if buttonName == "" {
-		return
-	}
-
-	pressed := event.State == sdl.PRESSED
-	h.updateButton(buttonName, pressed)
-
-	// Generate event for mapper
-	var eventType EventType
-	var value float64
-	if pressed {
-		eventType = EventButtonPress
-		value = 1.0
-	} else {
-		eventType = EventButtonRelease
-		value = 0.0
+// handleEvent applies an input event to the gamepad state and maps it
+func (h *Handler) handleEvent(event Event) {
+	if event.Timestamp.IsZero() {
+		event.Timestamp = time.Now()
 	}
 
-	gamepadEvent := Event{
-		Type:      eventType,
-		Input:     buttonName,
-		Value:     value,
-		Timestamp: time.Now(),
-	}
-
-	commands, err := h.mapper.MapEvent(gamepadEvent)
-	if err != nil {
-		h.onError(fmt.Errorf("failed to map button event: %w", err))
-	} else {
-		for _, cmd := range commands {
-			h.triggerCommand(cmd)
-		}
-	}
-}
-
-// handleDeviceEvent processes controller connection/disconnection
-func (h *Handler) handleDeviceEvent(event *sdl.ControllerDeviceEvent) {
 	switch event.Type {
-	case sdl.CONTROLLERDEVICEADDED:
-		utils.Logger.Infof("Gamepad connected: %d", event.Which)
-		if h.gamepad == nil {
-			h.openGamepad()
-		}
-	case sdl.CONTROLLERDEVICEREMOVED:
-		utils.Logger.Infof("Gamepad disconnected: %d", event.Which)
-		if h.gamepad != nil && h.gamepad.Joystick().InstanceID() == event.Which {
-			h.gamepad.Close()
-			h.gamepad = nil
-			h.joystick = nil
-		}
+	case EventButtonPress, EventButtonRelease:
+		h.updateButton(ButtonType(event.Input), event.Type == EventButtonPress, event.Timestamp)
+	case EventAxisChange:
+		h.updateAxis(AxisType(event.Input), event.Value)
+	default:
+		return
 	}
-}
 
-// processAxes processes continuous axis values
-func (h *Handler) processAxes() {
-	if h.gamepad == nil {
+	commands, err := h.mapper.MapEvent(event)
+	if err != nil {
+		h.reportError(fmt.Errorf("failed to map %s event: %w", event.Type, err))
 		return
 	}
-
-	// Process all known axes
-	axes := []sdl.GameControllerAxis{
-		sdl.CONTROLLER_AXIS_LEFTX,
-		sdl.CONTROLLER_AXIS_LEFTY,
-		sdl.CONTROLLER_AXIS_RIGHTX,
-		sdl.CONTROLLER_AXIS_RIGHTY,
-		sdl.CONTROLLER_AXIS_TRIGGERLEFT,
-		sdl.CONTROLLER_AXIS_TRIGGERRIGHT,
-	}
-
-	for _, axis := range axes {
-		axisName := h.getAxisName(axis)
-		if axisName == "" {
-			continue
-		}
-
-		value := h.gamepad.Axis(axis)
-		normalizedValue := float32(value) / 32767.0
-		if normalizedValue < -1.0 {
-			normalizedValue = -1.0
-		} else if normalizedValue > 1.0 {
-			normalizedValue = 1.0
-		}
-
-		h.updateAxis(axisName, normalizedValue)
+	for _, cmd := range commands {
+		h.triggerCommand(cmd)
 	}
 }
 
-// getAxisName converts SDL axis enum to configuration axis name
-func (h *Handler) getAxisName(axis sdl.GameControllerAxis) string {
-	switch axis {
-	case sdl.CONTROLLER_AXIS_LEFTX:
-		return "left_x"
-	case sdl.CONTROLLER_AXIS_LEFTY:
-		return "left_y"
-	case sdl.CONTROLLER_AXIS_RIGHTX:
-		return "right_x"
-	case sdl.CONTROLLER_AXIS_RIGHTY:
-		return "right_y"
-	case sdl.CONTROLLER_AXIS_TRIGGERLEFT:
-		return "left_trigger"
-	case sdl.CONTROLLER_AXIS_TRIGGERRIGHT:
-		return "right_trigger"
-	default:
-		return ""
+// reportError calls the error callback
+func (h *Handler) reportError(err error) {
+	if h.onError != nil {
+		h.onError(err)
 	}
 }
 
-// getButtonName converts SDL button enum to configuration button name
-func (h *Handler) getButtonName(button sdl.GameControllerButton) string {
-	switch button {
-	case sdl.CONTROLLER_BUTTON_A:
-		return "a"
-	case sdl.CONTROLLER_BUTTON_B:
-		return "b"
-	case sdl.CONTROLLER_BUTTON_X:
-		return "x"
-	case sdl.CONTROLLER_BUTTON_Y:
-		return "y"
-	case sdl.CONTROLLER_BUTTON_BACK:
-		return "back"
-	case sdl.CONTROLLER_BUTTON_GUIDE:
-		return "guide"
-	case sdl.CONTROLLER_BUTTON_START:
-		return "start"
-	case sdl.CONTROLLER_BUTTON_LEFTSTICK:
-		return "left_stick"
-	case sdl.CONTROLLER_BUTTON_RIGHTSTICK:
-		return "right_stick"
-	case sdl.CONTROLLER_BUTTON_LEFTSHOULDER:
-		return "left_shoulder"
-	case sdl.CONTROLLER_BUTTON_RIGHTSHOULDER:
-		return "right_shoulder"
-	case sdl.CONTROLLER_BUTTON_DPAD_UP:
-		return "dpad_up"
-	case sdl.CONTROLLER_BUTTON_DPAD_DOWN:
-		return "dpad_down"
-	case sdl.CONTROLLER_BUTTON_DPAD_LEFT:
-		return "dpad_left"
-	case sdl.CONTROLLER_BUTTON_DPAD_RIGHT:
-		return "dpad_right"
-	default:
-		return ""
+// triggerCommand calls the command callback
+func (h *Handler) triggerCommand(command Command) {
+	if h.onCommand != nil {
+		utils.Logger.Debugf("Triggering command: %v", command)
+		h.onCommand(command)
 	}
 }
 
 // updateAxis updates axis state in the gamepad state
-func (h *Handler) updateAxis(name string, value float32) {
+func (h *Handler) updateAxis(axisType AxisType, value float64) {
 	h.mu.Lock()
 	defer h.mu.Unlock()
 
-	axisType := AxisType(name)
 	if _, exists := h.state.Axes[axisType]; !exists {
 		h.state.Axes[axisType] = &AxisState{}
 	}
 
 	h.state.Axes[axisType].LastValue = h.state.Axes[axisType].Value
-	h.state.Axes[axisType].Value = float64(value)
+	h.state.Axes[axisType].Value = value
 }
 
 // updateButton updates button state in the gamepad state
-func (h *Handler) updateButton(name string, pressed bool) {
+func (h *Handler) updateButton(buttonType ButtonType, pressed bool, now time.Time) {
 	h.mu.Lock()
 	defer h.mu.Unlock()
 
-	now := time.Now()
-	buttonType := ButtonType(name)
-
 	if _, exists := h.state.Buttons[buttonType]; !exists {
 		h.state.Buttons[buttonType] = &ButtonState{}
 	}
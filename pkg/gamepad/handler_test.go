@@ -2,6 +2,7 @@ package gamepad
 
 import (
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
@@ -85,17 +86,86 @@ func TestHandlerStateManagement(t *testing.T) {
 }
 
 func TestHandlerStartStop(t *testing.T) {
-	t.Run("start handler without gamepad", func(t *testing.T) {
-		t.Skip("Skipping test due to SDL2 initialization requirements")
+	newHandler := func(t *testing.T) *Handler {
+		handler, err := NewHandler(HandlerOptions{
+			Config: DefaultConfig(),
+			Source: NewReplaySource("empty", nil),
+		})
+		require.NoError(t, err)
+		return handler
+	}
+
+	t.Run("start and stop handler", func(t *testing.T) {
+		handler := newHandler(t)
+		require.NoError(t, handler.Start())
+		assert.True(t, handler.IsRunning())
+		require.NoError(t, handler.Stop())
+		assert.False(t, handler.IsRunning())
 	})
 
 	t.Run("double start should fail", func(t *testing.T) {
-		t.Skip("Skipping test due to SDL2 initialization requirements")
+		handler := newHandler(t)
+		require.NoError(t, handler.Start())
+		assert.Error(t, handler.Start())
 	})
 
 	t.Run("stop when not running should fail", func(t *testing.T) {
-		t.Skip("Skipping test due to SDL2 initialization requirements")
+		handler := newHandler(t)
+		assert.Error(t, handler.Stop())
+	})
+}
+
+func TestHandlerWithScriptedInput(t *testing.T) {
+	// Recorded a minute ago, so hold times have long passed by the time
+	// the mapper checks them
+	recorded := time.Now().Add(-time.Minute)
+	script := NewReplaySource("script", []Event{
+		axisEvent(AxisLeftStickX, 1.0, recorded),
+		buttonEvent(ButtonX, true, recorded),
+		buttonEvent(ButtonX, false, recorded.Add(100*time.Millisecond)),
+		buttonEvent(ButtonA, true, recorded.Add(time.Second)),
+		buttonEvent(ButtonA, false, recorded.Add(2*time.Second)),
+	})
+	clock := recorded
+	script.now = func() time.Time { return clock }
+
+	var commands []Command
+	handler, err := NewHandler(HandlerOptions{
+		Config: DefaultConfig(),
+		Source: script,
+		OnCommand: func(cmd Command) {
+			commands = append(commands, cmd)
+		},
+		OnError: func(err error) {
+			t.Errorf("unexpected error: %v", err)
+		},
 	})
+	require.NoError(t, err)
+	require.NoError(t, handler.Start())
+	defer handler.Stop()
+
+	handler.ProcessEvents()
+	assert.Contains(t, commands, Command{Type: CommandAction, Data: ActionFlipForward})
+	assert.Contains(t, commands, Command{Type: CommandRC, Data: RCValues{A: 80}})
+	assert.True(t, handler.GetState().Buttons[ButtonX].Pressed)
+
+	commands = nil
+	clock = recorded.Add(time.Second)
+	handler.ProcessEvents()
+	assert.False(t, handler.GetState().Buttons[ButtonX].Pressed)
+	assert.True(t, handler.GetState().Buttons[ButtonA].Pressed)
+	assert.Contains(t, commands, Command{Type: CommandAction, Data: ActionTakeoff}, "A was held past its hold time")
+
+	clock = recorded.Add(time.Hour)
+	handler.ProcessEvents()
+	assert.False(t, handler.Ended())
+	handler.ProcessEvents()
+	assert.True(t, handler.Ended())
+	assert.Zero(t, handler.GetState().Axes[AxisLeftStickX].Value, "Nothing stays held after the input ends")
+
+	commands = nil
+	handler.ProcessEvents()
+	assert.Empty(t, commands)
 }
 
 func TestHandlerUtilityFunctions(t *testing.T) {
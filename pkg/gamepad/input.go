@@ -0,0 +1,62 @@
+package gamepad
+
+import (
+	"errors"
+	"time"
+)
+
+// ErrInputEnded is returned by an InputSource that has no more input to
+// give, e.g. a replay that played to its end or a device that went away
+var ErrInputEnded = errors.New("gamepad input ended")
+
+// InputSource delivers gamepad input to a Handler as normalized events.
+// Buttons and axes are named by ButtonType and AxisType, axis values run
+// from -1.0 to 1.0 (0.0 to 1.0 for triggers) and buttons are 1.0 or 0.0.
+type InputSource interface {
+	// Open connects to the input
+	Open() error
+
+	// Poll returns the events since the last call without blocking. It
+	// returns ErrInputEnded once the input is gone for good.
+	Poll() ([]Event, error)
+
+	// Name describes the input, e.g. the controller's name
+	Name() string
+
+	// Close disconnects from the input
+	Close() error
+}
+
+// buttonEvent creates the event for a button being pressed or released
+func buttonEvent(button ButtonType, pressed bool, timestamp time.Time) Event {
+	event := Event{
+		Type:      EventButtonRelease,
+		Input:     string(button),
+		Timestamp: timestamp,
+	}
+	if pressed {
+		event.Type = EventButtonPress
+		event.Value = 1.0
+	}
+	return event
+}
+
+// axisEvent creates the event for an axis moving
+func axisEvent(axis AxisType, value float64, timestamp time.Time) Event {
+	return Event{
+		Type:      EventAxisChange,
+		Input:     string(axis),
+		Value:     value,
+		Timestamp: timestamp,
+	}
+}
+
+// clampAxis limits an axis value to [-1.0, 1.0]
+func clampAxis(value float64) float64 {
+	if value < -1.0 {
+		return -1.0
+	} else if value > 1.0 {
+		return 1.0
+	}
+	return value
+}
@@ -0,0 +1,367 @@
+package gamepad
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/conceptcodes/dji-tello-sdk-go/pkg/utils"
+)
+
+// Event types and codes from linux/input-event-codes.h
+const (
+	evSyn = 0x00
+	evKey = 0x01
+	evAbs = 0x03
+
+	synDropped = 0x03
+
+	btnSouth  = 0x130 // BTN_A
+	btnEast   = 0x131 // BTN_B
+	btnNorth  = 0x133 // BTN_X
+	btnWest   = 0x134 // BTN_Y
+	btnTL     = 0x136
+	btnTR     = 0x137
+	btnTL2    = 0x138
+	btnTR2    = 0x139
+	btnSelect = 0x13a
+	btnStart  = 0x13b
+	btnThumbL = 0x13d
+	btnThumbR = 0x13e
+	btnDUp    = 0x220
+	btnDDown  = 0x221
+	btnDLeft  = 0x222
+	btnDRight = 0x223
+
+	absX     = 0x00
+	absY     = 0x01
+	absZ     = 0x02
+	absRX    = 0x03
+	absRY    = 0x04
+	absRZ    = 0x05
+	absHat0X = 0x10
+	absHat0Y = 0x11
+)
+
+// evdevEventSize is the size of a struct input_event: a struct timeval of
+// two longs, then a u16 type, a u16 code and an s32 value
+var evdevEventSize = 2*strconv.IntSize/8 + 8
+
+// evdevJoystickGlob matches the event devices udev links for joysticks
+const evdevJoystickGlob = "/dev/input/by-id/*-event-joystick"
+
+// evdevButtons maps key codes to buttons, following the kernel's gamepad
+// layout (Documentation/input/gamepad.rst) as the xpad driver reports it
+var evdevButtons = map[uint16]ButtonType{
+	btnSouth:  ButtonA,
+	btnEast:   ButtonB,
+	btnNorth:  ButtonX,
+	btnWest:   ButtonY,
+	btnTL:     LeftBumper,
+	btnTR:     RightBumper,
+	btnTL2:    LeftTrigger,
+	btnTR2:    RightTrigger,
+	btnSelect: ButtonSelect,
+	btnStart:  ButtonStart,
+	btnThumbL: LeftStickButton,
+	btnThumbR: RightStickButton,
+	btnDUp:    DPadUp,
+	btnDDown:  DPadDown,
+	btnDLeft:  DPadLeft,
+	btnDRight: DPadRight,
+}
+
+// evdevAxes maps absolute axis codes to axes
+var evdevAxes = map[uint16]AxisType{
+	absX:     AxisLeftStickX,
+	absY:     AxisLeftStickY,
+	absZ:     AxisLeftTrigger,
+	absRX:    AxisRightStickX,
+	absRY:    AxisRightStickY,
+	absRZ:    AxisRightTrigger,
+	absHat0X: AxisDPadX,
+	absHat0Y: AxisDPadY,
+}
+
+// absRange is the range an absolute axis reports
+type absRange struct {
+	min, max int32
+}
+
+// defaultAbsRange is assumed for an axis whose range cannot be read
+func defaultAbsRange(code uint16) absRange {
+	switch code {
+	case absZ, absRZ:
+		return absRange{0, 255}
+	case absHat0X, absHat0Y:
+		return absRange{-1, 1}
+	default:
+		return absRange{-32768, 32767}
+	}
+}
+
+// EvdevDevice describes a Linux input device
+type EvdevDevice struct {
+	Path string
+	Name string
+}
+
+// ListEvdevDevices returns the joysticks under /dev/input
+func ListEvdevDevices() ([]EvdevDevice, error) {
+	paths, err := filepath.Glob(evdevJoystickGlob)
+	if err != nil {
+		return nil, err
+	}
+
+	devices := make([]EvdevDevice, 0, len(paths))
+	for _, path := range paths {
+		device := EvdevDevice{Path: path}
+		if f, err := os.Open(path); err == nil {
+			device.Name, _ = evdevName(f)
+			f.Close()
+		}
+		devices = append(devices, device)
+	}
+	return devices, nil
+}
+
+// EvdevSource reads a gamepad straight from a Linux input device
+// (/dev/input/event*), so it needs neither SDL2 nor a display. The user
+// must be able to read the device, which usually means the input group.
+type EvdevSource struct {
+	path string
+	name string
+	file *os.File
+
+	mu      sync.Mutex
+	decoder *evdevDecoder
+	pending []Event
+	err     error // Why reading stopped
+}
+
+// NewEvdevSource creates a source for the device at path. An empty path
+// picks the first joystick under /dev/input/by-id.
+func NewEvdevSource(path string) *EvdevSource {
+	return &EvdevSource{path: path}
+}
+
+// Open opens the device and starts reading it
+func (s *EvdevSource) Open() error {
+	path := s.path
+	if path == "" {
+		devices, err := ListEvdevDevices()
+		if err != nil {
+			return err
+		}
+		if len(devices) == 0 {
+			return fmt.Errorf("no joysticks found matching %s", evdevJoystickGlob)
+		}
+		path = devices[0].Path
+	}
+
+	f, err := os.Open(path)
+	if err != nil {
+		return fmt.Errorf("failed to open input device: %w", err)
+	}
+
+	name, err := evdevName(f)
+	if err != nil || name == "" {
+		name = path
+	}
+
+	ranges := make(map[uint16]absRange, len(evdevAxes))
+	for code := range evdevAxes {
+		r, err := evdevAbsRange(f, code)
+		if err != nil || r.max <= r.min {
+			r = defaultAbsRange(code)
+		}
+		ranges[code] = r
+	}
+
+	s.mu.Lock()
+	s.path = path
+	s.name = name
+	s.file = f
+	s.decoder = newEvdevDecoder(ranges)
+	s.pending = nil
+	s.err = nil
+	s.mu.Unlock()
+
+	go s.read(f)
+
+	utils.Logger.Infof("Reading %s from %s", name, path)
+	return nil
+}
+
+// Poll returns the events read since the last call
+func (s *EvdevSource) Poll() ([]Event, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	events := s.pending
+	s.pending = nil
+	if len(events) == 0 && s.err != nil {
+		return nil, s.err
+	}
+	return events, nil
+}
+
+// Name returns the device's name
+func (s *EvdevSource) Name() string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.name == "" {
+		return "evdev " + s.path
+	}
+	return s.name
+}
+
+// Close closes the device, which stops the reader
+func (s *EvdevSource) Close() error {
+	s.mu.Lock()
+	f := s.file
+	s.file = nil
+	s.mu.Unlock()
+
+	if f == nil {
+		return nil
+	}
+	return f.Close()
+}
+
+// read decodes the device's events until it fails, e.g. when unplugged
+func (s *EvdevSource) read(r io.Reader) {
+	buf := make([]byte, evdevEventSize*64)
+	for {
+		n, err := io.ReadAtLeast(r, buf, evdevEventSize)
+		// The kernel only hands out whole events
+		n -= n % evdevEventSize
+
+		s.mu.Lock()
+		for off := 0; off < n; off += evdevEventSize {
+			s.pending = append(s.pending, s.decoder.decode(buf[off:off+evdevEventSize])...)
+		}
+		if err != nil {
+			if !errors.Is(err, os.ErrClosed) {
+				utils.Logger.Warnf("Stopped reading %s: %v", s.path, err)
+			}
+			s.err = fmt.Errorf("%w: %v", ErrInputEnded, err)
+		}
+		s.mu.Unlock()
+
+		if err != nil {
+			return
+		}
+	}
+}
+
+// evdevDecoder turns raw input events into gamepad events
+type evdevDecoder struct {
+	ranges map[uint16]absRange
+	hat    map[uint16]int32 // Last value of each hat axis
+}
+
+func newEvdevDecoder(ranges map[uint16]absRange) *evdevDecoder {
+	return &evdevDecoder{
+		ranges: ranges,
+		hat:    make(map[uint16]int32),
+	}
+}
+
+// decode converts one struct input_event
+func (d *evdevDecoder) decode(raw []byte) []Event {
+	long := strconv.IntSize / 8
+	sec := readLong(raw[0:long])
+	usec := readLong(raw[long : 2*long])
+	timestamp := time.Unix(sec, usec*int64(time.Microsecond))
+
+	typ := binary.NativeEndian.Uint16(raw[2*long:])
+	code := binary.NativeEndian.Uint16(raw[2*long+2:])
+	value := int32(binary.NativeEndian.Uint32(raw[2*long+4:]))
+
+	switch typ {
+	case evKey:
+		button, ok := evdevButtons[code]
+		if !ok || value == 2 { // Autorepeat
+			return nil
+		}
+		return []Event{buttonEvent(button, value != 0, timestamp)}
+
+	case evAbs:
+		axis, ok := evdevAxes[code]
+		if !ok {
+			return nil
+		}
+		events := []Event{axisEvent(axis, d.normalize(code, value), timestamp)}
+		if code == absHat0X || code == absHat0Y {
+			events = append(events, d.hatButtons(code, value, timestamp)...)
+		}
+		return events
+
+	case evSyn:
+		if code == synDropped {
+			utils.Logger.Warn("Input device dropped events; gamepad state may lag until the next change")
+		}
+	}
+	return nil
+}
+
+// normalize scales an axis value to [-1.0, 1.0], or [0.0, 1.0] for triggers
+func (d *evdevDecoder) normalize(code uint16, value int32) float64 {
+	r, ok := d.ranges[code]
+	if !ok {
+		r = defaultAbsRange(code)
+	}
+	if code == absHat0X || code == absHat0Y {
+		// Hats report -1, 0 or 1 however wide their range
+		switch {
+		case value < 0:
+			return -1.0
+		case value > 0:
+			return 1.0
+		default:
+			return 0.0
+		}
+	}
+
+	scaled := float64(value-r.min) / float64(r.max-r.min)
+	if code == absZ || code == absRZ {
+		return clampAxis(scaled)
+	}
+	return clampAxis(scaled*2 - 1)
+}
+
+// hatButtons reports a hat's movement as D-pad buttons too, since some
+// controllers have no D-pad buttons of their own
+func (d *evdevDecoder) hatButtons(code uint16, value int32, timestamp time.Time) []Event {
+	negative, positive := DPadLeft, DPadRight
+	if code == absHat0Y {
+		negative, positive = DPadUp, DPadDown
+	}
+
+	last := d.hat[code]
+	d.hat[code] = value
+
+	var events []Event
+	if (last < 0) != (value < 0) {
+		events = append(events, buttonEvent(negative, value < 0, timestamp))
+	}
+	if (last > 0) != (value > 0) {
+		events = append(events, buttonEvent(positive, value > 0, timestamp))
+	}
+	return events
+}
+
+// readLong reads a C long
+func readLong(b []byte) int64 {
+	if len(b) == 8 {
+		return int64(binary.NativeEndian.Uint64(b))
+	}
+	return int64(int32(binary.NativeEndian.Uint32(b)))
+}
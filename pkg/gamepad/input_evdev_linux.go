@@ -0,0 +1,69 @@
+package gamepad
+
+import (
+	"os"
+	"strings"
+	"syscall"
+	"unsafe"
+)
+
+// ioctl request encoding from asm-generic/ioctl.h
+const (
+	iocRead      = 2
+	iocNRShift   = 0
+	iocTypeShift = 8
+	iocSizeShift = 16
+	iocDirShift  = 30
+)
+
+func evdevIOR(nr, size uintptr) uintptr {
+	return iocRead<<iocDirShift | size<<iocSizeShift | 'E'<<iocTypeShift | nr<<iocNRShift
+}
+
+// inputAbsinfo mirrors struct input_absinfo
+type inputAbsinfo struct {
+	Value      int32
+	Minimum    int32
+	Maximum    int32
+	Fuzz       int32
+	Flat       int32
+	Resolution int32
+}
+
+// evdevName reads the device's name (EVIOCGNAME)
+func evdevName(f *os.File) (string, error) {
+	buf := make([]byte, 256)
+	if err := evdevIoctl(f, evdevIOR(0x06, uintptr(len(buf))), unsafe.Pointer(&buf[0])); err != nil {
+		return "", err
+	}
+	return strings.TrimRight(string(buf), "\x00"), nil
+}
+
+// evdevAbsRange reads an absolute axis's range (EVIOCGABS)
+func evdevAbsRange(f *os.File, code uint16) (absRange, error) {
+	var info inputAbsinfo
+	req := evdevIOR(0x40+uintptr(code), unsafe.Sizeof(info))
+	if err := evdevIoctl(f, req, unsafe.Pointer(&info)); err != nil {
+		return absRange{}, err
+	}
+	return absRange{min: info.Minimum, max: info.Maximum}, nil
+}
+
+// evdevIoctl runs an ioctl without taking the file out of non-blocking
+// mode, as Fd would, so Close still interrupts a pending read
+func evdevIoctl(f *os.File, req uintptr, arg unsafe.Pointer) error {
+	conn, err := f.SyscallConn()
+	if err != nil {
+		return err
+	}
+	var errno syscall.Errno
+	if err := conn.Control(func(fd uintptr) {
+		_, _, errno = syscall.Syscall(syscall.SYS_IOCTL, fd, req, uintptr(arg))
+	}); err != nil {
+		return err
+	}
+	if errno != 0 {
+		return errno
+	}
+	return nil
+}
@@ -0,0 +1,18 @@
+//go:build !linux
+
+package gamepad
+
+import (
+	"errors"
+	"os"
+)
+
+var errEvdevUnsupported = errors.New("evdev input is only available on Linux")
+
+func evdevName(f *os.File) (string, error) {
+	return "", errEvdevUnsupported
+}
+
+func evdevAbsRange(f *os.File, code uint16) (absRange, error) {
+	return absRange{}, errEvdevUnsupported
+}
@@ -0,0 +1,106 @@
+package gamepad
+
+import (
+	"bytes"
+	"encoding/binary"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// rawEvdevEvent encodes a struct input_event the way the kernel hands it out
+func rawEvdevEvent(sec int64, typ, code uint16, value int32) []byte {
+	long := strconv.IntSize / 8
+	raw := make([]byte, evdevEventSize)
+	if long == 8 {
+		binary.NativeEndian.PutUint64(raw, uint64(sec))
+	} else {
+		binary.NativeEndian.PutUint32(raw, uint32(sec))
+	}
+	binary.NativeEndian.PutUint16(raw[2*long:], typ)
+	binary.NativeEndian.PutUint16(raw[2*long+2:], code)
+	binary.NativeEndian.PutUint32(raw[2*long+4:], uint32(value))
+	return raw
+}
+
+func TestEvdevDecoder(t *testing.T) {
+	decoder := newEvdevDecoder(map[uint16]absRange{
+		absX:  {-32768, 32767},
+		absRZ: {0, 1023},
+	})
+
+	t.Run("buttons", func(t *testing.T) {
+		events := decoder.decode(rawEvdevEvent(1700000000, evKey, btnSouth, 1))
+		require.Len(t, events, 1)
+		assert.Equal(t, EventButtonPress, events[0].Type)
+		assert.Equal(t, string(ButtonA), events[0].Input)
+		assert.Equal(t, time.Unix(1700000000, 0), events[0].Timestamp)
+
+		events = decoder.decode(rawEvdevEvent(1700000000, evKey, btnTR, 0))
+		require.Len(t, events, 1)
+		assert.Equal(t, EventButtonRelease, events[0].Type)
+		assert.Equal(t, string(RightBumper), events[0].Input)
+
+		assert.Empty(t, decoder.decode(rawEvdevEvent(0, evKey, btnSouth, 2)), "Autorepeats are no presses")
+		assert.Empty(t, decoder.decode(rawEvdevEvent(0, evKey, 0x100, 1)), "Unknown keys are ignored")
+	})
+
+	t.Run("axes are normalized", func(t *testing.T) {
+		for _, tc := range []struct {
+			code  uint16
+			value int32
+			want  float64
+		}{
+			{absX, -32768, -1.0},
+			{absX, 32767, 1.0},
+			{absRZ, 0, 0.0},
+			{absRZ, 1023, 1.0},
+			{absY, 32767, 1.0}, // Default range
+		} {
+			events := decoder.decode(rawEvdevEvent(0, evAbs, tc.code, tc.value))
+			require.Len(t, events, 1)
+			assert.Equal(t, EventAxisChange, events[0].Type)
+			assert.Equal(t, string(evdevAxes[tc.code]), events[0].Input)
+			assert.InDelta(t, tc.want, events[0].Value, 0.001)
+		}
+	})
+
+	t.Run("hats press the D-pad", func(t *testing.T) {
+		events := decoder.decode(rawEvdevEvent(0, evAbs, absHat0Y, -1))
+		require.Len(t, events, 2)
+		assert.Equal(t, axisEvent(AxisDPadY, -1, events[0].Timestamp), events[0])
+		assert.Equal(t, buttonEvent(DPadUp, true, events[0].Timestamp), events[1])
+
+		events = decoder.decode(rawEvdevEvent(0, evAbs, absHat0Y, 1))
+		require.Len(t, events, 3)
+		assert.Equal(t, buttonEvent(DPadUp, false, events[0].Timestamp), events[1])
+		assert.Equal(t, buttonEvent(DPadDown, true, events[0].Timestamp), events[2])
+
+		events = decoder.decode(rawEvdevEvent(0, evAbs, absHat0Y, 0))
+		require.Len(t, events, 2)
+		assert.Equal(t, buttonEvent(DPadDown, false, events[0].Timestamp), events[1])
+	})
+}
+
+func TestEvdevSource_Read(t *testing.T) {
+	var raw bytes.Buffer
+	raw.Write(rawEvdevEvent(0, evKey, btnNorth, 1))
+	raw.Write(rawEvdevEvent(0, evAbs, absX, 32767))
+	raw.Write(rawEvdevEvent(0, evSyn, 0, 0))
+
+	source := NewEvdevSource("test")
+	source.decoder = newEvdevDecoder(nil)
+	source.read(&raw)
+
+	events, err := source.Poll()
+	require.NoError(t, err, "Events read before the error come first")
+	require.Len(t, events, 2)
+	assert.Equal(t, string(ButtonX), events[0].Input)
+	assert.Equal(t, string(AxisLeftStickX), events[1].Input)
+
+	_, err = source.Poll()
+	assert.ErrorIs(t, err, ErrInputEnded)
+}
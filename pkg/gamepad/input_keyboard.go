@@ -0,0 +1,156 @@
+package gamepad
+
+import (
+	"sync"
+	"time"
+)
+
+// keyboardRelease is how long a key counts as held after it was last
+// seen. Terminals report no key releases, only repeats while a key is
+// held, and the first repeat can take half a second to come.
+const keyboardRelease = 600 * time.Millisecond
+
+// KeyBinding is what a key does on the emulated gamepad: press a button,
+// or push an axis to a value
+type KeyBinding struct {
+	Button ButtonType
+	Axis   AxisType
+	Value  float64
+}
+
+// DefaultKeyBindings returns the keys the keyboard source knows by
+// default. Keys are named the way Bubble Tea names them, and the sticks
+// move the way a controller's do, so up is negative.
+func DefaultKeyBindings() map[string]KeyBinding {
+	return map[string]KeyBinding{
+		"w":     {Axis: AxisLeftStickY, Value: -1},
+		"s":     {Axis: AxisLeftStickY, Value: 1},
+		"a":     {Axis: AxisLeftStickX, Value: -1},
+		"d":     {Axis: AxisLeftStickX, Value: 1},
+		"up":    {Axis: AxisRightStickY, Value: -1},
+		"down":  {Axis: AxisRightStickY, Value: 1},
+		"left":  {Axis: AxisRightStickX, Value: -1},
+		"right": {Axis: AxisRightStickX, Value: 1},
+		"t":     {Button: ButtonA},
+		" ":     {Button: ButtonB},
+		"i":     {Button: ButtonX},
+		"k":     {Button: ButtonY},
+		"u":     {Button: LeftBumper},
+		"o":     {Button: RightBumper},
+		"v":     {Button: ButtonSelect},
+	}
+}
+
+// heldKey is an input a key holds
+type heldKey struct {
+	value float64
+	until time.Time
+}
+
+// KeyboardSource emulates a gamepad from key presses, e.g. those the TUI
+// receives. A key holds its button or axis until it has not been seen for
+// a while, so holding a key down works like holding a button.
+type KeyboardSource struct {
+	bindings map[string]KeyBinding
+	now      func() time.Time
+
+	mu      sync.Mutex
+	held    map[string]heldKey // By button or axis name
+	pending []Event
+}
+
+// NewKeyboardSource creates a keyboard source; nil bindings use
+// DefaultKeyBindings
+func NewKeyboardSource(bindings map[string]KeyBinding) *KeyboardSource {
+	if bindings == nil {
+		bindings = DefaultKeyBindings()
+	}
+	return &KeyboardSource{
+		bindings: bindings,
+		now:      time.Now,
+		held:     make(map[string]heldKey),
+	}
+}
+
+// Key takes a key press and reports whether the key is bound
+func (k *KeyboardSource) Key(key string) bool {
+	binding, ok := k.bindings[key]
+	if !ok {
+		return false
+	}
+
+	k.mu.Lock()
+	defer k.mu.Unlock()
+
+	now := k.now()
+	input, value := string(binding.Button), 1.0
+	if binding.Axis != "" {
+		input, value = string(binding.Axis), binding.Value
+	}
+
+	last, held := k.held[input]
+	k.held[input] = heldKey{value: value, until: now.Add(keyboardRelease)}
+	if held && last.value == value {
+		return true
+	}
+
+	if binding.Axis != "" {
+		k.pending = append(k.pending, axisEvent(binding.Axis, value, now))
+	} else {
+		k.pending = append(k.pending, buttonEvent(binding.Button, true, now))
+	}
+	return true
+}
+
+// Open does nothing; keys arrive through Key
+func (k *KeyboardSource) Open() error {
+	return nil
+}
+
+// Poll returns the key presses since the last call, and releases for the
+// keys no longer held
+func (k *KeyboardSource) Poll() ([]Event, error) {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+
+	now := k.now()
+	for input, key := range k.held {
+		if now.Before(key.until) {
+			continue
+		}
+		delete(k.held, input)
+		if k.isAxis(input) {
+			k.pending = append(k.pending, axisEvent(AxisType(input), 0, now))
+		} else {
+			k.pending = append(k.pending, buttonEvent(ButtonType(input), false, now))
+		}
+	}
+
+	events := k.pending
+	k.pending = nil
+	return events, nil
+}
+
+// Name returns "keyboard"
+func (k *KeyboardSource) Name() string {
+	return "keyboard"
+}
+
+// Close releases every held key
+func (k *KeyboardSource) Close() error {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+	k.held = make(map[string]heldKey)
+	k.pending = nil
+	return nil
+}
+
+// isAxis reports whether an input name is one of the bound axes
+func (k *KeyboardSource) isAxis(input string) bool {
+	for _, binding := range k.bindings {
+		if string(binding.Axis) == input {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,57 @@
+package gamepad
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestKeyboardSource(t *testing.T) {
+	now := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	keyboard := NewKeyboardSource(nil)
+	keyboard.now = func() time.Time { return now }
+	require.NoError(t, keyboard.Open())
+
+	assert.False(t, keyboard.Key("q"), "Unbound keys are left to the caller")
+
+	t.Run("keys hold an axis", func(t *testing.T) {
+		assert.True(t, keyboard.Key("w"))
+		events, err := keyboard.Poll()
+		require.NoError(t, err)
+		assert.Equal(t, []Event{axisEvent(AxisLeftStickY, -1, now)}, events)
+
+		// Repeats keep it held
+		now = now.Add(keyboardRelease / 2)
+		keyboard.Key("w")
+		now = now.Add(keyboardRelease / 2)
+		events, _ = keyboard.Poll()
+		assert.Empty(t, events)
+
+		keyboard.Key("s")
+		events, _ = keyboard.Poll()
+		assert.Equal(t, []Event{axisEvent(AxisLeftStickY, 1, now)}, events)
+
+		now = now.Add(keyboardRelease)
+		events, _ = keyboard.Poll()
+		assert.Equal(t, []Event{axisEvent(AxisLeftStickY, 0, now)}, events)
+	})
+
+	t.Run("keys press buttons", func(t *testing.T) {
+		keyboard.Key("t")
+		keyboard.Key(" ")
+		events, _ := keyboard.Poll()
+		assert.ElementsMatch(t, []Event{
+			buttonEvent(ButtonA, true, now),
+			buttonEvent(ButtonB, true, now),
+		}, events)
+
+		now = now.Add(keyboardRelease)
+		events, _ = keyboard.Poll()
+		assert.ElementsMatch(t, []Event{
+			buttonEvent(ButtonA, false, now),
+			buttonEvent(ButtonB, false, now),
+		}, events)
+	})
+}
@@ -0,0 +1,161 @@
+package gamepad
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"sync"
+	"time"
+)
+
+// Recorded input is stored as JSON lines, one Event per line:
+//
+//	{"type":"button_press","input":"button_a","value":1,"timestamp":"2024-05-01T12:00:00Z"}
+//
+// Replays keep the time between events and restamp them as they play.
+
+// ReadEvents reads recorded input events
+func ReadEvents(r io.Reader) ([]Event, error) {
+	var events []Event
+	scanner := bufio.NewScanner(r)
+	for line := 1; scanner.Scan(); line++ {
+		if len(scanner.Bytes()) == 0 {
+			continue
+		}
+		var event Event
+		if err := json.Unmarshal(scanner.Bytes(), &event); err != nil {
+			return nil, fmt.Errorf("line %d: %w", line, err)
+		}
+		switch event.Type {
+		case EventButtonPress, EventButtonRelease, EventAxisChange:
+		default:
+			return nil, fmt.Errorf("line %d: unknown event type %q", line, event.Type)
+		}
+		if event.Timestamp.IsZero() {
+			return nil, fmt.Errorf("line %d: event has no timestamp", line)
+		}
+		events = append(events, event)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	return events, nil
+}
+
+// ReplaySource plays back recorded input. Each event comes due once as
+// much time has passed since Open as passed between it and the first
+// recorded event. Poll returns ErrInputEnded after the last one.
+type ReplaySource struct {
+	name   string
+	events []Event
+	now    func() time.Time
+
+	mu    sync.Mutex
+	start time.Time
+	next  int
+}
+
+// NewReplaySource creates a source that plays back events, e.g. a
+// scripted sequence in a test
+func NewReplaySource(name string, events []Event) *ReplaySource {
+	return &ReplaySource{
+		name:   name,
+		events: events,
+		now:    time.Now,
+	}
+}
+
+// LoadReplayFile creates a source that plays back a recording
+func LoadReplayFile(path string) (*ReplaySource, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open replay file: %w", err)
+	}
+	defer f.Close()
+
+	events, err := ReadEvents(f)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read replay file %s: %w", path, err)
+	}
+	return NewReplaySource("replay "+path, events), nil
+}
+
+// Open starts playback from the beginning
+func (r *ReplaySource) Open() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.start = r.now()
+	r.next = 0
+	return nil
+}
+
+// Poll returns the events that have come due
+func (r *ReplaySource) Poll() ([]Event, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.next >= len(r.events) {
+		return nil, ErrInputEnded
+	}
+
+	elapsed := r.now().Sub(r.start)
+	first := r.events[0].Timestamp
+
+	var events []Event
+	for ; r.next < len(r.events); r.next++ {
+		event := r.events[r.next]
+		offset := event.Timestamp.Sub(first)
+		if offset > elapsed {
+			break
+		}
+		event.Timestamp = r.start.Add(offset)
+		events = append(events, event)
+	}
+	return events, nil
+}
+
+// Name describes the replay
+func (r *ReplaySource) Name() string {
+	return r.name
+}
+
+// Close does nothing
+func (r *ReplaySource) Close() error {
+	return nil
+}
+
+// RecordingSource passes another source's input through, writing each
+// event to a recording that a ReplaySource can play back
+type RecordingSource struct {
+	InputSource
+
+	mu  sync.Mutex
+	enc *json.Encoder
+}
+
+// NewRecordingSource records source's events to w
+func NewRecordingSource(source InputSource, w io.Writer) *RecordingSource {
+	return &RecordingSource{
+		InputSource: source,
+		enc:         json.NewEncoder(w),
+	}
+}
+
+// Poll polls the source and records its events
+func (r *RecordingSource) Poll() ([]Event, error) {
+	events, err := r.InputSource.Poll()
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, event := range events {
+		if event.Timestamp.IsZero() {
+			event.Timestamp = time.Now()
+		}
+		if encErr := r.enc.Encode(event); encErr != nil && err == nil {
+			err = fmt.Errorf("failed to record input: %w", encErr)
+		}
+	}
+	return events, err
+}
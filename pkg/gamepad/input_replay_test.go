@@ -0,0 +1,93 @@
+package gamepad
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestReadEvents(t *testing.T) {
+	events, err := ReadEvents(strings.NewReader(`{"type":"button_press","input":"button_a","value":1,"timestamp":"2024-05-01T12:00:00Z"}
+
+{"type":"axis_change","input":"left_stick_x","value":-0.5,"timestamp":"2024-05-01T12:00:00.25Z"}
+`))
+	require.NoError(t, err)
+	require.Len(t, events, 2)
+	assert.Equal(t, string(ButtonA), events[0].Input)
+	assert.Equal(t, -0.5, events[1].Value)
+
+	_, err = ReadEvents(strings.NewReader(`{"type":"wiggle","input":"button_a","timestamp":"2024-05-01T12:00:00Z"}`))
+	assert.ErrorContains(t, err, `line 1: unknown event type "wiggle"`)
+
+	_, err = ReadEvents(strings.NewReader(`{"type":"button_press","input":"button_a"}`))
+	assert.ErrorContains(t, err, "no timestamp")
+}
+
+func TestReplaySource(t *testing.T) {
+	recorded := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	replay := NewReplaySource("script", []Event{
+		buttonEvent(ButtonA, true, recorded),
+		buttonEvent(ButtonA, false, recorded.Add(time.Second)),
+		axisEvent(AxisLeftStickX, 1, recorded.Add(time.Second)),
+	})
+
+	now := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	replay.now = func() time.Time { return now }
+	require.NoError(t, replay.Open())
+
+	events, err := replay.Poll()
+	require.NoError(t, err)
+	assert.Equal(t, []Event{buttonEvent(ButtonA, true, now)}, events, "Events are restamped")
+
+	now = now.Add(500 * time.Millisecond)
+	events, err = replay.Poll()
+	require.NoError(t, err)
+	assert.Empty(t, events)
+
+	now = now.Add(500 * time.Millisecond)
+	events, err = replay.Poll()
+	require.NoError(t, err)
+	assert.Equal(t, []Event{
+		buttonEvent(ButtonA, false, now),
+		axisEvent(AxisLeftStickX, 1, now),
+	}, events)
+
+	_, err = replay.Poll()
+	assert.ErrorIs(t, err, ErrInputEnded)
+
+	t.Run("open restarts", func(t *testing.T) {
+		require.NoError(t, replay.Open())
+		events, err := replay.Poll()
+		require.NoError(t, err)
+		assert.Len(t, events, 1)
+	})
+}
+
+func TestRecordingSource(t *testing.T) {
+	recorded := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	script := []Event{
+		buttonEvent(ButtonX, true, recorded),
+		axisEvent(AxisRightStickY, -0.25, recorded.Add(40*time.Millisecond)),
+	}
+
+	var out bytes.Buffer
+	recorder := NewRecordingSource(NewReplaySource("script", script), &out)
+	require.NoError(t, recorder.Open())
+	for {
+		if _, err := recorder.Poll(); err != nil {
+			assert.ErrorIs(t, err, ErrInputEnded)
+			break
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+
+	events, err := ReadEvents(&out)
+	require.NoError(t, err)
+	require.Len(t, events, 2)
+	assert.Equal(t, script[1].Input, events[1].Input)
+	assert.Equal(t, 40*time.Millisecond, events[1].Timestamp.Sub(events[0].Timestamp), "The recording keeps the timing")
+}
@@ -0,0 +1,291 @@
+package gamepad
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/conceptcodes/dji-tello-sdk-go/pkg/utils"
+	"github.com/veandco/go-sdl2/sdl"
+)
+
+// sdlAxes are the controller axes read on every poll
+var sdlAxes = []sdl.GameControllerAxis{
+	sdl.CONTROLLER_AXIS_LEFTX,
+	sdl.CONTROLLER_AXIS_LEFTY,
+	sdl.CONTROLLER_AXIS_RIGHTX,
+	sdl.CONTROLLER_AXIS_RIGHTY,
+	sdl.CONTROLLER_AXIS_TRIGGERLEFT,
+	sdl.CONTROLLER_AXIS_TRIGGERRIGHT,
+}
+
+// SDLSource reads the first game controller SDL2 finds. SDL2 functions
+// should generally be called from the main thread, so poll it from there.
+type SDLSource struct {
+	gamepad  *sdl.GameController
+	joystick *sdl.Joystick
+	name     string // Name of the last controller opened
+
+	axes map[AxisType]float64 // Last value reported for each axis
+}
+
+// NewSDLSource initializes SDL2's game controller support
+func NewSDLSource() (*SDLSource, error) {
+	if err := sdl.Init(sdl.INIT_GAMECONTROLLER); err != nil {
+		return nil, fmt.Errorf("failed to initialize SDL2: %w", err)
+	}
+
+	// Load game controller mappings from database
+	if err := loadControllerMappings(); err != nil {
+		utils.Logger.Warnf("Failed to load controller mappings: %v", err)
+	}
+
+	return &SDLSource{axes: make(map[AxisType]float64)}, nil
+}
+
+// Open opens the first available gamepad
+func (s *SDLSource) Open() error {
+	var err error
+	sdl.Do(func() {
+		err = s.openGamepad()
+	})
+	return err
+}
+
+// Poll returns the controller's button and axis changes
+func (s *SDLSource) Poll() ([]Event, error) {
+	var events []Event
+	sdl.Do(func() {
+		for event := sdl.PollEvent(); event != nil; event = sdl.PollEvent() {
+			switch e := event.(type) {
+			case *sdl.ControllerAxisEvent:
+				if axis := sdlAxisType(sdl.GameControllerAxis(e.Axis)); axis != "" {
+					events = s.appendAxis(events, axis, e.Value)
+				}
+			case *sdl.ControllerButtonEvent:
+				if button := sdlButtonType(sdl.GameControllerButton(e.Button)); button != "" {
+					events = append(events, buttonEvent(button, e.State == sdl.PRESSED, time.Now()))
+				}
+			case *sdl.ControllerDeviceEvent:
+				s.handleDeviceEvent(e)
+			}
+		}
+
+		// Read the axes as well, in case an axis event was missed
+		if s.gamepad != nil {
+			for _, axis := range sdlAxes {
+				events = s.appendAxis(events, sdlAxisType(axis), s.gamepad.Axis(axis))
+			}
+		}
+	})
+	return events, nil
+}
+
+// Name returns the name of the controller opened
+func (s *SDLSource) Name() string {
+	if s.name == "" {
+		return "SDL2 game controller"
+	}
+	return s.name
+}
+
+// Rumble runs the controller's motors. It does nothing while no controller
+// is connected.
+func (s *SDLSource) Rumble(low, high float64, duration time.Duration) error {
+	var err error
+	sdl.Do(func() {
+		if s.gamepad == nil {
+			return
+		}
+		err = s.gamepad.Rumble(motorStrength(low), motorStrength(high), uint32(duration.Milliseconds()))
+	})
+	return err
+}
+
+// SetLED sets the controller's light bar. It does nothing while no
+// controller is connected.
+func (s *SDLSource) SetLED(red, green, blue uint8) error {
+	var err error
+	sdl.Do(func() {
+		if s.gamepad == nil {
+			return
+		}
+		err = setControllerLED(s.gamepad, red, green, blue)
+	})
+	return err
+}
+
+// Close closes the gamepad
+func (s *SDLSource) Close() error {
+	sdl.Do(func() {
+		if s.gamepad != nil {
+			s.gamepad.Close()
+			s.gamepad = nil
+		}
+		s.joystick = nil
+	})
+	return nil
+}
+
+// appendAxis adds an event for an axis whose value changed
+func (s *SDLSource) appendAxis(events []Event, axis AxisType, raw int16) []Event {
+	// Normalize axis value to [-1.0, 1.0]
+	value := clampAxis(float64(raw) / 32767.0)
+	if last, ok := s.axes[axis]; ok && last == value {
+		return events
+	}
+	s.axes[axis] = value
+	return append(events, axisEvent(axis, value, time.Now()))
+}
+
+// motorStrength converts a rumble strength from 0.0-1.0 to SDL2's range
+func motorStrength(strength float64) uint16 {
+	return uint16(max(0, min(strength, 1.0)) * 0xffff)
+}
+
+// ListGamepads returns a list of available gamepads
+func ListGamepads() []string {
+	var gamepads []string
+
+	numJoysticks := sdl.NumJoysticks()
+	for i := 0; i < numJoysticks; i++ {
+		if sdl.IsGameController(i) {
+			name := sdl.GameControllerNameForIndex(i)
+			gamepads = append(gamepads, fmt.Sprintf("%d: %s", i, name))
+		}
+	}
+
+	return gamepads
+}
+
+// GetGamepadInfo returns information about available gamepads
+func GetGamepadInfo() []map[string]string {
+	var gamepads []map[string]string
+
+	numJoysticks := sdl.NumJoysticks()
+	for i := 0; i < numJoysticks; i++ {
+		info := map[string]string{
+			"id":   fmt.Sprintf("%d", i),
+			"name": sdl.JoystickNameForIndex(i),
+		}
+
+		if sdl.IsGameController(i) {
+			info["type"] = "GameController"
+			info["mapping"] = sdl.GameControllerMappingForDeviceIndex(i)
+		} else {
+			info["type"] = "Joystick"
+		}
+
+		gamepads = append(gamepads, info)
+	}
+
+	return gamepads
+}
+
+// openGamepad opens the first available gamepad
+func (s *SDLSource) openGamepad() error {
+	numJoysticks := sdl.NumJoysticks()
+	if numJoysticks == 0 {
+		return fmt.Errorf("no gamepads connected")
+	}
+
+	// Try to open the first available game controller
+	for i := 0; i < numJoysticks; i++ {
+		if sdl.IsGameController(i) {
+			gamepad := sdl.GameControllerOpen(i)
+			if gamepad == nil {
+				utils.Logger.Warnf("Failed to open gamepad %d", i)
+				continue
+			}
+
+			s.gamepad = gamepad
+			s.joystick = gamepad.Joystick()
+			s.name = gamepad.Name()
+
+			utils.Logger.Infof("Connected to gamepad: %s", gamepad.Name())
+			return nil
+		}
+	}
+
+	return fmt.Errorf("no compatible gamepads found")
+}
+
+// loadControllerMappings loads game controller database mappings
+func loadControllerMappings() error {
+	// SDL2 automatically loads system game controller database
+	// Additional mappings can be loaded here if needed
+	return nil
+}
+
+// handleDeviceEvent processes controller connection/disconnection
+func (s *SDLSource) handleDeviceEvent(event *sdl.ControllerDeviceEvent) {
+	switch event.Type {
+	case sdl.CONTROLLERDEVICEADDED:
+		utils.Logger.Infof("Gamepad connected: %d", event.Which)
+		if s.gamepad == nil {
+			s.openGamepad()
+		}
+	case sdl.CONTROLLERDEVICEREMOVED:
+		utils.Logger.Infof("Gamepad disconnected: %d", event.Which)
+		if s.gamepad != nil && s.joystick.InstanceID() == event.Which {
+			s.gamepad.Close()
+			s.gamepad = nil
+			s.joystick = nil
+		}
+	}
+}
+
+// sdlAxisType converts an SDL axis to the axis it is configured as
+func sdlAxisType(axis sdl.GameControllerAxis) AxisType {
+	switch axis {
+	case sdl.CONTROLLER_AXIS_LEFTX:
+		return AxisLeftStickX
+	case sdl.CONTROLLER_AXIS_LEFTY:
+		return AxisLeftStickY
+	case sdl.CONTROLLER_AXIS_RIGHTX:
+		return AxisRightStickX
+	case sdl.CONTROLLER_AXIS_RIGHTY:
+		return AxisRightStickY
+	case sdl.CONTROLLER_AXIS_TRIGGERLEFT:
+		return AxisLeftTrigger
+	case sdl.CONTROLLER_AXIS_TRIGGERRIGHT:
+		return AxisRightTrigger
+	default:
+		return ""
+	}
+}
+
+// sdlButtonType converts an SDL button to the button it is configured as
+func sdlButtonType(button sdl.GameControllerButton) ButtonType {
+	switch button {
+	case sdl.CONTROLLER_BUTTON_A:
+		return ButtonA
+	case sdl.CONTROLLER_BUTTON_B:
+		return ButtonB
+	case sdl.CONTROLLER_BUTTON_X:
+		return ButtonX
+	case sdl.CONTROLLER_BUTTON_Y:
+		return ButtonY
+	case sdl.CONTROLLER_BUTTON_BACK:
+		return ButtonSelect
+	case sdl.CONTROLLER_BUTTON_START:
+		return ButtonStart
+	case sdl.CONTROLLER_BUTTON_LEFTSTICK:
+		return LeftStickButton
+	case sdl.CONTROLLER_BUTTON_RIGHTSTICK:
+		return RightStickButton
+	case sdl.CONTROLLER_BUTTON_LEFTSHOULDER:
+		return LeftBumper
+	case sdl.CONTROLLER_BUTTON_RIGHTSHOULDER:
+		return RightBumper
+	case sdl.CONTROLLER_BUTTON_DPAD_UP:
+		return DPadUp
+	case sdl.CONTROLLER_BUTTON_DPAD_DOWN:
+		return DPadDown
+	case sdl.CONTROLLER_BUTTON_DPAD_LEFT:
+		return DPadLeft
+	case sdl.CONTROLLER_BUTTON_DPAD_RIGHT:
+		return DPadRight
+	default:
+		return ""
+	}
+}
@@ -0,0 +1,40 @@
+//go:build !static
+
+package gamepad
+
+/*
+#cgo windows LDFLAGS: -lSDL2
+#cgo linux freebsd darwin openbsd pkg-config: sdl2
+
+#if defined(_WIN32)
+	#include <SDL2/SDL.h>
+#else
+	#include <SDL.h>
+#endif
+
+// go-sdl2 has no binding for the light bar, which SDL 2.0.14 added
+static int gamepadSetLED(void *controller, Uint8 red, Uint8 green, Uint8 blue)
+{
+#if SDL_VERSION_ATLEAST(2,0,14)
+	return SDL_GameControllerSetLED((SDL_GameController *)controller, red, green, blue);
+#else
+	SDL_SetError("setting the light bar needs SDL 2.0.14 or later");
+	return -1;
+#endif
+}
+*/
+import "C"
+
+import (
+	"unsafe"
+
+	"github.com/veandco/go-sdl2/sdl"
+)
+
+// setControllerLED sets the light bar of a controller that has one
+func setControllerLED(controller *sdl.GameController, red, green, blue uint8) error {
+	if C.gamepadSetLED(unsafe.Pointer(controller), C.Uint8(red), C.Uint8(green), C.Uint8(blue)) != 0 {
+		return sdl.GetError()
+	}
+	return nil
+}
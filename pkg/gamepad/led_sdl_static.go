@@ -0,0 +1,15 @@
+//go:build static
+
+package gamepad
+
+import (
+	"fmt"
+
+	"github.com/veandco/go-sdl2/sdl"
+)
+
+// setControllerLED fails in static builds: the SDL2 that go-sdl2 bundles
+// predates light bar support (SDL 2.0.14)
+func setControllerLED(controller *sdl.GameController, red, green, blue uint8) error {
+	return fmt.Errorf("light bars need a build against the system's SDL2 (without the static tag)")
+}
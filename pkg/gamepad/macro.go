@@ -0,0 +1,150 @@
+package gamepad
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Macro steps are written the way the SDK names its commands, with two
+// additions: "photo" saves a photo from the video stream and "wait 1.5"
+// pauses for that many seconds.
+//
+//	"macro": ["up 50", "cw 360", "photo"]
+//	"macro": ["speed 50"]
+
+// Macro commands whose arguments are not numbers, or that the SDK lacks
+const (
+	MacroPhoto = "photo"
+	MacroWait  = "wait"
+	MacroFlip  = "flip"
+)
+
+// macroArgs are the commands a macro may use, with the range of each
+// argument as the SDK documents it
+var macroArgs = map[string][][2]int{
+	"takeoff":   nil,
+	"land":      nil,
+	"streamon":  nil,
+	"streamoff": nil,
+	MacroPhoto:  nil,
+	"up":        {{20, 500}},
+	"down":      {{20, 500}},
+	"left":      {{20, 500}},
+	"right":     {{20, 500}},
+	"forward":   {{20, 500}},
+	"back":      {{20, 500}},
+	"cw":        {{1, 360}},
+	"ccw":       {{1, 360}},
+	"speed":     {{10, 100}},
+	"go":        {{-500, 500}, {-500, 500}, {-500, 500}, {10, 100}},
+	"curve":     {{-500, 500}, {-500, 500}, {-500, 500}, {-500, 500}, {-500, 500}, {-500, 500}, {10, 60}},
+}
+
+// flipDirections are the directions the SDK's flip command takes
+var flipDirections = map[string]bool{"l": true, "r": true, "f": true, "b": true}
+
+// MacroStep is one step of a macro
+type MacroStep struct {
+	Command string        // SDK command, or "photo" or "wait"
+	Args    []int         // Numeric arguments, e.g. the distance of "up 50"
+	Flip    string        // Direction of a flip: l, r, f or b
+	Wait    time.Duration // Pause of a wait step
+}
+
+// String returns the step as written
+func (s MacroStep) String() string {
+	parts := []string{s.Command}
+	switch s.Command {
+	case MacroFlip:
+		parts = append(parts, s.Flip)
+	case MacroWait:
+		parts = append(parts, strconv.FormatFloat(s.Wait.Seconds(), 'f', -1, 64))
+	default:
+		for _, arg := range s.Args {
+			parts = append(parts, strconv.Itoa(arg))
+		}
+	}
+	return strings.Join(parts, " ")
+}
+
+// Macro is a sequence of steps a binding runs in turn
+type Macro []MacroStep
+
+// Macro implements CommandData
+func (m Macro) isCommandData() {}
+
+// String lists the steps
+func (m Macro) String() string {
+	steps := make([]string, len(m))
+	for i, step := range m {
+		steps[i] = step.String()
+	}
+	return strings.Join(steps, ", ")
+}
+
+// ParseMacro parses the steps of a macro
+func ParseMacro(steps []string) (Macro, error) {
+	if len(steps) == 0 {
+		return nil, fmt.Errorf("macro has no steps")
+	}
+	macro := make(Macro, 0, len(steps))
+	for i, text := range steps {
+		step, err := ParseMacroStep(text)
+		if err != nil {
+			return nil, fmt.Errorf("step %d: %w", i+1, err)
+		}
+		macro = append(macro, step)
+	}
+	return macro, nil
+}
+
+// ParseMacroStep parses one step, e.g. "up 50", "flip f" or "wait 2"
+func ParseMacroStep(text string) (MacroStep, error) {
+	fields := strings.Fields(text)
+	if len(fields) == 0 {
+		return MacroStep{}, fmt.Errorf("empty step")
+	}
+	step := MacroStep{Command: strings.ToLower(fields[0])}
+	args := fields[1:]
+
+	switch step.Command {
+	case MacroFlip:
+		if len(args) != 1 || !flipDirections[args[0]] {
+			return MacroStep{}, fmt.Errorf("usage: flip <l|r|f|b>")
+		}
+		step.Flip = args[0]
+		return step, nil
+
+	case MacroWait:
+		if len(args) != 1 {
+			return MacroStep{}, fmt.Errorf("usage: wait <seconds>")
+		}
+		seconds, err := strconv.ParseFloat(args[0], 64)
+		if err != nil || seconds <= 0 {
+			return MacroStep{}, fmt.Errorf("wait needs a positive number of seconds, got %q", args[0])
+		}
+		step.Wait = time.Duration(seconds * float64(time.Second))
+		return step, nil
+	}
+
+	ranges, ok := macroArgs[step.Command]
+	if !ok {
+		return MacroStep{}, fmt.Errorf("unknown command %q", step.Command)
+	}
+	if len(args) != len(ranges) {
+		return MacroStep{}, fmt.Errorf("%s takes %d argument(s), got %d", step.Command, len(ranges), len(args))
+	}
+	for i, arg := range args {
+		value, err := strconv.Atoi(arg)
+		if err != nil {
+			return MacroStep{}, fmt.Errorf("%s: %q is not a whole number", step.Command, arg)
+		}
+		if value < ranges[i][0] || value > ranges[i][1] {
+			return MacroStep{}, fmt.Errorf("%s: %d is outside %d to %d", step.Command, value, ranges[i][0], ranges[i][1])
+		}
+		step.Args = append(step.Args, value)
+	}
+	return step, nil
+}
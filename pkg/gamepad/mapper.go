@@ -2,21 +2,57 @@ package gamepad
 
 import (
 	"fmt"
+	"math"
+	"sync"
 	"time"
+
+	"github.com/conceptcodes/dji-tello-sdk-go/pkg/utils"
 )
 
-// DefaultMapper implements the Mapper interface with configurable gamepad-to-drone mapping
+// actionDebounce is the shortest time between two of the same action, so a
+// bouncing button or a hurried second press does not repeat it. Emergency
+// is never held back.
+const actionDebounce = 500 * time.Millisecond
+
+// DefaultMapper implements the Mapper interface with configurable gamepad-to-drone mapping.
+// Bindings trigger once per press, and the drone model decides what toggle
+// buttons do and which actions are allowed. With flight modes configured,
+// the active mode sets the RC limits and how quickly RC values may change.
 type DefaultMapper struct {
 	config *Config
+	model  *DroneModel
+	now    func() time.Time
+
+	mu        sync.Mutex
+	firedAt   map[string]time.Time      // When each binding last triggered, so a press triggers once
+	issued    map[DroneAction]time.Time // When each action was last issued
+	mode      int                       // Index of the active flight mode
+	comboHeld bool                      // The mode cycle combo is held and has cycled
+	comboUsed map[ButtonType]time.Time  // Presses that went into the combo, by press time
+	rcOut     [4]float64                // RC values last sent, before rounding
+	rcAt      time.Time                 // When rcOut was worked out
+	rcMoving  bool                      // The last RC values sent were not all zero
 }
 
 // NewDefaultMapper creates a new mapper with the given configuration
 func NewDefaultMapper(config *Config) *DefaultMapper {
 	return &DefaultMapper{
-		config: config,
+		config:    config,
+		model:     NewDroneModel(),
+		now:       time.Now,
+		firedAt:   make(map[string]time.Time),
+		issued:    make(map[DroneAction]time.Time),
+		mode:      defaultModeIndex(config),
+		comboUsed: make(map[ButtonType]time.Time),
 	}
 }
 
+// Model returns the drone model the mapper consults. Feed it the drone's
+// states with UpdateState so toggles follow the actual drone.
+func (m *DefaultMapper) Model() *DroneModel {
+	return m.model
+}
+
 // MapEvent converts a gamepad event to one or more drone commands
 func (m *DefaultMapper) MapEvent(event Event) ([]Command, error) {
 	var commands []Command
@@ -24,11 +60,25 @@ func (m *DefaultMapper) MapEvent(event Event) ([]Command, error) {
 	switch event.Type {
 	case EventButtonPress:
 		if event.Value == 1.0 { // Button pressed
-			if action := m.mapButtonToAction(ButtonType(event.Input)); action != "" {
-				commands = append(commands, Command{
-					Type: CommandAction,
-					Data: action,
-				})
+			// Instant buttons trigger here; MapState sees the same press
+			// and leaves it, and handles hold times, double taps and combos
+			pressTime := event.Timestamp
+			if pressTime.IsZero() {
+				pressTime = time.Now()
+			}
+			pressed := ButtonType(event.Input)
+			button := &ButtonState{Pressed: true, PressTime: pressTime, TapCount: 1, LastTapTime: pressTime}
+			bindings := m.bindings()
+			if m.inCombo(pressed, bindings) {
+				break
+			}
+			for _, binding := range bindings {
+				if binding.isCombo() || binding.Buttons[0] != pressed || !m.shouldTriggerButtonAction(button, binding.mapping(), pressTime) {
+					continue
+				}
+				if command, ok := m.triggerBinding(binding, pressTime); ok {
+					commands = append(commands, command)
+				}
 			}
 		}
 	case EventAxisChange:
@@ -43,27 +93,26 @@ func (m *DefaultMapper) MapEvent(event Event) ([]Command, error) {
 func (m *DefaultMapper) MapState(state *GamepadState) ([]Command, error) {
 	var commands []Command
 
-	// Process axes for RC control
-	rcValues := m.processAxes(state)
-	if !rcValues.IsZero() {
-		// Apply safety limits
-		rcValues = rcValues.Clamp(m.config.Safety.RCLimits)
-
+	// Cycle the flight mode when its combo is held
+	if mode := m.processModeCombo(state); mode != "" {
 		commands = append(commands, Command{
-			Type: CommandRC,
-			Data: rcValues,
+			Type: CommandFlightMode,
+			Data: FlightModeName(mode),
 		})
 	}
 
-	// Process buttons for actions
-	actions := m.processButtons(state)
-	for _, action := range actions {
+	// Process axes for RC control. Once they return to center, zeros are
+	// sent once so the drone stops.
+	if rcValues, send := m.shapeRC(m.axisTargets(state)); send {
 		commands = append(commands, Command{
-			Type: CommandAction,
-			Data: action,
+			Type: CommandRC,
+			Data: rcValues,
 		})
 	}
 
+	// Process buttons for actions and macros
+	commands = append(commands, m.processButtons(state)...)
+
 	return commands, nil
 }
 
@@ -72,39 +121,104 @@ func (m *DefaultMapper) UpdateConfig(config *Config) error {
 	if config == nil {
 		return fmt.Errorf("config cannot be nil")
 	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
 	m.config = config
+	m.mode = defaultModeIndex(config)
 	return nil
 }
 
 // processAxes converts axis states to RC values
 func (m *DefaultMapper) processAxes(state *GamepadState) RCValues {
-	rc := NewRCValues()
+	targets := m.axisTargets(state)
+	return RCValues{
+		A: int(targets[0]),
+		B: int(targets[1]),
+		C: int(targets[2]),
+		D: int(targets[3]),
+	}
+}
 
-	// Process Movement X (left/right)
-	if axis, exists := state.Axes[m.config.Mappings.Axes.MovementX.Axis]; exists {
-		value := m.applyAxisProcessing(axis.Value, m.config.Mappings.Axes.MovementX)
-		rc.A = int(value * float64(m.config.Safety.RCLimits.Horizontal))
+// axisTargets works out the RC values the sticks ask for, in the order
+// left/right, forward/backward, up/down and yaw
+func (m *DefaultMapper) axisTargets(state *GamepadState) [4]float64 {
+	axes := m.config.Mappings.Axes
+	limits := m.config.Safety.RCLimits
+	mode := m.activeMode()
+	if mode != nil {
+		limits = mode.RCLimits
 	}
 
-	// Process Movement Y (forward/backward)
-	if axis, exists := state.Axes[m.config.Mappings.Axes.MovementY.Axis]; exists {
-		value := m.applyAxisProcessing(axis.Value, m.config.Mappings.Axes.MovementY)
-		rc.B = int(value * float64(m.config.Safety.RCLimits.Horizontal))
+	var targets [4]float64
+	for i, axis := range []struct {
+		mapping AxisMapping
+		limit   int
+	}{
+		{axes.MovementX, limits.Horizontal},
+		{axes.MovementY, limits.Horizontal},
+		{axes.Altitude, limits.Vertical},
+		{axes.Yaw, limits.Yaw},
+	} {
+		value, exists := state.Axes[axis.mapping.Axis]
+		if !exists {
+			continue
+		}
+		mapping := axis.mapping
+		if mode != nil && mode.Expo != nil {
+			mapping.Expo = mode.Expo
+		}
+		targets[i] = m.applyAxisProcessing(value.Value, mapping) * float64(axis.limit)
 	}
+	return targets
+}
 
-	// Process Altitude (up/down)
-	if axis, exists := state.Axes[m.config.Mappings.Axes.Altitude.Axis]; exists {
-		value := m.applyAxisProcessing(axis.Value, m.config.Mappings.Axes.Altitude)
-		rc.C = int(value * float64(m.config.Safety.RCLimits.Vertical))
+// shapeRC smooths and slew-limits the RC values as the active flight mode
+// asks, clamps them to its limits and reports whether to send them
+func (m *DefaultMapper) shapeRC(targets [4]float64) (RCValues, bool) {
+	limits := m.config.Safety.RCLimits
+	mode := m.activeMode()
+	if mode != nil {
+		limits = mode.RCLimits
 	}
 
-	// Process Yaw (rotation)
-	if axis, exists := state.Axes[m.config.Mappings.Axes.Yaw.Axis]; exists {
-		value := m.applyAxisProcessing(axis.Value, m.config.Mappings.Axes.Yaw)
-		rc.D = int(value * float64(m.config.Safety.RCLimits.Yaw))
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	now := m.now()
+	dt := now.Sub(m.rcAt).Seconds()
+	if m.rcAt.IsZero() || dt <= 0 || dt > 1 {
+		dt = 1 / float64(max(m.config.Controller.UpdateRate, 1))
+	}
+	m.rcAt = now
+
+	for i, target := range targets {
+		out := target
+		if mode != nil {
+			last := m.rcOut[i]
+			if mode.Smoothing > 0 {
+				out = target + (last-target)*math.Pow(mode.Smoothing, dt*60)
+			}
+			if mode.SlewRate > 0 {
+				step := float64(mode.SlewRate) * dt
+				out = math.Max(last-step, math.Min(last+step, out))
+			}
+			// Settle rather than creep toward the target forever
+			if math.Abs(out-target) < 0.5 {
+				out = target
+			}
+		}
+		m.rcOut[i] = out
 	}
 
-	return rc
+	rc := RCValues{
+		A: int(m.rcOut[0]),
+		B: int(m.rcOut[1]),
+		C: int(m.rcOut[2]),
+		D: int(m.rcOut[3]),
+	}.Clamp(limits)
+	send := !rc.IsZero() || m.rcMoving
+	m.rcMoving = !rc.IsZero()
+	return rc, send
 }
 
 // applyAxisProcessing applies deadzone, sensitivity, and inversion to axis values
@@ -119,6 +233,9 @@ func (m *DefaultMapper) applyAxisProcessing(value float64, mapping AxisMapping)
 		return 0.0
 	}
 
+	// Apply expo and super rate curves
+	value = applyCurves(value, mapping)
+
 	// Apply sensitivity
 	sensitivity := m.config.Controller.Sensitivity
 	if mapping.Sensitivity != nil {
@@ -141,75 +258,92 @@ func (m *DefaultMapper) applyAxisProcessing(value float64, mapping AxisMapping)
 	return value
 }
 
-// processButtons converts button states to drone actions
-func (m *DefaultMapper) processButtons(state *GamepadState) []DroneAction {
-	var actions []DroneAction
+// processButtons converts button states to action and macro commands
+func (m *DefaultMapper) processButtons(state *GamepadState) []Command {
+	var commands []Command
 	now := time.Now()
 
-	// Check Takeoff/Land button
-	if button, exists := state.Buttons[m.config.Mappings.Buttons.TakeoffLand.Button]; exists {
-		if m.shouldTriggerButtonAction(button, m.config.Mappings.Buttons.TakeoffLand, now) {
-			// Determine takeoff vs land based on current state or toggle logic
-			// For now, we'll use a simple toggle based on press duration
-			if button.Pressed && time.Since(button.PressTime) >= time.Duration(m.config.Mappings.Buttons.TakeoffLand.HoldTime)*time.Millisecond {
-				actions = append(actions, ActionTakeoff)
-			} else if !button.Pressed && time.Since(button.PressTime) < time.Duration(m.config.Mappings.Buttons.TakeoffLand.HoldTime)*time.Millisecond {
-				actions = append(actions, ActionLand)
+	bindings := m.bindings()
+	for _, binding := range bindings {
+		button, ok := bindingState(state, binding)
+		if !ok {
+			continue
+		}
+		if binding.isCombo() {
+			m.holdCombo(state, binding)
+		} else if m.inCombo(binding.Buttons[0], bindings) {
+			// Combo buttons act on release, unless the press went into a combo
+			if m.releasedOutsideCombo(button, binding.mapping()) {
+				if command, ok := m.triggerBinding(binding, button.PressTime); ok {
+					commands = append(commands, command)
+				}
 			}
+			continue
 		}
-	}
-
-	// Check Emergency button
-	if button, exists := state.Buttons[m.config.Mappings.Buttons.Emergency.Button]; exists {
-		if m.shouldTriggerButtonAction(button, m.config.Mappings.Buttons.Emergency, now) {
-			actions = append(actions, ActionEmergency)
+		if !m.shouldTriggerButtonAction(button, binding.mapping(), now) {
+			continue
 		}
-	}
-
-	// Check Flip Forward button
-	if button, exists := state.Buttons[m.config.Mappings.Buttons.FlipForward.Button]; exists {
-		if m.shouldTriggerButtonAction(button, m.config.Mappings.Buttons.FlipForward, now) {
-			actions = append(actions, ActionFlipForward)
+		if command, ok := m.triggerBinding(binding, button.PressTime); ok {
+			commands = append(commands, command)
 		}
 	}
 
-	// Check Flip Backward button
-	if button, exists := state.Buttons[m.config.Mappings.Buttons.FlipBackward.Button]; exists {
-		if m.shouldTriggerButtonAction(button, m.config.Mappings.Buttons.FlipBackward, now) {
-			actions = append(actions, ActionFlipBackward)
-		}
-	}
+	return commands
+}
 
-	// Check optional Flip Left button
-	if m.config.Mappings.Buttons.FlipLeft != nil {
-		if button, exists := state.Buttons[m.config.Mappings.Buttons.FlipLeft.Button]; exists {
-			if m.shouldTriggerButtonAction(button, *m.config.Mappings.Buttons.FlipLeft, now) {
-				actions = append(actions, ActionFlipLeft)
-			}
-		}
+// bindings returns the configured bindings
+func (m *DefaultMapper) bindings() []Binding {
+	return configBindings(m.config)
+}
+
+// triggerBinding issues the command of a press that met its binding's
+// requirements, unless the press already triggered, the action does not fit
+// the drone's flight state or it was issued moments ago
+func (m *DefaultMapper) triggerBinding(binding Binding, pressTime time.Time) (Command, bool) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	key := binding.key()
+	if firedAt, fired := m.firedAt[key]; fired && !pressTime.After(firedAt) {
+		return Command{}, false
 	}
+	now := time.Now()
+	m.firedAt[key] = now
 
-	// Check optional Flip Right button
-	if m.config.Mappings.Buttons.FlipRight != nil {
-		if button, exists := state.Buttons[m.config.Mappings.Buttons.FlipRight.Button]; exists {
-			if m.shouldTriggerButtonAction(button, *m.config.Mappings.Buttons.FlipRight, now) {
-				actions = append(actions, ActionFlipRight)
-			}
+	if len(binding.Macro) > 0 {
+		macro, err := ParseMacro(binding.Macro)
+		if err != nil {
+			utils.Logger.Warnf("Ignoring the macro on %s: %v", key, err)
+			return Command{}, false
 		}
+		return Command{Type: CommandMacro, Data: macro}, true
 	}
 
-	// Check optional Stream Toggle button
-	if m.config.Mappings.Buttons.StreamToggle != nil {
-		if button, exists := state.Buttons[m.config.Mappings.Buttons.StreamToggle.Button]; exists {
-			if m.shouldTriggerButtonAction(button, *m.config.Mappings.Buttons.StreamToggle, now) {
-				// This would need state tracking for proper toggle behavior
-				// For now, just trigger stream on
-				actions = append(actions, ActionStreamOn)
-			}
-		}
+	action := m.resolveAction(binding.Action)
+	if action == "" {
+		return Command{}, false
+	}
+	if flight := m.model.Flight(); !actionAllowed(action, flight) {
+		utils.Logger.Infof("Ignoring %s: the drone is %s", action, flight)
+		return Command{}, false
+	}
+	if action != ActionEmergency && now.Sub(m.issued[action]) < actionDebounce {
+		return Command{}, false
 	}
 
-	return actions
+	m.issued[action] = now
+	m.model.apply(action)
+	return Command{Type: CommandAction, Data: action}, true
+}
+
+// actionAllowed reports whether action fits the flight state
+func actionAllowed(action DroneAction, flight FlightState) bool {
+	switch action {
+	case ActionFlipForward, ActionFlipBackward, ActionFlipLeft, ActionFlipRight:
+		return flight == FlightFlying || flight == FlightUnknown
+	default:
+		return true
+	}
 }
 
 // shouldTriggerButtonAction determines if a button action should be triggered
@@ -233,22 +367,56 @@ func (m *DefaultMapper) shouldTriggerButtonAction(button *ButtonState, mapping B
 	return true
 }
 
-// mapButtonToAction maps a button type to a drone action (simplified version)
+// mapButtonToAction returns the action a button is bound to on its own,
+// resolving toggles from the drone model
 func (m *DefaultMapper) mapButtonToAction(button ButtonType) DroneAction {
-	switch button {
-	case m.config.Mappings.Buttons.TakeoffLand.Button:
-		return ActionTakeoff // Simplified - real logic would need state tracking
-	case m.config.Mappings.Buttons.Emergency.Button:
-		return ActionEmergency
-	case m.config.Mappings.Buttons.FlipForward.Button:
-		return ActionFlipForward
-	case m.config.Mappings.Buttons.FlipBackward.Button:
-		return ActionFlipBackward
+	for _, binding := range m.bindings() {
+		if !binding.isCombo() && binding.Buttons[0] == button && binding.Action != "" {
+			return m.resolveAction(binding.Action)
+		}
+	}
+	return ""
+}
+
+// resolveAction turns a toggle into the action that fits the drone model.
+// Takeoff/land does nothing while either is under way.
+func (m *DefaultMapper) resolveAction(action DroneAction) DroneAction {
+	switch action {
+	case ActionTakeoffLand:
+		switch m.model.Flight() {
+		case FlightFlying:
+			return ActionLand
+		case FlightTakingOff, FlightLanding:
+			return ""
+		default:
+			return ActionTakeoff
+		}
+	case ActionStreamToggle:
+		if m.model.Streaming() {
+			return ActionStreamOff
+		}
+		return ActionStreamOn
 	default:
-		return ""
+		return action
 	}
 }
 
+// applyCurves shapes the stick response. Expo blends in the cube of the
+// value, softening the center; super rate steepens the outer stick. Full
+// stick stays full output either way.
+func applyCurves(value float64, mapping AxisMapping) float64 {
+	stick := abs(value)
+	if mapping.Expo != nil {
+		expo := *mapping.Expo
+		value = (1-expo)*value + expo*value*value*value
+	}
+	if mapping.SuperRate != nil {
+		rate := *mapping.SuperRate
+		value = value * (1 - rate) / (1 - stick*rate)
+	}
+	return value
+}
+
 // abs returns the absolute value of a float64
 func abs(x float64) float64 {
 	if x < 0 {
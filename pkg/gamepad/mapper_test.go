@@ -6,6 +6,8 @@ import (
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
+
+	"github.com/conceptcodes/dji-tello-sdk-go/pkg/types"
 )
 
 func TestNewDefaultMapper(t *testing.T) {
@@ -26,6 +28,23 @@ func TestDefaultMapper_MapEvent(t *testing.T) {
 		config := DefaultConfig()
 		mapper := NewDefaultMapper(config)
 
+		event := Event{
+			Type:      EventButtonPress,
+			Input:     string(config.Mappings.Buttons.FlipForward.Button),
+			Value:     1.0,
+			Timestamp: time.Now(),
+		}
+
+		commands, err := mapper.MapEvent(event)
+		require.NoError(t, err)
+		// Should return a command for an instant button
+		assert.Equal(t, []Command{{Type: CommandAction, Data: ActionFlipForward}}, commands)
+	})
+
+	t.Run("map press of button with hold time", func(t *testing.T) {
+		config := DefaultConfig()
+		mapper := NewDefaultMapper(config)
+
 		event := Event{
 			Type:      EventButtonPress,
 			Input:     string(config.Mappings.Buttons.TakeoffLand.Button),
@@ -35,8 +54,8 @@ func TestDefaultMapper_MapEvent(t *testing.T) {
 
 		commands, err := mapper.MapEvent(event)
 		require.NoError(t, err)
-		// Should return a command for takeoff/land button
-		assert.NotEmpty(t, commands)
+		// Takeoff/land must be held, which MapState sees
+		assert.Empty(t, commands)
 	})
 
 	t.Run("map button release event", func(t *testing.T) {
@@ -360,7 +379,7 @@ func TestDefaultMapper_processButtons(t *testing.T) {
 
 		actions := mapper.processButtons(state)
 		// Should have takeoff action
-		assert.Contains(t, actions, ActionTakeoff)
+		assert.Contains(t, actions, Command{Type: CommandAction, Data: ActionTakeoff})
 	})
 
 	t.Run("process emergency button", func(t *testing.T) {
@@ -394,7 +413,7 @@ func TestDefaultMapper_processButtons(t *testing.T) {
 		}
 
 		actions := mapper.processButtons(state)
-		assert.Contains(t, actions, ActionFlipForward)
+		assert.Contains(t, actions, Command{Type: CommandAction, Data: ActionFlipForward})
 	})
 
 	t.Run("process button with hold time requirement", func(t *testing.T) {
@@ -412,7 +431,7 @@ func TestDefaultMapper_processButtons(t *testing.T) {
 
 		actions := mapper.processButtons(state)
 		// Should not trigger because hold time not met
-		assert.NotContains(t, actions, ActionTakeoff)
+		assert.NotContains(t, actions, Command{Type: CommandAction, Data: ActionTakeoff})
 	})
 
 	t.Run("process button with double tap requirement", func(t *testing.T) {
@@ -432,7 +451,7 @@ func TestDefaultMapper_processButtons(t *testing.T) {
 
 		actions := mapper.processButtons(state)
 		// Should not trigger because double tap required
-		assert.NotContains(t, actions, ActionEmergency)
+		assert.NotContains(t, actions, Command{Type: CommandAction, Data: ActionEmergency})
 
 		// Now with double tap
 		state.Buttons[config.Mappings.Buttons.Emergency.Button] = &ButtonState{
@@ -590,3 +609,128 @@ func TestAbsFunction(t *testing.T) {
 		assert.Equal(t, 0.0, result)
 	})
 }
+
+// heldButton returns a state with button pressed for hold
+func heldButton(button ButtonType, hold time.Duration) *GamepadState {
+	state := NewGamepadState()
+	state.Buttons[button] = &ButtonState{
+		Pressed:   true,
+		PressTime: time.Now().Add(-hold),
+		TapCount:  1,
+	}
+	return state
+}
+
+// actionCommands returns the commands that issue actions
+func actionCommands(actions ...DroneAction) []Command {
+	commands := make([]Command, len(actions))
+	for i, action := range actions {
+		commands[i] = Command{Type: CommandAction, Data: action}
+	}
+	return commands
+}
+
+// rewind makes the mapper's past triggers d older, as if time had passed
+func rewind(mapper *DefaultMapper, d time.Duration) {
+	for button, at := range mapper.firedAt {
+		mapper.firedAt[button] = at.Add(-d)
+	}
+	for action, at := range mapper.issued {
+		mapper.issued[action] = at.Add(-d)
+	}
+}
+
+func TestDefaultMapper_DroneState(t *testing.T) {
+	t.Run("takeoff/land toggles with flight state", func(t *testing.T) {
+		config := DefaultConfig()
+		mapper := NewDefaultMapper(config)
+		button := config.Mappings.Buttons.TakeoffLand.Button
+		hold := time.Duration(config.Mappings.Buttons.TakeoffLand.HoldTime) * time.Millisecond
+		mapper.Model().UpdateState(&types.State{H: 0})
+
+		assert.Equal(t, actionCommands(ActionTakeoff), mapper.processButtons(heldButton(button, hold)))
+
+		// Pressing again while the drone climbs does nothing
+		rewind(mapper, time.Minute)
+		assert.Empty(t, mapper.processButtons(heldButton(button, hold)))
+
+		mapper.Model().UpdateState(&types.State{H: 80})
+		rewind(mapper, time.Minute)
+		assert.Equal(t, actionCommands(ActionLand), mapper.processButtons(heldButton(button, hold)))
+	})
+
+	t.Run("flips are refused on the ground", func(t *testing.T) {
+		config := DefaultConfig()
+		mapper := NewDefaultMapper(config)
+		button := config.Mappings.Buttons.FlipForward.Button
+		mapper.Model().UpdateState(&types.State{H: 0})
+
+		assert.Empty(t, mapper.processButtons(heldButton(button, 0)))
+
+		mapper.Model().UpdateState(&types.State{H: 80})
+		rewind(mapper, time.Minute)
+		assert.Equal(t, actionCommands(ActionFlipForward), mapper.processButtons(heldButton(button, 0)))
+	})
+
+	t.Run("stream toggle alternates", func(t *testing.T) {
+		config := DefaultConfig()
+		mapper := NewDefaultMapper(config)
+		button := config.Mappings.Buttons.StreamToggle.Button
+
+		assert.Equal(t, actionCommands(ActionStreamOn), mapper.processButtons(heldButton(button, 0)))
+		rewind(mapper, time.Minute)
+		assert.Equal(t, actionCommands(ActionStreamOff), mapper.processButtons(heldButton(button, 0)))
+
+		mapper.Model().ActionFailed(ActionStreamOff)
+		rewind(mapper, time.Minute)
+		assert.Equal(t, actionCommands(ActionStreamOff), mapper.processButtons(heldButton(button, 0)))
+	})
+}
+
+func TestDefaultMapper_Debounce(t *testing.T) {
+	t.Run("held button triggers once", func(t *testing.T) {
+		config := DefaultConfig()
+		mapper := NewDefaultMapper(config)
+		state := heldButton(config.Mappings.Buttons.FlipForward.Button, 0)
+
+		assert.Equal(t, actionCommands(ActionFlipForward), mapper.processButtons(state))
+		for i := 0; i < 3; i++ {
+			assert.Empty(t, mapper.processButtons(state))
+		}
+	})
+
+	t.Run("press event and state trigger once", func(t *testing.T) {
+		config := DefaultConfig()
+		mapper := NewDefaultMapper(config)
+		button := config.Mappings.Buttons.FlipForward.Button
+		state := heldButton(button, 0)
+
+		commands, err := mapper.MapEvent(Event{Type: EventButtonPress, Input: string(button), Value: 1.0, Timestamp: time.Now()})
+		require.NoError(t, err)
+		assert.Len(t, commands, 1)
+		assert.Empty(t, mapper.processButtons(state))
+	})
+
+	t.Run("quick second press is ignored", func(t *testing.T) {
+		config := DefaultConfig()
+		mapper := NewDefaultMapper(config)
+		button := config.Mappings.Buttons.FlipForward.Button
+
+		assert.NotEmpty(t, mapper.processButtons(heldButton(button, 0)))
+		rewind(mapper, actionDebounce/2)
+		assert.Empty(t, mapper.processButtons(heldButton(button, 0)))
+		rewind(mapper, actionDebounce)
+		assert.Equal(t, actionCommands(ActionFlipForward), mapper.processButtons(heldButton(button, 0)))
+	})
+
+	t.Run("emergency is never held back", func(t *testing.T) {
+		config := DefaultConfig()
+		mapper := NewDefaultMapper(config)
+		button := config.Mappings.Buttons.Emergency.Button
+		hold := time.Duration(config.Mappings.Buttons.Emergency.HoldTime) * time.Millisecond
+
+		assert.Equal(t, actionCommands(ActionEmergency), mapper.processButtons(heldButton(button, hold)))
+		rewind(mapper, hold) // A new press held as long, sooner than the debounce allows
+		assert.Equal(t, actionCommands(ActionEmergency), mapper.processButtons(heldButton(button, hold)))
+	})
+}
@@ -47,6 +47,8 @@ type AxisMapping struct {
 	Invert      bool     `json:"invert"`
 	Deadzone    *float64 `json:"deadzone,omitempty"`
 	Sensitivity *float64 `json:"sensitivity,omitempty"`
+	Expo        *float64 `json:"expo,omitempty"`       // 0.0 (linear) to 1.0 (cubic), softens the center
+	SuperRate   *float64 `json:"super_rate,omitempty"` // 0.0 to 0.95, steepens the outer stick
 }
 
 // ButtonMapping defines how a button is mapped to drone actions
@@ -75,10 +77,22 @@ type ButtonsMapping struct {
 	StreamToggle *ButtonMapping `json:"stream_toggle,omitempty"`
 }
 
-// Mappings contains all controller mappings
+// Binding maps a button, or buttons held together, to a drone action or a
+// macro. Exactly one of Action and Macro is set.
+type Binding struct {
+	Buttons   []ButtonType `json:"buttons"`              // One button, or a combination held together
+	HoldTime  int          `json:"hold_time,omitempty"`  // milliseconds
+	DoubleTap bool         `json:"double_tap,omitempty"` // require double tap
+	Action    DroneAction  `json:"action,omitempty"`
+	Macro     []string     `json:"macro,omitempty"` // Steps run in turn, e.g. ["up 50", "cw 360", "photo"]
+}
+
+// Mappings contains all controller mappings. The fixed Buttons of older
+// profiles work as bindings; a binding with the same trigger replaces one.
 type Mappings struct {
-	Axes    AxesMapping    `json:"axes"`
-	Buttons ButtonsMapping `json:"buttons"`
+	Axes     AxesMapping    `json:"axes"`
+	Buttons  ButtonsMapping `json:"buttons,omitzero"`
+	Bindings []Binding      `json:"bindings,omitempty"`
 }
 
 // RCLimits defines safety limits for RC control values
@@ -110,12 +124,55 @@ type ControllerConfig struct {
 	ControllerID *string `json:"controller_id,omitempty"`
 }
 
+// FlightMode is a set of stick limits and response the pilot can switch to
+type FlightMode struct {
+	Name      string   `json:"name"`
+	RCLimits  RCLimits `json:"rc_limits"`           // Replace the safety RC limits while the mode is active
+	Expo      *float64 `json:"expo,omitempty"`      // Overrides the expo of every axis
+	Smoothing float64  `json:"smoothing,omitempty"` // Share of the last RC value kept every 1/60 s (0.0-0.95)
+	SlewRate  int      `json:"slew_rate,omitempty"` // Most an RC value may change per second (0 for no limit)
+}
+
+// FlightModes contains the flight modes and how to cycle through them
+type FlightModes struct {
+	Modes      []FlightMode `json:"modes"`
+	Default    string       `json:"default,omitempty"`     // Mode to start in (default: the first)
+	CycleCombo []ButtonType `json:"cycle_combo,omitempty"` // Buttons held together to cycle (default: both bumpers)
+}
+
+// RumblePulse is one burst of a rumble pattern
+type RumblePulse struct {
+	Low      float64 `json:"low"`             // Strength of the low frequency (left) motor, 0.0-1.0
+	High     float64 `json:"high"`            // Strength of the high frequency (right) motor, 0.0-1.0
+	Duration int     `json:"duration"`        // milliseconds
+	Pause    int     `json:"pause,omitempty"` // milliseconds of quiet before the next pulse
+}
+
+// FeedbackPattern is how the controller signals a safety event
+type FeedbackPattern struct {
+	Event    string        `json:"event"`              // Safety event type, e.g. battery, altitude, sensor or connection
+	Level    string        `json:"level,omitempty"`    // Event level it is for (default: any)
+	Rumble   []RumblePulse `json:"rumble,omitempty"`   // Pulses played in turn
+	LED      string        `json:"led,omitempty"`      // Light bar color, e.g. "#ff0000"
+	Hold     int           `json:"hold,omitempty"`     // milliseconds the color stays after the last event (0 until replaced)
+	Cooldown int           `json:"cooldown,omitempty"` // milliseconds before the rumble plays again (default: 10000)
+}
+
+// FeedbackConfig contains the rumble and light bar patterns played for
+// safety events, on controllers that have them
+type FeedbackConfig struct {
+	LED      string            `json:"led,omitempty"` // Light bar color when nothing is signalled
+	Patterns []FeedbackPattern `json:"patterns"`      // The first pattern matching an event plays
+}
+
 // Config is the main gamepad configuration structure
 type Config struct {
-	Version    string           `json:"version"`
-	Controller ControllerConfig `json:"controller"`
-	Safety     Safety           `json:"safety"`
-	Mappings   Mappings         `json:"mappings"`
+	Version     string           `json:"version"`
+	Controller  ControllerConfig `json:"controller"`
+	Safety      Safety           `json:"safety"`
+	Mappings    Mappings         `json:"mappings"`
+	FlightModes *FlightModes     `json:"flight_modes,omitempty"`
+	Feedback    *FeedbackConfig  `json:"feedback,omitempty"`
 }
 
 // ButtonState represents the current state of a button
@@ -161,14 +218,21 @@ const (
 	ActionFlipRight    DroneAction = "flip_right"
 	ActionStreamOn     DroneAction = "stream_on"
 	ActionStreamOff    DroneAction = "stream_off"
+	ActionPhoto        DroneAction = "photo"
+
+	// Toggles, which the mapper resolves from the drone model
+	ActionTakeoffLand  DroneAction = "takeoff_land"
+	ActionStreamToggle DroneAction = "stream_toggle"
 )
 
 // CommandType represents the type of command
 type CommandType string
 
 const (
-	CommandRC     CommandType = "rc"     // RC control values
-	CommandAction CommandType = "action" // Discrete drone action
+	CommandRC         CommandType = "rc"          // RC control values
+	CommandAction     CommandType = "action"      // Discrete drone action
+	CommandFlightMode CommandType = "flight_mode" // The flight mode changed
+	CommandMacro      CommandType = "macro"       // Steps to run in turn
 )
 
 // CommandData is a union type for command data
@@ -182,10 +246,16 @@ func (r RCValues) isCommandData() {}
 // DroneAction implements CommandData
 func (d DroneAction) isCommandData() {}
 
+// FlightModeName names the flight mode a flight_mode command switched to
+type FlightModeName string
+
+// FlightModeName implements CommandData
+func (f FlightModeName) isCommandData() {}
+
 // Command represents a high-level drone command
 type Command struct {
 	Type CommandType `json:"type"`
-	Data CommandData `json:"data"` // RCValues for rc, DroneAction for action
+	Data CommandData `json:"data"` // RCValues for rc, DroneAction for action, FlightModeName for flight_mode, Macro for macro
 }
 
 // Mapper defines the interface for converting gamepad events to drone commands
@@ -5,6 +5,7 @@ import (
 	"testing"
 
 	"github.com/conceptcodes/dji-tello-sdk-go/pkg/safety"
+	"github.com/conceptcodes/dji-tello-sdk-go/pkg/tello"
 	"github.com/conceptcodes/dji-tello-sdk-go/pkg/transport"
 	"github.com/conceptcodes/dji-tello-sdk-go/pkg/types"
 )
@@ -84,7 +85,7 @@ func (m *MockCommander) Backward(distance int) error      { return nil }
 func (m *MockCommander) Clockwise(angle int) error        { return nil }
 func (m *MockCommander) CounterClockwise(angle int) error { return nil }
 
-func (m *MockCommander) Flip(direction string) error {
+func (m *MockCommander) Flip(direction tello.FlipDirection) error {
 	m.flipCalled = true
 	return m.flipError
 }
@@ -92,6 +93,13 @@ func (m *MockCommander) Flip(direction string) error {
 func (m *MockCommander) Go(x, y, z, speed int) error                   { return nil }
 func (m *MockCommander) Curve(x1, y1, z1, x2, y2, z2, speed int) error { return nil }
 
+// Mission Pad Commands
+func (m *MockCommander) EnableMissionPads(enabled bool) error                             { return nil }
+func (m *MockCommander) SetMissionPadDirection(direction tello.MissionPadDirection) error { return nil }
+func (m *MockCommander) GoToPad(x, y, z, speed, mid int) error                            { return nil }
+func (m *MockCommander) CurveToPad(x1, y1, z1, x2, y2, z2, speed, mid int) error          { return nil }
+func (m *MockCommander) JumpBetweenPads(x, y, z, speed, yaw, mid1, mid2 int) error        { return nil }
+
 // Set Commands
 func (m *MockCommander) SetSpeed(speed int) error                       { return nil }
 func (m *MockCommander) SetRcControl(a, b, c, d int) error              { return nil }
@@ -109,7 +117,7 @@ func (m *MockCommander) GetAcceleration() (int, int, int, error) { return 0, 0,
 func (m *MockCommander) GetTof() (int, error)                    { return 200, nil }
 
 // Video Commands
-func (m *MockCommander) SetVideoFrameCallback(callback func(transport.VideoFrame)) {}
+func (m *MockCommander) SetVideoFrameCallback(callback tello.VideoFrameCallback) {}
 func (m *MockCommander) GetVideoFrameChannel() <-chan transport.VideoFrame {
 	ch := make(chan transport.VideoFrame)
 	close(ch)
@@ -0,0 +1,444 @@
+package mission
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/conceptcodes/dji-tello-sdk-go/pkg/utils"
+)
+
+// ErrAborted is returned by Executor.Run when the mission was aborted
+var ErrAborted = errors.New("mission aborted")
+
+// Commander is the part of tello.TelloCommander and safety.Manager that
+// missions are flown with
+type Commander interface {
+	TakeOff() error
+	Land() error
+	Go(x, y, z, speed int) error
+	Curve(x1, y1, z1, x2, y2, z2, speed int) error
+	Clockwise(angle int) error
+	CounterClockwise(angle int) error
+	GetBatteryPercentage() (int, error)
+	GetHeight() (int, error)
+}
+
+// CompletionWaiter reports when the drone has finished a command, which it
+// answers once done. navigation.Odometry implements it when registered with
+// tello.WithFlightRecorder.
+type CompletionWaiter interface {
+	AwaitCommand(command string) (<-chan bool, func())
+}
+
+// PhotoCapturer saves a photo from the drone's camera under name and returns
+// the path it was written to
+type PhotoCapturer interface {
+	CapturePhoto(name string) (string, error)
+}
+
+// Status is the state of an Executor
+type Status string
+
+const (
+	StatusIdle      Status = "idle"
+	StatusRunning   Status = "running"
+	StatusPaused    Status = "paused"
+	StatusCompleted Status = "completed"
+	StatusAborted   Status = "aborted"
+	StatusFailed    Status = "failed"
+)
+
+// Event reports the progress of a step
+type Event struct {
+	Path  string // Position in the mission, as in PlanStep
+	Step  *Step
+	Done  bool   // False when the step starts, true when it has finished
+	Err   error  // Why the step failed, when Done
+	Photo string // Path of the photo taken by a photo step
+}
+
+// ExecutorOption configures an Executor
+type ExecutorOption func(*Executor)
+
+// WithCompletionWaiter makes the executor wait for the drone to finish each
+// command. Without one, it waits for the predicted duration of the step.
+func WithCompletionWaiter(waiter CompletionWaiter) ExecutorOption {
+	return func(e *Executor) {
+		e.waiter = waiter
+	}
+}
+
+// WithPhotoCapturer sets the camera used by photo steps. Missions with photo
+// steps fail to start without one.
+func WithPhotoCapturer(capturer PhotoCapturer) ExecutorOption {
+	return func(e *Executor) {
+		e.capturer = capturer
+	}
+}
+
+// WithEventHandler calls handler when each step starts and finishes
+func WithEventHandler(handler func(Event)) ExecutorOption {
+	return func(e *Executor) {
+		e.onEvent = handler
+	}
+}
+
+// WithPollInterval sets how often wait_until steps read the drone's
+// telemetry (default: 1s)
+func WithPollInterval(interval time.Duration) ExecutorOption {
+	return func(e *Executor) {
+		e.pollInterval = interval
+	}
+}
+
+// WithStepTimeout sets the time allowed beyond a step's predicted duration
+// for the drone to report that it has finished (default: 10s)
+func WithStepTimeout(timeout time.Duration) ExecutorOption {
+	return func(e *Executor) {
+		e.stepTimeout = timeout
+	}
+}
+
+// Executor flies a mission. Pause holds the mission before its next step,
+// since a move cannot be interrupted once sent; Abort stops it and lands.
+type Executor struct {
+	commander    Commander
+	mission      *Mission
+	waiter       CompletionWaiter
+	capturer     PhotoCapturer
+	onEvent      func(Event)
+	pollInterval time.Duration
+	stepTimeout  time.Duration
+
+	mu      sync.Mutex
+	status  Status
+	resume  chan struct{} // Closed to resume a paused mission
+	cancel  context.CancelFunc
+	aborted bool
+	flying  bool
+	photos  int
+}
+
+// NewExecutor creates an executor for mission. The mission should have been
+// validated by a Loader or Mission.Validate.
+func NewExecutor(commander Commander, mission *Mission, opts ...ExecutorOption) *Executor {
+	e := &Executor{
+		commander:    commander,
+		mission:      mission,
+		pollInterval: time.Second,
+		stepTimeout:  10 * time.Second,
+		status:       StatusIdle,
+	}
+	for _, opt := range opts {
+		opt(e)
+	}
+	return e
+}
+
+// Status returns the state of the mission
+func (e *Executor) Status() Status {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	return e.status
+}
+
+// Pause holds the mission once the current step has finished
+func (e *Executor) Pause() {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	if e.status == StatusRunning {
+		e.status = StatusPaused
+		e.resume = make(chan struct{})
+	}
+}
+
+// Resume continues a paused mission
+func (e *Executor) Resume() {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	if e.status == StatusPaused {
+		e.status = StatusRunning
+		close(e.resume)
+		e.resume = nil
+	}
+}
+
+// Abort stops the mission. If the drone is flying, Run lands it before
+// returning ErrAborted.
+func (e *Executor) Abort() {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.aborted = true
+	if e.cancel != nil {
+		e.cancel()
+	}
+}
+
+// Run flies the mission and blocks until it has finished. If a step fails
+// or the mission is aborted while the drone is flying, the drone is landed.
+// An executor can only be run once.
+func (e *Executor) Run(ctx context.Context) error {
+	if e.mission.HasPhotos() && e.capturer == nil {
+		return fmt.Errorf("mission %q takes photos but no camera is available", e.mission.Name)
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	e.mu.Lock()
+	if e.status != StatusIdle {
+		e.mu.Unlock()
+		return fmt.Errorf("mission has already been run")
+	}
+	if e.aborted {
+		e.status = StatusAborted
+		e.mu.Unlock()
+		return ErrAborted
+	}
+	e.status = StatusRunning
+	e.cancel = cancel
+	e.mu.Unlock()
+
+	utils.Logger.Infof("Starting mission %q", e.mission.Name)
+	err := e.runSteps(ctx, e.mission.Steps, "", "")
+
+	e.mu.Lock()
+	if e.aborted {
+		err = ErrAborted
+	}
+	flying := e.flying
+	switch {
+	case err == nil:
+		e.status = StatusCompleted
+	case errors.Is(err, ErrAborted):
+		e.status = StatusAborted
+	default:
+		e.status = StatusFailed
+	}
+	e.mu.Unlock()
+
+	if err != nil && flying {
+		utils.Logger.Warnf("Mission %q stopped in flight (%v), landing", e.mission.Name, err)
+		if landErr := e.commander.Land(); landErr != nil {
+			utils.Logger.Errorf("Failed to land after mission stopped: %v", landErr)
+		}
+	}
+	if err == nil {
+		utils.Logger.Infof("Mission %q completed", e.mission.Name)
+	}
+	return err
+}
+
+func (e *Executor) runSteps(ctx context.Context, steps []Step, prefix, iteration string) error {
+	for i := range steps {
+		step := &steps[i]
+		path := stepPath(prefix, i)
+
+		if step.Type == StepLoop {
+			for n := 1; n <= step.Count; n++ {
+				if err := e.runSteps(ctx, step.Steps, path+iteration, "#"+strconv.Itoa(n)); err != nil {
+					return err
+				}
+			}
+			continue
+		}
+
+		if err := e.checkpoint(ctx); err != nil {
+			return err
+		}
+
+		event := Event{Path: path + iteration, Step: step}
+		e.emit(event)
+		photo, err := e.runStep(ctx, step)
+		event.Done, event.Err, event.Photo = true, err, photo
+		e.emit(event)
+		if err != nil {
+			if ctx.Err() != nil {
+				return ctx.Err()
+			}
+			return fmt.Errorf("step %s (%s) failed: %w", event.Path, step, err)
+		}
+	}
+	return nil
+}
+
+// checkpoint blocks while the mission is paused
+func (e *Executor) checkpoint(ctx context.Context) error {
+	e.mu.Lock()
+	resume := e.resume
+	e.mu.Unlock()
+
+	if resume != nil {
+		select {
+		case <-resume:
+		case <-ctx.Done():
+		}
+	}
+	return ctx.Err()
+}
+
+func (e *Executor) emit(event Event) {
+	if e.onEvent != nil {
+		e.onEvent(event)
+	}
+}
+
+func (e *Executor) runStep(ctx context.Context, step *Step) (string, error) {
+	speed := e.mission.speed(step)
+	switch step.Type {
+	case StepTakeoff:
+		// Flying from the moment takeoff is sent, so that stopping during the
+		// climb still lands
+		return "", e.fly(ctx, step, "takeoff", func() error {
+			e.setFlying(true)
+			return e.commander.TakeOff()
+		})
+	case StepLand:
+		err := e.fly(ctx, step, "land", e.commander.Land)
+		if err == nil {
+			e.setFlying(false)
+		}
+		return "", err
+	case StepGo:
+		return "", e.fly(ctx, step, fmt.Sprintf("go %d %d %d %d", step.X, step.Y, step.Z, speed), func() error {
+			return e.commander.Go(step.X, step.Y, step.Z, speed)
+		})
+	case StepCurve:
+		command := fmt.Sprintf("curve %d %d %d %d %d %d %d", step.X1, step.Y1, step.Z1, step.X2, step.Y2, step.Z2, speed)
+		return "", e.fly(ctx, step, command, func() error {
+			return e.commander.Curve(step.X1, step.Y1, step.Z1, step.X2, step.Y2, step.Z2, speed)
+		})
+	case StepRotate:
+		if step.Angle < 0 {
+			return "", e.fly(ctx, step, fmt.Sprintf("ccw %d", -step.Angle), func() error {
+				return e.commander.CounterClockwise(-step.Angle)
+			})
+		}
+		return "", e.fly(ctx, step, fmt.Sprintf("cw %d", step.Angle), func() error {
+			return e.commander.Clockwise(step.Angle)
+		})
+	case StepWait:
+		return "", sleep(ctx, seconds(step.Seconds))
+	case StepWaitUntil:
+		return "", e.waitUntil(ctx, step)
+	case StepPhoto:
+		return e.photo(step)
+	default:
+		return "", fmt.Errorf("unknown step type %q", step.Type)
+	}
+}
+
+// fly sends a command and waits until the drone has finished it
+func (e *Executor) fly(ctx context.Context, step *Step, command string, send func() error) error {
+	estimate := e.mission.estimate(step)
+
+	if e.waiter == nil {
+		if err := send(); err != nil {
+			return err
+		}
+		return sleep(ctx, estimate)
+	}
+
+	// Register for the answer before sending so a fast one cannot be missed
+	completed, stop := e.waiter.AwaitCommand(command)
+	defer stop()
+	if err := send(); err != nil {
+		return err
+	}
+
+	timeout := estimate + e.stepTimeout
+	timer := time.NewTimer(timeout)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return fmt.Errorf("no response to '%s' within %v", command, timeout)
+	case ok := <-completed:
+		if !ok {
+			return fmt.Errorf("drone rejected '%s'", command)
+		}
+		return nil
+	}
+}
+
+// waitUntil polls the step's metric until its condition holds
+func (e *Executor) waitUntil(ctx context.Context, step *Step) error {
+	read := e.commander.GetBatteryPercentage
+	if step.Metric == MetricHeight {
+		read = e.commander.GetHeight
+	}
+
+	deadline := time.Now().Add(seconds(step.timeout()))
+	for {
+		value, err := read()
+		if err != nil {
+			utils.Logger.Warnf("Failed to read %s: %v", step.Metric, err)
+		} else if (step.Above == nil || value >= *step.Above) && (step.Below == nil || value <= *step.Below) {
+			return nil
+		}
+
+		if time.Now().After(deadline) {
+			if err != nil {
+				return fmt.Errorf("failed to read %s within %gs: %w", step.Metric, step.timeout(), err)
+			}
+			return fmt.Errorf("%s was %d after %gs", step.Metric, value, step.timeout())
+		}
+		if err := sleep(ctx, e.pollInterval); err != nil {
+			return err
+		}
+	}
+}
+
+// photo saves a photo named after the step, or numbered in mission order
+func (e *Executor) photo(step *Step) (string, error) {
+	e.mu.Lock()
+	e.photos++
+	name := step.Name
+	if name == "" {
+		name = fmt.Sprintf("photo-%03d.jpg", e.photos)
+	}
+	e.mu.Unlock()
+
+	path, err := e.capturer.CapturePhoto(name)
+	if err != nil {
+		return "", err
+	}
+	utils.Logger.Infof("Saved photo %s", path)
+	return path, nil
+}
+
+func (e *Executor) setFlying(flying bool) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.flying = flying
+}
+
+// HasPhotos reports whether any step takes a photo
+func (m *Mission) HasPhotos() bool {
+	var walk func([]Step) bool
+	walk = func(steps []Step) bool {
+		for i := range steps {
+			if steps[i].Type == StepPhoto || walk(steps[i].Steps) {
+				return true
+			}
+		}
+		return false
+	}
+	return walk(m.Steps)
+}
+
+func sleep(ctx context.Context, d time.Duration) error {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
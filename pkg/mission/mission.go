@@ -0,0 +1,445 @@
+// Package mission runs scripted flights described in YAML or JSON files.
+//
+// A mission is a list of steps (takeoff, go, curve, rotate, wait, wait_until,
+// photo, loop and land) that is validated against the mission schema in
+// configs/schemas, planned without a drone for dry runs, and flown by an
+// Executor that can be paused, resumed and aborted:
+//
+//	name: square
+//	speed: 50
+//	steps:
+//	  - type: takeoff
+//	  - type: loop
+//	    count: 4
+//	    steps:
+//	      - type: go
+//	        x: 100
+//	        y: 0
+//	        z: 0
+//	      - type: rotate
+//	        angle: 90
+//	  - type: land
+//
+// Offsets use the SDK body frame: x forward, y left and z up, in cm.
+package mission
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/conceptcodes/dji-tello-sdk-go/configs"
+	"github.com/conceptcodes/dji-tello-sdk-go/internal/config"
+	"github.com/conceptcodes/dji-tello-sdk-go/pkg/transport"
+	"github.com/conceptcodes/dji-tello-sdk-go/pkg/utils"
+	"github.com/santhosh-tekuri/jsonschema/v6"
+	"gopkg.in/yaml.v3"
+)
+
+// StepType identifies what a step does
+type StepType string
+
+const (
+	StepTakeoff   StepType = "takeoff"
+	StepLand      StepType = "land"
+	StepGo        StepType = "go"
+	StepCurve     StepType = "curve"
+	StepRotate    StepType = "rotate"
+	StepWait      StepType = "wait"
+	StepWaitUntil StepType = "wait_until"
+	StepPhoto     StepType = "photo"
+	StepLoop      StepType = "loop"
+)
+
+// Metrics a wait_until step can check
+const (
+	MetricBattery = "battery" // Battery percentage
+	MetricHeight  = "height"  // Height above takeoff (cm)
+)
+
+const (
+	// DefaultSpeed is the speed of go and curve steps when neither the step
+	// nor the mission sets one (cm/s)
+	DefaultSpeed = 50
+
+	// DefaultWaitTimeout is how long a wait_until step waits by default (s)
+	DefaultWaitTimeout = 30
+
+	// maxCurveSpeed is the SDK's speed limit for curve (cm/s)
+	maxCurveSpeed = 60
+
+	// minGoOffset is the SDK's smallest move: x, y and z cannot all be
+	// within this distance at once
+	minGoOffset = 20
+
+	// Arc radius limits of the SDK's curve command (cm)
+	minCurveRadius = 50
+	maxCurveRadius = 1000
+)
+
+// Mission is a scripted flight
+type Mission struct {
+	Name        string `json:"name" yaml:"name"`
+	Description string `json:"description,omitempty" yaml:"description,omitempty"`
+
+	// Speed is the default speed of go and curve steps (cm/s)
+	Speed int `json:"speed,omitempty" yaml:"speed,omitempty"`
+
+	Steps []Step `json:"steps" yaml:"steps"`
+}
+
+// Step is one action of a mission. Which fields apply depends on Type.
+type Step struct {
+	Type StepType `json:"type" yaml:"type"`
+
+	// go: offset to fly to
+	X int `json:"x,omitempty" yaml:"x,omitempty"`
+	Y int `json:"y,omitempty" yaml:"y,omitempty"`
+	Z int `json:"z,omitempty" yaml:"z,omitempty"`
+
+	// curve: the arc passes through (X1, Y1, Z1) and ends at (X2, Y2, Z2)
+	X1 int `json:"x1,omitempty" yaml:"x1,omitempty"`
+	Y1 int `json:"y1,omitempty" yaml:"y1,omitempty"`
+	Z1 int `json:"z1,omitempty" yaml:"z1,omitempty"`
+	X2 int `json:"x2,omitempty" yaml:"x2,omitempty"`
+	Y2 int `json:"y2,omitempty" yaml:"y2,omitempty"`
+	Z2 int `json:"z2,omitempty" yaml:"z2,omitempty"`
+
+	// go, curve: speed overriding the mission speed (cm/s)
+	Speed int `json:"speed,omitempty" yaml:"speed,omitempty"`
+
+	// rotate: degrees, clockwise when positive
+	Angle int `json:"angle,omitempty" yaml:"angle,omitempty"`
+
+	// wait: duration in seconds
+	Seconds float64 `json:"seconds,omitempty" yaml:"seconds,omitempty"`
+
+	// wait_until: waits until Metric is at least Above and at most Below,
+	// failing the mission after Timeout seconds
+	Metric  string  `json:"metric,omitempty" yaml:"metric,omitempty"`
+	Above   *int    `json:"above,omitempty" yaml:"above,omitempty"`
+	Below   *int    `json:"below,omitempty" yaml:"below,omitempty"`
+	Timeout float64 `json:"timeout,omitempty" yaml:"timeout,omitempty"`
+
+	// photo: file name of the photo, a .jpg, .jpeg or .png
+	Name string `json:"name,omitempty" yaml:"name,omitempty"`
+
+	// loop: runs Steps Count times
+	Count int    `json:"count,omitempty" yaml:"count,omitempty"`
+	Steps []Step `json:"steps,omitempty" yaml:"steps,omitempty"`
+}
+
+// String describes the step in the style of the SDK command it sends
+func (s *Step) String() string {
+	switch s.Type {
+	case StepGo:
+		return fmt.Sprintf("go %d %d %d", s.X, s.Y, s.Z)
+	case StepCurve:
+		return fmt.Sprintf("curve %d %d %d %d %d %d", s.X1, s.Y1, s.Z1, s.X2, s.Y2, s.Z2)
+	case StepRotate:
+		if s.Angle < 0 {
+			return fmt.Sprintf("ccw %d", -s.Angle)
+		}
+		return fmt.Sprintf("cw %d", s.Angle)
+	case StepWait:
+		return fmt.Sprintf("wait %gs", s.Seconds)
+	case StepWaitUntil:
+		var conditions []string
+		if s.Above != nil {
+			conditions = append(conditions, fmt.Sprintf("%s >= %d", s.Metric, *s.Above))
+		}
+		if s.Below != nil {
+			conditions = append(conditions, fmt.Sprintf("%s <= %d", s.Metric, *s.Below))
+		}
+		return "wait until " + strings.Join(conditions, " and ")
+	case StepPhoto:
+		if s.Name != "" {
+			return "photo " + s.Name
+		}
+		return "photo"
+	case StepLoop:
+		return fmt.Sprintf("loop %dx", s.Count)
+	default:
+		return string(s.Type)
+	}
+}
+
+// speed returns the step's speed, falling back to the mission speed
+func (m *Mission) speed(step *Step) int {
+	if step.Speed > 0 {
+		return step.Speed
+	}
+	if m.Speed > 0 {
+		return m.Speed
+	}
+	return DefaultSpeed
+}
+
+// timeout returns the wait_until timeout in seconds
+func (s *Step) timeout() float64 {
+	if s.Timeout > 0 {
+		return s.Timeout
+	}
+	return DefaultWaitTimeout
+}
+
+// Validate checks the parts of a mission the schema cannot: the SDK's
+// limits on go and curve, that the drone is flying when it moves, and that
+// loops end in the flight state they started in.
+func (m *Mission) Validate() error {
+	if len(m.Steps) == 0 {
+		return fmt.Errorf("mission has no steps")
+	}
+	flying, err := m.validateSteps(m.Steps, "", false)
+	if err != nil {
+		return err
+	}
+	if flying {
+		return fmt.Errorf("mission ends in flight; add a land step")
+	}
+	return nil
+}
+
+func (m *Mission) validateSteps(steps []Step, prefix string, flying bool) (bool, error) {
+	for i := range steps {
+		step := &steps[i]
+		path := stepPath(prefix, i)
+		var err error
+		flying, err = m.validateStep(step, path, flying)
+		if err != nil {
+			return flying, fmt.Errorf("step %s (%s): %w", path, step.Type, err)
+		}
+	}
+	return flying, nil
+}
+
+func (m *Mission) validateStep(step *Step, path string, flying bool) (bool, error) {
+	switch step.Type {
+	case StepTakeoff:
+		if flying {
+			return flying, fmt.Errorf("drone is already flying")
+		}
+		return true, nil
+	case StepLand:
+		if !flying {
+			return flying, fmt.Errorf("drone is not flying")
+		}
+		return false, nil
+	case StepGo:
+		if !flying {
+			return flying, fmt.Errorf("drone must take off first")
+		}
+		if err := validateOffset(step.X, step.Y, step.Z); err != nil {
+			return flying, err
+		}
+		return flying, utils.ValidateNumberInRange(m.speed(step), 10, 100)
+	case StepCurve:
+		if !flying {
+			return flying, fmt.Errorf("drone must take off first")
+		}
+		if err := validateOffset(step.X1, step.Y1, step.Z1); err != nil {
+			return flying, fmt.Errorf("invalid first point: %w", err)
+		}
+		if err := validateOffset(step.X2, step.Y2, step.Z2); err != nil {
+			return flying, fmt.Errorf("invalid end point: %w", err)
+		}
+		if err := utils.ValidateNumberInRange(m.speed(step), 10, maxCurveSpeed); err != nil {
+			return flying, fmt.Errorf("invalid curve speed: %w", err)
+		}
+		radius, _ := curveArc(step)
+		if radius < minCurveRadius || radius > maxCurveRadius {
+			return flying, fmt.Errorf("arc radius %.0f cm is outside %d-%d cm", radius, minCurveRadius, maxCurveRadius)
+		}
+		return flying, nil
+	case StepRotate:
+		if !flying {
+			return flying, fmt.Errorf("drone must take off first")
+		}
+		if step.Angle == 0 {
+			return flying, fmt.Errorf("angle must not be 0")
+		}
+		return flying, utils.ValidateNumberInRange(abs(step.Angle), 1, 3600)
+	case StepWait:
+		if step.Seconds <= 0 {
+			return flying, fmt.Errorf("seconds must be positive")
+		}
+		return flying, nil
+	case StepWaitUntil:
+		if step.Metric != MetricBattery && step.Metric != MetricHeight {
+			return flying, fmt.Errorf("unknown metric %q", step.Metric)
+		}
+		if step.Above == nil && step.Below == nil {
+			return flying, fmt.Errorf("above or below is required")
+		}
+		if step.Above != nil && step.Below != nil && *step.Above > *step.Below {
+			return flying, fmt.Errorf("above (%d) is greater than below (%d)", *step.Above, *step.Below)
+		}
+		return flying, nil
+	case StepPhoto:
+		if step.Name != "" && filepath.Base(step.Name) != step.Name {
+			return flying, fmt.Errorf("photo name %q must not contain a directory", step.Name)
+		}
+		if step.Name != "" {
+			if _, err := transport.PhotoFormatFromPath(step.Name); err != nil {
+				return flying, err
+			}
+		}
+		return flying, nil
+	case StepLoop:
+		if step.Count < 1 {
+			return flying, fmt.Errorf("count must be at least 1")
+		}
+		if len(step.Steps) == 0 {
+			return flying, fmt.Errorf("loop has no steps")
+		}
+		after, err := m.validateSteps(step.Steps, path, flying)
+		if err != nil {
+			return flying, err
+		}
+		if after != flying {
+			return flying, fmt.Errorf("loop must end in the flight state it starts in")
+		}
+		return flying, nil
+	default:
+		return flying, fmt.Errorf("unknown step type")
+	}
+}
+
+// validateOffset applies the SDK's limits on a relative go or curve point
+func validateOffset(x, y, z int) error {
+	for _, v := range []int{x, y, z} {
+		if err := utils.ValidateNumberInRange(v, -500, 500); err != nil {
+			return fmt.Errorf("invalid offset: %w", err)
+		}
+	}
+	if abs(x) <= minGoOffset && abs(y) <= minGoOffset && abs(z) <= minGoOffset {
+		return fmt.Errorf("offset (%d, %d, %d) is within %d cm on every axis", x, y, z, minGoOffset)
+	}
+	return nil
+}
+
+// stepPath returns the 1-based position of a step, e.g. "3.2" for the second
+// step of the loop at step 3
+func stepPath(prefix string, index int) string {
+	if prefix == "" {
+		return fmt.Sprint(index + 1)
+	}
+	return fmt.Sprintf("%s.%d", prefix, index+1)
+}
+
+// Loader parses missions and validates them against the mission schema
+type Loader struct {
+	schema *jsonschema.Schema
+}
+
+// NewLoader creates a loader with the embedded mission schema
+func NewLoader() (*Loader, error) {
+	var fallbacks []string
+	if wd, err := os.Getwd(); err == nil {
+		fallbacks = append(fallbacks, filepath.Join(wd, "configs", "schemas", "mission-schema.json"))
+	}
+
+	schema, err := config.CompileSchema(configs.MissionSchema, fallbacks, "embedded://mission-schema.json")
+	if err != nil {
+		return nil, fmt.Errorf("failed to compile mission schema: %w", err)
+	}
+	return &Loader{schema: schema}, nil
+}
+
+// Load reads and validates the mission file at path
+func (l *Loader) Load(path string) (*Mission, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read mission file: %w", err)
+	}
+	mission, err := l.Parse(data)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return mission, nil
+}
+
+// Parse parses and validates a mission written in YAML or JSON
+func (l *Loader) Parse(data []byte) (*Mission, error) {
+	// YAML is a superset of JSON, so both are decoded the same way and
+	// converted to JSON for schema validation
+	var document interface{}
+	if err := yaml.Unmarshal(data, &document); err != nil {
+		return nil, fmt.Errorf("failed to parse mission: %w", err)
+	}
+	jsonData, err := json.Marshal(document)
+	if err != nil {
+		return nil, fmt.Errorf("failed to convert mission to JSON: %w", err)
+	}
+
+	if err := config.ValidateJSON(l.schema, jsonData); err != nil {
+		return nil, fmt.Errorf("mission validation failed: %w", err)
+	}
+
+	var mission Mission
+	if err := json.Unmarshal(jsonData, &mission); err != nil {
+		return nil, fmt.Errorf("failed to decode mission: %w", err)
+	}
+	if err := mission.Validate(); err != nil {
+		return nil, fmt.Errorf("invalid mission: %w", err)
+	}
+	return &mission, nil
+}
+
+// Load reads and validates the mission file at path
+func Load(path string) (*Mission, error) {
+	loader, err := NewLoader()
+	if err != nil {
+		return nil, err
+	}
+	return loader.Load(path)
+}
+
+func abs(v int) int {
+	if v < 0 {
+		return -v
+	}
+	return v
+}
+
+// curveArc returns the radius and length of a curve step's arc, which starts
+// at the drone and passes through the first point to the end point. Points
+// on a line give an infinite radius and the length of the straight path.
+func curveArc(step *Step) (radius, length float64) {
+	a := vec3{float64(step.X1), float64(step.Y1), float64(step.Z1)}
+	b := vec3{float64(step.X2), float64(step.Y2), float64(step.Z2)}
+	straight := a.norm() + b.sub(a).norm()
+
+	axb := a.cross(b)
+	denominator := 2 * axb.dot(axb)
+	if denominator < 1e-9 {
+		return math.Inf(1), straight
+	}
+
+	// Circumcentre of the triangle (0, a, b)
+	center := b.scale(a.dot(a)).sub(a.scale(b.dot(b))).cross(axb).scale(1 / denominator)
+	radius = center.norm()
+
+	// The arc runs from the start through a to b
+	start, mid, end := center.scale(-1), a.sub(center), b.sub(center)
+	length = radius * (angleBetween(start, mid) + angleBetween(mid, end))
+	return radius, length
+}
+
+type vec3 [3]float64
+
+func (v vec3) sub(o vec3) vec3      { return vec3{v[0] - o[0], v[1] - o[1], v[2] - o[2]} }
+func (v vec3) scale(f float64) vec3 { return vec3{v[0] * f, v[1] * f, v[2] * f} }
+func (v vec3) dot(o vec3) float64   { return v[0]*o[0] + v[1]*o[1] + v[2]*o[2] }
+func (v vec3) norm() float64        { return math.Sqrt(v.dot(v)) }
+func (v vec3) cross(o vec3) vec3 {
+	return vec3{v[1]*o[2] - v[2]*o[1], v[2]*o[0] - v[0]*o[2], v[0]*o[1] - v[1]*o[0]}
+}
+
+func angleBetween(a, b vec3) float64 {
+	cos := a.dot(b) / (a.norm() * b.norm())
+	return math.Acos(math.Max(-1, math.Min(1, cos)))
+}
@@ -0,0 +1,635 @@
+package mission
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+const squareMission = `
+name: square
+speed: 50
+steps:
+  - type: takeoff
+  - type: loop
+    count: 4
+    steps:
+      - type: go
+        x: 100
+        y: 0
+        z: 0
+      - type: rotate
+        angle: 90
+  - type: land
+`
+
+func newTestLoader(t *testing.T) *Loader {
+	t.Helper()
+	loader, err := NewLoader()
+	if err != nil {
+		t.Fatalf("Failed to create loader: %v", err)
+	}
+	return loader
+}
+
+func TestParse(t *testing.T) {
+	loader := newTestLoader(t)
+
+	tests := []struct {
+		name      string
+		data      string
+		wantSteps int
+		wantErr   string
+	}{
+		{
+			name:      "yaml",
+			data:      squareMission,
+			wantSteps: 3,
+		},
+		{
+			name:      "json",
+			data:      `{"name": "hop", "steps": [{"type": "takeoff"}, {"type": "wait", "seconds": 1.5}, {"type": "land"}]}`,
+			wantSteps: 3,
+		},
+		{
+			name:    "missing go offset",
+			data:    "name: m\nsteps:\n  - type: takeoff\n  - type: go\n    x: 100\n  - type: land\n",
+			wantErr: "mission validation failed",
+		},
+		{
+			name:    "unknown step type",
+			data:    "name: m\nsteps:\n  - type: flip\n",
+			wantErr: "mission validation failed",
+		},
+		{
+			name:    "unknown field",
+			data:    "name: m\nsteps:\n  - type: takeoff\n    height: 100\n",
+			wantErr: "mission validation failed",
+		},
+		{
+			name:    "photo name with directory",
+			data:    "name: m\nsteps:\n  - type: photo\n    name: ../a.jpg\n",
+			wantErr: "mission validation failed",
+		},
+		{
+			name:    "photo name without image extension",
+			data:    "name: m\nsteps:\n  - type: photo\n    name: a.gif\n",
+			wantErr: "unsupported photo format",
+		},
+		{
+			name:    "wait_until without condition",
+			data:    "name: m\nsteps:\n  - type: wait_until\n    metric: battery\n",
+			wantErr: "mission validation failed",
+		},
+		{
+			name:    "go before takeoff",
+			data:    "name: m\nsteps:\n  - type: go\n    x: 100\n    y: 0\n    z: 0\n",
+			wantErr: "step 1 (go): drone must take off first",
+		},
+		{
+			name:    "not yaml",
+			data:    "name: [",
+			wantErr: "failed to parse mission",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mission, err := loader.Parse([]byte(tt.data))
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Errorf("Expected error containing %q, got %v", tt.wantErr, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Expected no error, got %v", err)
+			}
+			if len(mission.Steps) != tt.wantSteps {
+				t.Errorf("Expected %d steps, got %d", tt.wantSteps, len(mission.Steps))
+			}
+		})
+	}
+}
+
+func TestParseStepFields(t *testing.T) {
+	mission, err := newTestLoader(t).Parse([]byte(`
+name: survey
+steps:
+  - type: takeoff
+  - type: wait_until
+    metric: height
+    above: 60
+    timeout: 5
+  - type: curve
+    x1: 100
+    y1: 100
+    z1: 0
+    x2: 200
+    y2: 0
+    z2: 0
+    speed: 30
+  - type: photo
+    name: end.jpg
+  - type: land
+`))
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	wait := mission.Steps[1]
+	if wait.Metric != MetricHeight || wait.Above == nil || *wait.Above != 60 || wait.Below != nil || wait.timeout() != 5 {
+		t.Errorf("Expected wait until height >= 60 within 5s, got %+v", wait)
+	}
+	curve := mission.Steps[2]
+	if got := curve.String(); got != "curve 100 100 0 200 0 0" {
+		t.Errorf("Expected curve 100 100 0 200 0 0, got %s", got)
+	}
+	if got := mission.speed(&curve); got != 30 {
+		t.Errorf("Expected curve speed 30, got %d", got)
+	}
+	if got := mission.speed(&mission.Steps[0]); got != DefaultSpeed {
+		t.Errorf("Expected default speed %d, got %d", DefaultSpeed, got)
+	}
+	if mission.Steps[3].Name != "end.jpg" {
+		t.Errorf("Expected photo end.jpg, got %q", mission.Steps[3].Name)
+	}
+}
+
+func TestValidate(t *testing.T) {
+	takeoff := Step{Type: StepTakeoff}
+	land := Step{Type: StepLand}
+
+	tests := []struct {
+		name    string
+		steps   []Step
+		wantErr string
+	}{
+		{
+			name:  "hop",
+			steps: []Step{takeoff, land},
+		},
+		{
+			name:    "no steps",
+			wantErr: "mission has no steps",
+		},
+		{
+			name:    "ends in flight",
+			steps:   []Step{takeoff},
+			wantErr: "mission ends in flight",
+		},
+		{
+			name:    "takeoff twice",
+			steps:   []Step{takeoff, takeoff, land},
+			wantErr: "step 2 (takeoff): drone is already flying",
+		},
+		{
+			name:    "land on the ground",
+			steps:   []Step{land},
+			wantErr: "step 1 (land): drone is not flying",
+		},
+		{
+			name:    "go too short",
+			steps:   []Step{takeoff, {Type: StepGo, X: 10, Y: -15, Z: 5}, land},
+			wantErr: "step 2 (go): offset (10, -15, 5) is within 20 cm",
+		},
+		{
+			name:    "curve too fast",
+			steps:   []Step{takeoff, {Type: StepCurve, X1: 100, Y1: 100, X2: 200, Speed: 80}, land},
+			wantErr: "step 2 (curve): invalid curve speed",
+		},
+		{
+			name:    "curve radius too small",
+			steps:   []Step{takeoff, {Type: StepCurve, X1: 30, Y1: 30, X2: 60}, land},
+			wantErr: "arc radius 30 cm is outside 50-1000 cm",
+		},
+		{
+			name:    "curve in a straight line",
+			steps:   []Step{takeoff, {Type: StepCurve, X1: 100, X2: 200}, land},
+			wantErr: "arc radius",
+		},
+		{
+			name: "loop lands",
+			steps: []Step{takeoff, {Type: StepLoop, Count: 2, Steps: []Step{
+				{Type: StepRotate, Angle: 90},
+				land,
+			}}},
+			wantErr: "step 2 (loop): loop must end in the flight state it starts in",
+		},
+		{
+			name: "nested step path",
+			steps: []Step{takeoff, {Type: StepLoop, Count: 2, Steps: []Step{
+				{Type: StepRotate, Angle: 90},
+				{Type: StepGo, X: 5, Y: 5},
+			}}, land},
+			wantErr: "step 2.2 (go)",
+		},
+		{
+			name:    "conflicting wait",
+			steps:   []Step{{Type: StepWaitUntil, Metric: MetricBattery, Above: intPtr(50), Below: intPtr(20)}},
+			wantErr: "above (50) is greater than below (20)",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mission := &Mission{Name: tt.name, Steps: tt.steps}
+			err := mission.Validate()
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Errorf("Expected no error, got %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("Expected error containing %q, got %v", tt.wantErr, err)
+			}
+		})
+	}
+}
+
+func TestPlan(t *testing.T) {
+	mission, err := newTestLoader(t).Parse([]byte(squareMission))
+	if err != nil {
+		t.Fatalf("Failed to parse mission: %v", err)
+	}
+	plan := mission.Plan()
+
+	// takeoff, 4 x (go, rotate), land
+	if len(plan.Steps) != 10 {
+		t.Fatalf("Expected 10 plan steps, got %d", len(plan.Steps))
+	}
+	if plan.Steps[3].Path != "2.1#2" {
+		t.Errorf("Expected path 2.1#2, got %s", plan.Steps[3].Path)
+	}
+
+	// The second leg flies right after a 90° clockwise turn
+	second := plan.Steps[3]
+	if math.Abs(second.X-100) > 0.001 || math.Abs(second.Y-100) > 0.001 || second.Yaw != 90 {
+		t.Errorf("Expected (100, 100) facing 90 after the second leg, got (%.1f, %.1f) facing %.0f", second.X, second.Y, second.Yaw)
+	}
+	last := plan.Steps[len(plan.Steps)-1]
+	if math.Abs(last.X) > 0.001 || math.Abs(last.Y) > 0.001 || last.Z != 0 {
+		t.Errorf("Expected to land at the start, got (%.1f, %.1f, %.1f)", last.X, last.Y, last.Z)
+	}
+
+	// 1.6s takeoff, 4 x (2s go + 1s rotate), 1.6s land
+	if want := 15200 * time.Millisecond; plan.Duration != want {
+		t.Errorf("Expected duration %v, got %v", want, plan.Duration)
+	}
+	if want := 560.0; math.Abs(plan.Distance-want) > 0.001 {
+		t.Errorf("Expected distance %.0f, got %.1f", want, plan.Distance)
+	}
+	if plan.MaxHeight != takeoffHeight {
+		t.Errorf("Expected max height %.0f, got %.0f", takeoffHeight, plan.MaxHeight)
+	}
+	if last.Elapsed != plan.Duration {
+		t.Errorf("Expected last step to end at %v, got %v", plan.Duration, last.Elapsed)
+	}
+}
+
+func TestPlanCurve(t *testing.T) {
+	mission := &Mission{Name: "arc", Speed: 50, Steps: []Step{
+		{Type: StepTakeoff},
+		{Type: StepCurve, X1: 100, Y1: 100, X2: 200},
+		{Type: StepWaitUntil, Metric: MetricBattery, Above: intPtr(0)},
+		{Type: StepLand},
+	}}
+	plan := mission.Plan()
+
+	// A semicircle of radius 100 cm
+	arc := plan.Steps[1]
+	if want := 100 * math.Pi / 50; math.Abs(arc.Duration.Seconds()-want) > 0.001 {
+		t.Errorf("Expected curve to take %.3fs, got %v", want, arc.Duration)
+	}
+	if arc.X != 200 || arc.Y != 0 {
+		t.Errorf("Expected curve to end at (200, 0), got (%.1f, %.1f)", arc.X, arc.Y)
+	}
+	if plan.ConditionalWaits != 1 {
+		t.Errorf("Expected 1 conditional wait, got %d", plan.ConditionalWaits)
+	}
+}
+
+// mockCommander records the commands it is sent
+type mockCommander struct {
+	mu       sync.Mutex
+	commands []string
+	battery  int
+	height   int
+	fail     string // Command that returns an error
+}
+
+func (m *mockCommander) send(command string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.commands = append(m.commands, command)
+	if command == m.fail {
+		return fmt.Errorf("%s failed", command)
+	}
+	return nil
+}
+
+func (m *mockCommander) sent() []string {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return append([]string(nil), m.commands...)
+}
+
+func (m *mockCommander) TakeOff() error { return m.send("takeoff") }
+func (m *mockCommander) Land() error    { return m.send("land") }
+func (m *mockCommander) Go(x, y, z, speed int) error {
+	return m.send(fmt.Sprintf("go %d %d %d %d", x, y, z, speed))
+}
+func (m *mockCommander) Curve(x1, y1, z1, x2, y2, z2, speed int) error {
+	return m.send(fmt.Sprintf("curve %d %d %d %d %d %d %d", x1, y1, z1, x2, y2, z2, speed))
+}
+func (m *mockCommander) Clockwise(angle int) error { return m.send(fmt.Sprintf("cw %d", angle)) }
+func (m *mockCommander) CounterClockwise(angle int) error {
+	return m.send(fmt.Sprintf("ccw %d", angle))
+}
+func (m *mockCommander) GetBatteryPercentage() (int, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.battery, nil
+}
+func (m *mockCommander) GetHeight() (int, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.height, nil
+}
+
+// mockWaiter answers every command immediately, rejecting those in reject
+type mockWaiter struct {
+	reject map[string]bool
+}
+
+func (w *mockWaiter) AwaitCommand(command string) (<-chan bool, func()) {
+	ch := make(chan bool, 1)
+	ch <- !w.reject[command]
+	return ch, func() {}
+}
+
+type mockCapturer struct {
+	names []string
+}
+
+func (c *mockCapturer) CapturePhoto(name string) (string, error) {
+	c.names = append(c.names, name)
+	return "/photos/" + name, nil
+}
+
+func intPtr(v int) *int {
+	return &v
+}
+
+func TestExecutorRun(t *testing.T) {
+	commander := &mockCommander{battery: 80}
+	capturer := &mockCapturer{}
+	mission := &Mission{Name: "tour", Speed: 40, Steps: []Step{
+		{Type: StepTakeoff},
+		{Type: StepLoop, Count: 2, Steps: []Step{
+			{Type: StepGo, X: 100, Y: -50, Z: 0},
+			{Type: StepRotate, Angle: -90},
+			{Type: StepPhoto},
+		}},
+		{Type: StepCurve, X1: 100, Y1: 100, X2: 200, Speed: 20},
+		{Type: StepWaitUntil, Metric: MetricBattery, Above: intPtr(50)},
+		{Type: StepLand},
+	}}
+
+	var mu sync.Mutex
+	var paths []string
+	executor := NewExecutor(commander, mission,
+		WithCompletionWaiter(&mockWaiter{}),
+		WithPhotoCapturer(capturer),
+		WithEventHandler(func(event Event) {
+			if event.Done {
+				mu.Lock()
+				paths = append(paths, event.Path)
+				mu.Unlock()
+			}
+		}),
+	)
+
+	if err := executor.Run(context.Background()); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if executor.Status() != StatusCompleted {
+		t.Errorf("Expected status %s, got %s", StatusCompleted, executor.Status())
+	}
+
+	want := []string{"takeoff", "go 100 -50 0 40", "ccw 90", "go 100 -50 0 40", "ccw 90", "curve 100 100 0 200 0 0 20", "land"}
+	if got := commander.sent(); strings.Join(got, ",") != strings.Join(want, ",") {
+		t.Errorf("Expected commands %v, got %v", want, got)
+	}
+	if strings.Join(capturer.names, ",") != "photo-001.jpg,photo-002.jpg" {
+		t.Errorf("Expected numbered photos, got %v", capturer.names)
+	}
+	wantPaths := "1,2.1#1,2.2#1,2.3#1,2.1#2,2.2#2,2.3#2,3,4,5"
+	if got := strings.Join(paths, ","); got != wantPaths {
+		t.Errorf("Expected paths %s, got %s", wantPaths, got)
+	}
+
+	if err := executor.Run(context.Background()); err == nil {
+		t.Error("Expected error running a mission twice")
+	}
+}
+
+func TestExecutorFailure(t *testing.T) {
+	hop := func(middle Step) *Mission {
+		return &Mission{Name: "hop", Steps: []Step{{Type: StepTakeoff}, middle, {Type: StepLand}}}
+	}
+
+	tests := []struct {
+		name      string
+		mission   *Mission
+		commander *mockCommander
+		waiter    *mockWaiter
+		wantErr   string
+		wantSent  []string
+	}{
+		{
+			name:      "command error",
+			mission:   hop(Step{Type: StepGo, X: 100}),
+			commander: &mockCommander{fail: "go 100 0 0 50"},
+			wantErr:   "step 2 (go 100 0 0) failed: go 100 0 0 50 failed",
+			wantSent:  []string{"takeoff", "go 100 0 0 50", "land"},
+		},
+		{
+			name:      "rejected by drone",
+			mission:   hop(Step{Type: StepRotate, Angle: 45}),
+			commander: &mockCommander{},
+			waiter:    &mockWaiter{reject: map[string]bool{"cw 45": true}},
+			wantErr:   "drone rejected 'cw 45'",
+			wantSent:  []string{"takeoff", "cw 45", "land"},
+		},
+		{
+			name:      "wait until timeout",
+			mission:   hop(Step{Type: StepWaitUntil, Metric: MetricHeight, Above: intPtr(100), Timeout: 0.05}),
+			commander: &mockCommander{height: 80},
+			wantErr:   "height was 80 after 0.05s",
+			wantSent:  []string{"takeoff", "land"},
+		},
+		{
+			name:      "takeoff failure lands",
+			mission:   hop(Step{Type: StepWait, Seconds: 0.01}),
+			commander: &mockCommander{fail: "takeoff"},
+			wantErr:   "step 1 (takeoff) failed",
+			wantSent:  []string{"takeoff", "land"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			waiter := tt.waiter
+			if waiter == nil {
+				waiter = &mockWaiter{}
+			}
+			executor := NewExecutor(tt.commander, tt.mission,
+				WithCompletionWaiter(waiter),
+				WithPollInterval(10*time.Millisecond),
+			)
+
+			err := executor.Run(context.Background())
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("Expected error containing %q, got %v", tt.wantErr, err)
+			}
+			if executor.Status() != StatusFailed {
+				t.Errorf("Expected status %s, got %s", StatusFailed, executor.Status())
+			}
+			if got := tt.commander.sent(); strings.Join(got, ",") != strings.Join(tt.wantSent, ",") {
+				t.Errorf("Expected commands %v, got %v", tt.wantSent, got)
+			}
+		})
+	}
+}
+
+func TestExecutorWithoutWaiter(t *testing.T) {
+	commander := &mockCommander{}
+	mission := &Mission{Name: "wait", Steps: []Step{{Type: StepWait, Seconds: 0.05}}}
+	executor := NewExecutor(commander, mission)
+
+	start := time.Now()
+	if err := executor.Run(context.Background()); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if elapsed := time.Since(start); elapsed < 50*time.Millisecond {
+		t.Errorf("Expected the wait to take 50ms, took %v", elapsed)
+	}
+}
+
+func TestExecutorRequiresCamera(t *testing.T) {
+	mission := &Mission{Name: "photo", Steps: []Step{{Type: StepPhoto}}}
+	executor := NewExecutor(&mockCommander{}, mission)
+	if err := executor.Run(context.Background()); err == nil || !strings.Contains(err.Error(), "no camera") {
+		t.Errorf("Expected error about the camera, got %v", err)
+	}
+}
+
+func TestExecutorPauseResume(t *testing.T) {
+	commander := &mockCommander{}
+	mission := &Mission{Name: "pause", Steps: []Step{
+		{Type: StepTakeoff},
+		{Type: StepRotate, Angle: 90},
+		{Type: StepLand},
+	}}
+
+	var executor *Executor
+	paused := make(chan struct{})
+	executor = NewExecutor(commander, mission,
+		WithCompletionWaiter(&mockWaiter{}),
+		WithEventHandler(func(event Event) {
+			if event.Done && event.Step.Type == StepTakeoff {
+				executor.Pause()
+				close(paused)
+			}
+		}),
+	)
+
+	done := make(chan error, 1)
+	go func() { done <- executor.Run(context.Background()) }()
+
+	<-paused
+	time.Sleep(50 * time.Millisecond)
+	if executor.Status() != StatusPaused {
+		t.Errorf("Expected status %s, got %s", StatusPaused, executor.Status())
+	}
+	if got := commander.sent(); len(got) != 1 {
+		t.Errorf("Expected only takeoff while paused, got %v", got)
+	}
+
+	executor.Resume()
+	select {
+	case err := <-done:
+		if err != nil {
+			t.Errorf("Expected no error, got %v", err)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("Mission did not finish after resuming")
+	}
+	if got := commander.sent(); len(got) != 3 {
+		t.Errorf("Expected 3 commands, got %v", got)
+	}
+}
+
+func TestExecutorAbort(t *testing.T) {
+	commander := &mockCommander{}
+	mission := &Mission{Name: "abort", Steps: []Step{
+		{Type: StepTakeoff},
+		{Type: StepWait, Seconds: 10},
+		{Type: StepLand},
+	}}
+
+	var executor *Executor
+	executor = NewExecutor(commander, mission,
+		WithCompletionWaiter(&mockWaiter{}),
+		WithEventHandler(func(event Event) {
+			if !event.Done && event.Step.Type == StepWait {
+				executor.Abort()
+			}
+		}),
+	)
+
+	err := executor.Run(context.Background())
+	if !errors.Is(err, ErrAborted) {
+		t.Errorf("Expected ErrAborted, got %v", err)
+	}
+	if executor.Status() != StatusAborted {
+		t.Errorf("Expected status %s, got %s", StatusAborted, executor.Status())
+	}
+	want := []string{"takeoff", "land"}
+	if got := commander.sent(); strings.Join(got, ",") != strings.Join(want, ",") {
+		t.Errorf("Expected commands %v, got %v", want, got)
+	}
+}
+
+func TestCurveArc(t *testing.T) {
+	tests := []struct {
+		name       string
+		step       Step
+		wantRadius float64
+		wantLength float64
+	}{
+		{"semicircle", Step{X1: 100, Y1: 100, X2: 200}, 100, 100 * math.Pi},
+		{"quarter circle", Step{X1: 75, Y1: 25, X2: 125, Y2: 125}, 125, 62.5 * math.Pi},
+		{"climbing semicircle", Step{X1: 100, Z1: 100, X2: 200}, 100, 100 * math.Pi},
+		{"straight", Step{X1: 50, X2: 100}, math.Inf(1), 100},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			radius, length := curveArc(&tt.step)
+			if (math.IsInf(tt.wantRadius, 1) != math.IsInf(radius, 1)) || (!math.IsInf(radius, 1) && math.Abs(radius-tt.wantRadius) > 0.01) {
+				t.Errorf("Expected radius %.2f, got %.2f", tt.wantRadius, radius)
+			}
+			if math.Abs(length-tt.wantLength) > 0.01 {
+				t.Errorf("Expected length %.2f, got %.2f", tt.wantLength, length)
+			}
+		})
+	}
+}
@@ -0,0 +1,148 @@
+package mission
+
+import (
+	"math"
+	"strconv"
+	"time"
+)
+
+// Flight model used to predict step durations. The values match the
+// simulator in pkg/sim.
+const (
+	takeoffHeight = 80.0 // Height reached after takeoff (cm)
+	verticalSpeed = 50.0 // Climb/descent rate for takeoff and land (cm/s)
+	yawRate       = 90.0 // Rotation rate (degrees/s)
+)
+
+// PlanStep is the predicted outcome of one step. Positions use the takeoff
+// frame: X forward and Y right along the heading at takeoff, Z up, and yaw
+// clockwise from the takeoff heading.
+type PlanStep struct {
+	Path     string        `json:"path"`     // Position in the mission, e.g. "3.2#4" for step 2 of the loop at step 3 in its 4th iteration
+	Step     string        `json:"step"`     // Description of the step
+	X        float64       `json:"x"`        // Position after the step (cm)
+	Y        float64       `json:"y"`        // Position after the step (cm)
+	Z        float64       `json:"z"`        // Height after the step (cm)
+	Yaw      float64       `json:"yaw"`      // Heading after the step (degrees)
+	Duration time.Duration `json:"duration"` // Predicted time the step takes
+	Elapsed  time.Duration `json:"elapsed"`  // Predicted time since the start when the step ends
+}
+
+// Plan is the predicted path and flight time of a mission
+type Plan struct {
+	Steps     []PlanStep    `json:"steps"`
+	Duration  time.Duration `json:"duration"`   // Predicted total time, excluding wait_until steps
+	Distance  float64       `json:"distance"`   // Horizontal and vertical distance flown (cm)
+	MaxHeight float64       `json:"max_height"` // Highest predicted height (cm)
+
+	// ConditionalWaits is the number of wait_until steps, whose duration
+	// depends on the drone and is not included in Duration
+	ConditionalWaits int `json:"conditional_waits"`
+}
+
+// planner tracks the predicted pose while walking a mission
+type planner struct {
+	mission *Mission
+	plan    *Plan
+	x, y, z float64
+	yaw     float64
+}
+
+// Plan predicts the path and flight time of the mission without a drone
+func (m *Mission) Plan() *Plan {
+	p := &planner{mission: m, plan: &Plan{}}
+	p.walk(m.Steps, "", "")
+	return p.plan
+}
+
+func (p *planner) walk(steps []Step, prefix, iteration string) {
+	for i := range steps {
+		step := &steps[i]
+		path := stepPath(prefix, i)
+		if step.Type == StepLoop {
+			for n := 1; n <= step.Count; n++ {
+				p.walk(step.Steps, path+iteration, "#"+strconv.Itoa(n))
+			}
+			continue
+		}
+
+		duration, distance := p.apply(step)
+		p.plan.Distance += distance
+		p.plan.MaxHeight = math.Max(p.plan.MaxHeight, p.z)
+		p.plan.Duration += duration
+		p.plan.Steps = append(p.plan.Steps, PlanStep{
+			Path:     path + iteration,
+			Step:     step.String(),
+			X:        p.x,
+			Y:        p.y,
+			Z:        p.z,
+			Yaw:      p.yaw,
+			Duration: duration,
+			Elapsed:  p.plan.Duration,
+		})
+	}
+}
+
+// apply moves the predicted pose through step and returns its duration and
+// the length of the path flown
+func (p *planner) apply(step *Step) (time.Duration, float64) {
+	switch step.Type {
+	case StepTakeoff:
+		p.z = takeoffHeight
+		return seconds(takeoffHeight / verticalSpeed), takeoffHeight
+	case StepLand:
+		height := p.z
+		p.z = 0
+		return seconds(height / verticalSpeed), height
+	case StepGo:
+		length := offsetLength(step.X, step.Y, step.Z)
+		p.move(step.X, step.Y, step.Z)
+		return seconds(length / float64(p.mission.speed(step))), length
+	case StepCurve:
+		_, length := curveArc(step)
+		p.move(step.X2, step.Y2, step.Z2)
+		return seconds(length / float64(p.mission.speed(step))), length
+	case StepRotate:
+		p.yaw = normalizeAngle(p.yaw + float64(step.Angle))
+		return seconds(math.Abs(float64(step.Angle)) / yawRate), 0
+	case StepWait:
+		return seconds(step.Seconds), 0
+	case StepWaitUntil:
+		p.plan.ConditionalWaits++
+	}
+	return 0, 0
+}
+
+// move applies a body-frame offset (x forward, y left, z up)
+func (p *planner) move(x, y, z int) {
+	rad := p.yaw * math.Pi / 180
+	sin, cos := math.Sin(rad), math.Cos(rad)
+	fwd, right := float64(x), -float64(y)
+	p.x += fwd*cos - right*sin
+	p.y += fwd*sin + right*cos
+	p.z = math.Max(0, p.z+float64(z))
+}
+
+// estimate returns the predicted duration of a single step
+func (m *Mission) estimate(step *Step) time.Duration {
+	p := &planner{mission: m, plan: &Plan{}, z: takeoffHeight}
+	duration, _ := p.apply(step)
+	return duration
+}
+
+func offsetLength(x, y, z int) float64 {
+	return math.Sqrt(float64(x*x + y*y + z*z))
+}
+
+func seconds(s float64) time.Duration {
+	return time.Duration(s * float64(time.Second))
+}
+
+// normalizeAngle wraps an angle in degrees to [-180, 180)
+func normalizeAngle(deg float64) float64 {
+	a := math.Mod(deg+180, 360)
+	if a < 0 {
+		a += 360
+	}
+	return a - 180
+}
@@ -0,0 +1,250 @@
+package navigation
+
+import (
+	"context"
+	"errors"
+	"math"
+	"net"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/conceptcodes/dji-tello-sdk-go/pkg/config"
+	"github.com/conceptcodes/dji-tello-sdk-go/pkg/sim"
+	"github.com/conceptcodes/dji-tello-sdk-go/pkg/tello"
+	"github.com/conceptcodes/dji-tello-sdk-go/pkg/types"
+)
+
+// testClock is a manually advanced clock for the odometry
+type testClock struct{ t time.Time }
+
+func (c *testClock) now() time.Time          { return c.t }
+func (c *testClock) advance(d time.Duration) { c.t = c.t.Add(d) }
+
+func newTestOdometry(weight float64) (*Odometry, *testClock) {
+	cfg := DefaultOdometryConfig()
+	cfg.CommandWeight = weight
+	odom := NewOdometry(cfg)
+	clock := &testClock{t: time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)}
+	odom.now = clock.now
+	return odom, clock
+}
+
+func assertPose(t *testing.T, got, want Pose) {
+	t.Helper()
+	const tolerance = 0.5
+	if math.Abs(got.X-want.X) > tolerance || math.Abs(got.Y-want.Y) > tolerance ||
+		math.Abs(got.Z-want.Z) > tolerance || math.Abs(got.Yaw-want.Yaw) > tolerance {
+		t.Errorf("Expected pose %+v, got %+v", want, got)
+	}
+}
+
+func TestOdometryIntegratesVelocity(t *testing.T) {
+	odom, clock := newTestOdometry(0.5)
+
+	// The drone was switched on facing 30°, which becomes the takeoff heading
+	odom.RecordState(&types.State{Yaw: 30})
+	odom.RecordCommand("takeoff", "ok", nil, time.Second)
+
+	// One second forward at 100 cm/s at 80 cm
+	for i := 0; i < 10; i++ {
+		clock.advance(100 * time.Millisecond)
+		odom.RecordState(&types.State{Yaw: 30, Vgx: 100, H: 80})
+	}
+	assertPose(t, odom.Pose(), Pose{X: 100, Z: 80})
+
+	// Turned right by 90°, flying forward now moves along +Y
+	for i := 0; i < 5; i++ {
+		clock.advance(100 * time.Millisecond)
+		odom.RecordState(&types.State{Yaw: 120, Vgx: 100, H: 80})
+	}
+	assertPose(t, odom.Pose(), Pose{X: 100, Y: 50, Z: 80, Yaw: 90})
+
+	// Gaps longer than StaleAfter are not extrapolated
+	clock.advance(5 * time.Second)
+	odom.RecordState(&types.State{Yaw: 120, Vgx: 100, H: 80})
+	assertPose(t, odom.Pose(), Pose{X: 100, Y: 50, Z: 80, Yaw: 90})
+}
+
+func TestOdometryCommandsWithoutTelemetry(t *testing.T) {
+	odom, _ := newTestOdometry(0.5)
+
+	commands := []string{"takeoff", "forward 100", "cw 90", "forward 50", "go 0 50 0 30", "up 40", "ccw 180"}
+	for _, command := range commands {
+		odom.RecordCommand(command, "ok", nil, time.Second)
+	}
+
+	// Facing +Y after cw 90, so "left 50" in the go command points along +X
+	assertPose(t, odom.Pose(), Pose{X: 150, Y: 50, Z: 40, Yaw: -90})
+	if !odom.Flying() {
+		t.Error("Expected odometry to report flying after takeoff")
+	}
+
+	// Rejected and failed commands do not move the estimate
+	odom.RecordCommand("forward 100", "error", nil, time.Second)
+	odom.RecordCommand("back 100", "", errors.New("timeout"), time.Second)
+	assertPose(t, odom.Pose(), Pose{X: 150, Y: 50, Z: 40, Yaw: -90})
+
+	odom.RecordCommand("land", "ok", nil, time.Second)
+	if odom.Flying() || odom.Pose().Z != 0 {
+		t.Errorf("Expected landed pose, got %+v flying=%v", odom.Pose(), odom.Flying())
+	}
+}
+
+func TestOdometryBlendsCommandWithTelemetry(t *testing.T) {
+	odom, clock := newTestOdometry(0.5)
+	odom.RecordState(&types.State{})
+	odom.RecordCommand("takeoff", "ok", nil, time.Second)
+
+	// Telemetry sees 80 cm of a 100 cm move
+	for i := 0; i < 8; i++ {
+		clock.advance(100 * time.Millisecond)
+		odom.RecordState(&types.State{Vgx: 100, H: 80})
+	}
+	odom.RecordCommand("forward 100", "ok", nil, time.Second)
+	assertPose(t, odom.Pose(), Pose{X: 90, Z: 80})
+
+	// Commands that do not move the drone do not blend
+	odom.RecordCommand("battery?", "87", nil, 10*time.Millisecond)
+	assertPose(t, odom.Pose(), Pose{X: 90, Z: 80})
+}
+
+func TestHomeSegment(t *testing.T) {
+	cfg := DefaultRTLConfig()
+	tests := []struct {
+		name  string
+		pose  Pose
+		x, y  int
+		found bool
+	}{
+		{"Ahead of home", Pose{X: 300}, -200, 0, true},
+		{"Right of home", Pose{Y: 100}, 0, 100, true},
+		{"Right of home facing right", Pose{Y: 100, Yaw: 90}, -100, 0, true},
+		{"Behind home facing back", Pose{X: -80, Yaw: 180}, -80, 0, true},
+		{"Within tolerance", Pose{X: 20, Y: -15}, 0, 0, false},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			x, y, found := homeSegment(test.pose, cfg)
+			if found != test.found || x != test.x || y != test.y {
+				t.Errorf("Expected (%d, %d, %v), got (%d, %d, %v)", test.x, test.y, test.found, x, y, found)
+			}
+		})
+	}
+}
+
+func TestRTLConfigValidate(t *testing.T) {
+	if err := DefaultRTLConfig().Validate(); err != nil {
+		t.Errorf("Expected default config to be valid, got %v", err)
+	}
+
+	invalid := []func(*RTLConfig){
+		func(c *RTLConfig) { c.Speed = 5 },
+		func(c *RTLConfig) { c.MaxSegment = 600 },
+		func(c *RTLConfig) { c.Tolerance = 10 },
+		func(c *RTLConfig) { c.MaxSegments = 0 },
+	}
+	for i, modify := range invalid {
+		cfg := DefaultRTLConfig()
+		modify(&cfg)
+		if err := cfg.Validate(); err == nil {
+			t.Errorf("Case %d: expected validation error", i)
+		}
+	}
+}
+
+func TestReturnToLaunchRequiresFlight(t *testing.T) {
+	odom := NewOdometry(DefaultOdometryConfig())
+	if err := ReturnToLaunch(context.Background(), nil, odom, DefaultRTLConfig()); err == nil {
+		t.Error("Expected an error when the drone has not taken off")
+	}
+}
+
+// freeUDPPort returns a local UDP port that is currently unused
+func freeUDPPort(t *testing.T) int {
+	t.Helper()
+	conn, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Failed to find free port: %v", err)
+	}
+	defer conn.Close()
+	return conn.LocalAddr().(*net.UDPAddr).Port
+}
+
+func TestReturnToLaunchWithSimulator(t *testing.T) {
+	const speedup = 10
+
+	statePort := freeUDPPort(t)
+	simCfg := sim.DefaultConfig()
+	simCfg.Addr = "127.0.0.1:0"
+	simCfg.StatePort = statePort
+	simCfg.StateInterval = 20 * time.Millisecond
+	simCfg.Speedup = speedup
+	s, err := sim.New(simCfg)
+	if err != nil {
+		t.Fatalf("Failed to create simulator: %v", err)
+	}
+	if err := s.Start(); err != nil {
+		t.Fatalf("Failed to start simulator: %v", err)
+	}
+	defer s.Stop()
+
+	// Run the odometry on simulated time so velocities integrate correctly
+	odom := NewOdometry(DefaultOdometryConfig())
+	start := time.Now()
+	odom.now = func() time.Time { return start.Add(time.Since(start) * speedup) }
+
+	cfg := config.DefaultTransportConfig().
+		WithDroneHost(s.Addr()).
+		WithLocalCommandAddr("127.0.0.1:0").
+		WithLocalStateAddr("127.0.0.1:" + strconv.Itoa(statePort)).
+		WithLocalVideoAddr("127.0.0.1:" + strconv.Itoa(freeUDPPort(t))).
+		WithCommandSendDelay(0).
+		WithCommandTimeout(5 * time.Second)
+	drone, err := tello.InitializeWithOptions(tello.WithTransportConfig(cfg), tello.WithSafetyDisabled(),
+		tello.WithFlightRecorder(odom))
+	if err != nil {
+		t.Fatalf("Failed to initialize commander: %v", err)
+	}
+	defer drone.Shutdown()
+
+	if err := drone.Init(); err != nil {
+		t.Fatalf("Failed to enter SDK mode: %v", err)
+	}
+
+	// Fly an L-shaped route away from home while turning
+	done, stop := odom.AwaitCommand("forward 100")
+	defer stop()
+	for _, move := range []func() error{
+		drone.TakeOff,
+		func() error { return drone.Forward(150) },
+		func() error { return drone.Clockwise(90) },
+		func() error { return drone.Forward(100) },
+	} {
+		if err := move(); err != nil {
+			t.Fatalf("Failed to send move: %v", err)
+		}
+	}
+	select {
+	case <-done:
+	case <-time.After(10 * time.Second):
+		t.Fatal("Timed out waiting for the outbound flight")
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Second)
+	defer cancel()
+	if err := ReturnToLaunch(ctx, drone, odom, DefaultRTLConfig()); err != nil {
+		t.Fatalf("Expected RTL to succeed, got %v", err)
+	}
+
+	deadline := time.Now().Add(5 * time.Second)
+	for s.IsFlying() && time.Now().Before(deadline) {
+		time.Sleep(20 * time.Millisecond)
+	}
+	if s.IsFlying() {
+		t.Error("Expected the drone to land after RTL")
+	}
+	if x, y, _ := s.Position(); math.Hypot(x, y) > 50 {
+		t.Errorf("Expected to land near the takeoff point, landed at (%.0f, %.0f)", x, y)
+	}
+}
@@ -0,0 +1,309 @@
+// Package navigation estimates where the drone is relative to its takeoff
+// point and flies it back there.
+//
+// The Tello has no GPS, so the position is dead-reckoned. Odometry integrates
+// the velocities, yaw and height from the state stream and corrects the
+// result with the displacement of every completed move command. It
+// implements tello.FlightRecorder and is fed by passing it to
+// tello.WithFlightRecorder, which delivers every state and every command
+// together with the drone's answer.
+//
+// All positions use the takeoff frame: X points forward and Y to the right
+// along the heading at takeoff, Z points up, and yaw is measured clockwise
+// from the takeoff heading. Distances are in centimetres.
+package navigation
+
+import (
+	"math"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/conceptcodes/dji-tello-sdk-go/pkg/tello"
+	"github.com/conceptcodes/dji-tello-sdk-go/pkg/types"
+)
+
+// Pose is a position and heading in the takeoff frame
+type Pose struct {
+	X   float64 `json:"x"`   // Forward of the takeoff point (cm)
+	Y   float64 `json:"y"`   // Right of the takeoff point (cm)
+	Z   float64 `json:"z"`   // Height above the takeoff point (cm)
+	Yaw float64 `json:"yaw"` // Heading clockwise from the takeoff heading, -180 to 180 (degrees)
+}
+
+// HomeDistance returns the horizontal distance to the takeoff point in cm
+func (p Pose) HomeDistance() float64 {
+	return math.Hypot(p.X, p.Y)
+}
+
+// HomeBearing returns the heading, clockwise from the takeoff heading in
+// degrees, that points from the pose to the takeoff point
+func (p Pose) HomeBearing() float64 {
+	return math.Atan2(-p.Y, -p.X) * 180 / math.Pi
+}
+
+// OdometryConfig holds the dead-reckoning settings
+type OdometryConfig struct {
+	// CommandWeight is how much a completed move's commanded displacement
+	// counts against the displacement integrated from telemetry during the
+	// move, from 0 (telemetry only) to 1 (commands only). Moves completed
+	// without telemetry always use the commanded displacement. (default: 0.5)
+	CommandWeight float64 `json:"command_weight" yaml:"command_weight"`
+
+	// StaleAfter is the longest gap between two states that is integrated.
+	// Longer gaps are skipped rather than extrapolated. (default: 1s)
+	StaleAfter time.Duration `json:"stale_after" yaml:"stale_after"`
+}
+
+// DefaultOdometryConfig returns the default odometry configuration
+func DefaultOdometryConfig() OdometryConfig {
+	return OdometryConfig{
+		CommandWeight: 0.5,
+		StaleAfter:    time.Second,
+	}
+}
+
+// Odometry estimates the pose of the drone relative to its takeoff point.
+// It is safe for concurrent use.
+type Odometry struct {
+	config OdometryConfig
+	now    func() time.Time
+
+	mu        sync.Mutex
+	pose      Pose
+	anchor    Pose      // Pose when the previous command completed
+	moved     bool      // Telemetry was integrated since the anchor was set
+	lastState time.Time // When the last state arrived
+	stateYaw  float64   // Raw yaw of the last state
+	yawOffset float64   // Raw yaw at takeoff
+	hasYaw    bool      // yawOffset has been set
+	flying    bool
+
+	// Callers waiting for a fire-and-forget command to complete
+	waiters map[*commandWaiter]struct{}
+}
+
+// commandWaiter receives whether the drone accepted a command
+type commandWaiter struct {
+	command string
+	result  chan bool
+}
+
+var _ tello.FlightRecorder = (*Odometry)(nil)
+
+// NewOdometry creates an estimator with the pose at the origin
+func NewOdometry(config OdometryConfig) *Odometry {
+	return &Odometry{
+		config:  config,
+		now:     time.Now,
+		waiters: make(map[*commandWaiter]struct{}),
+	}
+}
+
+// Pose returns the current pose estimate
+func (o *Odometry) Pose() Pose {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	return o.pose
+}
+
+// Flying reports whether a takeoff has completed without a landing since
+func (o *Odometry) Flying() bool {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	return o.flying
+}
+
+// Reset makes the current position and heading the new origin
+func (o *Odometry) Reset() {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	o.resetLocked()
+}
+
+func (o *Odometry) resetLocked() {
+	o.pose = Pose{Z: o.pose.Z}
+	o.anchor = o.pose
+	o.moved = false
+	if !o.lastState.IsZero() {
+		o.yawOffset = o.stateYaw
+		o.hasYaw = true
+	}
+}
+
+// RecordState integrates a telemetry state into the pose
+func (o *Odometry) RecordState(state *types.State) {
+	if state == nil {
+		return
+	}
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	now := o.now()
+	if !o.hasYaw {
+		o.yawOffset = float64(state.Yaw)
+		o.hasYaw = true
+	}
+	o.stateYaw = float64(state.Yaw)
+	heading := normalizeAngle(o.stateYaw - o.yawOffset)
+
+	// The drone reports velocity in its body frame: vgx forward, vgy right
+	if !o.lastState.IsZero() {
+		if dt := now.Sub(o.lastState); dt > 0 && dt <= o.config.StaleAfter {
+			rad := heading * math.Pi / 180
+			sin, cos := math.Sin(rad), math.Cos(rad)
+			vx, vy := float64(state.Vgx), float64(state.Vgy)
+			o.pose.X += (vx*cos - vy*sin) * dt.Seconds()
+			o.pose.Y += (vx*sin + vy*cos) * dt.Seconds()
+			o.moved = true
+		}
+	}
+	o.lastState = now
+	o.pose.Z = float64(state.H)
+	o.pose.Yaw = heading
+}
+
+// RecordCommand applies a completed command. The drone answers a move only
+// once it has finished, so the pose change since the previous command is the
+// move's measured displacement, which is blended with the commanded one.
+func (o *Odometry) RecordCommand(command, response string, err error, latency time.Duration) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	ok := err == nil && strings.EqualFold(strings.TrimSpace(response), "ok")
+	if ok {
+		o.applyLocked(command)
+	}
+	o.anchor = o.pose
+	o.moved = false
+
+	for w := range o.waiters {
+		if w.command == command {
+			w.result <- ok
+			delete(o.waiters, w)
+		}
+	}
+}
+
+func (o *Odometry) applyLocked(command string) {
+	fields := strings.Fields(command)
+	if len(fields) == 0 {
+		return
+	}
+
+	var fwd, right, up, turn float64
+	switch fields[0] {
+	case "takeoff":
+		o.flying = true
+		o.resetLocked()
+		return
+	case "land", "emergency":
+		o.flying = false
+		o.pose.Z = 0
+		return
+	case "forward", "back", "left", "right", "up", "down":
+		v, ok := intFields(fields, 1)
+		if !ok {
+			return
+		}
+		d := float64(v[0])
+		switch fields[0] {
+		case "forward":
+			fwd = d
+		case "back":
+			fwd = -d
+		case "left":
+			right = -d
+		case "right":
+			right = d
+		case "up":
+			up = d
+		case "down":
+			up = -d
+		}
+	case "cw", "ccw":
+		v, ok := intFields(fields, 1)
+		if !ok {
+			return
+		}
+		turn = float64(v[0])
+		if fields[0] == "ccw" {
+			turn = -turn
+		}
+	case "go":
+		// The SDK body frame is x forward, y left, z up. Pad-relative moves
+		// carry a mission pad ID and cannot be placed in the takeoff frame.
+		v, ok := intFields(fields, 4)
+		if !ok {
+			return
+		}
+		fwd, right, up = float64(v[0]), -float64(v[1]), float64(v[2])
+	case "curve":
+		v, ok := intFields(fields, 7)
+		if !ok {
+			return
+		}
+		fwd, right, up = float64(v[3]), -float64(v[4]), float64(v[5])
+	default:
+		return
+	}
+
+	rad := o.anchor.Yaw * math.Pi / 180
+	sin, cos := math.Sin(rad), math.Cos(rad)
+	dx := fwd*cos - right*sin
+	dy := fwd*sin + right*cos
+
+	if o.moved {
+		// Heading and height are measured directly; only blend the position
+		w := o.config.CommandWeight
+		o.pose.X = o.anchor.X + w*dx + (1-w)*(o.pose.X-o.anchor.X)
+		o.pose.Y = o.anchor.Y + w*dy + (1-w)*(o.pose.Y-o.anchor.Y)
+		return
+	}
+	o.pose.X = o.anchor.X + dx
+	o.pose.Y = o.anchor.Y + dy
+	o.pose.Z = math.Max(0, o.anchor.Z+up)
+	o.pose.Yaw = normalizeAngle(o.anchor.Yaw + turn)
+}
+
+// AwaitCommand returns a channel that receives whether the drone accepted
+// the next completion of command, which must be formatted exactly as the
+// commander sends it. Call it before sending the command so that a fast
+// answer cannot be missed. The returned function stops waiting.
+func (o *Odometry) AwaitCommand(command string) (<-chan bool, func()) {
+	w := &commandWaiter{command: command, result: make(chan bool, 1)}
+	o.mu.Lock()
+	o.waiters[w] = struct{}{}
+	o.mu.Unlock()
+	return w.result, func() {
+		o.mu.Lock()
+		delete(o.waiters, w)
+		o.mu.Unlock()
+	}
+}
+
+// intFields parses exactly n integer arguments following the command name
+func intFields(fields []string, n int) ([]int, bool) {
+	if len(fields) != n+1 {
+		return nil, false
+	}
+	v := make([]int, n)
+	for i := range v {
+		x, err := strconv.Atoi(fields[i+1])
+		if err != nil {
+			return nil, false
+		}
+		v[i] = x
+	}
+	return v, true
+}
+
+// normalizeAngle wraps an angle in degrees to [-180, 180)
+func normalizeAngle(deg float64) float64 {
+	a := math.Mod(deg+180, 360)
+	if a < 0 {
+		a += 360
+	}
+	return a - 180
+}
@@ -0,0 +1,164 @@
+package navigation
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"time"
+
+	"github.com/conceptcodes/dji-tello-sdk-go/pkg/errors"
+	"github.com/conceptcodes/dji-tello-sdk-go/pkg/tello"
+	"github.com/conceptcodes/dji-tello-sdk-go/pkg/utils"
+)
+
+// minGoOffset is the SDK's smallest go move: x, y and z cannot all be
+// within this distance at once.
+const minGoOffset = 20
+
+// RTLConfig holds the return-to-launch settings
+type RTLConfig struct {
+	// Speed is the speed of each segment (default: 50 cm/s)
+	Speed int `json:"speed" yaml:"speed"`
+
+	// MaxSegment is the longest go segment. Shorter segments let the pose
+	// estimate be corrected more often on the way home. (default: 200 cm)
+	MaxSegment int `json:"max_segment" yaml:"max_segment"`
+
+	// Tolerance is the horizontal distance from the takeoff point that
+	// counts as home (default: 30 cm)
+	Tolerance int `json:"tolerance" yaml:"tolerance"`
+
+	// MaxSegments caps the number of segments so a drifting estimate cannot
+	// keep the drone flying forever (default: 20)
+	MaxSegments int `json:"max_segments" yaml:"max_segments"`
+
+	// SegmentTimeout is added to each segment's expected flight time to
+	// give the time allowed for the drone to report completion (default: 10s)
+	SegmentTimeout time.Duration `json:"segment_timeout" yaml:"segment_timeout"`
+}
+
+// DefaultRTLConfig returns the default return-to-launch configuration
+func DefaultRTLConfig() RTLConfig {
+	return RTLConfig{
+		Speed:          50,
+		MaxSegment:     200,
+		Tolerance:      30,
+		MaxSegments:    20,
+		SegmentTimeout: 10 * time.Second,
+	}
+}
+
+// Validate checks the configuration against the SDK's go command limits
+func (c RTLConfig) Validate() error {
+	if err := utils.ValidateNumberInRange(c.Speed, 10, 100); err != nil {
+		return fmt.Errorf("invalid speed: %w", err)
+	}
+	if err := utils.ValidateNumberInRange(c.MaxSegment, minGoOffset*2, 500); err != nil {
+		return fmt.Errorf("invalid max segment: %w", err)
+	}
+	if c.Tolerance < minGoOffset {
+		return fmt.Errorf("tolerance must be at least %d cm, got %d", minGoOffset, c.Tolerance)
+	}
+	if c.MaxSegments < 1 {
+		return fmt.Errorf("max segments must be positive, got %d", c.MaxSegments)
+	}
+	return nil
+}
+
+// ReturnToLaunch flies the drone back above its takeoff point at its current
+// height and lands. The way home is split into go segments of at most
+// MaxSegment; after each one the remaining distance is recomputed from the
+// odometry, which must be receiving the drone's states and commands.
+func ReturnToLaunch(ctx context.Context, commander tello.TelloCommander, odometry *Odometry, config RTLConfig) error {
+	if err := config.Validate(); err != nil {
+		return errors.WrapSDKError(err, errors.ErrInvalidArgument, "Navigation", "invalid RTL configuration")
+	}
+	if !odometry.Flying() {
+		return errors.NewSDKError(errors.ErrInvalidArgument, "Navigation", "cannot return to launch: drone is not flying")
+	}
+
+	for segment := 0; segment < config.MaxSegments; segment++ {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+
+		pose := odometry.Pose()
+		x, y, ok := homeSegment(pose, config)
+		if !ok {
+			utils.Logger.Infof("RTL: home reached after %d segments, landing", segment)
+			return commander.Land()
+		}
+
+		utils.Logger.Infof("RTL: %.0f cm from home, flying go %d %d 0", pose.HomeDistance(), x, y)
+		if err := flySegment(ctx, commander, odometry, x, y, config); err != nil {
+			return err
+		}
+	}
+
+	return errors.NewSDKError(errors.ErrTimeout, "Navigation",
+		fmt.Sprintf("home not reached within %d segments (%.0f cm left)", config.MaxSegments, odometry.Pose().HomeDistance()))
+}
+
+// homeSegment returns the next go offset towards home in the SDK body frame
+// (x forward, y left). It returns false once home is within tolerance or
+// too close for a go command.
+func homeSegment(pose Pose, config RTLConfig) (x, y int, ok bool) {
+	distance := pose.HomeDistance()
+	if distance <= float64(config.Tolerance) {
+		return 0, 0, false
+	}
+
+	// Vector to home in the takeoff frame, limited to one segment
+	scale := math.Min(1, float64(config.MaxSegment)/distance)
+	dx, dy := -pose.X*scale, -pose.Y*scale
+
+	// Rotate into the body frame
+	rad := pose.Yaw * math.Pi / 180
+	sin, cos := math.Sin(rad), math.Cos(rad)
+	fwd := dx*cos + dy*sin
+	right := -dx*sin + dy*cos
+
+	x, y = int(math.Round(fwd)), int(math.Round(-right))
+	if abs(x) <= minGoOffset && abs(y) <= minGoOffset {
+		return 0, 0, false
+	}
+	return x, y, true
+}
+
+// flySegment sends one go command and waits until the drone reports that it
+// has finished
+func flySegment(ctx context.Context, commander tello.TelloCommander, odometry *Odometry, x, y int, config RTLConfig) error {
+	command := fmt.Sprintf("go %d %d 0 %d", x, y, config.Speed)
+	completed, stop := odometry.AwaitCommand(command)
+	defer stop()
+
+	if err := commander.Go(x, y, 0, config.Speed); err != nil {
+		return errors.WrapSDKError(err, errors.ErrCommandFailed, "Navigation", "failed to send RTL segment")
+	}
+
+	length := math.Hypot(float64(x), float64(y))
+	timeout := time.Duration(length/float64(config.Speed)*float64(time.Second)) + config.SegmentTimeout
+	timer := time.NewTimer(timeout)
+	defer timer.Stop()
+
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return errors.NewSDKError(errors.ErrTimeout, "Navigation",
+			fmt.Sprintf("no response to '%s' within %v", command, timeout))
+	case ok := <-completed:
+		if !ok {
+			return errors.NewSDKError(errors.ErrCommandFailed, "Navigation",
+				fmt.Sprintf("drone rejected '%s'", command))
+		}
+		return nil
+	}
+}
+
+func abs(v int) int {
+	if v < 0 {
+		return -v
+	}
+	return v
+}
@@ -267,6 +267,17 @@ func (cl *ConfigLoader) applyDefaults(config *Config) {
 	if config.Behavioral.MaxCommandRate == 0 {
 		config.Behavioral.MaxCommandRate = 10
 	}
+
+	// Apply default geofence settings if not set
+	if config.Geofence.Shape == "" {
+		config.Geofence.Shape = GeofenceShapeCylinder
+	}
+	if config.Geofence.Shape == GeofenceShapeCylinder && config.Geofence.Radius == 0 {
+		config.Geofence.Radius = 500
+	}
+	if config.Geofence.BreachAction == "" {
+		config.Geofence.BreachAction = "land"
+	}
 }
 
 // getSchemaFallbackPaths returns the ordered schema locations to check on disk.
@@ -45,6 +45,12 @@ func DefaultConfig() *Config {
 			MaxFlightTime:  600,
 			MaxCommandRate: 10,
 		},
+		Geofence: GeofenceLimits{
+			Enabled:      false,
+			Shape:        GeofenceShapeCylinder,
+			Radius:       500,
+			BreachAction: "land",
+		},
 	}
 }
 
@@ -114,6 +120,9 @@ func IndoorConfig() *Config {
 	config.Behavioral.EnableFlips = false
 	config.Sensors.MaxTiltAngle = 15
 	config.Behavioral.MaxFlightTime = 300
+	config.Geofence.Enabled = true
+	config.Geofence.Radius = 300
+	config.Geofence.BreachAction = "hover"
 
 	utils.Logger.Info("Created indoor safety configuration")
 	return config
@@ -133,6 +142,9 @@ func OutdoorConfig() *Config {
 	config.Behavioral.MaxFlightTime = 900
 	config.Sensors.MinTOFDistance = 30
 	config.Behavioral.EnableFlips = true
+	config.Geofence.Enabled = true
+	config.Geofence.Radius = 3000
+	config.Geofence.BreachAction = "return"
 
 	utils.Logger.Info("Created outdoor safety configuration")
 	return config
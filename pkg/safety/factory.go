@@ -28,10 +28,17 @@ type Manager interface {
 	Backward(distance int) error
 	Clockwise(angle int) error
 	CounterClockwise(angle int) error
-	Flip(direction string) error
+	Flip(direction tello.FlipDirection) error
 	Go(x, y, z, speed int) error
 	Curve(x1, y1, z1, x2, y2, z2, speed int) error
 
+	// Mission Pad Commands
+	EnableMissionPads(enabled bool) error
+	SetMissionPadDirection(direction tello.MissionPadDirection) error
+	GoToPad(x, y, z, speed, mid int) error
+	CurveToPad(x1, y1, z1, x2, y2, z2, speed, mid int) error
+	JumpBetweenPads(x, y, z, speed, yaw, mid1, mid2 int) error
+
 	// Set Commands
 	SetSpeed(speed int) error
 	SetRcControl(a, b, c, d int) error
@@ -49,16 +56,24 @@ type Manager interface {
 	GetTof() (int, error)
 
 	// Video Commands
-	SetVideoFrameCallback(callback func(transport.VideoFrame))
+	SetVideoFrameCallback(callback tello.VideoFrameCallback)
 	GetVideoFrameChannel() <-chan transport.VideoFrame
 
+	// Preemptive Commands
+	EmergencyNow() error
+	LandNow() error
+	StopNow() error
+
 	// Safety-specific methods
 	GetSafetyStatus() *SafetyStatus
 	GetSafetyEvents() []SafetyEvent
 	SetSafetyEnabled(enabled bool)
 	SetEmergencyMode(emergency bool)
+	GetSafetyConfig() *Config
 	SetSafetyConfig(config *Config)
 	SetEventCallback(callback func(*SafetyEvent))
+	AddEventListener(listener func(*SafetyEvent))
+	SetPositionSource(source PositionSource)
 	StartTelemetryProcessing(stateChan <-chan *types.State)
 	StopTelemetryProcessing()
 }
@@ -80,6 +95,9 @@ func NewManager(base tello.TelloCommander, config *Config) (Manager, error) {
 
 	// Create safety manager
 	safetyManager := NewSafetyManager(base, config)
+	if safetyManager == nil {
+		return nil, fmt.Errorf("commander %T cannot be wrapped by a safety manager", base)
+	}
 	return safetyManager, nil
 }
 
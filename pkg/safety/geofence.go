@@ -0,0 +1,308 @@
+package safety
+
+import (
+	"fmt"
+	"math"
+
+	"github.com/conceptcodes/dji-tello-sdk-go/pkg/navigation"
+	"github.com/conceptcodes/dji-tello-sdk-go/pkg/utils"
+)
+
+const (
+	// geofenceSampleStep is the distance between the points checked along
+	// a commanded path (cm)
+	geofenceSampleStep = 10.0
+
+	// geofenceReturnSpeed caps the speed of the go command sent by the
+	// "return" breach action (cm/s)
+	geofenceReturnSpeed = 50
+
+	// maxGoOffset and minGoOffset are the SDK's go command limits (cm)
+	maxGoOffset = 500
+	minGoOffset = 20
+)
+
+// PositionSource provides the estimated pose that geofences are checked
+// against. navigation.Odometry implements it.
+type PositionSource interface {
+	Pose() navigation.Pose
+}
+
+// geoPoint is a position in the takeoff frame in cm
+type geoPoint struct {
+	x, y, z float64
+}
+
+func (p geoPoint) String() string {
+	// Adding zero drops the sign of a coordinate that rounds to -0
+	return fmt.Sprintf("(%.0f, %.0f, %.0f)", math.Round(p.x)+0, math.Round(p.y)+0, math.Round(p.z)+0)
+}
+
+// inBoundary reports whether p is inside the horizontal boundary
+func (g *GeofenceLimits) inBoundary(p geoPoint) bool {
+	switch g.Shape {
+	case GeofenceShapePolygon:
+		return inPolygon(g.Polygon, p.x, p.y)
+	default:
+		return math.Hypot(p.x, p.y) <= float64(g.Radius)
+	}
+}
+
+// contains reports whether p is inside the box, including its faces
+func (b *KeepOutBox) contains(p geoPoint) bool {
+	return p.x >= float64(b.Min.X) && p.x <= float64(b.Max.X) &&
+		p.y >= float64(b.Min.Y) && p.y <= float64(b.Max.Y) &&
+		p.z >= float64(b.Min.Z) && p.z <= float64(b.Max.Z)
+}
+
+func (b *KeepOutBox) label() string {
+	if b.Name != "" {
+		return fmt.Sprintf("keep-out zone '%s'", b.Name)
+	}
+	return fmt.Sprintf("keep-out zone (%d, %d, %d)-(%d, %d, %d)",
+		b.Min.X, b.Min.Y, b.Min.Z, b.Max.X, b.Max.Y, b.Max.Z)
+}
+
+// breach describes how p violates the geofence, or returns "" if it does not
+func (g *GeofenceLimits) breach(p geoPoint) string {
+	if !g.inBoundary(p) {
+		return "outside the geofence"
+	}
+	for i := range g.KeepOut {
+		if g.KeepOut[i].contains(p) {
+			return "inside " + g.KeepOut[i].label()
+		}
+	}
+	return ""
+}
+
+// checkPath describes how flying along path would violate the geofence, or
+// returns "" if it would not. A path that starts in breach may still fly
+// back inside, so only leaving the boundary, entering a keep-out box or
+// ending in breach is a violation.
+func (g *GeofenceLimits) checkPath(path []geoPoint) string {
+	for i, p := range path {
+		end := i == len(path)-1
+		if !g.inBoundary(p) && (end || i > 0 && g.inBoundary(path[i-1])) {
+			return fmt.Sprintf("Path leaves the geofence at %s", p)
+		}
+		for j := range g.KeepOut {
+			box := &g.KeepOut[j]
+			if box.contains(p) && (end || i > 0 && !box.contains(path[i-1])) {
+				return fmt.Sprintf("Path enters %s at %s", box.label(), p)
+			}
+		}
+	}
+	return ""
+}
+
+// inPolygon tests a point against a polygon by ray casting
+func inPolygon(vertices []Point2D, x, y float64) bool {
+	inside := false
+	for i, j := 0, len(vertices)-1; i < len(vertices); j, i = i, i+1 {
+		xi, yi := float64(vertices[i].X), float64(vertices[i].Y)
+		xj, yj := float64(vertices[j].X), float64(vertices[j].Y)
+		if (yi > y) != (yj > y) && x < (xj-xi)*(y-yi)/(yj-yi)+xi {
+			inside = !inside
+		}
+	}
+	return inside
+}
+
+// toTakeoffFrame converts a body-frame offset (forward, right, up) from pose
+// into a position in the takeoff frame
+func toTakeoffFrame(pose navigation.Pose, forward, right, up float64) geoPoint {
+	rad := pose.Yaw * math.Pi / 180
+	sin, cos := math.Sin(rad), math.Cos(rad)
+	return geoPoint{
+		x: pose.X + forward*cos - right*sin,
+		y: pose.Y + forward*sin + right*cos,
+		z: pose.Z + up,
+	}
+}
+
+// linePath samples the straight move by a body-frame offset from pose
+func linePath(pose navigation.Pose, forward, right, up float64) []geoPoint {
+	n := int(math.Ceil(math.Sqrt(forward*forward+right*right+up*up) / geofenceSampleStep))
+	path := make([]geoPoint, 0, n+1)
+	for i := 0; i <= n; i++ {
+		t := 1.0
+		if n > 0 {
+			t = float64(i) / float64(n)
+		}
+		path = append(path, toTakeoffFrame(pose, forward*t, right*t, up*t))
+	}
+	return path
+}
+
+// arcPath samples the arc from pose through the body-frame offset mid to
+// end, as flown by the curve command. Collinear points fly a straight line.
+func arcPath(pose navigation.Pose, mid, end [3]float64) []geoPoint {
+	center, radius, ok := circumcenter(mid, end)
+	if !ok {
+		return linePath(pose, end[0], end[1], end[2])
+	}
+
+	// Basis of the arc's plane centred on the circle, with angle 0 at the
+	// start of the arc
+	u := scale3(sub3([3]float64{}, center), 1/radius)
+	normal := cross3(mid, end)
+	v := cross3(normal, u)
+	v = scale3(v, 1/norm3(v))
+
+	angle := func(p [3]float64) float64 {
+		d := sub3(p, center)
+		a := math.Atan2(dot3(d, v), dot3(d, u))
+		if a < 0 {
+			a += 2 * math.Pi
+		}
+		return a
+	}
+
+	// Sweep towards the end in the direction that passes through mid
+	sweep := angle(end)
+	if angle(mid) > sweep {
+		sweep -= 2 * math.Pi
+	}
+
+	n := int(math.Ceil(math.Abs(sweep) * radius / geofenceSampleStep))
+	path := make([]geoPoint, 0, n+1)
+	for i := 0; i <= n; i++ {
+		a := sweep * float64(i) / float64(n)
+		p := [3]float64{}
+		for k := range p {
+			p[k] = center[k] + radius*(math.Cos(a)*u[k]+math.Sin(a)*v[k])
+		}
+		path = append(path, toTakeoffFrame(pose, p[0], p[1], p[2]))
+	}
+	return path
+}
+
+// circumcenter returns the centre and radius of the circle through the
+// origin, a and b
+func circumcenter(a, b [3]float64) ([3]float64, float64, bool) {
+	axb := cross3(a, b)
+	denom := 2 * dot3(axb, axb)
+	if denom < 1e-9 {
+		return [3]float64{}, 0, false
+	}
+	// c = (|a|²(b×(a×b)) + |b|²((a×b)×a)) / 2|a×b|²
+	c := add3(scale3(cross3(b, axb), dot3(a, a)), scale3(cross3(axb, a), dot3(b, b)))
+	c = scale3(c, 1/denom)
+	return c, norm3(c), true
+}
+
+func add3(a, b [3]float64) [3]float64 { return [3]float64{a[0] + b[0], a[1] + b[1], a[2] + b[2]} }
+func sub3(a, b [3]float64) [3]float64 { return [3]float64{a[0] - b[0], a[1] - b[1], a[2] - b[2]} }
+func scale3(a [3]float64, f float64) [3]float64 {
+	return [3]float64{a[0] * f, a[1] * f, a[2] * f}
+}
+func dot3(a, b [3]float64) float64 { return a[0]*b[0] + a[1]*b[1] + a[2]*b[2] }
+func norm3(a [3]float64) float64   { return math.Sqrt(dot3(a, a)) }
+func cross3(a, b [3]float64) [3]float64 {
+	return [3]float64{a[1]*b[2] - a[2]*b[1], a[2]*b[0] - a[0]*b[2], a[0]*b[1] - a[1]*b[0]}
+}
+
+// SetPositionSource sets the pose estimate that geofences are checked
+// against. Without one, the geofence is not enforced.
+func (sm *SafetyManager) SetPositionSource(source PositionSource) {
+	sm.mutex.Lock()
+	defer sm.mutex.Unlock()
+	sm.positionSource = source
+}
+
+// validateGeofence checks the path that a command would fly, built from the
+// current pose estimate
+func (sm *SafetyManager) validateGeofence(path func(pose navigation.Pose) []geoPoint) CommandValidationResult {
+	if !sm.config.Geofence.Enabled || sm.positionSource == nil {
+		return CommandValidationResult{Allowed: true}
+	}
+
+	if reason := sm.config.Geofence.checkPath(path(sm.positionSource.Pose())); reason != "" {
+		return CommandValidationResult{
+			Allowed: false,
+			Reason:  reason,
+		}
+	}
+	return CommandValidationResult{Allowed: true}
+}
+
+// checkGeofenceSafety raises an event and takes the breach action when the
+// estimated position first breaches the geofence
+func (sm *SafetyManager) checkGeofenceSafety() {
+	if !sm.config.Geofence.Enabled || sm.positionSource == nil {
+		return
+	}
+
+	pose := sm.positionSource.Pose()
+	reason := sm.config.Geofence.breach(geoPoint{pose.X, pose.Y, pose.Z})
+	if reason == "" {
+		sm.geofenceBreached = false
+		return
+	}
+	if sm.geofenceBreached {
+		return
+	}
+	sm.geofenceBreached = true
+
+	action := SafetyAction(sm.config.Geofence.BreachAction)
+	event := NewSafetyEvent(SafetyEventGeofence, SafetyEventLevelCritical,
+		"Geofence breached - drone is "+reason, map[string]any{
+			"x":      pose.X,
+			"y":      pose.Y,
+			"z":      pose.Z,
+			"action": string(action),
+		})
+	sm.addEvent(event)
+
+	if sm.safetyEnabled && !sm.emergencyMode {
+		utils.Logger.Errorf("Geofence breached - triggering %s", action)
+		go sm.executeBreachAction(action, pose, sm.config.Velocity.MaxHorizontal)
+	}
+}
+
+// executeBreachAction takes the configured action after a geofence breach
+func (sm *SafetyManager) executeBreachAction(action SafetyAction, pose navigation.Pose, maxSpeed int) {
+	var err error
+	switch action {
+	case SafetyActionLand:
+		err = sm.LandNow()
+	case SafetyActionHover:
+		err = sm.StopNow()
+	case SafetyActionReturn:
+		err = sm.returnTowardsHome(pose, maxSpeed)
+	}
+	if err != nil {
+		utils.Logger.Errorf("Geofence %s action failed: %v", action, err)
+	}
+}
+
+// returnTowardsHome stops the drone, dropping any queued moves that would
+// carry it further out, and flies straight towards the takeoff point at the
+// current height by at most the SDK's longest go move
+func (sm *SafetyManager) returnTowardsHome(pose navigation.Pose, maxSpeed int) error {
+	if err := sm.StopNow(); err != nil {
+		return err
+	}
+
+	// Rotate the way home from the takeoff frame into the body frame
+	rad := pose.Yaw * math.Pi / 180
+	sin, cos := math.Sin(rad), math.Cos(rad)
+	forward := -pose.X*cos - pose.Y*sin
+	right := pose.X*sin - pose.Y*cos
+
+	if distance := math.Hypot(forward, right); distance > maxGoOffset {
+		forward *= maxGoOffset / distance
+		right *= maxGoOffset / distance
+	}
+	x, y := int(math.Round(forward)), int(math.Round(-right))
+	if abs(x) <= minGoOffset && abs(y) <= minGoOffset {
+		return nil
+	}
+
+	speed := geofenceReturnSpeed
+	if maxSpeed < speed {
+		speed = maxSpeed
+	}
+	return sm.commander.Go(x, y, 0, speed)
+}
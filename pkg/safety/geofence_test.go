@@ -0,0 +1,410 @@
+package safety
+
+import (
+	"fmt"
+	"math"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/conceptcodes/dji-tello-sdk-go/pkg/navigation"
+	"github.com/conceptcodes/dji-tello-sdk-go/pkg/types"
+)
+
+// fixedPosition is a PositionSource reporting a set pose
+type fixedPosition struct {
+	pose navigation.Pose
+}
+
+func (f *fixedPosition) Pose() navigation.Pose {
+	return f.pose
+}
+
+// actionCommander records the commands sent by geofence breach actions
+type actionCommander struct {
+	*MockCommander
+	actions chan string
+}
+
+func newActionCommander() *actionCommander {
+	return &actionCommander{MockCommander: NewMockCommander(), actions: make(chan string, 10)}
+}
+
+func (c *actionCommander) Land() error {
+	c.actions <- "land"
+	return nil
+}
+
+func (c *actionCommander) Go(x, y, z, speed int) error {
+	c.actions <- fmt.Sprintf("go %d %d %d %d", x, y, z, speed)
+	return nil
+}
+
+func (c *actionCommander) SetRcControl(a, b, cc, d int) error {
+	c.actions <- fmt.Sprintf("rc %d %d %d %d", a, b, cc, d)
+	return nil
+}
+
+// preemptiveActionCommander also records the preemptive commands
+type preemptiveActionCommander struct {
+	*actionCommander
+}
+
+func (c *preemptiveActionCommander) EmergencyNow() error {
+	c.actions <- "emergency now"
+	return nil
+}
+
+func (c *preemptiveActionCommander) LandNow() error {
+	c.actions <- "land now"
+	return nil
+}
+
+func (c *preemptiveActionCommander) StopNow() error {
+	c.actions <- "stop now"
+	return nil
+}
+
+func squareGeofence() GeofenceLimits {
+	return GeofenceLimits{
+		Enabled: true,
+		Shape:   GeofenceShapePolygon,
+		Polygon: []Point2D{{X: -100, Y: -100}, {X: 100, Y: -100}, {X: 100, Y: 100}, {X: -100, Y: 100}},
+		KeepOut: []KeepOutBox{
+			{Name: "table", Min: Point3D{X: 40, Y: -20, Z: 0}, Max: Point3D{X: 60, Y: 20, Z: 80}},
+		},
+		BreachAction: "land",
+	}
+}
+
+func TestGeofenceBreach(t *testing.T) {
+	cylinder := GeofenceLimits{Enabled: true, Shape: GeofenceShapeCylinder, Radius: 100}
+	square := squareGeofence()
+
+	tests := []struct {
+		name     string
+		geofence GeofenceLimits
+		point    geoPoint
+		expected string
+	}{
+		{"Cylinder centre", cylinder, geoPoint{0, 0, 100}, ""},
+		{"Cylinder edge", cylinder, geoPoint{60, 80, 100}, ""},
+		{"Outside cylinder", cylinder, geoPoint{80, 80, 100}, "outside the geofence"},
+		{"Polygon inside", square, geoPoint{-90, 90, 100}, ""},
+		{"Outside polygon", square, geoPoint{110, 0, 100}, "outside the geofence"},
+		{"Inside keep-out zone", square, geoPoint{50, 0, 50}, "inside keep-out zone 'table'"},
+		{"Above keep-out zone", square, geoPoint{50, 0, 100}, ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.geofence.breach(tt.point); got != tt.expected {
+				t.Errorf("Expected breach %q, got %q", tt.expected, got)
+			}
+		})
+	}
+}
+
+func TestGeofenceCheckPath(t *testing.T) {
+	geofence := squareGeofence()
+
+	tests := []struct {
+		name     string
+		from     navigation.Pose
+		forward  float64
+		right    float64
+		up       float64
+		expected string
+	}{
+		{
+			name:    "Stays inside",
+			from:    navigation.Pose{X: -50, Y: 0, Z: 100},
+			forward: 0, right: 80,
+		},
+		{
+			name:     "Leaves the boundary",
+			from:     navigation.Pose{X: 0, Y: 45, Z: 100},
+			forward:  0,
+			right:    80,
+			expected: "Path leaves the geofence at (0, 105, 100)",
+		},
+		{
+			name:    "Returns from outside",
+			from:    navigation.Pose{X: 150, Y: 0, Z: 100},
+			forward: -100,
+		},
+		{
+			name:     "Ends outside",
+			from:     navigation.Pose{X: 150, Y: 0, Z: 100},
+			forward:  20,
+			expected: "Path leaves the geofence at (170, 0, 100)",
+		},
+		{
+			name:     "Crosses keep-out zone",
+			from:     navigation.Pose{X: 0, Y: 0, Z: 50},
+			forward:  90,
+			expected: "Path enters keep-out zone 'table' at (40, 0, 50)",
+		},
+		{
+			name:     "Crosses keep-out zone after turning",
+			from:     navigation.Pose{X: 50, Y: -80, Z: 50, Yaw: 90},
+			forward:  90,
+			expected: "Path enters keep-out zone 'table' at (50, -20, 50)",
+		},
+		{
+			name:    "Climbs over keep-out zone",
+			from:    navigation.Pose{X: 0, Y: 0, Z: 100},
+			forward: 90,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := linePath(tt.from, tt.forward, tt.right, tt.up)
+			if got := geofence.checkPath(path); got != tt.expected {
+				t.Errorf("Expected %q, got %q", tt.expected, got)
+			}
+		})
+	}
+}
+
+func TestArcPath(t *testing.T) {
+	// Half circle to the right from the takeoff point facing along Y
+	pose := navigation.Pose{Z: 100, Yaw: 90}
+	path := arcPath(pose, [3]float64{50, 50, 0}, [3]float64{100, 0, 0})
+
+	start, end := path[0], path[len(path)-1]
+	if math.Hypot(start.x, start.y) > 1e-6 {
+		t.Errorf("Expected the arc to start at the pose, got %s", start)
+	}
+	if math.Abs(end.x) > 1e-6 || math.Abs(end.y-100) > 1e-6 {
+		t.Errorf("Expected the arc to end at (0, 100, 100), got %s", end)
+	}
+
+	minX := 0.0
+	for _, p := range path {
+		if r := math.Hypot(p.x, p.y-50); math.Abs(r-50) > 1e-6 {
+			t.Fatalf("Point %s is %.1fcm from the centre, expected 50cm", p, r)
+		}
+		if p.z != 100 {
+			t.Fatalf("Point %s changed height", p)
+		}
+		minX = math.Min(minX, p.x)
+	}
+	// Right of the drone facing along Y is -X in the takeoff frame
+	if minX > -49 {
+		t.Errorf("Expected the arc to pass through x=-50, got min x %.1f", minX)
+	}
+}
+
+func TestValidateGeofenceCommands(t *testing.T) {
+	config := DefaultConfig()
+	config.Geofence = GeofenceLimits{Enabled: true, Shape: GeofenceShapeCylinder, Radius: 100, BreachAction: "land"}
+
+	tests := []struct {
+		name           string
+		pose           navigation.Pose
+		validate       func(sm *SafetyManager) CommandValidationResult
+		expectAllowed  bool
+		expectedReason string
+	}{
+		{
+			name:          "Go inside",
+			pose:          navigation.Pose{X: 50, Z: 100},
+			validate:      func(sm *SafetyManager) CommandValidationResult { return sm.validateGoCommand(40, 0, 0, 50) },
+			expectAllowed: true,
+		},
+		{
+			name:           "Go outside",
+			pose:           navigation.Pose{X: 50, Z: 100},
+			validate:       func(sm *SafetyManager) CommandValidationResult { return sm.validateGoCommand(100, 0, 0, 50) },
+			expectedReason: "Path leaves the geofence at (110, 0, 100)",
+		},
+		{
+			name:           "Go left after turning",
+			pose:           navigation.Pose{X: 50, Z: 100, Yaw: 90},
+			validate:       func(sm *SafetyManager) CommandValidationResult { return sm.validateGoCommand(0, 100, 0, 50) },
+			expectedReason: "Path leaves the geofence at (110, 0, 100)",
+		},
+		{
+			name:           "Move right outside",
+			pose:           navigation.Pose{Y: 50, Z: 100},
+			validate:       func(sm *SafetyManager) CommandValidationResult { return sm.validateMovementCommand("right", 0, 100, 0) },
+			expectedReason: "Path leaves the geofence at (0, 110, 100)",
+		},
+		{
+			name:          "Move up",
+			pose:          navigation.Pose{X: 90, Z: 100},
+			validate:      func(sm *SafetyManager) CommandValidationResult { return sm.validateMovementCommand("up", 0, 0, 50) },
+			expectAllowed: true,
+		},
+		{
+			name: "Curve outside",
+			pose: navigation.Pose{Z: 100},
+			validate: func(sm *SafetyManager) CommandValidationResult {
+				return sm.validateCurveCommand(60, 60, 0, 120, 0, 0, 30)
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			manager := NewSafetyManager(NewMockCommander(), config)
+			manager.SetPositionSource(&fixedPosition{pose: tt.pose})
+
+			result := tt.validate(manager)
+			if result.Allowed != tt.expectAllowed {
+				t.Fatalf("Expected allowed=%t, got %t: %s", tt.expectAllowed, result.Allowed, result.Reason)
+			}
+			if tt.expectedReason != "" && result.Reason != tt.expectedReason {
+				t.Errorf("Expected reason %q, got %q", tt.expectedReason, result.Reason)
+			}
+		})
+	}
+}
+
+func TestValidateGeofenceWithoutPosition(t *testing.T) {
+	config := DefaultConfig()
+	config.Geofence = GeofenceLimits{Enabled: true, Shape: GeofenceShapeCylinder, Radius: 100, BreachAction: "land"}
+	manager := NewSafetyManager(NewMockCommander(), config)
+
+	if result := manager.validateGoCommand(300, 0, 0, 50); !result.Allowed {
+		t.Errorf("Expected go to be allowed without a position source, got: %s", result.Reason)
+	}
+}
+
+func TestGeofenceBreachAction(t *testing.T) {
+	tests := []struct {
+		name       string
+		action     string
+		preemptive bool
+		pose       navigation.Pose
+		expected   []string
+	}{
+		{"Land", "land", false, navigation.Pose{X: 150, Z: 100}, []string{"land"}},
+		{"Land preemptively", "land", true, navigation.Pose{X: 150, Z: 100}, []string{"land now"}},
+		{"Hover", "hover", false, navigation.Pose{X: 150, Z: 100}, []string{"rc 0 0 0 0"}},
+		{"Hover preemptively", "hover", true, navigation.Pose{X: 150, Z: 100}, []string{"stop now"}},
+		{"Return", "return", false, navigation.Pose{X: 150, Z: 100}, []string{"rc 0 0 0 0", "go -150 0 0 50"}},
+		{"Return preemptively", "return", true, navigation.Pose{X: 150, Z: 100}, []string{"stop now", "go -150 0 0 50"}},
+		{"Return after turning", "return", false, navigation.Pose{X: 150, Z: 100, Yaw: 90}, []string{"rc 0 0 0 0", "go 0 -150 0 50"}},
+		{"Return capped at the longest go", "return", false, navigation.Pose{Y: -1000, Z: 100}, []string{"rc 0 0 0 0", "go 0 -500 0 50"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			commander := newActionCommander()
+			config := DefaultConfig()
+			config.Geofence = GeofenceLimits{Enabled: true, Shape: GeofenceShapeCylinder, Radius: 100, BreachAction: tt.action}
+			var base CommanderInterface = commander
+			if tt.preemptive {
+				base = &preemptiveActionCommander{commander}
+			}
+			manager := NewSafetyManager(base, config)
+			position := &fixedPosition{pose: tt.pose}
+			manager.SetPositionSource(position)
+
+			state := &types.State{H: 100, Bat: 90, Tof: 100}
+			manager.UpdateState(state)
+			manager.UpdateState(state)
+
+			for _, expected := range tt.expected {
+				select {
+				case action := <-commander.actions:
+					if action != expected {
+						t.Errorf("Expected action %q, got %q", expected, action)
+					}
+				case <-time.After(time.Second):
+					t.Fatalf("Expected breach action %q", expected)
+				}
+			}
+			select {
+			case action := <-commander.actions:
+				t.Errorf("Expected no further breach action, got %q", action)
+			case <-time.After(50 * time.Millisecond):
+			}
+
+			events := 0
+			for _, event := range manager.GetSafetyEvents() {
+				if event.Type == string(SafetyEventGeofence) {
+					events++
+					if event.Level != string(SafetyEventLevelCritical) {
+						t.Errorf("Expected a critical event, got %v", event.Level)
+					}
+				}
+			}
+			if events != 1 {
+				t.Errorf("Expected 1 geofence event, got %d", events)
+			}
+
+			// Flying back inside and out again raises a new breach
+			position.pose = navigation.Pose{Z: 100}
+			manager.UpdateState(state)
+			position.pose = tt.pose
+			manager.UpdateState(state)
+			select {
+			case <-commander.actions:
+			case <-time.After(time.Second):
+				t.Fatal("Expected a breach action after leaving the geofence again")
+			}
+		})
+	}
+}
+
+// queueingCommander queues moves like the Tello commander, and drops them
+// when stopped preemptively
+type queueingCommander struct {
+	*preemptiveActionCommander
+
+	mu     sync.Mutex
+	queued []string
+}
+
+func (c *queueingCommander) Forward(distance int) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.queued = append(c.queued, fmt.Sprintf("forward %d", distance))
+	return nil
+}
+
+func (c *queueingCommander) StopNow() error {
+	c.mu.Lock()
+	c.queued = nil
+	c.mu.Unlock()
+	return c.preemptiveActionCommander.StopNow()
+}
+
+func TestGeofenceReturnDropsQueuedMoves(t *testing.T) {
+	actions := newActionCommander()
+	commander := &queueingCommander{preemptiveActionCommander: &preemptiveActionCommander{actions}}
+	config := DefaultConfig()
+	config.Geofence = GeofenceLimits{Enabled: true, Shape: GeofenceShapeCylinder, Radius: 100, BreachAction: "return"}
+	manager := NewSafetyManager(commander, config)
+	position := &fixedPosition{pose: navigation.Pose{Z: 100}}
+	manager.SetPositionSource(position)
+
+	// Moves still waiting in the queue when the geofence is breached
+	for i := 0; i < 3; i++ {
+		commander.Forward(50)
+	}
+	position.pose = navigation.Pose{X: 150, Z: 100}
+	state := &types.State{H: 100, Bat: 90, Tof: 100}
+	manager.UpdateState(state)
+
+	for _, expected := range []string{"stop now", "go -150 0 0 50"} {
+		select {
+		case action := <-actions.actions:
+			if action != expected {
+				t.Errorf("Expected action %q, got %q", expected, action)
+			}
+		case <-time.After(time.Second):
+			t.Fatalf("Expected breach action %q", expected)
+		}
+	}
+
+	commander.mu.Lock()
+	defer commander.mu.Unlock()
+	if len(commander.queued) != 0 {
+		t.Errorf("Expected the queued moves to be dropped, got %v", commander.queued)
+	}
+}
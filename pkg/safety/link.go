@@ -0,0 +1,75 @@
+package safety
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/conceptcodes/dji-tello-sdk-go/pkg/tello"
+	"github.com/conceptcodes/dji-tello-sdk-go/pkg/utils"
+)
+
+// LinkMonitor is implemented by commanders that watch the link to the drone,
+// as tello.TelloCommander does. The safety manager sets its timeout from
+// Emergency.ConnectionTimeout and takes Emergency.SensorFailureAction when
+// the link is lost.
+type LinkMonitor interface {
+	SetLinkTimeout(timeout time.Duration)
+	SetLinkCallback(callback func(tello.LinkEvent))
+}
+
+func linkTimeout(config *Config) time.Duration {
+	return time.Duration(config.Emergency.ConnectionTimeout) * time.Millisecond
+}
+
+// handleLinkEvent raises an event when the link is lost or comes back, and
+// takes the configured action when it is lost
+func (sm *SafetyManager) handleLinkEvent(link tello.LinkEvent) {
+	sm.mutex.Lock()
+	defer sm.mutex.Unlock()
+
+	sm.status.LinkLost = !link.Up
+	data := map[string]any{
+		"since_state_ms": link.SinceState.Milliseconds(),
+		"since_reply_ms": link.SinceReply.Milliseconds(),
+	}
+
+	if link.Up {
+		sm.addEvent(NewSafetyEvent(SafetyEventConnection, SafetyEventLevelInfo,
+			"Connection restored", data))
+		sm.updateSafetyStatus()
+		return
+	}
+
+	// The most recent of the two is how long the drone has been silent
+	silent := link.SinceState
+	if silent == 0 || (link.SinceReply != 0 && link.SinceReply < silent) {
+		silent = link.SinceReply
+	}
+	action := SafetyAction(sm.config.Emergency.SensorFailureAction)
+	data["action"] = string(action)
+	sm.addEvent(NewSafetyEvent(SafetyEventConnection, SafetyEventLevelCritical,
+		fmt.Sprintf("Connection lost - nothing heard from the drone for %v", silent.Round(100*time.Millisecond)), data))
+	sm.updateSafetyStatus()
+
+	if sm.safetyEnabled && !sm.emergencyMode {
+		utils.Logger.Errorf("Connection lost - triggering %s", action)
+		go sm.executeLinkLossAction(action)
+	}
+}
+
+// executeLinkLossAction takes the configured action after the link is lost.
+// The drone may still hear commands when only its replies are being lost.
+func (sm *SafetyManager) executeLinkLossAction(action SafetyAction) {
+	var err error
+	switch action {
+	case SafetyActionLand:
+		err = sm.LandNow()
+	case SafetyActionHover:
+		err = sm.StopNow()
+	case SafetyActionEmergency:
+		err = sm.EmergencyNow()
+	}
+	if err != nil {
+		utils.Logger.Errorf("Connection loss %s action failed: %v", action, err)
+	}
+}
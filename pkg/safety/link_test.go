@@ -0,0 +1,116 @@
+package safety
+
+import (
+	"testing"
+	"time"
+
+	"github.com/conceptcodes/dji-tello-sdk-go/pkg/tello"
+)
+
+// linkCommander is a preemptive commander that also watches the link
+type linkCommander struct {
+	*preemptiveActionCommander
+	timeout  time.Duration
+	callback func(tello.LinkEvent)
+}
+
+func newLinkCommander() *linkCommander {
+	return &linkCommander{preemptiveActionCommander: &preemptiveActionCommander{newActionCommander()}}
+}
+
+func (c *linkCommander) SetLinkTimeout(timeout time.Duration) {
+	c.timeout = timeout
+}
+
+func (c *linkCommander) SetLinkCallback(callback func(tello.LinkEvent)) {
+	c.callback = callback
+}
+
+func TestLinkMonitorConfiguredFromSafetyConfig(t *testing.T) {
+	commander := newLinkCommander()
+	config := DefaultConfig()
+	config.Emergency.ConnectionTimeout = 2500
+	manager := NewSafetyManager(commander, config)
+
+	if commander.timeout != 2500*time.Millisecond {
+		t.Errorf("Expected a 2.5s link timeout, got %v", commander.timeout)
+	}
+	if commander.callback == nil {
+		t.Fatal("Expected the safety manager to register a link callback")
+	}
+
+	updated := DefaultConfig()
+	updated.Emergency.ConnectionTimeout = 1000
+	manager.SetSafetyConfig(updated)
+	if commander.timeout != time.Second {
+		t.Errorf("Expected the link timeout to follow the config, got %v", commander.timeout)
+	}
+}
+
+func TestLinkLossAction(t *testing.T) {
+	tests := []struct {
+		action   string
+		expected string
+	}{
+		{"land", "land now"},
+		{"hover", "stop now"},
+		{"emergency", "emergency now"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.action, func(t *testing.T) {
+			commander := newLinkCommander()
+			config := DefaultConfig()
+			config.Emergency.SensorFailureAction = tt.action
+			manager := NewSafetyManager(commander, config)
+
+			commander.callback(tello.LinkEvent{SinceState: 3200 * time.Millisecond, SinceReply: 4 * time.Second})
+
+			select {
+			case action := <-commander.actions:
+				if action != tt.expected {
+					t.Errorf("Expected action %q, got %q", tt.expected, action)
+				}
+			case <-time.After(time.Second):
+				t.Fatal("Expected a link loss action")
+			}
+
+			status := manager.GetSafetyStatus()
+			if !status.LinkLost || status.IsSafe {
+				t.Errorf("Expected the link to be reported lost and unsafe, got link lost=%t safe=%t", status.LinkLost, status.IsSafe)
+			}
+			events := manager.GetSafetyEvents()
+			last := events[len(events)-1]
+			if last.Type != string(SafetyEventConnection) || last.Level != string(SafetyEventLevelCritical) {
+				t.Errorf("Expected a critical connection event, got %s %s", last.Level, last.Type)
+			}
+			if last.Message != "Connection lost - nothing heard from the drone for 3.2s" {
+				t.Errorf("Unexpected event message %q", last.Message)
+			}
+
+			commander.callback(tello.LinkEvent{Up: true})
+			if manager.GetSafetyStatus().LinkLost {
+				t.Error("Expected the link to be reported restored")
+			}
+			select {
+			case action := <-commander.actions:
+				t.Errorf("Expected no action when the link comes back, got %q", action)
+			case <-time.After(50 * time.Millisecond):
+			}
+		})
+	}
+}
+
+func TestLinkLossActionSkippedInEmergencyMode(t *testing.T) {
+	commander := newLinkCommander()
+	manager := NewSafetyManager(commander, DefaultConfig())
+	manager.SetEmergencyMode(true)
+
+	commander.callback(tello.LinkEvent{SinceReply: 4 * time.Second})
+
+	select {
+	case action := <-commander.actions:
+		t.Errorf("Expected no action in emergency mode, got %q", action)
+	case <-time.After(100 * time.Millisecond):
+	}
+}
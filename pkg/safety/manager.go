@@ -8,11 +8,17 @@ import (
 	"sync/atomic"
 	"time"
 
+	"github.com/conceptcodes/dji-tello-sdk-go/pkg/navigation"
+	"github.com/conceptcodes/dji-tello-sdk-go/pkg/tello"
 	"github.com/conceptcodes/dji-tello-sdk-go/pkg/transport"
 	"github.com/conceptcodes/dji-tello-sdk-go/pkg/types"
 	"github.com/conceptcodes/dji-tello-sdk-go/pkg/utils"
 )
 
+// landedHeight is the height (cm) at or below which a state counts the
+// drone as landed
+const landedHeight = 10
+
 // CommanderInterface defines the interface for drone commands (same as tello.TelloCommander)
 type CommanderInterface interface {
 	// Control Commands
@@ -30,10 +36,17 @@ type CommanderInterface interface {
 	Backward(distance int) error
 	Clockwise(angle int) error
 	CounterClockwise(angle int) error
-	Flip(direction string) error
+	Flip(direction tello.FlipDirection) error
 	Go(x, y, z, speed int) error
 	Curve(x1, y1, z1, x2, y2, z2, speed int) error
 
+	// Mission Pad Commands
+	EnableMissionPads(enabled bool) error
+	SetMissionPadDirection(direction tello.MissionPadDirection) error
+	GoToPad(x, y, z, speed, mid int) error
+	CurveToPad(x1, y1, z1, x2, y2, z2, speed, mid int) error
+	JumpBetweenPads(x, y, z, speed, yaw, mid1, mid2 int) error
+
 	// Set Commands
 	SetSpeed(speed int) error
 	SetRcControl(a, b, c, d int) error
@@ -51,17 +64,30 @@ type CommanderInterface interface {
 	GetTof() (int, error)
 
 	// Video Commands
-	SetVideoFrameCallback(callback func(transport.VideoFrame))
+	SetVideoFrameCallback(callback tello.VideoFrameCallback)
 	GetVideoFrameChannel() <-chan transport.VideoFrame
 }
 
+// Every tello.TelloCommander can be wrapped
+var _ CommanderInterface = tello.TelloCommander(nil)
+
+// PreemptiveCommander is implemented by commanders that can stop the drone
+// ahead of the commands already queued, as tello.TelloCommander does. Safety
+// triggers use it when the commander supports it.
+type PreemptiveCommander interface {
+	EmergencyNow() error
+	LandNow() error
+	StopNow() error
+}
+
 // SafetyManager wraps CommanderInterface to provide safety validation and monitoring
 type SafetyManager struct {
-	commander     CommanderInterface
-	config        *Config
-	status        *SafetyStatus
-	mutex         sync.RWMutex
-	eventCallback func(*SafetyEvent)
+	commander      CommanderInterface
+	config         *Config
+	status         *SafetyStatus
+	mutex          sync.RWMutex
+	eventCallback  func(*SafetyEvent)
+	eventListeners []func(*SafetyEvent)
 
 	// State tracking
 	lastStateUpdate time.Time
@@ -86,6 +112,16 @@ type SafetyManager struct {
 	// Emergency state
 	emergencyMode bool
 	safetyEnabled bool
+
+	// Mission pad state
+	missionPadsEnabled bool
+
+	// Geofence state
+	positionSource   PositionSource
+	geofenceBreached bool // The last position was in breach
+
+	// Battery state
+	autoLanding bool // Auto-land was triggered and the drone has not landed or recovered since
 }
 
 // NewSafetyManager creates a new safety manager
@@ -108,6 +144,11 @@ func NewSafetyManager(commander interface{}, config *Config) *SafetyManager {
 
 	sm.status.ConfigLevel = config.Level
 
+	if monitor, ok := commander.(LinkMonitor); ok {
+		monitor.SetLinkTimeout(linkTimeout(config))
+		monitor.SetLinkCallback(sm.handleLinkEvent)
+	}
+
 	return sm
 }
 
@@ -159,7 +200,16 @@ func (sm *SafetyManager) executeCallbackWithSemaphore(event *SafetyEvent) {
 		return
 	case sm.callbackSemaphore <- struct{}{}:
 		defer func() { <-sm.callbackSemaphore }()
-		sm.eventCallback(event)
+		sm.mutex.RLock()
+		callback, listeners := sm.eventCallback, sm.eventListeners
+		sm.mutex.RUnlock()
+
+		if callback != nil {
+			callback(event)
+		}
+		for _, listener := range listeners {
+			listener(event)
+		}
 	}
 }
 
@@ -192,6 +242,7 @@ func (sm *SafetyManager) UpdateState(state *types.State) {
 	sm.checkBatterySafety(state)
 	sm.checkSensorSafety(state)
 	sm.checkBehavioralSafety(state)
+	sm.checkGeofenceSafety()
 
 	// Update overall safety status
 	sm.updateSafetyStatus()
@@ -212,7 +263,8 @@ func (sm *SafetyManager) StopTelemetryProcessing() {
 	sm.telemetryWg.Wait()
 }
 
-// SetEventCallback sets a callback for safety events
+// SetEventCallback sets a callback for safety events, replacing the previous
+// one. Listeners added with AddEventListener are called as well.
 func (sm *SafetyManager) SetEventCallback(callback func(*SafetyEvent)) {
 	sm.mutex.Lock()
 	defer sm.mutex.Unlock()
@@ -223,6 +275,22 @@ func (sm *SafetyManager) SetEventCallback(callback func(*SafetyEvent)) {
 	}
 }
 
+// AddEventListener adds a function that is called with every safety event,
+// so that several consumers such as a flight log, the web interface and a
+// gamepad can each see them
+func (sm *SafetyManager) AddEventListener(listener func(*SafetyEvent)) {
+	if listener == nil {
+		return
+	}
+	sm.mutex.Lock()
+	defer sm.mutex.Unlock()
+	sm.eventListeners = append(sm.eventListeners, listener)
+
+	if atomic.LoadInt32(&sm.callbackStarted) == 0 {
+		sm.startCallbackWorker()
+	}
+}
+
 func (sm *SafetyManager) startCallbackWorker() {
 	sm.callbackChan = make(chan *SafetyEvent, 100)
 	sm.callbackSemaphore = make(chan struct{}, 10)
@@ -290,6 +358,13 @@ func (sm *SafetyManager) SetEmergencyMode(emergency bool) {
 	}
 }
 
+// GetSafetyConfig returns the configuration commands are validated against
+func (sm *SafetyManager) GetSafetyConfig() *Config {
+	sm.mutex.RLock()
+	defer sm.mutex.RUnlock()
+	return sm.config
+}
+
 // SetSafetyConfig updates the safety configuration
 func (sm *SafetyManager) SetSafetyConfig(config *Config) {
 	sm.mutex.Lock()
@@ -297,6 +372,9 @@ func (sm *SafetyManager) SetSafetyConfig(config *Config) {
 
 	sm.config = config
 	sm.status.ConfigLevel = config.Level
+	if monitor, ok := sm.commander.(LinkMonitor); ok {
+		monitor.SetLinkTimeout(linkTimeout(config))
+	}
 
 	utils.Logger.Infof("Safety configuration updated to: %s", config.Level)
 }
@@ -368,17 +446,45 @@ func (sm *SafetyManager) StreamOff() error {
 	return sm.commander.StreamOff()
 }
 
+// Emergency is always allowed, and goes ahead of any queued or in-flight
+// command like EmergencyNow
 func (sm *SafetyManager) Emergency() error {
-	// Emergency is always allowed
+	return sm.EmergencyNow()
+}
+
+// EmergencyNow stops the motors ahead of any queued commands if the
+// commander supports it, or like Emergency otherwise
+func (sm *SafetyManager) EmergencyNow() error {
+	if commander, ok := sm.commander.(PreemptiveCommander); ok {
+		return commander.EmergencyNow()
+	}
 	return sm.commander.Emergency()
 }
 
+// LandNow lands ahead of any queued commands if the commander supports it,
+// or like Land otherwise
+func (sm *SafetyManager) LandNow() error {
+	if commander, ok := sm.commander.(PreemptiveCommander); ok {
+		return commander.LandNow()
+	}
+	return sm.commander.Land()
+}
+
+// StopNow hovers ahead of any queued commands if the commander supports it,
+// or zeroes the RC sticks otherwise
+func (sm *SafetyManager) StopNow() error {
+	if commander, ok := sm.commander.(PreemptiveCommander); ok {
+		return commander.StopNow()
+	}
+	return sm.commander.SetRcControl(0, 0, 0, 0)
+}
+
 func (sm *SafetyManager) Up(distance int) error {
 	if !sm.safetyEnabled || sm.emergencyMode {
 		return sm.commander.Up(distance)
 	}
 
-	result := sm.validateMovementCommand("up", distance, 0, 0)
+	result := sm.validateMovementCommand("up", 0, 0, distance)
 	if !result.Allowed {
 		return fmt.Errorf("safety check failed: %s", result.Reason)
 	}
@@ -391,7 +497,7 @@ func (sm *SafetyManager) Down(distance int) error {
 		return sm.commander.Down(distance)
 	}
 
-	result := sm.validateMovementCommand("down", -distance, 0, 0)
+	result := sm.validateMovementCommand("down", 0, 0, -distance)
 	if !result.Allowed {
 		return fmt.Errorf("safety check failed: %s", result.Reason)
 	}
@@ -477,12 +583,12 @@ func (sm *SafetyManager) CounterClockwise(angle int) error {
 	return sm.commander.CounterClockwise(angle)
 }
 
-func (sm *SafetyManager) Flip(direction string) error {
+func (sm *SafetyManager) Flip(direction tello.FlipDirection) error {
 	if !sm.safetyEnabled || sm.emergencyMode {
 		return sm.commander.Flip(direction)
 	}
 
-	result := sm.validateFlipCommand(direction)
+	result := sm.validateFlipCommand(string(direction))
 	if !result.Allowed {
 		return fmt.Errorf("safety check failed: %s", result.Reason)
 	}
@@ -516,6 +622,60 @@ func (sm *SafetyManager) Curve(x1, y1, z1, x2, y2, z2, speed int) error {
 	return sm.commander.Curve(x1, y1, z1, x2, y2, z2, speed)
 }
 
+func (sm *SafetyManager) EnableMissionPads(enabled bool) error {
+	if err := sm.commander.EnableMissionPads(enabled); err != nil {
+		return err
+	}
+
+	sm.mutex.Lock()
+	sm.missionPadsEnabled = enabled
+	sm.mutex.Unlock()
+	return nil
+}
+
+func (sm *SafetyManager) SetMissionPadDirection(direction tello.MissionPadDirection) error {
+	return sm.commander.SetMissionPadDirection(direction)
+}
+
+func (sm *SafetyManager) GoToPad(x, y, z, speed, mid int) error {
+	if !sm.safetyEnabled || sm.emergencyMode {
+		return sm.commander.GoToPad(x, y, z, speed, mid)
+	}
+
+	result := sm.validatePadCommand("go_pad", speed, [3]int{x, y, z})
+	if !result.Allowed {
+		return fmt.Errorf("safety check failed: %s", result.Reason)
+	}
+
+	return sm.commander.GoToPad(x, y, z, speed, mid)
+}
+
+func (sm *SafetyManager) CurveToPad(x1, y1, z1, x2, y2, z2, speed, mid int) error {
+	if !sm.safetyEnabled || sm.emergencyMode {
+		return sm.commander.CurveToPad(x1, y1, z1, x2, y2, z2, speed, mid)
+	}
+
+	result := sm.validatePadCommand("curve_pad", speed, [3]int{x1, y1, z1}, [3]int{x2, y2, z2})
+	if !result.Allowed {
+		return fmt.Errorf("safety check failed: %s", result.Reason)
+	}
+
+	return sm.commander.CurveToPad(x1, y1, z1, x2, y2, z2, speed, mid)
+}
+
+func (sm *SafetyManager) JumpBetweenPads(x, y, z, speed, yaw, mid1, mid2 int) error {
+	if !sm.safetyEnabled || sm.emergencyMode {
+		return sm.commander.JumpBetweenPads(x, y, z, speed, yaw, mid1, mid2)
+	}
+
+	result := sm.validatePadCommand("jump", speed, [3]int{x, y, z})
+	if !result.Allowed {
+		return fmt.Errorf("safety check failed: %s", result.Reason)
+	}
+
+	return sm.commander.JumpBetweenPads(x, y, z, speed, yaw, mid1, mid2)
+}
+
 func (sm *SafetyManager) SetSpeed(speed int) error {
 	if !sm.safetyEnabled || sm.emergencyMode {
 		return sm.commander.SetSpeed(speed)
@@ -584,7 +744,7 @@ func (sm *SafetyManager) GetTof() (int, error) {
 }
 
 // Video commands
-func (sm *SafetyManager) SetVideoFrameCallback(callback func(transport.VideoFrame)) {
+func (sm *SafetyManager) SetVideoFrameCallback(callback tello.VideoFrameCallback) {
 	sm.commander.SetVideoFrameCallback(callback)
 }
 
@@ -594,6 +754,14 @@ func (sm *SafetyManager) GetVideoFrameChannel() <-chan transport.VideoFrame {
 
 // Private validation methods
 
+// movementCommands fly the drone somewhere else. They are refused at a
+// critical battery level, which leaves landing, hovering, turning and the RC
+// sticks to bring the drone down.
+var movementCommands = map[string]bool{
+	"up": true, "down": true, "left": true, "right": true, "forward": true, "backward": true,
+	"flip": true, "go": true, "curve": true, "go_pad": true, "curve_pad": true, "jump": true,
+}
+
 func (sm *SafetyManager) validateCommand(command string, params map[string]any) CommandValidationResult {
 	// Check command rate limiting
 	if !sm.checkCommandRate() {
@@ -611,9 +779,46 @@ func (sm *SafetyManager) validateCommand(command string, params map[string]any)
 		}
 	}
 
+	// A move can carry the drone far from where it should land
+	if state := sm.status.CurrentState; state != nil && movementCommands[command] &&
+		state.Bat <= sm.config.Battery.CriticalThreshold {
+		return CommandValidationResult{
+			Allowed: false,
+			Reason:  fmt.Sprintf("Battery %d%% at or below critical level %d%%", state.Bat, sm.config.Battery.CriticalThreshold),
+		}
+	}
+
 	return CommandValidationResult{Allowed: true}
 }
 
+// validateTargetAltitude checks a move that climbs z cm from the current
+// height. Without telemetry the drone is taken to be on the ground, so only
+// the maximum height can be checked.
+func (sm *SafetyManager) validateTargetAltitude(z int) CommandValidationResult {
+	state := sm.status.CurrentState
+	height := z
+	if state != nil {
+		height += state.H
+	}
+
+	if height > sm.config.Altitude.MaxHeight {
+		return CommandValidationResult{
+			Allowed: false,
+			Reason:  fmt.Sprintf("Target altitude %dcm exceeds maximum %dcm", height, sm.config.Altitude.MaxHeight),
+		}
+	}
+
+	if state != nil && height < sm.config.Altitude.MinHeight {
+		return CommandValidationResult{
+			Allowed: false,
+			Reason:  fmt.Sprintf("Altitude %dcm below minimum %dcm", height, sm.config.Altitude.MinHeight),
+		}
+	}
+
+	return CommandValidationResult{Allowed: true}
+}
+
+// validateMovementCommand checks a move by x forward, y right and z up
 func (sm *SafetyManager) validateMovementCommand(command string, x, y, z int) CommandValidationResult {
 	baseResult := sm.validateCommand(command, map[string]any{
 		"x": x, "y": y, "z": z,
@@ -640,7 +845,9 @@ func (sm *SafetyManager) validateMovementCommand(command string, x, y, z int) Co
 		}
 	}
 
-	return CommandValidationResult{Allowed: true}
+	return sm.validateGeofence(func(pose navigation.Pose) []geoPoint {
+		return linePath(pose, float64(x), float64(y), float64(z))
+	})
 }
 
 func (sm *SafetyManager) validateRotationCommand(command string, angle int) CommandValidationResult {
@@ -695,14 +902,14 @@ func (sm *SafetyManager) validateGoCommand(x, y, z, speed int) CommandValidation
 	}
 
 	// Check altitude limits
-	if z > sm.config.Altitude.MaxHeight {
-		return CommandValidationResult{
-			Allowed: false,
-			Reason:  fmt.Sprintf("Target altitude %dcm exceeds maximum %dcm", z, sm.config.Altitude.MaxHeight),
-		}
+	if result := sm.validateTargetAltitude(z); !result.Allowed {
+		return result
 	}
 
-	return CommandValidationResult{Allowed: true}
+	// The go offset's y points left
+	return sm.validateGeofence(func(pose navigation.Pose) []geoPoint {
+		return linePath(pose, float64(x), -float64(y), float64(z))
+	})
 }
 
 func (sm *SafetyManager) validateCurveCommand(x1, y1, z1, x2, y2, z2, speed int) CommandValidationResult {
@@ -723,6 +930,64 @@ func (sm *SafetyManager) validateCurveCommand(x1, y1, z1, x2, y2, z2, speed int)
 		}
 	}
 
+	// Check altitude limits at both points of the arc
+	for _, z := range []int{z1, z2} {
+		if result := sm.validateTargetAltitude(z); !result.Allowed {
+			return result
+		}
+	}
+
+	// The curve offsets' y points left
+	return sm.validateGeofence(func(pose navigation.Pose) []geoPoint {
+		mid := [3]float64{float64(x1), -float64(y1), float64(z1)}
+		end := [3]float64{float64(x2), -float64(y2), float64(z2)}
+		return arcPath(pose, mid, end)
+	})
+}
+
+// validatePadCommand checks a command whose targets are absolute positions in a
+// mission pad's frame. Pads lie on the floor, so the target z is the height the
+// drone will fly at and is checked directly against the altitude limits.
+func (sm *SafetyManager) validatePadCommand(command string, speed int, targets ...[3]int) CommandValidationResult {
+	baseResult := sm.validateCommand(command, map[string]any{
+		"targets": targets, "speed": speed,
+	})
+	if !baseResult.Allowed {
+		return baseResult
+	}
+
+	if !sm.missionPadsEnabled {
+		return CommandValidationResult{
+			Allowed: false,
+			Reason:  "Mission pad detection is not enabled",
+		}
+	}
+
+	// Check speed limits
+	if speed > sm.config.Velocity.MaxHorizontal {
+		return CommandValidationResult{
+			Allowed: false,
+			Reason:  fmt.Sprintf("Speed %d exceeds maximum %d", speed, sm.config.Velocity.MaxHorizontal),
+		}
+	}
+
+	// Check altitude limits for every target
+	for _, target := range targets {
+		z := target[2]
+		if z > sm.config.Altitude.MaxHeight {
+			return CommandValidationResult{
+				Allowed: false,
+				Reason:  fmt.Sprintf("Target altitude %dcm exceeds maximum %dcm", z, sm.config.Altitude.MaxHeight),
+			}
+		}
+		if z < sm.config.Altitude.MinHeight {
+			return CommandValidationResult{
+				Allowed: false,
+				Reason:  fmt.Sprintf("Target altitude %dcm below minimum %dcm", z, sm.config.Altitude.MinHeight),
+			}
+		}
+	}
+
 	return CommandValidationResult{Allowed: true}
 }
 
@@ -810,6 +1075,12 @@ func (sm *SafetyManager) checkAltitudeSafety(state *types.State) {
 func (sm *SafetyManager) checkBatterySafety(state *types.State) {
 	battery := state.Bat
 
+	// Auto-land once per episode: again only after the drone is down or the
+	// reading recovers
+	if battery > sm.config.Battery.EmergencyThreshold || state.H <= landedHeight {
+		sm.autoLanding = false
+	}
+
 	// Check emergency threshold
 	if battery <= sm.config.Battery.EmergencyThreshold {
 		event := NewSafetyEvent(SafetyEventBattery, SafetyEventLevelEmergency,
@@ -820,9 +1091,10 @@ func (sm *SafetyManager) checkBatterySafety(state *types.State) {
 		sm.addEvent(event)
 
 		// Trigger emergency landing
-		if sm.config.Battery.EnableAutoLand {
+		if sm.config.Battery.EnableAutoLand && state.H > landedHeight && !sm.autoLanding {
+			sm.autoLanding = true
 			utils.Logger.Error("Emergency battery level - triggering auto-land")
-			go sm.commander.Land()
+			go sm.LandNow()
 		}
 		return
 	}
@@ -960,8 +1232,8 @@ func (sm *SafetyManager) addEvent(event *SafetyEvent) {
 		utils.Logger.Infof("Safety Info: %s", event.Message)
 	}
 
-	// Call event callback if set (bounded, non-blocking)
-	if sm.eventCallback != nil {
+	// Call event callback and listeners if set (bounded, non-blocking)
+	if sm.eventCallback != nil || len(sm.eventListeners) > 0 {
 		workerRunning := atomic.LoadInt32(&sm.callbackStarted) == 1
 
 		if workerRunning {
@@ -19,6 +19,7 @@ import (
 	"testing"
 	"time"
 
+	"github.com/conceptcodes/dji-tello-sdk-go/pkg/tello"
 	"github.com/conceptcodes/dji-tello-sdk-go/pkg/transport"
 	"github.com/conceptcodes/dji-tello-sdk-go/pkg/types"
 	"go.uber.org/goleak"
@@ -49,6 +50,12 @@ type MockCommander struct {
 	goCalled               bool
 	curveCalled            bool
 
+	// Mission pad command tracking
+	missionPadsEnabled bool
+	goToPadCalled      bool
+	curveToPadCalled   bool
+	jumpCalled         bool
+
 	// Set command tracking
 	setSpeedCalled           bool
 	setRcControlCalled       bool
@@ -173,7 +180,7 @@ func (m *MockCommander) CounterClockwise(angle int) error {
 	return nil
 }
 
-func (m *MockCommander) Flip(direction string) error {
+func (m *MockCommander) Flip(direction tello.FlipDirection) error {
 	m.flipCalled = true
 	return nil
 }
@@ -188,6 +195,31 @@ func (m *MockCommander) Curve(x1, y1, z1, x2, y2, z2, speed int) error {
 	return nil
 }
 
+// Mission Pad Commands
+func (m *MockCommander) EnableMissionPads(enabled bool) error {
+	m.missionPadsEnabled = enabled
+	return nil
+}
+
+func (m *MockCommander) SetMissionPadDirection(direction tello.MissionPadDirection) error {
+	return nil
+}
+
+func (m *MockCommander) GoToPad(x, y, z, speed, mid int) error {
+	m.goToPadCalled = true
+	return nil
+}
+
+func (m *MockCommander) CurveToPad(x1, y1, z1, x2, y2, z2, speed, mid int) error {
+	m.curveToPadCalled = true
+	return nil
+}
+
+func (m *MockCommander) JumpBetweenPads(x, y, z, speed, yaw, mid1, mid2 int) error {
+	m.jumpCalled = true
+	return nil
+}
+
 // Set Commands
 func (m *MockCommander) SetSpeed(speed int) error {
 	m.setSpeedCalled = true
@@ -242,7 +274,7 @@ func (m *MockCommander) GetTof() (int, error) {
 }
 
 // Video Commands
-func (m *MockCommander) SetVideoFrameCallback(callback func(transport.VideoFrame)) {
+func (m *MockCommander) SetVideoFrameCallback(callback tello.VideoFrameCallback) {
 	m.setVideoFrameCallbackCalled = true
 	m.videoCallback = callback
 }
@@ -757,6 +789,41 @@ func TestValidateCommand(t *testing.T) {
 			t.Errorf("Expected command to be allowed, got: %s", result.Reason)
 		}
 	})
+
+	t.Run("refuses movement at critical battery", func(t *testing.T) {
+		config := DefaultConfig()
+		tests := []struct {
+			command       string
+			expectAllowed bool
+		}{
+			{"forward", false},
+			{"up", false},
+			{"flip", false},
+			{"go", false},
+			{"curve", false},
+			{"go_pad", false},
+			{"jump", false},
+			{"clockwise", true},
+			{"takeoff", true},
+			{"rc", true},
+		}
+		for _, tt := range tests {
+			manager := NewSafetyManager(NewMockCommander(), config)
+			manager.UpdateState(&types.State{H: 100, Bat: config.Battery.CriticalThreshold})
+
+			result := manager.validateCommand(tt.command, nil)
+			if result.Allowed != tt.expectAllowed {
+				t.Errorf("%s: expected allowed %v, got %v (%s)", tt.command, tt.expectAllowed, result.Allowed, result.Reason)
+			}
+		}
+
+		// Above the critical level every command is allowed
+		manager := NewSafetyManager(NewMockCommander(), config)
+		manager.UpdateState(&types.State{H: 100, Bat: config.Battery.CriticalThreshold + 1})
+		if result := manager.validateCommand("forward", nil); !result.Allowed {
+			t.Errorf("Expected forward to be allowed, got: %s", result.Reason)
+		}
+	})
 }
 
 // TestValidateMovementCommand tests validateMovementCommand with boundary values.
@@ -1043,7 +1110,8 @@ func TestValidateFlipCommand(t *testing.T) {
 
 			// Set current height
 			state := &types.State{
-				H: tt.currentHeight,
+				H:   tt.currentHeight,
+				Bat: 85,
 			}
 			manager.UpdateState(state)
 
@@ -1070,6 +1138,7 @@ func TestValidateGoCommand(t *testing.T) {
 	tests := []struct {
 		name           string
 		x, y, z, speed int
+		currentHeight  int
 		expectAllowed  bool
 		expectedReason string
 	}{
@@ -1126,6 +1195,25 @@ func TestValidateGoCommand(t *testing.T) {
 			expectAllowed:  false,
 			expectedReason: "Target altitude 400cm exceeds maximum 300cm",
 		},
+		{
+			name:           "Climb above maximum from current height",
+			x:              0,
+			y:              0,
+			z:              100,
+			speed:          50,
+			currentHeight:  250,
+			expectAllowed:  false,
+			expectedReason: "Target altitude 350cm exceeds maximum 300cm",
+		},
+		{
+			name:          "Climb to maximum from current height",
+			x:             0,
+			y:             0,
+			z:             50,
+			speed:         50,
+			currentHeight: 250,
+			expectAllowed: true,
+		},
 		// Boundary values
 		{
 			name:          "At speed limit",
@@ -1142,6 +1230,9 @@ func TestValidateGoCommand(t *testing.T) {
 			mockCommander := NewMockCommander()
 			config := DefaultConfig()
 			manager := NewSafetyManager(mockCommander, config)
+			if tt.currentHeight > 0 {
+				manager.UpdateState(&types.State{H: tt.currentHeight, Bat: 85})
+			}
 
 			result := manager.validateGoCommand(tt.x, tt.y, tt.z, tt.speed)
 
@@ -1161,6 +1252,108 @@ func TestValidateGoCommand(t *testing.T) {
 	}
 }
 
+// TestValidatePadCommand tests range validation of mission pad commands.
+func TestValidatePadCommand(t *testing.T) {
+	tests := []struct {
+		name           string
+		padsEnabled    bool
+		speed          int
+		targets        [][3]int
+		expectAllowed  bool
+		expectedReason string
+	}{
+		{
+			name:          "Valid pad target",
+			padsEnabled:   true,
+			speed:         50,
+			targets:       [][3]int{{100, -50, 100}},
+			expectAllowed: true,
+		},
+		{
+			name:           "Pads not enabled",
+			padsEnabled:    false,
+			speed:          50,
+			targets:        [][3]int{{100, -50, 100}},
+			expectAllowed:  false,
+			expectedReason: "Mission pad detection is not enabled",
+		},
+		{
+			name:           "Speed exceeds maximum",
+			padsEnabled:    true,
+			speed:          150,
+			targets:        [][3]int{{100, 0, 100}},
+			expectAllowed:  false,
+			expectedReason: "Speed 150 exceeds maximum 100",
+		},
+		{
+			name:           "Curve end above maximum altitude",
+			padsEnabled:    true,
+			speed:          30,
+			targets:        [][3]int{{50, 50, 100}, {100, 0, 400}},
+			expectAllowed:  false,
+			expectedReason: "Target altitude 400cm exceeds maximum 300cm",
+		},
+		{
+			name:           "Target below minimum altitude",
+			padsEnabled:    true,
+			speed:          30,
+			targets:        [][3]int{{100, 0, 10}},
+			expectAllowed:  false,
+			expectedReason: "Target altitude 10cm below minimum 20cm",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			manager := NewSafetyManager(NewMockCommander(), DefaultConfig())
+			manager.missionPadsEnabled = tt.padsEnabled
+
+			result := manager.validatePadCommand("go_pad", tt.speed, tt.targets...)
+
+			if tt.expectAllowed {
+				if !result.Allowed {
+					t.Errorf("Expected pad command to be allowed, got: %s", result.Reason)
+				}
+			} else {
+				if result.Allowed {
+					t.Errorf("Expected pad command to be rejected")
+				}
+				if tt.expectedReason != "" && result.Reason != tt.expectedReason {
+					t.Errorf("Expected reason %q, got: %q", tt.expectedReason, result.Reason)
+				}
+			}
+		})
+	}
+}
+
+// TestSafetyManager_MissionPads tests that pad commands require detection to be enabled.
+func TestSafetyManager_MissionPads(t *testing.T) {
+	mockCommander := NewMockCommander()
+	manager := NewSafetyManager(mockCommander, DefaultConfig())
+
+	if err := manager.GoToPad(100, 0, 100, 50, 1); err == nil {
+		t.Error("Expected GoToPad to fail before mission pads are enabled")
+	}
+	if mockCommander.goToPadCalled {
+		t.Error("Expected GoToPad not to reach the commander")
+	}
+
+	if err := manager.EnableMissionPads(true); err != nil {
+		t.Fatalf("EnableMissionPads failed: %v", err)
+	}
+	if !mockCommander.missionPadsEnabled {
+		t.Error("Expected EnableMissionPads to be forwarded to the commander")
+	}
+
+	time.Sleep(110 * time.Millisecond) // Respect the command rate limit
+	if err := manager.GoToPad(100, 0, 100, 50, 1); err != nil {
+		t.Errorf("Expected GoToPad to succeed, got %v", err)
+	}
+	if !mockCommander.goToPadCalled {
+		t.Error("Expected GoToPad to reach the commander")
+	}
+}
+
 // TestValidateCurveCommand tests validateCurveCommand with arc validation.
 func TestValidateCurveCommand(t *testing.T) {
 	tests := []struct {
@@ -1168,6 +1361,7 @@ func TestValidateCurveCommand(t *testing.T) {
 		x1, y1, z1    int
 		x2, y2, z2    int
 		speed         int
+		currentHeight int
 		expectAllowed bool
 	}{
 		// Valid cases
@@ -1227,6 +1421,43 @@ func TestValidateCurveCommand(t *testing.T) {
 			speed:         150, // Exceeds MaxHorizontal=100
 			expectAllowed: false,
 		},
+		// Altitude from the current height
+		{
+			name:          "End point above maximum from current height",
+			x1:            50,
+			y1:            0,
+			z1:            20,
+			x2:            100,
+			y2:            0,
+			z2:            100,
+			speed:         50,
+			currentHeight: 250,
+			expectAllowed: false,
+		},
+		{
+			name:          "Mid point above maximum from current height",
+			x1:            50,
+			y1:            0,
+			z1:            100,
+			x2:            100,
+			y2:            0,
+			z2:            0,
+			speed:         50,
+			currentHeight: 250,
+			expectAllowed: false,
+		},
+		{
+			name:          "Arc within maximum from current height",
+			x1:            50,
+			y1:            0,
+			z1:            40,
+			x2:            100,
+			y2:            0,
+			z2:            50,
+			speed:         50,
+			currentHeight: 250,
+			expectAllowed: true,
+		},
 	}
 
 	for _, tt := range tests {
@@ -1234,6 +1465,9 @@ func TestValidateCurveCommand(t *testing.T) {
 			mockCommander := NewMockCommander()
 			config := DefaultConfig()
 			manager := NewSafetyManager(mockCommander, config)
+			if tt.currentHeight > 0 {
+				manager.UpdateState(&types.State{H: tt.currentHeight, Bat: 85})
+			}
 
 			result := manager.validateCurveCommand(tt.x1, tt.y1, tt.z1, tt.x2, tt.y2, tt.z2, tt.speed)
 
@@ -1772,7 +2006,8 @@ func TestValidateFlipCommandErrorInjection(t *testing.T) {
 
 			// Set a height for flip validation
 			state := &types.State{
-				H: 200,
+				H:   200,
+				Bat: 85,
 			}
 			manager.UpdateState(state)
 
@@ -2266,7 +2501,7 @@ func TestSafetyManager_RotationCommands(t *testing.T) {
 func TestSafetyManager_Flip(t *testing.T) {
 	tests := []struct {
 		name          string
-		direction     string
+		direction     tello.FlipDirection
 		currentHeight int
 		enableFlips   bool
 		minFlipHeight int
@@ -2463,6 +2698,30 @@ func TestSafetyManager_Go(t *testing.T) {
 		}
 	})
 
+	t.Run("Go blocked by the drone's state", func(t *testing.T) {
+		config := DefaultConfig()
+		tests := []struct {
+			name  string
+			state types.State
+			z     int
+		}{
+			{"below minimum height", types.State{H: config.Altitude.MinHeight + 10, Bat: 80}, -20},
+			{"critical battery", types.State{H: 100, Bat: config.Battery.CriticalThreshold}, 0},
+		}
+		for _, tt := range tests {
+			mockCommander := NewMockCommander()
+			manager := NewSafetyManager(mockCommander, config)
+			manager.status.CurrentState = &tt.state
+
+			if err := manager.Go(100, 0, tt.z, 50); err == nil {
+				t.Errorf("%s: expected Go to be blocked", tt.name)
+			}
+			if mockCommander.goCalled {
+				t.Errorf("%s: expected commander.Go NOT to be called", tt.name)
+			}
+		}
+	})
+
 	t.Run("Go with negative coordinates allowed", func(t *testing.T) {
 		mockCommander := NewMockCommander()
 		config := DefaultConfig()
@@ -3169,6 +3428,35 @@ func TestSafetyManager_SetEventCallback(t *testing.T) {
 	})
 }
 
+// TestSafetyManager_AddEventListener tests that every listener and the
+// callback see each event.
+func TestSafetyManager_AddEventListener(t *testing.T) {
+	manager := NewSafetyManager(NewMockCommander(), DefaultConfig())
+	defer manager.StopTelemetryProcessing()
+
+	received := make(chan string, 10)
+	manager.SetEventCallback(func(event *SafetyEvent) { received <- "callback" })
+	manager.AddEventListener(func(event *SafetyEvent) { received <- "first" })
+	manager.AddEventListener(nil)
+	manager.AddEventListener(func(event *SafetyEvent) { received <- "second" })
+	manager.SetEventCallback(func(event *SafetyEvent) { received <- "replaced" })
+
+	manager.addEvent(NewSafetyEvent(SafetyEventBattery, SafetyEventLevelWarning, "Test battery warning", nil))
+
+	got := map[string]bool{}
+	for range 3 {
+		select {
+		case name := <-received:
+			got[name] = true
+		case <-time.After(500 * time.Millisecond):
+			t.Fatalf("Expected the callback and both listeners to be called, got %v", got)
+		}
+	}
+	if !got["replaced"] || !got["first"] || !got["second"] {
+		t.Errorf("Expected the latest callback and both listeners, got %v", got)
+	}
+}
+
 // TestSafetyManager_CommandBlocking generates safety events correctly.
 func TestSafetyManager_CommandBlocking(t *testing.T) {
 	t.Run("Blocked command returns error", func(t *testing.T) {
@@ -4076,3 +4364,67 @@ func TestSafetyManagerEmergencyModeNoLeak(t *testing.T) {
 	// Give goroutines time to clean up
 	time.Sleep(100 * time.Millisecond)
 }
+
+func TestEmergencyPreempts(t *testing.T) {
+	commander := newActionCommander()
+	manager := NewSafetyManager(&preemptiveActionCommander{commander}, DefaultConfig())
+
+	if err := manager.Emergency(); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if action := <-commander.actions; action != "emergency now" {
+		t.Errorf("Expected emergency to go ahead of queued commands, got %q", action)
+	}
+
+	// Commanders that cannot preempt still stop the motors
+	plain := NewMockCommander()
+	if err := NewSafetyManager(plain, DefaultConfig()).Emergency(); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if !plain.emergencyCalled {
+		t.Error("Expected Emergency to be called")
+	}
+}
+
+func TestBatteryAutoLandOncePerEpisode(t *testing.T) {
+	commander := newActionCommander()
+	config := DefaultConfig()
+	config.Battery.EnableAutoLand = true
+	manager := NewSafetyManager(&preemptiveActionCommander{commander}, config)
+
+	expectLands := func(want int, why string) {
+		t.Helper()
+		got := 0
+		for {
+			select {
+			case action := <-commander.actions:
+				if action == "land now" {
+					got++
+				}
+				continue
+			case <-time.After(50 * time.Millisecond):
+			}
+			break
+		}
+		if got != want {
+			t.Errorf("%s: expected %d auto-land(s), got %d", why, want, got)
+		}
+	}
+
+	low := config.Battery.EmergencyThreshold
+	for range 10 {
+		manager.UpdateState(&types.State{H: 100, Bat: low, Tof: 100})
+	}
+	expectLands(1, "States below the threshold")
+
+	manager.UpdateState(&types.State{H: 0, Bat: low, Tof: 10})
+	expectLands(0, "On the ground")
+
+	manager.UpdateState(&types.State{H: 100, Bat: low, Tof: 100})
+	manager.UpdateState(&types.State{H: 100, Bat: low, Tof: 100})
+	expectLands(1, "Airborne again after landing")
+
+	manager.UpdateState(&types.State{H: 100, Bat: low + 1, Tof: 100})
+	manager.UpdateState(&types.State{H: 100, Bat: low, Tof: 100})
+	expectLands(1, "The reading recovered and dropped again")
+}
@@ -22,7 +22,7 @@ const (
 type SafetyEvent struct {
 	Timestamp time.Time              `json:"timestamp"`
 	Level     string                 `json:"level"` // "info", "warning", "critical", "emergency"
-	Type      string                 `json:"type"`  // "altitude", "battery", "sensor", "behavioral"
+	Type      string                 `json:"type"`  // "altitude", "battery", "sensor", "behavioral", "geofence"
 	Message   string                 `json:"message"`
 	Data      map[string]interface{} `json:"data,omitempty"`
 }
@@ -35,6 +35,7 @@ type SafetyStatus struct {
 	ConfigLevel   SafetyLevel   `json:"config_level"`
 	SafetyEnabled bool          `json:"safety_enabled"`
 	EmergencyMode bool          `json:"emergency_mode"`
+	LinkLost      bool          `json:"link_lost"`
 	CurrentState  *types.State  `json:"current_state,omitempty"`
 }
 
@@ -86,6 +87,45 @@ type BehavioralLimits struct {
 	MaxCommandRate int  `json:"max_command_rate"` // commands/second - rate limiting
 }
 
+// GeofenceShape is the horizontal boundary of a geofence
+type GeofenceShape string
+
+const (
+	GeofenceShapeCylinder GeofenceShape = "cylinder"
+	GeofenceShapePolygon  GeofenceShape = "polygon"
+)
+
+// Point2D is a horizontal position in the takeoff frame
+type Point2D struct {
+	X int `json:"x"` // cm - forward of the takeoff point
+	Y int `json:"y"` // cm - right of the takeoff point
+}
+
+// Point3D is a position in the takeoff frame
+type Point3D struct {
+	X int `json:"x"` // cm - forward of the takeoff point
+	Y int `json:"y"` // cm - right of the takeoff point
+	Z int `json:"z"` // cm - above the takeoff point
+}
+
+// KeepOutBox is a no-fly volume aligned with the takeoff frame
+type KeepOutBox struct {
+	Name string  `json:"name,omitempty"`
+	Min  Point3D `json:"min"` // corner with the smallest coordinates
+	Max  Point3D `json:"max"` // corner with the largest coordinates
+}
+
+// GeofenceLimits defines where the drone may fly. Positions use the takeoff
+// frame: X forward and Y right along the heading at takeoff, Z up.
+type GeofenceLimits struct {
+	Enabled      bool          `json:"enabled"`            // check commands and telemetry against the geofence
+	Shape        GeofenceShape `json:"shape"`              // "cylinder" or "polygon"
+	Radius       int           `json:"radius,omitempty"`   // cm - cylinder radius around the takeoff point
+	Polygon      []Point2D     `json:"polygon,omitempty"`  // polygon vertices in order
+	KeepOut      []KeepOutBox  `json:"keep_out,omitempty"` // volumes the drone must not enter
+	BreachAction string        `json:"breach_action"`      // "hover", "return", "land"
+}
+
 // Config is the main safety configuration structure
 type Config struct {
 	Version    string              `json:"version"`
@@ -96,6 +136,7 @@ type Config struct {
 	Sensors    SensorSafety        `json:"sensors"`
 	Emergency  EmergencyProcedures `json:"emergency"`
 	Behavioral BehavioralLimits    `json:"behavioral"`
+	Geofence   GeofenceLimits      `json:"geofence"`
 }
 
 // CommandValidationResult represents the result of command validation
@@ -112,6 +153,7 @@ const (
 	SafetyActionLand      SafetyAction = "land"
 	SafetyActionHover     SafetyAction = "hover"
 	SafetyActionEmergency SafetyAction = "emergency"
+	SafetyActionReturn    SafetyAction = "return"
 	SafetyActionNone      SafetyAction = "none"
 )
 
@@ -125,6 +167,7 @@ const (
 	SafetyEventBehavioral SafetyEventType = "behavioral"
 	SafetyEventConnection SafetyEventType = "connection"
 	SafetyEventEmergency  SafetyEventType = "emergency"
+	SafetyEventGeofence   SafetyEventType = "geofence"
 )
 
 // SafetyEventLevel represents severity levels of safety events
@@ -178,6 +221,7 @@ func IsValidSafetyAction(action string) bool {
 		SafetyActionLand,
 		SafetyActionHover,
 		SafetyActionEmergency,
+		SafetyActionReturn,
 		SafetyActionNone,
 	}
 
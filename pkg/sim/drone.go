@@ -0,0 +1,578 @@
+package sim
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+
+	"github.com/conceptcodes/dji-tello-sdk-go/pkg/types"
+)
+
+const (
+	takeoffHeight   = 80.0  // Height reached after takeoff (cm)
+	verticalSpeed   = 50.0  // Climb/descent rate for takeoff and land (cm/s)
+	yawRate         = 90.0  // Rotation rate (degrees/s)
+	flipDuration    = 1.0   // Time spent executing a flip (s)
+	rcMaxSpeed      = 100.0 // Velocity at full RC stick deflection (cm/s)
+	groundTof       = 10    // ToF reading reported when the sensor is out of range
+	baseBarometer   = 100.0 // Barometer reading on the ground (m)
+	minTakeoffBat   = 10    // Battery percentage required for takeoff
+	minFlipBat      = 50    // Battery percentage required for flips
+	flyingDrainRate = 0.12  // Battery drain while flying (%/s), roughly 13 minutes of flight
+	idleDrainRate   = 0.01  // Battery drain while grounded (%/s)
+	padRange        = 100.0 // Horizontal distance within which a mission pad is detected (cm)
+	padMaxHeight    = 300.0 // Height above which mission pads are no longer detected (cm)
+)
+
+// MissionPad places a simulated mission pad on the floor. X and Y are in the
+// simulator's world frame; the pad's axes are aligned with the power-on heading.
+type MissionPad struct {
+	ID int     `json:"id" yaml:"id"`
+	X  float64 `json:"x" yaml:"x"`
+	Y  float64 `json:"y" yaml:"y"`
+}
+
+// vec3 is a position in the simulator's world frame: x forward and y right
+// relative to the heading at power-on, z up, all in centimetres.
+type vec3 struct {
+	x, y, z float64
+}
+
+// motion describes a command in progress. Waypoints are visited in order,
+// then the yaw target is reached, then the hold timer runs down.
+type motion struct {
+	waypoints []vec3
+	yaw       float64
+	speed     float64
+	hold      float64
+	landing   bool
+}
+
+// drone is the flight model behind the simulator. It is not safe for
+// concurrent use; the Simulator serialises access with its own mutex.
+type drone struct {
+	sdkMode   bool
+	flying    bool
+	streaming bool
+
+	pos    vec3
+	vel    vec3 // world frame velocity (cm/s)
+	yaw    float64
+	speed  float64
+	rc     [4]int
+	active *motion
+
+	battery    float64
+	flightTime float64
+	serial     string
+
+	padsEnabled  bool
+	padDirection int
+	pads         []MissionPad
+}
+
+func newDrone(battery int, serial string, pads []MissionPad) *drone {
+	return &drone{
+		speed:   100,
+		battery: float64(battery),
+		serial:  serial,
+		pads:    pads,
+	}
+}
+
+// handle executes a single SDK command. It returns the reply to send (empty
+// for commands that have no reply, such as rc) and whether the reply must be
+// deferred until the started motion completes.
+func (d *drone) handle(command string) (reply string, deferred bool) {
+	fields := strings.Fields(command)
+	if len(fields) == 0 {
+		return "error", false
+	}
+	name, args := fields[0], fields[1:]
+
+	if name == "command" {
+		d.sdkMode = true
+		return "ok", false
+	}
+	if !d.sdkMode {
+		return "error", false
+	}
+
+	if strings.HasSuffix(name, "?") {
+		return d.read(name), false
+	}
+
+	switch name {
+	case "emergency":
+		d.active = nil
+		d.flying = false
+		d.rc = [4]int{}
+		d.pos.z = 0
+		return "ok", false
+	case "stop":
+		if !d.flying {
+			return "error", false
+		}
+		d.active = nil
+		d.rc = [4]int{}
+		return "ok", false
+	case "land":
+		if !d.flying {
+			return "error", false
+		}
+		d.rc = [4]int{}
+		d.active = &motion{
+			waypoints: []vec3{{d.pos.x, d.pos.y, 0}},
+			yaw:       d.yaw,
+			speed:     verticalSpeed,
+			landing:   true,
+		}
+		return "", true
+	case "rc":
+		d.setRC(args)
+		return "", false
+	case "streamon":
+		d.streaming = true
+		return "ok", false
+	case "streamoff":
+		d.streaming = false
+		return "ok", false
+	case "speed":
+		v, ok := intArgs(args, 1, 10, 100)
+		if !ok {
+			return "error", false
+		}
+		d.speed = float64(v[0])
+		return "ok", false
+	case "wifi":
+		if len(args) != 2 {
+			return "error", false
+		}
+		return "ok", false
+	case "mon", "moff":
+		d.padsEnabled = name == "mon"
+		return "ok", false
+	case "mdirection":
+		v, ok := intArgs(args, 1, 0, 2)
+		if !ok {
+			return "error", false
+		}
+		d.padDirection = v[0]
+		return "ok", false
+	}
+
+	if d.active != nil {
+		return "error", false
+	}
+
+	switch name {
+	case "takeoff":
+		if d.flying || int(d.battery) < minTakeoffBat {
+			return "error", false
+		}
+		d.flying = true
+		d.active = &motion{
+			waypoints: []vec3{{d.pos.x, d.pos.y, takeoffHeight}},
+			yaw:       d.yaw,
+			speed:     verticalSpeed,
+		}
+		return "", true
+	}
+
+	if !d.flying {
+		return "error", false
+	}
+
+	switch name {
+	case "up", "down", "left", "right", "forward", "back":
+		v, ok := intArgs(args, 1, 20, 500)
+		if !ok {
+			return "error", false
+		}
+		dist := float64(v[0])
+		var fwd, right, up float64
+		switch name {
+		case "up":
+			up = dist
+		case "down":
+			up = -dist
+		case "left":
+			right = -dist
+		case "right":
+			right = dist
+		case "forward":
+			fwd = dist
+		case "back":
+			fwd = -dist
+		}
+		d.active = &motion{waypoints: []vec3{d.bodyOffset(fwd, right, up)}, yaw: d.yaw, speed: d.speed}
+	case "cw", "ccw":
+		v, ok := intArgs(args, 1, 1, 3600)
+		if !ok {
+			return "error", false
+		}
+		angle := float64(v[0])
+		if name == "ccw" {
+			angle = -angle
+		}
+		d.active = &motion{yaw: d.yaw + angle, speed: d.speed}
+	case "flip":
+		if len(args) != 1 || len(args[0]) != 1 || !strings.Contains("lrfb", args[0]) {
+			return "error", false
+		}
+		if int(d.battery) < minFlipBat {
+			return "error", false
+		}
+		d.active = &motion{yaw: d.yaw, hold: flipDuration}
+	case "go":
+		if len(args) == 5 {
+			return d.padMotion(args[:4], args[4], 100)
+		}
+		v, ok := intArgs(args, 4, -500, 500)
+		if !ok || v[3] < 10 || v[3] > 100 {
+			return "error", false
+		}
+		// The SDK body frame is x forward, y left, z up.
+		d.active = &motion{
+			waypoints: []vec3{d.bodyOffset(float64(v[0]), -float64(v[1]), float64(v[2]))},
+			yaw:       d.yaw,
+			speed:     float64(v[3]),
+		}
+	case "curve":
+		if len(args) == 8 {
+			return d.padMotion(args[:7], args[7], 60)
+		}
+		v, ok := intArgs(args, 7, -500, 500)
+		if !ok || v[6] < 10 || v[6] > 60 {
+			return "error", false
+		}
+		d.active = &motion{
+			waypoints: []vec3{
+				d.bodyOffset(float64(v[0]), -float64(v[1]), float64(v[2])),
+				d.bodyOffset(float64(v[3]), -float64(v[4]), float64(v[5])),
+			},
+			yaw:   d.yaw,
+			speed: float64(v[6]),
+		}
+	case "jump":
+		if len(args) != 7 {
+			return "error", false
+		}
+		v, ok := intArgs(args[:5], 5, -500, 500)
+		_, seen := d.detectedPad(args[5])
+		to, exists := d.findPad(args[6])
+		if !ok || v[3] < 10 || v[3] > 100 || !seen || !exists {
+			return "error", false
+		}
+		d.active = &motion{
+			waypoints: []vec3{padOffset(to, v[0], v[1], v[2])},
+			yaw:       float64(v[4]),
+			speed:     float64(v[3]),
+		}
+	default:
+		return "error", false
+	}
+
+	return "", true
+}
+
+// padMotion starts a go or curve command whose coordinates are relative to a
+// mission pad. coords holds x y z [x y z] speed.
+func (d *drone) padMotion(coords []string, mid string, maxSpeed int) (string, bool) {
+	v, ok := intArgs(coords, len(coords), -500, 500)
+	if !ok {
+		return "error", false
+	}
+	speed := v[len(v)-1]
+	pad, seen := d.detectedPad(mid)
+	if speed < 10 || speed > maxSpeed || !seen {
+		return "error", false
+	}
+
+	m := &motion{yaw: d.yaw, speed: float64(speed)}
+	for i := 0; i+2 < len(v)-1; i += 3 {
+		m.waypoints = append(m.waypoints, padOffset(pad, v[i], v[i+1], v[i+2]))
+	}
+	d.active = m
+	return "", true
+}
+
+// padOffset converts a position in a pad's frame (x forward, y left, z up)
+// into the world frame.
+func padOffset(pad MissionPad, x, y, z int) vec3 {
+	return vec3{x: pad.X + float64(x), y: pad.Y - float64(y), z: math.Max(0, float64(z))}
+}
+
+// findPad looks up a pad by its SDK identifier, e.g. "m3".
+func (d *drone) findPad(mid string) (MissionPad, bool) {
+	id, err := strconv.Atoi(strings.TrimPrefix(mid, "m"))
+	if err != nil || !strings.HasPrefix(mid, "m") {
+		return MissionPad{}, false
+	}
+	for _, pad := range d.pads {
+		if pad.ID == id {
+			return pad, true
+		}
+	}
+	return MissionPad{}, false
+}
+
+// detectedPad returns the pad identified by mid if it is currently in view.
+// The wildcards m-1 (random) and m-2 (nearest) match whichever pad is visible.
+func (d *drone) detectedPad(mid string) (MissionPad, bool) {
+	visible, ok := d.visiblePad()
+	if !ok {
+		return MissionPad{}, false
+	}
+	if mid == "m-1" || mid == "m-2" {
+		return visible, true
+	}
+	pad, exists := d.findPad(mid)
+	return pad, exists && pad.ID == visible.ID
+}
+
+// visiblePad returns the nearest pad within detection range, if any.
+func (d *drone) visiblePad() (MissionPad, bool) {
+	if !d.padsEnabled || !d.flying || d.pos.z > padMaxHeight {
+		return MissionPad{}, false
+	}
+	best, bestDist := MissionPad{}, math.Inf(1)
+	for _, pad := range d.pads {
+		dist := math.Hypot(d.pos.x-pad.X, d.pos.y-pad.Y)
+		if dist <= padRange && dist < bestDist {
+			best, bestDist = pad, dist
+		}
+	}
+	return best, !math.IsInf(bestDist, 1)
+}
+
+// read answers a query command such as "battery?".
+func (d *drone) read(name string) string {
+	s := d.state()
+	switch name {
+	case "speed?":
+		return strconv.Itoa(int(d.speed))
+	case "battery?":
+		return strconv.Itoa(s.Bat)
+	case "time?":
+		return strconv.Itoa(s.Time)
+	case "height?":
+		return strconv.Itoa(s.H)
+	case "temp?":
+		return strconv.Itoa(s.Temph)
+	case "attitude?":
+		return fmt.Sprintf("%d %d %d", s.Pitch, s.Roll, s.Yaw)
+	case "baro?":
+		return strconv.FormatFloat(s.Baro, 'f', 2, 64)
+	case "acceleration?":
+		return fmt.Sprintf("%d %d %d", int(s.Agx), int(s.Agy), int(s.Agz))
+	case "tof?":
+		return strconv.Itoa(s.Tof)
+	case "wifi?":
+		return "90"
+	case "sdk?":
+		return "30"
+	case "sn?":
+		return d.serial
+	default:
+		return "error"
+	}
+}
+
+func (d *drone) setRC(args []string) {
+	v, ok := intArgs(args, 4, -100, 100)
+	if !ok || !d.flying || d.active != nil {
+		return
+	}
+	d.rc = [4]int{v[0], v[1], v[2], v[3]}
+}
+
+// bodyOffset converts a displacement in the drone's body frame (forward,
+// right, up) into an absolute world-frame position.
+func (d *drone) bodyOffset(fwd, right, up float64) vec3 {
+	rad := d.yaw * math.Pi / 180
+	sin, cos := math.Sin(rad), math.Cos(rad)
+	return vec3{
+		x: d.pos.x + fwd*cos - right*sin,
+		y: d.pos.y + fwd*sin + right*cos,
+		z: math.Max(0, d.pos.z+up),
+	}
+}
+
+// step advances the model by dt simulated seconds. It returns true when the
+// active motion finished during this step, and false with aborted set when
+// the motion was cut short (for example by an empty battery).
+func (d *drone) step(dt float64) (finished, aborted bool) {
+	if dt <= 0 {
+		return false, false
+	}
+
+	if d.flying {
+		d.flightTime += dt
+		d.battery -= flyingDrainRate * dt
+	} else {
+		d.battery -= idleDrainRate * dt
+	}
+	if d.battery < 0 {
+		d.battery = 0
+	}
+
+	start := d.pos
+	switch {
+	case d.active != nil:
+		finished = d.advance(dt)
+	case d.flying && d.rc != [4]int{}:
+		rad := d.yaw * math.Pi / 180
+		fwd := float64(d.rc[1]) / 100 * rcMaxSpeed * dt
+		right := float64(d.rc[0]) / 100 * rcMaxSpeed * dt
+		d.pos.x += fwd*math.Cos(rad) - right*math.Sin(rad)
+		d.pos.y += fwd*math.Sin(rad) + right*math.Cos(rad)
+		d.pos.z = math.Max(0, d.pos.z+float64(d.rc[2])/100*rcMaxSpeed*dt)
+		d.yaw += float64(d.rc[3]) / 100 * yawRate * dt
+	}
+	d.vel = vec3{(d.pos.x - start.x) / dt, (d.pos.y - start.y) / dt, (d.pos.z - start.z) / dt}
+
+	if d.flying && d.battery <= 0 {
+		// Out of battery: the drone drops out of the sky
+		aborted = d.active != nil
+		d.active = nil
+		d.flying = false
+		d.rc = [4]int{}
+		d.pos.z = 0
+		return false, aborted
+	}
+
+	return finished, false
+}
+
+// advance moves the active motion forward and reports whether it completed.
+func (d *drone) advance(dt float64) bool {
+	m := d.active
+	budget := m.speed * dt
+	for len(m.waypoints) > 0 && budget > 0 {
+		target := m.waypoints[0]
+		dx, dy, dz := target.x-d.pos.x, target.y-d.pos.y, target.z-d.pos.z
+		dist := math.Sqrt(dx*dx + dy*dy + dz*dz)
+		if dist <= budget {
+			d.pos = target
+			budget -= dist
+			m.waypoints = m.waypoints[1:]
+			continue
+		}
+		f := budget / dist
+		d.pos.x += dx * f
+		d.pos.y += dy * f
+		d.pos.z += dz * f
+		return false
+	}
+	if len(m.waypoints) > 0 {
+		return false
+	}
+
+	if diff := m.yaw - d.yaw; diff != 0 {
+		turn := yawRate * dt
+		if math.Abs(diff) <= turn {
+			d.yaw = m.yaw
+		} else {
+			d.yaw += math.Copysign(turn, diff)
+			return false
+		}
+	}
+
+	if m.hold > 0 {
+		m.hold -= dt
+		if m.hold > 0 {
+			return false
+		}
+	}
+
+	if m.landing {
+		d.flying = false
+	}
+	d.active = nil
+	return true
+}
+
+// state returns the telemetry the drone would report right now.
+func (d *drone) state() *types.State {
+	rad := d.yaw * math.Pi / 180
+	// Rotate world velocity back into the body frame
+	vFwd := d.vel.x*math.Cos(rad) + d.vel.y*math.Sin(rad)
+	vRight := -d.vel.x*math.Sin(rad) + d.vel.y*math.Cos(rad)
+
+	tof := groundTof
+	if d.flying && d.pos.z > groundTof {
+		tof = int(math.Round(d.pos.z))
+	}
+
+	temp := 60 + int(math.Min(d.flightTime/60, 25))
+
+	mid, padX, padY, padZ := -1, 0, 0, 0
+	if pad, ok := d.visiblePad(); ok {
+		mid = pad.ID
+		padX = int(math.Round(d.pos.x - pad.X))
+		padY = int(math.Round(pad.Y - d.pos.y))
+		padZ = int(math.Round(d.pos.z))
+	}
+	pitch, roll, yaw := int(math.Round(-vFwd/10)), int(math.Round(vRight/10)), normalizeYaw(d.yaw)
+	mpry := [3]int{}
+	if mid != -1 {
+		mpry = [3]int{pitch, roll, yaw}
+	}
+
+	return &types.State{
+		Mid:   mid,
+		X:     padX,
+		Y:     padY,
+		Z:     padZ,
+		Mpry:  mpry,
+		Pitch: pitch,
+		Roll:  roll,
+		Yaw:   yaw,
+		Vgx:   int(math.Round(vFwd)),
+		Vgy:   int(math.Round(vRight)),
+		Vgz:   int(math.Round(d.vel.z)),
+		Templ: temp,
+		Temph: temp + 2,
+		Tof:   tof,
+		H:     int(math.Round(d.pos.z)),
+		Bat:   int(math.Ceil(d.battery)),
+		Baro:  baseBarometer + d.pos.z/100,
+		Time:  int(d.flightTime),
+		Agx:   0,
+		Agy:   0,
+		Agz:   -1000,
+	}
+}
+
+// formatState renders a state packet in the format the real drone sends to port 8890.
+func formatState(s *types.State) string {
+	return fmt.Sprintf("mid:%d;x:%d;y:%d;z:%d;mpry:%d,%d,%d;pitch:%d;roll:%d;yaw:%d;vgx:%d;vgy:%d;vgz:%d;templ:%d;temph:%d;tof:%d;h:%d;bat:%d;baro:%.2f;time:%d;agx:%.2f;agy:%.2f;agz:%.2f;\r\n",
+		s.Mid, s.X, s.Y, s.Z, s.Mpry[0], s.Mpry[1], s.Mpry[2], s.Pitch, s.Roll, s.Yaw, s.Vgx, s.Vgy, s.Vgz, s.Templ, s.Temph, s.Tof, s.H, s.Bat, s.Baro, s.Time, s.Agx, s.Agy, s.Agz)
+}
+
+// normalizeYaw maps an accumulated heading into the drone's [-180, 180) range.
+func normalizeYaw(yaw float64) int {
+	y := math.Mod(yaw+180, 360)
+	if y < 0 {
+		y += 360
+	}
+	return int(math.Round(y - 180))
+}
+
+// intArgs parses exactly n integer arguments, each within [min, max].
+func intArgs(args []string, n, min, max int) ([]int, bool) {
+	if len(args) != n {
+		return nil, false
+	}
+	values := make([]int, n)
+	for i, arg := range args {
+		v, err := strconv.Atoi(arg)
+		if err != nil || v < min || v > max {
+			return nil, false
+		}
+		values[i] = v
+	}
+	return values, true
+}
@@ -0,0 +1,292 @@
+// Package sim provides a UDP simulator that speaks the Tello SDK protocol.
+//
+// The simulator listens for commands the same way the drone does, answers
+// them with "ok", "error" or a value, and pushes state packets to the
+// controller's state port once SDK mode has been entered. It models position,
+// yaw, battery drain and flight time so that the full
+// InitializeWithOptions → transport → StateListener path can be exercised
+// without hardware.
+package sim
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/conceptcodes/dji-tello-sdk-go/pkg/errors"
+	"github.com/conceptcodes/dji-tello-sdk-go/pkg/types"
+	"github.com/conceptcodes/dji-tello-sdk-go/pkg/utils"
+)
+
+// physicsInterval is the wall-clock period of the flight model integration.
+const physicsInterval = 20 * time.Millisecond
+
+// Config holds the simulator settings
+type Config struct {
+	// Addr is the local address the simulator accepts commands on (default: "0.0.0.0:8889")
+	Addr string `json:"addr" yaml:"addr"`
+
+	// StatePort is the port on the controller's host that state packets are sent to (default: 8890)
+	StatePort int `json:"state_port" yaml:"state_port"`
+
+	// StateInterval is the period between state packets (default: 100ms, i.e. 10 Hz)
+	StateInterval time.Duration `json:"state_interval" yaml:"state_interval"`
+
+	// Speedup is the number of simulated seconds per wall-clock second (default: 1)
+	Speedup float64 `json:"speedup" yaml:"speedup"`
+
+	// Battery is the initial battery percentage (default: 100)
+	Battery int `json:"battery" yaml:"battery"`
+
+	// SerialNumber is returned in response to "sn?"
+	SerialNumber string `json:"serial_number" yaml:"serial_number"`
+
+	// MissionPads places mission pads on the floor (default: m1 at the takeoff point)
+	MissionPads []MissionPad `json:"mission_pads" yaml:"mission_pads"`
+}
+
+// DefaultConfig returns the default simulator configuration
+func DefaultConfig() Config {
+	return Config{
+		Addr:          "0.0.0.0:8889",
+		StatePort:     8890,
+		StateInterval: 100 * time.Millisecond,
+		Speedup:       1,
+		Battery:       100,
+		SerialNumber:  "0TQSIM00000001",
+		MissionPads:   []MissionPad{{ID: 1}},
+	}
+}
+
+// Validate validates the simulator configuration
+func (c *Config) Validate() error {
+	if _, _, err := net.SplitHostPort(c.Addr); err != nil {
+		return errors.ValidationError("Simulator", "addr", fmt.Sprintf("invalid format: %v", err))
+	}
+	if err := utils.ValidateNumberInRange(c.StatePort, 1, 65535); err != nil {
+		return errors.ValidationError("Simulator", "state_port", err.Error())
+	}
+	if c.StateInterval < 10*time.Millisecond {
+		return errors.ValidationError("Simulator", "state_interval", "must be >= 10ms")
+	}
+	if c.Speedup <= 0 {
+		return errors.ValidationError("Simulator", "speedup", "must be > 0")
+	}
+	if err := utils.ValidateNumberInRange(c.Battery, 0, 100); err != nil {
+		return errors.ValidationError("Simulator", "battery", err.Error())
+	}
+	if c.SerialNumber == "" {
+		return errors.ValidationError("Simulator", "serial_number", "cannot be empty")
+	}
+	for _, pad := range c.MissionPads {
+		if err := utils.ValidateNumberInRange(pad.ID, 1, 8); err != nil {
+			return errors.ValidationError("Simulator", "mission_pads.id", err.Error())
+		}
+	}
+	return nil
+}
+
+// Simulator emulates a single Tello drone over UDP
+type Simulator struct {
+	config Config
+	conn   *net.UDPConn
+
+	mu        sync.Mutex
+	drone     *drone
+	pending   *net.UDPAddr // Controller waiting for the active motion to finish
+	stateAddr *net.UDPAddr // Where state packets are pushed once in SDK mode
+
+	ctx    context.Context
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
+}
+
+// New creates a simulator with the given configuration
+func New(cfg Config) (*Simulator, error) {
+	if err := cfg.Validate(); err != nil {
+		return nil, err
+	}
+	return &Simulator{
+		config: cfg,
+		drone:  newDrone(cfg.Battery, cfg.SerialNumber, cfg.MissionPads),
+	}, nil
+}
+
+// Start binds the command socket and starts the simulation in the background.
+// Use Stop to shut it down.
+func (s *Simulator) Start() error {
+	if s.conn != nil {
+		return fmt.Errorf("simulator on %s is already started", s.config.Addr)
+	}
+
+	addr, err := net.ResolveUDPAddr("udp", s.config.Addr)
+	if err != nil {
+		return errors.ConnectionError("Simulator", "resolve address", err)
+	}
+	conn, err := net.ListenUDP("udp", addr)
+	if err != nil {
+		return errors.ConnectionError("Simulator", "listen", err)
+	}
+
+	s.conn = conn
+	s.ctx, s.cancel = context.WithCancel(context.Background())
+
+	s.wg.Add(2)
+	go s.readLoop()
+	go s.tickLoop()
+
+	utils.Logger.Infof("Tello simulator listening on %s", conn.LocalAddr())
+	return nil
+}
+
+// Stop shuts the simulator down and waits for its goroutines to exit
+func (s *Simulator) Stop() {
+	if s.conn == nil {
+		return
+	}
+	s.cancel()
+	s.conn.Close()
+	s.wg.Wait()
+	s.conn = nil
+	utils.Logger.Info("Tello simulator stopped")
+}
+
+// Addr returns the address the simulator is listening on. This resolves
+// ephemeral ports when the configured address ends in ":0".
+func (s *Simulator) Addr() string {
+	if s.conn == nil {
+		return s.config.Addr
+	}
+	return s.conn.LocalAddr().String()
+}
+
+// State returns the telemetry the simulator is currently reporting
+func (s *Simulator) State() *types.State {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.drone.state()
+}
+
+// Position returns the simulated ground-truth position (cm) relative to the
+// power-on location and heading: x forward, y right, z up.
+func (s *Simulator) Position() (x, y, z float64) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.drone.pos.x, s.drone.pos.y, s.drone.pos.z
+}
+
+// IsFlying reports whether the simulated drone is airborne
+func (s *Simulator) IsFlying() bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.drone.flying
+}
+
+func (s *Simulator) readLoop() {
+	defer s.wg.Done()
+
+	buffer := make([]byte, 1024)
+	for {
+		n, addr, err := s.conn.ReadFromUDP(buffer)
+		if err != nil {
+			select {
+			case <-s.ctx.Done():
+				return
+			default:
+			}
+			if strings.Contains(err.Error(), "use of closed network connection") {
+				return
+			}
+			utils.Logger.Warnf("Simulator read error: %v", err)
+			continue
+		}
+
+		command := strings.TrimSpace(string(buffer[:n]))
+		if reply := s.handleCommand(command, addr); reply != "" {
+			s.send(reply, addr)
+		}
+	}
+}
+
+// handleCommand applies a command to the flight model and returns the
+// immediate reply, if any.
+func (s *Simulator) handleCommand(command string, addr *net.UDPAddr) string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	utils.Logger.Debugf("Simulator received %q from %s", command, addr)
+
+	reply, deferred := s.drone.handle(command)
+
+	// Commands such as land, stop and emergency cancel the motion in progress
+	if s.pending != nil && (s.drone.active == nil || deferred) {
+		s.send("error", s.pending)
+		s.pending = nil
+	}
+
+	if command == "command" {
+		s.stateAddr = &net.UDPAddr{IP: addr.IP, Port: s.config.StatePort, Zone: addr.Zone}
+	}
+	if deferred {
+		s.pending = addr
+	}
+	return reply
+}
+
+func (s *Simulator) tickLoop() {
+	defer s.wg.Done()
+
+	physics := time.NewTicker(physicsInterval)
+	defer physics.Stop()
+	state := time.NewTicker(s.config.StateInterval)
+	defer state.Stop()
+
+	last := time.Now()
+	for {
+		select {
+		case <-s.ctx.Done():
+			return
+		case now := <-physics.C:
+			s.step(now.Sub(last).Seconds() * s.config.Speedup)
+			last = now
+		case <-state.C:
+			s.broadcastState()
+		}
+	}
+}
+
+func (s *Simulator) step(dt float64) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	finished, aborted := s.drone.step(dt)
+	if s.pending == nil || !(finished || aborted) {
+		return
+	}
+	if finished {
+		s.send("ok", s.pending)
+	} else {
+		s.send("error", s.pending)
+	}
+	s.pending = nil
+}
+
+func (s *Simulator) broadcastState() {
+	s.mu.Lock()
+	addr := s.stateAddr
+	packet := formatState(s.drone.state())
+	s.mu.Unlock()
+
+	if addr != nil {
+		s.send(packet, addr)
+	}
+}
+
+func (s *Simulator) send(message string, addr *net.UDPAddr) {
+	if _, err := s.conn.WriteToUDP([]byte(message), addr); err != nil {
+		utils.Logger.Debugf("Simulator failed to send to %s: %v", addr, err)
+	}
+}
@@ -0,0 +1,294 @@
+package sim
+
+import (
+	"net"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/conceptcodes/dji-tello-sdk-go/pkg/config"
+	"github.com/conceptcodes/dji-tello-sdk-go/pkg/tello"
+	"github.com/conceptcodes/dji-tello-sdk-go/pkg/utils"
+)
+
+// freeUDPPort returns a local UDP port that is currently unused
+func freeUDPPort(t *testing.T) int {
+	t.Helper()
+	conn, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Failed to find free port: %v", err)
+	}
+	defer conn.Close()
+	return conn.LocalAddr().(*net.UDPAddr).Port
+}
+
+func startTestSimulator(t *testing.T, statePort int) *Simulator {
+	t.Helper()
+	cfg := DefaultConfig()
+	cfg.Addr = "127.0.0.1:0"
+	cfg.StatePort = statePort
+	cfg.Speedup = 20
+
+	s, err := New(cfg)
+	if err != nil {
+		t.Fatalf("Failed to create simulator: %v", err)
+	}
+	if err := s.Start(); err != nil {
+		t.Fatalf("Failed to start simulator: %v", err)
+	}
+	t.Cleanup(s.Stop)
+	return s
+}
+
+func sendRaw(t *testing.T, conn *net.UDPConn, command string) string {
+	t.Helper()
+	if _, err := conn.Write([]byte(command)); err != nil {
+		t.Fatalf("Failed to send %q: %v", command, err)
+	}
+	conn.SetReadDeadline(time.Now().Add(2 * time.Second))
+	buf := make([]byte, 1024)
+	n, err := conn.Read(buf)
+	if err != nil {
+		t.Fatalf("No reply to %q: %v", command, err)
+	}
+	return string(buf[:n])
+}
+
+func TestConfigValidate(t *testing.T) {
+	cfg := DefaultConfig()
+	if err := cfg.Validate(); err != nil {
+		t.Errorf("Expected default config to be valid, got %v", err)
+	}
+
+	cfg.Speedup = 0
+	if err := cfg.Validate(); err == nil {
+		t.Error("Expected error for zero speedup")
+	}
+
+	cfg = DefaultConfig()
+	cfg.Addr = "invalid"
+	if err := cfg.Validate(); err == nil {
+		t.Error("Expected error for invalid address")
+	}
+}
+
+func TestSimulatorReplies(t *testing.T) {
+	s := startTestSimulator(t, freeUDPPort(t))
+
+	raddr, _ := net.ResolveUDPAddr("udp", s.Addr())
+	conn, err := net.DialUDP("udp", nil, raddr)
+	if err != nil {
+		t.Fatalf("Failed to dial simulator: %v", err)
+	}
+	defer conn.Close()
+
+	if resp := sendRaw(t, conn, "battery?"); resp != "error" {
+		t.Errorf("Expected error before SDK mode, got %q", resp)
+	}
+	if resp := sendRaw(t, conn, "command"); resp != "ok" {
+		t.Errorf("Expected ok for command, got %q", resp)
+	}
+	if resp := sendRaw(t, conn, "battery?"); resp != "100" {
+		t.Errorf("Expected battery 100, got %q", resp)
+	}
+	if resp := sendRaw(t, conn, "forward 50"); resp != "error" {
+		t.Errorf("Expected error moving while grounded, got %q", resp)
+	}
+	if resp := sendRaw(t, conn, "sn?"); resp != DefaultConfig().SerialNumber {
+		t.Errorf("Expected serial number, got %q", resp)
+	}
+	if resp := sendRaw(t, conn, "takeoff"); resp != "ok" {
+		t.Errorf("Expected ok for takeoff, got %q", resp)
+	}
+	if resp := sendRaw(t, conn, "height?"); resp != "80" {
+		t.Errorf("Expected height 80, got %q", resp)
+	}
+	if resp := sendRaw(t, conn, "forward 10"); resp != "error" {
+		t.Errorf("Expected error for out of range distance, got %q", resp)
+	}
+	if resp := sendRaw(t, conn, "cw 90"); resp != "ok" {
+		t.Errorf("Expected ok for cw, got %q", resp)
+	}
+	if resp := sendRaw(t, conn, "forward 100"); resp != "ok" {
+		t.Errorf("Expected ok for forward, got %q", resp)
+	}
+
+	x, y, z := s.Position()
+	if x < -1 || x > 1 || y < 99 || y > 101 || z != 80 {
+		t.Errorf("Expected position (0, 100, 80) after turning right and flying forward, got (%.1f, %.1f, %.1f)", x, y, z)
+	}
+	if yaw := s.State().Yaw; yaw != 90 {
+		t.Errorf("Expected yaw 90, got %d", yaw)
+	}
+
+	if resp := sendRaw(t, conn, "land"); resp != "ok" {
+		t.Errorf("Expected ok for land, got %q", resp)
+	}
+	if s.IsFlying() {
+		t.Error("Expected drone to be grounded after land")
+	}
+	if resp := sendRaw(t, conn, "unknown"); resp != "error" {
+		t.Errorf("Expected error for unknown command, got %q", resp)
+	}
+}
+
+func TestDroneBatteryAndFlightTime(t *testing.T) {
+	d := newDrone(100, "sn", nil)
+	d.handle("command")
+	if _, deferred := d.handle("takeoff"); !deferred {
+		t.Fatal("Expected takeoff to be deferred until the climb finishes")
+	}
+
+	for i := 0; i < 100; i++ {
+		d.step(1)
+	}
+
+	s := d.state()
+	if s.Time != 100 {
+		t.Errorf("Expected flight time 100, got %d", s.Time)
+	}
+	if s.Bat >= 100 || s.Bat < 80 {
+		t.Errorf("Expected battery to drain to 80-99%%, got %d", s.Bat)
+	}
+	if s.H != 80 || s.Tof != 80 {
+		t.Errorf("Expected height and tof of 80, got %d and %d", s.H, s.Tof)
+	}
+}
+
+func TestDroneLandsWhenBatteryEmpty(t *testing.T) {
+	d := newDrone(100, "sn", nil)
+	d.handle("command")
+	d.handle("takeoff")
+	d.step(2)
+	d.battery = 1
+	d.handle("speed 10")
+	if _, deferred := d.handle("forward 500"); !deferred {
+		t.Fatal("Expected forward to start a motion")
+	}
+
+	aborted := false
+	for i := 0; i < 100 && d.flying; i++ {
+		_, aborted = d.step(0.5)
+	}
+	if !aborted {
+		t.Error("Expected active motion to be aborted on empty battery")
+	}
+	if d.flying || d.pos.z != 0 {
+		t.Error("Expected drone to be on the ground with an empty battery")
+	}
+}
+
+func TestStatePacketParses(t *testing.T) {
+	d := newDrone(87, "sn", nil)
+	packet := formatState(d.state())
+
+	state, err := utils.ParseState(packet)
+	if err != nil {
+		t.Fatalf("Failed to parse state packet %q: %v", packet, err)
+	}
+	if state.Bat != 87 {
+		t.Errorf("Expected battery 87, got %d", state.Bat)
+	}
+	if state.Agz != -1000 {
+		t.Errorf("Expected agz -1000, got %f", state.Agz)
+	}
+}
+
+func TestDroneMissionPads(t *testing.T) {
+	d := newDrone(100, "sn", []MissionPad{{ID: 1}, {ID: 2, X: 200}})
+	d.handle("command")
+	d.handle("takeoff")
+	d.step(2)
+
+	if s := d.state(); s.Mid != -1 {
+		t.Errorf("Expected no pad before mon, got m%d", s.Mid)
+	}
+	if reply, _ := d.handle("go 0 0 100 50 m1"); reply != "error" {
+		t.Errorf("Expected error for pad go with detection off, got %q", reply)
+	}
+	if reply, _ := d.handle("mon"); reply != "ok" {
+		t.Errorf("Expected ok for mon, got %q", reply)
+	}
+	if reply, _ := d.handle("mdirection 3"); reply != "error" {
+		t.Errorf("Expected error for invalid mdirection, got %q", reply)
+	}
+
+	if _, deferred := d.handle("go 30 -20 100 50 m1"); !deferred {
+		t.Fatal("Expected pad go to start a motion")
+	}
+	for i := 0; i < 20 && d.active != nil; i++ {
+		d.step(0.5)
+	}
+
+	s := d.state()
+	if s.Mid != 1 || s.X != 30 || s.Y != -20 || s.Z != 100 {
+		t.Errorf("Expected m1 at (30, -20, 100), got m%d at (%d, %d, %d)", s.Mid, s.X, s.Y, s.Z)
+	}
+	packet, err := utils.ParseState(formatState(s))
+	if err != nil || packet.Mid != 1 || packet.Y != -20 {
+		t.Errorf("Expected pad fields to round-trip through the state packet, got %+v (%v)", packet, err)
+	}
+
+	if _, deferred := d.handle("jump 0 0 100 50 90 m1 m2"); !deferred {
+		t.Fatal("Expected jump to start a motion")
+	}
+	for i := 0; i < 20 && d.active != nil; i++ {
+		d.step(0.5)
+	}
+	if s := d.state(); s.Mid != 2 || s.X != 0 || s.Yaw != 90 {
+		t.Errorf("Expected to hover over m2 facing 90, got m%d x=%d yaw=%d", s.Mid, s.X, s.Yaw)
+	}
+}
+
+func TestEndToEndWithCommander(t *testing.T) {
+	statePort := freeUDPPort(t)
+	s := startTestSimulator(t, statePort)
+
+	cfg := config.DefaultTransportConfig().
+		WithDroneHost(s.Addr()).
+		WithLocalCommandAddr("127.0.0.1:0").
+		WithLocalStateAddr("127.0.0.1:" + strconv.Itoa(statePort)).
+		WithLocalVideoAddr("127.0.0.1:" + strconv.Itoa(freeUDPPort(t))).
+		WithCommandSendDelay(0).
+		WithCommandTimeout(2 * time.Second)
+
+	drone, err := tello.InitializeWithOptions(tello.WithTransportConfig(cfg), tello.WithSafetyDisabled())
+	if err != nil {
+		t.Fatalf("Failed to initialize commander: %v", err)
+	}
+	defer func() {
+		s.Stop()
+		drone.Shutdown()
+	}()
+
+	if err := drone.Init(); err != nil {
+		t.Fatalf("Failed to enter SDK mode: %v", err)
+	}
+	if err := drone.TakeOff(); err != nil {
+		t.Fatalf("Failed to take off: %v", err)
+	}
+
+	timeout := time.After(3 * time.Second)
+	for airborne := false; !airborne; {
+		select {
+		case state := <-drone.GetStateChannel():
+			if state == nil || state.H != 80 {
+				continue
+			}
+			if state.Bat <= 0 || state.Bat > 100 {
+				t.Errorf("Expected a valid battery percentage, got %d", state.Bat)
+			}
+			airborne = true
+		case <-timeout:
+			t.Fatal("Timed out waiting for airborne state from the state listener")
+		}
+	}
+
+	height, err := drone.GetHeight()
+	if err != nil {
+		t.Fatalf("Failed to get height: %v", err)
+	}
+	if height != 80 {
+		t.Errorf("Expected height 80 after takeoff, got %d", height)
+	}
+}
@@ -0,0 +1,133 @@
+package swarm
+
+import (
+	"context"
+	"encoding/binary"
+	"net"
+	"strings"
+	"time"
+
+	"github.com/conceptcodes/dji-tello-sdk-go/pkg/errors"
+	"github.com/conceptcodes/dji-tello-sdk-go/pkg/utils"
+)
+
+// Discover scans the configured subnet for drones. It sends "command" to
+// every host, asks each responder for its serial number with "sn?" and
+// returns the drones that answered both, ordered by IP. Drones that answer
+// are left in SDK mode.
+func Discover(ctx context.Context, cfg Config) ([]DroneInfo, error) {
+	if err := cfg.Validate(); err != nil {
+		return nil, err
+	}
+
+	hosts, err := subnetHosts(cfg.Subnet)
+	if err != nil {
+		return nil, errors.ValidationError("Swarm", "subnet", err.Error())
+	}
+
+	conn, err := net.ListenUDP("udp4", nil)
+	if err != nil {
+		return nil, errors.ConnectionError("Swarm", "open discovery socket", err)
+	}
+	defer conn.Close()
+
+	utils.Logger.Infof("Scanning %s (%d hosts) for Tello drones", cfg.Subnet, len(hosts))
+	for _, host := range hosts {
+		addr := &net.UDPAddr{IP: host, Port: cfg.CommandPort}
+		if _, err := conn.WriteToUDP([]byte("command"), addr); err != nil {
+			utils.Logger.Debugf("Discovery probe to %s failed: %v", addr, err)
+		}
+	}
+
+	deadline := time.Now().Add(cfg.DiscoveryTimeout)
+	if d, ok := ctx.Deadline(); ok && d.Before(deadline) {
+		deadline = d
+	}
+
+	serials := make(map[string]string) // IP -> serial number, "" until sn? is answered
+	buffer := make([]byte, 256)
+	for {
+		if err := ctx.Err(); err != nil {
+			return nil, errors.WrapSDKError(err, errors.ErrTimeout, "Swarm", "discovery cancelled")
+		}
+
+		// Wake up periodically so that cancellation is noticed
+		wake := time.Now().Add(100 * time.Millisecond)
+		if wake.After(deadline) {
+			wake = deadline
+		}
+		if err := conn.SetReadDeadline(wake); err != nil {
+			return nil, errors.ConnectionError("Swarm", "set discovery read deadline", err)
+		}
+
+		n, addr, err := conn.ReadFromUDP(buffer)
+		if err != nil {
+			if ne, ok := err.(net.Error); ok && ne.Timeout() {
+				if time.Now().Before(deadline) {
+					continue
+				}
+				break
+			}
+			return nil, errors.ConnectionError("Swarm", "read discovery reply", err)
+		}
+
+		ip := addr.IP.String()
+		reply := strings.TrimSpace(string(buffer[:n]))
+		serial, known := serials[ip]
+
+		switch {
+		case strings.EqualFold(reply, "ok"):
+			if !known {
+				serials[ip] = ""
+				if _, err := conn.WriteToUDP([]byte("sn?"), addr); err != nil {
+					utils.Logger.Warnf("Failed to request serial number from %s: %v", ip, err)
+				}
+			}
+		case strings.EqualFold(reply, "error"):
+			utils.Logger.Debugf("Discovery: %s replied error", ip)
+		case known && serial == "":
+			serials[ip] = reply
+			utils.Logger.Infof("Discovered drone %s at %s", reply, ip)
+		}
+	}
+
+	drones := make([]DroneInfo, 0, len(serials))
+	for ip, serial := range serials {
+		if serial == "" {
+			utils.Logger.Warnf("Drone at %s entered SDK mode but did not report a serial number", ip)
+			continue
+		}
+		drones = append(drones, DroneInfo{IP: ip, SerialNumber: serial})
+	}
+	sortDrones(drones)
+	return drones, nil
+}
+
+// subnetHosts lists the host addresses of an IPv4 network, excluding the
+// network and broadcast addresses where the prefix has them.
+func subnetHosts(cidr string) ([]net.IP, error) {
+	_, subnet, err := net.ParseCIDR(cidr)
+	if err != nil {
+		return nil, err
+	}
+	base := subnet.IP.To4()
+	if base == nil {
+		return nil, errors.InvalidArgumentError("Swarm", "subnet", "must be an IPv4 network")
+	}
+
+	ones, bits := subnet.Mask.Size()
+	size := uint32(1) << uint(bits-ones)
+	first, last := uint32(0), size-1
+	if size > 2 {
+		first, last = 1, size-2
+	}
+
+	start := binary.BigEndian.Uint32(base)
+	hosts := make([]net.IP, 0, last-first+1)
+	for i := first; i <= last; i++ {
+		ip := make(net.IP, 4)
+		binary.BigEndian.PutUint32(ip, start+i)
+		hosts = append(hosts, ip)
+	}
+	return hosts, nil
+}
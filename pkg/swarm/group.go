@@ -0,0 +1,189 @@
+package swarm
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/conceptcodes/dji-tello-sdk-go/pkg/errors"
+	"github.com/conceptcodes/dji-tello-sdk-go/pkg/utils"
+)
+
+// GroupError reports which drones failed a group action. Drones that are not
+// listed completed it.
+type GroupError struct {
+	Action string
+	Total  int
+	Errors map[string]error // Keyed by serial number
+}
+
+// Error implements the error interface
+func (e *GroupError) Error() string {
+	failed := e.Failed()
+	parts := make([]string, 0, len(failed))
+	for _, serial := range failed {
+		parts = append(parts, fmt.Sprintf("%s: %v", serial, e.Errors[serial]))
+	}
+	return fmt.Sprintf("swarm %s failed on %d of %d drones: %s",
+		e.Action, len(failed), e.Total, strings.Join(parts, "; "))
+}
+
+// Unwrap returns the per-drone errors so that errors.Is and errors.As can
+// inspect them
+func (e *GroupError) Unwrap() []error {
+	errs := make([]error, 0, len(e.Errors))
+	for _, serial := range e.Failed() {
+		errs = append(errs, e.Errors[serial])
+	}
+	return errs
+}
+
+// Failed returns the serial numbers of the drones that failed, sorted
+func (e *GroupError) Failed() []string {
+	failed := make([]string, 0, len(e.Errors))
+	for serial := range e.Errors {
+		failed = append(failed, serial)
+	}
+	sort.Strings(failed)
+	return failed
+}
+
+// Offset is a relative move in a drone's body frame (cm): x forward, y left, z up
+type Offset struct {
+	X int `json:"x" yaml:"x"`
+	Y int `json:"y" yaml:"y"`
+	Z int `json:"z" yaml:"z"`
+}
+
+// Do runs fn for every drone concurrently and waits for all of them. It
+// returns a *GroupError listing the drones whose fn returned an error.
+func (s *Swarm) Do(ctx context.Context, action string, fn func(ctx context.Context, d *Drone) error) error {
+	return s.do(ctx, action, s.drones, fn)
+}
+
+func (s *Swarm) do(ctx context.Context, action string, drones []*Drone, fn func(ctx context.Context, d *Drone) error) error {
+	var (
+		wg   sync.WaitGroup
+		mu   sync.Mutex
+		errs = make(map[string]error)
+	)
+
+	for _, drone := range drones {
+		wg.Add(1)
+		go func(d *Drone) {
+			defer wg.Done()
+			if err := fn(ctx, d); err != nil {
+				mu.Lock()
+				errs[d.SerialNumber] = err
+				mu.Unlock()
+			}
+		}(drone)
+	}
+	wg.Wait()
+
+	if len(errs) == 0 {
+		utils.Logger.Debugf("Swarm %s completed on %d drones", action, len(drones))
+		return nil
+	}
+	return &GroupError{Action: action, Total: len(drones), Errors: errs}
+}
+
+// Command sends the same control command to every drone and waits for all
+// replies
+func (s *Swarm) Command(ctx context.Context, command string) error {
+	return s.Do(ctx, command, func(ctx context.Context, d *Drone) error {
+		_, err := d.Send(ctx, command)
+		return err
+	})
+}
+
+// Init puts every drone into SDK mode
+func (s *Swarm) Init(ctx context.Context) error {
+	return s.Command(ctx, "command")
+}
+
+// TakeOff takes off all drones and returns once every drone has finished
+// climbing or failed
+func (s *Swarm) TakeOff(ctx context.Context) error {
+	return s.Command(ctx, "takeoff")
+}
+
+// Land lands all drones and returns once every drone is down or has failed
+func (s *Swarm) Land(ctx context.Context) error {
+	return s.Command(ctx, "land")
+}
+
+// Emergency stops the motors of every drone. It drops their queued control
+// commands and cuts short any command in flight.
+func (s *Swarm) Emergency(ctx context.Context) error {
+	return s.Do(ctx, "emergency", func(ctx context.Context, d *Drone) error {
+		return d.Commander.EmergencyNow()
+	})
+}
+
+// Formation moves the drones listed in offsets, keyed by serial number, by
+// their offset at the given speed (cm/s) and waits until all have arrived.
+// Drones that are not listed hold position. Offsets are validated for every
+// drone before any command is sent.
+func (s *Swarm) Formation(ctx context.Context, speed int, offsets map[string]Offset) error {
+	if err := utils.ValidateNumberInRange(speed, 10, 100); err != nil {
+		return errors.InvalidArgumentError("Swarm", "speed", err.Error())
+	}
+
+	drones := make([]*Drone, 0, len(offsets))
+	for _, drone := range s.drones {
+		offset, ok := offsets[drone.SerialNumber]
+		if !ok {
+			continue
+		}
+		if err := validateOffset(offset); err != nil {
+			return errors.InvalidArgumentError("Swarm", "offsets",
+				fmt.Sprintf("%s: %v", drone.SerialNumber, err))
+		}
+		drones = append(drones, drone)
+	}
+	if len(drones) != len(offsets) {
+		for serial := range offsets {
+			if _, ok := s.bySN[serial]; !ok {
+				return errors.InvalidArgumentError("Swarm", "offsets",
+					fmt.Sprintf("unknown drone %s", serial))
+			}
+		}
+	}
+
+	return s.do(ctx, "formation", drones, func(ctx context.Context, d *Drone) error {
+		o := offsets[d.SerialNumber]
+		_, err := d.Send(ctx, fmt.Sprintf("go %d %d %d %d", o.X, o.Y, o.Z, speed))
+		return err
+	})
+}
+
+// Barrier waits until every drone has completed the control commands queued
+// before it, including fire-and-forget commands sent through a drone's own
+// Commander. It queues a "command" on each drone, which is harmless in flight.
+func (s *Swarm) Barrier(ctx context.Context) error {
+	return s.Command(ctx, "command")
+}
+
+// validateOffset applies the SDK limits for "go": each axis within ±500cm and
+// not all of them within ±20cm.
+func validateOffset(o Offset) error {
+	for _, v := range []int{o.X, o.Y, o.Z} {
+		if err := utils.ValidateNumberInRange(v, -500, 500); err != nil {
+			return err
+		}
+	}
+	if abs(o.X) <= 20 && abs(o.Y) <= 20 && abs(o.Z) <= 20 {
+		return fmt.Errorf("offset (%d, %d, %d) is too small: at least one axis must exceed 20cm", o.X, o.Y, o.Z)
+	}
+	return nil
+}
+
+func abs(v int) int {
+	if v < 0 {
+		return -v
+	}
+	return v
+}
@@ -0,0 +1,271 @@
+// Package swarm controls several Tello EDU drones that have joined a shared
+// network in station (AP) mode with "ap <ssid> <pass>".
+//
+// Drones are found by scanning a subnet with the SDK "command" and "sn?"
+// commands. Each drone gets its own TelloCommander with a dedicated command
+// socket, while state packets, which every drone pushes to the same
+// controller port, are demultiplexed by source IP. Group actions run on all
+// drones concurrently and report failures per drone.
+package swarm
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/conceptcodes/dji-tello-sdk-go/pkg/config"
+	"github.com/conceptcodes/dji-tello-sdk-go/pkg/errors"
+	"github.com/conceptcodes/dji-tello-sdk-go/pkg/tello"
+	"github.com/conceptcodes/dji-tello-sdk-go/pkg/transport"
+	"github.com/conceptcodes/dji-tello-sdk-go/pkg/utils"
+)
+
+// maxSubnetHostBits caps discovery at a /20 so a typo cannot start a scan of
+// millions of addresses.
+const maxSubnetHostBits = 12
+
+// Config holds the swarm settings
+type Config struct {
+	// Subnet is the IPv4 network scanned for drones, e.g. "192.168.1.0/24"
+	Subnet string `json:"subnet" yaml:"subnet"`
+
+	// CommandPort is the SDK command port on each drone (default: 8889)
+	CommandPort int `json:"command_port" yaml:"command_port"`
+
+	// LocalStateAddr is the local address that all drones send state to (default: "0.0.0.0:8890")
+	LocalStateAddr string `json:"local_state_addr" yaml:"local_state_addr"`
+
+	// DiscoveryTimeout is how long to wait for drones to answer during discovery (default: 2s)
+	DiscoveryTimeout time.Duration `json:"discovery_timeout" yaml:"discovery_timeout"`
+
+	// Transport configures the per-drone command connections. DroneHost and
+	// LocalCommandAddr are ignored: each drone is addressed by its discovered IP
+	// from an ephemeral local port so that replies are separated by socket.
+	Transport config.TransportConfig `json:"transport" yaml:"transport"`
+}
+
+// DefaultConfig returns the default swarm configuration
+func DefaultConfig() Config {
+	return Config{
+		Subnet:           "192.168.1.0/24",
+		CommandPort:      8889,
+		LocalStateAddr:   "0.0.0.0:8890",
+		DiscoveryTimeout: 2 * time.Second,
+		Transport:        config.DefaultTransportConfig(),
+	}
+}
+
+// Validate validates the swarm configuration
+func (c *Config) Validate() error {
+	_, subnet, err := net.ParseCIDR(c.Subnet)
+	if err != nil {
+		return errors.ValidationError("Swarm", "subnet", fmt.Sprintf("invalid format: %v", err))
+	}
+	ones, bits := subnet.Mask.Size()
+	if bits != 32 {
+		return errors.ValidationError("Swarm", "subnet", "must be an IPv4 network")
+	}
+	if bits-ones > maxSubnetHostBits {
+		return errors.ValidationError("Swarm", "subnet",
+			fmt.Sprintf("too large to scan (max /%d)", 32-maxSubnetHostBits))
+	}
+	if err := utils.ValidateNumberInRange(c.CommandPort, 1, 65535); err != nil {
+		return errors.ValidationError("Swarm", "command_port", err.Error())
+	}
+	if _, _, err := net.SplitHostPort(c.LocalStateAddr); err != nil {
+		return errors.ValidationError("Swarm", "local_state_addr", fmt.Sprintf("invalid format: %v", err))
+	}
+	if c.DiscoveryTimeout <= 0 {
+		return errors.ValidationError("Swarm", "discovery_timeout", "must be > 0")
+	}
+	return nil
+}
+
+// DroneInfo identifies a drone found on the network
+type DroneInfo struct {
+	IP           string `json:"ip"`
+	SerialNumber string `json:"serial_number"`
+}
+
+// Drone is a member of the swarm
+type Drone struct {
+	DroneInfo
+
+	// Commander controls this drone individually. Its state channel only
+	// carries packets sent from this drone's IP.
+	Commander tello.TelloCommander
+
+	queue *tello.PriorityCommandQueue
+}
+
+// Send enqueues a control command behind any commands already queued for
+// this drone and waits for the reply. If ctx ends first the command stays
+// queued and is still sent.
+func (d *Drone) Send(ctx context.Context, command string) (string, error) {
+	return d.await(ctx, command, d.queue.EnqueueControlWithResponse(command))
+}
+
+func (d *Drone) await(ctx context.Context, command string, respChan <-chan tello.CommandResponse) (string, error) {
+	select {
+	case resp := <-respChan:
+		return resp.Response, resp.Error
+	case <-ctx.Done():
+		return "", errors.WrapSDKError(ctx.Err(), errors.ErrTimeout, "Swarm",
+			fmt.Sprintf("stopped waiting for '%s' on %s", command, d.SerialNumber))
+	}
+}
+
+// Swarm controls a group of drones
+type Swarm struct {
+	config Config
+	demux  *transport.StateDemux
+	drones []*Drone
+	bySN   map[string]*Drone
+	once   sync.Once
+}
+
+// New creates a swarm from drones that were already discovered. The drones
+// must be in SDK mode; Discover leaves them in it.
+func New(cfg Config, drones []DroneInfo) (*Swarm, error) {
+	if err := cfg.Validate(); err != nil {
+		return nil, err
+	}
+	if len(drones) == 0 {
+		return nil, errors.InvalidArgumentError("Swarm", "drones", "at least one drone is required")
+	}
+
+	demux, err := transport.NewStateDemux(cfg.LocalStateAddr)
+	if err != nil {
+		return nil, errors.ConnectionError("Swarm", "create state demux", err)
+	}
+
+	s := &Swarm{
+		config: cfg,
+		demux:  demux,
+		bySN:   make(map[string]*Drone, len(drones)),
+	}
+
+	ordered := make([]DroneInfo, len(drones))
+	copy(ordered, drones)
+	sortDrones(ordered)
+
+	for _, info := range ordered {
+		if _, exists := s.bySN[info.SerialNumber]; exists {
+			s.closeDrones()
+			return nil, errors.InvalidArgumentError("Swarm", "drones",
+				fmt.Sprintf("duplicate serial number %s", info.SerialNumber))
+		}
+
+		drone, err := s.newDrone(info)
+		if err != nil {
+			s.closeDrones()
+			return nil, err
+		}
+		s.drones = append(s.drones, drone)
+		s.bySN[info.SerialNumber] = drone
+	}
+
+	// Bind the state port here so that a port in use fails New
+	if err := demux.Listen(); err != nil {
+		s.closeDrones()
+		return nil, errors.ConnectionError("Swarm", "listen for drone states", err)
+	}
+	go func() {
+		if err := demux.Serve(); err != nil {
+			utils.Logger.Errorf("Swarm state demux stopped: %v", err)
+		}
+	}()
+
+	utils.Logger.Infof("Swarm created with %d drones", len(s.drones))
+	return s, nil
+}
+
+func (s *Swarm) newDrone(info DroneInfo) (*Drone, error) {
+	cfg := s.config.Transport
+	cfg.DroneHost = net.JoinHostPort(info.IP, strconv.Itoa(s.config.CommandPort))
+	cfg.LocalCommandAddr = ""
+
+	commandClient, err := transport.NewCommandConnectionWithConfig(cfg)
+	if err != nil {
+		return nil, errors.ConnectionError("Swarm", fmt.Sprintf("create command connection to %s", info.SerialNumber), err)
+	}
+
+	queue := tello.NewPriorityCommandQueue()
+	return &Drone{
+		DroneInfo: info,
+		// Video from several drones arrives on the same port and cannot be
+		// told apart, so swarm members have no video listener.
+		Commander: tello.NewTelloCommander(commandClient, queue, s.demux.Listener(info.IP), nil),
+		queue:     queue,
+	}, nil
+}
+
+// Connect discovers the drones on the configured subnet and creates a swarm
+// from them
+func Connect(ctx context.Context, cfg Config) (*Swarm, error) {
+	drones, err := Discover(ctx, cfg)
+	if err != nil {
+		return nil, err
+	}
+	if len(drones) == 0 {
+		return nil, errors.NewSDKError(errors.ErrConnectionFailed, "Swarm",
+			fmt.Sprintf("no drones found on %s", cfg.Subnet))
+	}
+	return New(cfg, drones)
+}
+
+// Drones returns the members of the swarm ordered by IP address
+func (s *Swarm) Drones() []*Drone {
+	drones := make([]*Drone, len(s.drones))
+	copy(drones, s.drones)
+	return drones
+}
+
+// Drone returns the member with the given serial number
+func (s *Swarm) Drone(serialNumber string) (*Drone, bool) {
+	drone, ok := s.bySN[serialNumber]
+	return drone, ok
+}
+
+// Size returns the number of drones in the swarm
+func (s *Swarm) Size() int {
+	return len(s.drones)
+}
+
+// Shutdown shuts down every drone's commander and the shared state socket
+func (s *Swarm) Shutdown() error {
+	s.once.Do(func() {
+		utils.Logger.Info("Shutting down swarm...")
+		s.closeDrones()
+		s.demux.Stop()
+	})
+	return nil
+}
+
+func (s *Swarm) closeDrones() {
+	for _, drone := range s.drones {
+		if err := drone.Commander.Shutdown(); err != nil {
+			utils.Logger.Errorf("Error shutting down drone %s: %v", drone.SerialNumber, err)
+		}
+	}
+}
+
+// sortDrones orders drones by IP so that group output is stable
+func sortDrones(drones []DroneInfo) {
+	sort.Slice(drones, func(i, j int) bool {
+		a, b := net.ParseIP(drones[i].IP).To4(), net.ParseIP(drones[j].IP).To4()
+		if a == nil || b == nil {
+			return drones[i].IP < drones[j].IP
+		}
+		for k := range a {
+			if a[k] != b[k] {
+				return a[k] < b[k]
+			}
+		}
+		return false
+	})
+}
@@ -0,0 +1,265 @@
+package swarm
+
+import (
+	"context"
+	stderrors "errors"
+	"net"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/conceptcodes/dji-tello-sdk-go/pkg/sim"
+	"github.com/conceptcodes/dji-tello-sdk-go/pkg/types"
+)
+
+// freeUDPPort returns a local UDP port that is currently unused
+func freeUDPPort(t *testing.T) int {
+	t.Helper()
+	conn, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Failed to find free port: %v", err)
+	}
+	defer conn.Close()
+	return conn.LocalAddr().(*net.UDPAddr).Port
+}
+
+// startSimulators starts one simulator per loopback address, all on the same
+// command port like a room full of drones
+func startSimulators(t *testing.T, commandPort, statePort int, batteries map[string]int) {
+	t.Helper()
+	for ip, battery := range batteries {
+		cfg := sim.DefaultConfig()
+		cfg.Addr = net.JoinHostPort(ip, strconv.Itoa(commandPort))
+		cfg.StatePort = statePort
+		cfg.Speedup = 20
+		cfg.Battery = battery
+		cfg.SerialNumber = "SIM-" + ip
+
+		s, err := sim.New(cfg)
+		if err != nil {
+			t.Fatalf("Failed to create simulator: %v", err)
+		}
+		if err := s.Start(); err != nil {
+			t.Skipf("Cannot bind simulator on %s: %v", cfg.Addr, err)
+		}
+		t.Cleanup(s.Stop)
+	}
+}
+
+func testConfig(commandPort, statePort int) Config {
+	cfg := DefaultConfig()
+	cfg.Subnet = "127.0.0.0/29"
+	cfg.CommandPort = commandPort
+	cfg.LocalStateAddr = "127.0.0.1:" + strconv.Itoa(statePort)
+	cfg.DiscoveryTimeout = 300 * time.Millisecond
+	cfg.Transport = cfg.Transport.WithCommandSendDelay(0).WithCommandTimeout(2 * time.Second)
+	return cfg
+}
+
+func TestConfigValidate(t *testing.T) {
+	cfg := DefaultConfig()
+	if err := cfg.Validate(); err != nil {
+		t.Errorf("Expected default config to be valid, got %v", err)
+	}
+
+	tests := []struct {
+		name   string
+		modify func(*Config)
+	}{
+		{"invalid subnet", func(c *Config) { c.Subnet = "192.168.1.0" }},
+		{"IPv6 subnet", func(c *Config) { c.Subnet = "fd00::/120" }},
+		{"subnet too large", func(c *Config) { c.Subnet = "10.0.0.0/8" }},
+		{"invalid command port", func(c *Config) { c.CommandPort = 0 }},
+		{"invalid state address", func(c *Config) { c.LocalStateAddr = "8890" }},
+		{"zero discovery timeout", func(c *Config) { c.DiscoveryTimeout = 0 }},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := DefaultConfig()
+			tt.modify(&cfg)
+			if err := cfg.Validate(); err == nil {
+				t.Error("Expected validation error")
+			}
+		})
+	}
+}
+
+func TestSubnetHosts(t *testing.T) {
+	tests := []struct {
+		cidr  string
+		first string
+		last  string
+		count int
+	}{
+		{"192.168.1.0/24", "192.168.1.1", "192.168.1.254", 254},
+		{"10.0.0.8/30", "10.0.0.9", "10.0.0.10", 2},
+		{"10.0.0.8/31", "10.0.0.8", "10.0.0.9", 2},
+		{"10.0.0.8/32", "10.0.0.8", "10.0.0.8", 1},
+	}
+
+	for _, tt := range tests {
+		hosts, err := subnetHosts(tt.cidr)
+		if err != nil {
+			t.Fatalf("Unexpected error for %s: %v", tt.cidr, err)
+		}
+		if len(hosts) != tt.count {
+			t.Errorf("Expected %d hosts in %s, got %d", tt.count, tt.cidr, len(hosts))
+			continue
+		}
+		if hosts[0].String() != tt.first || hosts[len(hosts)-1].String() != tt.last {
+			t.Errorf("Expected %s-%s in %s, got %s-%s", tt.first, tt.last, tt.cidr, hosts[0], hosts[len(hosts)-1])
+		}
+	}
+}
+
+func TestFormationValidation(t *testing.T) {
+	s := &Swarm{bySN: map[string]*Drone{}}
+
+	tests := []struct {
+		name    string
+		speed   int
+		offsets map[string]Offset
+	}{
+		{"speed too low", 5, nil},
+		{"offset out of range", 50, map[string]Offset{"A": {X: 600}}},
+		{"offset too small", 50, map[string]Offset{"A": {X: 10, Y: -10, Z: 20}}},
+		{"unknown drone", 50, map[string]Offset{"B": {X: 100}}},
+	}
+
+	s.drones = []*Drone{{DroneInfo: DroneInfo{SerialNumber: "A"}}}
+	s.bySN["A"] = s.drones[0]
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := s.Formation(context.Background(), tt.speed, tt.offsets); err == nil {
+				t.Error("Expected validation error")
+			}
+		})
+	}
+}
+
+func TestGroupError(t *testing.T) {
+	cause := stderrors.New("no reply")
+	err := &GroupError{
+		Action: "takeoff",
+		Total:  3,
+		Errors: map[string]error{"B": cause, "A": cause},
+	}
+
+	if failed := err.Failed(); len(failed) != 2 || failed[0] != "A" || failed[1] != "B" {
+		t.Errorf("Expected failed drones [A B], got %v", failed)
+	}
+	if !stderrors.Is(err, cause) {
+		t.Error("Expected GroupError to unwrap to the per-drone error")
+	}
+	expected := "swarm takeoff failed on 2 of 3 drones: A: no reply; B: no reply"
+	if err.Error() != expected {
+		t.Errorf("Expected %q, got %q", expected, err.Error())
+	}
+}
+
+func TestNewStatePortInUse(t *testing.T) {
+	taken, err := net.ListenUDP("udp", &net.UDPAddr{IP: net.IPv4(127, 0, 0, 1)})
+	if err != nil {
+		t.Fatalf("Failed to bind a port: %v", err)
+	}
+	defer taken.Close()
+
+	cfg := testConfig(freeUDPPort(t), taken.LocalAddr().(*net.UDPAddr).Port)
+	s, err := New(cfg, []DroneInfo{{IP: "127.0.0.2", SerialNumber: "SIM-127.0.0.2"}})
+	if err == nil {
+		s.Shutdown()
+		t.Fatal("Expected an error when the state port is in use")
+	}
+}
+
+func TestSwarmWithSimulators(t *testing.T) {
+	commandPort, statePort := freeUDPPort(t), freeUDPPort(t)
+	startSimulators(t, commandPort, statePort, map[string]int{
+		"127.0.0.2": 90,
+		"127.0.0.3": 60,
+	})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Second)
+	defer cancel()
+
+	s, err := Connect(ctx, testConfig(commandPort, statePort))
+	if err != nil {
+		t.Fatalf("Failed to connect to swarm: %v", err)
+	}
+	defer s.Shutdown()
+
+	if s.Size() != 2 {
+		t.Fatalf("Expected 2 drones, got %d", s.Size())
+	}
+	drones := s.Drones()
+	if drones[0].IP != "127.0.0.2" || drones[0].SerialNumber != "SIM-127.0.0.2" {
+		t.Errorf("Expected first drone SIM-127.0.0.2 at 127.0.0.2, got %s at %s", drones[0].SerialNumber, drones[0].IP)
+	}
+
+	// Moving before takeoff fails on every drone
+	err = s.Formation(ctx, 50, map[string]Offset{
+		"SIM-127.0.0.2": {X: 100},
+		"SIM-127.0.0.3": {X: 100},
+	})
+	var groupErr *GroupError
+	if !stderrors.As(err, &groupErr) || len(groupErr.Errors) != 2 {
+		t.Fatalf("Expected a GroupError for both grounded drones, got %v", err)
+	}
+
+	if err := s.TakeOff(ctx); err != nil {
+		t.Fatalf("Failed to take off: %v", err)
+	}
+
+	// State packets are routed by source IP
+	for _, drone := range drones {
+		expected := map[string]int{"127.0.0.2": 90, "127.0.0.3": 60}[drone.IP]
+		state := waitForState(t, drone, func(s *types.State) bool { return s.H == 80 })
+		if state.Bat > expected || state.Bat < expected-2 {
+			t.Errorf("Expected battery near %d for %s, got %d", expected, drone.SerialNumber, state.Bat)
+		}
+	}
+
+	// Only the first drone moves; the second holds position
+	if err := s.Formation(ctx, 100, map[string]Offset{"SIM-127.0.0.2": {X: 100, Z: 50}}); err != nil {
+		t.Fatalf("Formation failed: %v", err)
+	}
+	waitForState(t, drones[0], func(s *types.State) bool { return s.H == 130 })
+
+	// A fire-and-forget command through the drone's own commander completes
+	// before the barrier returns
+	if err := drones[1].Commander.Up(20); err != nil {
+		t.Fatalf("Failed to enqueue up: %v", err)
+	}
+	if err := s.Barrier(ctx); err != nil {
+		t.Fatalf("Barrier failed: %v", err)
+	}
+	height, err := drones[1].Commander.GetHeight()
+	if err != nil || height != 100 {
+		t.Errorf("Expected second drone at 100cm after the barrier, got %d (%v)", height, err)
+	}
+
+	if err := s.Land(ctx); err != nil {
+		t.Errorf("Failed to land: %v", err)
+	}
+}
+
+func waitForState(t *testing.T, d *Drone, match func(*types.State) bool) *types.State {
+	t.Helper()
+	timeout := time.After(5 * time.Second)
+	for {
+		select {
+		case state, ok := <-d.Commander.GetStateChannel():
+			if !ok {
+				t.Fatalf("State channel for %s closed", d.SerialNumber)
+			}
+			if match(state) {
+				return state
+			}
+		case <-timeout:
+			t.Fatalf("Timed out waiting for state from %s", d.SerialNumber)
+			return nil
+		}
+	}
+}
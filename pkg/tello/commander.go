@@ -3,6 +3,7 @@ package tello
 import (
 	"context"
 	"fmt"
+	"image"
 	"strings"
 	"sync"
 	"time"
@@ -10,8 +11,8 @@ import (
 	"github.com/conceptcodes/dji-tello-sdk-go/pkg/config"
 	"github.com/conceptcodes/dji-tello-sdk-go/pkg/errors"
 	"github.com/conceptcodes/dji-tello-sdk-go/pkg/transport"
+	"github.com/conceptcodes/dji-tello-sdk-go/pkg/types"
 	"github.com/conceptcodes/dji-tello-sdk-go/pkg/utils"
-	"golang.org/x/sync/errgroup"
 )
 
 type FlipDirection string
@@ -23,12 +24,39 @@ const (
 	FlipBackward FlipDirection = "b"
 )
 
+// MissionPadDirection selects which camera is used for mission pad detection
+type MissionPadDirection int
+
+const (
+	MissionPadDownward MissionPadDirection = 0 // Detect pads with the downward camera only (20Hz)
+	MissionPadForward  MissionPadDirection = 1 // Detect pads with the forward camera only (20Hz)
+	MissionPadBoth     MissionPadDirection = 2 // Detect pads with both cameras (10Hz each)
+)
+
+const (
+	// urgentReplyTimeout is how long EmergencyNow and StopNow wait for each reply
+	urgentReplyTimeout = time.Second
+
+	// landReplyTimeout is how long LandNow waits for each reply, which the
+	// drone only sends once it is down
+	landReplyTimeout = 10 * time.Second
+)
+
+// Mission pad IDs accepted in addition to the numbered pads m1-m8
+const (
+	MissionPadRandom  = -1 // Any detected pad (m-1)
+	MissionPadNearest = -2 // The nearest detected pad (m-2)
+)
+
 type telloCommander struct {
 	commandClient       CommandConnection
 	commandQueue        *PriorityCommandQueue
 	stateListener       *transport.StateListener
 	videoStreamListener *transport.VideoStreamListener
 	videoFrameCallback  VideoFrameCallback
+	recorder            FlightRecorder
+	telemetryHub        *transport.TelemetryHub
+	link                linkWatchdog
 	ctx                 context.Context
 	cancel              context.CancelFunc
 	wg                  sync.WaitGroup
@@ -37,9 +65,35 @@ type telloCommander struct {
 // CommandConnection interface for command sending
 type CommandConnection interface {
 	SendCommand(command string) (string, error)
+	// SendUrgent sends a command ahead of the one in progress, which gives up
+	// waiting for its reply
+	SendUrgent(command string, replyTimeout time.Duration) (string, error)
 	Close() error
 }
 
+// FlightRecorder receives the telemetry and command traffic of a flight, e.g.
+// to write it to a black-box log (see pkg/flightlog). Methods are called from
+// the listener and command goroutines and must not block.
+type FlightRecorder interface {
+	RecordState(state *types.State)
+	RecordCommand(command, response string, err error, latency time.Duration)
+}
+
+// flightRecorders passes everything on to several recorders in order
+type flightRecorders []FlightRecorder
+
+func (r flightRecorders) RecordState(state *types.State) {
+	for _, recorder := range r {
+		recorder.RecordState(state)
+	}
+}
+
+func (r flightRecorders) RecordCommand(command, response string, err error, latency time.Duration) {
+	for _, recorder := range r {
+		recorder.RecordCommand(command, response, err, latency)
+	}
+}
+
 // VideoFrameCallback is called when a new video frame is received
 type VideoFrameCallback func(frame transport.VideoFrame)
 
@@ -63,11 +117,55 @@ type TelloCommander interface {
 	Go(x, y, z, speed int) error                   // Fly to a certain position (x, y, z) with a certain speed (cm/s)
 	Curve(x1, y1, z1, x2, y2, z2, speed int) error // Fly in a curve to a certain position (x1, y1, z1) and (x2, y2, z2) with a certain speed (cm/s)
 
+	// Mission Pad Commands (Tello EDU)
+	EnableMissionPads(enabled bool) error                       // Enable or disable mission pad detection (mon/moff)
+	SetMissionPadDirection(direction MissionPadDirection) error // Select the camera(s) used for pad detection
+	GoToPad(x, y, z, speed, mid int) error                      // Fly to (x, y, z) in the coordinate frame of pad mid
+	CurveToPad(x1, y1, z1, x2, y2, z2, speed, mid int) error    // Fly a curve through (x1, y1, z1) to (x2, y2, z2) in the frame of pad mid
+	JumpBetweenPads(x, y, z, speed, yaw, mid1, mid2 int) error  // Fly to (x, y, z) over mid1, then to the same position over mid2 and turn to yaw
+
+	// Preemptive Commands
+	// These skip the command queue: queued control commands are dropped, the
+	// command waiting for its reply gives up, and theirs is sent straight away
+	// with its own retries.
+	EmergencyNow() error // Stop all motors immediately
+	LandNow() error      // Land immediately
+	StopNow() error      // Stop and hover in place
+
 	// Set Commands
 	SetSpeed(speed int) error                       // Set the speed of the drone (cm/s)
 	SetRcControl(a, b, c, d int) error              // Set the RC control values (a: left/right, b: forward/backward, c: up/down, d: yaw)
 	SetWiFiCredentials(ssid, password string) error // Set the WiFi credentials for the drone
 
+	// Awaitable Control Commands
+	// Each sends the same command as its counterpart above, then waits for the
+	// drone's reply, which for maneuvers arrives once they are done. The error
+	// is the drone's failure as an *errors.SDKError, or wraps ctx.Err() if ctx
+	// ends first. A command still queued when ctx ends is never sent.
+	TakeOffCtx(ctx context.Context) error
+	LandCtx(ctx context.Context) error
+	StreamOnCtx(ctx context.Context) error
+	StreamOffCtx(ctx context.Context) error
+	EmergencyCtx(ctx context.Context) error
+	UpCtx(ctx context.Context, distance int) error
+	DownCtx(ctx context.Context, distance int) error
+	LeftCtx(ctx context.Context, distance int) error
+	RightCtx(ctx context.Context, distance int) error
+	ForwardCtx(ctx context.Context, distance int) error
+	BackwardCtx(ctx context.Context, distance int) error
+	ClockwiseCtx(ctx context.Context, angle int) error
+	CounterClockwiseCtx(ctx context.Context, angle int) error
+	FlipCtx(ctx context.Context, direction FlipDirection) error
+	GoCtx(ctx context.Context, x, y, z, speed int) error
+	CurveCtx(ctx context.Context, x1, y1, z1, x2, y2, z2, speed int) error
+	EnableMissionPadsCtx(ctx context.Context, enabled bool) error
+	SetMissionPadDirectionCtx(ctx context.Context, direction MissionPadDirection) error
+	GoToPadCtx(ctx context.Context, x, y, z, speed, mid int) error
+	CurveToPadCtx(ctx context.Context, x1, y1, z1, x2, y2, z2, speed, mid int) error
+	JumpBetweenPadsCtx(ctx context.Context, x, y, z, speed, yaw, mid1, mid2 int) error
+	SetSpeedCtx(ctx context.Context, speed int) error
+	SetWiFiCredentialsCtx(ctx context.Context, ssid, password string) error
+
 	// Read Commands
 	GetSpeed() (int, error)                  // Get the current speed of the drone (cm/s)
 	GetBatteryPercentage() (int, error)      // Get the current battery percentage of the drone
@@ -80,8 +178,21 @@ type TelloCommander interface {
 	GetTof() (int, error)                    // Get the distance value from time of flight of the drone (cm)
 
 	// Video Commands
-	SetVideoFrameCallback(callback VideoFrameCallback) // Set callback for video frames
-	GetVideoFrameChannel() <-chan transport.VideoFrame // Get read-only channel for video frames
+	SetVideoFrameCallback(callback VideoFrameCallback)     // Set callback for video frames
+	GetVideoFrameChannel() <-chan transport.VideoFrame     // Get read-only channel for video frames
+	CaptureStill(ctx context.Context) (image.Image, error) // Wait for the next keyframe and decode it
+	TakePhoto(ctx context.Context, path string) error      // Capture a still and save it as a JPEG or PNG with the drone's state
+
+	// State Commands
+	GetStateChannel() <-chan *types.State     // Get read-only channel for telemetry states
+	GetTelemetryHub() *transport.TelemetryHub // Get the hub that broadcasts every state to its subscribers
+
+	// Link Watchdog
+	StartWatchdog(config WatchdogConfig)      // Send keepalives while idle and watch for the drone falling silent
+	StopWatchdog()                            // Stop the watchdog
+	SetLinkTimeout(timeout time.Duration)     // Change how long the drone may be silent before the link is lost
+	SetLinkCallback(callback func(LinkEvent)) // Set the function called when the link is lost or comes back
+	GetLinkStatus() LinkStatus                // Get the watchdog's view of the link
 
 	// Lifecycle Commands
 	Shutdown() error // Gracefully shutdown all components and clean up resources
@@ -93,12 +204,33 @@ func NewTelloCommander(
 	stateListener *transport.StateListener,
 	videoStreamListener *transport.VideoStreamListener,
 ) TelloCommander {
+	return newTelloCommander(commandClient, commandQueue, stateListener, videoStreamListener, nil)
+}
+
+func newTelloCommander(
+	commandClient CommandConnection,
+	commandQueue *PriorityCommandQueue,
+	stateListener *transport.StateListener,
+	videoStreamListener *transport.VideoStreamListener,
+	recorder FlightRecorder,
+) *telloCommander {
+	// Without a listener of its own, the hub stays empty
+	var hub *transport.TelemetryHub
+	if stateListener != nil {
+		hub = stateListener.Hub()
+	}
+	if hub == nil {
+		hub = transport.NewTelemetryHub()
+	}
+
 	ctx, cancel := context.WithCancel(context.Background())
 	tc := &telloCommander{
 		commandClient:       commandClient,
 		commandQueue:        commandQueue,
 		stateListener:       stateListener,
 		videoStreamListener: videoStreamListener,
+		recorder:            recorder,
+		telemetryHub:        hub,
 		ctx:                 ctx,
 		cancel:              cancel,
 	}
@@ -133,6 +265,17 @@ func (t *telloCommander) processCommandQueue() {
 			}
 		}
 
+		// Drop awaited commands whose caller has stopped waiting
+		if req.Context != nil && req.Context.Err() != nil {
+			utils.Logger.Debugf("Dropping cancelled command '%s'", req.Command)
+			req.ResponseChan <- CommandResponse{
+				Error: errors.WrapSDKError(req.Context.Err(), errors.ErrTimeout, "TelloCommander",
+					fmt.Sprintf("command '%s' cancelled before it was sent", req.Command)),
+			}
+			close(req.ResponseChan)
+			continue
+		}
+
 		respStr, err := t.sendCommand(req.Command)
 
 		// If there's a response channel, send the result back
@@ -152,10 +295,16 @@ func (t *telloCommander) processCommandQueue() {
 func (t *telloCommander) sendCommand(cmd string) (string, error) {
 	utils.Logger.Debugf("Sending command: %s", cmd)
 
+	start := time.Now()
+	t.markSent()
 	response, err := t.commandClient.SendCommand(cmd)
+	if t.recorder != nil {
+		t.recorder.RecordCommand(cmd, response, err, time.Since(start))
+	}
 	if err != nil {
 		return "", errors.CommandError(cmd, err)
 	}
+	t.markReplied()
 
 	respStr := string(response)
 	if respStr != "ok" && respStr != "OK" {
@@ -217,37 +366,161 @@ func (t *telloCommander) Init() error {
 	return nil
 }
 
+func (t *telloCommander) EmergencyNow() error {
+	return t.sendPreemptive("emergency", urgentReplyTimeout)
+}
+
+func (t *telloCommander) LandNow() error {
+	return t.sendPreemptive("land", landReplyTimeout)
+}
+
+func (t *telloCommander) StopNow() error {
+	return t.sendPreemptive("stop", urgentReplyTimeout)
+}
+
+// sendPreemptive flushes the queued control commands and sends cmd ahead of
+// the one in progress
+func (t *telloCommander) sendPreemptive(cmd string, replyTimeout time.Duration) error {
+	if dropped := t.commandQueue.FlushControl(); dropped > 0 {
+		utils.Logger.Warnf("Dropped %d queued control commands for '%s'", dropped, cmd)
+	}
+
+	utils.Logger.Debugf("Sending urgent command: %s", cmd)
+	start := time.Now()
+	t.markSent()
+	response, err := t.commandClient.SendUrgent(cmd, replyTimeout)
+	if t.recorder != nil {
+		t.recorder.RecordCommand(cmd, response, err, time.Since(start))
+	}
+	if err != nil {
+		return errors.CommandError(cmd, err)
+	}
+	t.markReplied()
+	if response != "ok" && response != "OK" {
+		return errors.NewSDKError(errors.ErrCommandFailed, "TelloCommander",
+			fmt.Sprintf("unexpected response to '%s': %s", cmd, response))
+	}
+	return nil
+}
+
+// dispatch sends a control command built by one of the command methods
+type dispatch func(cmd string) error
+
+// enqueue queues a control command without waiting for the reply
+func (t *telloCommander) enqueue(cmd string) error {
+	t.commandQueue.EnqueueControl(cmd)
+	return nil
+}
+
+// await returns a dispatch that queues a control command and waits for the
+// drone to reply
+func (t *telloCommander) await(ctx context.Context) dispatch {
+	return func(cmd string) error {
+		_, err := t.sendControlCommand(ctx, cmd)
+		return err
+	}
+}
+
+// sendControlCommand queues a control command behind those already queued
+// and waits for the reply. The drone answers movements once they are done,
+// so this also waits for the maneuver. If ctx ends before the command is
+// sent it is dropped from the queue.
+func (t *telloCommander) sendControlCommand(ctx context.Context, cmd string) (string, error) {
+	respChan := t.commandQueue.EnqueueControlContext(ctx, cmd)
+
+	select {
+	case resp := <-respChan:
+		if resp.Error != nil {
+			return "", resp.Error
+		}
+		if resp.Response != "ok" && resp.Response != "OK" {
+			return resp.Response, errors.NewSDKError(errors.ErrCommandFailed, "TelloCommander",
+				fmt.Sprintf("unexpected response to '%s': %s", cmd, resp.Response))
+		}
+		return resp.Response, nil
+	case <-ctx.Done():
+		return "", errors.WrapSDKError(ctx.Err(), errors.ErrTimeout, "TelloCommander",
+			fmt.Sprintf("stopped waiting for command '%s'", cmd))
+	case <-t.ctx.Done():
+		return "", errors.NewSDKError(errors.ErrCommandFailed, "TelloCommander",
+			fmt.Sprintf("commander shut down while waiting for command '%s'", cmd))
+	}
+}
+
 func (t *telloCommander) TakeOff() error {
+	return t.sendTakeOff(t.enqueue)
+}
+
+func (t *telloCommander) TakeOffCtx(ctx context.Context) error {
+	return t.sendTakeOff(t.await(ctx))
+}
+
+func (t *telloCommander) sendTakeOff(send dispatch) error {
 	utils.Logger.Debugf("Enqueuing Take off Command")
-	t.commandQueue.EnqueueControl("takeoff")
-	return nil
+	return send("takeoff")
 }
 
 func (t *telloCommander) Land() error {
+	return t.sendLand(t.enqueue)
+}
+
+func (t *telloCommander) LandCtx(ctx context.Context) error {
+	return t.sendLand(t.await(ctx))
+}
+
+func (t *telloCommander) sendLand(send dispatch) error {
 	utils.Logger.Debugf("Enqueuing Land Command")
-	t.commandQueue.EnqueueControl("land")
-	return nil
+	return send("land")
 }
 
 func (t *telloCommander) StreamOn() error {
+	return t.sendStreamOn(t.enqueue)
+}
+
+func (t *telloCommander) StreamOnCtx(ctx context.Context) error {
+	return t.sendStreamOn(t.await(ctx))
+}
+
+func (t *telloCommander) sendStreamOn(send dispatch) error {
 	utils.Logger.Debugf("Starting video stream")
-	t.commandQueue.EnqueueControl("streamon")
-	return nil
+	return send("streamon")
 }
 
 func (t *telloCommander) StreamOff() error {
+	return t.sendStreamOff(t.enqueue)
+}
+
+func (t *telloCommander) StreamOffCtx(ctx context.Context) error {
+	return t.sendStreamOff(t.await(ctx))
+}
+
+func (t *telloCommander) sendStreamOff(send dispatch) error {
 	utils.Logger.Debugf("Stopping video stream")
-	t.commandQueue.EnqueueControl("streamoff")
-	return nil
+	return send("streamoff")
 }
 
 func (t *telloCommander) Emergency() error {
+	return t.sendEmergency(t.enqueue)
+}
+
+func (t *telloCommander) EmergencyCtx(ctx context.Context) error {
+	return t.sendEmergency(t.await(ctx))
+}
+
+func (t *telloCommander) sendEmergency(send dispatch) error {
 	utils.Logger.Debugf("Emergency landing")
-	t.commandQueue.EnqueueControl("emergency")
-	return nil
+	return send("emergency")
 }
 
 func (t *telloCommander) Up(distance int) error {
+	return t.sendUp(t.enqueue, distance)
+}
+
+func (t *telloCommander) UpCtx(ctx context.Context, distance int) error {
+	return t.sendUp(t.await(ctx), distance)
+}
+
+func (t *telloCommander) sendUp(send dispatch, distance int) error {
 	if err := utils.ValidateNumberInRange(distance, 20, 500); err != nil {
 		return err
 	}
@@ -255,11 +528,18 @@ func (t *telloCommander) Up(distance int) error {
 	utils.Logger.Debugf("Flying up %d cm", distance)
 	cmd := fmt.Sprintf("up %d", distance)
 
-	t.commandQueue.EnqueueControl(cmd)
-	return nil
+	return send(cmd)
 }
 
 func (t *telloCommander) Down(distance int) error {
+	return t.sendDown(t.enqueue, distance)
+}
+
+func (t *telloCommander) DownCtx(ctx context.Context, distance int) error {
+	return t.sendDown(t.await(ctx), distance)
+}
+
+func (t *telloCommander) sendDown(send dispatch, distance int) error {
 	if err := utils.ValidateNumberInRange(distance, 20, 500); err != nil {
 		return err
 	}
@@ -267,11 +547,18 @@ func (t *telloCommander) Down(distance int) error {
 	utils.Logger.Debugf("Flying down %d cm", distance)
 	cmd := fmt.Sprintf("down %d", distance)
 
-	t.commandQueue.EnqueueControl(cmd)
-	return nil
+	return send(cmd)
 }
 
 func (t *telloCommander) Left(distance int) error {
+	return t.sendLeft(t.enqueue, distance)
+}
+
+func (t *telloCommander) LeftCtx(ctx context.Context, distance int) error {
+	return t.sendLeft(t.await(ctx), distance)
+}
+
+func (t *telloCommander) sendLeft(send dispatch, distance int) error {
 	if err := utils.ValidateNumberInRange(distance, 20, 500); err != nil {
 		return err
 	}
@@ -279,11 +566,18 @@ func (t *telloCommander) Left(distance int) error {
 	utils.Logger.Debugf("Flying left %d cm", distance)
 	cmd := fmt.Sprintf("left %d", distance)
 
-	t.commandQueue.EnqueueControl(cmd)
-	return nil
+	return send(cmd)
 }
 
 func (t *telloCommander) Right(distance int) error {
+	return t.sendRight(t.enqueue, distance)
+}
+
+func (t *telloCommander) RightCtx(ctx context.Context, distance int) error {
+	return t.sendRight(t.await(ctx), distance)
+}
+
+func (t *telloCommander) sendRight(send dispatch, distance int) error {
 	if err := utils.ValidateNumberInRange(distance, 20, 500); err != nil {
 		return err
 	}
@@ -291,11 +585,18 @@ func (t *telloCommander) Right(distance int) error {
 	utils.Logger.Debugf("Flying right %d cm", distance)
 	cmd := fmt.Sprintf("right %d", distance)
 
-	t.commandQueue.EnqueueControl(cmd)
-	return nil
+	return send(cmd)
 }
 
 func (t *telloCommander) Forward(distance int) error {
+	return t.sendForward(t.enqueue, distance)
+}
+
+func (t *telloCommander) ForwardCtx(ctx context.Context, distance int) error {
+	return t.sendForward(t.await(ctx), distance)
+}
+
+func (t *telloCommander) sendForward(send dispatch, distance int) error {
 	if err := utils.ValidateNumberInRange(distance, 20, 500); err != nil {
 		return err
 	}
@@ -303,11 +604,18 @@ func (t *telloCommander) Forward(distance int) error {
 	utils.Logger.Debugf("Flying forward %d cm", distance)
 	cmd := fmt.Sprintf("forward %d", distance)
 
-	t.commandQueue.EnqueueControl(cmd)
-	return nil
+	return send(cmd)
 }
 
 func (t *telloCommander) Backward(distance int) error {
+	return t.sendBackward(t.enqueue, distance)
+}
+
+func (t *telloCommander) BackwardCtx(ctx context.Context, distance int) error {
+	return t.sendBackward(t.await(ctx), distance)
+}
+
+func (t *telloCommander) sendBackward(send dispatch, distance int) error {
 	if err := utils.ValidateNumberInRange(distance, 20, 500); err != nil {
 		return err
 	}
@@ -315,11 +623,18 @@ func (t *telloCommander) Backward(distance int) error {
 	utils.Logger.Debugf("Flying backward %d cm", distance)
 	cmd := fmt.Sprintf("back %d", distance)
 
-	t.commandQueue.EnqueueControl(cmd)
-	return nil
+	return send(cmd)
 }
 
 func (t *telloCommander) Clockwise(angle int) error {
+	return t.sendClockwise(t.enqueue, angle)
+}
+
+func (t *telloCommander) ClockwiseCtx(ctx context.Context, angle int) error {
+	return t.sendClockwise(t.await(ctx), angle)
+}
+
+func (t *telloCommander) sendClockwise(send dispatch, angle int) error {
 	if err := utils.ValidateNumberInRange(angle, 1, 3600); err != nil {
 		return err
 	}
@@ -327,11 +642,18 @@ func (t *telloCommander) Clockwise(angle int) error {
 	utils.Logger.Debugf("Rotating clockwise %d degrees", angle)
 	cmd := fmt.Sprintf("cw %d", angle)
 
-	t.commandQueue.EnqueueControl(cmd)
-	return nil
+	return send(cmd)
 }
 
 func (t *telloCommander) CounterClockwise(angle int) error {
+	return t.sendCounterClockwise(t.enqueue, angle)
+}
+
+func (t *telloCommander) CounterClockwiseCtx(ctx context.Context, angle int) error {
+	return t.sendCounterClockwise(t.await(ctx), angle)
+}
+
+func (t *telloCommander) sendCounterClockwise(send dispatch, angle int) error {
 	if err := utils.ValidateNumberInRange(angle, 1, 3600); err != nil {
 		return err
 	}
@@ -339,26 +661,35 @@ func (t *telloCommander) CounterClockwise(angle int) error {
 	utils.Logger.Debugf("Rotating counter-clockwise %d degrees", angle)
 	cmd := fmt.Sprintf("ccw %d", angle)
 
-	t.commandQueue.EnqueueControl(cmd)
-	return nil
+	return send(cmd)
 }
 
 func (t *telloCommander) Flip(direction FlipDirection) error {
+	return t.sendFlip(t.enqueue, direction)
+}
+
+func (t *telloCommander) FlipCtx(ctx context.Context, direction FlipDirection) error {
+	return t.sendFlip(t.await(ctx), direction)
+}
+
+func (t *telloCommander) sendFlip(send dispatch, direction FlipDirection) error {
 	utils.Logger.Debugf("Flipping %s", direction)
 	cmd := fmt.Sprintf("flip %s", direction)
 
-	t.commandQueue.EnqueueControl(cmd)
-	return nil
+	return send(cmd)
 }
 
 func (t *telloCommander) Go(x, y, z, speed int) error {
-	if err := utils.ValidateNumberInRange(x, 20, 500); err != nil {
-		return err
-	}
-	if err := utils.ValidateNumberInRange(y, 20, 500); err != nil {
-		return err
-	}
-	if err := utils.ValidateNumberInRange(z, 20, 500); err != nil {
+	return t.sendGo(t.enqueue, x, y, z, speed)
+}
+
+func (t *telloCommander) GoCtx(ctx context.Context, x, y, z, speed int) error {
+	return t.sendGo(t.await(ctx), x, y, z, speed)
+}
+
+func (t *telloCommander) sendGo(send dispatch, x, y, z, speed int) error {
+	// The target is relative to the drone, so negative offsets are valid
+	if err := validateCoordinates(x, y, z); err != nil {
 		return err
 	}
 	if err := utils.ValidateNumberInRange(speed, 10, 100); err != nil {
@@ -368,11 +699,18 @@ func (t *telloCommander) Go(x, y, z, speed int) error {
 	utils.Logger.Debugf("Flying to (%d, %d, %d) with speed %d", x, y, z, speed)
 	cmd := fmt.Sprintf("go %d %d %d %d", x, y, z, speed)
 
-	t.commandQueue.EnqueueControl(cmd)
-	return nil
+	return send(cmd)
 }
 
 func (t *telloCommander) Curve(x1, y1, z1, x2, y2, z2, speed int) error {
+	return t.sendCurve(t.enqueue, x1, y1, z1, x2, y2, z2, speed)
+}
+
+func (t *telloCommander) CurveCtx(ctx context.Context, x1, y1, z1, x2, y2, z2, speed int) error {
+	return t.sendCurve(t.await(ctx), x1, y1, z1, x2, y2, z2, speed)
+}
+
+func (t *telloCommander) sendCurve(send dispatch, x1, y1, z1, x2, y2, z2, speed int) error {
 	if err := utils.ValidateNumberInRange(x1, 20, 500); err != nil {
 		return err
 	}
@@ -412,11 +750,174 @@ func (t *telloCommander) Curve(x1, y1, z1, x2, y2, z2, speed int) error {
 	utils.Logger.Debugf("Flying in a curve to (%d, %d, %d) and (%d, %d, %d) with speed %d", x1, y1, z1, x2, y2, z2, speed)
 	cmd := fmt.Sprintf("curve %d %d %d %d %d %d %d", x1, y1, z1, x2, y2, z2, speed)
 
-	t.commandQueue.EnqueueControl(cmd)
+	return send(cmd)
+}
+
+func (t *telloCommander) EnableMissionPads(enabled bool) error {
+	return t.sendEnableMissionPads(t.enqueue, enabled)
+}
+
+func (t *telloCommander) EnableMissionPadsCtx(ctx context.Context, enabled bool) error {
+	return t.sendEnableMissionPads(t.await(ctx), enabled)
+}
+
+func (t *telloCommander) sendEnableMissionPads(send dispatch, enabled bool) error {
+	cmd := "moff"
+	if enabled {
+		cmd = "mon"
+	}
+
+	utils.Logger.Debugf("Setting mission pad detection: %s", cmd)
+	return send(cmd)
+}
+
+func (t *telloCommander) SetMissionPadDirection(direction MissionPadDirection) error {
+	return t.sendSetMissionPadDirection(t.enqueue, direction)
+}
+
+func (t *telloCommander) SetMissionPadDirectionCtx(ctx context.Context, direction MissionPadDirection) error {
+	return t.sendSetMissionPadDirection(t.await(ctx), direction)
+}
+
+func (t *telloCommander) sendSetMissionPadDirection(send dispatch, direction MissionPadDirection) error {
+	if err := utils.ValidateNumberInRange(int(direction), int(MissionPadDownward), int(MissionPadBoth)); err != nil {
+		return err
+	}
+
+	utils.Logger.Debugf("Setting mission pad detection direction to %d", direction)
+	cmd := fmt.Sprintf("mdirection %d", direction)
+
+	return send(cmd)
+}
+
+func (t *telloCommander) GoToPad(x, y, z, speed, mid int) error {
+	return t.sendGoToPad(t.enqueue, x, y, z, speed, mid)
+}
+
+func (t *telloCommander) GoToPadCtx(ctx context.Context, x, y, z, speed, mid int) error {
+	return t.sendGoToPad(t.await(ctx), x, y, z, speed, mid)
+}
+
+func (t *telloCommander) sendGoToPad(send dispatch, x, y, z, speed, mid int) error {
+	if err := validateCoordinates(x, y, z); err != nil {
+		return err
+	}
+	if err := utils.ValidateNumberInRange(speed, 10, 100); err != nil {
+		return err
+	}
+	pad, err := missionPadID(mid)
+	if err != nil {
+		return err
+	}
+
+	utils.Logger.Debugf("Flying to (%d, %d, %d) relative to pad %s with speed %d", x, y, z, pad, speed)
+	cmd := fmt.Sprintf("go %d %d %d %d %s", x, y, z, speed, pad)
+
+	return send(cmd)
+}
+
+func (t *telloCommander) CurveToPad(x1, y1, z1, x2, y2, z2, speed, mid int) error {
+	return t.sendCurveToPad(t.enqueue, x1, y1, z1, x2, y2, z2, speed, mid)
+}
+
+func (t *telloCommander) CurveToPadCtx(ctx context.Context, x1, y1, z1, x2, y2, z2, speed, mid int) error {
+	return t.sendCurveToPad(t.await(ctx), x1, y1, z1, x2, y2, z2, speed, mid)
+}
+
+func (t *telloCommander) sendCurveToPad(send dispatch, x1, y1, z1, x2, y2, z2, speed, mid int) error {
+	if err := validateCoordinates(x1, y1, z1); err != nil {
+		return err
+	}
+	if err := validateCoordinates(x2, y2, z2); err != nil {
+		return err
+	}
+	if err := utils.ValidateNumberInRange(speed, 10, 60); err != nil {
+		return err
+	}
+	pad, err := missionPadID(mid)
+	if err != nil {
+		return err
+	}
+
+	utils.Logger.Debugf("Flying in a curve to (%d, %d, %d) and (%d, %d, %d) relative to pad %s with speed %d",
+		x1, y1, z1, x2, y2, z2, pad, speed)
+	cmd := fmt.Sprintf("curve %d %d %d %d %d %d %d %s", x1, y1, z1, x2, y2, z2, speed, pad)
+
+	return send(cmd)
+}
+
+func (t *telloCommander) JumpBetweenPads(x, y, z, speed, yaw, mid1, mid2 int) error {
+	return t.sendJumpBetweenPads(t.enqueue, x, y, z, speed, yaw, mid1, mid2)
+}
+
+func (t *telloCommander) JumpBetweenPadsCtx(ctx context.Context, x, y, z, speed, yaw, mid1, mid2 int) error {
+	return t.sendJumpBetweenPads(t.await(ctx), x, y, z, speed, yaw, mid1, mid2)
+}
+
+func (t *telloCommander) sendJumpBetweenPads(send dispatch, x, y, z, speed, yaw, mid1, mid2 int) error {
+	if err := validateCoordinates(x, y, z); err != nil {
+		return err
+	}
+	if err := utils.ValidateNumberInRange(speed, 10, 100); err != nil {
+		return err
+	}
+	if err := utils.ValidateNumberInRange(yaw, -360, 360); err != nil {
+		return err
+	}
+	pad1, err := missionPadID(mid1)
+	if err != nil {
+		return err
+	}
+	pad2, err := missionPadID(mid2)
+	if err != nil {
+		return err
+	}
+
+	utils.Logger.Debugf("Jumping from pad %s to pad %s at (%d, %d, %d) with speed %d and yaw %d",
+		pad1, pad2, x, y, z, speed, yaw)
+	cmd := fmt.Sprintf("jump %d %d %d %d %d %s %s", x, y, z, speed, yaw, pad1, pad2)
+
+	return send(cmd)
+}
+
+// validateCoordinates checks a go, curve or mission pad target against the
+// SDK's ranges: each axis within -500 to 500 cm, and not all of them within
+// -20 to 20 cm at once
+func validateCoordinates(x, y, z int) error {
+	if err := utils.ValidateNumberInRange(x, -500, 500); err != nil {
+		return err
+	}
+	if err := utils.ValidateNumberInRange(y, -500, 500); err != nil {
+		return err
+	}
+	if err := utils.ValidateNumberInRange(z, -500, 500); err != nil {
+		return err
+	}
+	if (x >= -20 && x <= 20) && (y >= -20 && y <= 20) && (z >= -20 && z <= 20) {
+		return errors.InvalidArgumentError("TelloCommander", "coordinates",
+			"x, y, z cannot all be between -20 and 20 at the same time")
+	}
 	return nil
 }
 
+// missionPadID formats a pad number as the SDK's mission pad identifier
+func missionPadID(mid int) (string, error) {
+	if mid != MissionPadRandom && mid != MissionPadNearest && (mid < 1 || mid > 8) {
+		return "", errors.InvalidArgumentError("TelloCommander", "mission pad id",
+			fmt.Sprintf("must be 1-8, %d (random) or %d (nearest), got %d", MissionPadRandom, MissionPadNearest, mid))
+	}
+	return fmt.Sprintf("m%d", mid), nil
+}
+
 func (t *telloCommander) SetSpeed(speed int) error {
+	return t.sendSetSpeed(t.enqueue, speed)
+}
+
+func (t *telloCommander) SetSpeedCtx(ctx context.Context, speed int) error {
+	return t.sendSetSpeed(t.await(ctx), speed)
+}
+
+func (t *telloCommander) sendSetSpeed(send dispatch, speed int) error {
 	if err := utils.ValidateNumberInRange(speed, 10, 100); err != nil {
 		return err
 	}
@@ -424,8 +925,7 @@ func (t *telloCommander) SetSpeed(speed int) error {
 	utils.Logger.Debugf("Setting speed to %d cm/s", speed)
 	cmd := fmt.Sprintf("speed %d", speed)
 
-	t.commandQueue.EnqueueControl(cmd)
-	return nil
+	return send(cmd)
 }
 
 func (t *telloCommander) SetRcControl(a, b, c, d int) error {
@@ -450,6 +950,14 @@ func (t *telloCommander) SetRcControl(a, b, c, d int) error {
 }
 
 func (t *telloCommander) SetWiFiCredentials(ssid, password string) error {
+	return t.sendSetWiFiCredentials(t.enqueue, ssid, password)
+}
+
+func (t *telloCommander) SetWiFiCredentialsCtx(ctx context.Context, ssid, password string) error {
+	return t.sendSetWiFiCredentials(t.await(ctx), ssid, password)
+}
+
+func (t *telloCommander) sendSetWiFiCredentials(send dispatch, ssid, password string) error {
 	if err := utils.ValidateNumberInRange(len(ssid), 1, 32); err != nil {
 		return errors.InvalidArgumentError("TelloCommander", "ssid",
 			"must be greater than 1 and less than 32 chars")
@@ -462,8 +970,7 @@ func (t *telloCommander) SetWiFiCredentials(ssid, password string) error {
 	utils.Logger.Debugf("Setting WiFi credentials to SSID: %s, Password: %s", ssid, password)
 	cmd := fmt.Sprintf("wifi %s %s", ssid, password)
 
-	t.commandQueue.EnqueueControl(cmd)
-	return nil
+	return send(cmd)
 }
 
 func (t *telloCommander) GetSpeed() (int, error) {
@@ -630,6 +1137,79 @@ func (t *telloCommander) GetVideoFrameChannel() <-chan transport.VideoFrame {
 	return nil
 }
 
+// CaptureStill waits for the next keyframe on the video stream and decodes
+// it. The stream must have been started with StreamOn. Frames are not taken
+// from the frame channel, so recording and display carry on.
+func (t *telloCommander) CaptureStill(ctx context.Context) (image.Image, error) {
+	frame, err := t.nextKeyFrame(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return t.decodeStill(frame)
+}
+
+// TakePhoto captures a still and saves it to path as a JPEG or PNG, chosen by
+// the extension, with the height, yaw and battery of the state received when
+// the picture arrived
+func (t *telloCommander) TakePhoto(ctx context.Context, path string) error {
+	if _, err := transport.PhotoFormatFromPath(path); err != nil {
+		return errors.InvalidArgumentError("TelloCommander", "path", err.Error())
+	}
+
+	frame, err := t.nextKeyFrame(ctx)
+	if err != nil {
+		return err
+	}
+	meta := transport.PhotoMetadata{Taken: frame.Timestamp}
+	if t.telemetryHub != nil {
+		meta.State = t.telemetryHub.Latest()
+	}
+
+	img, err := t.decodeStill(frame)
+	if err != nil {
+		return err
+	}
+	if err := transport.SavePhoto(path, img, meta); err != nil {
+		return errors.WrapSDKError(err, errors.ErrVideoStreamFailed, "TelloCommander", "failed to save photo")
+	}
+	return nil
+}
+
+func (t *telloCommander) nextKeyFrame(ctx context.Context) (transport.VideoFrame, error) {
+	if t.videoStreamListener == nil {
+		return transport.VideoFrame{}, errors.NewSDKError(errors.ErrVideoStreamFailed, "TelloCommander", "video stream listener is not available")
+	}
+	frame, err := t.videoStreamListener.NextKeyFrame(ctx)
+	if err != nil {
+		return transport.VideoFrame{}, errors.WrapSDKError(err, errors.ErrVideoStreamFailed, "TelloCommander", "no keyframe received")
+	}
+	return frame, nil
+}
+
+func (t *telloCommander) decodeStill(frame transport.VideoFrame) (image.Image, error) {
+	img, err := transport.DecodeKeyFrame(frame)
+	if err != nil {
+		return nil, errors.WrapSDKError(err, errors.ErrVideoDecode, "TelloCommander", "failed to decode still")
+	}
+	return img, nil
+}
+
+// GetStateChannel returns a read-only channel for receiving telemetry states.
+// There is only one such channel; consumers that each need every state
+// should subscribe to GetTelemetryHub instead.
+func (t *telloCommander) GetStateChannel() <-chan *types.State {
+	if t.stateListener != nil {
+		return t.stateListener.GetStateChannel()
+	}
+	return nil
+}
+
+// GetTelemetryHub returns the hub that broadcasts every state received from
+// the drone, for any number of subscribers
+func (t *telloCommander) GetTelemetryHub() *transport.TelemetryHub {
+	return t.telemetryHub
+}
+
 // Initialize creates and configures a new TelloCommander with all necessary components
 func Initialize() (TelloCommander, error) {
 	return InitializeWithInit(true)
@@ -661,6 +1241,7 @@ func InitializeWithInit(autoInit bool) (TelloCommander, error) {
 	}
 
 	commander := NewTelloCommander(commandClient, commandQueue, stateListener, videoStreamListener)
+	commander.StartWatchdog(DefaultWatchdogConfig())
 
 	go func() {
 		if err := stateListener.Start(); err != nil {
@@ -691,6 +1272,8 @@ type InitializeOptions struct {
 	SafetyConfigPath string
 	SafetyPreset     string
 	SafetyEnabled    bool
+	FlightRecorders  []FlightRecorder
+	Watchdog         *WatchdogConfig // nil disables the link watchdog
 }
 
 // WithTransportConfig specifies custom transport configuration
@@ -721,16 +1304,36 @@ func WithSafetyDisabled() func(*InitializeOptions) {
 	}
 }
 
+// WithFlightRecorder records every state and command of the flight. It can
+// be given several times to attach several recorders.
+func WithFlightRecorder(recorder FlightRecorder) func(*InitializeOptions) {
+	return func(opts *InitializeOptions) {
+		opts.FlightRecorders = append(opts.FlightRecorders, recorder)
+	}
+}
+
+// WithWatchdog configures the link watchdog (default: DefaultWatchdogConfig())
+func WithWatchdog(cfg WatchdogConfig) func(*InitializeOptions) {
+	return func(opts *InitializeOptions) {
+		opts.Watchdog = &cfg
+	}
+}
+
+// WithWatchdogDisabled disables the link watchdog and its keepalives
+func WithWatchdogDisabled() func(*InitializeOptions) {
+	return func(opts *InitializeOptions) {
+		opts.Watchdog = nil
+	}
+}
+
 // InitializeWithOptions creates and configures a new TelloCommander with safety options
 func InitializeWithOptions(opts ...func(*InitializeOptions)) (TelloCommander, error) {
-	// Create context for listener startup
-	ctx, cancel := context.WithCancel(context.Background())
-	defer cancel()
-
 	// Apply default options
+	watchdog := DefaultWatchdogConfig()
 	options := &InitializeOptions{
 		TransportConfig: config.DefaultTransportConfig(),
 		SafetyEnabled:   true, // Enable safety by default
+		Watchdog:        &watchdog,
 	}
 
 	// Apply provided options
@@ -764,7 +1367,34 @@ func InitializeWithOptions(opts ...func(*InitializeOptions)) (TelloCommander, er
 		return nil, errors.ConnectionError("TelloCommander", "create video stream listener", err)
 	}
 
-	commander := NewTelloCommander(commandClient, commandQueue, stateListener, videoStreamListener)
+	// Bind the listeners' ports now, so that a port in use fails here rather
+	// than in the background
+	if err := stateListener.Listen(); err != nil {
+		commandClient.Close()
+		return nil, errors.ConnectionError("TelloCommander", "start state listener", err)
+	}
+	if err := videoStreamListener.Listen(); err != nil {
+		stateListener.Stop()
+		commandClient.Close()
+		return nil, errors.ConnectionError("TelloCommander", "start video stream listener", err)
+	}
+
+	var recorder FlightRecorder
+	switch len(options.FlightRecorders) {
+	case 0:
+	case 1:
+		recorder = options.FlightRecorders[0]
+	default:
+		recorder = flightRecorders(options.FlightRecorders)
+	}
+	if recorder != nil {
+		stateListener.SetStateObserver(recorder.RecordState)
+	}
+
+	commander := newTelloCommander(commandClient, commandQueue, stateListener, videoStreamListener, recorder)
+	if options.Watchdog != nil {
+		commander.StartWatchdog(*options.Watchdog)
+	}
 
 	// Note: Safety manager wrapping should be done by the caller using the safety package
 	// This avoids circular imports between pkg/tello and pkg/safety
@@ -773,27 +1403,18 @@ func InitializeWithOptions(opts ...func(*InitializeOptions)) (TelloCommander, er
 		utils.Logger.Infof("Safety config: %s", getSafetyConfigName(options))
 	}
 
-	// Start listeners with error handling using errgroup
-	g, ctx := errgroup.WithContext(ctx)
-
-	g.Go(func() error {
-		if err := stateListener.Start(); err != nil {
-			return errors.ConnectionError("TelloCommander", "start state listener", err)
+	// Listeners block while serving, so run them in the background
+	go func() {
+		if err := stateListener.Serve(); err != nil {
+			utils.Logger.Errorf("State listener failed: %v", err)
 		}
-		return nil
-	})
+	}()
 
-	g.Go(func() error {
-		if err := videoStreamListener.Start(); err != nil {
-			return errors.ConnectionError("TelloCommander", "start video stream listener", err)
+	go func() {
+		if err := videoStreamListener.Serve(); err != nil {
+			utils.Logger.Errorf("Video stream listener failed: %v", err)
 		}
-		return nil
-	})
-
-	// Wait for listener startup and return first error if any
-	if err := g.Wait(); err != nil {
-		return nil, err
-	}
+	}()
 
 	utils.Logger.Info("Tello SDK initialized successfully")
 	return commander, nil
@@ -821,6 +1442,9 @@ func (t *telloCommander) Shutdown() error {
 	if t.videoStreamListener != nil {
 		t.videoStreamListener.Stop()
 	}
+	if t.telemetryHub != nil {
+		t.telemetryHub.Close()
+	}
 
 	// Close command connection
 	if t.commandClient != nil {
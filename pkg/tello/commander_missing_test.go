@@ -68,13 +68,31 @@ func TestGoCommand(t *testing.T) {
 		}
 	})
 
+	t.Run("go command with negative coordinates", func(t *testing.T) {
+		queue := NewPriorityCommandQueue()
+		commander := &telloCommander{
+			commandQueue: queue,
+		}
+
+		// Offsets are relative to the drone, so negative values fly back, right or down
+		err := commander.Go(-150, 0, -30, 50)
+		if err != nil {
+			t.Errorf("Expected no error, got %v", err)
+		}
+
+		req, ok := queue.Dequeue(context.Background())
+		if !ok || req.Command != "go -150 0 -30 50" {
+			t.Errorf("Expected 'go -150 0 -30 50' command, got '%s'", req.Command)
+		}
+	})
+
 	t.Run("go command with invalid x coordinate", func(t *testing.T) {
 		queue := NewPriorityCommandQueue()
 		commander := &telloCommander{
 			commandQueue: queue,
 		}
 
-		err := commander.Go(10, 200, 300, 50) // x = 10 is below minimum 20
+		err := commander.Go(-600, 200, 300, 50) // x = -600 is below minimum -500
 		if err == nil {
 			t.Error("Expected error for invalid x coordinate")
 		}
@@ -98,15 +116,15 @@ func TestGoCommand(t *testing.T) {
 		}
 	})
 
-	t.Run("go command with invalid z coordinate", func(t *testing.T) {
+	t.Run("go command with target too close", func(t *testing.T) {
 		queue := NewPriorityCommandQueue()
 		commander := &telloCommander{
 			commandQueue: queue,
 		}
 
-		err := commander.Go(100, 200, 10, 50) // z = 10 is below minimum 20
+		err := commander.Go(10, -20, 15, 50) // x, y and z are all within 20
 		if err == nil {
-			t.Error("Expected error for invalid z coordinate")
+			t.Error("Expected error for a target closer than 20 on every axis")
 		}
 		if queue.Size() != 0 {
 			t.Errorf("Expected queue size 0, got %d", queue.Size())
@@ -3,11 +3,15 @@ package tello
 import (
 	"context"
 	"errors"
+	"net"
 	"sync"
 	"testing"
 	"time"
 
+	"github.com/conceptcodes/dji-tello-sdk-go/pkg/config"
+	sdkerrors "github.com/conceptcodes/dji-tello-sdk-go/pkg/errors"
 	"github.com/conceptcodes/dji-tello-sdk-go/pkg/transport"
+	"github.com/conceptcodes/dji-tello-sdk-go/pkg/types"
 	"go.uber.org/goleak"
 )
 
@@ -49,6 +53,10 @@ func (m *MockCommandConnection) SendCommand(command string) (string, error) {
 	return "ok", nil
 }
 
+func (m *MockCommandConnection) SendUrgent(command string, replyTimeout time.Duration) (string, error) {
+	return m.SendCommand(command)
+}
+
 func (m *MockCommandConnection) GetSentCommands() []string {
 	return m.sentCommands
 }
@@ -85,6 +93,125 @@ func TestNewTelloCommander(t *testing.T) {
 	}
 }
 
+// freeUDPAddr returns a local address with a UDP port no one is bound to
+func freeUDPAddr(t *testing.T) string {
+	t.Helper()
+	conn, err := net.ListenUDP("udp", &net.UDPAddr{IP: net.IPv4(127, 0, 0, 1)})
+	if err != nil {
+		t.Fatalf("Failed to find a free port: %v", err)
+	}
+	defer conn.Close()
+	return conn.LocalAddr().String()
+}
+
+func TestInitializeWithOptionsPortInUse(t *testing.T) {
+	taken, err := net.ListenUDP("udp", &net.UDPAddr{IP: net.IPv4(127, 0, 0, 1)})
+	if err != nil {
+		t.Fatalf("Failed to bind a port: %v", err)
+	}
+	defer taken.Close()
+
+	for _, busy := range []string{"state", "video"} {
+		t.Run(busy, func(t *testing.T) {
+			cfg := config.DefaultTransportConfig()
+			cfg.DroneHost = freeUDPAddr(t)
+			cfg.LocalCommandAddr = freeUDPAddr(t)
+			cfg.LocalStateAddr = freeUDPAddr(t)
+			cfg.LocalVideoAddr = freeUDPAddr(t)
+			if busy == "state" {
+				cfg.LocalStateAddr = taken.LocalAddr().String()
+			} else {
+				cfg.LocalVideoAddr = taken.LocalAddr().String()
+			}
+
+			drone, err := InitializeWithOptions(WithTransportConfig(cfg), WithWatchdogDisabled())
+			if err == nil {
+				drone.Shutdown()
+				t.Fatal("Expected an error when a listener's port is in use")
+			}
+
+			// Nothing is left bound
+			for _, addr := range []string{cfg.LocalStateAddr, cfg.LocalVideoAddr} {
+				if addr == taken.LocalAddr().String() {
+					continue
+				}
+				udpAddr, _ := net.ResolveUDPAddr("udp", addr)
+				conn, err := net.ListenUDP("udp", udpAddr)
+				if err != nil {
+					t.Errorf("Expected %s to be released, got %v", addr, err)
+					continue
+				}
+				conn.Close()
+			}
+		})
+	}
+}
+
+func TestGetTelemetryHub(t *testing.T) {
+	listener, err := transport.NewStateListener("127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Failed to create state listener: %v", err)
+	}
+	commander := NewTelloCommander(NewMockCommandConnection(), NewPriorityCommandQueue(), listener, nil)
+	defer commander.Shutdown()
+
+	if commander.GetTelemetryHub() != listener.Hub() {
+		t.Error("Expected the commander to expose the state listener's hub")
+	}
+
+	// A commander without a state listener still has a hub to subscribe to
+	standalone := NewTelloCommander(NewMockCommandConnection(), NewPriorityCommandQueue(), nil, nil)
+	defer standalone.Shutdown()
+	if _, err := standalone.GetTelemetryHub().Subscribe(); err != nil {
+		t.Errorf("Expected to subscribe without a state listener, got %v", err)
+	}
+}
+
+func TestCaptureStill(t *testing.T) {
+	// Without a video stream listener there is nothing to capture
+	commander := createTestCommander(NewMockCommandConnection())
+	defer commander.Shutdown()
+	if _, err := commander.CaptureStill(context.Background()); !sdkerrors.Is(err, sdkerrors.ErrVideoStreamFailed) {
+		t.Errorf("Expected a video stream error, got %v", err)
+	}
+	if err := commander.TakePhoto(context.Background(), "photo.gif"); err == nil {
+		t.Error("Expected an error for an unsupported photo format")
+	}
+
+	listener, err := transport.NewVideoStreamListener("127.0.0.1:11161")
+	if err != nil {
+		t.Fatalf("Failed to create video stream listener: %v", err)
+	}
+	go listener.Start()
+	streaming := NewTelloCommander(NewMockCommandConnection(), NewPriorityCommandQueue(), nil, listener)
+	defer streaming.Shutdown()
+
+	// Keep sending a keyframe with a slice the decoder cannot read
+	conn, err := net.Dial("udp", "127.0.0.1:11161")
+	if err != nil {
+		t.Fatalf("Failed to dial video listener: %v", err)
+	}
+	defer conn.Close()
+	done := make(chan struct{})
+	defer close(done)
+	go func() {
+		for {
+			select {
+			case <-done:
+				return
+			case <-time.After(10 * time.Millisecond):
+				conn.Write([]byte{0x00, 0x00, 0x00, 0x01, 0x65, 0x88, 0x84, 0x00})
+			}
+		}
+	}()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+	if _, err := streaming.CaptureStill(ctx); !sdkerrors.Is(err, sdkerrors.ErrVideoDecode) {
+		t.Errorf("Expected the keyframe to be received and fail to decode, got %v", err)
+	}
+}
+
 // Read Command Tests
 func TestGetSpeed(t *testing.T) {
 	mockConn := NewMockCommandConnection()
@@ -440,6 +567,73 @@ func TestFlipCommand(t *testing.T) {
 	}
 }
 
+func TestMissionPadCommands(t *testing.T) {
+	queue := NewPriorityCommandQueue()
+
+	commander := &telloCommander{
+		commandQueue: queue,
+	}
+
+	tests := []struct {
+		name    string
+		call    func() error
+		command string
+	}{
+		{"EnableMissionPads", func() error { return commander.EnableMissionPads(true) }, "mon"},
+		{"DisableMissionPads", func() error { return commander.EnableMissionPads(false) }, "moff"},
+		{"SetMissionPadDirection", func() error { return commander.SetMissionPadDirection(MissionPadBoth) }, "mdirection 2"},
+		{"GoToPad", func() error { return commander.GoToPad(100, -50, 80, 40, 1) }, "go 100 -50 80 40 m1"},
+		{"GoToNearestPad", func() error { return commander.GoToPad(0, 0, 100, 20, MissionPadNearest) }, "go 0 0 100 20 m-2"},
+		{"CurveToPad", func() error { return commander.CurveToPad(50, 50, 80, 100, 0, 80, 30, 4) }, "curve 50 50 80 100 0 80 30 m4"},
+		{"JumpBetweenPads", func() error { return commander.JumpBetweenPads(0, 0, 100, 50, 90, 1, 2) }, "jump 0 0 100 50 90 m1 m2"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if err := test.call(); err != nil {
+				t.Errorf("Expected no error for %s, got %v", test.name, err)
+			}
+
+			req, ok := queue.Dequeue(context.Background())
+			if !ok || req.Command != test.command {
+				t.Errorf("Expected '%s' command for %s, got '%s'", test.command, test.name, req.Command)
+			}
+		})
+	}
+}
+
+func TestMissionPadCommandsValidation(t *testing.T) {
+	commander := &telloCommander{
+		commandQueue: NewPriorityCommandQueue(),
+	}
+
+	tests := []struct {
+		name string
+		call func() error
+	}{
+		{"DirectionOutOfRange", func() error { return commander.SetMissionPadDirection(3) }},
+		{"GoToPadInvalidPad", func() error { return commander.GoToPad(100, 0, 80, 40, 9) }},
+		{"GoToPadOutOfRange", func() error { return commander.GoToPad(600, 0, 80, 40, 1) }},
+		{"GoToPadTooClose", func() error { return commander.GoToPad(10, -10, 20, 40, 1) }},
+		{"GoToPadSpeedTooHigh", func() error { return commander.GoToPad(100, 0, 80, 101, 1) }},
+		{"CurveToPadSpeedTooHigh", func() error { return commander.CurveToPad(50, 50, 80, 100, 0, 80, 61, 1) }},
+		{"JumpYawOutOfRange", func() error { return commander.JumpBetweenPads(0, 0, 100, 50, 361, 1, 2) }},
+		{"JumpInvalidSecondPad", func() error { return commander.JumpBetweenPads(0, 0, 100, 50, 90, 1, 0) }},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if err := test.call(); err == nil {
+				t.Errorf("Expected validation error for %s", test.name)
+			}
+		})
+	}
+
+	if size := commander.commandQueue.Size(); size != 0 {
+		t.Errorf("Expected no commands to be queued, got %d", size)
+	}
+}
+
 func TestSetSpeed(t *testing.T) {
 	queue := NewPriorityCommandQueue()
 
@@ -572,6 +766,337 @@ func TestSendCommandUnexpectedResponse(t *testing.T) {
 	}
 }
 
+// recordedCommand is a command seen by mockFlightRecorder
+type recordedCommand struct {
+	command  string
+	response string
+	err      error
+	latency  time.Duration
+}
+
+// mockFlightRecorder collects the commands passed to it
+type mockFlightRecorder struct {
+	mu       sync.Mutex
+	commands []recordedCommand
+}
+
+func (m *mockFlightRecorder) RecordState(state *types.State) {}
+
+func (m *mockFlightRecorder) RecordCommand(command, response string, err error, latency time.Duration) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.commands = append(m.commands, recordedCommand{command, response, err, latency})
+}
+
+func TestSendCommandRecordsCommands(t *testing.T) {
+	mockConn := NewMockCommandConnection()
+	mockConn.SetResponse("battery?", "85")
+	mockConn.SetError("takeoff", errors.New("network error"))
+	recorder := &mockFlightRecorder{}
+
+	commander := &telloCommander{
+		commandClient: mockConn,
+		commandQueue:  NewPriorityCommandQueue(),
+		recorder:      recorder,
+	}
+
+	_, _ = commander.sendCommand("battery?")
+	_, _ = commander.sendCommand("takeoff")
+
+	if len(recorder.commands) != 2 {
+		t.Fatalf("Expected 2 recorded commands, got %d", len(recorder.commands))
+	}
+	if got := recorder.commands[0]; got.command != "battery?" || got.response != "85" || got.err != nil {
+		t.Errorf("Expected battery? -> 85, got %+v", got)
+	}
+	if got := recorder.commands[1]; got.command != "takeoff" || got.err == nil {
+		t.Errorf("Expected takeoff to be recorded with its error, got %+v", got)
+	}
+	if recorder.commands[0].latency < 0 {
+		t.Errorf("Expected non-negative latency, got %v", recorder.commands[0].latency)
+	}
+}
+
+// blockingCommandConnection holds every command until it is released or
+// preempted by an urgent command
+type blockingCommandConnection struct {
+	mu          sync.Mutex
+	sent        []string
+	started     chan string
+	release     chan struct{}
+	preempted   chan struct{}
+	preemptOnce sync.Once
+}
+
+func newBlockingCommandConnection() *blockingCommandConnection {
+	return &blockingCommandConnection{
+		started:   make(chan string, 10),
+		release:   make(chan struct{}),
+		preempted: make(chan struct{}),
+	}
+}
+
+func (b *blockingCommandConnection) SendCommand(command string) (string, error) {
+	b.mu.Lock()
+	b.sent = append(b.sent, command)
+	b.mu.Unlock()
+
+	b.started <- command
+	select {
+	case <-b.release:
+		return "ok", nil
+	case <-b.preempted:
+		return "", errors.New("preempted")
+	}
+}
+
+func (b *blockingCommandConnection) SendUrgent(command string, replyTimeout time.Duration) (string, error) {
+	b.preemptOnce.Do(func() { close(b.preempted) })
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.sent = append(b.sent, command)
+	return "ok", nil
+}
+
+func (b *blockingCommandConnection) Close() error {
+	return nil
+}
+
+func (b *blockingCommandConnection) sentCommands() []string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return append([]string(nil), b.sent...)
+}
+
+func TestControlCommandsCtx(t *testing.T) {
+	tests := []struct {
+		name         string
+		response     string
+		sendErr      error
+		send         func(ctx context.Context, c *telloCommander) error
+		command      string
+		expectedCode sdkerrors.ErrorCode
+	}{
+		{
+			name:    "Takeoff succeeds",
+			send:    func(ctx context.Context, c *telloCommander) error { return c.TakeOffCtx(ctx) },
+			command: "takeoff",
+		},
+		{
+			name:    "Go succeeds",
+			send:    func(ctx context.Context, c *telloCommander) error { return c.GoCtx(ctx, 50, -50, 0, 30) },
+			command: "go 50 -50 0 30",
+		},
+		{
+			name:         "Drone answers error",
+			response:     "error",
+			send:         func(ctx context.Context, c *telloCommander) error { return c.ForwardCtx(ctx, 100) },
+			command:      "forward 100",
+			expectedCode: sdkerrors.ErrCommandFailed,
+		},
+		{
+			name:         "Drone answers something else",
+			response:     "out of range",
+			send:         func(ctx context.Context, c *telloCommander) error { return c.UpCtx(ctx, 100) },
+			command:      "up 100",
+			expectedCode: sdkerrors.ErrCommandFailed,
+		},
+		{
+			name:         "Connection fails",
+			sendErr:      errors.New("network error"),
+			send:         func(ctx context.Context, c *telloCommander) error { return c.LandCtx(ctx) },
+			command:      "land",
+			expectedCode: sdkerrors.ErrCommandFailed,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockConn := NewMockCommandConnection()
+			if tt.response != "" {
+				mockConn.SetResponse(tt.command, tt.response)
+			}
+			if tt.sendErr != nil {
+				mockConn.SetError(tt.command, tt.sendErr)
+			}
+
+			commander := createTestCommander(mockConn)
+			defer commander.Shutdown()
+
+			err := tt.send(context.Background(), commander)
+			if tt.expectedCode == "" {
+				if err != nil {
+					t.Fatalf("Expected no error, got %v", err)
+				}
+			} else if !sdkerrors.Is(err, tt.expectedCode) {
+				t.Fatalf("Expected %s error, got %v", tt.expectedCode, err)
+			}
+
+			commands := mockConn.GetSentCommands()
+			if len(commands) != 1 || commands[0] != tt.command {
+				t.Errorf("Expected '%s' to be sent, got %v", tt.command, commands)
+			}
+		})
+	}
+}
+
+func TestControlCommandsCtxValidation(t *testing.T) {
+	commander := &telloCommander{commandQueue: NewPriorityCommandQueue()}
+
+	if err := commander.UpCtx(context.Background(), 10); err == nil {
+		t.Error("Expected error for out of range distance, got nil")
+	}
+	if !commander.commandQueue.IsEmpty() {
+		t.Error("Expected an invalid command not to be queued")
+	}
+}
+
+func TestControlCommandsCtxWaitsForReply(t *testing.T) {
+	conn := newBlockingCommandConnection()
+	commander := createTestCommander(conn)
+	defer commander.Shutdown()
+
+	done := make(chan error, 1)
+	go func() { done <- commander.TakeOffCtx(context.Background()) }()
+
+	if cmd := <-conn.started; cmd != "takeoff" {
+		t.Fatalf("Expected 'takeoff' to be sent, got '%s'", cmd)
+	}
+	select {
+	case err := <-done:
+		t.Fatalf("Expected TakeOffCtx to wait for the reply, returned %v", err)
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	close(conn.release)
+	select {
+	case err := <-done:
+		if err != nil {
+			t.Errorf("Expected no error, got %v", err)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("Expected TakeOffCtx to return after the reply")
+	}
+}
+
+func TestControlCommandsCtxCancellation(t *testing.T) {
+	conn := newBlockingCommandConnection()
+	commander := createTestCommander(conn)
+	defer commander.Shutdown()
+
+	// Hold the queue with a takeoff so that later commands stay queued
+	if err := commander.TakeOff(); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	<-conn.started
+
+	deadlineCtx, cancelDeadline := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancelDeadline()
+	err := commander.ForwardCtx(deadlineCtx, 100)
+	if !errors.Is(err, context.DeadlineExceeded) || !sdkerrors.Is(err, sdkerrors.ErrTimeout) {
+		t.Errorf("Expected a timeout wrapping the deadline, got %v", err)
+	}
+
+	cancelCtx, cancel := context.WithCancel(context.Background())
+	go func() {
+		time.Sleep(20 * time.Millisecond)
+		cancel()
+	}()
+	err = commander.BackwardCtx(cancelCtx, 100)
+	if !errors.Is(err, context.Canceled) {
+		t.Errorf("Expected an error wrapping the cancellation, got %v", err)
+	}
+
+	// Release the takeoff; the abandoned commands must not be sent
+	close(conn.release)
+	if err := commander.LandCtx(context.Background()); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	sent := conn.sentCommands()
+	if len(sent) != 2 || sent[0] != "takeoff" || sent[1] != "land" {
+		t.Errorf("Expected only takeoff and land to be sent, got %v", sent)
+	}
+}
+
+func TestPreemptiveCommands(t *testing.T) {
+	tests := []struct {
+		name    string
+		send    func(c *telloCommander) error
+		command string
+	}{
+		{"Emergency", func(c *telloCommander) error { return c.EmergencyNow() }, "emergency"},
+		{"Land", func(c *telloCommander) error { return c.LandNow() }, "land"},
+		{"Stop", func(c *telloCommander) error { return c.StopNow() }, "stop"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			conn := newBlockingCommandConnection()
+			recorder := &mockFlightRecorder{}
+			commander := createTestCommander(conn)
+			commander.recorder = recorder
+			defer commander.Shutdown()
+
+			// A maneuver in progress with more queued behind it
+			maneuver := make(chan error, 1)
+			go func() { maneuver <- commander.GoCtx(context.Background(), 500, 0, 0, 10) }()
+			<-conn.started
+			queued := make(chan error, 1)
+			go func() { queued <- commander.ForwardCtx(context.Background(), 100) }()
+			commander.Right(100)
+			for commander.commandQueue.LowPrioritySize() < 2 {
+				time.Sleep(time.Millisecond)
+			}
+
+			if err := tt.send(commander); err != nil {
+				t.Fatalf("Expected no error, got %v", err)
+			}
+
+			for name, ch := range map[string]chan error{"maneuver": maneuver, "queued command": queued} {
+				select {
+				case err := <-ch:
+					if err == nil {
+						t.Errorf("Expected the %s to fail", name)
+					}
+				case <-time.After(time.Second):
+					t.Fatalf("Expected the %s to return", name)
+				}
+			}
+			if !commander.commandQueue.IsEmpty() {
+				t.Errorf("Expected the queue to be flushed, %d left", commander.commandQueue.Size())
+			}
+
+			sent := conn.sentCommands()
+			if len(sent) != 2 || sent[0] != "go 500 0 0 10" || sent[1] != tt.command {
+				t.Errorf("Expected the maneuver then '%s', got %v", tt.command, sent)
+			}
+
+			recorder.mu.Lock()
+			defer recorder.mu.Unlock()
+			recorded := false
+			for _, c := range recorder.commands {
+				recorded = recorded || c.command == tt.command
+			}
+			if !recorded {
+				t.Errorf("Expected '%s' to be recorded, got %+v", tt.command, recorder.commands)
+			}
+		})
+	}
+}
+
+func TestPreemptiveCommandErrorResponse(t *testing.T) {
+	mockConn := NewMockCommandConnection()
+	mockConn.SetResponse("land", "error")
+	commander := createTestCommander(mockConn)
+	defer commander.Shutdown()
+
+	if err := commander.LandNow(); !sdkerrors.Is(err, sdkerrors.ErrCommandFailed) {
+		t.Errorf("Expected a command failure, got %v", err)
+	}
+}
+
 // ==================== Goroutine Leak Detection Tests ====================
 
 // TestCommanderShutdownNoLeak verifies no goroutines leak after commander shutdown
@@ -21,6 +21,7 @@ type CommandResponse struct {
 type CommandRequest struct {
 	Command      string
 	ResponseChan chan CommandResponse
+	Context      context.Context // If set and done before sending, the command is dropped
 }
 
 // PriorityCommandQueue manages command execution with priority-based ordering.
@@ -93,6 +94,56 @@ func (pcq *PriorityCommandQueue) EnqueueControl(command string) {
 	pcq.cond.Signal()
 }
 
+// EnqueueControlWithResponse adds a low-priority control command to the queue and
+// returns a channel that receives the drone's reply once the command completes.
+// Control commands keep their relative order, so waiting on the reply also
+// waits for every control command enqueued before it.
+func (pcq *PriorityCommandQueue) EnqueueControlWithResponse(command string) <-chan CommandResponse {
+	return pcq.EnqueueControlContext(context.Background(), command)
+}
+
+// EnqueueControlContext works like EnqueueControlWithResponse, except that
+// the command is dropped instead of sent if ctx is done by the time it is
+// dequeued. The channel then receives the context's error.
+func (pcq *PriorityCommandQueue) EnqueueControlContext(ctx context.Context, command string) <-chan CommandResponse {
+	pcq.mutex.Lock()
+	defer pcq.mutex.Unlock()
+
+	respChan := make(chan CommandResponse, 1)
+	if pcq.closed {
+		respChan <- CommandResponse{Error: fmt.Errorf("queue is closed")}
+		close(respChan)
+		return respChan
+	}
+
+	req := CommandRequest{
+		Command:      command,
+		ResponseChan: respChan,
+		Context:      ctx,
+	}
+
+	pcq.lowPriority = append(pcq.lowPriority, req)
+	pcq.cond.Signal()
+	return respChan
+}
+
+// FlushControl drops all queued control commands and returns how many were
+// dropped. Callers waiting on a dropped command's reply receive an error.
+func (pcq *PriorityCommandQueue) FlushControl() int {
+	pcq.mutex.Lock()
+	defer pcq.mutex.Unlock()
+
+	dropped := pcq.lowPriority
+	pcq.lowPriority = make([]CommandRequest, 0)
+	for _, req := range dropped {
+		if req.ResponseChan != nil {
+			req.ResponseChan <- CommandResponse{Error: fmt.Errorf("command '%s' was flushed from the queue", req.Command)}
+			close(req.ResponseChan)
+		}
+	}
+	return len(dropped)
+}
+
 // EnqueueWithPriority adds a command with specified priority
 func (pcq *PriorityCommandQueue) EnqueueWithPriority(command string, priority int) {
 	switch priority {
@@ -188,3 +188,61 @@ func TestPriorityCommandQueueConcurrency(t *testing.T) {
 		t.Errorf("Expected 10 control commands, got %d", controlCount)
 	}
 }
+
+func TestPriorityCommandQueueControlWithResponse(t *testing.T) {
+	pq := NewPriorityCommandQueue()
+
+	pq.EnqueueControl("takeoff")
+	respChan := pq.EnqueueControlWithResponse("forward 50")
+	pq.EnqueueRead("battery?")
+
+	if pq.LowPrioritySize() != 2 {
+		t.Errorf("Expected low priority size 2, got %d", pq.LowPrioritySize())
+	}
+
+	expected := []string{"battery?", "takeoff", "forward 50"}
+	for _, want := range expected {
+		req, ok := pq.Dequeue(context.Background())
+		if !ok || req.Command != want {
+			t.Fatalf("Expected '%s', got '%s'", want, req.Command)
+		}
+		if want == "forward 50" {
+			if req.ResponseChan == nil {
+				t.Fatal("Expected control command to carry a response channel")
+			}
+			req.ResponseChan <- CommandResponse{Response: "ok"}
+		}
+	}
+
+	if resp := <-respChan; resp.Response != "ok" {
+		t.Errorf("Expected response 'ok', got '%s'", resp.Response)
+	}
+
+	pq.Close()
+	if resp := <-pq.EnqueueControlWithResponse("land"); resp.Error == nil {
+		t.Error("Expected error when enqueueing on a closed queue")
+	}
+}
+
+func TestPriorityCommandQueueFlushControl(t *testing.T) {
+	pcq := NewPriorityCommandQueue()
+
+	pcq.EnqueueControl("takeoff")
+	awaited := pcq.EnqueueControlWithResponse("forward 100")
+	pcq.EnqueueRead("battery?")
+
+	if dropped := pcq.FlushControl(); dropped != 2 {
+		t.Errorf("Expected 2 dropped commands, got %d", dropped)
+	}
+
+	resp, ok := <-awaited
+	if !ok || resp.Error == nil {
+		t.Errorf("Expected an error for the dropped command, got %+v", resp)
+	}
+	if pcq.LowPrioritySize() != 0 {
+		t.Errorf("Expected no control commands, got %d", pcq.LowPrioritySize())
+	}
+	if pcq.HighPrioritySize() != 1 {
+		t.Errorf("Expected the read command to stay queued, got %d", pcq.HighPrioritySize())
+	}
+}
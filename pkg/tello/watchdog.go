@@ -0,0 +1,247 @@
+package tello
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/conceptcodes/dji-tello-sdk-go/pkg/utils"
+)
+
+const (
+	// DefaultKeepaliveInterval is how long the link may be idle before the
+	// watchdog sends a keepalive, well within the ~15 s after which the Tello
+	// lands on its own when it hears no commands
+	DefaultKeepaliveInterval = 5 * time.Second
+
+	// DefaultLinkTimeout is how long the drone may be silent before the link
+	// is reported lost
+	DefaultLinkTimeout = 3 * time.Second
+
+	// watchdogCheckInterval is how often the watchdog checks the link
+	watchdogCheckInterval = 250 * time.Millisecond
+
+	// keepaliveCommand is a cheap read that resets the drone's idle timer
+	keepaliveCommand = "battery?"
+)
+
+// WatchdogConfig configures the link watchdog
+type WatchdogConfig struct {
+	KeepaliveInterval time.Duration // Idle time before a keepalive is sent, 0 disables keepalives
+	LinkTimeout       time.Duration // Silence after which the link is reported lost
+}
+
+// DefaultWatchdogConfig returns the watchdog configuration used by
+// InitializeWithOptions
+func DefaultWatchdogConfig() WatchdogConfig {
+	return WatchdogConfig{
+		KeepaliveInterval: DefaultKeepaliveInterval,
+		LinkTimeout:       DefaultLinkTimeout,
+	}
+}
+
+// LinkEvent reports that the link to the drone was lost or has come back
+type LinkEvent struct {
+	Up         bool          // The link came back rather than being lost
+	SinceState time.Duration // Time since the last state packet, 0 if none was ever received
+	SinceReply time.Duration // Time since the last command reply, 0 if none was ever received
+}
+
+// LinkStatus is the watchdog's view of the link to the drone
+type LinkStatus struct {
+	Up        bool      // Something was heard from the drone within the link timeout
+	LastState time.Time // Zero if no state packet was ever received
+	LastReply time.Time // Zero if no command reply was ever received
+}
+
+// linkWatchdog holds the watchdog state of a telloCommander
+type linkWatchdog struct {
+	lastSent  atomic.Int64 // Unix nanoseconds of the last command sent
+	lastReply atomic.Int64 // Unix nanoseconds of the last command reply
+
+	mu       sync.Mutex
+	config   WatchdogConfig
+	callback func(LinkEvent)
+	lost     bool
+	cancel   context.CancelFunc
+	done     chan struct{}
+}
+
+// StartWatchdog starts sending keepalives while the link is idle and
+// watching for the drone falling silent, replacing a watchdog already
+// running. When the link comes back after being lost, SDK mode is entered
+// again.
+func (t *telloCommander) StartWatchdog(config WatchdogConfig) {
+	t.StopWatchdog()
+
+	if config.LinkTimeout <= 0 {
+		config.LinkTimeout = DefaultLinkTimeout
+	}
+
+	w := &t.link
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	ctx, cancel := context.WithCancel(t.ctx)
+	w.config = config
+	w.lost = false
+	w.cancel = cancel
+	w.done = make(chan struct{})
+
+	t.wg.Add(1)
+	go t.runWatchdog(ctx, w.done)
+}
+
+// StopWatchdog stops the watchdog, if it is running
+func (t *telloCommander) StopWatchdog() {
+	w := &t.link
+	w.mu.Lock()
+	cancel, done := w.cancel, w.done
+	w.cancel, w.done = nil, nil
+	w.mu.Unlock()
+
+	if cancel != nil {
+		cancel()
+		<-done
+	}
+}
+
+// SetLinkTimeout changes how long the drone may be silent before the link
+// is reported lost
+func (t *telloCommander) SetLinkTimeout(timeout time.Duration) {
+	if timeout <= 0 {
+		return
+	}
+	t.link.mu.Lock()
+	defer t.link.mu.Unlock()
+	t.link.config.LinkTimeout = timeout
+}
+
+// SetLinkCallback sets the function called from the watchdog goroutine when
+// the link is lost or comes back. It must not block.
+func (t *telloCommander) SetLinkCallback(callback func(LinkEvent)) {
+	t.link.mu.Lock()
+	defer t.link.mu.Unlock()
+	t.link.callback = callback
+}
+
+// GetLinkStatus returns the watchdog's view of the link
+func (t *telloCommander) GetLinkStatus() LinkStatus {
+	t.link.mu.Lock()
+	lost := t.link.lost
+	t.link.mu.Unlock()
+
+	status := LinkStatus{LastState: t.lastStateTime(), LastReply: unixTime(t.link.lastReply.Load())}
+	status.Up = !lost && (!status.LastState.IsZero() || !status.LastReply.IsZero())
+	return status
+}
+
+// markSent and markReplied record command traffic for the watchdog
+func (t *telloCommander) markSent() {
+	t.link.lastSent.Store(time.Now().UnixNano())
+}
+
+func (t *telloCommander) markReplied() {
+	t.link.lastReply.Store(time.Now().UnixNano())
+}
+
+func (t *telloCommander) runWatchdog(ctx context.Context, done chan struct{}) {
+	defer t.wg.Done()
+	defer close(done)
+
+	ticker := time.NewTicker(watchdogCheckInterval)
+	defer ticker.Stop()
+
+	var keepalive <-chan CommandResponse
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case now := <-ticker.C:
+			keepalive = t.checkLink(now, keepalive)
+		}
+	}
+}
+
+// checkLink reports link changes and sends a keepalive if the link is idle.
+// It returns the reply channel of the keepalive in flight, if any.
+func (t *telloCommander) checkLink(now time.Time, keepalive <-chan CommandResponse) <-chan CommandResponse {
+	lastState := t.lastStateTime()
+	lastReply := unixTime(t.link.lastReply.Load())
+	last := lastState
+	if lastReply.After(last) {
+		last = lastReply
+	}
+	// Nothing has been heard from the drone yet, e.g. before Init
+	if last.IsZero() {
+		return keepalive
+	}
+
+	w := &t.link
+	w.mu.Lock()
+	config := w.config
+	wasLost := w.lost
+	lost := now.Sub(last) > config.LinkTimeout
+	w.lost = lost
+	callback := w.callback
+	w.mu.Unlock()
+
+	if lost != wasLost {
+		event := LinkEvent{Up: !lost, SinceState: since(now, lastState), SinceReply: since(now, lastReply)}
+		if lost {
+			utils.Logger.Warnf("Link to drone lost: no state for %v, no reply for %v", event.SinceState, event.SinceReply)
+		} else {
+			utils.Logger.Info("Link to drone restored, re-entering SDK mode")
+			t.wg.Add(1)
+			go t.relink()
+		}
+		if callback != nil {
+			callback(event)
+		}
+	}
+
+	// Wait for the previous keepalive so they cannot pile up behind a long maneuver
+	if keepalive != nil {
+		select {
+		case <-keepalive:
+			keepalive = nil
+		default:
+			return keepalive
+		}
+	}
+	if !lost && config.KeepaliveInterval > 0 && now.Sub(unixTime(w.lastSent.Load())) >= config.KeepaliveInterval {
+		utils.Logger.Debugf("Link idle, sending keepalive")
+		keepalive = t.commandQueue.EnqueueRead(keepaliveCommand)
+	}
+	return keepalive
+}
+
+// relink enters SDK mode again once the link is back, in case the drone left
+// it while out of contact
+func (t *telloCommander) relink() {
+	defer t.wg.Done()
+	if err := t.Init(); err != nil {
+		utils.Logger.Warnf("Failed to re-enter SDK mode after the link returned: %v", err)
+	}
+}
+
+func (t *telloCommander) lastStateTime() time.Time {
+	if t.stateListener == nil {
+		return time.Time{}
+	}
+	return t.stateListener.LastStateTime()
+}
+
+func unixTime(nanos int64) time.Time {
+	if nanos == 0 {
+		return time.Time{}
+	}
+	return time.Unix(0, nanos)
+}
+
+func since(now, t time.Time) time.Duration {
+	if t.IsZero() {
+		return 0
+	}
+	return now.Sub(t)
+}
@@ -0,0 +1,154 @@
+package tello
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// linkTestConnection answers every command unless it is silenced, and is safe
+// to use from the watchdog and command goroutines
+type linkTestConnection struct {
+	mu     sync.Mutex
+	sent   []string
+	silent bool
+}
+
+func (c *linkTestConnection) SendCommand(command string) (string, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.sent = append(c.sent, command)
+	if c.silent {
+		return "", fmt.Errorf("read udp: i/o timeout")
+	}
+	if strings.HasSuffix(command, "?") {
+		return "87", nil
+	}
+	return "ok", nil
+}
+
+func (c *linkTestConnection) SendUrgent(command string, replyTimeout time.Duration) (string, error) {
+	return c.SendCommand(command)
+}
+
+func (c *linkTestConnection) Close() error {
+	return nil
+}
+
+func (c *linkTestConnection) setSilent(silent bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.silent = silent
+}
+
+func (c *linkTestConnection) count(command string) int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	n := 0
+	for _, sent := range c.sent {
+		if sent == command {
+			n++
+		}
+	}
+	return n
+}
+
+func TestWatchdogSendsKeepalivesWhileIdle(t *testing.T) {
+	conn := &linkTestConnection{}
+	commander := createTestCommander(conn)
+	defer commander.Shutdown()
+
+	// Nothing is sent before anything has been heard from the drone
+	commander.StartWatchdog(WatchdogConfig{KeepaliveInterval: 300 * time.Millisecond, LinkTimeout: 2 * time.Second})
+	time.Sleep(600 * time.Millisecond)
+	if n := conn.count(keepaliveCommand); n != 0 {
+		t.Fatalf("Expected no keepalives before Init, got %d", n)
+	}
+
+	if err := commander.Init(); err != nil {
+		t.Fatalf("Init failed: %v", err)
+	}
+	time.Sleep(1200 * time.Millisecond)
+	if n := conn.count(keepaliveCommand); n < 2 {
+		t.Errorf("Expected keepalives while idle, got %d", n)
+	}
+	if status := commander.GetLinkStatus(); !status.Up || status.LastReply.IsZero() {
+		t.Errorf("Expected the link to be up, got %+v", status)
+	}
+
+	// Stopping the watchdog stops the keepalives
+	commander.StopWatchdog()
+	sent := conn.count(keepaliveCommand)
+	time.Sleep(600 * time.Millisecond)
+	if n := conn.count(keepaliveCommand); n != sent {
+		t.Errorf("Expected no keepalives after StopWatchdog, got %d more", n-sent)
+	}
+}
+
+func TestWatchdogReportsLinkLossAndRecovery(t *testing.T) {
+	conn := &linkTestConnection{}
+	commander := createTestCommander(conn)
+	defer commander.Shutdown()
+
+	events := make(chan LinkEvent, 10)
+	commander.SetLinkCallback(func(event LinkEvent) { events <- event })
+	commander.StartWatchdog(WatchdogConfig{LinkTimeout: 300 * time.Millisecond})
+	if err := commander.Init(); err != nil {
+		t.Fatalf("Init failed: %v", err)
+	}
+
+	conn.setSilent(true)
+	select {
+	case event := <-events:
+		if event.Up {
+			t.Fatalf("Expected the link to be lost, got %+v", event)
+		}
+		if event.SinceReply < 300*time.Millisecond || event.SinceState != 0 {
+			t.Errorf("Expected only a stale reply, got %+v", event)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("Expected a link lost event")
+	}
+	if commander.GetLinkStatus().Up {
+		t.Error("Expected the link status to be down")
+	}
+
+	// A reply brings the link back, and SDK mode is entered again
+	conn.setSilent(false)
+	if _, err := commander.GetBatteryPercentage(); err != nil {
+		t.Fatalf("Read failed: %v", err)
+	}
+	select {
+	case event := <-events:
+		if !event.Up {
+			t.Fatalf("Expected the link to come back, got %+v", event)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("Expected a link restored event")
+	}
+
+	deadline := time.Now().Add(time.Second)
+	for conn.count("command") < 2 {
+		if time.Now().After(deadline) {
+			t.Fatal("Expected SDK mode to be entered again after the link returned")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func TestWatchdogSetLinkTimeout(t *testing.T) {
+	commander := createTestCommander(&linkTestConnection{})
+	defer commander.Shutdown()
+
+	commander.StartWatchdog(WatchdogConfig{})
+	commander.SetLinkTimeout(time.Second)
+	commander.SetLinkTimeout(0)
+
+	commander.link.mu.Lock()
+	defer commander.link.mu.Unlock()
+	if commander.link.config.LinkTimeout != time.Second {
+		t.Errorf("Expected a 1s link timeout, got %v", commander.link.config.LinkTimeout)
+	}
+}
@@ -20,6 +20,24 @@ type UDPClientInterface interface {
 	Close() error
 }
 
+// preemptPollInterval is how often SendUrgent interrupts a pending receive
+// while waiting for the connection
+const preemptPollInterval = 10 * time.Millisecond
+
+// urgentDrainTimeout is how long SendUrgent waits for replies still owed to
+// earlier commands before sending its own
+const urgentDrainTimeout = 20 * time.Millisecond
+
+// maxDrainedReplies bounds the replies SendUrgent discards, should the
+// socket keep receiving
+const maxDrainedReplies = 8
+
+// interruptible is implemented by UDP clients whose pending Receive can be
+// cut short from another goroutine
+type interruptible interface {
+	Interrupt() error
+}
+
 // CommandConnection manages UDP communication with the Tello drone
 type CommandConnection struct {
 	config      config.TransportConfig
@@ -28,6 +46,12 @@ type CommandConnection struct {
 	useBound    bool
 	inFallback  bool // Track if we're in ephemeral port fallback mode
 	fallbackTry int  // Count of fallback attempts
+
+	// Preemption by SendUrgent, guarded by preemptMutex rather than mutex,
+	// which SendCommand holds while waiting for replies
+	preemptMutex sync.Mutex
+	preempting   int                // Number of SendUrgent calls in progress
+	receiving    UDPClientInterface // Client SendCommand is waiting on, if any
 }
 
 // NewCommandConnection creates a new command connection with default configuration
@@ -124,7 +148,12 @@ func (c *CommandConnection) SendCommand(command string) (string, error) {
 			time.Sleep(c.config.CommandSendDelay)
 		}
 
+		if !c.beginReceive() {
+			return "", preemptedError(command)
+		}
+
 		if err := c.client.Send(data); err != nil {
+			c.endReceive()
 			lastErr = errors.Wrapf(err, "failed to send command '%s'", command)
 
 			// Determine error type and handle appropriately
@@ -156,6 +185,10 @@ func (c *CommandConnection) SendCommand(command string) (string, error) {
 		}
 
 		response, err := c.client.Receive(2048, c.config.CommandTimeout)
+		preempted := c.endReceive()
+		if err != nil && preempted {
+			return "", preemptedError(command)
+		}
 		if err != nil {
 			lastErr = errors.Wrapf(err, "failed to receive response for command '%s'", command)
 
@@ -203,6 +236,117 @@ func (c *CommandConnection) SendCommand(command string) (string, error) {
 	return "", lastErr
 }
 
+// SendUrgent sends a command ahead of the one in progress, e.g. to stop the
+// drone while SendCommand is still retrying a maneuver. The pending
+// SendCommand gives up with an error, and the command is sent with its own
+// retries, waiting up to replyTimeout for each reply.
+func (c *CommandConnection) SendUrgent(command string, replyTimeout time.Duration) (string, error) {
+	c.preemptMutex.Lock()
+	c.preempting++
+	c.preemptMutex.Unlock()
+	defer func() {
+		c.preemptMutex.Lock()
+		c.preempting--
+		c.preemptMutex.Unlock()
+	}()
+
+	// Keep interrupting the pending receive until SendCommand lets go of the
+	// connection, as it may only just be setting its read deadline
+	for !c.mutex.TryLock() {
+		c.interruptReceive()
+		time.Sleep(preemptPollInterval)
+	}
+	defer c.mutex.Unlock()
+
+	if c.client == nil {
+		return "", errors.NewSDKError(errors.ErrConnectionFailed, "CommandConnection",
+			"UDP client is not initialized")
+	}
+
+	var lastErr error
+	data := []byte(command + "\r\n")
+	for attempt := 1; attempt <= c.config.CommandRetries; attempt++ {
+		// Replies are not tagged with their command, so clear out any still
+		// owed to the preempted command or an earlier attempt; the one reply
+		// read after sending is then this command's
+		c.drainReplies()
+
+		if err := c.client.Send(data); err != nil {
+			lastErr = errors.Wrapf(err, "failed to send urgent command '%s'", command)
+			if recErr := c.reconnect(); recErr != nil {
+				return "", errors.WrapSDKError(recErr, errors.ErrConnectionFailed, "CommandConnection",
+					"failed to reconnect command socket")
+			}
+			continue
+		}
+
+		response, err := c.client.Receive(2048, replyTimeout)
+		if err != nil {
+			lastErr = errors.Wrapf(err, "failed to receive response for urgent command '%s'", command)
+			utils.Logger.Warnf("No reply to urgent command '%s' (attempt %d/%d)", command, attempt, c.config.CommandRetries)
+			continue
+		}
+		return response, nil
+	}
+
+	if lastErr == nil {
+		lastErr = errors.NewSDKError(errors.ErrCommandFailed, "CommandConnection",
+			fmt.Sprintf("failed to send urgent command '%s': unknown error", command))
+	}
+	return "", lastErr
+}
+
+// drainReplies discards the replies that arrive within urgentDrainTimeout
+func (c *CommandConnection) drainReplies() {
+	for range maxDrainedReplies {
+		reply, err := c.client.Receive(2048, urgentDrainTimeout)
+		if err != nil {
+			return
+		}
+		utils.Logger.Debugf("Discarded reply %q owed to an earlier command", reply)
+	}
+}
+
+// interruptReceive cuts short the receive SendCommand is waiting on, if any
+func (c *CommandConnection) interruptReceive() {
+	c.preemptMutex.Lock()
+	defer c.preemptMutex.Unlock()
+	if c.receiving == nil {
+		return
+	}
+	if client, ok := c.receiving.(interruptible); ok {
+		if err := client.Interrupt(); err != nil {
+			utils.Logger.Warnf("Failed to interrupt pending command: %v", err)
+		}
+	}
+}
+
+// beginReceive records that SendCommand is about to wait on the client, or
+// reports false if an urgent command has preempted it
+func (c *CommandConnection) beginReceive() bool {
+	c.preemptMutex.Lock()
+	defer c.preemptMutex.Unlock()
+	if c.preempting > 0 {
+		return false
+	}
+	c.receiving = c.client
+	return true
+}
+
+// endReceive clears the client SendCommand waited on and reports whether an
+// urgent command preempted the wait
+func (c *CommandConnection) endReceive() bool {
+	c.preemptMutex.Lock()
+	defer c.preemptMutex.Unlock()
+	c.receiving = nil
+	return c.preempting > 0
+}
+
+func preemptedError(command string) error {
+	return errors.NewSDKError(errors.ErrCommandFailed, "CommandConnection",
+		fmt.Sprintf("command '%s' was preempted by an urgent command", command))
+}
+
 func isBrokenPipe(err error) bool {
 	if err == nil {
 		return false
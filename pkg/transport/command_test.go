@@ -2,6 +2,8 @@ package transport
 
 import (
 	"fmt"
+	"strings"
+	"sync"
 	"testing"
 	"time"
 )
@@ -408,3 +410,193 @@ func TestCommandConnectionSendCommandDifferentTypes(t *testing.T) {
 		})
 	}
 }
+
+// InterruptibleMockUDPClient answers "ok" to urgent commands and leaves every
+// other command waiting until its timeout or an interrupt
+type InterruptibleMockUDPClient struct {
+	mu         sync.Mutex
+	sent       []string
+	replies    []string // Replies waiting to be received, in order
+	interrupts chan struct{}
+	dropUrgent int      // Urgent commands to leave unanswered
+	late       []string // Replies the interrupted command receives late
+}
+
+func NewInterruptibleMockUDPClient() *InterruptibleMockUDPClient {
+	return &InterruptibleMockUDPClient{interrupts: make(chan struct{}, 1)}
+}
+
+func (m *InterruptibleMockUDPClient) Send(data []byte) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	command := strings.TrimSpace(string(data))
+	m.sent = append(m.sent, command)
+	if command == "emergency" {
+		if m.dropUrgent > 0 {
+			m.dropUrgent--
+		} else {
+			m.replies = append(m.replies, "ok")
+		}
+	}
+	return nil
+}
+
+func (m *InterruptibleMockUDPClient) Receive(bufferSize int, timeout time.Duration) (string, error) {
+	m.mu.Lock()
+	if len(m.replies) > 0 {
+		reply := m.replies[0]
+		m.replies = m.replies[1:]
+		m.mu.Unlock()
+		return reply, nil
+	}
+	m.mu.Unlock()
+
+	select {
+	case <-m.interrupts:
+		return "", fmt.Errorf("read udp: i/o timeout")
+	case <-time.After(timeout):
+		return "", fmt.Errorf("read udp: i/o timeout")
+	}
+}
+
+// Interrupt cuts the pending receive short, after which the late replies
+// arrive
+func (m *InterruptibleMockUDPClient) Interrupt() error {
+	m.mu.Lock()
+	m.replies = append(m.replies, m.late...)
+	m.late = nil
+	m.mu.Unlock()
+	select {
+	case m.interrupts <- struct{}{}:
+	default:
+	}
+	return nil
+}
+
+// Pending returns the replies no one has received
+func (m *InterruptibleMockUDPClient) Pending() []string {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return append([]string(nil), m.replies...)
+}
+
+func (m *InterruptibleMockUDPClient) Close() error {
+	return nil
+}
+
+func (m *InterruptibleMockUDPClient) Sent() []string {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return append([]string(nil), m.sent...)
+}
+
+func TestCommandConnectionSendUrgentPreemptsPendingCommand(t *testing.T) {
+	mockClient := NewInterruptibleMockUDPClient()
+	conn := NewCommandConnectionWithClient(mockClient)
+
+	pending := make(chan error, 1)
+	go func() {
+		_, err := conn.SendCommand("go 500 0 0 10")
+		pending <- err
+	}()
+	// Wait for the maneuver to be sent and waiting for its reply
+	deadline := time.Now().Add(time.Second)
+	for len(mockClient.Sent()) == 0 {
+		if time.Now().After(deadline) {
+			t.Fatal("Expected the maneuver to be sent")
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+
+	start := time.Now()
+	response, err := conn.SendUrgent("emergency", 100*time.Millisecond)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if response != "ok" {
+		t.Errorf("Expected response 'ok', got '%s'", response)
+	}
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Errorf("Expected the urgent command to preempt the pending one, took %v", elapsed)
+	}
+
+	select {
+	case err := <-pending:
+		if err == nil || !strings.Contains(err.Error(), "preempted") {
+			t.Errorf("Expected the pending command to be preempted, got %v", err)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("Expected the pending command to return")
+	}
+
+	sent := mockClient.Sent()
+	if len(sent) != 2 || sent[0] != "go 500 0 0 10" || sent[1] != "emergency" {
+		t.Errorf("Expected the maneuver to be sent once before emergency, got %v", sent)
+	}
+}
+
+func TestCommandConnectionSendUrgentRetries(t *testing.T) {
+	mockClient := NewInterruptibleMockUDPClient()
+	mockClient.dropUrgent = 1
+	conn := NewCommandConnectionWithClient(mockClient)
+
+	response, err := conn.SendUrgent("emergency", 20*time.Millisecond)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if response != "ok" {
+		t.Errorf("Expected response 'ok', got '%s'", response)
+	}
+	if sent := mockClient.Sent(); len(sent) != 2 {
+		t.Errorf("Expected emergency to be sent twice, got %v", sent)
+	}
+}
+
+// preemptManeuver starts a maneuver and waits for it to be sent
+func preemptManeuver(t *testing.T, conn *CommandConnection, mockClient *InterruptibleMockUDPClient) {
+	t.Helper()
+	go conn.SendCommand("forward 100")
+	deadline := time.Now().Add(time.Second)
+	for len(mockClient.Sent()) == 0 {
+		if time.Now().After(deadline) {
+			t.Fatal("Expected the maneuver to be sent")
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
+
+func TestCommandConnectionSendUrgentSkipsStaleReply(t *testing.T) {
+	mockClient := NewInterruptibleMockUDPClient()
+	mockClient.late = []string{"error"}
+	conn := NewCommandConnectionWithClient(mockClient)
+	preemptManeuver(t, conn, mockClient)
+
+	response, err := conn.SendUrgent("emergency", 100*time.Millisecond)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if response != "ok" {
+		t.Errorf("Expected the reply after the interrupted command's, got '%s'", response)
+	}
+}
+
+func TestCommandConnectionSendUrgentSkipsLateOk(t *testing.T) {
+	mockClient := NewInterruptibleMockUDPClient()
+	mockClient.late = []string{"ok"}
+	conn := NewCommandConnectionWithClient(mockClient)
+	preemptManeuver(t, conn, mockClient)
+
+	response, err := conn.SendUrgent("emergency", 100*time.Millisecond)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if response != "ok" {
+		t.Errorf("Expected response 'ok', got '%s'", response)
+	}
+	if pending := mockClient.Pending(); len(pending) != 0 {
+		t.Errorf("Expected the maneuver's late reply to be drained, not the urgent one left for the next command: %v", pending)
+	}
+	if sent := mockClient.Sent(); len(sent) != 2 || sent[1] != "emergency" {
+		t.Errorf("Expected the maneuver then emergency, got %v", sent)
+	}
+}
@@ -0,0 +1,181 @@
+package transport
+
+import (
+	"fmt"
+	"image"
+	"io"
+	"os/exec"
+	"sync"
+
+	"github.com/conceptcodes/dji-tello-sdk-go/pkg/errors"
+	"github.com/conceptcodes/dji-tello-sdk-go/pkg/utils"
+)
+
+// FFmpegDecoder decodes the stream with an FFmpeg subprocess. Access units
+// are written to FFmpeg's stdin and raw YUV 4:2:0 pictures are read back from
+// its stdout, so it handles any stream FFmpeg supports at the cost of an
+// external dependency and a few frames of latency.
+//
+// Decode does not wait for FFmpeg: it returns the most recent picture read so
+// far. The subprocess is started at the first keyframe, whose SPS gives the
+// picture size, and restarted when the size changes.
+type FFmpegDecoder struct {
+	path string
+
+	mu            sync.Mutex
+	cmd           *exec.Cmd
+	stdin         io.WriteCloser
+	generation    int // Incremented per subprocess so stale readers are ignored
+	width, height int
+	latest        *image.YCbCr
+	readErr       error
+}
+
+// NewFFmpegDecoder creates a decoder using the ffmpeg binary found in PATH
+func NewFFmpegDecoder() (*FFmpegDecoder, error) {
+	path, err := exec.LookPath("ffmpeg")
+	if err != nil {
+		return nil, fmt.Errorf("FFmpeg not found. Please install FFmpeg: %w", err)
+	}
+	return newFFmpegDecoder(path), nil
+}
+
+func newFFmpegDecoder(path string) *FFmpegDecoder {
+	return &FFmpegDecoder{path: path}
+}
+
+// Decode feeds an access unit to FFmpeg and returns the latest decoded picture
+func (fd *FFmpegDecoder) Decode(frame VideoFrame) (*image.YCbCr, error) {
+	fd.mu.Lock()
+	defer fd.mu.Unlock()
+
+	if frame.IsKeyFrame {
+		if width, height, ok := frameDimensions(frame); ok && (fd.cmd == nil || width != fd.width || height != fd.height) {
+			fd.stopLocked()
+			if err := fd.startLocked(width, height); err != nil {
+				return nil, errors.WrapSDKError(err, errors.ErrVideoDecode, "FFmpegDecoder", "failed to start FFmpeg")
+			}
+		}
+	}
+	if fd.cmd == nil {
+		return nil, nil
+	}
+	if fd.readErr != nil {
+		err := fd.readErr
+		fd.stopLocked()
+		return nil, errors.WrapSDKError(err, errors.ErrVideoDecode, "FFmpegDecoder", "FFmpeg stopped producing pictures")
+	}
+
+	if _, err := fd.stdin.Write(frame.Data); err != nil {
+		fd.stopLocked()
+		return nil, errors.WrapSDKError(err, errors.ErrVideoDecode, "FFmpegDecoder", "failed to write frame to FFmpeg")
+	}
+	return fd.latest, nil
+}
+
+// Close stops the FFmpeg subprocess
+func (fd *FFmpegDecoder) Close() error {
+	fd.mu.Lock()
+	defer fd.mu.Unlock()
+	fd.stopLocked()
+	return nil
+}
+
+// frameDimensions returns the visible picture size from the SPS in a frame
+func frameDimensions(frame VideoFrame) (width, height int, ok bool) {
+	nalUnits := frame.NALUnits
+	if len(nalUnits) == 0 {
+		nalUnits, _ = NewH264Parser().ParseFrame(frame.Data)
+	}
+	for _, nal := range nalUnits {
+		if len(nal.Data) == 0 || nal.Data[0]&0x1F != NALUTypeSPS {
+			continue
+		}
+		sps, err := parseSPS(nal.Data)
+		if err != nil {
+			utils.Logger.Debugf("FFmpeg decoder: cannot read picture size from SPS: %v", err)
+			return 0, 0, false
+		}
+		return sps.Width(), sps.Height(), true
+	}
+	return 0, 0, false
+}
+
+func (fd *FFmpegDecoder) startLocked(width, height int) error {
+	cmd := exec.Command(fd.path,
+		"-loglevel", "error",
+		"-fflags", "nobuffer",
+		"-flags", "low_delay",
+		"-f", "h264",
+		"-i", "pipe:0",
+		"-f", "rawvideo",
+		"-pix_fmt", "yuv420p",
+		"pipe:1",
+	)
+	stdin, err := cmd.StdinPipe()
+	if err != nil {
+		return fmt.Errorf("failed to create stdin pipe: %w", err)
+	}
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return fmt.Errorf("failed to create stdout pipe: %w", err)
+	}
+	if err := cmd.Start(); err != nil {
+		return fmt.Errorf("failed to start %s: %w", fd.path, err)
+	}
+
+	fd.cmd, fd.stdin = cmd, stdin
+	fd.width, fd.height = width, height
+	fd.latest, fd.readErr = nil, nil
+	fd.generation++
+	go fd.readPictures(stdout, width, height, fd.generation)
+
+	utils.Logger.Infof("Started FFmpeg decoder for %dx%d video", width, height)
+	return nil
+}
+
+// readPictures reads raw pictures from FFmpeg until its output ends
+func (fd *FFmpegDecoder) readPictures(stdout io.Reader, width, height, generation int) {
+	ySize, cSize := width*height, (width/2)*(height/2)
+	buf := make([]byte, ySize+2*cSize)
+	for {
+		if _, err := io.ReadFull(stdout, buf); err != nil {
+			fd.mu.Lock()
+			if fd.generation == generation && err != io.EOF {
+				fd.readErr = err
+			}
+			fd.mu.Unlock()
+			return
+		}
+
+		img := image.NewYCbCr(image.Rect(0, 0, width, height), image.YCbCrSubsampleRatio420)
+		copy(img.Y, buf[:ySize])
+		copy(img.Cb, buf[ySize:ySize+cSize])
+		copy(img.Cr, buf[ySize+cSize:])
+
+		fd.mu.Lock()
+		if fd.generation == generation {
+			fd.latest = img
+		}
+		fd.mu.Unlock()
+	}
+}
+
+// stopLocked terminates FFmpeg. Pictures still buffered in FFmpeg are not
+// needed, and waiting for them could block on the reader goroutine, which
+// needs fd.mu to store them. The reader finishes once stdout is closed.
+func (fd *FFmpegDecoder) stopLocked() {
+	if fd.cmd == nil {
+		return
+	}
+	fd.stdin.Close()
+	if err := fd.cmd.Process.Kill(); err != nil {
+		utils.Logger.Debugf("Failed to kill FFmpeg decoder: %v", err)
+	}
+	if err := fd.cmd.Wait(); err != nil {
+		utils.Logger.Debugf("FFmpeg decoder exited: %v", err)
+	}
+	fd.cmd, fd.stdin = nil, nil
+	fd.latest = nil
+	fd.generation++
+}
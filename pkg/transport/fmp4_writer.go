@@ -0,0 +1,357 @@
+package transport
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"os"
+	"time"
+)
+
+// mp4Timescale is the number of media time units per second, the usual 90 kHz
+// of video
+const mp4Timescale = 90000
+
+// maxFragmentDuration bounds how much video is held in memory, and so how much
+// a crash can lose: a fragment is written at every keyframe and at least this
+// often
+const maxFragmentDuration = time.Second
+
+// defaultSampleDuration is used for frames without a timestamp and for the
+// last frame, whose duration is unknown
+const defaultSampleDuration = time.Second / 30
+
+// Sample flags of the trun box (ISO/IEC 14496-12 8.8.3.1)
+const (
+	mp4SyncSampleFlags    = 0x02000000 // Depends on no other sample
+	mp4NonSyncSampleFlags = 0x01010000 // Depends on others, not a sync sample
+)
+
+// FMP4Writer muxes H.264 access units into a fragmented MP4 (ISO BMFF) file
+// without FFmpeg. The header is written once the first keyframe has supplied
+// the SPS and PPS, and each fragment is written in one piece as soon as it is
+// complete, so a file cut short by a crash plays up to its last fragment.
+// Frames before the first keyframe with an SPS and PPS are dropped.
+type FMP4Writer struct {
+	w        io.Writer
+	parser   *H264Parser
+	started  bool
+	start    time.Time // Timestamp of the first frame, media time zero
+	last     time.Time // Timestamp of the last frame written
+	pending  []mp4Sample
+	sequence uint32
+	width    int
+	height   int
+}
+
+// mp4Sample is an access unit waiting for its fragment to be written
+type mp4Sample struct {
+	data      []byte // Length-prefixed NAL units
+	timestamp time.Time
+	sync      bool
+}
+
+// NewFMP4Writer creates a writer that muxes frames into w. Close must be
+// called to write the last fragment; it does not close w.
+func NewFMP4Writer(w io.Writer) *FMP4Writer {
+	return &FMP4Writer{
+		w:      w,
+		parser: NewH264Parser(),
+	}
+}
+
+// WriteFrame adds an access unit to the file. The sample's time comes from
+// frame.Timestamp, or follows the last frame at 30 fps when it is zero.
+func (fw *FMP4Writer) WriteFrame(frame VideoFrame) error {
+	nalUnits := frame.NALUnits
+	if len(nalUnits) == 0 {
+		var err error
+		if nalUnits, err = fw.parser.ParseFrame(frame.Data); err != nil {
+			return fmt.Errorf("failed to parse frame %d: %w", frame.SeqNum, err)
+		}
+	}
+
+	sample := mp4Sample{sync: fw.parser.HasKeyFrame(nalUnits)}
+	for _, nal := range nalUnits {
+		switch nal.Type {
+		case NALUTypeSPS, NALUTypePPS, NALUTypeAUD:
+			continue // The parameter sets are in avcC and AUDs are not allowed
+		}
+		data := bytes.TrimRight(nal.Data, "\x00") // Annex B trailing zero bytes
+		sample.data = binary.BigEndian.AppendUint32(sample.data, uint32(len(data)))
+		sample.data = append(sample.data, data...)
+	}
+	if len(sample.data) == 0 {
+		return nil
+	}
+	if !fw.started {
+		sps, pps := fw.parser.ExtractSPS(nalUnits), fw.parser.ExtractPPS(nalUnits)
+		if !sample.sync || sps == nil || pps == nil {
+			return nil
+		}
+		if err := fw.writeHeader(sps, pps); err != nil {
+			return err
+		}
+	}
+
+	switch {
+	case !fw.started:
+		sample.timestamp = frame.Timestamp
+		if sample.timestamp.IsZero() {
+			sample.timestamp = time.Now()
+		}
+		fw.start = sample.timestamp
+		fw.started = true
+	case frame.Timestamp.IsZero():
+		sample.timestamp = fw.last.Add(defaultSampleDuration)
+	case !frame.Timestamp.After(fw.last):
+		// Keep decode times increasing even if frames arrive out of order
+		sample.timestamp = fw.last.Add(time.Second / mp4Timescale)
+	default:
+		sample.timestamp = frame.Timestamp
+	}
+
+	if len(fw.pending) > 0 && (sample.sync || sample.timestamp.Sub(fw.pending[0].timestamp) >= maxFragmentDuration) {
+		if err := fw.writeFragment(sample.timestamp); err != nil {
+			return err
+		}
+	}
+	fw.pending = append(fw.pending, sample)
+	fw.last = sample.timestamp
+	return nil
+}
+
+// Size returns the picture size from the SPS, or zeros before the first
+// keyframe
+func (fw *FMP4Writer) Size() (width, height int) {
+	return fw.width, fw.height
+}
+
+// Close writes the frames still buffered as the last fragment. The last
+// frame lasts as long as the one before it.
+func (fw *FMP4Writer) Close() error {
+	if len(fw.pending) == 0 {
+		return nil
+	}
+	duration := defaultSampleDuration
+	if n := len(fw.pending); n > 1 {
+		duration = fw.pending[n-1].timestamp.Sub(fw.pending[n-2].timestamp)
+	}
+	return fw.writeFragment(fw.last.Add(duration))
+}
+
+// writeHeader writes the ftyp and moov boxes describing the stream with the
+// given parameter sets
+func (fw *FMP4Writer) writeHeader(sps, pps []byte) error {
+	sps = bytes.TrimRight(sps, "\x00")
+	pps = bytes.TrimRight(pps, "\x00")
+	params, err := parseSPS(sps)
+	if err != nil {
+		return fmt.Errorf("failed to parse SPS: %w", err)
+	}
+	fw.width, fw.height = params.Width(), params.Height()
+
+	var header []byte
+	header = append(header, mp4Box("ftyp", []byte("isom"), u32(0x200), []byte("isomiso5iso6avc1mp41"))...)
+	header = append(header, mp4Box("moov",
+		mp4FullBox("mvhd", 0, 0,
+			u32(0), u32(0), // Creation and modification time
+			u32(1000), u32(0), // Timescale and duration
+			u32(0x00010000), u16(0x0100), make([]byte, 10), // Rate, volume, reserved
+			mp4Matrix(), make([]byte, 24),
+			u32(2)), // Next track ID
+		mp4Box("trak",
+			mp4FullBox("tkhd", 0, 0x000003, // Enabled and in movie
+				u32(0), u32(0), u32(1), u32(0), u32(0), // Times, track ID, reserved, duration
+				make([]byte, 8), u16(0), u16(0), u16(0), u16(0), // Reserved, layer, group, volume, reserved
+				mp4Matrix(),
+				u32(uint32(fw.width)<<16), u32(uint32(fw.height)<<16)),
+			mp4Box("mdia",
+				mp4FullBox("mdhd", 0, 0,
+					u32(0), u32(0), u32(mp4Timescale), u32(0),
+					u16(0x55C4), u16(0)), // Language "und"
+				mp4FullBox("hdlr", 0, 0,
+					u32(0), []byte("vide"), make([]byte, 12), []byte("VideoHandler\x00")),
+				mp4Box("minf",
+					mp4FullBox("vmhd", 0, 1, make([]byte, 8)),
+					mp4Box("dinf", mp4FullBox("dref", 0, 0, u32(1), mp4FullBox("url ", 0, 1))),
+					mp4Box("stbl",
+						mp4FullBox("stsd", 0, 0, u32(1), fw.avc1(sps, pps)),
+						mp4FullBox("stts", 0, 0, u32(0)),
+						mp4FullBox("stsc", 0, 0, u32(0)),
+						mp4FullBox("stsz", 0, 0, u32(0), u32(0)),
+						mp4FullBox("stco", 0, 0, u32(0)))))),
+		mp4Box("mvex",
+			mp4FullBox("trex", 0, 0, u32(1), u32(1), u32(0), u32(0), u32(0))))...)
+
+	if _, err := fw.w.Write(header); err != nil {
+		return fmt.Errorf("failed to write MP4 header: %w", err)
+	}
+	return nil
+}
+
+// avc1 builds the sample entry, whose avcC box carries the parameter sets
+func (fw *FMP4Writer) avc1(sps, pps []byte) []byte {
+	avcC := []byte{
+		1,                      // Configuration version
+		sps[1], sps[2], sps[3], // Profile, compatibility and level
+		0xFC | 3, // 4-byte NAL unit lengths
+		0xE0 | 1, // One SPS
+	}
+	avcC = append(avcC, u16(uint16(len(sps)))...)
+	avcC = append(avcC, sps...)
+	avcC = append(avcC, 1) // One PPS
+	avcC = append(avcC, u16(uint16(len(pps)))...)
+	avcC = append(avcC, pps...)
+
+	return mp4Box("avc1",
+		make([]byte, 6), u16(1), // Reserved, data reference index
+		make([]byte, 16), u16(uint16(fw.width)), u16(uint16(fw.height)),
+		u32(0x00480000), u32(0x00480000), u32(0), u16(1), // 72 dpi, reserved, frame count
+		make([]byte, 32), u16(0x0018), u16(0xFFFF), // Compressor name, depth, pre-defined
+		mp4Box("avcC", avcC))
+}
+
+// writeFragment writes the pending samples as a moof and mdat pair. end is
+// when the last sample stops being shown.
+func (fw *FMP4Writer) writeFragment(end time.Time) error {
+	fw.sequence++
+	baseTime := fw.mediaTime(fw.pending[0].timestamp)
+
+	var entries, mdat []byte
+	for i, sample := range fw.pending {
+		next := end
+		if i+1 < len(fw.pending) {
+			next = fw.pending[i+1].timestamp
+		}
+		// Durations are differences of rounded times so that rounding errors
+		// do not add up over a long recording
+		duration := fw.mediaTime(next) - fw.mediaTime(sample.timestamp)
+		flags := uint32(mp4NonSyncSampleFlags)
+		if sample.sync {
+			flags = mp4SyncSampleFlags
+		}
+		entries = append(entries, u32(uint32(max(duration, 1)))...)
+		entries = append(entries, u32(uint32(len(sample.data)))...)
+		entries = append(entries, u32(flags)...)
+		mdat = append(mdat, sample.data...)
+	}
+
+	// The data offset counts from the start of moof, whose size it is part of
+	moof := func(dataOffset uint32) []byte {
+		return mp4Box("moof",
+			mp4FullBox("mfhd", 0, 0, u32(fw.sequence)),
+			mp4Box("traf",
+				mp4FullBox("tfhd", 0, 0x020000, u32(1)), // Default base is moof
+				mp4FullBox("tfdt", 1, 0, binary.BigEndian.AppendUint64(nil, uint64(baseTime))),
+				mp4FullBox("trun", 0, 0x000701, // Data offset, sample duration, size and flags
+					u32(uint32(len(fw.pending))), u32(dataOffset), entries)))
+	}
+	fragment := moof(0)
+	fragment = moof(uint32(len(fragment) + 8))
+	fragment = append(fragment, mp4Box("mdat", mdat)...)
+
+	fw.pending = fw.pending[:0]
+	if _, err := fw.w.Write(fragment); err != nil {
+		return fmt.Errorf("failed to write MP4 fragment %d: %w", fw.sequence, err)
+	}
+	return nil
+}
+
+// mediaTime converts t to media time units since the first frame
+func (fw *FMP4Writer) mediaTime(t time.Time) int64 {
+	elapsed := t.Sub(fw.start)
+	return (int64(elapsed)*mp4Timescale + int64(time.Second)/2) / int64(time.Second)
+}
+
+// RepairFMP4 truncates a fragmented MP4 file after its last complete box,
+// which removes a fragment that was being written when the recording was cut
+// short. A file without an incomplete box is left as it is.
+func RepairFMP4(path string) error {
+	file, err := os.OpenFile(path, os.O_RDWR, 0)
+	if err != nil {
+		return fmt.Errorf("failed to open MP4 file '%s': %w", path, err)
+	}
+	defer file.Close()
+
+	info, err := file.Stat()
+	if err != nil {
+		return fmt.Errorf("failed to stat MP4 file '%s': %w", path, err)
+	}
+
+	var offset, lastMoof int64
+	lastMoof = -1
+	header := make([]byte, 16)
+	for offset < info.Size() {
+		if _, err := file.ReadAt(header[:8], offset); err != nil {
+			break
+		}
+		size := int64(binary.BigEndian.Uint32(header))
+		boxType := string(header[4:8])
+		switch size {
+		case 0: // Box extends to the end of the file, as written by a crashed muxer
+			size = info.Size() - offset
+		case 1:
+			if _, err := file.ReadAt(header[8:16], offset+8); err != nil {
+				size = -1
+				break
+			}
+			size = int64(binary.BigEndian.Uint64(header[8:]))
+		}
+		if size < 8 || offset+size > info.Size() {
+			break
+		}
+		switch boxType {
+		case "moof":
+			lastMoof = offset
+		case "mdat":
+			lastMoof = -1
+		}
+		offset += size
+	}
+
+	// A moof without its mdat is as incomplete as a cut-off box
+	if lastMoof >= 0 {
+		offset = lastMoof
+	}
+	if offset == info.Size() {
+		return nil
+	}
+	if err := file.Truncate(offset); err != nil {
+		return fmt.Errorf("failed to truncate MP4 file '%s': %w", path, err)
+	}
+	return nil
+}
+
+// mp4Box builds a box of the given type around the concatenated payload
+func mp4Box(boxType string, payload ...[]byte) []byte {
+	size := 8
+	for _, part := range payload {
+		size += len(part)
+	}
+	box := make([]byte, 0, size)
+	box = binary.BigEndian.AppendUint32(box, uint32(size))
+	box = append(box, boxType...)
+	for _, part := range payload {
+		box = append(box, part...)
+	}
+	return box
+}
+
+// mp4FullBox builds a box that starts with a version and flags
+func mp4FullBox(boxType string, version byte, flags uint32, payload ...[]byte) []byte {
+	return mp4Box(boxType, append([][]byte{u32(uint32(version)<<24 | flags)}, payload...)...)
+}
+
+// mp4Matrix is the identity transformation matrix of mvhd and tkhd
+func mp4Matrix() []byte {
+	var matrix []byte
+	for _, v := range []uint32{0x00010000, 0, 0, 0, 0x00010000, 0, 0, 0, 0x40000000} {
+		matrix = append(matrix, u32(v)...)
+	}
+	return matrix
+}
+
+func u16(v uint16) []byte { return binary.BigEndian.AppendUint16(nil, v) }
+func u32(v uint32) []byte { return binary.BigEndian.AppendUint32(nil, v) }
@@ -0,0 +1,324 @@
+package transport
+
+import (
+	"bytes"
+	"encoding/binary"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+type testBox struct {
+	boxType string
+	offset  int
+	body    []byte
+}
+
+// parseBoxes splits data into boxes, failing on a box that does not fit
+func parseBoxes(t *testing.T, data []byte) []testBox {
+	t.Helper()
+	var boxes []testBox
+	for offset := 0; offset < len(data); {
+		if len(data)-offset < 8 {
+			t.Fatalf("Truncated box header at %d", offset)
+		}
+		size := int(binary.BigEndian.Uint32(data[offset:]))
+		if size < 8 || offset+size > len(data) {
+			t.Fatalf("Box at %d has bad size %d", offset, size)
+		}
+		boxes = append(boxes, testBox{string(data[offset+4 : offset+8]), offset, data[offset+8 : offset+size]})
+		offset += size
+	}
+	return boxes
+}
+
+// findBox follows path through nested boxes. skip gives the bytes before the
+// children of boxes that have fields of their own.
+func findBox(t *testing.T, data []byte, path ...string) []byte {
+	t.Helper()
+	skip := map[string]int{"stsd": 8, "avc1": 78, "dref": 8}
+	for i, boxType := range path {
+		found := false
+		for _, box := range parseBoxes(t, data) {
+			if box.boxType == boxType {
+				data, found = box.body, true
+				break
+			}
+		}
+		if !found {
+			t.Fatalf("No %s box in %v", boxType, path[:i])
+		}
+		if i+1 < len(path) {
+			data = data[skip[boxType]:]
+		}
+	}
+	return data
+}
+
+type testTrun struct {
+	baseTime  uint64
+	dataStart int // Offset of the first sample from the start of the file
+	durations []uint32
+	sizes     []uint32
+	flags     []uint32
+}
+
+func parseFragment(t *testing.T, moof testBox) testTrun {
+	t.Helper()
+	tfdt := findBox(t, moof.body, "traf", "tfdt")
+	trun := findBox(t, moof.body, "traf", "trun")
+	if tfdt[0] != 1 {
+		t.Fatalf("Expected a version 1 tfdt, got %d", tfdt[0])
+	}
+
+	fragment := testTrun{
+		baseTime:  binary.BigEndian.Uint64(tfdt[4:]),
+		dataStart: moof.offset + int(binary.BigEndian.Uint32(trun[8:])),
+	}
+	for i := 0; i < int(binary.BigEndian.Uint32(trun[4:])); i++ {
+		entry := trun[12+12*i:]
+		fragment.durations = append(fragment.durations, binary.BigEndian.Uint32(entry))
+		fragment.sizes = append(fragment.sizes, binary.BigEndian.Uint32(entry[4:]))
+		fragment.flags = append(fragment.flags, binary.BigEndian.Uint32(entry[8:]))
+	}
+	return fragment
+}
+
+// testRecording muxes a keyframe, two P frames, a keyframe and a P frame,
+// 33 ms apart except for 34 ms before the second keyframe
+func testRecording(t *testing.T) (data []byte, ts testCodedStream) {
+	t.Helper()
+	ts = testCodedStream{widthMbs: 2, heightMbs: 2, qp: 26}
+	y, cb, cr := pcmPicture(32, 32)
+	keyFrame := testAccessUnit(ts.sps(), ts.pps(), ts.pcmSlice(y, cb, cr))
+	pFrame := testAccessUnit([]byte{0x41, 0x9A, 0x02, 0x03})
+
+	var buf bytes.Buffer
+	writer := NewFMP4Writer(&buf)
+	start := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	frames := []struct {
+		frame VideoFrame
+		at    time.Duration
+	}{
+		{pFrame, 0}, // Dropped, as nothing can be decoded before the first keyframe
+		{keyFrame, 10 * time.Millisecond},
+		{pFrame, 43 * time.Millisecond},
+		{pFrame, 76 * time.Millisecond},
+		{keyFrame, 110 * time.Millisecond},
+		{pFrame, 143 * time.Millisecond},
+	}
+	for _, f := range frames {
+		f.frame.Timestamp = start.Add(f.at)
+		if err := writer.WriteFrame(f.frame); err != nil {
+			t.Fatalf("WriteFrame failed: %v", err)
+		}
+	}
+	if width, height := writer.Size(); width != 32 || height != 32 {
+		t.Errorf("Expected a 32x32 stream, got %dx%d", width, height)
+	}
+	if err := writer.Close(); err != nil {
+		t.Fatalf("Close failed: %v", err)
+	}
+	return buf.Bytes(), ts
+}
+
+func TestFMP4WriterStructure(t *testing.T) {
+	data, ts := testRecording(t)
+
+	var types []string
+	for _, box := range parseBoxes(t, data) {
+		types = append(types, box.boxType)
+	}
+	if got := strings.Join(types, " "); got != "ftyp moov moof mdat moof mdat" {
+		t.Fatalf("Expected ftyp, moov and two moof/mdat pairs, got %v", types)
+	}
+
+	avcC := findBox(t, data, "moov", "trak", "mdia", "minf", "stbl", "stsd", "avc1", "avcC")
+	sps, pps := ts.sps(), ts.pps()
+	expected := []byte{1, sps[1], sps[2], sps[3], 0xFF, 0xE1, 0, byte(len(sps))}
+	expected = append(expected, sps...)
+	expected = append(expected, 1, 0, byte(len(pps)))
+	expected = append(expected, pps...)
+	if !bytes.Equal(avcC, expected) {
+		t.Errorf("Expected avcC % x, got % x", expected, avcC)
+	}
+
+	tkhd := findBox(t, data, "moov", "trak", "tkhd")
+	if width, height := binary.BigEndian.Uint32(tkhd[76:]), binary.BigEndian.Uint32(tkhd[80:]); width != 32<<16 || height != 32<<16 {
+		t.Errorf("Expected a 32x32 track, got %dx%d", width>>16, height>>16)
+	}
+	if timescale := binary.BigEndian.Uint32(findBox(t, data, "moov", "trak", "mdia", "mdhd")[12:]); timescale != mp4Timescale {
+		t.Errorf("Expected timescale %d, got %d", mp4Timescale, timescale)
+	}
+}
+
+func TestFMP4WriterTimestamps(t *testing.T) {
+	data, _ := testRecording(t)
+
+	var fragments []testTrun
+	for _, box := range parseBoxes(t, data) {
+		if box.boxType == "moof" {
+			fragments = append(fragments, parseFragment(t, box))
+		}
+	}
+	if len(fragments) != 2 {
+		t.Fatalf("Expected a fragment per keyframe, got %d", len(fragments))
+	}
+
+	tests := []struct {
+		baseTime  uint64
+		durations []uint32
+		flags     []uint32
+	}{
+		{0, []uint32{2970, 2970, 3060}, []uint32{mp4SyncSampleFlags, mp4NonSyncSampleFlags, mp4NonSyncSampleFlags}},
+		{9000, []uint32{2970, 2970}, []uint32{mp4SyncSampleFlags, mp4NonSyncSampleFlags}},
+	}
+	for i, tt := range tests {
+		got := fragments[i]
+		if got.baseTime != tt.baseTime {
+			t.Errorf("Fragment %d: expected base time %d, got %d", i, tt.baseTime, got.baseTime)
+		}
+		if !equalUint32s(got.durations, tt.durations) {
+			t.Errorf("Fragment %d: expected durations %v, got %v", i, tt.durations, got.durations)
+		}
+		if !equalUint32s(got.flags, tt.flags) {
+			t.Errorf("Fragment %d: expected flags %x, got %x", i, tt.flags, got.flags)
+		}
+	}
+}
+
+func TestFMP4WriterSamplesDecode(t *testing.T) {
+	data, ts := testRecording(t)
+	var moof testBox
+	for _, box := range parseBoxes(t, data) {
+		if box.boxType == "moof" {
+			moof = box
+			break
+		}
+	}
+	fragment := parseFragment(t, moof)
+
+	// The keyframe sample holds the slice alone, length-prefixed
+	sample := data[fragment.dataStart : fragment.dataStart+int(fragment.sizes[0])]
+	size := binary.BigEndian.Uint32(sample)
+	if int(size) != len(sample)-4 || sample[4]&0x1F != NALUTypeIDR {
+		t.Fatalf("Expected one length-prefixed IDR slice, got % x", sample[:8])
+	}
+
+	img, err := DecodeKeyFrame(testAccessUnit(ts.sps(), ts.pps(), sample[4:]))
+	if err != nil {
+		t.Fatalf("Expected the muxed keyframe to decode, got %v", err)
+	}
+	if y, _, _ := pcmPicture(32, 32); !bytes.Equal(img.Y[:32], y[:32]) {
+		t.Error("Expected the muxed keyframe to decode to the original picture")
+	}
+}
+
+func TestFMP4WriterWaitsForParameterSets(t *testing.T) {
+	var buf bytes.Buffer
+	writer := NewFMP4Writer(&buf)
+
+	idr := testAccessUnit([]byte{0x65, 0x88, 0x84})
+	idr.Timestamp = time.Now()
+	if err := writer.WriteFrame(idr); err != nil {
+		t.Fatalf("WriteFrame failed: %v", err)
+	}
+	if err := writer.Close(); err != nil {
+		t.Fatalf("Close failed: %v", err)
+	}
+	if buf.Len() != 0 {
+		t.Errorf("Expected nothing to be written without an SPS and PPS, got %d bytes", buf.Len())
+	}
+}
+
+func TestRepairFMP4(t *testing.T) {
+	data, _ := testRecording(t)
+	boxes := parseBoxes(t, data)
+	lastMoof := boxes[len(boxes)-2]
+
+	tests := []struct {
+		name     string
+		length   int
+		expected int
+	}{
+		{"Complete", len(data), len(data)},
+		{"Cut in mdat", len(data) - 3, lastMoof.offset},
+		{"Cut after moof", lastMoof.offset + 8 + len(lastMoof.body), lastMoof.offset},
+		{"Cut in moof", lastMoof.offset + 20, lastMoof.offset},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "crashed.mp4")
+			if err := os.WriteFile(path, data[:tt.length], 0o644); err != nil {
+				t.Fatalf("Failed to write test file: %v", err)
+			}
+			if err := RepairFMP4(path); err != nil {
+				t.Fatalf("RepairFMP4 failed: %v", err)
+			}
+			repaired, err := os.ReadFile(path)
+			if err != nil {
+				t.Fatalf("Failed to read repaired file: %v", err)
+			}
+			if len(repaired) != tt.expected {
+				t.Errorf("Expected %d bytes, got %d", tt.expected, len(repaired))
+			}
+			parseBoxes(t, repaired)
+		})
+	}
+
+	if err := RepairFMP4(filepath.Join(t.TempDir(), "missing.mp4")); err == nil {
+		t.Error("Expected an error for a missing file")
+	}
+}
+
+func TestMP4VideoRecorderNativeMuxer(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "flight.mp4")
+	recorder := NewMP4VideoRecorder(path)
+	if err := recorder.SetMuxer("avi"); err == nil {
+		t.Error("Expected an error for an unknown muxer")
+	}
+
+	if err := recorder.StartRecording(); err != nil {
+		t.Fatalf("StartRecording failed: %v", err)
+	}
+	ts := testCodedStream{widthMbs: 2, heightMbs: 2, qp: 26}
+	y, cb, cr := pcmPicture(32, 32)
+	frame := testAccessUnit(ts.sps(), ts.pps(), ts.pcmSlice(y, cb, cr))
+	frame.Timestamp = time.Now()
+	if err := recorder.SaveFrame(frame); err != nil {
+		t.Fatalf("SaveFrame failed: %v", err)
+	}
+	if err := recorder.StopRecording(); err != nil {
+		t.Fatalf("StopRecording failed: %v", err)
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("Failed to read recording: %v", err)
+	}
+	var types []string
+	for _, box := range parseBoxes(t, data) {
+		types = append(types, box.boxType)
+	}
+	if got := strings.Join(types, " "); got != "ftyp moov moof mdat" {
+		t.Errorf("Expected ftyp, moov, moof and mdat, got %v", types)
+	}
+	if stats := recorder.GetStats(); stats["muxer"] != MP4MuxerGo || stats["frame_count"] != 1 {
+		t.Errorf("Expected one frame muxed by the Go muxer, got %v", stats)
+	}
+}
+
+func equalUint32s(a, b []uint32) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,38 @@
+package transport
+
+import (
+	"fmt"
+	"image"
+	"strings"
+)
+
+// FrameDecoder turns reassembled H.264 access units into pictures.
+//
+// Frames must be passed in stream order, including frames that are not
+// displayed, since later frames are predicted from them. Decode returns a nil
+// image without an error while no picture is available yet, e.g. before the
+// first keyframe.
+type FrameDecoder interface {
+	Decode(frame VideoFrame) (*image.YCbCr, error)
+	Close() error
+}
+
+// Frame decoder backends accepted by NewFrameDecoder
+const (
+	DecoderBackendGo     = "go"
+	DecoderBackendFFmpeg = "ffmpeg"
+)
+
+// NewFrameDecoder creates a decoder for the named backend: "go" (the default
+// when empty) for the pure-Go H264Decoder or "ffmpeg" for FFmpegDecoder
+func NewFrameDecoder(backend string) (FrameDecoder, error) {
+	switch strings.ToLower(backend) {
+	case "", DecoderBackendGo:
+		return NewH264Decoder(), nil
+	case DecoderBackendFFmpeg:
+		return NewFFmpegDecoder()
+	default:
+		return nil, fmt.Errorf("unknown video decoder backend %q (expected %q or %q)",
+			backend, DecoderBackendGo, DecoderBackendFFmpeg)
+	}
+}
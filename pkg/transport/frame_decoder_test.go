@@ -0,0 +1,190 @@
+package transport
+
+import (
+	"image"
+	"net"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestNewFrameDecoder(t *testing.T) {
+	for _, backend := range []string{"", "go", "Go"} {
+		decoder, err := NewFrameDecoder(backend)
+		if err != nil {
+			t.Fatalf("Expected no error for backend %q, got %v", backend, err)
+		}
+		if _, ok := decoder.(*H264Decoder); !ok {
+			t.Errorf("Expected H264Decoder for backend %q, got %T", backend, decoder)
+		}
+	}
+
+	if _, err := NewFrameDecoder("vaapi"); err == nil {
+		t.Error("Expected an error for an unknown backend")
+	}
+}
+
+// fakeFFmpeg writes a script that ignores its arguments, outputs one grey
+// 16x16 picture and then consumes its input like FFmpeg would
+func fakeFFmpeg(t *testing.T) string {
+	t.Helper()
+	if _, err := exec.LookPath("sh"); err != nil {
+		t.Skip("sh not available")
+	}
+	path := filepath.Join(t.TempDir(), "ffmpeg")
+	script := "#!/bin/sh\nhead -c 384 /dev/zero | tr '\\000' '\\200'\ncat > /dev/null\n"
+	if err := os.WriteFile(path, []byte(script), 0o755); err != nil {
+		t.Fatalf("Failed to write fake ffmpeg: %v", err)
+	}
+	return path
+}
+
+func TestFFmpegDecoder(t *testing.T) {
+	fd := newFFmpegDecoder(fakeFFmpeg(t))
+	defer fd.Close()
+
+	ts := testCodedStream{widthMbs: 1, heightMbs: 1, qp: 26}
+	y, cb, cr := pcmPicture(16, 16)
+	keyframe := testAccessUnit(ts.sps(), ts.pps(), ts.pcmSlice(y, cb, cr))
+
+	// P frames before the first keyframe are dropped without starting FFmpeg
+	w := ts.sliceHeader(false, 1)
+	w.ue(1)
+	if img, err := fd.Decode(testAccessUnit(w.nal(0x41))); img != nil || err != nil {
+		t.Fatalf("Expected nothing before the first keyframe, got %v, %v", img, err)
+	}
+
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		img, err := fd.Decode(keyframe)
+		if err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+		if img != nil {
+			if img.Bounds().Dx() != 16 || img.Bounds().Dy() != 16 {
+				t.Errorf("Expected 16x16 picture, got %v", img.Bounds())
+			}
+			if img.Y[0] != 0x80 || img.Cr[len(img.Cr)-1] != 0x80 {
+				t.Errorf("Expected picture from FFmpeg output, got Y %d Cr %d", img.Y[0], img.Cr[len(img.Cr)-1])
+			}
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("Timed out waiting for a picture from FFmpeg")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	if err := fd.Close(); err != nil {
+		t.Errorf("Expected no error on close, got %v", err)
+	}
+	if fd.cmd != nil {
+		t.Error("Expected FFmpeg to be stopped after close")
+	}
+}
+
+func TestVideoStreamListener_SetDecoder(t *testing.T) {
+	listener, err := NewVideoStreamListener("127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Failed to create listener: %v", err)
+	}
+	listener.SetDecoder(NewH264Decoder())
+	defer listener.Close()
+
+	ts := testCodedStream{widthMbs: 1, heightMbs: 1, qp: 26}
+	y, cb, cr := pcmPicture(16, 16)
+	// The reassembler emits a frame once the next access unit starts
+	w := ts.sliceHeader(false, 1)
+	w.ue(1)
+	listener.onVideoStreamData(testAccessUnit(ts.sps(), ts.pps(), ts.pcmSlice(y, cb, cr)).Data, &net.UDPAddr{})
+	listener.onVideoStreamData(testAccessUnit(w.nal(0x41)).Data, &net.UDPAddr{})
+
+	select {
+	case frame := <-listener.GetFrameChannel():
+		if frame.Image == nil {
+			t.Fatal("Expected frame to carry a decoded picture")
+		}
+		comparePlane(t, "Y", frame.Image.Y, frame.Image.YStride, y, 16, 16)
+
+		enhanced := frame.ToEnhancedFrame()
+		if enhanced.Width != 16 || enhanced.Height != 16 {
+			t.Errorf("Expected 16x16 enhanced frame, got %dx%d", enhanced.Width, enhanced.Height)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("Expected a frame on the channel")
+	}
+}
+
+// blockingDecoder decodes every frame into a blank picture once released
+type blockingDecoder struct {
+	entered chan int // SeqNum of each frame passed to Decode
+	release chan struct{}
+}
+
+func (d *blockingDecoder) Decode(frame VideoFrame) (*image.YCbCr, error) {
+	d.entered <- frame.SeqNum
+	<-d.release
+	return image.NewYCbCr(image.Rect(0, 0, 16, 16), image.YCbCrSubsampleRatio420), nil
+}
+
+func (d *blockingDecoder) Close() error { return nil }
+
+func TestVideoStreamListener_SlowDecoderDropsOldest(t *testing.T) {
+	listener, err := NewVideoStreamListener("127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Failed to create listener: %v", err)
+	}
+	decoder := &blockingDecoder{entered: make(chan int, 100), release: make(chan struct{})}
+	listener.SetDecoder(decoder)
+	frameChan := listener.GetFrameChannel()
+
+	push := func(au []byte) {
+		for _, packet := range packetize(au) {
+			listener.onVideoStreamData(packet, &net.UDPAddr{})
+		}
+	}
+
+	// Frame 0, the first keyframe, keeps the decoder busy
+	push(concat(testSPS, testPPS, testIDR(1000)))
+	select {
+	case <-decoder.entered:
+	case <-time.After(time.Second):
+		t.Fatal("Expected the decoder to receive the first keyframe")
+	}
+
+	// Frames 1-20 are P frames, 21 a keyframe and 22 a P frame. The reader
+	// must not wait for the decoder, so only the newest frames stay queued.
+	for range 20 {
+		push(testP(500))
+	}
+	push(testIDR(1000))
+	push(testP(500))
+
+	close(decoder.release)
+	listener.Close()
+
+	if dropped := listener.Stats().DecodeDroppedFrames; dropped != 22-decodeQueueSize {
+		t.Errorf("Expected %d frames dropped for the decoder, got %d", 22-decodeQueueSize, dropped)
+	}
+
+	decoded := []int{0}
+	for len(decoder.entered) > 0 {
+		decoded = append(decoded, <-decoder.entered)
+	}
+	if len(decoded) != 3 || decoded[1] != 21 || decoded[2] != 22 {
+		t.Errorf("Expected decoding to resume at the next keyframe, got %v", decoded)
+	}
+
+	var delivered []int
+	for frame := range frameChan {
+		delivered = append(delivered, frame.SeqNum)
+		if hasImage := frame.Image != nil; hasImage != (frame.SeqNum == 0 || frame.SeqNum >= 21) {
+			t.Errorf("Frame %d: unexpected picture %v", frame.SeqNum, hasImage)
+		}
+	}
+	if len(delivered) != 1+decodeQueueSize || delivered[0] != 0 || delivered[1] != 23-decodeQueueSize {
+		t.Errorf("Expected frame 0 and the last %d frames, got %v", decodeQueueSize, delivered)
+	}
+}
@@ -0,0 +1,220 @@
+package transport
+
+import "fmt"
+
+// h264BitReader reads the syntax elements of an H.264 RBSP. Reads past the end
+// return zero bits and mark the reader as overrun, so that callers can check
+// for truncated data once per macroblock instead of after every element.
+type h264BitReader struct {
+	data    []byte
+	pos     int // Bit position
+	end     int // Bit position of the rbsp_stop_one_bit
+	overrun bool
+	invalid bool
+}
+
+// newH264BitReader returns a reader over a NAL unit payload. The NAL header
+// byte must already be stripped; emulation prevention bytes are removed here.
+func newH264BitReader(payload []byte) *h264BitReader {
+	data := unescapeRBSP(payload)
+
+	end := len(data) * 8
+	for i := len(data) - 1; i >= 0; i-- {
+		if data[i] == 0 {
+			continue
+		}
+		for bit := 0; bit < 8; bit++ {
+			if data[i]&(1<<uint(bit)) != 0 {
+				end = i*8 + 7 - bit
+				break
+			}
+		}
+		break
+	}
+
+	return &h264BitReader{data: data, end: end}
+}
+
+// unescapeRBSP removes the emulation prevention bytes (0x03 following two zero
+// bytes) from a NAL unit payload
+func unescapeRBSP(payload []byte) []byte {
+	escaped := false
+	for i := 2; i < len(payload); i++ {
+		if payload[i] == 0x03 && payload[i-1] == 0x00 && payload[i-2] == 0x00 {
+			escaped = true
+			break
+		}
+	}
+	if !escaped {
+		return payload
+	}
+
+	rbsp := make([]byte, 0, len(payload))
+	zeros := 0
+	for _, b := range payload {
+		if zeros >= 2 && b == 0x03 {
+			zeros = 0
+			continue
+		}
+		rbsp = append(rbsp, b)
+		if b == 0x00 {
+			zeros++
+		} else {
+			zeros = 0
+		}
+	}
+	return rbsp
+}
+
+func (br *h264BitReader) readBit() int {
+	if br.pos >= len(br.data)*8 {
+		br.overrun = true
+		return 0
+	}
+	bit := int(br.data[br.pos>>3]>>(7-uint(br.pos&7))) & 1
+	br.pos++
+	return bit
+}
+
+func (br *h264BitReader) readFlag() bool {
+	return br.readBit() == 1
+}
+
+// readBits reads n (at most 32) bits as an unsigned integer
+func (br *h264BitReader) readBits(n int) int {
+	v := br.peekBits(n)
+	br.skipBits(n)
+	return v
+}
+
+// peekBits returns the next n (at most 32) bits without consuming them,
+// padding with zeros past the end of the data
+func (br *h264BitReader) peekBits(n int) int {
+	var v uint64
+	pos := br.pos
+	for i := 0; i < n; {
+		byteIdx := pos >> 3
+		if byteIdx >= len(br.data) {
+			v <<= uint(n - i)
+			break
+		}
+		// Take as many bits as possible from the current byte
+		avail := 8 - pos&7
+		take := n - i
+		if take > avail {
+			take = avail
+		}
+		bits := (uint64(br.data[byteIdx]) >> uint(avail-take)) & (1<<uint(take) - 1)
+		v = v<<uint(take) | bits
+		pos += take
+		i += take
+	}
+	return int(v)
+}
+
+func (br *h264BitReader) skipBits(n int) {
+	br.pos += n
+	if br.pos > len(br.data)*8 {
+		br.overrun = true
+	}
+}
+
+// readUE reads an unsigned Exp-Golomb code, ue(v)
+func (br *h264BitReader) readUE() int {
+	zeros := 0
+	for br.readBit() == 0 {
+		zeros++
+		if zeros > 31 {
+			br.invalid = true
+			return 0
+		}
+	}
+	if zeros == 0 {
+		return 0
+	}
+	return (1 << uint(zeros)) - 1 + br.readBits(zeros)
+}
+
+// readSE reads a signed Exp-Golomb code, se(v)
+func (br *h264BitReader) readSE() int {
+	k := br.readUE()
+	if k&1 == 1 {
+		return (k + 1) / 2
+	}
+	return -(k / 2)
+}
+
+// readTE reads a truncated Exp-Golomb code, te(v), with the given maximum
+func (br *h264BitReader) readTE(max int) int {
+	if max > 1 {
+		return br.readUE()
+	}
+	return 1 - br.readBit()
+}
+
+func (br *h264BitReader) byteAligned() bool {
+	return br.pos&7 == 0
+}
+
+func (br *h264BitReader) alignByte() {
+	br.pos = (br.pos + 7) &^ 7
+}
+
+// moreRBSPData reports whether syntax elements remain before the trailing bits
+func (br *h264BitReader) moreRBSPData() bool {
+	return br.pos < br.end
+}
+
+// err reports a read past the end of the data or an invalid code
+func (br *h264BitReader) err() error {
+	if br.invalid {
+		return fmt.Errorf("invalid code at bit %d", br.pos)
+	}
+	if br.overrun {
+		return fmt.Errorf("unexpected end of NAL unit data")
+	}
+	return nil
+}
+
+// h264VLC is a lookup table for a variable length code. Each entry holds the
+// code length in the high byte and the symbol in the low byte; zero marks an
+// invalid code.
+type h264VLC struct {
+	bits  int
+	table []uint16
+}
+
+// newH264VLC builds a lookup table from per-symbol code lengths and values.
+// Symbols with a zero length do not occur.
+func newH264VLC(lengths, codes []uint8) *h264VLC {
+	maxLen := 0
+	for _, l := range lengths {
+		if int(l) > maxLen {
+			maxLen = int(l)
+		}
+	}
+
+	v := &h264VLC{bits: maxLen, table: make([]uint16, 1<<uint(maxLen))}
+	for sym, l := range lengths {
+		if l == 0 {
+			continue
+		}
+		shift := uint(maxLen - int(l))
+		first := int(codes[sym]) << shift
+		for i := 0; i < 1<<shift; i++ {
+			v.table[first+i] = uint16(l)<<8 | uint16(sym)
+		}
+	}
+	return v
+}
+
+// readVLC decodes one symbol, returning -1 for an invalid code
+func (br *h264BitReader) readVLC(v *h264VLC) int {
+	entry := v.table[br.peekBits(v.bits)]
+	if entry == 0 {
+		br.invalid = true
+		return -1
+	}
+	br.skipBits(int(entry >> 8))
+	return int(entry & 0xFF)
+}
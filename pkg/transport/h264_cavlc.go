@@ -0,0 +1,319 @@
+package transport
+
+import "sync"
+
+// CAVLC code tables from ITU-T H.264 section 9.2. coeff_token symbols are
+// indexed by TotalCoeff*4 + TrailingOnes.
+
+var coeffTokenLengths = [4][4 * 17]uint8{
+	{ // 0 <= nC < 2
+		1, 0, 0, 0,
+		6, 2, 0, 0, 8, 6, 3, 0, 9, 8, 7, 5, 10, 9, 8, 6,
+		11, 10, 9, 7, 13, 11, 10, 8, 13, 13, 11, 9, 13, 13, 13, 10,
+		14, 14, 13, 11, 14, 14, 14, 13, 15, 15, 14, 14, 15, 15, 15, 14,
+		16, 15, 15, 15, 16, 16, 16, 15, 16, 16, 16, 16, 16, 16, 16, 16,
+	},
+	{ // 2 <= nC < 4
+		2, 0, 0, 0,
+		6, 2, 0, 0, 6, 5, 3, 0, 7, 6, 6, 4, 8, 6, 6, 4,
+		8, 7, 7, 5, 9, 8, 8, 6, 11, 9, 9, 6, 11, 11, 11, 7,
+		12, 11, 11, 9, 12, 12, 12, 11, 12, 12, 12, 11, 13, 13, 13, 12,
+		13, 13, 13, 13, 13, 14, 13, 13, 14, 14, 14, 13, 14, 14, 14, 14,
+	},
+	{ // 4 <= nC < 8
+		4, 0, 0, 0,
+		6, 4, 0, 0, 6, 5, 4, 0, 6, 5, 5, 4, 7, 5, 5, 4,
+		7, 5, 5, 4, 7, 6, 6, 4, 7, 6, 6, 4, 8, 7, 7, 5,
+		8, 8, 7, 6, 9, 8, 8, 7, 9, 9, 8, 8, 9, 9, 9, 8,
+		10, 9, 9, 9, 10, 10, 10, 10, 10, 10, 10, 10, 10, 10, 10, 10,
+	},
+	{ // 8 <= nC
+		6, 0, 0, 0,
+		6, 6, 0, 0, 6, 6, 6, 0, 6, 6, 6, 6, 6, 6, 6, 6,
+		6, 6, 6, 6, 6, 6, 6, 6, 6, 6, 6, 6, 6, 6, 6, 6,
+		6, 6, 6, 6, 6, 6, 6, 6, 6, 6, 6, 6, 6, 6, 6, 6,
+		6, 6, 6, 6, 6, 6, 6, 6, 6, 6, 6, 6, 6, 6, 6, 6,
+	},
+}
+
+var coeffTokenCodes = [4][4 * 17]uint8{
+	{
+		1, 0, 0, 0,
+		5, 1, 0, 0, 7, 4, 1, 0, 7, 6, 5, 3, 7, 6, 5, 3,
+		7, 6, 5, 4, 15, 6, 5, 4, 11, 14, 5, 4, 8, 10, 13, 4,
+		15, 14, 9, 4, 11, 10, 13, 12, 15, 14, 9, 12, 11, 10, 13, 8,
+		15, 1, 9, 12, 11, 14, 13, 8, 7, 10, 9, 12, 4, 6, 5, 8,
+	},
+	{
+		3, 0, 0, 0,
+		11, 2, 0, 0, 7, 7, 3, 0, 7, 10, 9, 5, 7, 6, 5, 4,
+		4, 6, 5, 6, 7, 6, 5, 8, 15, 6, 5, 4, 11, 14, 13, 4,
+		15, 10, 9, 4, 11, 14, 13, 12, 8, 10, 9, 8, 15, 14, 13, 12,
+		11, 10, 9, 12, 7, 11, 6, 8, 9, 8, 10, 1, 7, 6, 5, 4,
+	},
+	{
+		15, 0, 0, 0,
+		15, 14, 0, 0, 11, 15, 13, 0, 8, 12, 14, 12, 15, 10, 11, 11,
+		11, 8, 9, 10, 9, 14, 13, 9, 8, 10, 9, 8, 15, 14, 13, 13,
+		11, 14, 10, 12, 15, 10, 13, 12, 11, 14, 9, 12, 8, 10, 13, 8,
+		13, 7, 9, 12, 9, 12, 11, 10, 5, 8, 7, 6, 1, 4, 3, 2,
+	},
+	{
+		3, 0, 0, 0,
+		0, 1, 0, 0, 4, 5, 6, 0, 8, 9, 10, 11, 12, 13, 14, 15,
+		16, 17, 18, 19, 20, 21, 22, 23, 24, 25, 26, 27, 28, 29, 30, 31,
+		32, 33, 34, 35, 36, 37, 38, 39, 40, 41, 42, 43, 44, 45, 46, 47,
+		48, 49, 50, 51, 52, 53, 54, 55, 56, 57, 58, 59, 60, 61, 62, 63,
+	},
+}
+
+// coeff_token for chroma DC (nC == -1)
+var (
+	chromaDCCoeffTokenLengths = [4 * 5]uint8{
+		2, 0, 0, 0,
+		6, 1, 0, 0,
+		6, 6, 3, 0,
+		6, 7, 7, 6,
+		6, 8, 8, 7,
+	}
+	chromaDCCoeffTokenCodes = [4 * 5]uint8{
+		1, 0, 0, 0,
+		7, 1, 0, 0,
+		4, 6, 1, 0,
+		3, 3, 2, 5,
+		2, 3, 2, 0,
+	}
+)
+
+// total_zeros for 4x4 blocks, indexed by TotalCoeff-1
+var (
+	totalZerosLengths = [15][16]uint8{
+		{1, 3, 3, 4, 4, 5, 5, 6, 6, 7, 7, 8, 8, 9, 9, 9},
+		{3, 3, 3, 3, 3, 4, 4, 4, 4, 5, 5, 6, 6, 6, 6},
+		{4, 3, 3, 3, 4, 4, 3, 3, 4, 5, 5, 6, 5, 6},
+		{5, 3, 4, 4, 3, 3, 3, 4, 3, 4, 5, 5, 5},
+		{4, 4, 4, 3, 3, 3, 3, 3, 4, 5, 4, 5},
+		{6, 5, 3, 3, 3, 3, 3, 3, 4, 3, 6},
+		{6, 5, 3, 3, 3, 2, 3, 4, 3, 6},
+		{6, 4, 5, 3, 2, 2, 3, 3, 6},
+		{6, 6, 4, 2, 2, 3, 2, 5},
+		{5, 5, 3, 2, 2, 2, 4},
+		{4, 4, 3, 3, 1, 3},
+		{4, 4, 2, 1, 3},
+		{3, 3, 1, 2},
+		{2, 2, 1},
+		{1, 1},
+	}
+	totalZerosCodes = [15][16]uint8{
+		{1, 3, 2, 3, 2, 3, 2, 3, 2, 3, 2, 3, 2, 3, 2, 1},
+		{7, 6, 5, 4, 3, 5, 4, 3, 2, 3, 2, 3, 2, 1, 0},
+		{5, 7, 6, 5, 4, 3, 4, 3, 2, 3, 2, 1, 1, 0},
+		{3, 7, 5, 4, 6, 5, 4, 3, 3, 2, 2, 1, 0},
+		{5, 4, 3, 7, 6, 5, 4, 3, 2, 1, 1, 0},
+		{1, 1, 7, 6, 5, 4, 3, 2, 1, 1, 0},
+		{1, 1, 5, 4, 3, 3, 2, 1, 1, 0},
+		{1, 1, 1, 3, 3, 2, 2, 1, 0},
+		{1, 0, 1, 3, 2, 1, 1, 1},
+		{1, 0, 1, 3, 2, 1, 1},
+		{0, 1, 1, 2, 1, 3},
+		{0, 1, 1, 1, 1},
+		{0, 1, 1, 1},
+		{0, 1, 1},
+		{0, 1},
+	}
+)
+
+// total_zeros for chroma DC, indexed by TotalCoeff-1
+var (
+	chromaDCTotalZerosLengths = [3][4]uint8{
+		{1, 2, 3, 3},
+		{1, 2, 2},
+		{1, 1},
+	}
+	chromaDCTotalZerosCodes = [3][4]uint8{
+		{1, 1, 1, 0},
+		{1, 1, 0},
+		{1, 0},
+	}
+)
+
+// run_before, indexed by min(zerosLeft, 7)-1
+var (
+	runBeforeLengths = [7][15]uint8{
+		{1, 1},
+		{1, 2, 2},
+		{2, 2, 2, 2},
+		{2, 2, 2, 3, 3},
+		{2, 2, 3, 3, 3, 3},
+		{2, 3, 3, 3, 3, 3, 3},
+		{3, 3, 3, 3, 3, 3, 3, 4, 5, 6, 7, 8, 9, 10, 11},
+	}
+	runBeforeCodes = [7][15]uint8{
+		{1, 0},
+		{1, 1, 0},
+		{3, 2, 1, 0},
+		{3, 2, 1, 1, 0},
+		{3, 2, 3, 2, 1, 0},
+		{3, 0, 1, 3, 2, 5, 4},
+		{7, 6, 5, 4, 3, 2, 1, 1, 1, 1, 1, 1, 1, 1, 1},
+	}
+)
+
+// cavlcTables holds the lookup tables built from the code tables above
+type cavlcTables struct {
+	coeffToken        [4]*h264VLC
+	chromaDCToken     *h264VLC
+	totalZeros        [15]*h264VLC
+	chromaDCTotalZero [3]*h264VLC
+	runBefore         [7]*h264VLC
+}
+
+var (
+	cavlcOnce sync.Once
+	cavlc     cavlcTables
+)
+
+func getCAVLCTables() *cavlcTables {
+	cavlcOnce.Do(func() {
+		for i := range cavlc.coeffToken {
+			cavlc.coeffToken[i] = newH264VLC(coeffTokenLengths[i][:], coeffTokenCodes[i][:])
+		}
+		cavlc.chromaDCToken = newH264VLC(chromaDCCoeffTokenLengths[:], chromaDCCoeffTokenCodes[:])
+		for i := range cavlc.totalZeros {
+			cavlc.totalZeros[i] = newH264VLC(totalZerosLengths[i][:], totalZerosCodes[i][:])
+		}
+		for i := range cavlc.chromaDCTotalZero {
+			cavlc.chromaDCTotalZero[i] = newH264VLC(chromaDCTotalZerosLengths[i][:], chromaDCTotalZerosCodes[i][:])
+		}
+		for i := range cavlc.runBefore {
+			cavlc.runBefore[i] = newH264VLC(runBeforeLengths[i][:], runBeforeCodes[i][:])
+		}
+	})
+	return &cavlc
+}
+
+// readResidualBlock decodes one CAVLC residual block (section 9.2) into
+// coeffs[startIdx:startIdx+maxNumCoeff] in scan order and returns TotalCoeff.
+// nC selects the coeff_token table; -1 selects the chroma DC table.
+func (br *h264BitReader) readResidualBlock(coeffs []int32, startIdx, maxNumCoeff, nC int) int {
+	t := getCAVLCTables()
+
+	var token int
+	switch {
+	case nC == -1:
+		token = br.readVLC(t.chromaDCToken)
+	case nC < 2:
+		token = br.readVLC(t.coeffToken[0])
+	case nC < 4:
+		token = br.readVLC(t.coeffToken[1])
+	case nC < 8:
+		token = br.readVLC(t.coeffToken[2])
+	default:
+		token = br.readVLC(t.coeffToken[3])
+	}
+	if token < 0 {
+		return 0
+	}
+
+	totalCoeff, trailingOnes := token>>2, token&3
+	if totalCoeff == 0 {
+		return 0
+	}
+	if totalCoeff > maxNumCoeff {
+		br.invalid = true
+		return 0
+	}
+
+	// Levels, highest frequency first
+	var levels [16]int32
+	suffixLength := 0
+	if totalCoeff > 10 && trailingOnes < 3 {
+		suffixLength = 1
+	}
+	for i := 0; i < totalCoeff; i++ {
+		if i < trailingOnes {
+			levels[i] = int32(1 - 2*br.readBit())
+			continue
+		}
+
+		levelPrefix := 0
+		for br.readBit() == 0 {
+			levelPrefix++
+			if levelPrefix > 32 {
+				br.invalid = true
+				return 0
+			}
+		}
+
+		levelCode := min(15, levelPrefix) << uint(suffixLength)
+		suffixSize := suffixLength
+		if levelPrefix == 14 && suffixLength == 0 {
+			suffixSize = 4
+		} else if levelPrefix >= 15 {
+			suffixSize = levelPrefix - 3
+		}
+		if suffixSize > 0 {
+			levelCode += br.readBits(suffixSize)
+		}
+		if levelPrefix >= 15 && suffixLength == 0 {
+			levelCode += 15
+		}
+		if levelPrefix >= 16 {
+			levelCode += (1 << uint(levelPrefix-3)) - 4096
+		}
+		if i == trailingOnes && trailingOnes < 3 {
+			levelCode += 2
+		}
+
+		if levelCode%2 == 0 {
+			levels[i] = int32((levelCode + 2) >> 1)
+		} else {
+			levels[i] = int32((-levelCode - 1) >> 1)
+		}
+
+		if suffixLength == 0 {
+			suffixLength = 1
+		}
+		if abs32(levels[i]) > 3<<uint(suffixLength-1) && suffixLength < 6 {
+			suffixLength++
+		}
+	}
+
+	// Zeros interleaved with the levels
+	zerosLeft := 0
+	if totalCoeff < maxNumCoeff {
+		if nC == -1 {
+			zerosLeft = br.readVLC(t.chromaDCTotalZero[totalCoeff-1])
+		} else {
+			zerosLeft = br.readVLC(t.totalZeros[totalCoeff-1])
+		}
+		if zerosLeft < 0 || zerosLeft+totalCoeff > maxNumCoeff {
+			br.invalid = true
+			return 0
+		}
+	}
+
+	coeffNum := zerosLeft + totalCoeff - 1
+	for i := 0; i < totalCoeff; i++ {
+		coeffs[startIdx+coeffNum] = levels[i]
+		run := 0
+		if i < totalCoeff-1 && zerosLeft > 0 {
+			run = br.readVLC(t.runBefore[min(zerosLeft, 7)-1])
+			if run < 0 || run > zerosLeft {
+				br.invalid = true
+				return 0
+			}
+			zerosLeft -= run
+		}
+		coeffNum -= run + 1
+	}
+
+	return totalCoeff
+}
+
+func abs32(v int32) int32 {
+	if v < 0 {
+		return -v
+	}
+	return v
+}
@@ -0,0 +1,295 @@
+package transport
+
+// Deblocking filter (section 8.7), applied to the whole picture once all of
+// its slices are decoded.
+
+var deblockAlpha = [52]int32{
+	0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0,
+	4, 4, 5, 6, 7, 8, 9, 10, 12, 13, 15, 17, 20, 22, 25, 28,
+	32, 36, 40, 45, 50, 56, 63, 71, 80, 90, 101, 113, 127, 144, 162, 182,
+	203, 226, 255, 255,
+}
+
+var deblockBeta = [52]int32{
+	0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0,
+	2, 2, 2, 3, 3, 3, 3, 4, 4, 4, 6, 6, 7, 7, 8, 8,
+	9, 9, 10, 10, 11, 11, 12, 12, 13, 13, 14, 14, 15, 15, 16, 16,
+	17, 17, 18, 18,
+}
+
+// deblockTC0 is indexed by indexA and bS-1
+var deblockTC0 = [52][3]int32{
+	{0, 0, 0}, {0, 0, 0}, {0, 0, 0}, {0, 0, 0}, {0, 0, 0}, {0, 0, 0}, {0, 0, 0}, {0, 0, 0},
+	{0, 0, 0}, {0, 0, 0}, {0, 0, 0}, {0, 0, 0}, {0, 0, 0}, {0, 0, 0}, {0, 0, 0}, {0, 0, 0},
+	{0, 0, 0}, {0, 0, 1}, {0, 0, 1}, {0, 0, 1}, {0, 0, 1}, {0, 1, 1}, {0, 1, 1}, {1, 1, 1},
+	{1, 1, 1}, {1, 1, 1}, {1, 1, 1}, {1, 1, 2}, {1, 1, 2}, {1, 1, 2}, {1, 1, 2}, {1, 2, 3},
+	{1, 2, 3}, {2, 2, 3}, {2, 2, 4}, {2, 3, 4}, {2, 3, 4}, {3, 3, 5}, {3, 4, 6}, {3, 4, 6},
+	{4, 5, 7}, {4, 5, 8}, {4, 6, 9}, {5, 7, 10}, {6, 8, 11}, {6, 8, 13}, {7, 10, 14}, {8, 11, 16},
+	{9, 12, 18}, {10, 13, 20}, {11, 15, 23}, {13, 17, 25},
+}
+
+// deblockPicture filters the edges of every decoded macroblock
+func (d *H264Decoder) deblockPicture() {
+	for addr := range d.mbs {
+		mb := &d.mbs[addr]
+		if mb.slice < 0 {
+			continue
+		}
+		sh := d.slices[mb.slice]
+		if sh.disableDeblock == 1 {
+			continue
+		}
+
+		mbX, mbY := addr%d.widthMbs, addr/d.widthMbs
+		var left, top *h264Macroblock
+		if mbX > 0 {
+			left = &d.mbs[addr-1]
+		}
+		if mbY > 0 {
+			top = &d.mbs[addr-d.widthMbs]
+		}
+		// Edges shared with undecoded or, in mode 2, other slices' macroblocks
+		// are left alone
+		if left != nil && (left.slice < 0 || sh.disableDeblock == 2 && left.slice != mb.slice) {
+			left = nil
+		}
+		if top != nil && (top.slice < 0 || sh.disableDeblock == 2 && top.slice != mb.slice) {
+			top = nil
+		}
+
+		for _, vertical := range []bool{true, false} {
+			neighbour := left
+			if !vertical {
+				neighbour = top
+			}
+			for edge := 0; edge < 4; edge++ {
+				p := mb
+				if edge == 0 {
+					if neighbour == nil {
+						continue
+					}
+					p = neighbour
+				}
+
+				var bS [4]int32
+				filter := false
+				for k := 0; k < 4; k++ {
+					bS[k] = boundaryStrength(p, mb, edge, k, vertical)
+					filter = filter || bS[k] != 0
+				}
+				if !filter {
+					continue
+				}
+
+				d.filterLumaEdge(mbX, mbY, edge, vertical, bS, p.deblockQP(), mb.deblockQP(), sh)
+				if edge%2 == 0 {
+					for c := 0; c < 2; c++ {
+						offset := sh.pps.chromaQPOffset[c]
+						qpP := chromaQP(p.deblockQP(), offset)
+						qpQ := chromaQP(mb.deblockQP(), offset)
+						d.filterChromaEdge(c, mbX, mbY, edge/2, vertical, bS, qpP, qpQ, sh)
+					}
+				}
+			}
+		}
+	}
+}
+
+// deblockQP returns the QP used by the filter; I_PCM macroblocks count as 0
+func (mb *h264Macroblock) deblockQP() int {
+	if mb.kind == mbIPCM {
+		return 0
+	}
+	return mb.qp
+}
+
+// boundaryStrength derives bS for the k-th 4-sample segment of a luma edge.
+// p is the macroblock on the left or top side of the edge, q the current one.
+func boundaryStrength(p, q *h264Macroblock, edge, k int, vertical bool) int32 {
+	mbEdge := edge == 0
+	if p.isIntra() || q.isIntra() {
+		if mbEdge {
+			return 4
+		}
+		return 3
+	}
+
+	// 4x4 block indices on either side of the edge
+	var bp, bq int
+	if vertical {
+		bq = k*4 + edge
+		bp = k*4 + (edge+3)%4
+	} else {
+		bq = edge*4 + k
+		bp = ((edge+3)%4)*4 + k
+	}
+
+	if p.nonZero[bp] != 0 || q.nonZero[bq] != 0 {
+		return 2
+	}
+
+	refP := p.refPic[(bp>>3)*2+(bp&3)>>1]
+	refQ := q.refPic[(bq>>3)*2+(bq&3)>>1]
+	if refP != refQ {
+		return 1
+	}
+	mvP, mvQ := p.mv[bp], q.mv[bq]
+	if abs16(mvP[0]-mvQ[0]) >= 4 || abs16(mvP[1]-mvQ[1]) >= 4 {
+		return 1
+	}
+	return 0
+}
+
+func abs16(v int16) int16 {
+	if v < 0 {
+		return -v
+	}
+	return v
+}
+
+// edgeThresholds returns alpha, beta and indexA for an edge
+func edgeThresholds(qpP, qpQ int, sh *h264SliceHeader) (alpha, beta int32, indexA int) {
+	qpAv := (qpP + qpQ + 1) >> 1
+	indexA = clip3(0, 51, qpAv+sh.alphaOffset)
+	indexB := clip3(0, 51, qpAv+sh.betaOffset)
+	return deblockAlpha[indexA], deblockBeta[indexB], indexA
+}
+
+// filterLumaEdge filters one 16-sample luma edge of the macroblock
+func (d *H264Decoder) filterLumaEdge(mbX, mbY, edge int, vertical bool, bS [4]int32, qpP, qpQ int, sh *h264SliceHeader) {
+	alpha, beta, indexA := edgeThresholds(qpP, qpQ, sh)
+	if alpha == 0 || beta == 0 {
+		return
+	}
+
+	img := d.pic.img
+	stride := img.YStride
+	x0, y0 := mbX*16, mbY*16
+
+	for i := 0; i < 16; i++ {
+		strength := bS[i/4]
+		if strength == 0 {
+			continue
+		}
+		// q0 position and the step from one sample to the next across the edge
+		var pos, step int
+		if vertical {
+			pos, step = (y0+i)*stride+x0+edge*4, 1
+		} else {
+			pos, step = (y0+edge*4)*stride+x0+i, stride
+		}
+		filterSamples(img.Y, pos, step, strength, alpha, beta, indexA, false)
+	}
+}
+
+// filterChromaEdge filters one 8-sample chroma edge; chroma edge k lies on
+// luma edge 2k and shares its boundary strengths
+func (d *H264Decoder) filterChromaEdge(c, mbX, mbY, edge int, vertical bool, bS [4]int32, qpP, qpQ int, sh *h264SliceHeader) {
+	alpha, beta, indexA := edgeThresholds(qpP, qpQ, sh)
+	if alpha == 0 || beta == 0 {
+		return
+	}
+
+	img := d.pic.img
+	plane := img.Cb
+	if c == 1 {
+		plane = img.Cr
+	}
+	stride := img.CStride
+	x0, y0 := mbX*8, mbY*8
+
+	for i := 0; i < 8; i++ {
+		strength := bS[i/2]
+		if strength == 0 {
+			continue
+		}
+		var pos, step int
+		if vertical {
+			pos, step = (y0+i)*stride+x0+edge*4, 1
+		} else {
+			pos, step = (y0+edge*4)*stride+x0+i, stride
+		}
+		filterSamples(plane, pos, step, strength, alpha, beta, indexA, true)
+	}
+}
+
+// filterSamples filters the samples on a line across an edge (section
+// 8.7.2.3 and 8.7.2.4). pos is the index of q0 and step the distance between
+// neighbouring samples on the line.
+func filterSamples(pix []byte, pos, step int, bS, alpha, beta int32, indexA int, chroma bool) {
+	p0, p1 := int32(pix[pos-step]), int32(pix[pos-2*step])
+	q0, q1 := int32(pix[pos]), int32(pix[pos+step])
+
+	if abs32(p0-q0) >= alpha || abs32(p1-p0) >= beta || abs32(q1-q0) >= beta {
+		return
+	}
+
+	var p2, q2 int32
+	var ap, aq int32
+	if !chroma {
+		p2, q2 = int32(pix[pos-3*step]), int32(pix[pos+2*step])
+		ap, aq = abs32(p2-p0), abs32(q2-q0)
+	}
+
+	if bS < 4 {
+		tc0 := deblockTC0[indexA][bS-1]
+		tc := tc0 + 1
+		if !chroma {
+			tc = tc0
+			if ap < beta {
+				tc++
+			}
+			if aq < beta {
+				tc++
+			}
+		}
+
+		delta := clip3i32(-tc, tc, (((q0-p0)<<2)+(p1-q1)+4)>>3)
+		pix[pos-step] = clip1(p0 + delta)
+		pix[pos] = clip1(q0 - delta)
+
+		if !chroma {
+			if ap < beta {
+				pix[pos-2*step] = byte(p1 + clip3i32(-tc0, tc0, (p2+((p0+q0+1)>>1)-(p1<<1))>>1))
+			}
+			if aq < beta {
+				pix[pos+step] = byte(q1 + clip3i32(-tc0, tc0, (q2+((p0+q0+1)>>1)-(q1<<1))>>1))
+			}
+		}
+		return
+	}
+
+	// Strong filtering on macroblock edges of intra macroblocks
+	if chroma {
+		pix[pos-step] = byte((2*p1 + p0 + q1 + 2) >> 2)
+		pix[pos] = byte((2*q1 + q0 + p1 + 2) >> 2)
+		return
+	}
+
+	p3, q3 := int32(pix[pos-4*step]), int32(pix[pos+3*step])
+	strong := abs32(p0-q0) < (alpha>>2)+2
+	if ap < beta && strong {
+		pix[pos-step] = byte((p2 + 2*p1 + 2*p0 + 2*q0 + q1 + 4) >> 3)
+		pix[pos-2*step] = byte((p2 + p1 + p0 + q0 + 2) >> 2)
+		pix[pos-3*step] = byte((2*p3 + 3*p2 + p1 + p0 + q0 + 4) >> 3)
+	} else {
+		pix[pos-step] = byte((2*p1 + p0 + q1 + 2) >> 2)
+	}
+	if aq < beta && strong {
+		pix[pos] = byte((p1 + 2*p0 + 2*q0 + 2*q1 + q2 + 4) >> 3)
+		pix[pos+step] = byte((p0 + q0 + q1 + q2 + 2) >> 2)
+		pix[pos+2*step] = byte((2*q3 + 3*q2 + q1 + q0 + p0 + 4) >> 3)
+	} else {
+		pix[pos] = byte((2*q1 + q0 + p1 + 2) >> 2)
+	}
+}
+
+func clip3i32(lo, hi, v int32) int32 {
+	if v < lo {
+		return lo
+	}
+	if v > hi {
+		return hi
+	}
+	return v
+}
@@ -0,0 +1,980 @@
+package transport
+
+import (
+	"fmt"
+	"image"
+	"sort"
+
+	"github.com/conceptcodes/dji-tello-sdk-go/pkg/errors"
+	"github.com/conceptcodes/dji-tello-sdk-go/pkg/utils"
+)
+
+// H264Decoder is a pure-Go H.264 decoder for the baseline profile streams the
+// Tello sends: progressive 4:2:0 frames with CAVLC entropy coding, I and P
+// slices and short-term reference pictures. Streams that use other tools
+// (CABAC, B slices, interlacing, slice groups, weighted prediction) are
+// rejected with an error; FFmpegDecoder handles those.
+//
+// Pictures are output in decoding order, which is display order for these
+// streams. An H264Decoder is not safe for concurrent use.
+type H264Decoder struct {
+	spss map[int]*h264SPS
+	ppss map[int]*h264PPS
+
+	// Active sequence
+	sps                 *h264SPS
+	widthMbs, heightMbs int
+	width, height       int
+	refs                []*h264Picture // Short-term reference pictures
+	waitForIDR          bool
+
+	// Picture being decoded
+	pic    *h264Picture
+	mbs    []h264Macroblock
+	slices []*h264SliceHeader
+}
+
+// h264Picture is a decoded picture, kept while it is used for reference
+type h264Picture struct {
+	img      *image.YCbCr
+	frameNum int
+}
+
+type h264MbKind uint8
+
+const (
+	mbPSkip h264MbKind = iota
+	mbInter
+	mbIntra4x4
+	mbIntra16x16
+	mbIPCM
+)
+
+// h264Macroblock keeps the per-macroblock state that neighbouring macroblocks
+// and the deblocking filter need. Per-block arrays are in raster order of the
+// 4x4 (or 8x8 for reference indices) blocks.
+type h264Macroblock struct {
+	slice     int // Index into the picture's slices, -1 until decoded
+	kind      h264MbKind
+	qp        int
+	nonZero   [16]uint8 // TotalCoeff of each luma block
+	chromaNZ  [2][4]uint8
+	predModes [16]int8 // Intra4x4PredMode, DC for other macroblock types
+	refIdx    [4]int8
+	refPic    [4]*h264Picture
+	mv        [16][2]int16
+}
+
+func (mb *h264Macroblock) isIntra() bool {
+	return mb.kind >= mbIntra4x4
+}
+
+// lumaBlkRaster maps luma4x4BlkIdx to the raster index of the block within
+// the macroblock. The mapping is its own inverse.
+var lumaBlkRaster = [16]int{0, 1, 4, 5, 2, 3, 6, 7, 8, 9, 12, 13, 10, 11, 14, 15}
+
+// coded_block_pattern mapping for Intra4x4 and inter macroblocks (Table 9-4)
+var (
+	intraCBP = [48]uint8{
+		47, 31, 15, 0, 23, 27, 29, 30, 7, 11, 13, 14, 39, 43, 45, 46,
+		16, 3, 5, 10, 12, 19, 21, 26, 28, 35, 37, 42, 44, 1, 2, 4,
+		8, 17, 18, 20, 24, 6, 9, 22, 25, 32, 33, 34, 36, 40, 38, 41,
+	}
+	interCBP = [48]uint8{
+		0, 16, 1, 2, 4, 8, 32, 3, 5, 10, 12, 15, 47, 7, 11, 13,
+		14, 6, 9, 31, 35, 37, 42, 44, 33, 34, 36, 40, 39, 43, 45, 46,
+		17, 18, 20, 24, 19, 21, 26, 28, 23, 27, 29, 30, 22, 25, 38, 41,
+	}
+)
+
+// NewH264Decoder creates a decoder. Decoding starts at the first keyframe.
+func NewH264Decoder() *H264Decoder {
+	return &H264Decoder{
+		spss:       make(map[int]*h264SPS),
+		ppss:       make(map[int]*h264PPS),
+		waitForIDR: true,
+	}
+}
+
+// Decode decodes one access unit. It returns nil without an error for access
+// units that carry no picture and for pictures that cannot be decoded until
+// the next keyframe, e.g. after a decoding error. The returned image is
+// cropped to the visible area and must not be modified.
+func (d *H264Decoder) Decode(frame VideoFrame) (*image.YCbCr, error) {
+	nalUnits := frame.NALUnits
+	if len(nalUnits) == 0 {
+		units, err := NewH264Parser().ParseFrame(frame.Data)
+		if err != nil {
+			return nil, errors.WrapSDKError(err, errors.ErrVideoDecode, "H264Decoder", "failed to split access unit")
+		}
+		nalUnits = units
+	}
+
+	started := false
+	for _, nal := range nalUnits {
+		if len(nal.Data) == 0 {
+			continue
+		}
+
+		switch nalType := nal.Data[0] & 0x1F; nalType {
+		case NALUTypeSPS:
+			sps, err := parseSPS(nal.Data)
+			if err != nil {
+				return nil, d.fail(err, "invalid sequence parameter set")
+			}
+			d.spss[sps.id] = sps
+		case NALUTypePPS:
+			pps, err := parsePPS(nal.Data)
+			if err != nil {
+				return nil, d.fail(err, "invalid picture parameter set")
+			}
+			d.ppss[pps.id] = pps
+		case NALUTypeSlice, NALUTypeIDR:
+			if d.waitForIDR && nalType != NALUTypeIDR {
+				return nil, nil
+			}
+			if err := d.decodeSlice(nal.Data, !started); err != nil {
+				return nil, d.fail(err, "failed to decode slice")
+			}
+			started = true
+		case 2, 3, 4:
+			return nil, d.fail(fmt.Errorf("NAL unit type %d", nalType), "data partitioning is not supported")
+		}
+	}
+
+	if !started {
+		return nil, nil
+	}
+	return d.finishPicture(), nil
+}
+
+// Close releases the reference pictures
+func (d *H264Decoder) Close() error {
+	d.refs = nil
+	d.pic = nil
+	d.mbs = nil
+	return nil
+}
+
+// fail drops the picture being decoded and the reference pictures so that
+// decoding resumes at the next keyframe
+func (d *H264Decoder) fail(err error, message string) error {
+	d.waitForIDR = true
+	d.refs = nil
+	d.pic = nil
+	return errors.WrapSDKError(err, errors.ErrVideoDecode, "H264Decoder", message)
+}
+
+// decodeSlice decodes one coded slice into the current picture, starting a
+// new picture for the first slice of an access unit
+func (d *H264Decoder) decodeSlice(nal []byte, first bool) error {
+	sh, br, err := parseSliceHeader(nal, d.spss, d.ppss)
+	if err != nil {
+		return err
+	}
+
+	if first {
+		if err := d.startPicture(sh); err != nil {
+			return err
+		}
+	} else if sh.sps != d.sps {
+		return fmt.Errorf("sequence parameter set changed within a picture")
+	}
+	d.slices = append(d.slices, sh)
+
+	s := &h264SliceDecoder{
+		d:     d,
+		sh:    sh,
+		br:    br,
+		slice: len(d.slices) - 1,
+		qp:    sh.qp,
+	}
+	if sh.sliceType == h264SliceP {
+		if s.refList, err = d.refPicList(sh); err != nil {
+			return err
+		}
+	}
+	return s.decode()
+}
+
+// startPicture allocates the picture for an access unit and activates its SPS
+func (d *H264Decoder) startPicture(sh *h264SliceHeader) error {
+	if sh.idr {
+		d.waitForIDR = false
+		if sh.sps != d.sps {
+			d.sps = sh.sps
+			d.widthMbs, d.heightMbs = sh.sps.widthMbs, sh.sps.heightMbs
+			d.width, d.height = d.widthMbs*16, d.heightMbs*16
+			d.mbs = make([]h264Macroblock, d.widthMbs*d.heightMbs)
+			utils.Logger.Debugf("H.264 decoder: %dx%d stream, profile %d, level %d",
+				sh.sps.Width(), sh.sps.Height(), sh.sps.profileIDC, sh.sps.levelIDC)
+		}
+	} else if sh.sps != d.sps {
+		return fmt.Errorf("sequence parameter set changed without a keyframe")
+	}
+
+	d.pic = &h264Picture{
+		img:      image.NewYCbCr(image.Rect(0, 0, d.width, d.height), image.YCbCrSubsampleRatio420),
+		frameNum: sh.frameNum,
+	}
+	for i := range d.mbs {
+		d.mbs[i].slice = -1
+	}
+	d.slices = d.slices[:0]
+	return nil
+}
+
+// finishPicture deblocks the picture, conceals missing macroblocks, updates
+// the reference pictures and returns the visible area
+func (d *H264Decoder) finishPicture() *image.YCbCr {
+	d.deblockPicture()
+	d.conceal()
+	d.markReference()
+
+	pic := d.pic
+	d.pic = nil
+	return pic.img.SubImage(d.sps.crop).(*image.YCbCr)
+}
+
+// conceal fills macroblocks that no slice covered from the most recent
+// reference picture, or with grey when there is none
+func (d *H264Decoder) conceal() {
+	var ref *image.YCbCr
+	if len(d.refs) > 0 {
+		ref = d.refs[len(d.refs)-1].img
+	}
+
+	img := d.pic.img
+	missing := 0
+	for addr := range d.mbs {
+		if d.mbs[addr].slice >= 0 {
+			continue
+		}
+		missing++
+		x, y := (addr%d.widthMbs)*16, (addr/d.widthMbs)*16
+		for j := 0; j < 16; j++ {
+			row := img.Y[(y+j)*img.YStride+x : (y+j)*img.YStride+x+16]
+			if ref != nil {
+				copy(row, ref.Y[(y+j)*img.YStride+x:])
+			} else {
+				fillBytes(row, 128)
+			}
+		}
+		for j := 0; j < 8; j++ {
+			offset := (y/2+j)*img.CStride + x/2
+			if ref != nil {
+				copy(img.Cb[offset:offset+8], ref.Cb[offset:])
+				copy(img.Cr[offset:offset+8], ref.Cr[offset:])
+			} else {
+				fillBytes(img.Cb[offset:offset+8], 128)
+				fillBytes(img.Cr[offset:offset+8], 128)
+			}
+		}
+	}
+	if missing > 0 {
+		utils.Logger.Debugf("H.264 decoder: concealed %d missing macroblocks", missing)
+	}
+}
+
+func fillBytes(b []byte, v byte) {
+	for i := range b {
+		b[i] = v
+	}
+}
+
+// markReference applies the decoded reference picture marking (section 8.2.5)
+// for short-term pictures. Long-term operations are not supported and ignored.
+func (d *H264Decoder) markReference() {
+	sh := d.slices[0]
+	if sh.nalRefIDC == 0 {
+		return
+	}
+
+	maxFrameNum := 1 << uint(d.sps.log2MaxFrameNum)
+	switch {
+	case sh.idr:
+		d.refs = d.refs[:0]
+	case sh.adaptiveMarking:
+		for _, mmco := range sh.mmcos {
+			switch mmco.op {
+			case 1:
+				picNum := d.pic.frameNum - mmco.differenceOfPicNums
+				for i, ref := range d.refs {
+					if frameNumWrap(ref.frameNum, d.pic.frameNum, maxFrameNum) == picNum {
+						d.refs = append(d.refs[:i], d.refs[i+1:]...)
+						break
+					}
+				}
+			case 5:
+				d.refs = d.refs[:0]
+				d.pic.frameNum = 0
+			default:
+				utils.Logger.Debugf("H.264 decoder: ignoring long-term memory management operation %d", mmco.op)
+			}
+		}
+	}
+
+	// Sliding window; also keeps the buffer bounded if adaptive marking left
+	// too many pictures
+	maxRefs := max(d.sps.numRefFrames, 1)
+	for len(d.refs) >= maxRefs {
+		oldest := 0
+		for i, ref := range d.refs {
+			if frameNumWrap(ref.frameNum, d.pic.frameNum, maxFrameNum) <
+				frameNumWrap(d.refs[oldest].frameNum, d.pic.frameNum, maxFrameNum) {
+				oldest = i
+			}
+		}
+		d.refs = append(d.refs[:oldest], d.refs[oldest+1:]...)
+	}
+	d.refs = append(d.refs, d.pic)
+}
+
+// frameNumWrap returns the picture number of a short-term reference frame
+// relative to the current frame_num
+func frameNumWrap(frameNum, current, maxFrameNum int) int {
+	if frameNum > current {
+		return frameNum - maxFrameNum
+	}
+	return frameNum
+}
+
+// refPicList builds reference picture list 0 for a P slice (section 8.2.4)
+func (d *H264Decoder) refPicList(sh *h264SliceHeader) ([]*h264Picture, error) {
+	maxFrameNum := 1 << uint(sh.sps.log2MaxFrameNum)
+	picNum := func(p *h264Picture) int {
+		return frameNumWrap(p.frameNum, sh.frameNum, maxFrameNum)
+	}
+
+	initial := append([]*h264Picture(nil), d.refs...)
+	sort.SliceStable(initial, func(i, j int) bool {
+		return picNum(initial[i]) > picNum(initial[j])
+	})
+
+	n := sh.numRefIdxActive
+	list := make([]*h264Picture, n, n+1)
+	copy(list, initial)
+
+	predPicNum := sh.frameNum
+	refIdx := 0
+	for _, mod := range sh.refPicListMods {
+		if mod.idc == 2 {
+			return nil, fmt.Errorf("long-term reference pictures are not supported")
+		}
+		if refIdx >= n {
+			return nil, fmt.Errorf("too many reference list modifications")
+		}
+
+		absDiff := mod.value + 1
+		noWrap := predPicNum - absDiff
+		if mod.idc == 0 {
+			if noWrap < 0 {
+				noWrap += maxFrameNum
+			}
+		} else {
+			noWrap = predPicNum + absDiff
+			if noWrap >= maxFrameNum {
+				noWrap -= maxFrameNum
+			}
+		}
+		predPicNum = noWrap
+
+		target := noWrap
+		if target > sh.frameNum {
+			target -= maxFrameNum
+		}
+		var pic *h264Picture
+		for _, ref := range d.refs {
+			if picNum(ref) == target {
+				pic = ref
+				break
+			}
+		}
+		if pic == nil {
+			return nil, fmt.Errorf("reference picture %d not found", target)
+		}
+
+		// Insert at refIdx and drop the later duplicate
+		list = append(list, nil)
+		copy(list[refIdx+1:], list[refIdx:n])
+		list[refIdx] = pic
+		refIdx++
+		next := refIdx
+		for i := refIdx; i <= n; i++ {
+			if list[i] != pic {
+				list[next] = list[i]
+				next++
+			}
+		}
+		list = list[:n]
+	}
+	return list, nil
+}
+
+// h264SliceDecoder decodes the macroblocks of one slice
+type h264SliceDecoder struct {
+	d       *H264Decoder
+	sh      *h264SliceHeader
+	br      *h264BitReader
+	slice   int
+	refList []*h264Picture
+	qp      int
+
+	// Current macroblock and its neighbours in the same slice: left, above,
+	// above right and above left
+	mbX, mbY           int
+	mb                 *h264Macroblock
+	mbA, mbB, mbC, mbD *h264Macroblock
+	mvAssigned         [16]bool
+
+	// Residual coefficients in raster order
+	lumaCoeffs [16][16]int32
+	lumaDC     [16]int32
+	chromaDC   [2][4]int32
+	chromaAC   [2][4][16]int32
+}
+
+// decode parses the slice data (section 7.3.4)
+func (s *h264SliceDecoder) decode() error {
+	br := s.br
+	total := len(s.d.mbs)
+	addr := s.sh.firstMb
+
+	for {
+		if s.sh.sliceType == h264SliceP {
+			run := br.readUE()
+			if err := br.err(); err != nil {
+				return err
+			}
+			for ; run > 0; run-- {
+				if addr >= total {
+					return fmt.Errorf("skip run extends past the picture")
+				}
+				s.startMacroblock(addr)
+				if err := s.decodeSkip(); err != nil {
+					return fmt.Errorf("macroblock %d: %w", addr, err)
+				}
+				addr++
+			}
+			if !br.moreRBSPData() {
+				break
+			}
+		}
+
+		if addr >= total {
+			return fmt.Errorf("slice extends past the picture")
+		}
+		s.startMacroblock(addr)
+		if err := s.decodeMacroblock(); err != nil {
+			return fmt.Errorf("macroblock %d: %w", addr, err)
+		}
+		addr++
+
+		if !br.moreRBSPData() {
+			break
+		}
+	}
+	return br.err()
+}
+
+// startMacroblock resets the state for the macroblock at addr
+func (s *h264SliceDecoder) startMacroblock(addr int) {
+	d := s.d
+	s.mbX, s.mbY = addr%d.widthMbs, addr/d.widthMbs
+	s.mb = &d.mbs[addr]
+	*s.mb = h264Macroblock{slice: s.slice, qp: s.qp}
+	for i := range s.mb.predModes {
+		s.mb.predModes[i] = intra4x4DC
+	}
+	s.mvAssigned = [16]bool{}
+
+	neighbour := func(ok bool, n int) *h264Macroblock {
+		if !ok || d.mbs[n].slice != s.slice {
+			return nil
+		}
+		return &d.mbs[n]
+	}
+	w := d.widthMbs
+	s.mbA = neighbour(s.mbX > 0, addr-1)
+	s.mbB = neighbour(s.mbY > 0, addr-w)
+	s.mbC = neighbour(s.mbY > 0 && s.mbX < w-1, addr-w+1)
+	s.mbD = neighbour(s.mbX > 0 && s.mbY > 0, addr-w-1)
+}
+
+// decodeSkip reconstructs a P_Skip macroblock
+func (s *h264SliceDecoder) decodeSkip() error {
+	s.mb.kind = mbPSkip
+	if s.refList[0] == nil {
+		return fmt.Errorf("missing reference picture")
+	}
+	s.setMotion(0, 0, 16, 16, 0, s.predictSkipMV())
+	s.predictInter()
+	return nil
+}
+
+// decodeMacroblock parses and reconstructs one coded macroblock (section 7.3.5)
+func (s *h264SliceDecoder) decodeMacroblock() error {
+	mbType := s.br.readUE()
+	if s.sh.sliceType == h264SliceP {
+		if mbType < 5 {
+			return s.decodeInter(mbType)
+		}
+		mbType -= 5
+	}
+	if mbType > 25 {
+		return fmt.Errorf("invalid macroblock type %d", mbType)
+	}
+	if mbType == 25 {
+		return s.decodePCM()
+	}
+	return s.decodeIntra(mbType)
+}
+
+// decodePCM reads the raw samples of an I_PCM macroblock
+func (s *h264SliceDecoder) decodePCM() error {
+	br := s.br
+	br.alignByte()
+	start := br.pos >> 3
+	if start+384 > len(br.data) {
+		return fmt.Errorf("truncated I_PCM macroblock")
+	}
+	samples := br.data[start : start+384]
+	br.pos += 384 * 8
+
+	img := s.d.pic.img
+	x, y := s.mbX*16, s.mbY*16
+	for j := 0; j < 16; j++ {
+		copy(img.Y[(y+j)*img.YStride+x:(y+j)*img.YStride+x+16], samples[j*16:])
+	}
+	for j := 0; j < 8; j++ {
+		offset := (y/2+j)*img.CStride + x/2
+		copy(img.Cb[offset:offset+8], samples[256+j*8:])
+		copy(img.Cr[offset:offset+8], samples[320+j*8:])
+	}
+
+	mb := s.mb
+	mb.kind = mbIPCM
+	for i := range mb.nonZero {
+		mb.nonZero[i] = 16
+	}
+	for c := range mb.chromaNZ {
+		for i := range mb.chromaNZ[c] {
+			mb.chromaNZ[c][i] = 16
+		}
+	}
+	return nil
+}
+
+// decodeIntra parses and reconstructs an Intra4x4 or Intra16x16 macroblock
+func (s *h264SliceDecoder) decodeIntra(mbType int) error {
+	br, mb := s.br, s.mb
+
+	var cbpLuma, cbpChroma, predMode16x16 int
+	if mbType == 0 {
+		mb.kind = mbIntra4x4
+		var prevFlag [16]bool
+		var rem [16]int
+		for blk := 0; blk < 16; blk++ {
+			if prevFlag[blk] = br.readFlag(); !prevFlag[blk] {
+				rem[blk] = br.readBits(3)
+			}
+		}
+		for blk := 0; blk < 16; blk++ {
+			rb := lumaBlkRaster[blk]
+			pred := s.predIntra4x4Mode(rb)
+			mode := pred
+			if !prevFlag[blk] {
+				mode = rem[blk]
+				if mode >= pred {
+					mode++
+				}
+			}
+			mb.predModes[rb] = int8(mode)
+		}
+	} else {
+		mb.kind = mbIntra16x16
+		predMode16x16 = (mbType - 1) % 4
+		cbpChroma = ((mbType - 1) / 4) % 3
+		if mbType >= 13 {
+			cbpLuma = 15
+		}
+	}
+
+	chromaMode := br.readUE()
+	if chromaMode > 3 {
+		return fmt.Errorf("invalid chroma prediction mode %d", chromaMode)
+	}
+
+	if mb.kind == mbIntra4x4 {
+		code := br.readUE()
+		if code > 47 {
+			return fmt.Errorf("invalid coded block pattern %d", code)
+		}
+		cbp := int(intraCBP[code])
+		cbpLuma, cbpChroma = cbp&15, cbp>>4
+	}
+	if err := s.readResidual(cbpLuma, cbpChroma, mb.kind == mbIntra16x16); err != nil {
+		return err
+	}
+
+	// Reconstruction
+	img := s.d.pic.img
+	x0, y0 := s.mbX*16, s.mbY*16
+	availA, availB := s.intraAvailable(s.mbA), s.intraAvailable(s.mbB)
+	availC, availD := s.intraAvailable(s.mbC), s.intraAvailable(s.mbD)
+
+	if mb.kind == mbIntra4x4 {
+		for blk := 0; blk < 16; blk++ {
+			rb := lumaBlkRaster[blk]
+			x, y := (rb&3)*4, (rb>>2)*4
+
+			avail := intraNeighbours{
+				left:    x > 0 || availA,
+				top:     y > 0 || availB,
+				topLeft: (x > 0 && y > 0) || (x == 0 && y > 0 && availA) || (x > 0 && y == 0 && availB) || (x == 0 && y == 0 && availD),
+			}
+			switch {
+			case y == 0 && x < 12:
+				avail.topRight = availB
+			case y == 0:
+				avail.topRight = availC
+			case x < 12:
+				// Inside the macroblock the block above right must already be decoded
+				avail.topRight = lumaBlkRaster[rb-4+1] < blk
+			}
+
+			predictIntra4x4(img.Y, img.YStride, x0+x, y0+y, int(mb.predModes[rb]), avail)
+			if mb.nonZero[rb] != 0 {
+				dequantize4x4(&s.lumaCoeffs[rb], mb.qp, false)
+				idct4x4Add(&s.lumaCoeffs[rb], img.Y[(y0+y)*img.YStride+x0+x:], img.YStride)
+			}
+		}
+	} else {
+		avail := intraNeighbours{left: availA, top: availB, topLeft: availD}
+		predictIntra16x16(img.Y, img.YStride, x0, y0, predMode16x16, avail)
+		s.addLumaResidual(true)
+	}
+
+	avail := intraNeighbours{left: availA, top: availB, topLeft: availD}
+	predictIntraChroma(img.Cb, img.CStride, x0/2, y0/2, chromaMode, avail)
+	predictIntraChroma(img.Cr, img.CStride, x0/2, y0/2, chromaMode, avail)
+	s.addChromaResidual()
+	return nil
+}
+
+// intraAvailable reports whether a neighbouring macroblock can be used for
+// intra prediction
+func (s *h264SliceDecoder) intraAvailable(n *h264Macroblock) bool {
+	return n != nil && (n.isIntra() || !s.sh.pps.constrainedIntraPred)
+}
+
+// predIntra4x4Mode derives predIntra4x4PredMode for the block at raster index rb
+func (s *h264SliceDecoder) predIntra4x4Mode(rb int) int {
+	bx, by := rb&3, rb>>2
+
+	modeOf := func(n *h264Macroblock, idx int) (int, bool) {
+		if n == nil || (!n.isIntra() && s.sh.pps.constrainedIntraPred) {
+			return 0, false
+		}
+		return int(n.predModes[idx]), true
+	}
+
+	var modeA, modeB int
+	okA, okB := true, true
+	if bx > 0 {
+		modeA = int(s.mb.predModes[rb-1])
+	} else {
+		modeA, okA = modeOf(s.mbA, by*4+3)
+	}
+	if by > 0 {
+		modeB = int(s.mb.predModes[rb-4])
+	} else {
+		modeB, okB = modeOf(s.mbB, 12+bx)
+	}
+
+	if !okA || !okB {
+		return intra4x4DC
+	}
+	return min(modeA, modeB)
+}
+
+// decodeInter parses and reconstructs a P macroblock other than P_Skip
+func (s *h264SliceDecoder) decodeInter(mbType int) error {
+	br, mb := s.br, s.mb
+	mb.kind = mbInter
+	numRef := s.sh.numRefIdxActive
+
+	readRef := func() (int, error) {
+		if numRef == 1 {
+			return 0, nil
+		}
+		ref := br.readTE(numRef - 1)
+		if ref >= numRef || s.refList[ref] == nil {
+			return 0, fmt.Errorf("invalid reference index %d", ref)
+		}
+		return ref, nil
+	}
+
+	// Partitions in decoding order: position, size and reference index
+	type partition struct {
+		x, y, w, h, ref int
+	}
+	var parts []partition
+
+	switch mbType {
+	case 0:
+		parts = []partition{{0, 0, 16, 16, 0}}
+	case 1:
+		parts = []partition{{0, 0, 16, 8, 0}, {0, 8, 16, 8, 0}}
+	case 2:
+		parts = []partition{{0, 0, 8, 16, 0}, {8, 0, 8, 16, 0}}
+	}
+
+	if mbType < 3 {
+		for i := range parts {
+			ref, err := readRef()
+			if err != nil {
+				return err
+			}
+			parts[i].ref = ref
+		}
+	} else {
+		var subTypes [4]int
+		for i := range subTypes {
+			if subTypes[i] = br.readUE(); subTypes[i] > 3 {
+				return fmt.Errorf("invalid sub-macroblock type %d", subTypes[i])
+			}
+		}
+		var refs [4]int
+		if mbType == 3 {
+			for i := range refs {
+				ref, err := readRef()
+				if err != nil {
+					return err
+				}
+				refs[i] = ref
+			}
+		}
+		for i, subType := range subTypes {
+			x, y := (i%2)*8, (i/2)*8
+			switch subType {
+			case 0:
+				parts = append(parts, partition{x, y, 8, 8, refs[i]})
+			case 1:
+				parts = append(parts, partition{x, y, 8, 4, refs[i]}, partition{x, y + 4, 8, 4, refs[i]})
+			case 2:
+				parts = append(parts, partition{x, y, 4, 8, refs[i]}, partition{x + 4, y, 4, 8, refs[i]})
+			case 3:
+				parts = append(parts,
+					partition{x, y, 4, 4, refs[i]}, partition{x + 4, y, 4, 4, refs[i]},
+					partition{x, y + 4, 4, 4, refs[i]}, partition{x + 4, y + 4, 4, 4, refs[i]})
+			}
+		}
+	}
+
+	if s.refList[0] == nil {
+		return fmt.Errorf("missing reference picture")
+	}
+
+	// Motion vector differences follow all reference indices; predictors
+	// depend on the vectors of earlier partitions
+	for _, p := range parts {
+		mvdX, mvdY := br.readSE(), br.readSE()
+		mvp := s.predictMV(p.x, p.y, p.w, p.h, p.ref)
+		mv := [2]int16{int16(int(mvp[0]) + mvdX), int16(int(mvp[1]) + mvdY)}
+		s.setMotion(p.x, p.y, p.w, p.h, p.ref, mv)
+	}
+
+	code := br.readUE()
+	if code > 47 {
+		return fmt.Errorf("invalid coded block pattern %d", code)
+	}
+	cbp := int(interCBP[code])
+	if err := s.readResidual(cbp&15, cbp>>4, false); err != nil {
+		return err
+	}
+
+	s.predictInter()
+	s.addLumaResidual(false)
+	s.addChromaResidual()
+	return nil
+}
+
+// readResidual reads mb_qp_delta when present and the residual blocks of the
+// current macroblock (section 7.3.5.3)
+func (s *h264SliceDecoder) readResidual(cbpLuma, cbpChroma int, intra16x16 bool) error {
+	br, mb := s.br, s.mb
+
+	s.lumaCoeffs = [16][16]int32{}
+	s.lumaDC = [16]int32{}
+	s.chromaDC = [2][4]int32{}
+	s.chromaAC = [2][4][16]int32{}
+
+	if cbpLuma == 0 && cbpChroma == 0 && !intra16x16 {
+		return br.err()
+	}
+
+	delta := br.readSE()
+	if delta < -26 || delta > 25 {
+		return fmt.Errorf("invalid QP delta %d", delta)
+	}
+	s.qp = (s.qp + delta + 52) % 52
+	mb.qp = s.qp
+
+	var scan [16]int32
+	if intra16x16 {
+		br.readResidualBlock(scan[:], 0, 16, s.lumaNC(0, 0))
+		for k, v := range scan {
+			s.lumaDC[zigzag4x4[k]] = v
+		}
+	}
+
+	for blk := 0; blk < 16; blk++ {
+		rb := lumaBlkRaster[blk]
+		if cbpLuma&(1<<uint(blk/4)) == 0 {
+			continue
+		}
+		scan = [16]int32{}
+		nC := s.lumaNC(rb&3, rb>>2)
+		var n int
+		if intra16x16 {
+			n = br.readResidualBlock(scan[:], 1, 15, nC)
+		} else {
+			n = br.readResidualBlock(scan[:], 0, 16, nC)
+		}
+		mb.nonZero[rb] = uint8(n)
+		for k, v := range scan {
+			s.lumaCoeffs[rb][zigzag4x4[k]] = v
+		}
+	}
+
+	if cbpChroma != 0 {
+		for c := 0; c < 2; c++ {
+			br.readResidualBlock(s.chromaDC[c][:], 0, 4, -1)
+		}
+	}
+	if cbpChroma == 2 {
+		for c := 0; c < 2; c++ {
+			for b := 0; b < 4; b++ {
+				scan = [16]int32{}
+				n := br.readResidualBlock(scan[:], 1, 15, s.chromaNC(c, b))
+				mb.chromaNZ[c][b] = uint8(n)
+				for k, v := range scan {
+					s.chromaAC[c][b][zigzag4x4[k]] = v
+				}
+			}
+		}
+	}
+
+	return br.err()
+}
+
+// lumaNC predicts the number of coefficients of the luma block at (bx, by)
+// from its left and upper neighbours (section 9.2.1)
+func (s *h264SliceDecoder) lumaNC(bx, by int) int {
+	var nA, nB int
+	availA, availB := true, true
+	switch {
+	case bx > 0:
+		nA = int(s.mb.nonZero[by*4+bx-1])
+	case s.mbA != nil:
+		nA = int(s.mbA.nonZero[by*4+3])
+	default:
+		availA = false
+	}
+	switch {
+	case by > 0:
+		nB = int(s.mb.nonZero[(by-1)*4+bx])
+	case s.mbB != nil:
+		nB = int(s.mbB.nonZero[12+bx])
+	default:
+		availB = false
+	}
+	return combineNC(nA, nB, availA, availB)
+}
+
+// chromaNC predicts the number of coefficients of chroma AC block b
+func (s *h264SliceDecoder) chromaNC(c, b int) int {
+	bx, by := b&1, b>>1
+	var nA, nB int
+	availA, availB := true, true
+	switch {
+	case bx > 0:
+		nA = int(s.mb.chromaNZ[c][b-1])
+	case s.mbA != nil:
+		nA = int(s.mbA.chromaNZ[c][by*2+1])
+	default:
+		availA = false
+	}
+	switch {
+	case by > 0:
+		nB = int(s.mb.chromaNZ[c][b-2])
+	case s.mbB != nil:
+		nB = int(s.mbB.chromaNZ[c][2+bx])
+	default:
+		availB = false
+	}
+	return combineNC(nA, nB, availA, availB)
+}
+
+func combineNC(nA, nB int, availA, availB bool) int {
+	switch {
+	case availA && availB:
+		return (nA + nB + 1) >> 1
+	case availA:
+		return nA
+	case availB:
+		return nB
+	default:
+		return 0
+	}
+}
+
+// addLumaResidual adds the luma residual of the current macroblock to its
+// prediction
+func (s *h264SliceDecoder) addLumaResidual(intra16x16 bool) {
+	mb := s.mb
+	img := s.d.pic.img
+	x0, y0 := s.mbX*16, s.mbY*16
+
+	if intra16x16 {
+		lumaDCTransform(&s.lumaDC, mb.qp)
+	}
+	for rb := 0; rb < 16; rb++ {
+		c := &s.lumaCoeffs[rb]
+		if intra16x16 {
+			c[0] = s.lumaDC[rb]
+			if mb.nonZero[rb] == 0 && c[0] == 0 {
+				continue
+			}
+		} else if mb.nonZero[rb] == 0 {
+			continue
+		}
+		dequantize4x4(c, mb.qp, intra16x16)
+		x, y := x0+(rb&3)*4, y0+(rb>>2)*4
+		idct4x4Add(c, img.Y[y*img.YStride+x:], img.YStride)
+	}
+}
+
+// addChromaResidual adds the chroma residual of the current macroblock to its
+// prediction
+func (s *h264SliceDecoder) addChromaResidual() {
+	mb := s.mb
+	img := s.d.pic.img
+	x0, y0 := s.mbX*8, s.mbY*8
+
+	for c, plane := range [2][]byte{img.Cb, img.Cr} {
+		qp := chromaQP(mb.qp, s.sh.pps.chromaQPOffset[c])
+		chromaDCTransform(&s.chromaDC[c], qp)
+		for b := 0; b < 4; b++ {
+			coeffs := &s.chromaAC[c][b]
+			coeffs[0] = s.chromaDC[c][b]
+			if mb.chromaNZ[c][b] == 0 && coeffs[0] == 0 {
+				continue
+			}
+			dequantize4x4(coeffs, qp, true)
+			x, y := x0+(b&1)*4, y0+(b>>1)*4
+			idct4x4Add(coeffs, plane[y*img.CStride+x:], img.CStride)
+		}
+	}
+}
@@ -0,0 +1,666 @@
+package transport
+
+import (
+	"bytes"
+	"image"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// testBitWriter builds RBSPs for decoder tests
+type testBitWriter struct {
+	bits []byte
+}
+
+func (w *testBitWriter) u(n int, v int) {
+	for i := n - 1; i >= 0; i-- {
+		w.bits = append(w.bits, byte(v>>uint(i))&1)
+	}
+}
+
+func (w *testBitWriter) flag(b bool) {
+	if b {
+		w.u(1, 1)
+	} else {
+		w.u(1, 0)
+	}
+}
+
+func (w *testBitWriter) ue(v int) {
+	n := 0
+	for (v+1)>>uint(n+1) != 0 {
+		n++
+	}
+	w.u(n, 0)
+	w.u(n+1, v+1)
+}
+
+func (w *testBitWriter) se(v int) {
+	if v > 0 {
+		w.ue(2*v - 1)
+	} else {
+		w.ue(-2 * v)
+	}
+}
+
+// bitString appends bits written as a string of 0s and 1s
+func (w *testBitWriter) bitString(s string) {
+	for _, c := range s {
+		w.bits = append(w.bits, byte(c-'0'))
+	}
+}
+
+func (w *testBitWriter) align() {
+	for len(w.bits)%8 != 0 {
+		w.bits = append(w.bits, 0)
+	}
+}
+
+// nal appends the RBSP trailing bits and returns the NAL unit with the given
+// header byte and emulation prevention applied
+func (w *testBitWriter) nal(header byte) []byte {
+	w.u(1, 1)
+	w.align()
+
+	out := []byte{header}
+	zeros := 0
+	for i := 0; i < len(w.bits); i += 8 {
+		var b byte
+		for _, bit := range w.bits[i : i+8] {
+			b = b<<1 | bit
+		}
+		if zeros >= 2 && b <= 3 {
+			out = append(out, 0x03)
+			zeros = 0
+		}
+		out = append(out, b)
+		if b == 0 {
+			zeros++
+		} else {
+			zeros = 0
+		}
+	}
+	return out
+}
+
+// testCodedStream describes the parameter sets of a test stream
+type testCodedStream struct {
+	widthMbs, heightMbs int
+	cropBottom          int // In crop units of two lines
+	qp                  int
+	deblockControl      bool
+}
+
+func (ts testCodedStream) sps() []byte {
+	w := &testBitWriter{}
+	w.u(8, 66) // Baseline
+	w.u(8, 0)
+	w.u(8, 30)
+	w.ue(0) // seq_parameter_set_id
+	w.ue(0) // log2_max_frame_num_minus4
+	w.ue(2) // pic_order_cnt_type
+	w.ue(1) // max_num_ref_frames
+	w.flag(false)
+	w.ue(ts.widthMbs - 1)
+	w.ue(ts.heightMbs - 1)
+	w.flag(true) // frame_mbs_only_flag
+	w.flag(true) // direct_8x8_inference_flag
+	w.flag(ts.cropBottom > 0)
+	if ts.cropBottom > 0 {
+		w.ue(0)
+		w.ue(0)
+		w.ue(0)
+		w.ue(ts.cropBottom)
+	}
+	w.flag(false) // vui_parameters_present_flag
+	return w.nal(0x67)
+}
+
+func (ts testCodedStream) pps() []byte {
+	w := &testBitWriter{}
+	w.ue(0)       // pic_parameter_set_id
+	w.ue(0)       // seq_parameter_set_id
+	w.flag(false) // entropy_coding_mode_flag
+	w.flag(false) // bottom_field_pic_order_in_frame_present_flag
+	w.ue(0)       // num_slice_groups_minus1
+	w.ue(0)       // num_ref_idx_l0_default_active_minus1
+	w.ue(0)       // num_ref_idx_l1_default_active_minus1
+	w.flag(false) // weighted_pred_flag
+	w.u(2, 0)     // weighted_bipred_idc
+	w.se(ts.qp - 26)
+	w.se(0) // pic_init_qs_minus26
+	w.se(0) // chroma_qp_index_offset
+	w.flag(ts.deblockControl)
+	w.flag(false) // constrained_intra_pred_flag
+	w.flag(false) // redundant_pic_cnt_present_flag
+	return w.nal(0x68)
+}
+
+// sliceHeader writes a slice header for frame_num frameNum; IDR pictures
+// are I slices and all others P slices. Deblocking is disabled when the
+// PPS allows it.
+func (ts testCodedStream) sliceHeader(idr bool, frameNum int) *testBitWriter {
+	w := &testBitWriter{}
+	w.ue(0) // first_mb_in_slice
+	if idr {
+		w.ue(7)
+	} else {
+		w.ue(5)
+	}
+	w.ue(0) // pic_parameter_set_id
+	w.u(4, frameNum)
+	if idr {
+		w.ue(0)       // idr_pic_id
+		w.flag(false) // no_output_of_prior_pics_flag
+		w.flag(false) // long_term_reference_flag
+	} else {
+		w.flag(false) // num_ref_idx_active_override_flag
+		w.flag(false) // ref_pic_list_modification_flag_l0
+		w.flag(false) // adaptive_ref_pic_marking_mode_flag
+	}
+	w.se(0) // slice_qp_delta
+	if ts.deblockControl {
+		w.ue(1)
+	}
+	return w
+}
+
+func testAccessUnit(nals ...[]byte) VideoFrame {
+	var data []byte
+	for _, nal := range nals {
+		data = append(data, 0x00, 0x00, 0x00, 0x01)
+		data = append(data, nal...)
+	}
+	return VideoFrame{Data: data, IsKeyFrame: nals[0][0]&0x1F == NALUTypeSPS}
+}
+
+// pcmPicture returns the luma and chroma planes used by the I_PCM tests
+func pcmPicture(width, height int) (y, cb, cr []byte) {
+	y = make([]byte, width*height)
+	for j := 0; j < height; j++ {
+		for i := 0; i < width; i++ {
+			y[j*width+i] = byte(10 + 5*i + j)
+		}
+	}
+	cb = make([]byte, width*height/4)
+	cr = make([]byte, width*height/4)
+	for j := 0; j < height/2; j++ {
+		for i := 0; i < width/2; i++ {
+			cb[j*width/2+i] = byte(40 + 7*i + 3*j)
+			cr[j*width/2+i] = byte(200 - 6*i - 2*j)
+		}
+	}
+	return y, cb, cr
+}
+
+// pcmSlice encodes the planes as an IDR slice of I_PCM macroblocks
+func (ts testCodedStream) pcmSlice(y, cb, cr []byte) []byte {
+	width := ts.widthMbs * 16
+	w := ts.sliceHeader(true, 0)
+	for mbY := 0; mbY < ts.heightMbs; mbY++ {
+		for mbX := 0; mbX < ts.widthMbs; mbX++ {
+			w.ue(25) // I_PCM
+			w.align()
+			for j := 0; j < 16; j++ {
+				for i := 0; i < 16; i++ {
+					w.u(8, int(y[(mbY*16+j)*width+mbX*16+i]))
+				}
+			}
+			for _, plane := range [][]byte{cb, cr} {
+				for j := 0; j < 8; j++ {
+					for i := 0; i < 8; i++ {
+						w.u(8, int(plane[(mbY*8+j)*width/2+mbX*8+i]))
+					}
+				}
+			}
+		}
+	}
+	return w.nal(0x65)
+}
+
+func comparePlane(t *testing.T, name string, got []byte, gotStride int, want []byte, width, height int) {
+	t.Helper()
+	for j := 0; j < height; j++ {
+		if !bytes.Equal(got[j*gotStride:j*gotStride+width], want[j*width:(j+1)*width]) {
+			t.Errorf("Expected %s row %d to be %v, got %v", name, j, want[j*width:(j+1)*width], got[j*gotStride:j*gotStride+width])
+			return
+		}
+	}
+}
+
+// TestH264Decoder_EncoderClips decodes the clips in testdata, made by a real
+// encoder with gen_clips.js, and compares every picture with FFmpeg's decode
+// of the same clip. The clips together must exercise every macroblock kind
+// the Tello sends and the deblocking filter.
+func TestH264Decoder_EncoderClips(t *testing.T) {
+	clips, err := filepath.Glob(filepath.Join("testdata", "*.h264"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(clips) == 0 {
+		t.Fatal("No encoded clips in testdata; run testdata/gen_clips.js")
+	}
+
+	// Macroblocks decoded per kind, and those in deblocked pictures at a QP
+	// where the filter changes samples (indexA 16 and up)
+	var kinds [mbIPCM + 1]int
+	deblocked := 0
+
+	for _, clip := range clips {
+		t.Run(filepath.Base(clip), func(t *testing.T) {
+			stream, err := os.ReadFile(clip)
+			if err != nil {
+				t.Fatal(err)
+			}
+			want, err := os.ReadFile(strings.TrimSuffix(clip, ".h264") + ".yuv")
+			if err != nil {
+				t.Fatalf("Missing the expected pixels: %v", err)
+			}
+
+			// Feed the clip through the reassembler a picture at a time, as
+			// the drone sends it. The clips have one slice per picture.
+			nalUnits, err := NewH264Parser().ParseFrame(stream)
+			if err != nil {
+				t.Fatal(err)
+			}
+			var accessUnits [][][]byte
+			var au []byte
+			for _, nal := range nalUnits {
+				au = append(append(au, 0x00, 0x00, 0x00, 0x01), nal.Data...)
+				if nal.Type == NALUTypeSlice || nal.Type == NALUTypeIDR {
+					accessUnits = append(accessUnits, packetize(au))
+					au = nil
+				}
+			}
+			frames := pushAll(NewH264Reassembler(), accessUnits)
+			if len(frames) != len(accessUnits) {
+				t.Fatalf("Expected %d frames from the reassembler, got %d", len(accessUnits), len(frames))
+			}
+
+			d := NewH264Decoder()
+			offset := 0
+			for _, frame := range frames {
+				img, err := d.Decode(frame)
+				if err != nil {
+					t.Fatalf("Frame %d: %v", frame.SeqNum, err)
+				}
+				if img == nil {
+					continue
+				}
+				for i := range d.mbs {
+					mb := &d.mbs[i]
+					kinds[mb.kind]++
+					if d.slices[mb.slice].disableDeblock != 1 && mb.qp >= 16 {
+						deblocked++
+					}
+				}
+
+				width, height := img.Rect.Dx(), img.Rect.Dy()
+				size := width * height
+				if offset+size*3/2 > len(want) {
+					t.Fatalf("Frame %d: decoded more pictures than the reference", frame.SeqNum)
+				}
+				comparePlane(t, "Y", img.Y, img.YStride, want[offset:offset+size], width, height)
+				comparePlane(t, "Cb", img.Cb, img.CStride, want[offset+size:offset+size*5/4], width/2, height/2)
+				comparePlane(t, "Cr", img.Cr, img.CStride, want[offset+size*5/4:offset+size*3/2], width/2, height/2)
+				offset += size * 3 / 2
+			}
+			if offset != len(want) {
+				t.Errorf("Expected %d bytes of pictures, decoded %d", len(want), offset)
+			}
+		})
+	}
+
+	for _, kind := range []struct {
+		name  string
+		count int
+	}{
+		{"Intra4x4", kinds[mbIntra4x4]},
+		{"Intra16x16", kinds[mbIntra16x16]},
+		{"inter", kinds[mbInter]},
+		{"P_Skip", kinds[mbPSkip]},
+		{"deblocked", deblocked},
+	} {
+		if kind.count == 0 {
+			t.Errorf("Expected the clips to contain %s macroblocks", kind.name)
+		}
+	}
+}
+
+func TestH264Decoder_IPCM(t *testing.T) {
+	ts := testCodedStream{widthMbs: 2, heightMbs: 2, qp: 26}
+	y, cb, cr := pcmPicture(32, 32)
+
+	d := NewH264Decoder()
+	img, err := d.Decode(testAccessUnit(ts.sps(), ts.pps(), ts.pcmSlice(y, cb, cr)))
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if img == nil {
+		t.Fatal("Expected a decoded picture")
+	}
+	if img.Bounds() != image.Rect(0, 0, 32, 32) {
+		t.Fatalf("Expected bounds 32x32, got %v", img.Bounds())
+	}
+	if img.SubsampleRatio != image.YCbCrSubsampleRatio420 {
+		t.Errorf("Expected 4:2:0 picture, got %v", img.SubsampleRatio)
+	}
+	comparePlane(t, "Y", img.Y, img.YStride, y, 32, 32)
+	comparePlane(t, "Cb", img.Cb, img.CStride, cb, 16, 16)
+	comparePlane(t, "Cr", img.Cr, img.CStride, cr, 16, 16)
+}
+
+func TestH264Decoder_Cropping(t *testing.T) {
+	ts := testCodedStream{widthMbs: 2, heightMbs: 2, cropBottom: 4, qp: 26}
+	y, cb, cr := pcmPicture(32, 32)
+
+	d := NewH264Decoder()
+	img, err := d.Decode(testAccessUnit(ts.sps(), ts.pps(), ts.pcmSlice(y, cb, cr)))
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if img.Bounds().Dx() != 32 || img.Bounds().Dy() != 24 {
+		t.Fatalf("Expected 32x24 picture, got %v", img.Bounds())
+	}
+	comparePlane(t, "Y", img.Y, img.YStride, y, 32, 24)
+}
+
+func TestH264Decoder_Intra16x16DC(t *testing.T) {
+	tests := []struct {
+		name       string
+		signBit    string
+		wantLuma   byte
+		wantChroma byte
+	}{
+		{"positive DC", "0", 129, 128},
+		{"negative DC", "1", 127, 128},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ts := testCodedStream{widthMbs: 1, heightMbs: 1, qp: 28}
+			w := ts.sliceHeader(true, 0)
+			w.ue(3) // I_16x16_2_0_0: DC prediction, no AC or chroma residual
+			w.ue(intraChromaDC)
+			w.se(0) // mb_qp_delta
+			// Intra16x16DCLevel with a single trailing one at scan position 0:
+			// coeff_token, sign, total_zeros
+			w.bitString("01" + tt.signBit + "1")
+
+			d := NewH264Decoder()
+			img, err := d.Decode(testAccessUnit(ts.sps(), ts.pps(), w.nal(0x65)))
+			if err != nil {
+				t.Fatalf("Expected no error, got %v", err)
+			}
+			for i, v := range img.Y {
+				if v != tt.wantLuma {
+					t.Fatalf("Expected luma %d everywhere, got %d at %d", tt.wantLuma, v, i)
+				}
+			}
+			for i := range img.Cb {
+				if img.Cb[i] != tt.wantChroma || img.Cr[i] != tt.wantChroma {
+					t.Fatalf("Expected chroma %d everywhere, got %d/%d at %d", tt.wantChroma, img.Cb[i], img.Cr[i], i)
+				}
+			}
+		})
+	}
+}
+
+func TestH264Decoder_PFrame(t *testing.T) {
+	ts := testCodedStream{widthMbs: 2, heightMbs: 2, qp: 26, deblockControl: true}
+	y, cb, cr := pcmPicture(32, 32)
+
+	tests := []struct {
+		name string
+		mvX  int
+		// Expected luma of the first macroblock; the others are skipped with
+		// a zero motion vector and copy the reference
+		luma func(x, y int) byte
+		// Columns of the first macroblock to check; half sample positions
+		// near the picture edge use clamped samples
+		fromX int
+		cb    func(x, y int) byte
+	}{
+		{
+			name:  "full sample",
+			mvX:   4,
+			luma:  func(x, y int) byte { return byte(10 + 5*(x+1) + y) },
+			fromX: 0,
+			cb: func(x, y int) byte {
+				return byte((int(cb[y*16+x]) + int(cb[y*16+x+1]) + 1) >> 1)
+			},
+		},
+		{
+			name:  "half sample",
+			mvX:   2,
+			luma:  func(x, y int) byte { return byte(13 + 5*x + y) },
+			fromX: 2,
+			cb: func(x, y int) byte {
+				return byte((6*int(cb[y*16+x]) + 2*int(cb[y*16+x+1]) + 4) >> 3)
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			d := NewH264Decoder()
+			if _, err := d.Decode(testAccessUnit(ts.sps(), ts.pps(), ts.pcmSlice(y, cb, cr))); err != nil {
+				t.Fatalf("Expected no error decoding IDR, got %v", err)
+			}
+
+			w := ts.sliceHeader(false, 1)
+			w.ue(0) // mb_skip_run
+			w.ue(0) // P_L0_16x16
+			w.se(tt.mvX)
+			w.se(0)
+			w.ue(0) // coded_block_pattern 0
+			w.ue(3) // mb_skip_run for the remaining macroblocks
+			img, err := d.Decode(testAccessUnit(w.nal(0x41)))
+			if err != nil {
+				t.Fatalf("Expected no error decoding P frame, got %v", err)
+			}
+			if img == nil {
+				t.Fatal("Expected a decoded P picture")
+			}
+
+			for j := 0; j < 32; j++ {
+				for i := 0; i < 32; i++ {
+					want := y[j*32+i]
+					if i < 16 && j < 16 {
+						if i < tt.fromX {
+							continue
+						}
+						want = tt.luma(i, j)
+					}
+					if got := img.Y[j*img.YStride+i]; got != want {
+						t.Fatalf("Expected luma %d at (%d,%d), got %d", want, i, j, got)
+					}
+				}
+			}
+			for j := 0; j < 8; j++ {
+				for i := 0; i < 8; i++ {
+					if got, want := img.Cb[j*img.CStride+i], tt.cb(i, j); got != want {
+						t.Fatalf("Expected Cb %d at (%d,%d), got %d", want, i, j, got)
+					}
+				}
+			}
+		})
+	}
+}
+
+func TestH264Decoder_WaitsForKeyframe(t *testing.T) {
+	ts := testCodedStream{widthMbs: 1, heightMbs: 1, qp: 26}
+
+	d := NewH264Decoder()
+	w := ts.sliceHeader(false, 1)
+	w.ue(1)
+	img, err := d.Decode(testAccessUnit(ts.sps(), ts.pps(), w.nal(0x41)))
+	if err != nil {
+		t.Errorf("Expected no error before the first keyframe, got %v", err)
+	}
+	if img != nil {
+		t.Error("Expected no picture before the first keyframe")
+	}
+}
+
+func TestH264Decoder_InvalidSliceResyncs(t *testing.T) {
+	ts := testCodedStream{widthMbs: 1, heightMbs: 1, qp: 26}
+	y, cb, cr := pcmPicture(16, 16)
+
+	d := NewH264Decoder()
+	if _, err := d.Decode(testAccessUnit(ts.sps(), ts.pps(), ts.pcmSlice(y, cb, cr))); err != nil {
+		t.Fatalf("Expected no error decoding IDR, got %v", err)
+	}
+
+	// mb_type 31 does not exist in P slices
+	w := ts.sliceHeader(false, 1)
+	w.ue(0)
+	w.ue(31)
+	if _, err := d.Decode(testAccessUnit(w.nal(0x41))); err == nil {
+		t.Fatal("Expected an error for an invalid macroblock type")
+	}
+
+	// Following P frames are dropped until the next keyframe
+	w = ts.sliceHeader(false, 2)
+	w.ue(1)
+	if img, err := d.Decode(testAccessUnit(w.nal(0x41))); img != nil || err != nil {
+		t.Errorf("Expected P frame to be skipped after an error, got %v, %v", img, err)
+	}
+	if img, err := d.Decode(testAccessUnit(ts.sps(), ts.pps(), ts.pcmSlice(y, cb, cr))); img == nil || err != nil {
+		t.Errorf("Expected keyframe to decode after an error, got %v, %v", img, err)
+	}
+}
+
+func TestH264Decoder_UnsupportedStreams(t *testing.T) {
+	ts := testCodedStream{widthMbs: 1, heightMbs: 1, qp: 26}
+
+	// PPS with entropy_coding_mode_flag set
+	w := &testBitWriter{}
+	w.ue(0)
+	w.ue(0)
+	w.flag(true)
+	cabacPPS := w.nal(0x68)
+
+	d := NewH264Decoder()
+	if _, err := d.Decode(testAccessUnit(ts.sps(), cabacPPS)); err == nil {
+		t.Error("Expected an error for a CABAC stream")
+	}
+}
+
+func TestReadResidualBlock(t *testing.T) {
+	// Example from Richardson, "The H.264 Advanced Video Compression
+	// Standard": coefficients 0, 3, -1, 0 / 0, -1, 1, 0 / 1, 0, 0, 0 / 0...
+	w := &testBitWriter{}
+	w.bitString("000010001110010111101101")
+	br := newH264BitReader(w.nal(0x01)[1:])
+
+	coeffs := make([]int32, 16)
+	n := br.readResidualBlock(coeffs, 0, 16, 0)
+	if err := br.err(); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if n != 5 {
+		t.Errorf("Expected 5 coefficients, got %d", n)
+	}
+	want := []int32{0, 3, 0, 1, -1, -1, 0, 1, 0, 0, 0, 0, 0, 0, 0, 0}
+	for i := range want {
+		if coeffs[i] != want[i] {
+			t.Errorf("Expected coefficients %v, got %v", want, coeffs)
+			break
+		}
+	}
+}
+
+func TestH264BitReader_ExpGolomb(t *testing.T) {
+	w := &testBitWriter{}
+	values := []int{0, 1, 2, 3, 7, 8, 254, 255, 65535}
+	for _, v := range values {
+		w.ue(v)
+	}
+	signed := []int{0, 1, -1, 2, -2, 100, -100}
+	for _, v := range signed {
+		w.se(v)
+	}
+	br := newH264BitReader(w.nal(0x01)[1:])
+
+	for _, want := range values {
+		if got := br.readUE(); got != want {
+			t.Errorf("Expected ue %d, got %d", want, got)
+		}
+	}
+	for _, want := range signed {
+		if got := br.readSE(); got != want {
+			t.Errorf("Expected se %d, got %d", want, got)
+		}
+	}
+	if br.moreRBSPData() {
+		t.Error("Expected no more RBSP data")
+	}
+	if err := br.err(); err != nil {
+		t.Errorf("Expected no error, got %v", err)
+	}
+}
+
+func TestUnescapeRBSP(t *testing.T) {
+	got := unescapeRBSP([]byte{0x00, 0x00, 0x03, 0x01, 0x00, 0x00, 0x03, 0x00, 0x03})
+	want := []byte{0x00, 0x00, 0x01, 0x00, 0x00, 0x00, 0x03}
+	if !bytes.Equal(got, want) {
+		t.Errorf("Expected %v, got %v", want, got)
+	}
+}
+
+// TestCAVLCTables checks that every code table is prefix free, which would
+// catch a mistyped length or code
+func TestCAVLCTables(t *testing.T) {
+	check := func(name string, lengths, codes []uint8) {
+		type code struct{ length, value int }
+		var all []code
+		for sym, l := range lengths {
+			if l == 0 {
+				continue
+			}
+			if int(codes[sym]) >= 1<<l {
+				t.Errorf("%s: code %d of symbol %d does not fit in %d bits", name, codes[sym], sym, l)
+			}
+			all = append(all, code{int(l), int(codes[sym])})
+		}
+		for i, a := range all {
+			for j, b := range all {
+				if i == j || a.length > b.length {
+					continue
+				}
+				if b.value>>uint(b.length-a.length) == a.value {
+					t.Errorf("%s: code %d/%d is a prefix of %d/%d", name, a.value, a.length, b.value, b.length)
+				}
+			}
+		}
+	}
+
+	for i := range coeffTokenLengths {
+		check("coeff_token", coeffTokenLengths[i][:], coeffTokenCodes[i][:])
+	}
+	check("chroma DC coeff_token", chromaDCCoeffTokenLengths[:], chromaDCCoeffTokenCodes[:])
+	for i := range totalZerosLengths {
+		check("total_zeros", totalZerosLengths[i][:], totalZerosCodes[i][:])
+	}
+	for i := range chromaDCTotalZerosLengths {
+		check("chroma DC total_zeros", chromaDCTotalZerosLengths[i][:], chromaDCTotalZerosCodes[i][:])
+	}
+	for i := range runBeforeLengths {
+		check("run_before", runBeforeLengths[i][:], runBeforeCodes[i][:])
+	}
+
+	for _, table := range [][48]uint8{intraCBP, interCBP} {
+		var seen [48]bool
+		for _, v := range table {
+			seen[v] = true
+		}
+		for v, ok := range seen {
+			if !ok {
+				t.Errorf("Expected coded_block_pattern %d in mapping table", v)
+			}
+		}
+	}
+}
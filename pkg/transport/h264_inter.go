@@ -0,0 +1,287 @@
+package transport
+
+// Inter prediction for P macroblocks: motion vector prediction (section
+// 8.4.1) and sample interpolation (section 8.4.2.2).
+
+// mvNeighbour is the motion data of a neighbouring partition
+type mvNeighbour struct {
+	avail bool
+	ref   int
+	mv    [2]int16
+}
+
+// mvNeighbour returns the motion data covering luma position (xN, yN)
+// relative to the current macroblock. Inside the current macroblock only
+// partitions whose motion vectors were already derived are available.
+func (s *h264SliceDecoder) mvNeighbour(xN, yN int) mvNeighbour {
+	var n *h264Macroblock
+	switch {
+	case yN < 0 && xN < 0:
+		n = s.mbD
+	case yN < 0 && xN < 16:
+		n = s.mbB
+	case yN < 0:
+		n = s.mbC
+	case xN < 0:
+		n = s.mbA
+	case xN < 16:
+		if !s.mvAssigned[(yN>>2)*4+xN>>2] {
+			return mvNeighbour{ref: -1}
+		}
+		n = s.mb
+	}
+	if n == nil {
+		return mvNeighbour{ref: -1}
+	}
+	if n.isIntra() {
+		return mvNeighbour{avail: true, ref: -1}
+	}
+
+	xM, yM := xN&15, yN&15
+	return mvNeighbour{
+		avail: true,
+		ref:   int(n.refIdx[(yM>>3)*2+xM>>3]),
+		mv:    n.mv[(yM>>2)*4+xM>>2],
+	}
+}
+
+// predictMV derives the motion vector predictor for the partition at (x, y)
+// of size w x h within the current macroblock
+func (s *h264SliceDecoder) predictMV(x, y, w, h, ref int) [2]int16 {
+	a := s.mvNeighbour(x-1, y)
+	b := s.mvNeighbour(x, y-1)
+	c := s.mvNeighbour(x+w, y-1)
+	if !c.avail {
+		c = s.mvNeighbour(x-1, y-1)
+	}
+
+	// Directional prediction for 16x8 and 8x16 partitions
+	switch {
+	case w == 16 && h == 8:
+		if y == 0 && b.ref == ref {
+			return b.mv
+		}
+		if y == 8 && a.ref == ref {
+			return a.mv
+		}
+	case w == 8 && h == 16:
+		if x == 0 && a.ref == ref {
+			return a.mv
+		}
+		if x == 8 && c.ref == ref {
+			return c.mv
+		}
+	}
+
+	if !b.avail && !c.avail && a.avail {
+		b, c = a, a
+	}
+
+	matches := 0
+	var match mvNeighbour
+	for _, n := range []mvNeighbour{a, b, c} {
+		if n.ref == ref {
+			matches++
+			match = n
+		}
+	}
+	if matches == 1 {
+		return match.mv
+	}
+	return [2]int16{median16(a.mv[0], b.mv[0], c.mv[0]), median16(a.mv[1], b.mv[1], c.mv[1])}
+}
+
+// predictSkipMV derives the motion vector of a P_Skip macroblock
+func (s *h264SliceDecoder) predictSkipMV() [2]int16 {
+	a := s.mvNeighbour(-1, 0)
+	b := s.mvNeighbour(0, -1)
+	if !a.avail || !b.avail ||
+		(a.ref == 0 && a.mv == [2]int16{}) ||
+		(b.ref == 0 && b.mv == [2]int16{}) {
+		return [2]int16{}
+	}
+	return s.predictMV(0, 0, 16, 16, 0)
+}
+
+// setMotion assigns a reference index and motion vector to the w x h luma
+// area at (x, y) of the current macroblock
+func (s *h264SliceDecoder) setMotion(x, y, w, h, ref int, mv [2]int16) {
+	for j := y >> 2; j < (y+h)>>2; j++ {
+		for i := x >> 2; i < (x+w)>>2; i++ {
+			s.mb.mv[j*4+i] = mv
+			s.mvAssigned[j*4+i] = true
+		}
+	}
+	for j := y >> 3; j < (y+h+7)>>3; j++ {
+		for i := x >> 3; i < (x+w+7)>>3; i++ {
+			s.mb.refIdx[j*2+i] = int8(ref)
+			s.mb.refPic[j*2+i] = s.refList[ref]
+		}
+	}
+}
+
+func median16(a, b, c int16) int16 {
+	if a > b {
+		a, b = b, a
+	}
+	if b > c {
+		b = c
+	}
+	if a > b {
+		return a
+	}
+	return b
+}
+
+// predictInter writes the motion compensated prediction of the current
+// macroblock into the picture
+func (s *h264SliceDecoder) predictInter() {
+	mb := s.mb
+	uniform := true
+	for i := 1; i < 16; i++ {
+		if mb.mv[i] != mb.mv[0] {
+			uniform = false
+			break
+		}
+	}
+	for i := 1; i < 4; i++ {
+		if mb.refPic[i] != mb.refPic[0] {
+			uniform = false
+		}
+	}
+
+	if uniform {
+		s.motionCompensate(0, 0, 16, 16, mb.refPic[0], mb.mv[0])
+		return
+	}
+	for blk := 0; blk < 16; blk++ {
+		x, y := (blk&3)*4, (blk>>2)*4
+		s.motionCompensate(x, y, 4, 4, mb.refPic[(y>>3)*2+x>>3], mb.mv[blk])
+	}
+}
+
+// motionCompensate predicts the w x h luma area at (x, y) of the current
+// macroblock and the matching chroma area from ref
+func (s *h264SliceDecoder) motionCompensate(x, y, w, h int, ref *h264Picture, mv [2]int16) {
+	dst, src := s.d.pic.img, ref.img
+	lx, ly := s.mbX*16+x, s.mbY*16+y
+
+	lumaMC(dst.Y, src.Y, dst.YStride, s.d.width, s.d.height, lx, ly, w, h, int(mv[0]), int(mv[1]))
+	chromaMC(dst.Cb, src.Cb, dst.CStride, s.d.width/2, s.d.height/2, lx/2, ly/2, w/2, h/2, int(mv[0]), int(mv[1]))
+	chromaMC(dst.Cr, src.Cr, dst.CStride, s.d.width/2, s.d.height/2, lx/2, ly/2, w/2, h/2, int(mv[0]), int(mv[1]))
+}
+
+// lumaMC interpolates a w x h luma block at quarter sample precision. Reference
+// samples outside the picture repeat the nearest edge sample.
+func lumaMC(dst, ref []byte, stride, width, height, x, y, w, h, mvx, mvy int) {
+	xInt, yInt := x+(mvx>>2), y+(mvy>>2)
+	xFrac, yFrac := mvx&3, mvy&3
+
+	// Full sample positions entirely inside the picture are a plain copy
+	if xFrac == 0 && yFrac == 0 && xInt >= 0 && yInt >= 0 && xInt+w <= width && yInt+h <= height {
+		for j := 0; j < h; j++ {
+			copy(dst[(y+j)*stride+x:(y+j)*stride+x+w], ref[(yInt+j)*stride+xInt:])
+		}
+		return
+	}
+
+	p := refPlane{pix: ref, stride: stride, width: width, height: height}
+	for j := 0; j < h; j++ {
+		row := (y+j)*stride + x
+		for i := 0; i < w; i++ {
+			dst[row+i] = p.lumaSample(xInt+i, yInt+j, xFrac, yFrac)
+		}
+	}
+}
+
+// chromaMC interpolates a w x h chroma block at eighth sample precision
+func chromaMC(dst, ref []byte, stride, width, height, x, y, w, h, mvx, mvy int) {
+	xInt, yInt := x+(mvx>>3), y+(mvy>>3)
+	xFrac, yFrac := int32(mvx&7), int32(mvy&7)
+	p := refPlane{pix: ref, stride: stride, width: width, height: height}
+
+	for j := 0; j < h; j++ {
+		row := (y+j)*stride + x
+		for i := 0; i < w; i++ {
+			a := p.at(xInt+i, yInt+j)
+			b := p.at(xInt+i+1, yInt+j)
+			c := p.at(xInt+i, yInt+j+1)
+			d := p.at(xInt+i+1, yInt+j+1)
+			dst[row+i] = byte(((8-xFrac)*(8-yFrac)*a + xFrac*(8-yFrac)*b +
+				(8-xFrac)*yFrac*c + xFrac*yFrac*d + 32) >> 6)
+		}
+	}
+}
+
+// refPlane reads reference samples with edge clamping
+type refPlane struct {
+	pix                   []byte
+	stride, width, height int
+}
+
+func (p refPlane) at(x, y int) int32 {
+	x = clip3(0, p.width-1, x)
+	y = clip3(0, p.height-1, y)
+	return int32(p.pix[y*p.stride+x])
+}
+
+func tap6(a, b, c, d, e, f int32) int32 {
+	return a - 5*b + 20*c + 20*d - 5*e + f
+}
+
+// halfH returns the unscaled horizontal half sample between (x, y) and (x+1, y)
+func (p refPlane) halfH(x, y int) int32 {
+	return tap6(p.at(x-2, y), p.at(x-1, y), p.at(x, y), p.at(x+1, y), p.at(x+2, y), p.at(x+3, y))
+}
+
+// halfV returns the unscaled vertical half sample between (x, y) and (x, y+1)
+func (p refPlane) halfV(x, y int) int32 {
+	return tap6(p.at(x, y-2), p.at(x, y-1), p.at(x, y), p.at(x, y+1), p.at(x, y+2), p.at(x, y+3))
+}
+
+// lumaSample returns the luma sample at (x + xFrac/4, y + yFrac/4)
+func (p refPlane) lumaSample(x, y, xFrac, yFrac int) byte {
+	b := func(x, y int) int32 { return int32(clip1((p.halfH(x, y) + 16) >> 5)) }
+	h := func(x, y int) int32 { return int32(clip1((p.halfV(x, y) + 16) >> 5)) }
+	j := func() int32 {
+		j1 := tap6(p.halfH(x, y-2), p.halfH(x, y-1), p.halfH(x, y),
+			p.halfH(x, y+1), p.halfH(x, y+2), p.halfH(x, y+3))
+		return int32(clip1((j1 + 512) >> 10))
+	}
+	avg := func(a, b int32) byte { return byte((a + b + 1) >> 1) }
+
+	switch yFrac<<2 | xFrac {
+	case 0<<2 | 0:
+		return byte(p.at(x, y))
+	case 0<<2 | 1:
+		return avg(p.at(x, y), b(x, y))
+	case 0<<2 | 2:
+		return byte(b(x, y))
+	case 0<<2 | 3:
+		return avg(p.at(x+1, y), b(x, y))
+	case 1<<2 | 0:
+		return avg(p.at(x, y), h(x, y))
+	case 2<<2 | 0:
+		return byte(h(x, y))
+	case 3<<2 | 0:
+		return avg(p.at(x, y+1), h(x, y))
+	case 1<<2 | 1:
+		return avg(b(x, y), h(x, y))
+	case 1<<2 | 3:
+		return avg(b(x, y), h(x+1, y))
+	case 3<<2 | 1:
+		return avg(h(x, y), b(x, y+1))
+	case 3<<2 | 3:
+		return avg(h(x+1, y), b(x, y+1))
+	case 1<<2 | 2:
+		return avg(b(x, y), j())
+	case 3<<2 | 2:
+		return avg(j(), b(x, y+1))
+	case 2<<2 | 1:
+		return avg(h(x, y), j())
+	case 2<<2 | 3:
+		return avg(j(), h(x+1, y))
+	default: // 2<<2 | 2
+		return byte(j())
+	}
+}
@@ -0,0 +1,303 @@
+package transport
+
+// Intra prediction (section 8.3). Predictions are written straight into the
+// picture; residuals are added afterwards. Neighbouring samples that are not
+// available read as 128, which only matters for corrupt streams since the
+// encoder must not pick modes that use them.
+
+// Intra4x4 prediction modes
+const (
+	intra4x4Vertical = iota
+	intra4x4Horizontal
+	intra4x4DC
+	intra4x4DiagonalDownLeft
+	intra4x4DiagonalDownRight
+	intra4x4VerticalRight
+	intra4x4HorizontalDown
+	intra4x4VerticalLeft
+	intra4x4HorizontalUp
+)
+
+// Intra16x16 prediction modes
+const (
+	intra16x16Vertical = iota
+	intra16x16Horizontal
+	intra16x16DC
+	intra16x16Plane
+)
+
+// Intra chroma prediction modes
+const (
+	intraChromaDC = iota
+	intraChromaHorizontal
+	intraChromaVertical
+	intraChromaPlane
+)
+
+// intraNeighbours says which neighbouring samples of a block are available
+type intraNeighbours struct {
+	left, top, topRight, topLeft bool
+}
+
+// edgeSamples loads the n samples above a block (plus n more to the top
+// right), the n samples to its left and the top-left corner sample
+func edgeSamples(plane []byte, stride, x, y, n int, avail intraNeighbours) (top [32]int32, left [16]int32, corner int32) {
+	corner = 128
+	for i := 0; i < 2*n; i++ {
+		top[i] = 128
+	}
+	for i := 0; i < n; i++ {
+		left[i] = 128
+	}
+
+	if avail.top {
+		row := (y-1)*stride + x
+		for i := 0; i < n; i++ {
+			top[i] = int32(plane[row+i])
+		}
+		if avail.topRight {
+			for i := n; i < 2*n; i++ {
+				top[i] = int32(plane[row+i])
+			}
+		} else {
+			for i := n; i < 2*n; i++ {
+				top[i] = top[n-1]
+			}
+		}
+	}
+	if avail.left {
+		for i := 0; i < n; i++ {
+			left[i] = int32(plane[(y+i)*stride+x-1])
+		}
+	}
+	if avail.topLeft {
+		corner = int32(plane[(y-1)*stride+x-1])
+	}
+	return top, left, corner
+}
+
+// predictIntra4x4 writes the Intra4x4 prediction for the block at (x, y)
+func predictIntra4x4(plane []byte, stride, x, y, mode int, avail intraNeighbours) {
+	top, left, corner := edgeSamples(plane, stride, x, y, 4, avail)
+
+	// p returns the neighbouring sample p[px, py] with px or py equal to -1
+	p := func(px, py int) int32 {
+		switch {
+		case px < 0 && py < 0:
+			return corner
+		case py < 0:
+			return top[px]
+		default:
+			return left[py]
+		}
+	}
+
+	var pred [4][4]int32
+	for j := 0; j < 4; j++ {
+		for i := 0; i < 4; i++ {
+			var v int32
+			switch mode {
+			case intra4x4Vertical:
+				v = top[i]
+			case intra4x4Horizontal:
+				v = left[j]
+			case intra4x4DC:
+				v = intraDC(top[:4], left[:4], avail.top, avail.left)
+			case intra4x4DiagonalDownLeft:
+				if i == 3 && j == 3 {
+					v = (top[6] + 3*top[7] + 2) >> 2
+				} else {
+					v = (top[i+j] + 2*top[i+j+1] + top[i+j+2] + 2) >> 2
+				}
+			case intra4x4DiagonalDownRight:
+				switch {
+				case i > j:
+					v = (p(i-j-2, -1) + 2*p(i-j-1, -1) + p(i-j, -1) + 2) >> 2
+				case i < j:
+					v = (p(-1, j-i-2) + 2*p(-1, j-i-1) + p(-1, j-i) + 2) >> 2
+				default:
+					v = (p(0, -1) + 2*corner + p(-1, 0) + 2) >> 2
+				}
+			case intra4x4VerticalRight:
+				z := 2*i - j
+				switch {
+				case z >= 0 && z%2 == 0:
+					v = (p(i-(j>>1)-1, -1) + p(i-(j>>1), -1) + 1) >> 1
+				case z >= 0:
+					v = (p(i-(j>>1)-2, -1) + 2*p(i-(j>>1)-1, -1) + p(i-(j>>1), -1) + 2) >> 2
+				case z == -1:
+					v = (p(-1, 0) + 2*corner + p(0, -1) + 2) >> 2
+				default:
+					v = (p(-1, j-1) + 2*p(-1, j-2) + p(-1, j-3) + 2) >> 2
+				}
+			case intra4x4HorizontalDown:
+				z := 2*j - i
+				switch {
+				case z >= 0 && z%2 == 0:
+					v = (p(-1, j-(i>>1)-1) + p(-1, j-(i>>1)) + 1) >> 1
+				case z >= 0:
+					v = (p(-1, j-(i>>1)-2) + 2*p(-1, j-(i>>1)-1) + p(-1, j-(i>>1)) + 2) >> 2
+				case z == -1:
+					v = (p(-1, 0) + 2*corner + p(0, -1) + 2) >> 2
+				default:
+					v = (p(i-1, -1) + 2*p(i-2, -1) + p(i-3, -1) + 2) >> 2
+				}
+			case intra4x4VerticalLeft:
+				if j%2 == 0 {
+					v = (top[i+(j>>1)] + top[i+(j>>1)+1] + 1) >> 1
+				} else {
+					v = (top[i+(j>>1)] + 2*top[i+(j>>1)+1] + top[i+(j>>1)+2] + 2) >> 2
+				}
+			case intra4x4HorizontalUp:
+				z := i + 2*j
+				switch {
+				case z > 5:
+					v = left[3]
+				case z == 5:
+					v = (left[2] + 3*left[3] + 2) >> 2
+				case z%2 == 0:
+					v = (left[j+(i>>1)] + left[j+(i>>1)+1] + 1) >> 1
+				default:
+					v = (left[j+(i>>1)] + 2*left[j+(i>>1)+1] + left[j+(i>>1)+2] + 2) >> 2
+				}
+			}
+			pred[j][i] = v
+		}
+	}
+
+	for j := 0; j < 4; j++ {
+		row := (y+j)*stride + x
+		for i := 0; i < 4; i++ {
+			plane[row+i] = byte(pred[j][i])
+		}
+	}
+}
+
+// predictIntra16x16 writes the Intra16x16 prediction for the macroblock at (x, y)
+func predictIntra16x16(plane []byte, stride, x, y, mode int, avail intraNeighbours) {
+	top, left, corner := edgeSamples(plane, stride, x, y, 16, avail)
+
+	switch mode {
+	case intra16x16Vertical, intra16x16Horizontal, intra16x16DC:
+		dc := intraDC(top[:16], left[:16], avail.top, avail.left)
+		for j := 0; j < 16; j++ {
+			row := (y+j)*stride + x
+			for i := 0; i < 16; i++ {
+				switch mode {
+				case intra16x16Vertical:
+					plane[row+i] = byte(top[i])
+				case intra16x16Horizontal:
+					plane[row+i] = byte(left[j])
+				default:
+					plane[row+i] = byte(dc)
+				}
+			}
+		}
+	case intra16x16Plane:
+		predictPlane(plane, stride, x, y, 16, top[:], left[:], corner)
+	}
+}
+
+// predictIntraChroma writes the 8x8 chroma prediction for the macroblock at
+// chroma position (x, y)
+func predictIntraChroma(plane []byte, stride, x, y, mode int, avail intraNeighbours) {
+	top, left, corner := edgeSamples(plane, stride, x, y, 8, avail)
+
+	switch mode {
+	case intraChromaDC:
+		// Each 4x4 block has its own DC; the off-diagonal blocks prefer the
+		// neighbour they share an edge with
+		for by := 0; by < 2; by++ {
+			for bx := 0; bx < 2; bx++ {
+				t, l := top[bx*4:bx*4+4], left[by*4:by*4+4]
+				var dc int32
+				switch {
+				case bx == by:
+					dc = intraDC(t, l, avail.top, avail.left)
+				case bx == 1 && avail.top, bx == 0 && !avail.left:
+					dc = intraDC(t, l, avail.top, false)
+				default:
+					dc = intraDC(t, l, false, avail.left)
+				}
+				for j := 0; j < 4; j++ {
+					row := (y+by*4+j)*stride + x + bx*4
+					for i := 0; i < 4; i++ {
+						plane[row+i] = byte(dc)
+					}
+				}
+			}
+		}
+	case intraChromaHorizontal, intraChromaVertical:
+		for j := 0; j < 8; j++ {
+			row := (y+j)*stride + x
+			for i := 0; i < 8; i++ {
+				if mode == intraChromaHorizontal {
+					plane[row+i] = byte(left[j])
+				} else {
+					plane[row+i] = byte(top[i])
+				}
+			}
+		}
+	case intraChromaPlane:
+		predictPlane(plane, stride, x, y, 8, top[:], left[:], corner)
+	}
+}
+
+// intraDC averages the available top and left samples, or returns 128
+func intraDC(top, left []int32, useTop, useLeft bool) int32 {
+	var sum int32
+	n := 0
+	if useTop {
+		for _, v := range top {
+			sum += v
+		}
+		n += len(top)
+	}
+	if useLeft {
+		for _, v := range left {
+			sum += v
+		}
+		n += len(left)
+	}
+	if n == 0 {
+		return 128
+	}
+	return (sum + int32(n/2)) / int32(n)
+}
+
+// predictPlane writes the plane prediction for a 16x16 luma or 8x8 chroma block
+func predictPlane(plane []byte, stride, x, y, size int, top, left []int32, corner int32) {
+	half := size / 2
+	p := func(px, py int) int32 {
+		switch {
+		case px < 0 && py < 0:
+			return corner
+		case py < 0:
+			return top[px]
+		default:
+			return left[py]
+		}
+	}
+
+	var h, v int32
+	for k := 0; k < half; k++ {
+		h += int32(k+1) * (p(half+k, -1) - p(half-2-k, -1))
+		v += int32(k+1) * (p(-1, half+k) - p(-1, half-2-k))
+	}
+
+	a := 16 * (left[size-1] + top[size-1])
+	var b, c int32
+	if size == 16 {
+		b, c = (5*h+32)>>6, (5*v+32)>>6
+	} else {
+		b, c = (34*h+32)>>6, (34*v+32)>>6
+	}
+
+	center := int32(half - 1)
+	for j := 0; j < size; j++ {
+		row := (y+j)*stride + x
+		for i := 0; i < size; i++ {
+			plane[row+i] = clip1((a + b*(int32(i)-center) + c*(int32(j)-center) + 16) >> 5)
+		}
+	}
+}
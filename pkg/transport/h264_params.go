@@ -0,0 +1,369 @@
+package transport
+
+import (
+	"fmt"
+	"image"
+)
+
+// maxDecodeMbs bounds the picture size accepted from an SPS (4096x2304)
+const maxDecodeMbs = 256 * 144
+
+// h264SPS holds the sequence parameter set fields the decoder uses
+type h264SPS struct {
+	id                      int
+	profileIDC              int
+	levelIDC                int
+	log2MaxFrameNum         int
+	pocType                 int
+	log2MaxPOCLsb           int
+	deltaPicOrderAlwaysZero bool
+	numRefFrames            int
+	widthMbs                int
+	heightMbs               int
+	crop                    image.Rectangle // Visible area in luma samples
+}
+
+// Width returns the visible picture width
+func (s *h264SPS) Width() int { return s.crop.Dx() }
+
+// Height returns the visible picture height
+func (s *h264SPS) Height() int { return s.crop.Dy() }
+
+// parseSPS parses a sequence parameter set NAL unit, header byte included
+func parseSPS(nal []byte) (*h264SPS, error) {
+	if len(nal) < 4 {
+		return nil, fmt.Errorf("SPS too short")
+	}
+	br := newH264BitReader(nal[1:])
+	sps := &h264SPS{}
+
+	sps.profileIDC = br.readBits(8)
+	br.skipBits(8) // Constraint flags and reserved bits
+	sps.levelIDC = br.readBits(8)
+	sps.id = br.readUE()
+	if sps.id > 31 {
+		return nil, fmt.Errorf("invalid SPS id %d", sps.id)
+	}
+
+	switch sps.profileIDC {
+	case 100, 110, 122, 244, 44, 83, 86, 118, 128, 138, 139, 134, 135:
+		if chromaFormat := br.readUE(); chromaFormat != 1 {
+			return nil, fmt.Errorf("unsupported chroma format %d", chromaFormat)
+		}
+		if br.readUE() != 0 || br.readUE() != 0 {
+			return nil, fmt.Errorf("unsupported bit depth")
+		}
+		br.skipBits(1) // qpprime_y_zero_transform_bypass_flag
+		if br.readFlag() {
+			return nil, fmt.Errorf("scaling matrices are not supported")
+		}
+	}
+
+	sps.log2MaxFrameNum = br.readUE() + 4
+	sps.pocType = br.readUE()
+	switch sps.pocType {
+	case 0:
+		sps.log2MaxPOCLsb = br.readUE() + 4
+	case 1:
+		sps.deltaPicOrderAlwaysZero = br.readFlag()
+		br.readSE() // offset_for_non_ref_pic
+		br.readSE() // offset_for_top_to_bottom_field
+		cycle := br.readUE()
+		if cycle > 255 {
+			return nil, fmt.Errorf("invalid POC cycle length %d", cycle)
+		}
+		for i := 0; i < cycle; i++ {
+			br.readSE()
+		}
+	case 2:
+	default:
+		return nil, fmt.Errorf("invalid POC type %d", sps.pocType)
+	}
+	if sps.log2MaxFrameNum > 16 || sps.log2MaxPOCLsb > 16 {
+		return nil, fmt.Errorf("invalid frame number or POC size")
+	}
+
+	sps.numRefFrames = br.readUE()
+	if sps.numRefFrames > 16 {
+		return nil, fmt.Errorf("invalid number of reference frames %d", sps.numRefFrames)
+	}
+	br.skipBits(1) // gaps_in_frame_num_value_allowed_flag
+	sps.widthMbs = br.readUE() + 1
+	sps.heightMbs = br.readUE() + 1
+	if !br.readFlag() {
+		return nil, fmt.Errorf("interlaced video is not supported")
+	}
+	if sps.widthMbs*sps.heightMbs > maxDecodeMbs {
+		return nil, fmt.Errorf("picture size %dx%d macroblocks is too large", sps.widthMbs, sps.heightMbs)
+	}
+	br.skipBits(1) // direct_8x8_inference_flag
+
+	width, height := sps.widthMbs*16, sps.heightMbs*16
+	sps.crop = image.Rect(0, 0, width, height)
+	if br.readFlag() {
+		// Crop units are two samples in each direction for 4:2:0 frames
+		left, right := br.readUE()*2, br.readUE()*2
+		top, bottom := br.readUE()*2, br.readUE()*2
+		if left+right >= width || top+bottom >= height {
+			return nil, fmt.Errorf("invalid cropping window")
+		}
+		sps.crop = image.Rect(left, top, width-right, height-bottom)
+	}
+
+	if err := br.err(); err != nil {
+		return nil, err
+	}
+	return sps, nil
+}
+
+// h264PPS holds the picture parameter set fields the decoder uses
+type h264PPS struct {
+	id                             int
+	spsID                          int
+	bottomFieldPicOrderPresent     bool
+	numRefIdxL0Default             int
+	weightedPred                   bool
+	picInitQP                      int
+	chromaQPOffset                 [2]int // Cb, Cr
+	deblockingFilterControlPresent bool
+	constrainedIntraPred           bool
+	redundantPicCntPresent         bool
+}
+
+// parsePPS parses a picture parameter set NAL unit, header byte included
+func parsePPS(nal []byte) (*h264PPS, error) {
+	if len(nal) < 2 {
+		return nil, fmt.Errorf("PPS too short")
+	}
+	br := newH264BitReader(nal[1:])
+	pps := &h264PPS{}
+
+	pps.id = br.readUE()
+	pps.spsID = br.readUE()
+	if pps.id > 255 || pps.spsID > 31 {
+		return nil, fmt.Errorf("invalid PPS id %d or SPS id %d", pps.id, pps.spsID)
+	}
+	if br.readFlag() {
+		return nil, fmt.Errorf("CABAC entropy coding is not supported")
+	}
+	pps.bottomFieldPicOrderPresent = br.readFlag()
+	if br.readUE() != 0 {
+		return nil, fmt.Errorf("slice groups are not supported")
+	}
+	pps.numRefIdxL0Default = br.readUE() + 1
+	br.readUE() // num_ref_idx_l1_default_active_minus1
+	if pps.numRefIdxL0Default > 32 {
+		return nil, fmt.Errorf("invalid number of reference indices %d", pps.numRefIdxL0Default)
+	}
+	pps.weightedPred = br.readFlag()
+	br.skipBits(2) // weighted_bipred_idc
+	pps.picInitQP = 26 + br.readSE()
+	br.readSE() // pic_init_qs_minus26
+	pps.chromaQPOffset[0] = br.readSE()
+	pps.chromaQPOffset[1] = pps.chromaQPOffset[0]
+	pps.deblockingFilterControlPresent = br.readFlag()
+	pps.constrainedIntraPred = br.readFlag()
+	pps.redundantPicCntPresent = br.readFlag()
+
+	if br.moreRBSPData() {
+		if br.readFlag() {
+			return nil, fmt.Errorf("8x8 transforms are not supported")
+		}
+		if br.readFlag() {
+			return nil, fmt.Errorf("scaling matrices are not supported")
+		}
+		pps.chromaQPOffset[1] = br.readSE()
+	}
+
+	if pps.picInitQP < 0 || pps.picInitQP > 51 {
+		return nil, fmt.Errorf("invalid initial QP %d", pps.picInitQP)
+	}
+	if err := br.err(); err != nil {
+		return nil, err
+	}
+	return pps, nil
+}
+
+// Slice types after folding slice_type values 5-9 onto 0-4
+const (
+	h264SliceP = 0
+	h264SliceB = 1
+	h264SliceI = 2
+)
+
+// h264RefPicListMod is one ref_pic_list_modification operation
+type h264RefPicListMod struct {
+	idc   int
+	value int
+}
+
+// h264MMCO is one memory_management_control_operation
+type h264MMCO struct {
+	op                  int
+	differenceOfPicNums int
+}
+
+// h264SliceHeader holds the slice header fields the decoder uses
+type h264SliceHeader struct {
+	sps *h264SPS
+	pps *h264PPS
+
+	idr             bool
+	nalRefIDC       int
+	firstMb         int
+	sliceType       int
+	frameNum        int
+	numRefIdxActive int
+	refPicListMods  []h264RefPicListMod
+	adaptiveMarking bool
+	mmcos           []h264MMCO
+	qp              int
+	disableDeblock  int
+	alphaOffset     int
+	betaOffset      int
+}
+
+// parseSliceHeader parses the header of a coded slice NAL unit and returns the
+// reader positioned at the slice data
+func parseSliceHeader(nal []byte, spss map[int]*h264SPS, ppss map[int]*h264PPS) (*h264SliceHeader, *h264BitReader, error) {
+	if len(nal) < 2 {
+		return nil, nil, fmt.Errorf("slice too short")
+	}
+	br := newH264BitReader(nal[1:])
+	sh := &h264SliceHeader{
+		idr:       nal[0]&0x1F == NALUTypeIDR,
+		nalRefIDC: int(nal[0]>>5) & 0x03,
+	}
+
+	sh.firstMb = br.readUE()
+	sliceType := br.readUE()
+	if sliceType > 9 {
+		return nil, nil, fmt.Errorf("invalid slice type %d", sliceType)
+	}
+	sh.sliceType = sliceType % 5
+	if sh.sliceType != h264SliceP && sh.sliceType != h264SliceI {
+		return nil, nil, fmt.Errorf("unsupported slice type %d", sliceType)
+	}
+	if sh.idr && sh.sliceType != h264SliceI {
+		return nil, nil, fmt.Errorf("IDR slice must be intra coded")
+	}
+
+	ppsID := br.readUE()
+	pps, ok := ppss[ppsID]
+	if !ok {
+		return nil, nil, fmt.Errorf("unknown PPS %d", ppsID)
+	}
+	sps, ok := spss[pps.spsID]
+	if !ok {
+		return nil, nil, fmt.Errorf("unknown SPS %d", pps.spsID)
+	}
+	sh.sps, sh.pps = sps, pps
+	if sh.firstMb >= sps.widthMbs*sps.heightMbs {
+		return nil, nil, fmt.Errorf("first macroblock %d out of range", sh.firstMb)
+	}
+
+	sh.frameNum = br.readBits(sps.log2MaxFrameNum)
+	if sh.idr {
+		br.readUE() // idr_pic_id
+	}
+	switch sps.pocType {
+	case 0:
+		br.skipBits(sps.log2MaxPOCLsb)
+		if pps.bottomFieldPicOrderPresent {
+			br.readSE()
+		}
+	case 1:
+		if !sps.deltaPicOrderAlwaysZero {
+			br.readSE()
+			if pps.bottomFieldPicOrderPresent {
+				br.readSE()
+			}
+		}
+	}
+	if pps.redundantPicCntPresent {
+		if br.readUE() != 0 {
+			return nil, nil, fmt.Errorf("redundant pictures are not supported")
+		}
+	}
+
+	if sh.sliceType == h264SliceP {
+		sh.numRefIdxActive = pps.numRefIdxL0Default
+		if br.readFlag() {
+			sh.numRefIdxActive = br.readUE() + 1
+			if sh.numRefIdxActive > 32 {
+				return nil, nil, fmt.Errorf("invalid number of reference indices %d", sh.numRefIdxActive)
+			}
+		}
+
+		if br.readFlag() {
+			for {
+				idc := br.readUE()
+				if idc == 3 {
+					break
+				}
+				if idc > 2 || len(sh.refPicListMods) > 32 || br.overrun {
+					return nil, nil, fmt.Errorf("invalid reference list modification")
+				}
+				sh.refPicListMods = append(sh.refPicListMods, h264RefPicListMod{idc: idc, value: br.readUE()})
+			}
+		}
+
+		if pps.weightedPred {
+			return nil, nil, fmt.Errorf("weighted prediction is not supported")
+		}
+	}
+
+	if sh.nalRefIDC != 0 {
+		if sh.idr {
+			br.skipBits(2) // no_output_of_prior_pics_flag, long_term_reference_flag
+		} else if sh.adaptiveMarking = br.readFlag(); sh.adaptiveMarking {
+			for {
+				op := br.readUE()
+				if op == 0 {
+					break
+				}
+				if op > 6 || len(sh.mmcos) > 66 || br.overrun {
+					return nil, nil, fmt.Errorf("invalid memory management operation")
+				}
+				mmco := h264MMCO{op: op}
+				if op == 1 || op == 3 {
+					mmco.differenceOfPicNums = br.readUE() + 1
+				}
+				if op == 2 {
+					br.readUE() // long_term_pic_num
+				}
+				if op == 3 || op == 6 {
+					br.readUE() // long_term_frame_idx
+				}
+				if op == 4 {
+					br.readUE() // max_long_term_frame_idx_plus1
+				}
+				sh.mmcos = append(sh.mmcos, mmco)
+			}
+		}
+	}
+
+	sh.qp = pps.picInitQP + br.readSE()
+	if sh.qp < 0 || sh.qp > 51 {
+		return nil, nil, fmt.Errorf("invalid slice QP %d", sh.qp)
+	}
+
+	if pps.deblockingFilterControlPresent {
+		sh.disableDeblock = br.readUE()
+		if sh.disableDeblock > 2 {
+			return nil, nil, fmt.Errorf("invalid deblocking filter mode %d", sh.disableDeblock)
+		}
+		if sh.disableDeblock != 1 {
+			sh.alphaOffset = br.readSE() * 2
+			sh.betaOffset = br.readSE() * 2
+			if sh.alphaOffset < -12 || sh.alphaOffset > 12 || sh.betaOffset < -12 || sh.betaOffset > 12 {
+				return nil, nil, fmt.Errorf("invalid deblocking filter offsets")
+			}
+		}
+	}
+
+	if err := br.err(); err != nil {
+		return nil, nil, err
+	}
+	return sh, br, nil
+}
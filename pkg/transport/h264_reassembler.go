@@ -0,0 +1,403 @@
+package transport
+
+import (
+	"time"
+
+	"github.com/conceptcodes/dji-tello-sdk-go/pkg/utils"
+)
+
+const (
+	// telloVideoPacketSize is the payload size the Tello uses for every video
+	// packet except the last one of a NAL unit.
+	telloVideoPacketSize = 1460
+
+	// maxAccessUnitSize bounds the reassembly buffer. A 960x720 IDR frame is
+	// well under this; anything larger means boundaries were lost.
+	maxAccessUnitSize = 2 * 1024 * 1024
+)
+
+// ReassemblerStats counts what the reassembler has seen
+type ReassemblerStats struct {
+	Packets       int `json:"packets"`        // UDP packets received
+	Frames        int `json:"frames"`         // Complete access units emitted
+	KeyFrames     int `json:"key_frames"`     // Emitted access units that contain an IDR slice
+	PartialFrames int `json:"partial_frames"` // Access units discarded because data was missing or corrupt
+	LostFrames    int `json:"lost_frames"`    // Access units skipped while waiting for a keyframe after a loss
+}
+
+// nalRef locates a NAL unit inside the access unit being assembled
+type nalRef struct {
+	offset  int // Offset of the start code
+	codeLen int // Length of the start code (3 or 4)
+	end     int
+	nalType byte
+	refIDC  byte
+}
+
+// H264Reassembler rebuilds H.264 access units (one picture each) from the
+// Tello's video packets. The drone splits every NAL unit into 1460-byte
+// packets, so a single datagram rarely holds a whole frame. Packets are
+// buffered until the access unit is complete: either its slice ends with a
+// short packet, or the next access unit begins, which is detected from the
+// NAL unit types and first_mb_in_slice as described in H.264 section 7.4.1.2.3.
+//
+// The stream carries no sequence numbers, so losses are inferred from the
+// Tello's packetization: only the last packet of a NAL unit is shorter than
+// 1460 bytes, and only the first one begins with a start code. A frame that
+// lost its first packet is discarded and so are the following frames until
+// the next keyframe, because they reference a picture the decoder never
+// received. A NAL unit whose last packet is full-sized is either an exact
+// multiple of 1460 bytes or lost its end, which cannot be told apart, so it is
+// emitted and, like losses in the middle of a NAL unit, left to the decoder.
+// Keyframes are emitted with the most recent SPS and PPS in front of them so
+// that each one can be decoded on its own.
+//
+// An H264Reassembler is not safe for concurrent use.
+type H264Reassembler struct {
+	stream     []byte    // Bytes of the NAL unit currently being received
+	nalStart   time.Time // Arrival time of the first packet of that NAL unit
+	searchFrom int       // Where to resume looking for the next start code in stream
+	synced     bool      // A start code has been seen
+	lastShort  bool      // The previous packet ended a NAL unit
+	waitForIDR bool      // Drop frames until the next keyframe
+
+	au         []byte   // Access unit being assembled, start codes included
+	nals       []nalRef // NAL units in au
+	frameStart time.Time
+	hasVCL     bool
+	corrupted  bool
+
+	sps    []byte
+	pps    []byte
+	seqNum int
+	stats  ReassemblerStats
+}
+
+// NewH264Reassembler creates a reassembler that is waiting for the first start code
+func NewH264Reassembler() *H264Reassembler {
+	return &H264Reassembler{}
+}
+
+// Push adds one packet received at ts and returns the frames it completed
+func (r *H264Reassembler) Push(packet []byte, ts time.Time) []VideoFrame {
+	r.stats.Packets++
+
+	if len(packet) == 0 {
+		return nil
+	}
+
+	var frames []VideoFrame
+	startsNAL := startCodeLen(packet, 0) > 0
+	switch {
+	case r.synced && r.lastShort && !startsNAL:
+		// A NAL unit ended with the previous packet, so the packet carrying the
+		// next start code was lost. The buffered NAL unit is complete; the
+		// picture that followed it is gone.
+		utils.Logger.Debugf("H.264 reassembler: start of NAL unit lost")
+		if frame, ok := r.addNAL(r.stream); ok {
+			frames = append(frames, frame)
+		}
+		if frame, ok := r.finish(); ok {
+			frames = append(frames, frame)
+		}
+		r.stats.PartialFrames++
+		r.waitForIDR = true
+		r.stream = r.stream[:0]
+		r.synced = false
+	case r.synced && !r.lastShort && startsNAL:
+		// The buffered NAL unit never received a short final packet. Either it
+		// fills whole packets or its end was lost; the decoder can tell.
+		utils.Logger.Debugf("H.264 reassembler: NAL unit ended with a full packet")
+	}
+	r.lastShort = len(packet) < telloVideoPacketSize
+
+	if !r.synced {
+		idx := findStartCode(packet, 0)
+		if idx < 0 {
+			return frames
+		}
+		packet = packet[idx:]
+		r.synced = true
+		r.stream = r.stream[:0]
+		r.searchFrom = 0
+		r.nalStart = ts
+	}
+
+	if len(r.stream) == 0 {
+		r.nalStart = ts
+	}
+	r.stream = append(r.stream, packet...)
+
+	for {
+		// Skip the start code at the head of the stream when searching
+		from := r.searchFrom
+		if minFrom := startCodeLen(r.stream, 0); from < minFrom {
+			from = minFrom
+		}
+		next := findStartCode(r.stream, from)
+		if next < 0 {
+			// Resume just before the end, since a start code may straddle packets
+			r.searchFrom = len(r.stream) - 4
+			break
+		}
+		if frame, ok := r.addNAL(r.stream[:next]); ok {
+			frames = append(frames, frame)
+		}
+		r.stream = r.stream[next:]
+		r.searchFrom = 0
+		r.nalStart = ts
+	}
+
+	if len(r.stream)+len(r.au) > maxAccessUnitSize {
+		utils.Logger.Warnf("H.264 reassembler: access unit exceeds %d bytes, discarding", maxAccessUnitSize)
+		r.stats.PartialFrames++
+		r.waitForIDR = true
+		r.stream = r.stream[:0]
+		r.synced = false
+		r.discard()
+		return frames
+	}
+
+	// A short packet ends a NAL unit. The Tello sends one slice per picture,
+	// so a completed slice also completes the access unit and can be emitted
+	// without waiting for the next one to start.
+	if r.lastShort && len(r.stream) > 0 {
+		if frame, ok := r.addNAL(r.stream); ok {
+			frames = append(frames, frame)
+		}
+		r.stream = r.stream[:0]
+		r.searchFrom = 0
+		if r.hasVCL {
+			if frame, ok := r.finish(); ok {
+				frames = append(frames, frame)
+			}
+		}
+	}
+
+	return frames
+}
+
+// Flush completes the frame being assembled, treating the buffered data as
+// its last NAL unit, and returns the frames that completes. It is used at
+// the end of a stream.
+func (r *H264Reassembler) Flush() []VideoFrame {
+	var frames []VideoFrame
+	if r.synced && len(r.stream) > 0 {
+		if frame, ok := r.addNAL(r.stream); ok {
+			frames = append(frames, frame)
+		}
+		r.stream = r.stream[:0]
+		r.searchFrom = 0
+	}
+	if frame, ok := r.finish(); ok {
+		frames = append(frames, frame)
+	}
+	return frames
+}
+
+// Stats returns the reassembly counters
+func (r *H264Reassembler) Stats() ReassemblerStats {
+	return r.stats
+}
+
+// ParameterSets returns the most recent SPS and PPS NAL units, without start
+// codes, or nil if none has been received yet
+func (r *H264Reassembler) ParameterSets() (sps, pps []byte) {
+	return r.sps, r.pps
+}
+
+// addNAL appends one NAL unit, including its start code, to the current access
+// unit. If the NAL unit begins a new access unit, the previous one is
+// finished and returned.
+func (r *H264Reassembler) addNAL(nal []byte) (VideoFrame, bool) {
+	codeLen := startCodeLen(nal, 0)
+	if codeLen == 0 || len(nal) <= codeLen {
+		return VideoFrame{}, false
+	}
+
+	header := nal[codeLen]
+	nalType := header & 0x1F
+	isVCL := nalType >= NALUTypeSlice && nalType <= NALUTypeIDR
+
+	var frame VideoFrame
+	var finished bool
+	if r.hasVCL && startsAccessUnit(nal[codeLen:]) {
+		frame, finished = r.finish()
+	}
+
+	if len(r.au) == 0 {
+		r.frameStart = r.nalStart
+	}
+
+	r.nals = append(r.nals, nalRef{
+		offset:  len(r.au),
+		codeLen: codeLen,
+		end:     len(r.au) + len(nal),
+		nalType: nalType,
+		refIDC:  (header >> 5) & 0x03,
+	})
+	r.au = append(r.au, nal...)
+
+	// A set forbidden_zero_bit means the NAL unit was damaged in transit
+	if header&0x80 != 0 {
+		r.corrupted = true
+	}
+	if isVCL {
+		r.hasVCL = true
+	}
+
+	switch nalType {
+	case NALUTypeSPS:
+		r.sps = append(r.sps[:0], nal[codeLen:]...)
+	case NALUTypePPS:
+		r.pps = append(r.pps[:0], nal[codeLen:]...)
+	}
+
+	return frame, finished
+}
+
+// finish turns the current access unit into a frame, or discards it when it
+// is damaged or cannot be decoded.
+func (r *H264Reassembler) finish() (VideoFrame, bool) {
+	if len(r.nals) == 0 {
+		return VideoFrame{}, false
+	}
+	defer r.discard()
+
+	if !r.hasVCL {
+		// Parameter sets or SEI without a picture; keep the cached SPS/PPS only
+		return VideoFrame{}, false
+	}
+
+	isKey, hasSPS, hasPPS := false, false, false
+	for _, n := range r.nals {
+		switch n.nalType {
+		case NALUTypeIDR:
+			isKey = true
+		case NALUTypeSPS:
+			hasSPS = true
+		case NALUTypePPS:
+			hasPPS = true
+		}
+	}
+
+	if r.corrupted {
+		r.stats.PartialFrames++
+		r.waitForIDR = true
+		return VideoFrame{}, false
+	}
+	if r.waitForIDR && !isKey {
+		r.stats.LostFrames++
+		return VideoFrame{}, false
+	}
+	r.waitForIDR = false
+
+	// Build the frame in a fresh buffer since r.au is reused
+	size := len(r.au)
+	if isKey && !hasSPS && r.sps != nil {
+		size += 4 + len(r.sps)
+	}
+	if isKey && !hasPPS && r.pps != nil {
+		size += 4 + len(r.pps)
+	}
+	data := make([]byte, 0, size)
+	nalUnits := make([]NALUnit, 0, len(r.nals)+2)
+
+	appendNAL := func(startCode, payload []byte, refIDC byte) {
+		data = append(data, startCode...)
+		start := len(data)
+		data = append(data, payload...)
+		nalUnits = append(nalUnits, NALUnit{
+			Type:       payload[0] & 0x1F,
+			RefIDC:     refIDC,
+			Data:       data[start:len(data):len(data)],
+			StartCode:  data[start-len(startCode) : start : start],
+			Size:       len(payload),
+			IsKeyFrame: payload[0]&0x1F == NALUTypeIDR,
+		})
+	}
+
+	startCode := []byte{0x00, 0x00, 0x00, 0x01}
+	if isKey && !hasSPS && r.sps != nil {
+		appendNAL(startCode, r.sps, (r.sps[0]>>5)&0x03)
+	}
+	if isKey && !hasPPS && r.pps != nil {
+		appendNAL(startCode, r.pps, (r.pps[0]>>5)&0x03)
+	}
+	for _, n := range r.nals {
+		appendNAL(r.au[n.offset:n.offset+n.codeLen], r.au[n.offset+n.codeLen:n.end], n.refIDC)
+	}
+
+	frame := VideoFrame{
+		Data:       data,
+		Timestamp:  r.frameStart,
+		Size:       len(data),
+		SeqNum:     r.seqNum,
+		NALUnits:   nalUnits,
+		IsKeyFrame: isKey,
+	}
+	r.seqNum++
+	r.stats.Frames++
+	if isKey {
+		r.stats.KeyFrames++
+	}
+	return frame, true
+}
+
+// discard drops the current access unit
+func (r *H264Reassembler) discard() {
+	r.au = r.au[:0]
+	r.nals = r.nals[:0]
+	r.hasVCL = false
+	r.corrupted = false
+}
+
+// startsAccessUnit reports whether a NAL unit (header first) is the first of a
+// new access unit when the current one already holds a picture.
+func startsAccessUnit(nal []byte) bool {
+	switch nalType := nal[0] & 0x1F; {
+	case nalType == NALUTypeAUD, nalType == NALUTypeSPS, nalType == NALUTypePPS, nalType == NALUTypeSEI:
+		return true
+	case nalType >= 14 && nalType <= 18:
+		return true
+	case nalType >= NALUTypeSlice && nalType <= NALUTypeIDR:
+		// first_mb_in_slice is ue(v) coded; it is 0, i.e. the slice starts a
+		// new picture, exactly when its first bit is 1.
+		return len(nal) > 1 && nal[1]&0x80 != 0
+	default:
+		return false
+	}
+}
+
+// findStartCode returns the offset of the first start code at or after from,
+// including a leading zero byte of a 4-byte start code, or -1
+func findStartCode(data []byte, from int) int {
+	if from < 0 {
+		from = 0
+	}
+	for i := from; i+2 < len(data); i++ {
+		if data[i+2] > 1 {
+			i += 2
+			continue
+		}
+		if data[i] == 0 && data[i+1] == 0 && data[i+2] == 1 {
+			if i > from && data[i-1] == 0 {
+				return i - 1
+			}
+			return i
+		}
+	}
+	return -1
+}
+
+// startCodeLen returns the length of the start code at offset i, or 0
+func startCodeLen(data []byte, i int) int {
+	switch {
+	case len(data) >= i+4 && data[i] == 0 && data[i+1] == 0 && data[i+2] == 0 && data[i+3] == 1:
+		return 4
+	case len(data) >= i+3 && data[i] == 0 && data[i+1] == 0 && data[i+2] == 1:
+		return 3
+	default:
+		return 0
+	}
+}
@@ -0,0 +1,246 @@
+package transport
+
+import (
+	"bytes"
+	"testing"
+	"time"
+)
+
+// testNAL builds a NAL unit with a 4-byte start code, the given header bytes
+// and filler that contains no start code
+func testNAL(size int, header ...byte) []byte {
+	nal := append([]byte{0x00, 0x00, 0x00, 0x01}, header...)
+	for len(nal) < size {
+		nal = append(nal, 0xAB)
+	}
+	return nal
+}
+
+var (
+	testSPS = testNAL(12, 0x67, 0x42, 0xC0, 0x1E)
+	testPPS = testNAL(8, 0x68, 0xCE)
+)
+
+func testIDR(size int) []byte { return testNAL(size, 0x65, 0x88) }
+func testP(size int) []byte   { return testNAL(size, 0x41, 0x9A) }
+
+// packetize splits an access unit into Tello-sized packets
+func packetize(au []byte) [][]byte {
+	var packets [][]byte
+	for len(au) > telloVideoPacketSize {
+		packets = append(packets, au[:telloVideoPacketSize])
+		au = au[telloVideoPacketSize:]
+	}
+	return append(packets, au)
+}
+
+func concat(parts ...[]byte) []byte {
+	return bytes.Join(parts, nil)
+}
+
+// testStream returns the packets of SPS+PPS+IDR, three P frames, an IDR without
+// parameter sets and a final P frame
+func testStream() [][][]byte {
+	return [][][]byte{
+		packetize(concat(testSPS, testPPS, testIDR(5000))),
+		packetize(testP(2000)),
+		packetize(testP(2000)),
+		packetize(testP(2000)),
+		packetize(testIDR(4000)),
+		packetize(testP(900)),
+	}
+}
+
+func pushAll(r *H264Reassembler, aus [][][]byte) []VideoFrame {
+	var frames []VideoFrame
+	now := time.Now()
+	for i, packets := range aus {
+		for _, packet := range packets {
+			frames = append(frames, r.Push(packet, now.Add(time.Duration(i)*33*time.Millisecond))...)
+		}
+	}
+	return append(frames, r.Flush()...)
+}
+
+func nalTypes(frame VideoFrame) []byte {
+	types := make([]byte, 0, len(frame.NALUnits))
+	for _, nal := range frame.NALUnits {
+		types = append(types, nal.Type)
+	}
+	return types
+}
+
+func TestH264Reassembler_CompleteStream(t *testing.T) {
+	r := NewH264Reassembler()
+	frames := pushAll(r, testStream())
+
+	if len(frames) != 6 {
+		t.Fatalf("Expected 6 frames, got %d", len(frames))
+	}
+
+	expectedKey := []bool{true, false, false, false, true, false}
+	for i, frame := range frames {
+		if frame.IsKeyFrame != expectedKey[i] {
+			t.Errorf("Frame %d: expected keyframe %v, got %v", i, expectedKey[i], frame.IsKeyFrame)
+		}
+		if frame.SeqNum != i {
+			t.Errorf("Frame %d: expected sequence number %d, got %d", i, i, frame.SeqNum)
+		}
+		if frame.Size != len(frame.Data) {
+			t.Errorf("Frame %d: expected size %d, got %d", i, len(frame.Data), frame.Size)
+		}
+	}
+
+	if !bytes.Equal(frames[0].Data, concat(testSPS, testPPS, testIDR(5000))) {
+		t.Error("Expected first frame to contain SPS, PPS and the whole IDR slice")
+	}
+	if !bytes.Equal(frames[1].Data, testP(2000)) {
+		t.Error("Expected second frame to contain the whole P slice")
+	}
+	if types := nalTypes(frames[1]); !bytes.Equal(types, []byte{NALUTypeSlice}) {
+		t.Errorf("Expected a single slice NAL unit, got %v", types)
+	}
+
+	// The second keyframe arrives without parameter sets; the cached ones are added
+	if types := nalTypes(frames[4]); !bytes.Equal(types, []byte{NALUTypeSPS, NALUTypePPS, NALUTypeIDR}) {
+		t.Errorf("Expected cached SPS and PPS before the IDR slice, got %v", types)
+	}
+	if !bytes.Equal(frames[4].NALUnits[0].Data, testSPS[4:]) {
+		t.Error("Expected cached SPS to match the one received")
+	}
+
+	if frames[1].Timestamp.Sub(frames[0].Timestamp) != 33*time.Millisecond {
+		t.Errorf("Expected frames to be stamped with their first packet, got %v apart",
+			frames[1].Timestamp.Sub(frames[0].Timestamp))
+	}
+
+	stats := r.Stats()
+	if stats.Frames != 6 || stats.KeyFrames != 2 || stats.PartialFrames != 0 || stats.LostFrames != 0 {
+		t.Errorf("Unexpected stats: %+v", stats)
+	}
+
+	sps, pps := r.ParameterSets()
+	if !bytes.Equal(sps, testSPS[4:]) || !bytes.Equal(pps, testPPS[4:]) {
+		t.Error("Expected parameter sets to be cached")
+	}
+}
+
+func TestH264Reassembler_LostPackets(t *testing.T) {
+	aus := testStream()
+	aus[2] = aus[2][1:]
+
+	r := NewH264Reassembler()
+	frames := pushAll(r, aus)
+
+	// The P frame that lost its start is discarded, the next P frame is
+	// skipped and decoding resumes at the keyframe
+	if len(frames) != 4 {
+		t.Fatalf("Expected 4 frames, got %d", len(frames))
+	}
+	if !frames[2].IsKeyFrame {
+		t.Error("Expected the stream to resume at the next keyframe")
+	}
+	if !bytes.Equal(frames[1].Data, testP(2000)) {
+		t.Error("Expected the frame before the loss to be intact")
+	}
+
+	stats := r.Stats()
+	if stats.PartialFrames != 1 || stats.LostFrames != 1 {
+		t.Errorf("Expected 1 partial and 1 lost frame, got %+v", stats)
+	}
+}
+
+func TestH264Reassembler_FullFinalPacket(t *testing.T) {
+	tests := []struct {
+		name string
+		p    []byte // Second P frame, sent with a full-sized last packet
+		drop bool   // Its trailing packets are lost
+	}{
+		{name: "exact multiple of the packet size", p: testP(2 * telloVideoPacketSize)},
+		{name: "end of frame lost", p: testP(2000), drop: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			aus := testStream()
+			aus[2] = packetize(tt.p)
+			if tt.drop {
+				aus[2] = aus[2][:1]
+			}
+
+			r := NewH264Reassembler()
+			frames := pushAll(r, aus)
+
+			// Neither can be told apart from the packets, so the frame is
+			// emitted for the decoder to judge and the stream goes on
+			if len(frames) != 6 {
+				t.Fatalf("Expected 6 frames, got %d", len(frames))
+			}
+			want := tt.p
+			if tt.drop {
+				want = tt.p[:telloVideoPacketSize]
+			}
+			if !bytes.Equal(frames[2].Data, want) {
+				t.Error("Expected the frame with a full final packet to be emitted as received")
+			}
+			if !bytes.Equal(frames[3].Data, testP(2000)) {
+				t.Error("Expected the next P frame to follow")
+			}
+			if stats := r.Stats(); stats.PartialFrames != 0 || stats.LostFrames != 0 {
+				t.Errorf("Expected no partial or lost frames, got %+v", stats)
+			}
+		})
+	}
+}
+
+func TestH264Reassembler_SyncAndSplitStartCode(t *testing.T) {
+	r := NewH264Reassembler()
+	now := time.Now()
+
+	// Data before the first start code is skipped
+	if frames := r.Push([]byte{0xAB, 0xCD, 0xEF}, now); len(frames) != 0 {
+		t.Errorf("Expected no frames before sync, got %d", len(frames))
+	}
+
+	// An AUD with a 3-byte start code, and a start code that straddles two packets
+	aud := []byte{0x00, 0x00, 0x01, 0x09, 0xF0}
+	idrSize := telloVideoPacketSize - 2 - len(aud) - len(testSPS) - len(testPPS)
+	stream := concat(aud, testSPS, testPPS, testIDR(idrSize), testP(200), testP(200))
+
+	var frames []VideoFrame
+	for _, packet := range packetize(stream) {
+		frames = append(frames, r.Push(packet, now)...)
+	}
+	frames = append(frames, r.Flush()...)
+
+	if len(frames) != 3 || !frames[0].IsKeyFrame {
+		t.Fatalf("Expected 3 frames starting with a keyframe, got %d", len(frames))
+	}
+	if !bytes.Equal(frames[1].Data, testP(200)) {
+		t.Error("Expected the P slice split across packets to be reassembled")
+	}
+	if types := nalTypes(frames[0]); !bytes.Equal(types, []byte{NALUTypeAUD, NALUTypeSPS, NALUTypePPS, NALUTypeIDR}) {
+		t.Errorf("Expected AUD, SPS, PPS and IDR in the first frame, got %v", types)
+	}
+}
+
+func TestFindStartCode(t *testing.T) {
+	tests := []struct {
+		data     []byte
+		from     int
+		expected int
+	}{
+		{[]byte{0x00, 0x00, 0x01, 0x65}, 0, 0},
+		{[]byte{0x00, 0x00, 0x00, 0x01, 0x65}, 0, 0},
+		{[]byte{0xAB, 0x00, 0x00, 0x00, 0x01}, 0, 1},
+		{[]byte{0xAB, 0xAB, 0xAB, 0x00, 0x00, 0x01}, 0, 3},
+		{[]byte{0x00, 0x00, 0x01, 0x65, 0x00, 0x00, 0x01}, 3, 4},
+		{[]byte{0xAB, 0x00, 0x00, 0x02}, 0, -1},
+	}
+
+	for _, tt := range tests {
+		if got := findStartCode(tt.data, tt.from); got != tt.expected {
+			t.Errorf("findStartCode(%x, %d): expected %d, got %d", tt.data, tt.from, tt.expected, got)
+		}
+	}
+}
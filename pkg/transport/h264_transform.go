@@ -0,0 +1,142 @@
+package transport
+
+// zigzag4x4 maps scan position to raster position in a 4x4 block (frame scan)
+var zigzag4x4 = [16]int{0, 1, 4, 8, 5, 2, 3, 6, 9, 12, 13, 10, 7, 11, 14, 15}
+
+// dequantScale is normAdjust4x4 (section 8.5.9) per QP%6 and coefficient position.
+// With flat scaling matrices LevelScale4x4 is 16 times this value.
+var dequantScale = [6][16]int32{}
+
+func init() {
+	v := [6][3]int32{
+		{10, 16, 13},
+		{11, 18, 14},
+		{13, 20, 16},
+		{14, 23, 18},
+		{16, 25, 20},
+		{18, 29, 23},
+	}
+	for m := 0; m < 6; m++ {
+		for i := 0; i < 16; i++ {
+			x, y := i%4, i/4
+			switch {
+			case x%2 == 0 && y%2 == 0:
+				dequantScale[m][i] = v[m][0]
+			case x%2 == 1 && y%2 == 1:
+				dequantScale[m][i] = v[m][1]
+			default:
+				dequantScale[m][i] = v[m][2]
+			}
+		}
+	}
+}
+
+// chromaQPTable maps qPI (0-51) to QPc (Table 8-15)
+var chromaQPTable = [52]int{
+	0, 1, 2, 3, 4, 5, 6, 7, 8, 9, 10, 11, 12, 13, 14, 15, 16, 17, 18, 19,
+	20, 21, 22, 23, 24, 25, 26, 27, 28, 29, 29, 30, 31, 32, 32, 33, 34, 34,
+	35, 35, 36, 36, 37, 37, 37, 38, 38, 38, 39, 39, 39, 39,
+}
+
+// chromaQP derives QPc from the luma QP and the chroma offset
+func chromaQP(qp, offset int) int {
+	return chromaQPTable[clip3(0, 51, qp+offset)]
+}
+
+// dequantize4x4 scales the raster-ordered coefficients of a 4x4 block in
+// place. With skipDC set the DC coefficient is left alone because it was
+// scaled by the luma or chroma DC transform.
+func dequantize4x4(c *[16]int32, qp int, skipDC bool) {
+	scale := &dequantScale[qp%6]
+	shift := uint(qp / 6)
+	start := 0
+	if skipDC {
+		start = 1
+	}
+	for i := start; i < 16; i++ {
+		if c[i] != 0 {
+			c[i] = (c[i] * scale[i]) << shift
+		}
+	}
+}
+
+// idct4x4Add applies the inverse 4x4 transform (section 8.5.12.2) to the
+// raster-ordered coefficients and adds the result to the 4x4 block at dst
+func idct4x4Add(c *[16]int32, dst []byte, stride int) {
+	var tmp [16]int32
+	for i := 0; i < 4; i++ {
+		d0, d1, d2, d3 := c[i*4], c[i*4+1], c[i*4+2], c[i*4+3]
+		e, f := d0+d2, d0-d2
+		g, h := (d1>>1)-d3, d1+(d3>>1)
+		tmp[i*4] = e + h
+		tmp[i*4+1] = f + g
+		tmp[i*4+2] = f - g
+		tmp[i*4+3] = e - h
+	}
+	for j := 0; j < 4; j++ {
+		d0, d1, d2, d3 := tmp[j], tmp[4+j], tmp[8+j], tmp[12+j]
+		e, f := d0+d2, d0-d2
+		g, h := (d1>>1)-d3, d1+(d3>>1)
+		col := [4]int32{e + h, f + g, f - g, e - h}
+		for i := 0; i < 4; i++ {
+			p := &dst[i*stride+j]
+			*p = clip1(int32(*p) + (col[i]+32)>>6)
+		}
+	}
+}
+
+// lumaDCTransform applies the inverse Hadamard transform and scaling to the
+// raster-ordered Intra16x16 DC coefficients (section 8.5.10)
+func lumaDCTransform(c *[16]int32, qp int) {
+	var tmp [16]int32
+	for i := 0; i < 4; i++ {
+		a, b, cc, d := c[i*4], c[i*4+1], c[i*4+2], c[i*4+3]
+		tmp[i*4] = a + b + cc + d
+		tmp[i*4+1] = a + b - cc - d
+		tmp[i*4+2] = a - b - cc + d
+		tmp[i*4+3] = a - b + cc - d
+	}
+	scale := dequantScale[qp%6][0] * 16
+	for j := 0; j < 4; j++ {
+		a, b, cc, d := tmp[j], tmp[4+j], tmp[8+j], tmp[12+j]
+		f := [4]int32{a + b + cc + d, a + b - cc - d, a - b - cc + d, a - b + cc - d}
+		for i := 0; i < 4; i++ {
+			if qp >= 36 {
+				c[i*4+j] = (f[i] * scale) << uint(qp/6-6)
+			} else {
+				c[i*4+j] = (f[i]*scale + 1<<uint(5-qp/6)) >> uint(6-qp/6)
+			}
+		}
+	}
+}
+
+// chromaDCTransform applies the inverse 2x2 transform and scaling to the
+// raster-ordered chroma DC coefficients (section 8.5.11)
+func chromaDCTransform(c *[4]int32, qp int) {
+	a, b, cc, d := c[0], c[1], c[2], c[3]
+	f := [4]int32{a + b + cc + d, a - b + cc - d, a + b - cc - d, a - b - cc + d}
+	scale := dequantScale[qp%6][0] * 16
+	for i := range f {
+		c[i] = ((f[i] * scale) << uint(qp/6)) >> 5
+	}
+}
+
+func clip1(v int32) byte {
+	if v < 0 {
+		return 0
+	}
+	if v > 255 {
+		return 255
+	}
+	return byte(v)
+}
+
+func clip3(lo, hi, v int) int {
+	if v < lo {
+		return lo
+	}
+	if v > hi {
+		return hi
+	}
+	return v
+}
@@ -43,6 +43,7 @@ func NewMLVideoIntegration(listenAddr string, mlConfig *ml.MLConfig) (*MLVideoIn
 		cancel()
 		return nil, fmt.Errorf("failed to create video listener: %w", err)
 	}
+	videoListener.SetDecoder(NewH264Decoder())
 
 	// Create model manager
 	modelsDir := filepath.Join(".", "models")
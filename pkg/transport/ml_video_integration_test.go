@@ -39,13 +39,22 @@ func TestVideoFrame_ToEnhancedFrame(t *testing.T) {
 	assert.True(t, enhancedFrame.IsKeyFrame)
 	assert.False(t, enhancedFrame.Processed)
 	assert.NotNil(t, enhancedFrame.MLResults)
-	assert.Equal(t, defaultVideoFrameWidth, enhancedFrame.Width)
-	assert.Equal(t, defaultVideoFrameHeight, enhancedFrame.Height)
+
+	// Undecoded frames carry no picture
+	assert.Nil(t, enhancedFrame.Image)
+	assert.Equal(t, 0, enhancedFrame.Width)
+	assert.Equal(t, 0, enhancedFrame.Height)
+
+	// Decoded frames take the picture and its size
+	videoFrame.Image = image.NewYCbCr(image.Rect(0, 0, 960, 720), image.YCbCrSubsampleRatio420)
+	enhancedFrame = videoFrame.ToEnhancedFrame()
+	assert.Equal(t, 960, enhancedFrame.Width)
+	assert.Equal(t, 720, enhancedFrame.Height)
 	assert.Equal(t, 3, enhancedFrame.Channels)
 
 	img, ok := enhancedFrame.Image.(image.Image)
 	assert.True(t, ok)
-	assert.NotNil(t, img)
+	assert.Equal(t, videoFrame.Image, img)
 }
 
 func TestNewMLVideoIntegration(t *testing.T) {
@@ -3,6 +3,7 @@ package transport
 import (
 	"bufio"
 	"fmt"
+	"io"
 	"os"
 	"os/exec"
 	"path/filepath"
@@ -21,11 +22,21 @@ const (
 	FormatMP4  VideoFormat = "mp4"
 )
 
-// MP4VideoRecorder handles saving video frames to MP4 files using FFmpeg
+// MP4 muxers accepted by MP4VideoRecorder.SetMuxer
+const (
+	MP4MuxerGo     = "go"
+	MP4MuxerFFmpeg = "ffmpeg"
+)
+
+// MP4VideoRecorder handles saving video frames to MP4 files, either with the
+// pure-Go FMP4Writer (the default) or by piping them through FFmpeg
 type MP4VideoRecorder struct {
 	filePath    string
-	tempFile    string
+	muxer       string
+	file        *os.File
+	writer      *FMP4Writer
 	ffmpegCmd   *exec.Cmd
+	ffmpegPipe  io.WriteCloser
 	ffmpegStdin *bufio.Writer
 	mutex       sync.Mutex
 	isRecording bool
@@ -42,6 +53,7 @@ type MP4VideoRecorder struct {
 func NewMP4VideoRecorder(filePath string) *MP4VideoRecorder {
 	return &MP4VideoRecorder{
 		filePath: filePath,
+		muxer:    MP4MuxerGo,
 		width:    960,     // Tello video width
 		height:   720,     // Tello video height
 		fps:      30,      // Tello video FPS
@@ -49,7 +61,26 @@ func NewMP4VideoRecorder(filePath string) *MP4VideoRecorder {
 	}
 }
 
-// SetVideoParams sets video parameters for the MP4 output
+// SetMuxer selects the muxer of the next recording: "go" (the default) for
+// the pure-Go FMP4Writer or "ffmpeg" to pipe frames through FFmpeg
+func (mvr *MP4VideoRecorder) SetMuxer(muxer string) error {
+	switch strings.ToLower(muxer) {
+	case "", MP4MuxerGo:
+		muxer = MP4MuxerGo
+	case MP4MuxerFFmpeg:
+		muxer = MP4MuxerFFmpeg
+	default:
+		return fmt.Errorf("unknown MP4 muxer %q (expected %q or %q)", muxer, MP4MuxerGo, MP4MuxerFFmpeg)
+	}
+
+	mvr.mutex.Lock()
+	defer mvr.mutex.Unlock()
+	mvr.muxer = muxer
+	return nil
+}
+
+// SetVideoParams sets video parameters for the MP4 output. FFmpeg encodes
+// with them, while the Go muxer takes the picture size from the stream.
 func (mvr *MP4VideoRecorder) SetVideoParams(width, height, fps, bitrate int) {
 	mvr.width = width
 	mvr.height = height
@@ -75,17 +106,49 @@ func (mvr *MP4VideoRecorder) StartRecording() error {
 		return fmt.Errorf("MP4 recording is already in progress")
 	}
 
-	// Check FFmpeg availability
-	if err := mvr.checkFFmpeg(); err != nil {
-		return err
-	}
-
 	// Ensure output directory exists
 	outputDir := filepath.Dir(mvr.filePath)
 	if err := os.MkdirAll(outputDir, 0o755); err != nil {
 		return fmt.Errorf("failed to create output directory: %w", err)
 	}
 
+	var err error
+	if mvr.muxer == MP4MuxerFFmpeg {
+		err = mvr.startFFmpeg()
+	} else {
+		err = mvr.startWriter()
+	}
+	if err != nil {
+		return err
+	}
+
+	mvr.isRecording = true
+	mvr.frameCount = 0
+	mvr.totalBytes = 0
+	mvr.startTime = time.Now()
+
+	utils.Logger.Infof("Started MP4 recording to: %s (muxer: %s)", mvr.filePath, mvr.muxer)
+	return nil
+}
+
+// startWriter creates the file muxed by FMP4Writer
+func (mvr *MP4VideoRecorder) startWriter() error {
+	file, err := os.Create(mvr.filePath)
+	if err != nil {
+		return fmt.Errorf("failed to create video file '%s': %w", mvr.filePath, err)
+	}
+	mvr.file = file
+	mvr.writer = NewFMP4Writer(file)
+	return nil
+}
+
+// startFFmpeg starts FFmpeg muxing its stdin into the file
+func (mvr *MP4VideoRecorder) startFFmpeg() error {
+	// Check FFmpeg availability
+	if err := mvr.checkFFmpeg(); err != nil {
+		return err
+	}
+
 	// FFmpeg command to convert H.264 to MP4
 	ffmpegArgs := []string{
 		"-f", "h264",
@@ -115,13 +178,8 @@ func (mvr *MP4VideoRecorder) StartRecording() error {
 	}
 
 	mvr.ffmpegCmd = cmd
+	mvr.ffmpegPipe = stdin
 	mvr.ffmpegStdin = bufio.NewWriter(stdin)
-	mvr.isRecording = true
-	mvr.frameCount = 0
-	mvr.totalBytes = 0
-	mvr.startTime = time.Now()
-
-	utils.Logger.Infof("Started MP4 recording to: %s (%dx%d @ %d fps)", mvr.filePath, mvr.width, mvr.height, mvr.fps)
 	return nil
 }
 
@@ -134,23 +192,28 @@ func (mvr *MP4VideoRecorder) SaveFrame(frame VideoFrame) error {
 		return fmt.Errorf("not currently recording MP4")
 	}
 
-	if mvr.ffmpegStdin == nil {
-		return fmt.Errorf("FFmpeg stdin not available")
-	}
+	if mvr.writer != nil {
+		if err := mvr.writer.WriteFrame(frame); err != nil {
+			return fmt.Errorf("failed to mux frame: %w", err)
+		}
+	} else {
+		if mvr.ffmpegStdin == nil {
+			return fmt.Errorf("FFmpeg stdin not available")
+		}
 
-	// Write frame data to FFmpeg stdin
-	bytesWritten, err := mvr.ffmpegStdin.Write(frame.Data)
-	if err != nil {
-		return fmt.Errorf("failed to write frame to FFmpeg: %w", err)
-	}
+		// Write frame data to FFmpeg stdin
+		if _, err := mvr.ffmpegStdin.Write(frame.Data); err != nil {
+			return fmt.Errorf("failed to write frame to FFmpeg: %w", err)
+		}
 
-	// Flush the buffer to ensure data is sent to FFmpeg
-	if err := mvr.ffmpegStdin.Flush(); err != nil {
-		utils.Logger.Warnf("Failed to flush FFmpeg stdin: %v", err)
+		// Flush the buffer to ensure data is sent to FFmpeg
+		if err := mvr.ffmpegStdin.Flush(); err != nil {
+			utils.Logger.Warnf("Failed to flush FFmpeg stdin: %v", err)
+		}
 	}
 
 	mvr.frameCount++
-	mvr.totalBytes += int64(bytesWritten)
+	mvr.totalBytes += int64(len(frame.Data))
 
 	// Log progress every 100 frames
 	if mvr.frameCount%100 == 0 {
@@ -172,12 +235,29 @@ func (mvr *MP4VideoRecorder) StopRecording() error {
 		return fmt.Errorf("no MP4 recording is in progress")
 	}
 
+	// Write the last fragment
+	var closeErr error
+	if mvr.writer != nil {
+		if err := mvr.writer.Close(); err != nil {
+			closeErr = fmt.Errorf("failed to finalize MP4 file: %w", err)
+		}
+		if err := mvr.file.Close(); err != nil && closeErr == nil {
+			closeErr = fmt.Errorf("failed to close video file: %w", err)
+		}
+		mvr.writer = nil
+		mvr.file = nil
+	}
+
 	// Close stdin to signal EOF to FFmpeg
 	if mvr.ffmpegStdin != nil {
 		if err := mvr.ffmpegStdin.Flush(); err != nil {
 			utils.Logger.Warnf("Failed to flush FFmpeg stdin on stop: %v", err)
 		}
+		if err := mvr.ffmpegPipe.Close(); err != nil {
+			utils.Logger.Warnf("Failed to close FFmpeg stdin: %v", err)
+		}
 		mvr.ffmpegStdin = nil
+		mvr.ffmpegPipe = nil
 	}
 
 	// Wait for FFmpeg to finish
@@ -196,7 +276,7 @@ func (mvr *MP4VideoRecorder) StopRecording() error {
 	utils.Logger.Infof("Stopped MP4 recording. Frames: %d, Size: %.2f MB, Duration: %v",
 		mvr.frameCount, float64(mvr.totalBytes)/(1024*1024), duration)
 
-	return nil
+	return closeErr
 }
 
 // IsRecording returns whether the MP4 recorder is currently recording
@@ -217,6 +297,7 @@ func (mvr *MP4VideoRecorder) GetStats() map[string]interface{} {
 	stats["total_bytes"] = mvr.totalBytes
 	stats["file_path"] = mvr.filePath
 	stats["format"] = "mp4"
+	stats["muxer"] = mvr.muxer
 	stats["width"] = mvr.width
 	stats["height"] = mvr.height
 	stats["fps"] = mvr.fps
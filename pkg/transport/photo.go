@@ -0,0 +1,189 @@
+package transport
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"hash/crc32"
+	"image"
+	"image/jpeg"
+	"image/png"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/conceptcodes/dji-tello-sdk-go/pkg/types"
+)
+
+// Photo formats accepted by EncodePhoto
+const (
+	PhotoFormatJPEG = "jpeg"
+	PhotoFormatPNG  = "png"
+)
+
+// photoQuality is the JPEG quality photos are saved with
+const photoQuality = 90
+
+// PhotoMetadata describes when a photo was taken and the drone's state then
+type PhotoMetadata struct {
+	Taken time.Time
+	State *types.State // nil if no state had been received
+}
+
+// Description summarizes the drone's state, e.g.
+// "Height 120 cm, yaw 90 deg, battery 80%"
+func (m PhotoMetadata) Description() string {
+	if m.State == nil {
+		return "No drone state"
+	}
+	return fmt.Sprintf("Height %d cm, yaw %d deg, battery %d%%", m.State.H, m.State.Yaw, m.State.Bat)
+}
+
+// DecodeKeyFrame decodes a keyframe on its own, without the frames before it
+func DecodeKeyFrame(frame VideoFrame) (*image.YCbCr, error) {
+	if !frame.IsKeyFrame {
+		return nil, fmt.Errorf("frame %d is not a keyframe", frame.SeqNum)
+	}
+
+	decoder := NewH264Decoder()
+	defer decoder.Close()
+	img, err := decoder.Decode(frame)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode keyframe %d: %w", frame.SeqNum, err)
+	}
+	if img == nil {
+		return nil, fmt.Errorf("keyframe %d holds no picture", frame.SeqNum)
+	}
+	return img, nil
+}
+
+// PhotoFormatFromPath returns the photo format for a file name's extension
+func PhotoFormatFromPath(path string) (string, error) {
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".jpg", ".jpeg":
+		return PhotoFormatJPEG, nil
+	case ".png":
+		return PhotoFormatPNG, nil
+	default:
+		return "", fmt.Errorf("unsupported photo format '%s' (expected .jpg, .jpeg or .png)", filepath.Ext(path))
+	}
+}
+
+// SavePhoto writes img to path in the format given by its extension, with
+// the metadata embedded
+func SavePhoto(path string, img image.Image, meta PhotoMetadata) error {
+	format, err := PhotoFormatFromPath(path)
+	if err != nil {
+		return err
+	}
+
+	var buf bytes.Buffer
+	if err := EncodePhoto(&buf, img, format, meta); err != nil {
+		return err
+	}
+	if err := os.WriteFile(path, buf.Bytes(), 0o644); err != nil {
+		return fmt.Errorf("failed to write photo '%s': %w", path, err)
+	}
+	return nil
+}
+
+// EncodePhoto writes img as a JPEG or PNG. A JPEG carries the metadata as
+// EXIF tags (ImageDescription, Make, Model and DateTime) and a PNG as text
+// chunks (Description, Source and Creation Time).
+func EncodePhoto(w io.Writer, img image.Image, format string, meta PhotoMetadata) error {
+	var buf bytes.Buffer
+	var metadata []byte
+	var insertAt int
+
+	switch format {
+	case PhotoFormatJPEG:
+		if err := jpeg.Encode(&buf, img, &jpeg.Options{Quality: photoQuality}); err != nil {
+			return fmt.Errorf("failed to encode photo: %w", err)
+		}
+		taken := meta.Taken.Format("2006:01:02 15:04:05")
+		metadata = exifSegment([]exifTag{
+			{0x010E, meta.Description()}, // ImageDescription
+			{0x010F, "DJI"},              // Make
+			{0x0110, "Tello"},            // Model
+			{0x0132, taken},              // DateTime
+		})
+		insertAt = 2 // After SOI
+	case PhotoFormatPNG:
+		if err := png.Encode(&buf, img); err != nil {
+			return fmt.Errorf("failed to encode photo: %w", err)
+		}
+		metadata = append(metadata, pngTextChunk("Description", meta.Description())...)
+		metadata = append(metadata, pngTextChunk("Source", "DJI Tello")...)
+		metadata = append(metadata, pngTextChunk("Creation Time", meta.Taken.Format(time.RFC1123Z))...)
+		insertAt = 8 + 25 // After the signature and IHDR
+	default:
+		return fmt.Errorf("unsupported photo format '%s'", format)
+	}
+
+	encoded := buf.Bytes()
+	for _, part := range [][]byte{encoded[:insertAt], metadata, encoded[insertAt:]} {
+		if _, err := w.Write(part); err != nil {
+			return fmt.Errorf("failed to write photo: %w", err)
+		}
+	}
+	return nil
+}
+
+// exifTag is an ASCII tag of EXIF IFD0
+type exifTag struct {
+	id    uint16
+	value string
+}
+
+// exifSegment builds a JPEG APP1 segment holding tags, which must be sorted
+// by id, in a big-endian TIFF structure
+func exifSegment(tags []exifTag) []byte {
+	const headerSize = 8 // Byte order, magic number and IFD0 offset
+	dataOffset := headerSize + 2 + 12*len(tags) + 4
+
+	var ifd, data bytes.Buffer
+	binary.Write(&ifd, binary.BigEndian, uint16(len(tags)))
+	for _, tag := range tags {
+		value := append([]byte(tag.value), 0)
+		binary.Write(&ifd, binary.BigEndian, tag.id)
+		binary.Write(&ifd, binary.BigEndian, uint16(2)) // ASCII
+		binary.Write(&ifd, binary.BigEndian, uint32(len(value)))
+		if len(value) <= 4 {
+			var inline [4]byte
+			copy(inline[:], value)
+			ifd.Write(inline[:])
+			continue
+		}
+		binary.Write(&ifd, binary.BigEndian, uint32(dataOffset+data.Len()))
+		data.Write(value)
+		if data.Len()%2 == 1 {
+			data.WriteByte(0) // Values start on word boundaries
+		}
+	}
+	binary.Write(&ifd, binary.BigEndian, uint32(0)) // No IFD1
+
+	var tiff bytes.Buffer
+	tiff.WriteString("MM\x00\x2A")
+	binary.Write(&tiff, binary.BigEndian, uint32(headerSize))
+	tiff.Write(ifd.Bytes())
+	tiff.Write(data.Bytes())
+
+	var segment bytes.Buffer
+	segment.Write([]byte{0xFF, 0xE1})
+	binary.Write(&segment, binary.BigEndian, uint16(2+6+tiff.Len()))
+	segment.WriteString("Exif\x00\x00")
+	segment.Write(tiff.Bytes())
+	return segment.Bytes()
+}
+
+// pngTextChunk builds a tEXt chunk
+func pngTextChunk(keyword, text string) []byte {
+	payload := append([]byte("tEXt"+keyword+"\x00"), text...)
+
+	chunk := make([]byte, 4, 4+len(payload)+4)
+	binary.BigEndian.PutUint32(chunk, uint32(len(payload)-4))
+	chunk = append(chunk, payload...)
+	return binary.BigEndian.AppendUint32(chunk, crc32.ChecksumIEEE(payload))
+}
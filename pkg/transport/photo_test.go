@@ -0,0 +1,113 @@
+package transport
+
+import (
+	"bytes"
+	"image"
+	"image/jpeg"
+	"image/png"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/conceptcodes/dji-tello-sdk-go/pkg/types"
+)
+
+func testPhoto(t *testing.T) *image.YCbCr {
+	t.Helper()
+	ts := testCodedStream{widthMbs: 2, heightMbs: 2, qp: 26}
+	y, cb, cr := pcmPicture(32, 32)
+	img, err := DecodeKeyFrame(testAccessUnit(ts.sps(), ts.pps(), ts.pcmSlice(y, cb, cr)))
+	if err != nil {
+		t.Fatalf("DecodeKeyFrame failed: %v", err)
+	}
+	return img
+}
+
+func TestDecodeKeyFrame(t *testing.T) {
+	if img := testPhoto(t); img.Bounds() != image.Rect(0, 0, 32, 32) {
+		t.Errorf("Expected a 32x32 picture, got %v", img.Bounds())
+	}
+
+	if _, err := DecodeKeyFrame(VideoFrame{SeqNum: 7}); err == nil {
+		t.Error("Expected an error for a frame that is not a keyframe")
+	}
+}
+
+func TestEncodePhotoJPEG(t *testing.T) {
+	meta := PhotoMetadata{
+		Taken: time.Date(2024, 5, 1, 14, 30, 0, 0, time.UTC),
+		State: &types.State{H: 120, Yaw: -45, Bat: 80},
+	}
+
+	var buf bytes.Buffer
+	if err := EncodePhoto(&buf, testPhoto(t), PhotoFormatJPEG, meta); err != nil {
+		t.Fatalf("EncodePhoto failed: %v", err)
+	}
+
+	data := buf.Bytes()
+	if !bytes.HasPrefix(data, []byte{0xFF, 0xD8, 0xFF, 0xE1}) || !bytes.Contains(data[:64], []byte("Exif\x00\x00MM")) {
+		t.Fatalf("Expected an EXIF segment after SOI, got % x", data[:16])
+	}
+	for _, value := range []string{"Height 120 cm, yaw -45 deg, battery 80%\x00", "DJI\x00", "Tello\x00", "2024:05:01 14:30:00\x00"} {
+		if !bytes.Contains(data, []byte(value)) {
+			t.Errorf("Expected the EXIF tags to hold %q", value)
+		}
+	}
+
+	img, err := jpeg.Decode(bytes.NewReader(data))
+	if err != nil {
+		t.Fatalf("Expected a valid JPEG, got %v", err)
+	}
+	if img.Bounds() != image.Rect(0, 0, 32, 32) {
+		t.Errorf("Expected a 32x32 JPEG, got %v", img.Bounds())
+	}
+}
+
+func TestEncodePhotoPNG(t *testing.T) {
+	var buf bytes.Buffer
+	if err := EncodePhoto(&buf, testPhoto(t), PhotoFormatPNG, PhotoMetadata{Taken: time.Now()}); err != nil {
+		t.Fatalf("EncodePhoto failed: %v", err)
+	}
+
+	data := buf.Bytes()
+	for _, chunk := range []string{"tEXtDescription\x00No drone state", "tEXtSource\x00DJI Tello", "tEXtCreation Time\x00"} {
+		if !bytes.Contains(data, []byte(chunk)) {
+			t.Errorf("Expected a %q chunk", chunk)
+		}
+	}
+
+	// The decoder checks every chunk's CRC
+	if _, err := png.Decode(bytes.NewReader(data)); err != nil {
+		t.Fatalf("Expected a valid PNG, got %v", err)
+	}
+}
+
+func TestSavePhoto(t *testing.T) {
+	dir := t.TempDir()
+	img := testPhoto(t)
+
+	for _, name := range []string{"photo.jpg", "photo.JPEG", "photo.png"} {
+		path := filepath.Join(dir, name)
+		if err := SavePhoto(path, img, PhotoMetadata{Taken: time.Now()}); err != nil {
+			t.Errorf("SavePhoto(%s) failed: %v", name, err)
+			continue
+		}
+		file, err := os.Open(path)
+		if err != nil {
+			t.Fatalf("Failed to open %s: %v", name, err)
+		}
+		_, format, err := image.Decode(file)
+		file.Close()
+		if err != nil {
+			t.Errorf("Expected %s to decode, got %v", name, err)
+		}
+		if expected, _ := PhotoFormatFromPath(name); format != expected {
+			t.Errorf("Expected %s to be a %s, got %s", name, expected, format)
+		}
+	}
+
+	if err := SavePhoto(filepath.Join(dir, "photo.bmp"), img, PhotoMetadata{}); err == nil {
+		t.Error("Expected an error for an unsupported extension")
+	}
+}
@@ -0,0 +1,740 @@
+package transport
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"net"
+	"net/textproto"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/conceptcodes/dji-tello-sdk-go/pkg/utils"
+)
+
+// DefaultRTSPAddr is the address the RTSP server listens on unless told
+// otherwise, on the standard RTSP alternate port
+const DefaultRTSPAddr = ":8554"
+
+// RTSPStreamPath is the path of the stream in the URLs printed for viewers.
+// The server serves the same stream on any path.
+const RTSPStreamPath = "/tello"
+
+const (
+	rtpPayloadType   = 96   // First dynamic payload type, mapped to H.264 in the SDP
+	rtpMaxPayload    = 1400 // Keeps packets within an Ethernet MTU
+	rtspViewerBuffer = 60   // Frames queued per viewer before it falls behind
+	rtspParamsWait   = 5 * time.Second
+	rtspTimeout      = 60 // Seconds a session lives without requests, as advertised
+)
+
+// RTSPServer re-streams the drone's video to RTSP clients such as VLC,
+// GStreamer, FFmpeg or OpenCV. The reassembled H.264 access units are sent
+// as RTP (RFC 6184) over UDP or interleaved in the RTSP connection, with
+// large NAL units split into FU-A fragments. Every viewer gets its own RTP
+// session that starts at a keyframe; a viewer that falls behind loses frames
+// up to the next keyframe without holding up the others.
+type RTSPServer struct {
+	addr   string
+	frames <-chan VideoFrame
+	parser *H264Parser
+
+	listener net.Listener
+	rtpConn  *net.UDPConn
+	rtcpConn *net.UDPConn
+
+	mu       sync.Mutex
+	sessions map[string]*rtspSession
+	conns    map[*rtspConn]struct{}
+	sps, pps []byte
+	params   chan struct{} // Closed once the SPS and PPS are known
+	running  bool
+	done     chan struct{}
+	wg       sync.WaitGroup
+}
+
+// rtspConn is an RTSP client connection, whose writes are shared between
+// responses and interleaved RTP packets
+type rtspConn struct {
+	net.Conn
+	writeMu sync.Mutex
+}
+
+// rtspSession is one viewer's RTP session
+type rtspSession struct {
+	id      string
+	conn    *rtspConn
+	channel byte         // Interleaved RTP channel, for RTP over TCP
+	udpAddr *net.UDPAddr // Client RTP address, nil for RTP over TCP
+	frames  chan VideoFrame
+	playing bool
+	lost    atomic.Bool // Frames were dropped since the sender last looked
+
+	ssrc   uint32
+	seq    uint16
+	tsBase uint32
+	start  time.Time // Timestamp of the first frame sent, RTP time tsBase
+	synced bool      // A keyframe was sent since frames were last lost
+}
+
+// NewRTSPServer creates a server for the frames received on frames, which it
+// is the only reader of
+func NewRTSPServer(addr string, frames <-chan VideoFrame) *RTSPServer {
+	if addr == "" {
+		addr = DefaultRTSPAddr
+	}
+	return &RTSPServer{
+		addr:     addr,
+		frames:   frames,
+		parser:   NewH264Parser(),
+		sessions: make(map[string]*rtspSession),
+		conns:    make(map[*rtspConn]struct{}),
+		params:   make(chan struct{}),
+	}
+}
+
+// Start listens for RTSP clients and starts passing frames on to them. It
+// returns once the server is listening.
+func (s *RTSPServer) Start() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.running {
+		return fmt.Errorf("RTSP server is already running")
+	}
+
+	listener, err := net.Listen("tcp", s.addr)
+	if err != nil {
+		return fmt.Errorf("failed to listen on %s: %w", s.addr, err)
+	}
+	host, _, _ := net.SplitHostPort(listener.Addr().String())
+	rtpConn, rtcpConn, err := listenUDPPair(host)
+	if err != nil {
+		listener.Close()
+		return err
+	}
+
+	s.listener = listener
+	s.rtpConn = rtpConn
+	s.rtcpConn = rtcpConn
+	s.running = true
+	s.done = make(chan struct{})
+	s.wg.Add(2)
+	go s.acceptLoop()
+	go s.distribute()
+
+	utils.Logger.Infof("RTSP server listening on %s", listener.Addr())
+	return nil
+}
+
+// Stop disconnects every viewer and stops listening
+func (s *RTSPServer) Stop() error {
+	s.mu.Lock()
+	if !s.running {
+		s.mu.Unlock()
+		return nil
+	}
+	s.running = false
+	close(s.done)
+	err := s.listener.Close()
+	s.rtpConn.Close()
+	s.rtcpConn.Close()
+	for conn := range s.conns {
+		conn.Close()
+	}
+	for id, session := range s.sessions {
+		close(session.frames)
+		delete(s.sessions, id)
+	}
+	s.mu.Unlock()
+
+	s.wg.Wait()
+	utils.Logger.Info("RTSP server stopped")
+	return err
+}
+
+// Close stops the server
+func (s *RTSPServer) Close() error {
+	return s.Stop()
+}
+
+// Addr returns the address the server listens on, nil before Start
+func (s *RTSPServer) Addr() net.Addr {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.listener == nil {
+		return nil
+	}
+	return s.listener.Addr()
+}
+
+// Viewers returns the number of sessions playing the stream
+func (s *RTSPServer) Viewers() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	viewers := 0
+	for _, session := range s.sessions {
+		if session.playing {
+			viewers++
+		}
+	}
+	return viewers
+}
+
+// listenUDPPair binds the RTP port and the RTCP port above it
+func listenUDPPair(host string) (*net.UDPConn, *net.UDPConn, error) {
+	ip := net.ParseIP(host)
+	for attempt := 0; attempt < 10; attempt++ {
+		rtpConn, err := net.ListenUDP("udp", &net.UDPAddr{IP: ip})
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to listen for RTP: %w", err)
+		}
+		port := rtpConn.LocalAddr().(*net.UDPAddr).Port
+		rtcpConn, err := net.ListenUDP("udp", &net.UDPAddr{IP: ip, Port: port + 1})
+		if err == nil {
+			return rtpConn, rtcpConn, nil
+		}
+		rtpConn.Close()
+	}
+	return nil, nil, fmt.Errorf("failed to find a free RTP/RTCP port pair")
+}
+
+func (s *RTSPServer) acceptLoop() {
+	defer s.wg.Done()
+	for {
+		netConn, err := s.listener.Accept()
+		if err != nil {
+			select {
+			case <-s.done:
+			default:
+				utils.Logger.Errorf("RTSP accept failed: %v", err)
+			}
+			return
+		}
+
+		conn := &rtspConn{Conn: netConn}
+		s.mu.Lock()
+		if !s.running {
+			s.mu.Unlock()
+			netConn.Close()
+			return
+		}
+		s.conns[conn] = struct{}{}
+		s.wg.Add(1)
+		s.mu.Unlock()
+		go s.serveConn(conn)
+	}
+}
+
+// distribute records the parameter sets and queues every frame for the
+// playing viewers
+func (s *RTSPServer) distribute() {
+	defer s.wg.Done()
+	for {
+		var frame VideoFrame
+		var ok bool
+		select {
+		case frame, ok = <-s.frames:
+			if !ok {
+				return
+			}
+		case <-s.done:
+			return
+		}
+
+		if frame.IsKeyFrame {
+			s.updateParams(frame)
+		}
+
+		s.mu.Lock()
+		for _, session := range s.sessions {
+			if !session.playing {
+				continue
+			}
+			select {
+			case session.frames <- frame:
+			default:
+				session.lost.Store(true)
+			}
+		}
+		s.mu.Unlock()
+	}
+}
+
+// updateParams keeps the SPS and PPS of the latest keyframe for the SDP
+func (s *RTSPServer) updateParams(frame VideoFrame) {
+	nalUnits, err := frameNALUnits(s.parser, frame)
+	if err != nil {
+		return
+	}
+	sps, pps := s.parser.ExtractSPS(nalUnits), s.parser.ExtractPPS(nalUnits)
+	if len(sps) < 4 || pps == nil {
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.sps == nil {
+		close(s.params)
+	}
+	s.sps = bytes.TrimRight(sps, "\x00")
+	s.pps = bytes.TrimRight(pps, "\x00")
+}
+
+// serveConn answers the requests of one client until it disconnects, then
+// ends the sessions it set up
+func (s *RTSPServer) serveConn(conn *rtspConn) {
+	defer s.wg.Done()
+	defer func() {
+		conn.Close()
+		s.mu.Lock()
+		delete(s.conns, conn)
+		for id, session := range s.sessions {
+			if session.conn == conn {
+				close(session.frames)
+				delete(s.sessions, id)
+			}
+		}
+		s.mu.Unlock()
+	}()
+
+	reader := bufio.NewReader(conn)
+	for {
+		// Clients over TCP send their RTCP reports interleaved, which are
+		// not needed
+		if prefix, err := reader.Peek(1); err == nil && prefix[0] == '$' {
+			header := make([]byte, 4)
+			if _, err := io.ReadFull(reader, header); err != nil {
+				return
+			}
+			if _, err := reader.Discard(int(binary.BigEndian.Uint16(header[2:]))); err != nil {
+				return
+			}
+			continue
+		}
+
+		req, err := readRTSPRequest(reader)
+		if err != nil {
+			if err != io.EOF {
+				utils.Logger.Debugf("RTSP client %s: %v", conn.RemoteAddr(), err)
+			}
+			return
+		}
+		if !s.handleRequest(conn, req) {
+			return
+		}
+	}
+}
+
+// rtspRequest is a parsed RTSP request
+type rtspRequest struct {
+	method string
+	url    string
+	header textproto.MIMEHeader
+}
+
+func readRTSPRequest(reader *bufio.Reader) (*rtspRequest, error) {
+	tp := textproto.NewReader(reader)
+	line, err := tp.ReadLine()
+	if err != nil {
+		return nil, err
+	}
+	parts := strings.Fields(line)
+	if len(parts) != 3 || !strings.HasPrefix(parts[2], "RTSP/") {
+		return nil, fmt.Errorf("malformed request line %q", line)
+	}
+	header, err := tp.ReadMIMEHeader()
+	if err != nil {
+		return nil, fmt.Errorf("malformed headers: %w", err)
+	}
+	// Bodies, e.g. of SET_PARAMETER, carry nothing the server needs
+	if length, _ := strconv.Atoi(header.Get("Content-Length")); length > 0 {
+		if _, err := reader.Discard(length); err != nil {
+			return nil, err
+		}
+	}
+	return &rtspRequest{method: parts[0], url: parts[1], header: header}, nil
+}
+
+// handleRequest answers req, returning false once the connection should close
+func (s *RTSPServer) handleRequest(conn *rtspConn, req *rtspRequest) bool {
+	cseq := req.header.Get("CSeq")
+	switch req.method {
+	case "OPTIONS":
+		return conn.respond(cseq, 200, []string{"Public: OPTIONS, DESCRIBE, SETUP, PLAY, TEARDOWN, GET_PARAMETER, SET_PARAMETER"}, "")
+	case "DESCRIBE":
+		sdp, err := s.describe(conn)
+		if err != nil {
+			utils.Logger.Warnf("RTSP DESCRIBE failed: %v", err)
+			return conn.respond(cseq, 503, nil, "")
+		}
+		base := req.url
+		if !strings.HasSuffix(base, "/") {
+			base += "/"
+		}
+		return conn.respond(cseq, 200, []string{"Content-Base: " + base, "Content-Type: application/sdp"}, sdp)
+	case "SETUP":
+		return s.setup(conn, req)
+	case "PLAY":
+		session := s.session(conn, req)
+		if session == nil {
+			return conn.respond(cseq, 454, nil, "")
+		}
+		s.play(session)
+		return conn.respond(cseq, 200, []string{sessionHeader(session), "Range: npt=0.000-"}, "")
+	case "TEARDOWN":
+		session := s.session(conn, req)
+		if session == nil {
+			return conn.respond(cseq, 454, nil, "")
+		}
+		s.mu.Lock()
+		if _, ok := s.sessions[session.id]; ok {
+			close(session.frames)
+			delete(s.sessions, session.id)
+		}
+		s.mu.Unlock()
+		utils.Logger.Infof("RTSP viewer %s left", conn.RemoteAddr())
+		return conn.respond(cseq, 200, nil, "")
+	case "GET_PARAMETER", "SET_PARAMETER":
+		// Keepalives
+		return conn.respond(cseq, 200, nil, "")
+	default:
+		return conn.respond(cseq, 501, nil, "")
+	}
+}
+
+// describe builds the SDP of the stream. It waits a little for the first
+// keyframe so that the SDP can carry the SPS and PPS, which clients such as
+// OpenCV need to set up their decoder.
+func (s *RTSPServer) describe(conn *rtspConn) (string, error) {
+	select {
+	case <-s.params:
+	case <-time.After(rtspParamsWait):
+		return "", fmt.Errorf("no keyframe received within %v", rtspParamsWait)
+	case <-s.done:
+		return "", fmt.Errorf("server stopped")
+	}
+
+	s.mu.Lock()
+	sps, pps := s.sps, s.pps
+	s.mu.Unlock()
+
+	host, _, _ := net.SplitHostPort(conn.LocalAddr().String())
+	family := "IP4"
+	if ip := net.ParseIP(host); ip != nil && ip.To4() == nil {
+		family = "IP6"
+	}
+	lines := []string{
+		"v=0",
+		fmt.Sprintf("o=- %d 1 IN %s %s", time.Now().Unix(), family, host),
+		"s=DJI Tello",
+		"c=IN " + family + " " + host,
+		"t=0 0",
+		fmt.Sprintf("m=video 0 RTP/AVP %d", rtpPayloadType),
+		fmt.Sprintf("a=rtpmap:%d H264/%d", rtpPayloadType, mp4Timescale),
+		fmt.Sprintf("a=fmtp:%d packetization-mode=1;profile-level-id=%s;sprop-parameter-sets=%s,%s",
+			rtpPayloadType, hex.EncodeToString(sps[1:4]),
+			base64.StdEncoding.EncodeToString(sps), base64.StdEncoding.EncodeToString(pps)),
+		"a=control:trackID=0",
+	}
+	return strings.Join(lines, "\r\n") + "\r\n", nil
+}
+
+// setup creates a session for the transport the client asks for, RTP over
+// UDP or interleaved in the RTSP connection
+func (s *RTSPServer) setup(conn *rtspConn, req *rtspRequest) bool {
+	cseq := req.header.Get("CSeq")
+	if req.header.Get("Session") != "" {
+		// The stream has a single track, so there is nothing more to set up
+		return conn.respond(cseq, 459, nil, "")
+	}
+
+	session := &rtspSession{
+		id:     randomHex(8),
+		conn:   conn,
+		frames: make(chan VideoFrame, rtspViewerBuffer),
+		ssrc:   binary.BigEndian.Uint32(randomBytes(4)),
+		seq:    binary.BigEndian.Uint16(randomBytes(2)),
+		tsBase: binary.BigEndian.Uint32(randomBytes(4)),
+	}
+
+	var transport string
+	for _, option := range strings.Split(req.header.Get("Transport"), ",") {
+		option = strings.TrimSpace(option)
+		params := transportParams(option)
+		switch {
+		case strings.HasPrefix(option, "RTP/AVP/TCP"):
+			channels := params["interleaved"]
+			if channels == "" {
+				channels = "0-1"
+			}
+			first, _, _ := strings.Cut(channels, "-")
+			channel, err := strconv.Atoi(first)
+			if err != nil || channel < 0 || channel > 254 {
+				continue
+			}
+			session.channel = byte(channel)
+			transport = fmt.Sprintf("RTP/AVP/TCP;unicast;interleaved=%d-%d", channel, channel+1)
+		case strings.HasPrefix(option, "RTP/AVP") && params["client_port"] != "":
+			first, _, _ := strings.Cut(params["client_port"], "-")
+			port, err := strconv.Atoi(first)
+			if err != nil || port <= 0 || port > 65535 {
+				continue
+			}
+			host, _, _ := net.SplitHostPort(conn.RemoteAddr().String())
+			session.udpAddr = &net.UDPAddr{IP: net.ParseIP(host), Port: port}
+			serverPort := s.rtpConn.LocalAddr().(*net.UDPAddr).Port
+			transport = fmt.Sprintf("RTP/AVP;unicast;client_port=%d-%d;server_port=%d-%d;ssrc=%08X",
+				port, port+1, serverPort, serverPort+1, session.ssrc)
+		default:
+			continue
+		}
+		break
+	}
+	if transport == "" {
+		return conn.respond(cseq, 461, nil, "")
+	}
+
+	s.mu.Lock()
+	if !s.running {
+		s.mu.Unlock()
+		return conn.respond(cseq, 503, nil, "")
+	}
+	s.sessions[session.id] = session
+	s.mu.Unlock()
+	return conn.respond(cseq, 200, []string{"Transport: " + transport, sessionHeader(session)}, "")
+}
+
+// session returns the session named by req's Session header, if it was set
+// up on conn. Another connection cannot play or tear down a session even if
+// it learns its ID.
+func (s *RTSPServer) session(conn *rtspConn, req *rtspRequest) *rtspSession {
+	id, _, _ := strings.Cut(req.header.Get("Session"), ";")
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	session := s.sessions[strings.TrimSpace(id)]
+	if session == nil || session.conn != conn {
+		return nil
+	}
+	return session
+}
+
+// play starts sending frames to the session, once
+func (s *RTSPServer) play(session *rtspSession) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if session.playing {
+		return
+	}
+	if _, ok := s.sessions[session.id]; !ok {
+		return
+	}
+	session.playing = true
+	s.wg.Add(1)
+	go s.send(session)
+	utils.Logger.Infof("RTSP viewer %s started playing", session.conn.RemoteAddr())
+}
+
+// send packetizes the session's frames until it ends
+func (s *RTSPServer) send(session *rtspSession) {
+	defer s.wg.Done()
+	parser := NewH264Parser()
+	for frame := range session.frames {
+		if session.lost.Swap(false) {
+			session.synced = false
+		}
+		if !session.synced && !frame.IsKeyFrame {
+			continue
+		}
+		if err := s.sendFrame(session, parser, frame); err != nil {
+			utils.Logger.Debugf("RTSP viewer %s: %v", session.conn.RemoteAddr(), err)
+			if session.udpAddr == nil {
+				// The connection is gone; serveConn ends the session
+				session.conn.Close()
+			}
+			continue
+		}
+		session.synced = true
+	}
+}
+
+func (s *RTSPServer) sendFrame(session *rtspSession, parser *H264Parser, frame VideoFrame) error {
+	nalUnits, err := frameNALUnits(parser, frame)
+	if err != nil {
+		return err
+	}
+	var nals [][]byte
+	for _, nal := range nalUnits {
+		if nal.Type == NALUTypeAUD {
+			continue
+		}
+		nals = append(nals, bytes.TrimRight(nal.Data, "\x00"))
+	}
+	payloads := rtpPayloads(nals, rtpMaxPayload)
+	if len(payloads) == 0 {
+		return nil
+	}
+
+	timestamp := frame.Timestamp
+	if timestamp.IsZero() {
+		timestamp = time.Now()
+	}
+	if session.start.IsZero() {
+		session.start = timestamp
+	}
+	elapsed := timestamp.Sub(session.start)
+	rtpTime := session.tsBase + uint32(int64(elapsed)*mp4Timescale/int64(time.Second))
+
+	for i, payload := range payloads {
+		packet := make([]byte, 12, 12+len(payload))
+		packet[0] = 0x80 // Version 2
+		packet[1] = rtpPayloadType
+		if i == len(payloads)-1 {
+			packet[1] |= 0x80 // Marker: last packet of the access unit
+		}
+		binary.BigEndian.PutUint16(packet[2:], session.seq)
+		binary.BigEndian.PutUint32(packet[4:], rtpTime)
+		binary.BigEndian.PutUint32(packet[8:], session.ssrc)
+		packet = append(packet, payload...)
+		session.seq++
+
+		if session.udpAddr != nil {
+			if _, err := s.rtpConn.WriteToUDP(packet, session.udpAddr); err != nil {
+				return fmt.Errorf("failed to send RTP packet: %w", err)
+			}
+			continue
+		}
+		if err := session.conn.writeInterleaved(session.channel, packet); err != nil {
+			return fmt.Errorf("failed to send RTP packet: %w", err)
+		}
+	}
+	return nil
+}
+
+// rtpPayloads packetizes an access unit's NAL units (RFC 6184): units that
+// fit are sent whole, larger ones as FU-A fragments
+func rtpPayloads(nals [][]byte, maxSize int) [][]byte {
+	var payloads [][]byte
+	for _, nal := range nals {
+		if len(nal) == 0 {
+			continue
+		}
+		if len(nal) <= maxSize {
+			payloads = append(payloads, nal)
+			continue
+		}
+
+		indicator := nal[0]&0xE0 | 28 // FU-A with the unit's F and NRI bits
+		data := nal[1:]
+		for start := 0; start < len(data); start += maxSize - 2 {
+			end := min(start+maxSize-2, len(data))
+			header := nal[0] & 0x1F
+			if start == 0 {
+				header |= 0x80
+			}
+			if end == len(data) {
+				header |= 0x40
+			}
+			payloads = append(payloads, append([]byte{indicator, header}, data[start:end]...))
+		}
+	}
+	return payloads
+}
+
+// frameNALUnits returns the NAL units of an access unit, parsing them if the
+// frame does not carry them
+func frameNALUnits(parser *H264Parser, frame VideoFrame) ([]NALUnit, error) {
+	if len(frame.NALUnits) > 0 {
+		return frame.NALUnits, nil
+	}
+	return parser.ParseFrame(frame.Data)
+}
+
+func (c *rtspConn) respond(cseq string, status int, headers []string, body string) bool {
+	var b strings.Builder
+	fmt.Fprintf(&b, "RTSP/1.0 %d %s\r\n", status, rtspStatusText(status))
+	if cseq != "" {
+		fmt.Fprintf(&b, "CSeq: %s\r\n", cseq)
+	}
+	b.WriteString("Server: dji-tello-sdk-go\r\n")
+	for _, header := range headers {
+		b.WriteString(header + "\r\n")
+	}
+	if body != "" {
+		fmt.Fprintf(&b, "Content-Length: %d\r\n", len(body))
+	}
+	b.WriteString("\r\n" + body)
+
+	c.writeMu.Lock()
+	defer c.writeMu.Unlock()
+	if _, err := io.WriteString(c.Conn, b.String()); err != nil {
+		utils.Logger.Debugf("RTSP client %s: failed to respond: %v", c.RemoteAddr(), err)
+		return false
+	}
+	return true
+}
+
+// writeInterleaved sends an RTP packet in the RTSP connection (RFC 2326 10.12)
+func (c *rtspConn) writeInterleaved(channel byte, packet []byte) error {
+	frame := make([]byte, 4, 4+len(packet))
+	frame[0] = '$'
+	frame[1] = channel
+	binary.BigEndian.PutUint16(frame[2:], uint16(len(packet)))
+	frame = append(frame, packet...)
+
+	c.writeMu.Lock()
+	defer c.writeMu.Unlock()
+	_, err := c.Write(frame)
+	return err
+}
+
+func rtspStatusText(status int) string {
+	switch status {
+	case 200:
+		return "OK"
+	case 454:
+		return "Session Not Found"
+	case 459:
+		return "Aggregate Operation Not Allowed"
+	case 461:
+		return "Unsupported Transport"
+	case 501:
+		return "Not Implemented"
+	case 503:
+		return "Service Unavailable"
+	default:
+		return "Bad Request"
+	}
+}
+
+func sessionHeader(session *rtspSession) string {
+	return fmt.Sprintf("Session: %s;timeout=%d", session.id, rtspTimeout)
+}
+
+// transportParams parses the parameters of a Transport header option, e.g.
+// "client_port=5000-5001"
+func transportParams(option string) map[string]string {
+	params := make(map[string]string)
+	for _, param := range strings.Split(option, ";") {
+		key, value, _ := strings.Cut(strings.TrimSpace(param), "=")
+		params[key] = value
+	}
+	return params
+}
+
+func randomBytes(n int) []byte {
+	b := make([]byte, n)
+	rand.Read(b)
+	return b
+}
+
+func randomHex(n int) string {
+	return hex.EncodeToString(randomBytes(n))
+}
@@ -0,0 +1,271 @@
+package transport
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/base64"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"net"
+	"net/textproto"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestRTPPayloads(t *testing.T) {
+	small := []byte{0x67, 1, 2, 3}
+	large := append([]byte{0x65}, bytes.Repeat([]byte{0xAB}, 24)...)
+	payloads := rtpPayloads([][]byte{small, large}, 10)
+
+	if len(payloads) != 4 || !bytes.Equal(payloads[0], small) {
+		t.Fatalf("Expected the small unit whole and the large one in 3 fragments, got %d payloads", len(payloads))
+	}
+	var reassembled []byte
+	for i, payload := range payloads[1:] {
+		if payload[0] != 0x60|28 {
+			t.Errorf("Fragment %d: expected an FU-A indicator with NRI 3, got %#x", i, payload[0])
+		}
+		start, end := payload[1]&0x80 != 0, payload[1]&0x40 != 0
+		if start != (i == 0) || end != (i == 2) || payload[1]&0x1F != NALUTypeIDR {
+			t.Errorf("Fragment %d: unexpected FU header %#x", i, payload[1])
+		}
+		if len(payload) > 10 {
+			t.Errorf("Fragment %d: %d bytes exceed the limit", i, len(payload))
+		}
+		reassembled = append(reassembled, payload[2:]...)
+	}
+	if !bytes.Equal(reassembled, large[1:]) {
+		t.Error("Expected the fragments to hold the unit's payload")
+	}
+}
+
+// rtspTestClient speaks just enough RTSP to play a stream
+type rtspTestClient struct {
+	t      *testing.T
+	conn   net.Conn
+	reader *bufio.Reader
+	cseq   int
+}
+
+func dialRTSP(t *testing.T, addr net.Addr) *rtspTestClient {
+	t.Helper()
+	conn, err := net.Dial("tcp", addr.String())
+	if err != nil {
+		t.Fatalf("Failed to connect: %v", err)
+	}
+	t.Cleanup(func() { conn.Close() })
+	return &rtspTestClient{t: t, conn: conn, reader: bufio.NewReader(conn)}
+}
+
+func (c *rtspTestClient) request(method string, headers ...string) (int, textproto.MIMEHeader, string) {
+	c.t.Helper()
+	c.cseq++
+	req := fmt.Sprintf("%s rtsp://%s/tello RTSP/1.0\r\nCSeq: %d\r\n", method, c.conn.RemoteAddr(), c.cseq)
+	for _, header := range headers {
+		req += header + "\r\n"
+	}
+	if _, err := io.WriteString(c.conn, req+"\r\n"); err != nil {
+		c.t.Fatalf("%s failed: %v", method, err)
+	}
+
+	c.conn.SetReadDeadline(time.Now().Add(10 * time.Second))
+	tp := textproto.NewReader(c.reader)
+	status, err := tp.ReadLine()
+	if err != nil {
+		c.t.Fatalf("%s: failed to read response: %v", method, err)
+	}
+	header, err := tp.ReadMIMEHeader()
+	if err != nil {
+		c.t.Fatalf("%s: failed to read headers: %v", method, err)
+	}
+	if header.Get("CSeq") != strconv.Itoa(c.cseq) {
+		c.t.Errorf("%s: expected CSeq %d, got %q", method, c.cseq, header.Get("CSeq"))
+	}
+	var body []byte
+	if length, _ := strconv.Atoi(header.Get("Content-Length")); length > 0 {
+		body = make([]byte, length)
+		if _, err := io.ReadFull(c.reader, body); err != nil {
+			c.t.Fatalf("%s: failed to read body: %v", method, err)
+		}
+	}
+	code, _ := strconv.Atoi(strings.Fields(status)[1])
+	return code, header, string(body)
+}
+
+// readInterleaved returns the next RTP packet sent in the connection
+func (c *rtspTestClient) readInterleaved() []byte {
+	c.t.Helper()
+	header := make([]byte, 4)
+	if _, err := io.ReadFull(c.reader, header); err != nil || header[0] != '$' {
+		c.t.Fatalf("Expected an interleaved packet, got % x (%v)", header, err)
+	}
+	packet := make([]byte, binary.BigEndian.Uint16(header[2:]))
+	if _, err := io.ReadFull(c.reader, packet); err != nil {
+		c.t.Fatalf("Failed to read interleaved packet: %v", err)
+	}
+	return packet
+}
+
+// depacketize collects the NAL units of one access unit from RTP packets
+func depacketize(t *testing.T, next func() []byte) (nals [][]byte, timestamp uint32) {
+	t.Helper()
+	var fragment []byte
+	for {
+		packet := next()
+		if packet[0] != 0x80 || packet[1]&0x7F != rtpPayloadType {
+			t.Fatalf("Unexpected RTP header % x", packet[:2])
+		}
+		timestamp = binary.BigEndian.Uint32(packet[4:])
+		payload := packet[12:]
+		if payload[0]&0x1F == 28 {
+			if payload[1]&0x80 != 0 {
+				fragment = []byte{payload[0]&0xE0 | payload[1]&0x1F}
+			}
+			fragment = append(fragment, payload[2:]...)
+			if payload[1]&0x40 != 0 {
+				nals = append(nals, fragment)
+			}
+		} else {
+			nals = append(nals, payload)
+		}
+		if packet[1]&0x80 != 0 {
+			return nals, timestamp
+		}
+	}
+}
+
+func TestRTSPServer(t *testing.T) {
+	frames := make(chan VideoFrame, 10)
+	server := NewRTSPServer("127.0.0.1:0", frames)
+	if err := server.Start(); err != nil {
+		t.Fatalf("Start failed: %v", err)
+	}
+	defer server.Stop()
+
+	ts := testCodedStream{widthMbs: 2, heightMbs: 2, qp: 26}
+	y, cb, cr := pcmPicture(32, 32)
+	slice := ts.pcmSlice(y, cb, cr)
+	keyFrame := testAccessUnit(ts.sps(), ts.pps(), slice)
+	keyFrame.Timestamp = time.Now()
+	frames <- keyFrame
+
+	tcpViewer := dialRTSP(t, server.Addr())
+	if code, header, _ := tcpViewer.request("OPTIONS"); code != 200 || !strings.Contains(header.Get("Public"), "PLAY") {
+		t.Fatalf("OPTIONS: expected 200 listing PLAY, got %d %v", code, header)
+	}
+	code, _, sdp := tcpViewer.request("DESCRIBE", "Accept: application/sdp")
+	if code != 200 {
+		t.Fatalf("DESCRIBE: expected 200, got %d", code)
+	}
+	sprop := base64.StdEncoding.EncodeToString(ts.sps()) + "," + base64.StdEncoding.EncodeToString(ts.pps())
+	for _, line := range []string{"m=video 0 RTP/AVP 96", "a=rtpmap:96 H264/90000", "packetization-mode=1", "sprop-parameter-sets=" + sprop} {
+		if !strings.Contains(sdp, line) {
+			t.Errorf("Expected the SDP to contain %q:\n%s", line, sdp)
+		}
+	}
+
+	code, header, _ := tcpViewer.request("SETUP", "Transport: RTP/AVP/TCP;unicast;interleaved=2-3")
+	if code != 200 || !strings.Contains(header.Get("Transport"), "interleaved=2-3") {
+		t.Fatalf("SETUP: expected 200 with the interleaved channels, got %d %v", code, header)
+	}
+	tcpSession := header.Get("Session")
+	if code, _, _ := tcpViewer.request("PLAY", "Session: "+tcpSession); code != 200 {
+		t.Fatalf("PLAY: expected 200, got %d", code)
+	}
+
+	rtp, err := net.ListenUDP("udp", &net.UDPAddr{IP: net.IPv4(127, 0, 0, 1)})
+	if err != nil {
+		t.Fatalf("Failed to listen for RTP: %v", err)
+	}
+	defer rtp.Close()
+	port := rtp.LocalAddr().(*net.UDPAddr).Port
+	udpViewer := dialRTSP(t, server.Addr())
+	code, header, _ = udpViewer.request("SETUP", fmt.Sprintf("Transport: RTP/AVP;unicast;client_port=%d-%d", port, port+1))
+	if code != 200 || !strings.Contains(header.Get("Transport"), "server_port=") {
+		t.Fatalf("SETUP: expected 200 with the server ports, got %d %v", code, header)
+	}
+	udpSession := header.Get("Session")
+	if code, _, _ := udpViewer.request("PLAY", "Session: "+udpSession); code != 200 {
+		t.Fatalf("PLAY: expected 200, got %d", code)
+	}
+	if server.Viewers() != 2 {
+		t.Fatalf("Expected 2 viewers, got %d", server.Viewers())
+	}
+
+	// A P frame first, which viewers skip until they have a keyframe
+	frames <- VideoFrame{Data: []byte{0, 0, 0, 1, 0x41, 0x9A, 0x02}, Timestamp: keyFrame.Timestamp.Add(10 * time.Millisecond)}
+	keyFrame.Timestamp = keyFrame.Timestamp.Add(20 * time.Millisecond)
+	frames <- keyFrame
+	frames <- VideoFrame{Data: []byte{0, 0, 0, 1, 0x41, 0x9A, 0x03}, Timestamp: keyFrame.Timestamp.Add(40 * time.Millisecond)}
+
+	readUDP := func() []byte {
+		buf := make([]byte, 2048)
+		rtp.SetReadDeadline(time.Now().Add(5 * time.Second))
+		n, err := rtp.Read(buf)
+		if err != nil {
+			t.Fatalf("Failed to read RTP packet: %v", err)
+		}
+		return buf[:n]
+	}
+	for name, next := range map[string]func() []byte{"TCP": tcpViewer.readInterleaved, "UDP": readUDP} {
+		nals, first := depacketize(t, next)
+		if len(nals) != 3 || !bytes.Equal(nals[0], ts.sps()) || !bytes.Equal(nals[1], ts.pps()) || !bytes.Equal(nals[2], slice) {
+			t.Errorf("%s: expected the keyframe's SPS, PPS and slice, got %d units", name, len(nals))
+		}
+		nals, second := depacketize(t, next)
+		if len(nals) != 1 || !bytes.Equal(nals[0], []byte{0x41, 0x9A, 0x03}) {
+			t.Errorf("%s: expected the P frame after the keyframe, got % x", name, nals)
+		}
+		if second-first != 3600 {
+			t.Errorf("%s: expected 40 ms (3600) between the frames, got %d", name, second-first)
+		}
+	}
+
+	// Sessions belong to the connection that set them up
+	if code, _, _ := udpViewer.request("TEARDOWN", "Session: "+tcpSession); code != 454 {
+		t.Errorf("TEARDOWN: expected 454 for another client's session, got %d", code)
+	}
+	if code, _, _ := udpViewer.request("PLAY", "Session: "+tcpSession); code != 454 {
+		t.Errorf("PLAY: expected 454 for another client's session, got %d", code)
+	}
+	if server.Viewers() != 2 {
+		t.Errorf("Expected another client's session to be left alone, got %d viewers", server.Viewers())
+	}
+
+	if code, _, _ := udpViewer.request("TEARDOWN", "Session: "+udpSession); code != 200 {
+		t.Errorf("TEARDOWN: expected 200, got %d", code)
+	}
+	if server.Viewers() != 1 {
+		t.Errorf("Expected 1 viewer after a teardown, got %d", server.Viewers())
+	}
+	if code, _, _ := udpViewer.request("PLAY", "Session: "+udpSession); code != 454 {
+		t.Errorf("PLAY: expected 454 for an ended session, got %d", code)
+	}
+
+	tcpViewer.conn.Close()
+	for deadline := time.Now().Add(time.Second); server.Viewers() != 0; {
+		if time.Now().After(deadline) {
+			t.Fatal("Expected the session to end when its client disconnects")
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
+
+func TestRTSPServerRejectsUnsupportedTransport(t *testing.T) {
+	server := NewRTSPServer("127.0.0.1:0", make(chan VideoFrame))
+	if err := server.Start(); err != nil {
+		t.Fatalf("Start failed: %v", err)
+	}
+	defer server.Stop()
+
+	client := dialRTSP(t, server.Addr())
+	if code, _, _ := client.request("SETUP", "Transport: RTP/AVP;multicast"); code != 461 {
+		t.Errorf("Expected 461 for multicast, got %d", code)
+	}
+	if code, _, _ := client.request("RECORD"); code != 501 {
+		t.Errorf("Expected 501 for RECORD, got %d", code)
+	}
+}
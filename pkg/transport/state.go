@@ -4,6 +4,9 @@ import (
 	"context"
 	"fmt"
 	"net"
+	"sync"
+	"sync/atomic"
+	"time"
 
 	"github.com/conceptcodes/dji-tello-sdk-go/pkg/transport/udp"
 	"github.com/conceptcodes/dji-tello-sdk-go/pkg/types"
@@ -12,13 +15,27 @@ import (
 
 type StateListener struct {
 	server    *udp.UDPServer
-	stateChan chan *types.State
+	hub       *TelemetryHub
+	stateChan <-chan *types.State // The hub's first subscription, nil once stopped
+	observer  func(*types.State)  // Sees every parsed state, including dropped ones
+	mu        sync.RWMutex        // Guards stateChan and observer
+	lastState atomic.Int64        // Unix nanoseconds of the last state, 0 before the first
 }
 
-func NewStateListener(listenAddr string) (*StateListener, error) {
-	sl := &StateListener{
-		stateChan: make(chan *types.State, 100), // Buffer for 100 states
+// newStateListener creates a listener whose hub already has the subscription
+// behind GetStateChannel, which keeps the first states that arrive
+func newStateListener() *StateListener {
+	hub := NewTelemetryHub()
+	// Dropping the newest states matches the channel this replaced
+	sub, _ := hub.Subscribe(WithBufferSize(DefaultSubscriptionBuffer), WithDropPolicy(DropNewest))
+	return &StateListener{
+		hub:       hub,
+		stateChan: sub.C,
 	}
+}
+
+func NewStateListener(listenAddr string) (*StateListener, error) {
+	sl := newStateListener()
 
 	server, err := udp.NewUDPServer(
 		listenAddr,
@@ -38,47 +55,125 @@ func NewStateListener(listenAddr string) (*StateListener, error) {
 // Start begins listening for state data.
 // This is a blocking call and should typically be run in a goroutine.
 func (sl *StateListener) Start() error {
+	if err := sl.Listen(); err != nil {
+		return err
+	}
+	return sl.Serve()
+}
+
+// Listen binds the state port, so that an error such as the port being in
+// use is returned before Serve runs in the background
+func (sl *StateListener) Listen() error {
 	if sl.server == nil {
 		return fmt.Errorf("state listener server is not initialized")
 	}
 	utils.Logger.Infof("Starting Tello state listener on %s", sl.server.Addr)
-	return sl.server.Start(context.Background())
+	return sl.server.Listen()
+}
+
+// Serve receives states on the port bound by Listen until Stop is called
+func (sl *StateListener) Serve() error {
+	if sl.server == nil {
+		return fmt.Errorf("state listener server is not initialized")
+	}
+	return sl.server.Serve(context.Background())
+}
+
+// newRelayStateListener creates a StateListener without a socket of its own.
+// It is fed by a StateDemux that shares one port between several drones.
+func newRelayStateListener() *StateListener {
+	return newStateListener()
 }
 
 func (sl *StateListener) Stop() {
+	// Relay listeners created by a StateDemux have no server to stop
 	if sl.server != nil {
 		utils.Logger.Infof("Stopping Tello state listener on %s", sl.server.Addr)
 		sl.server.Stop()
-	} else {
-		utils.Logger.Warnf("Attempted to stop a nil state listener server")
 	}
 
-	// Close state channel
+	// Close the hub, and with it the state channel
+	sl.mu.Lock()
+	defer sl.mu.Unlock()
 	if sl.stateChan != nil {
-		close(sl.stateChan)
+		sl.hub.Close()
 		sl.stateChan = nil
 	}
 }
 
-// GetStateChannel returns a read-only channel for receiving telemetry states
+// GetStateChannel returns a read-only channel for receiving telemetry states.
+// There is only one such channel; consumers that each need every state
+// should subscribe to Hub instead.
 func (sl *StateListener) GetStateChannel() <-chan *types.State {
+	sl.mu.RLock()
+	defer sl.mu.RUnlock()
 	return sl.stateChan
 }
 
+// Hub returns the hub that broadcasts every state received, or nil for a
+// listener that was not created by NewStateListener or a StateDemux
+func (sl *StateListener) Hub() *TelemetryHub {
+	return sl.hub
+}
+
+// SetStateObserver registers a function that is called synchronously with
+// every parsed state before it is queued on the state channel. States dropped
+// because the channel is full are still observed, which makes it suitable for
+// recorders. Pass nil to remove the observer.
+func (sl *StateListener) SetStateObserver(observer func(*types.State)) {
+	sl.mu.Lock()
+	defer sl.mu.Unlock()
+	sl.observer = observer
+}
+
+// LastStateTime returns when the last state was received, or the zero time
+// if none has been received yet
+func (sl *StateListener) LastStateTime() time.Time {
+	if nanos := sl.lastState.Load(); nanos != 0 {
+		return time.Unix(0, nanos)
+	}
+	return time.Time{}
+}
+
 func (sl *StateListener) onStateData(data []byte, addr *net.UDPAddr) {
-	state, err := utils.ParseState(string(data))
+	state, err := parseStatePacket(data)
 	if err != nil {
 		utils.Logger.Warnf("Error parsing state data from %s: %v. Data: %s", addr.String(), err, string(data))
 		return
 	}
+	sl.publish(state, addr)
+}
+
+func (sl *StateListener) publish(state *types.State, addr *net.UDPAddr) {
+	sl.mu.RLock()
+	defer sl.mu.RUnlock()
+	if sl.stateChan == nil {
+		return
+	}
+	if state.Received.IsZero() {
+		state.Received = time.Now()
+	}
+	sl.lastState.Store(state.Received.UnixNano())
+	if sl.observer != nil {
+		sl.observer(state)
+	}
 
-	// Send state to channel (non-blocking)
-	select {
-	case sl.stateChan <- state:
-		utils.Logger.Debugf("State sent to channel from %s: %+v", addr.String(), state)
-	default:
-		utils.Logger.Warnf("State channel full, dropping state from %s", addr.String())
+	utils.Logger.Debugf("State received from %s: %+v", addr.String(), state)
+	sl.hub.Publish(state)
+}
+
+// parseStatePacket parses a state packet and stamps it with the time it was
+// received. Each packet gets a new State rather than one reused with
+// utils.ParseStateInto: the hub hands the same *State to every subscriber and
+// the observer, which may keep it, so it must not change once published.
+func parseStatePacket(data []byte) (*types.State, error) {
+	received := time.Now()
+	state, err := utils.ParseState(string(data))
+	if err != nil {
+		return nil, err
 	}
+	state.Received = received
+	return state, nil
 }
 
 func onStateError(err error) {
@@ -0,0 +1,107 @@
+package transport
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"sync"
+
+	"github.com/conceptcodes/dji-tello-sdk-go/pkg/transport/udp"
+	"github.com/conceptcodes/dji-tello-sdk-go/pkg/utils"
+)
+
+// StateDemux receives state packets from several drones on a single port and
+// routes them by source IP. Tello EDU drones in station (AP) mode all push
+// their state to port 8890 of the controller, so one socket has to be shared.
+type StateDemux struct {
+	server    *udp.UDPServer
+	mu        sync.RWMutex
+	listeners map[string]*StateListener
+}
+
+// NewStateDemux creates a demultiplexer that listens on listenAddr
+func NewStateDemux(listenAddr string) (*StateDemux, error) {
+	d := &StateDemux{
+		listeners: make(map[string]*StateListener),
+	}
+
+	server, err := udp.NewUDPServer(
+		listenAddr,
+		udp.WithOnData(d.onStateData),
+		udp.WithOnError(onStateError),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create UDP server for state demux on %s: %w", listenAddr, err)
+	}
+
+	d.server = server
+	return d, nil
+}
+
+// Start begins listening for state data.
+// This is a blocking call and should typically be run in a goroutine.
+func (d *StateDemux) Start() error {
+	if err := d.Listen(); err != nil {
+		return err
+	}
+	return d.Serve()
+}
+
+// Listen binds the shared state port, so that an error such as the port being
+// in use is returned before Serve runs in the background
+func (d *StateDemux) Listen() error {
+	utils.Logger.Infof("Starting Tello state demux on %s", d.server.Addr)
+	return d.server.Listen()
+}
+
+// Serve receives and routes states on the port bound by Listen until Stop is
+// called
+func (d *StateDemux) Serve() error {
+	return d.server.Serve(context.Background())
+}
+
+// Stop stops the shared socket and closes the channels of all listeners
+func (d *StateDemux) Stop() {
+	utils.Logger.Infof("Stopping Tello state demux on %s", d.server.Addr)
+	d.server.Stop()
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	for ip, listener := range d.listeners {
+		listener.Stop()
+		delete(d.listeners, ip)
+	}
+}
+
+// Listener returns the StateListener that receives packets sent from ip,
+// creating it if needed. The returned listener must not be started; its
+// packets arrive through the demux.
+func (d *StateDemux) Listener(ip string) *StateListener {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	listener, ok := d.listeners[ip]
+	if !ok {
+		listener = newRelayStateListener()
+		d.listeners[ip] = listener
+	}
+	return listener
+}
+
+func (d *StateDemux) onStateData(data []byte, addr *net.UDPAddr) {
+	d.mu.RLock()
+	listener, ok := d.listeners[addr.IP.String()]
+	d.mu.RUnlock()
+
+	if !ok {
+		utils.Logger.Debugf("Ignoring state packet from unknown drone %s", addr.String())
+		return
+	}
+
+	state, err := parseStatePacket(data)
+	if err != nil {
+		utils.Logger.Warnf("Error parsing state data from %s: %v. Data: %s", addr.String(), err, string(data))
+		return
+	}
+	listener.publish(state, addr)
+}
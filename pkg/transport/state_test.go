@@ -5,6 +5,8 @@ import (
 	"net"
 	"testing"
 	"time"
+
+	"github.com/conceptcodes/dji-tello-sdk-go/pkg/types"
 )
 
 // MockUDPServerForState is a mock implementation for testing StateListener
@@ -117,6 +119,96 @@ func TestStateListenerDataProcessing(t *testing.T) {
 	// the listener is created and can be started/stopped without errors
 }
 
+func TestStateListenerObserverSeesDroppedStates(t *testing.T) {
+	listener := newRelayStateListener()
+	defer listener.Stop()
+
+	observed := 0
+	listener.SetStateObserver(func(state *types.State) {
+		observed++
+	})
+
+	// Fill the channel buffer and overflow it by a few states
+	addr := &net.UDPAddr{IP: net.IPv4(127, 0, 0, 1)}
+	for i := 0; i < cap(listener.stateChan)+5; i++ {
+		listener.publish(&types.State{Bat: i}, addr)
+	}
+
+	if observed != cap(listener.stateChan)+5 {
+		t.Errorf("Expected observer to see %d states, got %d", cap(listener.stateChan)+5, observed)
+	}
+	if len(listener.stateChan) != cap(listener.stateChan) {
+		t.Errorf("Expected %d queued states, got %d", cap(listener.stateChan), len(listener.stateChan))
+	}
+
+	// Removing the observer stops notifications
+	listener.SetStateObserver(nil)
+	listener.publish(&types.State{}, addr)
+	if observed != cap(listener.stateChan)+5 {
+		t.Errorf("Expected no notifications after removing the observer, got %d", observed)
+	}
+}
+
+func TestStateListenerLastStateTime(t *testing.T) {
+	listener := newRelayStateListener()
+	defer listener.Stop()
+
+	if !listener.LastStateTime().IsZero() {
+		t.Errorf("Expected no last state time before the first state, got %v", listener.LastStateTime())
+	}
+
+	before := time.Now()
+	listener.publish(&types.State{}, &net.UDPAddr{IP: net.IPv4(127, 0, 0, 1)})
+	if last := listener.LastStateTime(); last.Before(before) || last.After(time.Now()) {
+		t.Errorf("Expected the last state time to be when the state was published, got %v", last)
+	}
+}
+
+func TestStateListenerStampsReceivedTime(t *testing.T) {
+	listener := newRelayStateListener()
+	defer listener.Stop()
+
+	before := time.Now()
+	listener.onStateData([]byte("mid:-1;bat:80;unknown:1;"), &net.UDPAddr{IP: net.IPv4(127, 0, 0, 1)})
+
+	state := <-listener.GetStateChannel()
+	if state.Received.Before(before) || state.Received.After(time.Now()) {
+		t.Errorf("Expected the state to be stamped with its receive time, got %v", state.Received)
+	}
+	if !listener.LastStateTime().Equal(state.Received) {
+		t.Errorf("Expected the last state time %v to be the receive time %v", listener.LastStateTime(), state.Received)
+	}
+	if state.Extra["unknown"] != "1" {
+		t.Errorf("Expected the unknown key to be kept, got %v", state.Extra)
+	}
+}
+
+func TestStateListenerHub(t *testing.T) {
+	listener := newRelayStateListener()
+	sub, err := listener.Hub().Subscribe()
+	if err != nil {
+		t.Fatalf("Subscribe failed: %v", err)
+	}
+
+	listener.publish(&types.State{Bat: 80}, &net.UDPAddr{IP: net.IPv4(127, 0, 0, 1)})
+
+	// The state channel and the subscription both see the state
+	if state := <-listener.GetStateChannel(); state.Bat != 80 {
+		t.Errorf("Expected the state channel to receive the state, got %+v", state)
+	}
+	if state := <-sub.C; state.Bat != 80 {
+		t.Errorf("Expected the subscription to receive the state, got %+v", state)
+	}
+	if latest := listener.Hub().Latest(); latest == nil || latest.Bat != 80 {
+		t.Errorf("Expected the hub's latest state to be the state, got %+v", latest)
+	}
+
+	listener.Stop()
+	if _, ok := <-sub.C; ok {
+		t.Error("Expected stopping the listener to close subscriptions")
+	}
+}
+
 func TestOnStateError(t *testing.T) {
 	// Test the onStateError function
 	testError := fmt.Errorf("test error")
@@ -124,3 +216,56 @@ func TestOnStateError(t *testing.T) {
 	// This function is called internally, we just need to ensure it doesn't panic
 	onStateError(testError)
 }
+
+func TestStateDemuxRoutesBySourceIP(t *testing.T) {
+	demux, err := NewStateDemux("127.0.0.1:8893")
+	if err != nil {
+		t.Fatalf("Failed to create state demux: %v", err)
+	}
+
+	first := demux.Listener("127.0.0.2")
+	second := demux.Listener("127.0.0.3")
+	if demux.Listener("127.0.0.2") != first {
+		t.Error("Expected the same listener for repeated lookups of one IP")
+	}
+
+	if err := demux.Listen(); err != nil {
+		t.Fatalf("Failed to listen: %v", err)
+	}
+	go demux.Serve()
+
+	send := func(from, packet string) {
+		local := &net.UDPAddr{IP: net.ParseIP(from)}
+		remote := &net.UDPAddr{IP: net.ParseIP("127.0.0.1"), Port: 8893}
+		conn, err := net.DialUDP("udp", local, remote)
+		if err != nil {
+			demux.Stop()
+			t.Skipf("Cannot send from %s: %v", from, err)
+		}
+		defer conn.Close()
+		conn.Write([]byte(packet))
+	}
+
+	send("127.0.0.3", "bat:60;h:10;")
+	send("127.0.0.2", "bat:90;h:20;")
+	send("127.0.0.4", "bat:10;h:30;") // Unknown drone, dropped
+
+	for _, tc := range []struct {
+		listener *StateListener
+		bat      int
+	}{{first, 90}, {second, 60}} {
+		select {
+		case state := <-tc.listener.GetStateChannel():
+			if state.Bat != tc.bat {
+				t.Errorf("Expected battery %d, got %d", tc.bat, state.Bat)
+			}
+		case <-time.After(2 * time.Second):
+			t.Fatalf("Timed out waiting for state with battery %d", tc.bat)
+		}
+	}
+
+	demux.Stop()
+	if first.GetStateChannel() != nil || second.GetStateChannel() != nil {
+		t.Error("Expected listener channels to be closed after Stop")
+	}
+}
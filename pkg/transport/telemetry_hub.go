@@ -0,0 +1,253 @@
+package transport
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/conceptcodes/dji-tello-sdk-go/pkg/types"
+)
+
+// DefaultSubscriptionBuffer is the number of states a subscription buffers
+// unless WithBufferSize says otherwise
+const DefaultSubscriptionBuffer = 100
+
+// DropPolicy decides which state a subscription loses when its buffer is full
+type DropPolicy int
+
+const (
+	DropOldest DropPolicy = iota // Discard the oldest buffered state, so readers catch up on the latest
+	DropNewest                   // Discard the incoming state, so readers see an unbroken prefix
+)
+
+// TelemetryHub broadcasts every published state to all of its subscribers.
+// A slow subscriber only loses states from its own buffer and never holds up
+// the publisher or other subscribers. States are shared between subscribers
+// and must not be modified.
+type TelemetryHub struct {
+	mu     sync.RWMutex
+	subs   map[*StateSubscription]struct{}
+	closed bool
+	latest atomic.Pointer[types.State]
+}
+
+// StateSubscription receives states from a TelemetryHub on C until it is
+// closed, either by Close or by the hub closing
+type StateSubscription struct {
+	C <-chan *types.State
+
+	hub         *TelemetryHub
+	ch          chan *types.State
+	policy      DropPolicy
+	minInterval time.Duration
+	fields      []int // Indexes of the types.State fields to compare, nil for all states
+	dropped     atomic.Uint64
+
+	mu       sync.Mutex // Guards ch against sends racing with Close
+	closed   bool
+	lastSent time.Time
+	last     *types.State
+}
+
+// SubscribeOption configures a StateSubscription
+type SubscribeOption func(*StateSubscription) error
+
+// WithBufferSize sets how many states the subscription buffers
+// (default: DefaultSubscriptionBuffer)
+func WithBufferSize(size int) SubscribeOption {
+	return func(s *StateSubscription) error {
+		if size < 1 {
+			return fmt.Errorf("buffer size must be at least 1, got %d", size)
+		}
+		s.ch = make(chan *types.State, size)
+		return nil
+	}
+}
+
+// WithDropPolicy sets which state is lost when the buffer is full
+// (default: DropOldest)
+func WithDropPolicy(policy DropPolicy) SubscribeOption {
+	return func(s *StateSubscription) error {
+		s.policy = policy
+		return nil
+	}
+}
+
+// WithMinInterval rate-limits the subscription to one state per interval.
+// States arriving sooner are skipped rather than counted as dropped.
+func WithMinInterval(interval time.Duration) SubscribeOption {
+	return func(s *StateSubscription) error {
+		s.minInterval = interval
+		return nil
+	}
+}
+
+// WithFields only delivers states in which one of the named fields differs
+// from the last state delivered. Fields are named by their JSON keys, which
+// are those of the Tello's state string, e.g. "bat" or "h".
+func WithFields(names ...string) SubscribeOption {
+	return func(s *StateSubscription) error {
+		for _, name := range names {
+			index, ok := stateFieldIndex(name)
+			if !ok {
+				return fmt.Errorf("unknown state field '%s'", name)
+			}
+			s.fields = append(s.fields, index)
+		}
+		return nil
+	}
+}
+
+// NewTelemetryHub creates a hub without subscribers
+func NewTelemetryHub() *TelemetryHub {
+	return &TelemetryHub{
+		subs: make(map[*StateSubscription]struct{}),
+	}
+}
+
+// Subscribe adds a subscriber that receives every state published from now on
+func (h *TelemetryHub) Subscribe(opts ...SubscribeOption) (*StateSubscription, error) {
+	sub := &StateSubscription{hub: h}
+	for _, opt := range opts {
+		if err := opt(sub); err != nil {
+			return nil, err
+		}
+	}
+	if sub.ch == nil {
+		sub.ch = make(chan *types.State, DefaultSubscriptionBuffer)
+	}
+	sub.C = sub.ch
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if h.closed {
+		return nil, fmt.Errorf("telemetry hub is closed")
+	}
+	h.subs[sub] = struct{}{}
+	return sub, nil
+}
+
+// Publish records state as the latest and offers it to every subscriber
+func (h *TelemetryHub) Publish(state *types.State) {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+	if h.closed {
+		return
+	}
+	h.latest.Store(state)
+	for sub := range h.subs {
+		sub.offer(state)
+	}
+}
+
+// Latest returns the last state published, or nil before the first
+func (h *TelemetryHub) Latest() *types.State {
+	return h.latest.Load()
+}
+
+// Subscribers returns the number of open subscriptions
+func (h *TelemetryHub) Subscribers() int {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+	return len(h.subs)
+}
+
+// Close closes every subscription. Later states are not published.
+func (h *TelemetryHub) Close() {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if h.closed {
+		return
+	}
+	h.closed = true
+	for sub := range h.subs {
+		sub.close()
+		delete(h.subs, sub)
+	}
+}
+
+// Close unsubscribes and closes C. It is safe to call more than once.
+func (s *StateSubscription) Close() {
+	s.hub.mu.Lock()
+	delete(s.hub.subs, s)
+	s.hub.mu.Unlock()
+	s.close()
+}
+
+// Dropped returns how many states were lost because the buffer was full
+func (s *StateSubscription) Dropped() uint64 {
+	return s.dropped.Load()
+}
+
+func (s *StateSubscription) close() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if !s.closed {
+		s.closed = true
+		close(s.ch)
+	}
+}
+
+// offer delivers state unless the subscription's filters skip it
+func (s *StateSubscription) offer(state *types.State) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.closed {
+		return
+	}
+
+	now := time.Now()
+	if s.minInterval > 0 && !s.lastSent.IsZero() && now.Sub(s.lastSent) < s.minInterval {
+		return
+	}
+	if s.fields != nil && s.last != nil && !fieldsChanged(s.last, state, s.fields) {
+		return
+	}
+	s.lastSent = now
+	s.last = state
+
+	for {
+		select {
+		case s.ch <- state:
+			return
+		default:
+		}
+		if s.policy == DropNewest {
+			s.dropped.Add(1)
+			return
+		}
+		// Make room by discarding the oldest state, unless the reader just did
+		select {
+		case <-s.ch:
+			s.dropped.Add(1)
+		default:
+		}
+	}
+}
+
+func fieldsChanged(a, b *types.State, fields []int) bool {
+	va, vb := reflect.ValueOf(a).Elem(), reflect.ValueOf(b).Elem()
+	for _, index := range fields {
+		if va.Field(index).Interface() != vb.Field(index).Interface() {
+			return true
+		}
+	}
+	return false
+}
+
+// stateFieldIndex finds the types.State field with the given JSON key,
+// leaving out fields such as Extra that cannot be compared
+func stateFieldIndex(name string) (int, bool) {
+	stateType := reflect.TypeOf(types.State{})
+	for i := 0; i < stateType.NumField(); i++ {
+		field := stateType.Field(i)
+		key, _, _ := strings.Cut(field.Tag.Get("json"), ",")
+		if key == name && key != "-" && field.Type.Comparable() {
+			return i, true
+		}
+	}
+	return 0, false
+}
@@ -0,0 +1,208 @@
+package transport
+
+import (
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/conceptcodes/dji-tello-sdk-go/pkg/types"
+)
+
+func subscribe(t *testing.T, hub *TelemetryHub, opts ...SubscribeOption) *StateSubscription {
+	t.Helper()
+	sub, err := hub.Subscribe(opts...)
+	if err != nil {
+		t.Fatalf("Subscribe failed: %v", err)
+	}
+	return sub
+}
+
+// drain returns the battery levels of the states buffered on sub
+func drain(sub *StateSubscription) []int {
+	var levels []int
+	for {
+		select {
+		case state := <-sub.C:
+			levels = append(levels, state.Bat)
+		default:
+			return levels
+		}
+	}
+}
+
+func equalInts(a, b []int) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+func TestTelemetryHubBroadcasts(t *testing.T) {
+	hub := NewTelemetryHub()
+	defer hub.Close()
+
+	if hub.Latest() != nil {
+		t.Error("Expected no latest state before the first publish")
+	}
+
+	first := subscribe(t, hub)
+	second := subscribe(t, hub)
+	for bat := 90; bat < 93; bat++ {
+		hub.Publish(&types.State{Bat: bat})
+	}
+
+	for _, sub := range []*StateSubscription{first, second} {
+		if got := drain(sub); !equalInts(got, []int{90, 91, 92}) {
+			t.Errorf("Expected every subscriber to receive every state, got %v", got)
+		}
+	}
+	if latest := hub.Latest(); latest == nil || latest.Bat != 92 {
+		t.Errorf("Expected the latest state to have 92%% battery, got %+v", latest)
+	}
+}
+
+func TestTelemetryHubDropPolicies(t *testing.T) {
+	tests := []struct {
+		name     string
+		policy   DropPolicy
+		expected []int
+	}{
+		{"Drop oldest", DropOldest, []int{3, 4}},
+		{"Drop newest", DropNewest, []int{0, 1}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			hub := NewTelemetryHub()
+			defer hub.Close()
+			sub := subscribe(t, hub, WithBufferSize(2), WithDropPolicy(tt.policy))
+			unbuffered := subscribe(t, hub)
+
+			for bat := 0; bat < 5; bat++ {
+				hub.Publish(&types.State{Bat: bat})
+			}
+
+			if got := drain(sub); !equalInts(got, tt.expected) {
+				t.Errorf("Expected %v, got %v", tt.expected, got)
+			}
+			if sub.Dropped() != 3 {
+				t.Errorf("Expected 3 dropped states, got %d", sub.Dropped())
+			}
+			// A full subscriber does not affect the others
+			if got := drain(unbuffered); len(got) != 5 {
+				t.Errorf("Expected the other subscriber to receive 5 states, got %v", got)
+			}
+		})
+	}
+}
+
+func TestTelemetryHubMinInterval(t *testing.T) {
+	hub := NewTelemetryHub()
+	defer hub.Close()
+	sub := subscribe(t, hub, WithMinInterval(50*time.Millisecond))
+
+	hub.Publish(&types.State{Bat: 1})
+	hub.Publish(&types.State{Bat: 2})
+	time.Sleep(60 * time.Millisecond)
+	hub.Publish(&types.State{Bat: 3})
+
+	if got := drain(sub); !equalInts(got, []int{1, 3}) {
+		t.Errorf("Expected one state per interval, got %v", got)
+	}
+	if sub.Dropped() != 0 {
+		t.Errorf("Expected rate-limited states not to count as dropped, got %d", sub.Dropped())
+	}
+}
+
+func TestTelemetryHubFields(t *testing.T) {
+	hub := NewTelemetryHub()
+	defer hub.Close()
+	sub := subscribe(t, hub, WithFields("bat", "mpry"))
+
+	hub.Publish(&types.State{Bat: 90, H: 10})
+	hub.Publish(&types.State{Bat: 90, H: 20})
+	hub.Publish(&types.State{Bat: 89, H: 20})
+	hub.Publish(&types.State{Bat: 89, Mpry: [3]int{0, 0, 90}})
+
+	var got []types.State
+	for _, state := range []*types.State{<-sub.C, <-sub.C, <-sub.C} {
+		got = append(got, *state)
+	}
+	if got[0].H != 10 || got[1].Bat != 89 || got[2].Mpry[2] != 90 {
+		t.Errorf("Expected only states with a changed field, got %+v", got)
+	}
+	if extra := drain(sub); len(extra) != 0 {
+		t.Errorf("Expected no more states, got %v", extra)
+	}
+
+	for _, name := range []string{"battery", "extra", "-"} {
+		if _, err := hub.Subscribe(WithFields(name)); err == nil {
+			t.Errorf("Expected an error for field '%s'", name)
+		}
+	}
+}
+
+func TestTelemetryHubClose(t *testing.T) {
+	hub := NewTelemetryHub()
+	sub := subscribe(t, hub)
+	other := subscribe(t, hub)
+
+	sub.Close()
+	sub.Close()
+	if _, ok := <-sub.C; ok {
+		t.Error("Expected the closed subscription's channel to be closed")
+	}
+	if hub.Subscribers() != 1 {
+		t.Errorf("Expected 1 subscriber after closing one, got %d", hub.Subscribers())
+	}
+	hub.Publish(&types.State{Bat: 50})
+
+	hub.Close()
+	if state, ok := <-other.C; !ok || state.Bat != 50 {
+		t.Errorf("Expected the buffered state before the channel closes, got %+v", state)
+	}
+	if _, ok := <-other.C; ok {
+		t.Error("Expected closing the hub to close every subscription")
+	}
+	if _, err := hub.Subscribe(); err == nil {
+		t.Error("Expected subscribing to a closed hub to fail")
+	}
+	hub.Publish(&types.State{})
+	other.Close()
+}
+
+func TestTelemetryHubConcurrentUse(t *testing.T) {
+	hub := NewTelemetryHub()
+
+	var wg sync.WaitGroup
+	for i := 0; i < 4; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 100; j++ {
+				hub.Publish(&types.State{Bat: j})
+			}
+		}()
+	}
+	for i := 0; i < 4; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 20; j++ {
+				sub, err := hub.Subscribe(WithBufferSize(1))
+				if err != nil {
+					return
+				}
+				drain(sub)
+				sub.Close()
+			}
+		}()
+	}
+	wg.Wait()
+	hub.Close()
+}
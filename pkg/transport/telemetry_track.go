@@ -0,0 +1,157 @@
+package transport
+
+import (
+	"fmt"
+	"io"
+	"math"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/conceptcodes/dji-tello-sdk-go/pkg/types"
+)
+
+// TelemetryTrackPath returns the path of the telemetry track recorded next to
+// a video, e.g. "flight.srt" for "flight.mp4"
+func TelemetryTrackPath(videoPath string) string {
+	return strings.TrimSuffix(videoPath, filepath.Ext(videoPath)) + ".srt"
+}
+
+// TelemetryTrack writes the drone's state as a SubRip (.srt) subtitle track
+// with one entry per video frame, which video editors show over the footage.
+// Each entry is shown for as long as its frame and holds the last state
+// received before the frame, matched by State.Received and
+// VideoFrame.Timestamp. The track starts at the first keyframe, like the
+// recordings of FMP4Writer, so both share the same timeline.
+//
+// An entry is written when the next frame arrives, so a state received just
+// before a frame has until then to be added.
+type TelemetryTrack struct {
+	w        io.Writer
+	mu       sync.Mutex
+	states   []*types.State // Ordered by receive time, from the state in effect at the pending frame
+	started  bool
+	start    time.Time     // Timestamp of the first frame, time zero of the track
+	pending  time.Time     // Timestamp of the frame whose entry is not written yet, zero if none
+	duration time.Duration // Length of the last frame written
+	index    int
+}
+
+// NewTelemetryTrack creates a track written to w. Close must be called to
+// write the last entry; it does not close w.
+func NewTelemetryTrack(w io.Writer) *TelemetryTrack {
+	return &TelemetryTrack{w: w}
+}
+
+// AddState records a state for the frames that follow it. A state without a
+// receive time is taken to have been received now.
+func (t *TelemetryTrack) AddState(state *types.State) {
+	if state.Received.IsZero() {
+		stamped := *state
+		stamped.Received = time.Now()
+		state = &stamped
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	// States come from one listener, so they rarely need to be sorted in
+	i := len(t.states)
+	for i > 0 && t.states[i-1].Received.After(state.Received) {
+		i--
+	}
+	t.states = append(t.states, nil)
+	copy(t.states[i+1:], t.states[i:])
+	t.states[i] = state
+}
+
+// AddFrame adds an entry for frame and writes the entry of the frame before
+// it. Frames before the first keyframe are skipped.
+func (t *TelemetryTrack) AddFrame(frame VideoFrame) error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if !t.started {
+		if !frame.IsKeyFrame {
+			return nil
+		}
+		if frame.Timestamp.IsZero() {
+			frame.Timestamp = time.Now()
+		}
+		t.start = frame.Timestamp
+		t.started = true
+	} else {
+		// Keep times increasing the way FMP4Writer does
+		switch {
+		case frame.Timestamp.IsZero():
+			frame.Timestamp = t.pending.Add(defaultSampleDuration)
+		case !frame.Timestamp.After(t.pending):
+			frame.Timestamp = t.pending.Add(time.Second / mp4Timescale)
+		}
+	}
+
+	var err error
+	if !t.pending.IsZero() {
+		t.duration = frame.Timestamp.Sub(t.pending)
+		err = t.writeEntry(frame.Timestamp)
+	}
+	t.pending = frame.Timestamp
+	return err
+}
+
+// Close writes the entry of the last frame, which is shown as long as the
+// frame before it
+func (t *TelemetryTrack) Close() error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if t.pending.IsZero() {
+		return nil
+	}
+	duration := defaultSampleDuration
+	if t.duration > 0 {
+		duration = t.duration
+	}
+	return t.writeEntry(t.pending.Add(duration))
+}
+
+// writeEntry writes the pending frame's entry, shown until end
+func (t *TelemetryTrack) writeEntry(end time.Time) error {
+	taken := t.pending
+	t.pending = time.Time{}
+	t.index++
+
+	// Find the last state received before the frame, dropping older ones
+	for len(t.states) > 1 && !t.states[1].Received.After(taken) {
+		t.states = t.states[1:]
+	}
+	var state *types.State
+	if len(t.states) > 0 && !t.states[0].Received.After(taken) {
+		state = t.states[0]
+	}
+
+	entry := fmt.Sprintf("%d\n%s --> %s\n%s\n\n",
+		t.index, srtTime(taken.Sub(t.start)), srtTime(end.Sub(t.start)), describeState(taken, state))
+	if _, err := io.WriteString(t.w, entry); err != nil {
+		return fmt.Errorf("failed to write telemetry entry %d: %w", t.index, err)
+	}
+	return nil
+}
+
+// srtTime formats d as a SubRip timestamp, e.g. "00:01:02,345"
+func srtTime(d time.Duration) string {
+	ms := d.Milliseconds()
+	return fmt.Sprintf("%02d:%02d:%02d,%03d", ms/3600000, ms/60000%60, ms/1000%60, ms%1000)
+}
+
+// describeState formats the entry text: the frame's wall-clock time and, in
+// the bracketed "[key: value]" style of DJI's own subtitle files, the state
+func describeState(taken time.Time, state *types.State) string {
+	clock := taken.Format("2006-01-02 15:04:05.000")
+	if state == nil {
+		return clock + "\nNo drone state"
+	}
+	speed := math.Sqrt(float64(state.Vgx*state.Vgx+state.Vgy*state.Vgy+state.Vgz*state.Vgz)) / 100
+	return fmt.Sprintf("%s\n[h: %d cm] [speed: %.1f m/s] [pitch: %d] [roll: %d] [yaw: %d] [bat: %d%%]",
+		clock, state.H, speed, state.Pitch, state.Roll, state.Yaw, state.Bat)
+}
@@ -0,0 +1,123 @@
+package transport
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/conceptcodes/dji-tello-sdk-go/pkg/types"
+)
+
+func TestTelemetryTrack(t *testing.T) {
+	var buf bytes.Buffer
+	track := NewTelemetryTrack(&buf)
+	start := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	at := func(ms int) time.Time { return start.Add(time.Duration(ms) * time.Millisecond) }
+
+	// The later state is added first, as the listener could race the recorder
+	track.AddState(&types.State{H: 110, Yaw: 90, Bat: 89, Vgx: 30, Vgy: 40, Received: at(40)})
+	track.AddState(&types.State{H: 100, Yaw: 90, Bat: 90, Received: at(-50)})
+
+	frames := []VideoFrame{
+		{Timestamp: at(-10)}, // Skipped, before the first keyframe
+		{Timestamp: at(0), IsKeyFrame: true},
+		{Timestamp: at(33)},
+		{Timestamp: at(66)},
+	}
+	for _, frame := range frames {
+		if err := track.AddFrame(frame); err != nil {
+			t.Fatalf("AddFrame failed: %v", err)
+		}
+	}
+	if err := track.Close(); err != nil {
+		t.Fatalf("Close failed: %v", err)
+	}
+
+	expected := "1\n00:00:00,000 --> 00:00:00,033\n2024-05-01 12:00:00.000\n" +
+		"[h: 100 cm] [speed: 0.0 m/s] [pitch: 0] [roll: 0] [yaw: 90] [bat: 90%]\n\n" +
+		"2\n00:00:00,033 --> 00:00:00,066\n2024-05-01 12:00:00.033\n" +
+		"[h: 100 cm] [speed: 0.0 m/s] [pitch: 0] [roll: 0] [yaw: 90] [bat: 90%]\n\n" +
+		"3\n00:00:00,066 --> 00:00:00,099\n2024-05-01 12:00:00.066\n" +
+		"[h: 110 cm] [speed: 0.5 m/s] [pitch: 0] [roll: 0] [yaw: 90] [bat: 89%]\n\n"
+	if buf.String() != expected {
+		t.Errorf("Expected track:\n%s\ngot:\n%s", expected, buf.String())
+	}
+}
+
+func TestTelemetryTrackWithoutState(t *testing.T) {
+	var buf bytes.Buffer
+	track := NewTelemetryTrack(&buf)
+
+	if err := track.AddFrame(VideoFrame{Timestamp: time.Now(), IsKeyFrame: true}); err != nil {
+		t.Fatalf("AddFrame failed: %v", err)
+	}
+	if err := track.Close(); err != nil {
+		t.Fatalf("Close failed: %v", err)
+	}
+	if !strings.Contains(buf.String(), "00:00:00,000 --> 00:00:00,033\n") || !strings.Contains(buf.String(), "No drone state") {
+		t.Errorf("Expected one entry without a state, got %q", buf.String())
+	}
+}
+
+func TestTelemetryTrackPath(t *testing.T) {
+	tests := map[string]string{
+		"flight.mp4":           "flight.srt",
+		"videos/flight.h264":   "videos/flight.srt",
+		"recording":            "recording.srt",
+		"dir.v2/recording.mp4": "dir.v2/recording.srt",
+	}
+	for video, expected := range tests {
+		if got := TelemetryTrackPath(video); got != expected {
+			t.Errorf("TelemetryTrackPath(%q) = %q, expected %q", video, got, expected)
+		}
+	}
+}
+
+func TestVideoRecorderTelemetry(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "flight.mp4")
+	frames := make(chan VideoFrame, 10)
+	recorder, err := NewVideoRecorderWithFormatAndChannel(frames, path, FormatMP4)
+	if err != nil {
+		t.Fatalf("Failed to create recorder: %v", err)
+	}
+	hub := NewTelemetryHub()
+	defer hub.Close()
+	recorder.SetTelemetry(hub)
+
+	if err := recorder.StartRecording(); err != nil {
+		t.Fatalf("StartRecording failed: %v", err)
+	}
+	hub.Publish(&types.State{H: 120, Bat: 75, Received: time.Now()})
+	time.Sleep(20 * time.Millisecond)
+
+	ts := testCodedStream{widthMbs: 2, heightMbs: 2, qp: 26}
+	y, cb, cr := pcmPicture(32, 32)
+	keyFrame := testAccessUnit(ts.sps(), ts.pps(), ts.pcmSlice(y, cb, cr))
+	keyFrame.Timestamp = time.Now()
+	frames <- keyFrame
+	for deadline := time.Now().Add(time.Second); recorder.GetStats()["frame_count"] != 1; {
+		if time.Now().After(deadline) {
+			t.Fatal("Timed out waiting for the frame to be recorded")
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+
+	if err := recorder.StopRecording(); err != nil {
+		t.Fatalf("StopRecording failed: %v", err)
+	}
+	select {
+	case <-recorder.framesDone:
+	default:
+		t.Error("Expected frame processing to be over before the track is finished")
+	}
+	data, err := os.ReadFile(TelemetryTrackPath(path))
+	if err != nil {
+		t.Fatalf("Expected a telemetry track next to the video: %v", err)
+	}
+	if !strings.HasPrefix(string(data), "1\n00:00:00,000 --> 00:00:00,033\n") || !strings.Contains(string(data), "[h: 120 cm]") {
+		t.Errorf("Expected an entry with the published state, got %q", data)
+	}
+}
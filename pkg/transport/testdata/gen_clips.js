@@ -0,0 +1,182 @@
+// Encodes the clips decoded by TestH264Decoder_EncoderClips and stores a
+// reference decode of each next to it as the expected pixels.
+//
+// The clips are encoded and decoded by headless Chrome's WebCodecs: its
+// software H.264 encoder is OpenH264, which writes the Baseline profile the
+// Tello uses (I and P slices, CAVLC), and its decoder is FFmpeg's.
+//
+// Usage: npm install puppeteer && node gen_clips.js
+const fs = require('fs');
+const path = require('path');
+const puppeteer = require('puppeteer');
+
+const clips = [
+	// Test pattern at the encoder's rate control, keyframe every 6 pictures
+	{ name: 'baseline_64x48', width: 64, height: 48, frames: 12, gop: 6, pattern: 'bars', bitrate: 200000 },
+	// Fine detail at a high bitrate (low QP), so most intra macroblocks are
+	// Intra4x4
+	{ name: 'intra4x4_64x48', width: 64, height: 48, frames: 6, gop: 3, pattern: 'detail', bitrate: 2000000 },
+	// Smooth motion at a very low bitrate (high QP), where the deblocking
+	// filter changes the most pixels. OpenH264 has no constant QP mode for
+	// WebCodecs, so the QP is steered through the bitrate.
+	{ name: 'deblock_64x48', width: 64, height: 48, frames: 12, gop: 12, pattern: 'gradient', bitrate: 2000 },
+];
+
+// Runs in the page: draws, encodes and decodes one clip. Returns the Annex B
+// stream and the decoded I420 pictures, base64 encoded.
+async function encodeClip(clip) {
+	const draw = {
+		bars(ctx, w, h, i) {
+			const colors = ['#c0c0c0', '#c0c000', '#00c0c0', '#00c000', '#c000c0', '#c00000', '#0000c0'];
+			colors.forEach((c, k) => {
+				ctx.fillStyle = c;
+				ctx.fillRect(k * w / colors.length, 0, w / colors.length + 1, h);
+			});
+			ctx.fillStyle = '#fff';
+			ctx.fillRect((i * 3) % w, h / 2 - 6, 12, 12);
+			ctx.fillStyle = '#000';
+			ctx.font = '10px monospace';
+			ctx.fillText(String(i).padStart(2, '0'), 4, h - 4);
+		},
+		detail(ctx, w, h, i) {
+			// Deterministic noise under text and thin lines
+			let seed = 12345 + i;
+			const img = ctx.createImageData(w, h);
+			for (let p = 0; p < img.data.length; p += 4) {
+				seed = (seed * 1103515245 + 12345) & 0x7fffffff;
+				const v = seed >> 23;
+				img.data[p] = v;
+				img.data[p + 1] = (v * 3) & 0xff;
+				img.data[p + 2] = 255 - v;
+				img.data[p + 3] = 255;
+			}
+			ctx.putImageData(img, 0, 0);
+			ctx.strokeStyle = '#fff';
+			for (let x = 0; x < w; x += 5) {
+				ctx.beginPath();
+				ctx.moveTo(x + i, 0);
+				ctx.lineTo(x + i + h / 2, h);
+				ctx.stroke();
+			}
+			ctx.fillStyle = '#000';
+			ctx.font = 'bold 12px sans-serif';
+			ctx.fillText('TELLO', 8 + i, 28);
+		},
+		gradient(ctx, w, h, i) {
+			const g = ctx.createLinearGradient(i * 2, 0, w, h);
+			g.addColorStop(0, '#203040');
+			g.addColorStop(1, '#e0c080');
+			ctx.fillStyle = g;
+			ctx.fillRect(0, 0, w, h);
+			ctx.fillStyle = '#4080c0';
+			ctx.beginPath();
+			ctx.arc(10 + i * 3, 24, 10, 0, 2 * Math.PI);
+			ctx.fill();
+		},
+	}[clip.pattern];
+
+	const canvas = new OffscreenCanvas(clip.width, clip.height);
+	const ctx = canvas.getContext('2d');
+
+	const chunks = [];
+	let encodeError;
+	const encoder = new VideoEncoder({
+		output: (chunk) => {
+			const data = new Uint8Array(chunk.byteLength);
+			chunk.copyTo(data);
+			chunks.push(data);
+		},
+		error: (e) => { encodeError = e; },
+	});
+	encoder.configure({
+		codec: 'avc1.42001e', // Constrained Baseline, level 3.0
+		width: clip.width,
+		height: clip.height,
+		framerate: 10,
+		hardwareAcceleration: 'prefer-software',
+		latencyMode: 'realtime',
+		avc: { format: 'annexb' },
+		bitrate: clip.bitrate,
+	});
+	for (let i = 0; i < clip.frames; i++) {
+		draw(ctx, clip.width, clip.height, i);
+		const frame = new VideoFrame(canvas, { timestamp: i * 100000, duration: 100000 });
+		encoder.encode(frame, { keyFrame: i % clip.gop === 0 });
+		frame.close();
+	}
+	await encoder.flush();
+	encoder.close();
+	if (encodeError) {
+		throw encodeError;
+	}
+
+	const pictures = [];
+	let decodeError;
+	const decoder = new VideoDecoder({
+		output: async (frame) => {
+			pictures.push(frame);
+		},
+		error: (e) => { decodeError = e; },
+	});
+	decoder.configure({ codec: 'avc1.42001e', hardwareAcceleration: 'prefer-software' });
+	chunks.forEach((data, i) => {
+		decoder.decode(new EncodedVideoChunk({
+			type: i % clip.gop === 0 ? 'key' : 'delta',
+			timestamp: i * 100000,
+			data,
+		}));
+	});
+	await decoder.flush();
+	decoder.close();
+	if (decodeError) {
+		throw decodeError;
+	}
+
+	const yuv = [];
+	for (const frame of pictures) {
+		if (frame.format !== 'I420') {
+			throw new Error(`decoder returned ${frame.format}, not I420`);
+		}
+		const rect = frame.visibleRect;
+		const data = new Uint8Array(rect.width * rect.height * 3 / 2);
+		await frame.copyTo(data, { rect });
+		frame.close();
+		yuv.push(data);
+	}
+
+	const base64 = (parts) => {
+		let s = '';
+		for (const part of parts) {
+			for (let i = 0; i < part.length; i++) {
+				s += String.fromCharCode(part[i]);
+			}
+		}
+		return btoa(s);
+	};
+	return { stream: base64(chunks), yuv: base64(yuv), pictures: pictures.length };
+}
+
+(async () => {
+	const browser = await puppeteer.launch({ headless: 'shell', args: ['--no-sandbox'] });
+	try {
+		const page = await browser.newPage();
+		// WebCodecs needs a secure context, which localhost is
+		await page.setRequestInterception(true);
+		page.on('request', (req) => req.respond({ contentType: 'text/html', body: '<!doctype html>' }));
+		await page.goto('http://localhost/');
+
+		for (const clip of clips) {
+			const result = await page.evaluate(encodeClip, clip);
+			const stream = Buffer.from(result.stream, 'base64');
+			const yuv = Buffer.from(result.yuv, 'base64');
+			fs.writeFileSync(path.join(__dirname, `${clip.name}.h264`), stream);
+			fs.writeFileSync(path.join(__dirname, `${clip.name}.yuv`), yuv);
+			console.log(`${clip.name}: ${stream.length} bytes, ${result.pictures} pictures`);
+		}
+	} finally {
+		await browser.close();
+	}
+})().catch((err) => {
+	console.error(err);
+	process.exit(1);
+});
@@ -69,6 +69,15 @@ func (c *UDPClient) Receive(bufferSize int, timeout time.Duration) (string, erro
 	return string(buf[:n]), nil
 }
 
+// Interrupt makes a pending Receive return with a timeout error right away.
+// It is safe to call from another goroutine.
+func (c *UDPClient) Interrupt() error {
+	if c.conn == nil {
+		return fmt.Errorf("UDP client connection is not initialized")
+	}
+	return c.conn.SetReadDeadline(time.Now())
+}
+
 func (c *UDPClient) Close() error {
 	if c.conn != nil {
 		return c.conn.Close()
@@ -20,6 +20,7 @@ type UDPServer struct {
 	mu             sync.RWMutex
 	handlerLimit   chan struct{} // semaphore for bounded concurrency
 	droppedPackets int64         // counter for dropped packets due to full handler limit
+	sequential     bool          // call OnData on the read goroutine, in arrival order
 }
 
 type UDPServerOption func(*UDPServer)
@@ -53,6 +54,15 @@ func WithMaxHandlers(n int) UDPServerOption {
 	}
 }
 
+// WithSequentialHandler calls OnData on the read goroutine so that packets are
+// handled one at a time in arrival order. Use it for streams whose packets
+// must be reassembled; OnData must then return quickly.
+func WithSequentialHandler() UDPServerOption {
+	return func(s *UDPServer) {
+		s.sequential = true
+	}
+}
+
 func NewUDPServer(listenAddr string, opts ...UDPServerOption) (*UDPServer, error) {
 	_, _, err := net.SplitHostPort(listenAddr)
 	if err != nil {
@@ -82,30 +92,49 @@ func NewUDPServer(listenAddr string, opts ...UDPServerOption) (*UDPServer, error
 // The provided context is used for cancellation propagation to OnData callback goroutines.
 // This is a blocking call that runs until the server is stopped or an unrecoverable error occurs.
 func (s *UDPServer) Start(ctx context.Context) error {
+	if err := s.Listen(); err != nil {
+		return err
+	}
+	return s.Serve(ctx)
+}
+
+// Listen binds the configured address without reading from it, so that an
+// error such as the port being in use can be returned before Serve runs in
+// the background
+func (s *UDPServer) Listen() error {
 	s.mu.Lock()
+	defer s.mu.Unlock()
 	if s.Conn != nil {
-		s.mu.Unlock()
 		return fmt.Errorf("UDP server on %s is already started or connection is not nil", s.Addr)
 	}
 
-	// Store context for propagation to handlers
-	s.ctx = ctx
-
 	udpAddr, err := net.ResolveUDPAddr("udp", s.Addr)
 	if err != nil {
-		s.mu.Unlock()
 		return fmt.Errorf("failed to resolve UDP address %s: %w", s.Addr, err)
 	}
 
 	conn, err := net.ListenUDP("udp", udpAddr)
 	if err != nil {
-		s.mu.Unlock()
 		return fmt.Errorf("failed to start UDP server on %s: %w", s.Addr, err)
 	}
 	s.Conn = conn
-	s.mu.Unlock()
 
 	utils.Logger.Infof("UDP server listening on %s", s.Addr)
+	return nil
+}
+
+// Serve reads packets from the address bound by Listen until the server is
+// stopped or an unrecoverable error occurs. The provided context is used for
+// cancellation propagation to OnData callback goroutines.
+func (s *UDPServer) Serve(ctx context.Context) error {
+	s.mu.Lock()
+	if s.Conn == nil {
+		s.mu.Unlock()
+		return fmt.Errorf("UDP server on %s is not listening", s.Addr)
+	}
+	// Store context for propagation to handlers
+	s.ctx = ctx
+	s.mu.Unlock()
 
 	buffer := make([]byte, 2048)
 	for {
@@ -139,7 +168,9 @@ func (s *UDPServer) Start(ctx context.Context) error {
 			dataCopy := make([]byte, n)
 			copy(dataCopy, buffer[:n])
 
-			if s.OnData != nil {
+			if s.OnData != nil && s.sequential {
+				s.OnData(dataCopy, remoteAddr)
+			} else if s.OnData != nil {
 				// Try to acquire semaphore non-blocking
 				select {
 				case s.handlerLimit <- struct{}{}:
@@ -172,6 +172,55 @@ func TestUDPServerStartAlreadyStarted(t *testing.T) {
 	server.Stop()
 }
 
+func TestUDPServerListenThenServe(t *testing.T) {
+	server, err := NewUDPServer("127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Failed to create UDP server: %v", err)
+	}
+	if err := server.Serve(context.Background()); err == nil {
+		t.Error("Expected an error when serving before listening")
+	}
+
+	// Listen returns once the port is bound, so a second server on it
+	// fails right away
+	if err := server.Listen(); err != nil {
+		t.Fatalf("Listen failed: %v", err)
+	}
+	other, err := NewUDPServer(server.Conn.LocalAddr().String())
+	if err != nil {
+		t.Fatalf("Failed to create UDP server: %v", err)
+	}
+	if err := other.Listen(); err == nil {
+		other.Stop()
+		t.Error("Expected an error when the port is in use")
+	}
+
+	received := make(chan []byte, 1)
+	server.OnData = func(data []byte, addr *net.UDPAddr) { received <- data }
+	served := make(chan error, 1)
+	go func() { served <- server.Serve(context.Background()) }()
+
+	client, err := net.DialUDP("udp", nil, server.Conn.LocalAddr().(*net.UDPAddr))
+	if err != nil {
+		t.Fatalf("Failed to dial server: %v", err)
+	}
+	defer client.Close()
+	client.Write([]byte("ok"))
+	select {
+	case data := <-received:
+		if string(data) != "ok" {
+			t.Errorf("Expected \"ok\", got %q", data)
+		}
+	case <-time.After(time.Second):
+		t.Error("Expected the packet to be served")
+	}
+
+	server.Stop()
+	if err := <-served; err != nil {
+		t.Errorf("Expected Serve to return nil once stopped, got %v", err)
+	}
+}
+
 func TestUDPServerStop(t *testing.T) {
 	server, err := NewUDPServer("127.0.0.1:8894")
 	if err != nil {
@@ -277,6 +326,50 @@ func TestUDPServerDataHandling(t *testing.T) {
 	server.Stop()
 }
 
+func TestUDPServerSequentialHandler(t *testing.T) {
+	received := make(chan byte, 100)
+	onData := func(data []byte, addr *net.UDPAddr) {
+		received <- data[0]
+	}
+
+	server, err := NewUDPServer("127.0.0.1:8887", WithOnData(onData), WithSequentialHandler())
+	if err != nil {
+		t.Fatalf("Failed to create UDP server: %v", err)
+	}
+
+	go func() {
+		_ = server.Start(context.Background())
+	}()
+	time.Sleep(100 * time.Millisecond)
+	defer server.Stop()
+
+	conn, err := net.DialUDP("udp", nil, &net.UDPAddr{
+		IP:   net.ParseIP("127.0.0.1"),
+		Port: 8887,
+	})
+	if err != nil {
+		t.Fatalf("Failed to create test connection: %v", err)
+	}
+	defer conn.Close()
+
+	for i := 0; i < 50; i++ {
+		if _, err := conn.Write([]byte{byte(i)}); err != nil {
+			t.Fatalf("Failed to send test data: %v", err)
+		}
+	}
+
+	for i := 0; i < 50; i++ {
+		select {
+		case b := <-received:
+			if int(b) != i {
+				t.Fatalf("Expected packet %d, got %d", i, b)
+			}
+		case <-time.After(1 * time.Second):
+			t.Fatalf("Did not receive packet %d within timeout", i)
+		}
+	}
+}
+
 func TestUDPServerErrorHandling(t *testing.T) {
 	errorReceived := make(chan error, 10)
 
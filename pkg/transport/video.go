@@ -4,8 +4,6 @@ import (
 	"context"
 	"fmt"
 	"image"
-	"image/color"
-	"image/draw"
 	"net"
 	"sync"
 	"time"
@@ -22,44 +20,61 @@ type VideoFrame struct {
 	SeqNum     int
 	NALUnits   []NALUnit
 	IsKeyFrame bool
+	Image      *image.YCbCr // Decoded picture; nil unless the listener has a FrameDecoder
 }
 
 type VideoStreamListener struct {
 	server    *udp.UDPServer
 	FrameChan chan VideoFrame
-	seqNum    int
+	mu        sync.RWMutex // Guards FrameChan against sends racing with Stop
+
+	reassembler         *H264Reassembler
+	statsMu             sync.Mutex
+	droppedFrames       int
+	decodeDroppedFrames int
+
+	decoderMu sync.Mutex
+	decoder   FrameDecoder
+
+	// Once a decoder is set, frames wait in decodeQueue (guarded by mu) for
+	// the decode goroutine, which closes decodeDone when it exits
+	decodeQueue chan VideoFrame
+	decodeDone  chan struct{}
+
+	keyFrameMu      sync.Mutex
+	keyFrameWaiters []chan VideoFrame // Closed when the listener stops
+	stopped         bool              // Set by Stop, so no waiter is added after it
+}
+
+// VideoStreamStats reports reassembly and delivery counters for the stream
+type VideoStreamStats struct {
+	ReassemblerStats
+	DroppedFrames       int `json:"dropped_frames"`        // Complete frames dropped because the frame channel was full
+	DecodeDroppedFrames int `json:"decode_dropped_frames"` // Frames dropped because the decoder fell behind
 }
 
 const (
 	defaultVideoFrameWidth  = 960
 	defaultVideoFrameHeight = 720
-	colorBlockSize          = 8
-)
-
-var (
-	parserPool = sync.Pool{
-		New: func() interface{} {
-			return NewH264Parser()
-		},
-	}
 
-	bufferPool = sync.Pool{
-		New: func() interface{} {
-			return image.NewRGBA(image.Rect(0, 0, defaultVideoFrameWidth, defaultVideoFrameHeight))
-		},
-	}
+	// decodeQueueSize is how many frames may wait for the decoder. A software
+	// decoder that falls further behind loses the oldest frames rather than
+	// stalling the packet reader.
+	decodeQueueSize = 8
 )
 
 func NewVideoStreamListener(listenAddr string) (*VideoStreamListener, error) {
 	vsl := &VideoStreamListener{
-		FrameChan: make(chan VideoFrame, 100), // Buffer for 100 frames
-		seqNum:    0,
+		FrameChan:   make(chan VideoFrame, 100), // Buffer for 100 frames
+		reassembler: NewH264Reassembler(),
 	}
 
+	// Packets are reassembled into frames, so they must be handled in order
 	server, err := udp.NewUDPServer(
 		listenAddr,
 		udp.WithOnData(vsl.onVideoStreamData),
 		udp.WithOnError(onVideoStreamError),
+		udp.WithSequentialHandler(),
 	)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create UDP server for video stream listener on %s: %w", listenAddr, err)
@@ -70,11 +85,28 @@ func NewVideoStreamListener(listenAddr string) (*VideoStreamListener, error) {
 }
 
 func (vsl *VideoStreamListener) Start() error {
+	if err := vsl.Listen(); err != nil {
+		return err
+	}
+	return vsl.Serve()
+}
+
+// Listen binds the video port, so that an error such as the port being in
+// use is returned before Serve runs in the background
+func (vsl *VideoStreamListener) Listen() error {
 	if vsl.server == nil {
 		return fmt.Errorf("video stream listener server is not initialized")
 	}
 	utils.Logger.Infof("Starting Tello video stream listener on %s", vsl.server.Addr)
-	return vsl.server.Start(context.Background())
+	return vsl.server.Listen()
+}
+
+// Serve receives video on the port bound by Listen until Stop is called
+func (vsl *VideoStreamListener) Serve() error {
+	if vsl.server == nil {
+		return fmt.Errorf("video stream listener server is not initialized")
+	}
+	return vsl.server.Serve(context.Background())
 }
 
 func (vsl *VideoStreamListener) Stop() {
@@ -85,7 +117,28 @@ func (vsl *VideoStreamListener) Stop() {
 		utils.Logger.Warnf("Attempted to stop a nil video stream listener server")
 	}
 
+	// Let the decoder finish the frames already queued
+	vsl.mu.Lock()
+	queue, done := vsl.decodeQueue, vsl.decodeDone
+	vsl.decodeQueue = nil
+	vsl.mu.Unlock()
+	if queue != nil {
+		close(queue)
+		<-done
+	}
+
+	// Release NextKeyFrame callers
+	vsl.keyFrameMu.Lock()
+	vsl.stopped = true
+	for _, waiter := range vsl.keyFrameWaiters {
+		close(waiter)
+	}
+	vsl.keyFrameWaiters = nil
+	vsl.keyFrameMu.Unlock()
+
 	// Close frame channel
+	vsl.mu.Lock()
+	defer vsl.mu.Unlock()
 	if vsl.FrameChan != nil {
 		close(vsl.FrameChan)
 		vsl.FrameChan = nil
@@ -96,114 +149,245 @@ func (vsl *VideoStreamListener) Stop() {
 func (vsl *VideoStreamListener) Close() error {
 	vsl.Stop()
 
-	// Clear pools to release memory (optional but good practice)
-	parserPool = sync.Pool{}
-	bufferPool = sync.Pool{}
+	vsl.decoderMu.Lock()
+	if vsl.decoder != nil {
+		if err := vsl.decoder.Close(); err != nil {
+			utils.Logger.Warnf("Failed to close video decoder: %v", err)
+		}
+		vsl.decoder = nil
+	}
+	vsl.decoderMu.Unlock()
 
 	utils.Logger.Info("Video stream listener closed successfully")
 	return nil
 }
 
-// GetFrameChannel returns a read-only channel for receiving video frames
+// GetFrameChannel returns a read-only channel for receiving video frames.
+// Each frame is a complete H.264 access unit.
 func (vsl *VideoStreamListener) GetFrameChannel() <-chan VideoFrame {
+	vsl.mu.RLock()
+	defer vsl.mu.RUnlock()
 	return vsl.FrameChan
 }
 
-func (vsl *VideoStreamListener) onVideoStreamData(data []byte, addr *net.UDPAddr) {
-	utils.Logger.Debugf("Received %d bytes of video data from %s", len(data), addr.String())
-
-	// Get parser from pool
-	parser := parserPool.Get().(*H264Parser)
-	defer parserPool.Put(parser)
-
-	nalUnits, err := parser.ParseFrame(data)
-	if err != nil {
-		utils.Logger.Errorf("Failed to parse H.264 frame: %v", err)
-		// Still send the frame even if parsing fails
-		nalUnits = []NALUnit{}
+// Stats returns the reassembly and delivery counters
+func (vsl *VideoStreamListener) Stats() VideoStreamStats {
+	vsl.statsMu.Lock()
+	defer vsl.statsMu.Unlock()
+	return VideoStreamStats{
+		ReassemblerStats:    vsl.reassembler.Stats(),
+		DroppedFrames:       vsl.droppedFrames,
+		DecodeDroppedFrames: vsl.decodeDroppedFrames,
 	}
+}
 
-	// Create video frame with metadata
-	frame := VideoFrame{
-		Data:       make([]byte, len(data)),
-		Timestamp:  time.Now(),
-		Size:       len(data),
-		SeqNum:     vsl.seqNum,
-		NALUnits:   nalUnits,
-		IsKeyFrame: parser.HasKeyFrame(nalUnits),
+// SetDecoder makes the listener decode every frame and attach the picture to
+// VideoFrame.Image. Frames are decoded on their own goroutine, even when the
+// frame channel is full, since later frames are predicted from them. When
+// the decoder falls behind, the oldest waiting frames are dropped and
+// decoding resumes at the next keyframe; the frames in between are delivered
+// without a picture. Pass nil to stop decoding; the previous decoder is not
+// closed.
+func (vsl *VideoStreamListener) SetDecoder(decoder FrameDecoder) {
+	vsl.decoderMu.Lock()
+	vsl.decoder = decoder
+	vsl.decoderMu.Unlock()
+
+	vsl.mu.Lock()
+	defer vsl.mu.Unlock()
+	if decoder != nil && vsl.decodeQueue == nil && vsl.FrameChan != nil {
+		vsl.decodeQueue = make(chan VideoFrame, decodeQueueSize)
+		vsl.decodeDone = make(chan struct{})
+		go vsl.decodeLoop(vsl.decodeQueue, vsl.decodeDone)
 	}
+}
 
-	// Copy data to avoid race conditions
-	copy(frame.Data, data)
-	vsl.seqNum++
+// NextKeyFrame waits for the next keyframe on the stream. Unlike the frame
+// channel it does not take frames from other readers, so it can be used
+// alongside recorders and displays.
+func (vsl *VideoStreamListener) NextKeyFrame(ctx context.Context) (VideoFrame, error) {
+	waiter := make(chan VideoFrame, 1)
+
+	vsl.keyFrameMu.Lock()
+	if vsl.stopped {
+		vsl.keyFrameMu.Unlock()
+		return VideoFrame{}, fmt.Errorf("video stream listener is stopped")
+	}
+	vsl.keyFrameWaiters = append(vsl.keyFrameWaiters, waiter)
+	vsl.keyFrameMu.Unlock()
 
-	// Send frame to channel (non-blocking to prevent UDP listener blocking)
 	select {
-	case vsl.FrameChan <- frame:
-		utils.Logger.Debugf("Frame %d sent to channel (%d bytes, %d NAL units, keyframe: %v)",
-			frame.SeqNum, frame.Size, len(frame.NALUnits), frame.IsKeyFrame)
-	default:
-		utils.Logger.Warnf("Frame channel full, dropping frame %d", frame.SeqNum)
+	case frame, ok := <-waiter:
+		if !ok {
+			return VideoFrame{}, fmt.Errorf("video stream listener stopped")
+		}
+		return frame, nil
+	case <-ctx.Done():
+		vsl.keyFrameMu.Lock()
+		for i, w := range vsl.keyFrameWaiters {
+			if w == waiter {
+				vsl.keyFrameWaiters = append(vsl.keyFrameWaiters[:i], vsl.keyFrameWaiters[i+1:]...)
+				break
+			}
+		}
+		vsl.keyFrameMu.Unlock()
+		return VideoFrame{}, ctx.Err()
 	}
 }
 
-// ToEnhancedFrame converts VideoFrame to EnhancedVideoFrame for ML processing
-func (vf *VideoFrame) ToEnhancedFrame() *ml.EnhancedVideoFrame {
-	enhanced := ml.NewEnhancedVideoFrame(vf.Data, vf.Timestamp, vf.SeqNum)
-	enhanced.IsKeyFrame = vf.IsKeyFrame
+// ParameterSets returns the most recent SPS and PPS received on the stream
+func (vsl *VideoStreamListener) ParameterSets() (sps, pps []byte) {
+	vsl.statsMu.Lock()
+	defer vsl.statsMu.Unlock()
+	sps, pps = vsl.reassembler.ParameterSets()
+	return append([]byte(nil), sps...), append([]byte(nil), pps...)
+}
 
-	img := vf.toRGBImage()
-	if img != nil {
-		enhanced.Image = img
-		enhanced.Width = defaultVideoFrameWidth
-		enhanced.Height = defaultVideoFrameHeight
-		enhanced.Channels = 3
+func (vsl *VideoStreamListener) onVideoStreamData(data []byte, addr *net.UDPAddr) {
+	utils.Logger.Debugf("Received %d bytes of video data from %s", len(data), addr.String())
+
+	vsl.statsMu.Lock()
+	frames := vsl.reassembler.Push(data, time.Now())
+	vsl.statsMu.Unlock()
+
+	vsl.mu.RLock()
+	queue := vsl.decodeQueue
+	if queue != nil {
+		for _, frame := range frames {
+			vsl.queueForDecode(queue, frame)
+		}
 	}
+	vsl.mu.RUnlock()
 
-	return enhanced
+	if queue == nil {
+		vsl.deliverFrames(frames)
+	}
 }
 
-func onVideoStreamError(err error) {
-	utils.Logger.Errorf("Video stream listener UDP server error: %v", err)
+// queueForDecode hands a frame to the decode goroutine, dropping the oldest
+// waiting frame when the queue is full. Callers hold mu for reading, so Stop
+// cannot close the queue meanwhile.
+func (vsl *VideoStreamListener) queueForDecode(queue chan VideoFrame, frame VideoFrame) {
+	for {
+		select {
+		case queue <- frame:
+			return
+		default:
+		}
+
+		select {
+		case old := <-queue:
+			vsl.statsMu.Lock()
+			vsl.decodeDroppedFrames++
+			vsl.statsMu.Unlock()
+			utils.Logger.Warnf("Video decoder is behind, dropping frame %d", old.SeqNum)
+		default:
+		}
+	}
 }
 
-// toRGBImage converts the raw frame bytes into an RGB image so downstream
-// processors (e.g. YOLO) always have pixel data available even before
-// a full H.264 decoder is integrated.
-func (vf *VideoFrame) toRGBImage() image.Image {
-	img := image.NewRGBA(image.Rect(0, 0, defaultVideoFrameWidth, defaultVideoFrameHeight))
+// decodeLoop decodes queued frames and delivers them. A gap in the frame
+// numbers means frames were dropped, so later frames would be predicted from
+// pictures the decoder never saw; those are delivered undecoded until the
+// next keyframe.
+func (vsl *VideoStreamListener) decodeLoop(queue <-chan VideoFrame, done chan<- struct{}) {
+	defer close(done)
+
+	next, resync := -1, false
+	for frame := range queue {
+		if next >= 0 && frame.SeqNum != next {
+			resync = true
+		}
+		next = frame.SeqNum + 1
+		if frame.IsKeyFrame {
+			resync = false
+		}
 
-	baseColor := color.RGBA{R: 30, G: 30, B: 30, A: 255}
-	if vf.IsKeyFrame {
-		baseColor = color.RGBA{R: 30, G: 90, B: 160, A: 255}
+		if !resync {
+			vsl.decodeFrame(&frame)
+		}
+		vsl.deliverFrames([]VideoFrame{frame})
 	}
+}
 
-	draw.Draw(img, img.Bounds(), &image.Uniform{C: baseColor}, image.Point{}, draw.Src)
+// decodeFrame attaches the decoded picture to frame when a decoder is set
+func (vsl *VideoStreamListener) decodeFrame(frame *VideoFrame) {
+	vsl.decoderMu.Lock()
+	defer vsl.decoderMu.Unlock()
+	if vsl.decoder == nil {
+		return
+	}
 
-	data := vf.Data
-	if len(data) == 0 {
-		return img
+	img, err := vsl.decoder.Decode(*frame)
+	if err != nil {
+		utils.Logger.Warnf("Failed to decode frame %d: %v", frame.SeqNum, err)
+		return
 	}
+	frame.Image = img
+}
 
-	dataLen := len(data)
-	dataIdx := 0
+// deliverFrames hands frames to NextKeyFrame callers and the frame channel
+func (vsl *VideoStreamListener) deliverFrames(frames []VideoFrame) {
+	vsl.deliverKeyFrame(frames)
 
-	for y := 0; y < defaultVideoFrameHeight; y += colorBlockSize {
-		for x := 0; x < defaultVideoFrameWidth; x += colorBlockSize {
-			r := data[dataIdx%dataLen]
-			g := data[(dataIdx+1)%dataLen]
-			b := data[(dataIdx+2)%dataLen]
-			col := color.RGBA{R: r, G: g, B: b, A: 255}
+	vsl.mu.RLock()
+	defer vsl.mu.RUnlock()
+	if vsl.FrameChan == nil {
+		return
+	}
 
-			for by := 0; by < colorBlockSize && (y+by) < defaultVideoFrameHeight; by++ {
-				for bx := 0; bx < colorBlockSize && (x+bx) < defaultVideoFrameWidth; bx++ {
-					img.Set(x+bx, y+by, col)
-				}
-			}
+	for _, frame := range frames {
+		// Send frame to channel (non-blocking to prevent UDP listener blocking)
+		select {
+		case vsl.FrameChan <- frame:
+			utils.Logger.Debugf("Frame %d sent to channel (%d bytes, %d NAL units, keyframe: %v)",
+				frame.SeqNum, frame.Size, len(frame.NALUnits), frame.IsKeyFrame)
+		default:
+			vsl.statsMu.Lock()
+			vsl.droppedFrames++
+			vsl.statsMu.Unlock()
+			utils.Logger.Warnf("Frame channel full, dropping frame %d", frame.SeqNum)
+		}
+	}
+}
+
+// deliverKeyFrame hands the first keyframe in frames to every NextKeyFrame
+// caller
+func (vsl *VideoStreamListener) deliverKeyFrame(frames []VideoFrame) {
+	vsl.keyFrameMu.Lock()
+	defer vsl.keyFrameMu.Unlock()
+	if len(vsl.keyFrameWaiters) == 0 {
+		return
+	}
 
-			dataIdx += 3
+	for _, frame := range frames {
+		if frame.IsKeyFrame {
+			for _, waiter := range vsl.keyFrameWaiters {
+				waiter <- frame
+			}
+			vsl.keyFrameWaiters = nil
+			return
 		}
 	}
+}
+
+// ToEnhancedFrame converts VideoFrame to EnhancedVideoFrame for ML processing.
+// Image, Width and Height are only set when the frame was decoded.
+func (vf *VideoFrame) ToEnhancedFrame() *ml.EnhancedVideoFrame {
+	enhanced := ml.NewEnhancedVideoFrame(vf.Data, vf.Timestamp, vf.SeqNum)
+	enhanced.IsKeyFrame = vf.IsKeyFrame
+
+	if vf.Image != nil {
+		bounds := vf.Image.Bounds()
+		enhanced.Image = vf.Image
+		enhanced.Width = bounds.Dx()
+		enhanced.Height = bounds.Dy()
+		enhanced.Channels = 3
+	}
 
-	return img
+	return enhanced
+}
+
+func onVideoStreamError(err error) {
+	utils.Logger.Errorf("Video stream listener UDP server error: %v", err)
 }
@@ -148,7 +148,12 @@ func (vd *VideoDisplay) Stop() {
 		}()
 	}
 
-	// Cleanup SDL resources
+	// Cleanup SDL resources. The web display never started SDL, whose
+	// main loop is not running then, so sdl.Do would panic.
+	if vd.displayType != DisplayTypeNative {
+		utils.Logger.Info("Video display stopped")
+		return
+	}
 	sdl.Do(func() {
 		if vd.texture != nil {
 			vd.texture.Destroy()
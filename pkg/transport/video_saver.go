@@ -153,14 +153,21 @@ func (vs *VideoSaver) Close() error {
 
 // VideoRecorder combines video stream listening and saving
 type VideoRecorder struct {
-	listener    *VideoStreamListener
-	saver       *VideoSaver
-	mp4Recorder *MP4VideoRecorder
-	frameChan   <-chan VideoFrame
-	stopChan    chan bool
-	isRunning   bool
-	mutex       sync.Mutex
-	format      VideoFormat
+	listener      *VideoStreamListener
+	saver         *VideoSaver
+	mp4Recorder   *MP4VideoRecorder
+	frameChan     <-chan VideoFrame
+	stopChan      chan bool
+	framesDone    chan struct{} // Closed when processFrames returns
+	isRunning     bool
+	mutex         sync.Mutex
+	format        VideoFormat
+	savePath      string
+	telemetryHub  *TelemetryHub
+	telemetrySub  *StateSubscription
+	telemetryDone chan struct{}
+	telemetryFile *os.File
+	track         *TelemetryTrack
 }
 
 // NewVideoRecorder creates a new video recorder
@@ -181,6 +188,7 @@ func NewVideoRecorderWithFormatAndChannel(frameChan <-chan VideoFrame, savePath
 		stopChan:  make(chan bool),
 		isRunning: false,
 		format:    format,
+		savePath:  savePath,
 	}
 
 	// Initialize appropriate saver based on format
@@ -209,6 +217,7 @@ func NewVideoRecorderWithFormat(listenAddr, savePath string, format VideoFormat)
 		stopChan:  make(chan bool),
 		isRunning: false,
 		format:    format,
+		savePath:  savePath,
 	}
 
 	// Initialize appropriate saver based on format
@@ -224,6 +233,23 @@ func NewVideoRecorderWithFormat(listenAddr, savePath string, format VideoFormat)
 	return recorder, nil
 }
 
+// SetMuxer selects the MP4 muxer, "go" or "ffmpeg", of an MP4 recorder
+func (vr *VideoRecorder) SetMuxer(muxer string) error {
+	if vr.mp4Recorder == nil {
+		return fmt.Errorf("muxer can only be set for MP4 recordings, not %s", vr.format)
+	}
+	return vr.mp4Recorder.SetMuxer(muxer)
+}
+
+// SetTelemetry records the states published on hub as a telemetry track
+// next to the video, at TelemetryTrackPath, from the next StartRecording on.
+// A nil hub stops recording the track.
+func (vr *VideoRecorder) SetTelemetry(hub *TelemetryHub) {
+	vr.mutex.Lock()
+	defer vr.mutex.Unlock()
+	vr.telemetryHub = hub
+}
+
 // StartRecording starts both the video listener and recording
 func (vr *VideoRecorder) StartRecording() error {
 	vr.mutex.Lock()
@@ -257,8 +283,16 @@ func (vr *VideoRecorder) StartRecording() error {
 		return fmt.Errorf("failed to start recording: %w", err)
 	}
 
+	if vr.telemetryHub != nil {
+		if err := vr.startTelemetry(); err != nil {
+			// The video is worth keeping without its telemetry
+			utils.Logger.Errorf("Failed to start telemetry track: %v", err)
+		}
+	}
+
 	// Start frame processing goroutine
-	go vr.processFrames()
+	vr.framesDone = make(chan struct{})
+	go vr.processFrames(vr.track, vr.framesDone)
 
 	vr.isRunning = true
 	utils.Logger.Infof("Video recorder started (format: %s)", vr.format)
@@ -274,8 +308,10 @@ func (vr *VideoRecorder) StopRecording() error {
 		return fmt.Errorf("video recorder is not running")
 	}
 
-	// Signal stop
+	// Signal stop, and wait for the frame being saved so that nothing is
+	// written to the recording or the track once they are finished
 	close(vr.stopChan)
+	<-vr.framesDone
 
 	// Stop recording based on format
 	switch vr.format {
@@ -289,8 +325,16 @@ func (vr *VideoRecorder) StopRecording() error {
 		}
 	}
 
-	// Stop listener
-	vr.listener.Stop()
+	if vr.track != nil {
+		if err := vr.stopTelemetry(); err != nil {
+			utils.Logger.Errorf("Failed to finish telemetry track: %v", err)
+		}
+	}
+
+	// Stop listener, unless the frames come from an existing channel
+	if vr.listener != nil {
+		vr.listener.Stop()
+	}
 
 	vr.isRunning = false
 	vr.stopChan = make(chan bool) // Recreate stop channel for next use
@@ -299,8 +343,56 @@ func (vr *VideoRecorder) StopRecording() error {
 	return nil
 }
 
-// processFrames processes incoming video frames and saves them
-func (vr *VideoRecorder) processFrames() {
+// startTelemetry creates the telemetry track and feeds it the hub's states
+func (vr *VideoRecorder) startTelemetry() error {
+	path := TelemetryTrackPath(vr.savePath)
+	file, err := os.Create(path)
+	if err != nil {
+		return fmt.Errorf("failed to create telemetry file '%s': %w", path, err)
+	}
+	sub, err := vr.telemetryHub.Subscribe()
+	if err != nil {
+		file.Close()
+		return err
+	}
+
+	track := NewTelemetryTrack(file)
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for state := range sub.C {
+			track.AddState(state)
+		}
+	}()
+
+	vr.telemetryFile = file
+	vr.telemetrySub = sub
+	vr.telemetryDone = done
+	vr.track = track
+	utils.Logger.Infof("Recording telemetry to: %s", path)
+	return nil
+}
+
+// stopTelemetry writes the last entry of the telemetry track and closes it
+func (vr *VideoRecorder) stopTelemetry() error {
+	vr.telemetrySub.Close()
+	<-vr.telemetryDone
+
+	err := vr.track.Close()
+	if closeErr := vr.telemetryFile.Close(); err == nil && closeErr != nil {
+		err = fmt.Errorf("failed to close telemetry file: %w", closeErr)
+	}
+	vr.telemetrySub = nil
+	vr.telemetryDone = nil
+	vr.telemetryFile = nil
+	vr.track = nil
+	return err
+}
+
+// processFrames processes incoming video frames and saves them, adding an
+// entry for each to track unless it is nil. It closes done when it returns.
+func (vr *VideoRecorder) processFrames(track *TelemetryTrack, done chan<- struct{}) {
+	defer close(done)
 	for {
 		select {
 		case frame, ok := <-vr.frameChan:
@@ -323,6 +415,11 @@ func (vr *VideoRecorder) processFrames() {
 			if err != nil {
 				utils.Logger.Errorf("Failed to save frame: %v", err)
 			}
+			if track != nil {
+				if err := track.AddFrame(frame); err != nil {
+					utils.Logger.Errorf("Failed to add telemetry entry: %v", err)
+				}
+			}
 
 		case <-vr.stopChan:
 			utils.Logger.Info("Received stop signal, stopping frame processing")
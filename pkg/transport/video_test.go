@@ -1,6 +1,7 @@
 package transport
 
 import (
+	"context"
 	"fmt"
 	"net"
 	"reflect"
@@ -198,61 +199,106 @@ func TestVideoStreamListener_GetFrameChannel(t *testing.T) {
 	}
 }
 
-func TestVideoFrame_toRGBImage(t *testing.T) {
-	// Test with empty data - should return image with base color
-	vf := &VideoFrame{
-		Data:       []byte{},
-		IsKeyFrame: false,
+func TestVideoStreamListener_ReassemblesFrames(t *testing.T) {
+	listener, err := NewVideoStreamListener("127.0.0.1:11147")
+	if err != nil {
+		t.Fatalf("Failed to create video stream listener: %v", err)
 	}
-	img := vf.toRGBImage()
-	if img == nil {
-		t.Fatal("Expected image to be created")
+	defer listener.Stop()
+
+	addr := &net.UDPAddr{IP: net.ParseIP("192.168.10.1"), Port: 62512}
+	for _, packets := range testStream() {
+		for _, packet := range packets {
+			listener.onVideoStreamData(packet, addr)
+		}
 	}
 
-	// Check dimensions
-	bounds := img.Bounds()
-	if bounds.Dx() != defaultVideoFrameWidth || bounds.Dy() != defaultVideoFrameHeight {
-		t.Errorf("Expected dimensions %dx%d, got %dx%d", defaultVideoFrameWidth, defaultVideoFrameHeight, bounds.Dx(), bounds.Dy())
+	frameChan := listener.GetFrameChannel()
+	if len(frameChan) != 6 {
+		t.Fatalf("Expected 6 complete frames from %d packets, got %d", listener.Stats().Packets, len(frameChan))
 	}
 
-	// Check base color for non-keyframe (should be gray: R=30, G=30, B=30)
-	r, g, b, _ := img.At(0, 0).RGBA()
-	if r>>8 != 30 || g>>8 != 30 || b>>8 != 30 {
-		t.Errorf("Expected base color (30,30,30) for non-keyframe, got (%d,%d,%d)", r>>8, g>>8, b>>8)
+	first := <-frameChan
+	if !first.IsKeyFrame || len(first.NALUnits) != 3 {
+		t.Errorf("Expected a keyframe with SPS, PPS and IDR, got keyframe=%v with %d NAL units",
+			first.IsKeyFrame, len(first.NALUnits))
 	}
 
-	// Test with keyframe - should have different base color
-	vf.IsKeyFrame = true
-	imgKeyframe := vf.toRGBImage()
-	r2, g2, b2, _ := imgKeyframe.At(0, 0).RGBA()
-	if r2>>8 != 30 || g2>>8 != 90 || b2>>8 != 160 {
-		t.Errorf("Expected base color (30,90,160) for keyframe, got (%d,%d,%d)", r2>>8, g2>>8, b2>>8)
+	stats := listener.Stats()
+	if stats.Frames != 6 || stats.KeyFrames != 2 || stats.DroppedFrames != 0 {
+		t.Errorf("Unexpected stats: %+v", stats)
 	}
 
-	// Test with data - should extract RGB values and fill blocks
-	// Create data that will give predictable colors: R=255, G=128, B=64, repeated
-	testData := []byte{255, 128, 64, 255, 128, 64} // Two sets of RGB
-	vf = &VideoFrame{
-		Data:       testData,
-		IsKeyFrame: false,
+	sps, pps := listener.ParameterSets()
+	if len(sps) == 0 || len(pps) == 0 {
+		t.Error("Expected parameter sets to be available")
 	}
-	imgWithData := vf.toRGBImage()
+}
 
-	// Check that first block (0,0 to 7,7) has the expected color
-	r3, g3, b3, _ := imgWithData.At(0, 0).RGBA()
-	if r3>>8 != 255 || g3>>8 != 128 || b3>>8 != 64 {
-		t.Errorf("Expected color (255,128,64) in first block, got (%d,%d,%d)", r3>>8, g3>>8, b3>>8)
+func TestVideoStreamListenerNextKeyFrame(t *testing.T) {
+	listener, err := NewVideoStreamListener("127.0.0.1:11148")
+	if err != nil {
+		t.Fatalf("Failed to create video stream listener: %v", err)
+	}
+
+	result := make(chan VideoFrame, 1)
+	go func() {
+		frame, err := listener.NextKeyFrame(context.Background())
+		if err != nil {
+			t.Errorf("NextKeyFrame failed: %v", err)
+		}
+		result <- frame
+	}()
+	for waiting := 0; waiting == 0; {
+		time.Sleep(5 * time.Millisecond)
+		listener.keyFrameMu.Lock()
+		waiting = len(listener.keyFrameWaiters)
+		listener.keyFrameMu.Unlock()
 	}
 
-	// Check that second block (8,0 to 15,7) also has the same color (data cycles)
-	r4, g4, b4, _ := imgWithData.At(8, 0).RGBA()
-	if r4>>8 != 255 || g4>>8 != 128 || b4>>8 != 64 {
-		t.Errorf("Expected color (255,128,64) in second block, got (%d,%d,%d)", r4>>8, g4>>8, b4>>8)
+	listener.deliverKeyFrame([]VideoFrame{{SeqNum: 1}, {SeqNum: 2, IsKeyFrame: true}, {SeqNum: 3, IsKeyFrame: true}})
+	if frame := <-result; frame.SeqNum != 2 {
+		t.Errorf("Expected the first keyframe, got frame %d", frame.SeqNum)
 	}
 
-	// Check that a pixel in the middle of a block has the same color
-	r5, g5, b5, _ := imgWithData.At(4, 4).RGBA()
-	if r5>>8 != 255 || g5>>8 != 128 || b5>>8 != 64 {
-		t.Errorf("Expected color (255,128,64) in middle of block, got (%d,%d,%d)", r5>>8, g5>>8, b5>>8)
+	// A cancelled wait is forgotten
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	if _, err := listener.NextKeyFrame(ctx); err != context.DeadlineExceeded {
+		t.Errorf("Expected the deadline to be exceeded, got %v", err)
+	}
+	if len(listener.keyFrameWaiters) != 0 {
+		t.Errorf("Expected no waiters after the wait was cancelled, got %d", len(listener.keyFrameWaiters))
+	}
+
+	listener.Stop()
+	if _, err := listener.NextKeyFrame(context.Background()); err == nil {
+		t.Error("Expected an error once the listener is stopped")
+	}
+}
+
+func TestVideoStreamListenerNextKeyFrameRacesStop(t *testing.T) {
+	listener, err := NewVideoStreamListener("127.0.0.1:11149")
+	if err != nil {
+		t.Fatalf("Failed to create video stream listener: %v", err)
+	}
+
+	// Waits that start while Stop runs must be released or refused, never
+	// left waiting for a keyframe that cannot come
+	const waits = 50
+	done := make(chan struct{}, waits)
+	for i := 0; i < waits; i++ {
+		go func() {
+			listener.NextKeyFrame(context.Background())
+			done <- struct{}{}
+		}()
+	}
+	listener.Stop()
+	for i := 0; i < waits; i++ {
+		select {
+		case <-done:
+		case <-time.After(2 * time.Second):
+			t.Fatalf("Expected every wait to return after Stop, %d still waiting", waits-i)
+		}
 	}
 }
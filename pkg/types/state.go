@@ -1,7 +1,14 @@
 package types
 
+import "time"
+
 // State represents current telemetry state of the Tello drone
 type State struct {
+	Mid   int     `json:"mid"`   // ID of the detected mission pad, -1 if none (Tello EDU)
+	X     int     `json:"x"`     // X coordinate relative to the detected mission pad (cm)
+	Y     int     `json:"y"`     // Y coordinate relative to the detected mission pad (cm)
+	Z     int     `json:"z"`     // Z coordinate relative to the detected mission pad (cm)
+	Mpry  [3]int  `json:"mpry"`  // Pitch, roll and yaw relative to the detected mission pad (degrees)
 	Pitch int     `json:"pitch"` // Pitch angle of the drone in degrees
 	Roll  int     `json:"roll"`  // Roll angle of the drone in degrees
 	Yaw   int     `json:"yaw"`   // Yaw angle of the drone in degrees
@@ -18,4 +25,7 @@ type State struct {
 	Agx   float64 `json:"agx"`   // Acceleration in the X direction (g)
 	Agy   float64 `json:"agy"`   // Acceleration in the Y direction (g)
 	Agz   float64 `json:"agz"`   // Acceleration in the Z direction (g)
+
+	Extra    map[string]string `json:"extra,omitempty"`   // Keys this SDK does not know, e.g. from newer or EDU/RMTT firmware
+	Received time.Time         `json:"received,omitzero"` // When the state packet was received, zero if not from a packet
 }
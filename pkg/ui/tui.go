@@ -1,7 +1,9 @@
 package ui
 
 import (
+	"context"
 	"fmt"
+	"strconv"
 	"strings"
 	"time"
 
@@ -10,7 +12,9 @@ import (
 	tea "github.com/charmbracelet/bubbletea"
 	"github.com/charmbracelet/lipgloss"
 	"github.com/conceptcodes/dji-tello-sdk-go/pkg/ml"
+	"github.com/conceptcodes/dji-tello-sdk-go/pkg/navigation"
 	"github.com/conceptcodes/dji-tello-sdk-go/pkg/tello"
+	"github.com/conceptcodes/dji-tello-sdk-go/pkg/transport"
 	"github.com/conceptcodes/dji-tello-sdk-go/pkg/types"
 	"github.com/conceptcodes/dji-tello-sdk-go/pkg/ui/components/controls"
 	"github.com/conceptcodes/dji-tello-sdk-go/pkg/ui/components/layout"
@@ -19,6 +23,9 @@ import (
 	"github.com/conceptcodes/dji-tello-sdk-go/pkg/ui/components/telemetry"
 )
 
+// stateRefreshInterval is how often the dashboard takes a new state
+const stateRefreshInterval = 100 * time.Millisecond
+
 // Styles
 var (
 	// Colors
@@ -106,6 +113,10 @@ type stateUpdateMsg struct {
 	state *types.State
 }
 
+type rtlDoneMsg struct {
+	err error
+}
+
 type TuiModel struct {
 	commander             tello.TelloCommander
 	textInput             textinput.Model
@@ -125,6 +136,10 @@ type TuiModel struct {
 	speed                 int
 	temp                  int
 	flightTime            int
+	odometry              *navigation.Odometry
+	pose                  navigation.Pose
+	states                *transport.StateSubscription
+	keyInput              func(key string) bool
 	inputMode             bool // true = typing command, false = flight control
 	showEnhancedTelemetry bool // true = enhanced dashboard, false = basic telemetry
 	showSafetyDashboard   bool // true = safety dashboard visible
@@ -155,8 +170,17 @@ func NewTuiModel(commander tello.TelloCommander) TuiModel {
 	// Initialize help system
 	helpSystem := controls.NewHelpSystem(80, 24)
 
+	// Only the latest state matters for display
+	var states *transport.StateSubscription
+	if commander != nil {
+		if hub := commander.GetTelemetryHub(); hub != nil {
+			states, _ = hub.Subscribe(transport.WithBufferSize(1), transport.WithMinInterval(stateRefreshInterval))
+		}
+	}
+
 	return TuiModel{
 		commander:             commander,
+		states:                states,
 		textInput:             ti,
 		viewport:              vp,
 		dashboard:             dashboard,
@@ -180,9 +204,24 @@ func (m TuiModel) Init() tea.Cmd {
 		textinput.Blink,
 		m.tickCmd(),
 		m.connectCmd(),
+		m.waitForStateCmd(),
 	)
 }
 
+// waitForStateCmd delivers the next state from the drone
+func (m TuiModel) waitForStateCmd() tea.Cmd {
+	if m.states == nil {
+		return nil
+	}
+	return func() tea.Msg {
+		state, ok := <-m.states.C
+		if !ok {
+			return nil
+		}
+		return stateUpdateMsg{state: state}
+	}
+}
+
 func (m TuiModel) connectCmd() tea.Cmd {
 	return func() tea.Msg {
 		// Try to initialize, but don't fail hard if it times out
@@ -241,6 +280,9 @@ func (m TuiModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		}
 
 		// Flight Mode
+		if m.keyInput != nil && m.keyInput(msg.String()) {
+			return m, nil
+		}
 		switch msg.String() {
 		case "/":
 			m.inputMode = true
@@ -268,9 +310,16 @@ func (m TuiModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			go m.commander.CounterClockwise(15)
 		case "right":
 			go m.commander.Clockwise(15)
+		case "h":
+			if m.odometry == nil {
+				m.logs = append(m.logs, m.formatLog("WARN", "Return to launch unavailable: odometry is not enabled", styleLogWarn))
+				break
+			}
+			m.logs = append(m.logs, m.formatLog("INFO", "Returning to launch...", styleLogInfo))
+			return m, m.returnToLaunchCmd()
 		case " ": // Spacebar
 			m.logs = append(m.logs, m.formatLog("WARN", "EMERGENCY STOP", styleLogError))
-			go m.commander.Emergency()
+			go m.commander.EmergencyNow()
 		case "f1":
 			if m.helpSystem != nil {
 				// Update help context based on current state
@@ -375,12 +424,19 @@ func (m TuiModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		m.updateTelemetry()
 		return m, m.tickCmd()
 
+	case rtlDoneMsg:
+		if msg.err != nil {
+			m.logs = append(m.logs, m.formatLog("ERROR", fmt.Sprintf("Return to launch failed: %v", msg.err), styleLogError))
+		} else {
+			m.logs = append(m.logs, m.formatLog("INFO", "Return to launch complete", styleLogInfo))
+		}
+
 	case stateUpdateMsg:
 		if msg.state != nil && m.dashboard != nil {
 			m.dashboard.UpdateState(msg.state)
 			m.updateTelemetry() // Update basic telemetry values
 		}
-		return m, nil
+		return m, m.waitForStateCmd()
 	}
 
 	// Update viewport content
@@ -434,16 +490,13 @@ func (m TuiModel) View() string {
 			telemetryPanel = stylePanel.
 				Width(dashboardWidth).
 				Height(dashboardHeight).
-				Render(m.dashboard.Render())
+				Render(m.withPose(m.dashboard.Render()))
 		} else {
 			// Basic telemetry
 			var telemetryContent string
 			if m.dashboard != nil {
 				telemetryContent = lipgloss.JoinVertical(lipgloss.Left,
-					m.renderStat("Height", fmt.Sprintf("%d cm", m.heightCm)),
-					m.renderStat("Speed", fmt.Sprintf("%d cm/s", m.speed)),
-					m.renderStat("Temp", fmt.Sprintf("%d °C", m.temp)),
-					m.renderStat("Time", fmt.Sprintf("%ds", m.flightTime)),
+					m.renderStats(),
 					"",
 					styleTitle.Render("CONTROLS"),
 					m.renderStat("[W/S]", "Fwd/Back"),
@@ -452,6 +505,7 @@ func (m TuiModel) View() string {
 					m.renderStat("[←/→]", "Rotate"),
 					m.renderStat("[T/L]", "Takeoff/Land"),
 					m.renderStat("[Space]", "Emergency"),
+					m.renderStat("[H]", "Return Home"),
 					m.renderStat("[/]", "Command Mode"),
 					m.renderStat("[F5]", "Toggle Telemetry"),
 				)
@@ -469,10 +523,7 @@ func (m TuiModel) View() string {
 			} else {
 				// Fallback if dashboard is nil
 				telemetryContent = lipgloss.JoinVertical(lipgloss.Left,
-					m.renderStat("Height", fmt.Sprintf("%d cm", m.heightCm)),
-					m.renderStat("Speed", fmt.Sprintf("%d cm/s", m.speed)),
-					m.renderStat("Temp", fmt.Sprintf("%d °C", m.temp)),
-					m.renderStat("Time", fmt.Sprintf("%ds", m.flightTime)),
+					m.renderStats(),
 					"",
 					styleTitle.Render("CONTROLS"),
 					m.renderStat("[W/S]", "Fwd/Back"),
@@ -481,6 +532,7 @@ func (m TuiModel) View() string {
 					m.renderStat("[←/→]", "Rotate"),
 					m.renderStat("[T/L]", "Takeoff/Land"),
 					m.renderStat("[Space]", "Emergency"),
+					m.renderStat("[H]", "Return Home"),
 					m.renderStat("[/]", "Command Mode"),
 					m.renderStat("[F5]", "Toggle Telemetry"),
 				)
@@ -571,6 +623,30 @@ func (m TuiModel) renderStat(label, value string) string {
 	)
 }
 
+// renderStats renders the basic telemetry values, followed by the pose
+// estimate when odometry is enabled
+func (m TuiModel) renderStats() string {
+	return m.withPose(lipgloss.JoinVertical(lipgloss.Left,
+		m.renderStat("Height", fmt.Sprintf("%d cm", m.heightCm)),
+		m.renderStat("Speed", fmt.Sprintf("%d cm/s", m.speed)),
+		m.renderStat("Temp", fmt.Sprintf("%d °C", m.temp)),
+		m.renderStat("Time", fmt.Sprintf("%ds", m.flightTime)),
+	))
+}
+
+// withPose appends the pose relative to the takeoff point to content
+func (m TuiModel) withPose(content string) string {
+	if m.odometry == nil {
+		return content
+	}
+	return lipgloss.JoinVertical(lipgloss.Left,
+		content,
+		m.renderStat("Position", fmt.Sprintf("%.0f, %.0f cm", m.pose.X, m.pose.Y)),
+		m.renderStat("Heading", fmt.Sprintf("%.0f°", m.pose.Yaw)),
+		m.renderStat("Home", fmt.Sprintf("%.0f cm", m.pose.HomeDistance())),
+	)
+}
+
 func (m TuiModel) formatLog(level, msg string, style lipgloss.Style) string {
 	timestamp := time.Now().Format("15:04:05")
 	return fmt.Sprintf("%s %s %s",
@@ -594,6 +670,33 @@ func (m *TuiModel) updateTelemetry() {
 		m.temp = state.Temph
 		m.flightTime = state.Time
 	}
+
+	if m.odometry != nil {
+		m.pose = m.odometry.Pose()
+	}
+}
+
+// SetOdometry shows the pose estimate in the telemetry panel and enables
+// return to launch with the H key. The odometry must be registered with the
+// commander via tello.WithFlightRecorder.
+func (m *TuiModel) SetOdometry(odometry *navigation.Odometry) {
+	m.odometry = odometry
+}
+
+// SetKeyInput hands flight-mode keys to fn before the built-in flight
+// controls, e.g. a gamepad.KeyboardSource's Key. Keys fn reports as
+// handled do nothing else.
+func (m *TuiModel) SetKeyInput(fn func(key string) bool) {
+	m.keyInput = fn
+}
+
+// returnToLaunchCmd flies the drone home and reports the result as a rtlDoneMsg
+func (m TuiModel) returnToLaunchCmd() tea.Cmd {
+	commander, odometry := m.commander, m.odometry
+	return func() tea.Msg {
+		err := navigation.ReturnToLaunch(context.Background(), commander, odometry, navigation.DefaultRTLConfig())
+		return rtlDoneMsg{err: err}
+	}
 }
 
 // updateMLState updates ML visualization state from ML results
@@ -682,13 +785,10 @@ func (m *TuiModel) updateLayoutPanes() {
 	// Update telemetry pane
 	var telemetryContent string
 	if m.showEnhancedTelemetry && m.dashboard != nil {
-		telemetryContent = m.dashboard.Render()
+		telemetryContent = m.withPose(m.dashboard.Render())
 	} else {
 		telemetryContent = lipgloss.JoinVertical(lipgloss.Left,
-			m.renderStat("Height", fmt.Sprintf("%d cm", m.heightCm)),
-			m.renderStat("Speed", fmt.Sprintf("%d cm/s", m.speed)),
-			m.renderStat("Temp", fmt.Sprintf("%d °C", m.temp)),
-			m.renderStat("Time", fmt.Sprintf("%ds", m.flightTime)),
+			m.renderStats(),
 		)
 	}
 	m.layoutManager.SetPaneContent("telemetry", telemetryContent)
@@ -768,7 +868,7 @@ func (m *TuiModel) executeCommand(cmd string) {
 	case "land":
 		m.commander.Land()
 	case "emergency":
-		m.commander.Emergency()
+		m.commander.EmergencyNow()
 	case "streamon":
 		m.commander.StreamOn()
 	case "streamoff":
@@ -792,6 +892,21 @@ func (m *TuiModel) executeCommand(cmd string) {
 			return
 		}
 		m.commander.Flip(tello.FlipDirection(direction))
+	case "mon":
+		m.commander.EnableMissionPads(true)
+	case "moff":
+		m.commander.EnableMissionPads(false)
+	case "mdirection":
+		if len(parts) < 2 {
+			m.logs = append(m.logs, m.formatLog("ERROR", "Usage: mdirection <0/1/2>", styleLogError))
+			return
+		}
+		direction, err := strconv.Atoi(parts[1])
+		if err != nil {
+			m.logs = append(m.logs, m.formatLog("ERROR", "Invalid direction. Use 0 (down), 1 (forward) or 2 (both)", styleLogError))
+			return
+		}
+		m.commander.SetMissionPadDirection(tello.MissionPadDirection(direction))
 	default:
 		m.logs = append(m.logs, m.formatLog("ERROR", fmt.Sprintf("Unknown command: %s", command), styleLogError))
 	}
@@ -2,6 +2,8 @@ package utils
 
 import (
 	"testing"
+
+	"github.com/conceptcodes/dji-tello-sdk-go/pkg/types"
 )
 
 func BenchmarkParseInt(b *testing.B) {
@@ -27,12 +29,24 @@ func BenchmarkParseFloat(b *testing.B) {
 func BenchmarkParseState(b *testing.B) {
 	testData := "pitch:10;roll:-5;yaw:180;vgx:20;vgy:30;vgz:40;templ:20;temph:30;tof:300;h:100;bat:85;baro:1013.25;time:120;agx:0.1;agy:0.2;agz:0.3;"
 
+	b.ReportAllocs()
 	b.ResetTimer()
 	for i := 0; i < b.N; i++ {
 		ParseState(testData)
 	}
 }
 
+func BenchmarkParseStateInto(b *testing.B) {
+	testData := "mid:-1;x:0;y:0;z:0;mpry:0,0,0;pitch:10;roll:-5;yaw:180;vgx:20;vgy:30;vgz:40;templ:20;temph:30;tof:300;h:100;bat:85;baro:1013.25;time:120;agx:0.1;agy:0.2;agz:0.3;"
+	state := &types.State{}
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		ParseStateInto(state, testData)
+	}
+}
+
 func BenchmarkParseStatePartial(b *testing.B) {
 	testData := "pitch:15;bat:90;time:200;"
 
@@ -16,32 +16,101 @@ func ParseFloat(value string) (float64, error) {
 	return strconv.ParseFloat(value, 64)
 }
 
+// ParseTriple parses a comma separated "a,b,c" integer triple such as the mpry field
+func ParseTriple(value string) ([3]int, error) {
+	var triple [3]int
+	rest := value
+	for i := range triple {
+		part, tail, found := strings.Cut(rest, ",")
+		if found != (i < len(triple)-1) {
+			return triple, fmt.Errorf("expected 3 comma separated values, got %q", value)
+		}
+		val, err := ParseInt(strings.TrimSpace(part))
+		if err != nil {
+			return triple, err
+		}
+		triple[i] = val
+		rest = tail
+	}
+	return triple, nil
+}
+
+// ParseState parses a state packet such as "pitch:0;roll:0;...;bat:87;".
+// Malformed parts are skipped and keys without a State field are kept in
+// Extra. It only fails when none of the packet's parts could be read.
 func ParseState(data string) (*types.State, error) {
 	state := &types.State{}
-
-	if data == "" {
-		return state, nil
+	if err := ParseStateInto(state, data); err != nil {
+		return nil, err
 	}
+	return state, nil
+}
+
+// ParseStateInto parses a state packet like ParseState, overwriting state and
+// reusing its Extra map. It does not allocate unless the packet has keys the
+// map has no room for, so a reused state can be parsed at the packet rate.
+// It suits a single reader that is done with the state before the next
+// packet; the state listener allocates instead, as it shares every state.
+func ParseStateInto(state *types.State, data string) error {
+	extra := state.Extra
+	clear(extra)
+	*state = types.State{Mid: -1, Extra: extra} // No mission pad unless the packet says so
 
-	parts := strings.Split(data, ";")
-	for _, part := range parts {
+	parsed, malformed := 0, 0
+	for rest := data; rest != ""; {
+		var part string
+		part, rest, _ = strings.Cut(rest, ";")
 		part = strings.TrimSpace(part)
 		if part == "" {
 			continue // Skip empty parts
 		}
 
-		telemetryData := strings.Split(part, ":")
-		if len(telemetryData) != 2 {
-			return nil, fmt.Errorf("invalid telemetry data: %s", part)
-		}
-		if len(telemetryData[0]) == 0 || len(telemetryData[1]) == 0 {
-			return nil, fmt.Errorf("invalid telemetry data: %s", part)
+		key, value, found := strings.Cut(part, ":")
+		key = strings.TrimSpace(key)
+		value = strings.TrimSpace(value)
+		if !found || key == "" || value == "" {
+			Logger.Debugf("skipping invalid telemetry data: %s", part)
+			malformed++
+			continue
 		}
-
-		key := strings.TrimSpace(telemetryData[0])
-		value := strings.TrimSpace(telemetryData[1])
+		parsed++
 
 		switch key {
+		case "mid":
+			val, err := ParseInt(value)
+			if err != nil {
+				Logger.Debugf("error parsing mid value: %v", err)
+				continue
+			}
+			state.Mid = val
+		case "x":
+			val, err := ParseInt(value)
+			if err != nil {
+				Logger.Debugf("error parsing x value: %v", err)
+				continue
+			}
+			state.X = val
+		case "y":
+			val, err := ParseInt(value)
+			if err != nil {
+				Logger.Debugf("error parsing y value: %v", err)
+				continue
+			}
+			state.Y = val
+		case "z":
+			val, err := ParseInt(value)
+			if err != nil {
+				Logger.Debugf("error parsing z value: %v", err)
+				continue
+			}
+			state.Z = val
+		case "mpry":
+			val, err := ParseTriple(value)
+			if err != nil {
+				Logger.Debugf("error parsing mpry value: %v", err)
+				continue
+			}
+			state.Mpry = val
 		case "pitch":
 			val, err := ParseInt(value)
 			if err != nil {
@@ -155,9 +224,15 @@ func ParseState(data string) (*types.State, error) {
 			}
 			state.Agz = val
 		default:
-			continue
+			if state.Extra == nil {
+				state.Extra = make(map[string]string)
+			}
+			state.Extra[key] = value
 		}
 	}
 
-	return state, nil
+	if parsed == 0 && malformed > 0 {
+		return fmt.Errorf("invalid telemetry data: %s", data)
+	}
+	return nil
 }
@@ -1,6 +1,7 @@
 package utils
 
 import (
+	"maps"
 	"testing"
 
 	"github.com/conceptcodes/dji-tello-sdk-go/pkg/types"
@@ -78,6 +79,22 @@ func TestParseFloat(t *testing.T) {
 	}
 }
 
+func TestParseTriple(t *testing.T) {
+	result, err := ParseTriple("1,-2,3")
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if result != [3]int{1, -2, 3} {
+		t.Errorf("Expected [1 -2 3], got %v", result)
+	}
+
+	for _, input := range []string{"1,2", "1,2,3,4", "a,b,c", ""} {
+		if _, err := ParseTriple(input); err == nil {
+			t.Errorf("Expected error for input '%s', got nil", input)
+		}
+	}
+}
+
 func TestParseState(t *testing.T) {
 	tests := []struct {
 		name     string
@@ -89,6 +106,7 @@ func TestParseState(t *testing.T) {
 			name:  "Complete valid state",
 			input: "pitch:10;roll:-5;yaw:180;vgx:20;vgy:30;vgz:40;templ:20;temph:30;tof:300;h:100;bat:85;baro:1013.25;time:120;agx:0.1;agy:0.2;agz:0.3;",
 			expected: &types.State{
+				Mid:   -1,
 				Pitch: 10, Roll: -5, Yaw: 180,
 				Vgx: 20, Vgy: 30, Vgz: 40,
 				Templ: 20, Temph: 30,
@@ -98,10 +116,28 @@ func TestParseState(t *testing.T) {
 			},
 			hasError: false,
 		},
+		{
+			name:  "Mission pad state",
+			input: "mid:3;x:-45;y:12;z:100;mpry:1,-2,90;pitch:0;roll:0;yaw:90;h:100;bat:70;",
+			expected: &types.State{
+				Mid: 3, X: -45, Y: 12, Z: 100, Mpry: [3]int{1, -2, 90},
+				Yaw: 90, H: 100, Bat: 70,
+			},
+			hasError: false,
+		},
+		{
+			name:  "No mission pad detected",
+			input: "mid:-1;x:0;y:0;z:0;mpry:0,0,0;bat:70;",
+			expected: &types.State{
+				Mid: -1, Bat: 70,
+			},
+			hasError: false,
+		},
 		{
 			name:  "Partial state",
 			input: "pitch:15;bat:90;time:200;",
 			expected: &types.State{
+				Mid:   -1,
 				Pitch: 15, Bat: 90, Time: 200,
 			},
 			hasError: false,
@@ -109,24 +145,39 @@ func TestParseState(t *testing.T) {
 		{
 			name:     "Empty input",
 			input:    "",
-			expected: &types.State{},
+			expected: &types.State{Mid: -1},
 			hasError: false,
 		},
 		{
-			name:     "Invalid format - missing colon",
-			input:    "pitch10;roll:20;",
-			expected: nil,
-			hasError: true,
+			name:  "Invalid format - missing colon",
+			input: "pitch10;roll:20;",
+			expected: &types.State{
+				Mid:  -1,
+				Roll: 20, // Malformed parts are skipped
+			},
+			hasError: false,
 		},
 		{
-			name:     "Invalid format - empty key",
-			input:    ":10;roll:20;",
-			expected: nil,
-			hasError: true,
+			name:  "Invalid format - empty key",
+			input: ":10;roll:20;",
+			expected: &types.State{
+				Mid:  -1,
+				Roll: 20,
+			},
+			hasError: false,
 		},
 		{
-			name:     "Invalid format - empty value",
-			input:    "pitch:;roll:20;",
+			name:  "Invalid format - empty value",
+			input: "pitch:;roll:20;",
+			expected: &types.State{
+				Mid:  -1,
+				Roll: 20,
+			},
+			hasError: false,
+		},
+		{
+			name:     "Invalid format - nothing readable",
+			input:    "pitch10;:20;roll:;",
 			expected: nil,
 			hasError: true,
 		},
@@ -134,15 +185,27 @@ func TestParseState(t *testing.T) {
 			name:  "Invalid numeric values",
 			input: "pitch:abc;roll:20;bat:xyz;",
 			expected: &types.State{
+				Mid:  -1,
 				Roll: 20, // Valid values should still be parsed
 			},
 			hasError: false, // Should not error, just skip invalid values
 		},
 		{
-			name:  "Unknown keys should be ignored",
+			name:  "Unknown keys are kept in Extra",
 			input: "pitch:10;unknown:123;roll:20;",
 			expected: &types.State{
+				Mid:   -1,
 				Pitch: 10, Roll: 20,
+				Extra: map[string]string{"unknown": "123"},
+			},
+			hasError: false,
+		},
+		{
+			name:  "Firmware specific keys",
+			input: "mid:-2;x:-200;y:-200;z:-200;mpry:0,0,0;pitch:1;bat:64;ext:tof:8190;wifi: 90 ;",
+			expected: &types.State{
+				Mid: -2, X: -200, Y: -200, Z: -200, Pitch: 1, Bat: 64,
+				Extra: map[string]string{"ext": "tof:8190", "wifi": "90"},
 			},
 			hasError: false,
 		},
@@ -150,6 +213,7 @@ func TestParseState(t *testing.T) {
 			name:  "State with extra whitespace",
 			input: " pitch : 10 ; roll : 20 ; ",
 			expected: &types.State{
+				Mid:   -1,
 				Pitch: 10, Roll: 20,
 			},
 			hasError: false,
@@ -181,6 +245,16 @@ func TestParseState(t *testing.T) {
 
 				if result != nil && test.expected != nil {
 					// Compare individual fields
+					if result.Mid != test.expected.Mid {
+						t.Errorf("Expected Mid %d, got %d", test.expected.Mid, result.Mid)
+					}
+					if result.X != test.expected.X || result.Y != test.expected.Y || result.Z != test.expected.Z {
+						t.Errorf("Expected pad position (%d, %d, %d), got (%d, %d, %d)",
+							test.expected.X, test.expected.Y, test.expected.Z, result.X, result.Y, result.Z)
+					}
+					if result.Mpry != test.expected.Mpry {
+						t.Errorf("Expected Mpry %v, got %v", test.expected.Mpry, result.Mpry)
+					}
 					if result.Pitch != test.expected.Pitch {
 						t.Errorf("Expected Pitch %d, got %d", test.expected.Pitch, result.Pitch)
 					}
@@ -229,8 +303,45 @@ func TestParseState(t *testing.T) {
 					if result.Agz != test.expected.Agz {
 						t.Errorf("Expected Agz %f, got %f", test.expected.Agz, result.Agz)
 					}
+					if !maps.Equal(result.Extra, test.expected.Extra) {
+						t.Errorf("Expected Extra %v, got %v", test.expected.Extra, result.Extra)
+					}
 				}
 			}
 		})
 	}
 }
+
+func TestParseStateInto(t *testing.T) {
+	state := &types.State{}
+	if err := ParseStateInto(state, "pitch:10;bat:80;unknown:1;"); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if state.Pitch != 10 || state.Bat != 80 || state.Extra["unknown"] != "1" {
+		t.Fatalf("Unexpected state %+v", state)
+	}
+
+	// Parsing again overwrites every field, including Extra
+	if err := ParseStateInto(state, "bat:79;other:2;"); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if state.Pitch != 0 || state.Bat != 79 {
+		t.Errorf("Expected only the new packet's fields, got %+v", state)
+	}
+	if !maps.Equal(state.Extra, map[string]string{"other": "2"}) {
+		t.Errorf("Expected Extra to hold only the new packet's keys, got %v", state.Extra)
+	}
+}
+
+func TestParseStateIntoDoesNotAllocate(t *testing.T) {
+	data := "mid:-1;x:0;y:0;z:0;mpry:0,0,0;pitch:10;roll:-5;yaw:180;vgx:20;vgy:30;vgz:40;templ:20;temph:30;tof:300;h:100;bat:85;baro:1013.25;time:120;agx:0.1;agy:0.2;agz:0.3;unknown:1;"
+	state := &types.State{}
+	ParseStateInto(state, data)
+
+	allocs := testing.AllocsPerRun(100, func() {
+		ParseStateInto(state, data)
+	})
+	if allocs != 0 {
+		t.Errorf("Expected no allocations, got %v per packet", allocs)
+	}
+}
@@ -0,0 +1,408 @@
+package web
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/conceptcodes/dji-tello-sdk-go/pkg/safety"
+	"github.com/conceptcodes/dji-tello-sdk-go/pkg/transport"
+	"github.com/conceptcodes/dji-tello-sdk-go/pkg/types"
+	"github.com/conceptcodes/dji-tello-sdk-go/pkg/utils"
+)
+
+// Live event names, sent as the event types of the /api/events stream
+const (
+	EventTelemetry  = "telemetry"  // TelemetryData
+	EventStatus     = "status"     // SystemStatus
+	EventHUD        = "hud"        // HUDData
+	EventMiniStats  = "ministats"  // MiniStatsData
+	EventDetections = "detections" // []Detection
+	EventConnection = "connection" // ConnectionStatus
+	EventSafety     = "safety"     // safety.SafetyEvent, one event per occurrence
+)
+
+const (
+	// liveStateInterval caps how often the drone's state is pushed
+	liveStateInterval = 100 * time.Millisecond
+	// liveRefreshInterval is how often data not driven by the state stream,
+	// like the clock and connection, is checked for changes
+	liveRefreshInterval = time.Second
+	// liveKeepAlive is how often an idle stream sends a comment, so proxies
+	// do not close it
+	liveKeepAlive = 15 * time.Second
+	// liveWriteTimeout drops a client that cannot take an update in time
+	liveWriteTimeout = 10 * time.Second
+	// maxQueuedSafetyEvents bounds the safety events a slow client buffers
+	maxQueuedSafetyEvents = 32
+)
+
+// liveEvent is an update rendered once and shared by all clients
+type liveEvent struct {
+	name string
+	html []byte // Fragment swapped in by the HTMX templates
+	json []byte
+}
+
+// eventClient buffers the updates of one stream. Updates of the same state
+// replace each other, so a slow client skips to the latest one instead of
+// falling behind; safety events are queued, losing the oldest when full.
+type eventClient struct {
+	json   bool
+	notify chan struct{}
+
+	mu      sync.Mutex
+	latest  map[string]*liveEvent
+	order   []string // Names in latest, in the order they were updated
+	queue   []*liveEvent
+	dropped uint64
+}
+
+func (c *eventClient) offer(event *liveEvent, coalesce bool) {
+	c.mu.Lock()
+	if coalesce {
+		if _, pending := c.latest[event.name]; pending {
+			c.dropped++
+		} else {
+			c.order = append(c.order, event.name)
+		}
+		c.latest[event.name] = event
+	} else {
+		if len(c.queue) == maxQueuedSafetyEvents {
+			c.queue = c.queue[1:]
+			c.dropped++
+		}
+		c.queue = append(c.queue, event)
+	}
+	c.mu.Unlock()
+
+	select {
+	case c.notify <- struct{}{}:
+	default:
+	}
+}
+
+// take returns the buffered updates, safety events first
+func (c *eventClient) take() []*liveEvent {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	events := c.queue
+	c.queue = nil
+	for _, name := range c.order {
+		events = append(events, c.latest[name])
+	}
+	c.order = c.order[:0]
+	clear(c.latest)
+	return events
+}
+
+// eventBroker fans updates out to the streams. The producer runs only while
+// at least one client is connected.
+type eventBroker struct {
+	run func(ctx context.Context)
+
+	mu      sync.Mutex
+	clients map[*eventClient]struct{}
+	last    map[string]*liveEvent // Last update of each state, sent to new clients
+	cancel  context.CancelFunc
+	done    chan struct{}
+}
+
+func newEventBroker(run func(ctx context.Context)) *eventBroker {
+	return &eventBroker{
+		run:     run,
+		clients: make(map[*eventClient]struct{}),
+		last:    make(map[string]*liveEvent),
+	}
+}
+
+// subscribe adds a client, starting the producer for the first one
+func (b *eventBroker) subscribe(asJSON bool) *eventClient {
+	client := &eventClient{
+		json:   asJSON,
+		notify: make(chan struct{}, 1),
+		latest: make(map[string]*liveEvent),
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	for _, event := range b.last {
+		client.offer(event, true)
+	}
+	b.clients[client] = struct{}{}
+	if b.cancel == nil {
+		ctx, cancel := context.WithCancel(context.Background())
+		done := make(chan struct{})
+		b.cancel, b.done = cancel, done
+		go func() {
+			defer close(done)
+			b.run(ctx)
+		}()
+	}
+	return client
+}
+
+// unsubscribe removes a client, stopping the producer after the last one
+func (b *eventBroker) unsubscribe(client *eventClient) {
+	b.mu.Lock()
+	delete(b.clients, client)
+	if len(b.clients) > 0 || b.cancel == nil {
+		b.mu.Unlock()
+		return
+	}
+	cancel, done := b.cancel, b.done
+	b.cancel, b.done = nil, nil
+	clear(b.last) // Stale once nobody is watching
+	b.mu.Unlock()
+
+	cancel()
+	<-done
+}
+
+// active reports whether any client is connected
+func (b *eventBroker) active() bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return len(b.clients) > 0
+}
+
+// publishState sends an update of a state unless it is unchanged
+func (b *eventBroker) publishState(event *liveEvent) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if last, ok := b.last[event.name]; ok && bytes.Equal(last.json, event.json) {
+		return
+	}
+	b.last[event.name] = event
+	for client := range b.clients {
+		client.offer(event, true)
+	}
+}
+
+// publishEvent sends a one-off event to the connected clients
+func (b *eventBroker) publishEvent(event *liveEvent) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	for client := range b.clients {
+		client.offer(event, false)
+	}
+}
+
+// handleEvents streams live updates as Server-Sent Events. Each event
+// carries the HTML fragment its panel swaps in, or JSON with ?format=json.
+func (ws *WebServer) handleEvents(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	var asJSON bool
+	switch format := r.URL.Query().Get("format"); format {
+	case "", "html":
+	case "json":
+		asJSON = true
+	default:
+		http.Error(w, fmt.Sprintf("Unknown format '%s'", format), http.StatusBadRequest)
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("X-Accel-Buffering", "no")
+	rc := http.NewResponseController(w)
+	if _, err := fmt.Fprint(w, "retry: 2000\n\n"); err != nil {
+		return
+	}
+	if err := rc.Flush(); err != nil {
+		utils.Logger.Errorf("Live events need a flushable response: %v", err)
+		return
+	}
+
+	client := ws.events.subscribe(asJSON)
+	defer func() {
+		ws.events.unsubscribe(client)
+		if client.dropped > 0 {
+			utils.Logger.Debugf("Live events client %s skipped %d updates", r.RemoteAddr, client.dropped)
+		}
+	}()
+
+	keepAlive := time.NewTicker(liveKeepAlive)
+	defer keepAlive.Stop()
+
+	for {
+		var events []*liveEvent
+		select {
+		case <-r.Context().Done():
+			return
+		case <-client.notify:
+			if events = client.take(); len(events) == 0 {
+				continue // Already sent with the previous notification
+			}
+		case <-keepAlive.C:
+		}
+
+		rc.SetWriteDeadline(time.Now().Add(liveWriteTimeout))
+		var err error
+		if len(events) == 0 {
+			_, err = fmt.Fprint(w, ": keep-alive\n\n")
+		}
+		for _, event := range events {
+			data := event.html
+			if asJSON {
+				data = event.json
+			}
+			if err = writeSSE(w, event.name, data); err != nil {
+				break
+			}
+		}
+		if err == nil {
+			err = rc.Flush()
+		}
+		if err != nil {
+			utils.Logger.Debugf("Live events client %s disconnected: %v", r.RemoteAddr, err)
+			return
+		}
+	}
+}
+
+// writeSSE writes one event, splitting data over as many data lines as it has
+func writeSSE(w http.ResponseWriter, name string, data []byte) error {
+	var buf strings.Builder
+	buf.WriteString("event: " + name + "\n")
+	for _, line := range strings.Split(strings.TrimRight(string(data), "\n"), "\n") {
+		buf.WriteString("data: " + strings.TrimSuffix(line, "\r") + "\n")
+	}
+	buf.WriteString("\n")
+	_, err := fmt.Fprint(w, buf.String())
+	return err
+}
+
+// streamLiveEvents produces updates while clients are connected: the flight
+// data whenever the drone reports a state, and everything else once a second.
+// Unchanged updates are dropped by the broker.
+func (ws *WebServer) streamLiveEvents(ctx context.Context) {
+	var states <-chan *types.State
+	if hub := ws.telemetryHub(); hub != nil {
+		sub, err := hub.Subscribe(transport.WithBufferSize(1), transport.WithMinInterval(liveStateInterval))
+		if err != nil {
+			utils.Logger.Warnf("Live events fall back to polling the drone: %v", err)
+		} else {
+			defer sub.Close()
+			states = sub.C
+		}
+	}
+
+	ticker := time.NewTicker(liveRefreshInterval)
+	defer ticker.Stop()
+
+	ws.publishFlightData()
+	ws.publishStatus()
+	ws.publishDetections()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case _, ok := <-states:
+			if !ok {
+				states = nil
+				continue
+			}
+			ws.publishFlightData()
+		case <-ticker.C:
+			if states == nil {
+				ws.publishFlightData()
+			} else {
+				ws.publishState(EventHUD, "feed_hud.html", ws.getHUDData())
+			}
+			ws.publishStatus()
+		}
+	}
+}
+
+// telemetryHub returns the commander's state hub, or nil without one
+func (ws *WebServer) telemetryHub() *transport.TelemetryHub {
+	if ws.commander == nil {
+		return nil
+	}
+	return ws.commander.GetTelemetryHub()
+}
+
+// publishFlightData pushes the panels that follow the drone's state
+func (ws *WebServer) publishFlightData() {
+	ws.publishState(EventTelemetry, "telemetry.html", ws.getTelemetryData())
+	ws.publishState(EventMiniStats, "feed_ministats.html", ws.getMiniStatsData())
+	ws.publishState(EventHUD, "feed_hud.html", ws.getHUDData())
+}
+
+// publishStatus pushes the system and connection status
+func (ws *WebServer) publishStatus() {
+	ws.publishState(EventStatus, "status.html", ws.getSystemStatus())
+	if ws.connection != nil {
+		ws.publishState(EventConnection, "", ws.connection.Status())
+	}
+}
+
+// publishDetections pushes the current detections and the count shown with
+// the telemetry
+func (ws *WebServer) publishDetections() {
+	detections := ws.getDetections()
+	if detections == nil {
+		detections = []Detection{}
+	}
+	ws.publishState(EventDetections, "detections.html", detections)
+	ws.publishState(EventTelemetry, "telemetry.html", ws.getTelemetryData())
+}
+
+// PublishSafetyEvent pushes a safety event to the live clients, whose event
+// log shows it. It can be passed directly to SafetyManager.AddEventListener.
+func (ws *WebServer) PublishSafetyEvent(event *safety.SafetyEvent) {
+	if event == nil || !ws.events.active() {
+		return
+	}
+	level := "info"
+	switch event.Level {
+	case string(safety.SafetyEventLevelWarning):
+		level = "warn"
+	case string(safety.SafetyEventLevelCritical), string(safety.SafetyEventLevelEmergency):
+		level = "error"
+	}
+	view := struct {
+		*safety.SafetyEvent
+		LogLevel string
+	}{event, level}
+
+	if live, ok := ws.renderLiveEvent(EventSafety, "safety_event.html", view, event); ok {
+		ws.events.publishEvent(live)
+	}
+}
+
+// publishState renders a state update, as JSON alone when fragment is empty
+func (ws *WebServer) publishState(name, fragment string, data interface{}) {
+	if live, ok := ws.renderLiveEvent(name, fragment, data, data); ok {
+		ws.events.publishState(live)
+	}
+}
+
+// renderLiveEvent renders view with the fragment template and data as JSON
+func (ws *WebServer) renderLiveEvent(name, fragment string, view, data interface{}) (*liveEvent, bool) {
+	encoded, err := json.Marshal(data)
+	if err != nil {
+		utils.Logger.Errorf("Failed to encode %s event: %v", name, err)
+		return nil, false
+	}
+	event := &liveEvent{name: name, html: encoded, json: encoded}
+	if fragment != "" {
+		var buf bytes.Buffer
+		if err := ws.templates.ExecuteTemplate(&buf, fragment, view); err != nil {
+			utils.Logger.Errorf("Failed to execute %s template: %v", fragment, err)
+			return nil, false
+		}
+		event.html = buf.Bytes()
+	}
+	return event, true
+}
@@ -0,0 +1,191 @@
+package web
+
+import (
+	"bufio"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/conceptcodes/dji-tello-sdk-go/pkg/safety"
+)
+
+func newTestEvent(name, data string) *liveEvent {
+	return &liveEvent{name: name, html: []byte(data), json: []byte(data)}
+}
+
+func eventNames(events []*liveEvent) []string {
+	names := make([]string, len(events))
+	for i, event := range events {
+		names[i] = event.name + "=" + string(event.json)
+	}
+	return names
+}
+
+func TestEventClientCoalescesStates(t *testing.T) {
+	client := &eventClient{notify: make(chan struct{}, 1), latest: make(map[string]*liveEvent)}
+
+	client.offer(newTestEvent(EventTelemetry, "1"), true)
+	client.offer(newTestEvent(EventHUD, "1"), true)
+	client.offer(newTestEvent(EventTelemetry, "2"), true)
+	client.offer(newTestEvent(EventSafety, "a"), false)
+	client.offer(newTestEvent(EventSafety, "b"), false)
+
+	got := strings.Join(eventNames(client.take()), " ")
+	want := "safety=a safety=b telemetry=2 hud=1"
+	if got != want {
+		t.Errorf("Expected %q, got %q", want, got)
+	}
+	if client.dropped != 1 {
+		t.Errorf("Expected 1 skipped update, got %d", client.dropped)
+	}
+	if events := client.take(); len(events) != 0 {
+		t.Errorf("Expected nothing left, got %v", eventNames(events))
+	}
+}
+
+func TestEventClientBoundsSafetyQueue(t *testing.T) {
+	client := &eventClient{notify: make(chan struct{}, 1), latest: make(map[string]*liveEvent)}
+
+	for i := 0; i < maxQueuedSafetyEvents+3; i++ {
+		client.offer(newTestEvent(EventSafety, string(rune('A'+i))), false)
+	}
+
+	events := client.take()
+	if len(events) != maxQueuedSafetyEvents {
+		t.Fatalf("Expected %d events, got %d", maxQueuedSafetyEvents, len(events))
+	}
+	if first := string(events[0].json); first != "D" {
+		t.Errorf("Expected the oldest events to be dropped, first is %q", first)
+	}
+	if client.dropped != 3 {
+		t.Errorf("Expected 3 dropped events, got %d", client.dropped)
+	}
+}
+
+func TestEventBroker(t *testing.T) {
+	var running atomic.Int32
+	started := make(chan struct{}, 1)
+	broker := newEventBroker(func(ctx context.Context) {
+		running.Add(1)
+		started <- struct{}{}
+		<-ctx.Done()
+		running.Add(-1)
+	})
+
+	first := broker.subscribe(false)
+	select {
+	case <-started:
+	case <-time.After(time.Second):
+		t.Fatal("Expected the producer to start with the first client")
+	}
+	broker.publishState(newTestEvent(EventStatus, "ok"))
+	broker.publishState(newTestEvent(EventStatus, "ok"))
+	if got := eventNames(first.take()); len(got) != 1 {
+		t.Errorf("Expected an unchanged state to be sent once, got %v", got)
+	}
+
+	// The last state is replayed to new clients
+	second := broker.subscribe(true)
+	if got := strings.Join(eventNames(second.take()), " "); got != "status=ok" {
+		t.Errorf("Expected the last state replayed, got %q", got)
+	}
+
+	broker.publishEvent(newTestEvent(EventSafety, "x"))
+	for _, client := range []*eventClient{first, second} {
+		if got := strings.Join(eventNames(client.take()), " "); got != "safety=x" {
+			t.Errorf("Expected the safety event, got %q", got)
+		}
+	}
+
+	broker.unsubscribe(first)
+	if !broker.active() || running.Load() != 1 {
+		t.Error("Expected the producer to keep running for the remaining client")
+	}
+	broker.unsubscribe(second)
+	if broker.active() || running.Load() != 0 {
+		t.Error("Expected the producer to stop after the last client")
+	}
+
+	// Nobody saw the old state, so a new client starts afresh
+	third := broker.subscribe(false)
+	defer broker.unsubscribe(third)
+	if events := third.take(); len(events) != 0 {
+		t.Errorf("Expected no stale state, got %v", eventNames(events))
+	}
+}
+
+func TestHandleEventsStreamsSafetyEvents(t *testing.T) {
+	ws := newTestServer(t, nil)
+	server := httptest.NewServer(http.HandlerFunc(ws.handleEvents))
+	defer server.Close()
+
+	resp, err := http.Get(server.URL + "?format=json")
+	if err != nil {
+		t.Fatalf("Failed to connect: %v", err)
+	}
+	defer resp.Body.Close()
+	if contentType := resp.Header.Get("Content-Type"); contentType != "text/event-stream" {
+		t.Fatalf("Expected an event stream, got %q", contentType)
+	}
+
+	deadline := time.Now().Add(time.Second)
+	for !ws.events.active() {
+		if time.Now().After(deadline) {
+			t.Fatal("Expected the client to subscribe")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	ws.PublishSafetyEvent(safety.NewSafetyEvent(safety.SafetyEventBattery, safety.SafetyEventLevelWarning, "Battery low", nil))
+
+	lines := make(chan string)
+	done := make(chan struct{})
+	defer close(done)
+	go func() {
+		defer close(lines)
+		scanner := bufio.NewScanner(resp.Body)
+		for scanner.Scan() {
+			select {
+			case lines <- scanner.Text():
+			case <-done:
+				return
+			}
+		}
+	}()
+
+	timeout := time.After(2 * time.Second)
+	var event string
+	for {
+		select {
+		case line, ok := <-lines:
+			if !ok {
+				t.Fatal("Stream closed before the safety event")
+			}
+			if name, found := strings.CutPrefix(line, "event: "); found {
+				event = name
+			} else if event == EventSafety && strings.HasPrefix(line, "data: ") {
+				if !strings.Contains(line, "Battery low") {
+					t.Errorf("Expected the safety event's message, got %q", line)
+				}
+				return
+			}
+		case <-timeout:
+			t.Fatal("Timed out waiting for the safety event")
+		}
+	}
+}
+
+func TestHandleEventsUnknownFormat(t *testing.T) {
+	ws := newTestServer(t, nil)
+	rec := httptest.NewRecorder()
+	ws.handleEvents(rec, httptest.NewRequest(http.MethodGet, "/api/events?format=xml", nil))
+	if rec.Code != http.StatusBadRequest {
+		t.Errorf("Expected status 400, got %d", rec.Code)
+	}
+	if ws.events.active() {
+		t.Error("Expected the request not to subscribe")
+	}
+}
@@ -1,20 +1,34 @@
 package web
 
 import (
+	"context"
 	"crypto/rand"
 	"encoding/json"
 	"fmt"
 	"html/template"
+	"math"
 	"net/http"
+	"os"
+	"path/filepath"
 	"strings"
 	"sync"
 	"time"
 
 	"github.com/conceptcodes/dji-tello-sdk-go/pkg/ml"
+	"github.com/conceptcodes/dji-tello-sdk-go/pkg/navigation"
+	"github.com/conceptcodes/dji-tello-sdk-go/pkg/safety"
 	"github.com/conceptcodes/dji-tello-sdk-go/pkg/tello"
+	"github.com/conceptcodes/dji-tello-sdk-go/pkg/types"
 	"github.com/conceptcodes/dji-tello-sdk-go/pkg/utils"
 )
 
+// DefaultPhotoDir is where the shutter button saves photos unless
+// SetPhotoDir says otherwise
+const DefaultPhotoDir = "photos"
+
+// photoTimeout is how long the shutter button waits for a keyframe
+const photoTimeout = 10 * time.Second
+
 // TelemetryData represents telemetry data structure
 type TelemetryData struct {
 	AltitudeM       float64 `json:"altitude_m"`
@@ -46,6 +60,18 @@ type SystemStatus struct {
 type HUDData struct {
 	TimeLocal string `json:"time_local"`
 	GPSLock   string `json:"gps_lock"`
+
+	// Dead-reckoned pose relative to the takeoff point, when odometry is enabled
+	HasPose       bool    `json:"has_pose"`
+	PoseXM        float64 `json:"pose_x_m"`
+	PoseYM        float64 `json:"pose_y_m"`
+	PoseZM        float64 `json:"pose_z_m"`
+	HeadingDeg    float64 `json:"heading_deg"`
+	HomeDistanceM float64 `json:"home_distance_m"`
+	ReturningHome bool    `json:"returning_home"`
+
+	// Progress of the last click-to-fly waypoint, empty when there is none
+	Waypoint string `json:"waypoint,omitempty"`
 }
 
 // MiniStatsData represents mini flight stats
@@ -123,7 +149,18 @@ type WebServer struct {
 	templates     *template.Template
 	csrfTokens    map[string]time.Time
 	connection    *ConnectionCoordinator
-	mu            sync.RWMutex
+	odometry      *navigation.Odometry
+	rtlActive     bool
+	rtlCancel     context.CancelFunc
+	photoDir      string
+	events        *eventBroker
+
+	// Click-to-fly
+	waypointConfig WaypointConfig
+	safetyManager  safety.Manager
+	waypoint       *waypointProgress
+
+	mu sync.RWMutex
 }
 
 // NewWebServer creates a new web server instance
@@ -136,7 +173,11 @@ func NewWebServer(commander tello.TelloCommander, recorder RecorderInterface, ml
 		lastMLResults: make(map[string]ml.MLResult),
 		csrfTokens:    make(map[string]time.Time),
 		connection:    NewConnectionCoordinator(commander),
+		photoDir:      DefaultPhotoDir,
+
+		waypointConfig: DefaultWaypointConfig(),
 	}
+	ws.events = newEventBroker(ws.streamLiveEvents)
 
 	// Load templates
 	ws.loadTemplates()
@@ -149,6 +190,22 @@ func NewWebServer(commander tello.TelloCommander, recorder RecorderInterface, ml
 	return ws
 }
 
+// SetOdometry enables return to launch and the pose display in the HUD.
+// The odometry must be registered with the commander via
+// tello.WithFlightRecorder so that it sees the drone's states and commands.
+func (ws *WebServer) SetOdometry(odometry *navigation.Odometry) {
+	ws.mu.Lock()
+	defer ws.mu.Unlock()
+	ws.odometry = odometry
+}
+
+// SetPhotoDir sets the directory the shutter button saves photos to
+func (ws *WebServer) SetPhotoDir(dir string) {
+	ws.mu.Lock()
+	defer ws.mu.Unlock()
+	ws.photoDir = dir
+}
+
 // loadTemplates loads HTML templates
 func (ws *WebServer) loadTemplates() {
 	ws.templates = template.Must(template.ParseGlob("web/templates/**/*.html"))
@@ -160,6 +217,10 @@ func (ws *WebServer) processMLResults() {
 		ws.mu.Lock()
 		ws.lastMLResults[result.GetProcessorName()] = result
 		ws.mu.Unlock()
+
+		if ws.events.active() {
+			ws.publishDetections()
+		}
 	}
 }
 
@@ -173,6 +234,7 @@ func (ws *WebServer) setupSharedRoutes(mux *http.ServeMux) {
 
 	// API endpoints
 	mux.HandleFunc("/api/csrf-token", ws.handleCSRFToken)
+	mux.HandleFunc("/api/events", ws.handleEvents)
 	mux.HandleFunc("/api/telemetry", ws.handleTelemetry)
 	mux.HandleFunc("/api/status", ws.handleSystemStatus)
 	mux.HandleFunc("/api/appchips", ws.handleAppChips)
@@ -186,7 +248,9 @@ func (ws *WebServer) setupSharedRoutes(mux *http.ServeMux) {
 
 	// Control endpoints
 	mux.HandleFunc("/api/controls/record", ws.handleRecordControl)
+	mux.HandleFunc("/api/controls/photo", ws.handlePhotoControl)
 	mux.HandleFunc("/api/controls/rtl", ws.handleRTLControl)
+	mux.HandleFunc("/api/controls/stop", ws.handleStopControl)
 	mux.HandleFunc("/api/controls/altitude", ws.handleAltitudeControl)
 	mux.HandleFunc("/api/controls/rotation", ws.handleRotationControl)
 	mux.HandleFunc("/api/controls/waypoint", ws.handleWaypointControl)
@@ -250,6 +314,7 @@ func (ws *WebServer) handleConnectionConnect(w http.ResponseWriter, r *http.Requ
 
 	err := ws.connection.Connect()
 	status := ws.connection.Status()
+	ws.publishStatus()
 
 	response := map[string]interface{}{
 		"status": status,
@@ -613,6 +678,48 @@ func (ws *WebServer) handleRecordControl(w http.ResponseWriter, r *http.Request)
 	json.NewEncoder(w).Encode(response)
 }
 
+func (ws *WebServer) handlePhotoControl(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	// Validate CSRF token
+	if !ws.validateCSRF(r) {
+		http.Error(w, "Invalid CSRF token", http.StatusForbidden)
+		return
+	}
+
+	if ws.commander == nil {
+		http.Error(w, "Drone not connected", http.StatusServiceUnavailable)
+		return
+	}
+
+	ws.mu.RLock()
+	dir := ws.photoDir
+	ws.mu.RUnlock()
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		http.Error(w, fmt.Sprintf("Failed to create photo directory: %v", err), http.StatusInternalServerError)
+		return
+	}
+
+	path := filepath.Join(dir, fmt.Sprintf("photo_%s.jpg", time.Now().Format("20060102_150405.000")))
+	ctx, cancel := context.WithTimeout(r.Context(), photoTimeout)
+	defer cancel()
+	if err := ws.commander.TakePhoto(ctx, path); err != nil {
+		http.Error(w, fmt.Sprintf("Failed to take photo: %v", err), http.StatusInternalServerError)
+		return
+	}
+
+	response := map[string]string{
+		"message": fmt.Sprintf("Photo saved to %s", path),
+		"path":    path,
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(response)
+}
+
 func (ws *WebServer) handleRTLControl(w http.ResponseWriter, r *http.Request) {
 	if r.Method != http.MethodPost {
 		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
@@ -637,14 +744,120 @@ func (ws *WebServer) handleRTLControl(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	// Execute RTL command if commander is available
-	if ws.commander != nil {
-		// This would implement RTL logic
-		// For now, just return success
+	if ws.commander == nil {
+		http.Error(w, "Drone not connected", http.StatusServiceUnavailable)
+		return
+	}
+
+	ws.mu.Lock()
+	odometry := ws.odometry
+	if odometry == nil {
+		ws.mu.Unlock()
+		http.Error(w, "Return to launch unavailable: odometry is not enabled", http.StatusServiceUnavailable)
+		return
+	}
+	if !odometry.Flying() {
+		ws.mu.Unlock()
+		http.Error(w, "Drone is not flying", http.StatusConflict)
+		return
+	}
+	if ws.rtlActive {
+		ws.mu.Unlock()
+		http.Error(w, "Return to launch already in progress", http.StatusConflict)
+		return
+	}
+	if ws.waypoint.active() {
+		ws.mu.Unlock()
+		http.Error(w, "Waypoint in progress", http.StatusConflict)
+		return
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	ws.rtlActive = true
+	ws.rtlCancel = cancel
+	ws.mu.Unlock()
+
+	go ws.returnToLaunch(ctx, odometry)
+
+	response := map[string]interface{}{
+		"message":         "Return to launch initiated",
+		"home_distance_m": odometry.Pose().HomeDistance() / 100,
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(response)
+}
+
+// returnToLaunch flies the drone home in the background until ctx is cancelled
+func (ws *WebServer) returnToLaunch(ctx context.Context, odometry *navigation.Odometry) {
+	defer func() {
+		ws.mu.Lock()
+		ws.rtlActive = false
+		ws.rtlCancel()
+		ws.rtlCancel = nil
+		ws.mu.Unlock()
+	}()
+
+	if err := navigation.ReturnToLaunch(ctx, ws.commander, odometry, navigation.DefaultRTLConfig()); err != nil {
+		utils.Logger.Errorf("Return to launch failed: %v", err)
+		return
+	}
+	utils.Logger.Info("Return to launch complete")
+}
+
+// handleStopControl stops the drone ahead of any queued commands and aborts
+// a return to launch in progress
+func (ws *WebServer) handleStopControl(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	var req ControlRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	// Validate CSRF token
+	if !ws.validateCSRF(r) {
+		http.Error(w, "Invalid CSRF token", http.StatusForbidden)
+		return
+	}
+
+	if ws.commander == nil {
+		http.Error(w, "Drone not connected", http.StatusServiceUnavailable)
+		return
+	}
+
+	var stop func() error
+	var message string
+	switch req.Action {
+	case "emergency":
+		stop, message = ws.commander.EmergencyNow, "Emergency stop sent"
+	case "land":
+		stop, message = ws.commander.LandNow, "Landing"
+	case "hover":
+		stop, message = ws.commander.StopNow, "Hovering"
+	default:
+		http.Error(w, "Invalid action", http.StatusBadRequest)
+		return
+	}
+
+	// Stop the RTL sending further moves before preempting the current one
+	ws.mu.Lock()
+	if ws.rtlCancel != nil {
+		ws.rtlCancel()
+	}
+	ws.mu.Unlock()
+
+	if err := stop(); err != nil {
+		http.Error(w, fmt.Sprintf("Failed to stop: %v", err), http.StatusBadGateway)
+		return
 	}
 
 	response := map[string]string{
-		"message": "Return to launch initiated",
+		"message": message,
+		"action":  req.Action,
 	}
 
 	w.Header().Set("Content-Type", "application/json")
@@ -755,17 +968,66 @@ func (ws *WebServer) handleWaypointControl(w http.ResponseWriter, r *http.Reques
 		return
 	}
 
-	// Execute waypoint command if commander is available
-	if ws.commander != nil {
-		// This would implement waypoint logic
-		// For now, just log the waypoint
-		utils.Logger.Infof("Waypoint set at normalized coordinates: (%.3f, %.3f)", *req.XNorm, *req.YNorm)
+	if ws.commander == nil {
+		http.Error(w, "Drone not connected", http.StatusServiceUnavailable)
+		return
 	}
 
-	response := map[string]string{
-		"message": "Waypoint set successfully",
-		"x":       fmt.Sprintf("%.3f", *req.XNorm),
-		"y":       fmt.Sprintf("%.3f", *req.YNorm),
+	// Reserve the waypoint so that concurrent clicks cannot both fly
+	ws.mu.Lock()
+	if ws.rtlActive || ws.waypoint.active() {
+		ws.mu.Unlock()
+		http.Error(w, "Another flight is in progress", http.StatusConflict)
+		return
+	}
+	previous := ws.waypoint
+	progress := &waypointProgress{state: "flying"}
+	ws.waypoint = progress
+	config := ws.waypointConfig
+	manager := ws.safetyManager
+	ws.mu.Unlock()
+
+	fail := func(message string, code int) {
+		ws.mu.Lock()
+		ws.waypoint = previous
+		ws.mu.Unlock()
+		http.Error(w, message, code)
+	}
+
+	if manager == nil {
+		fail("Click-to-fly needs a safety manager", http.StatusServiceUnavailable)
+		return
+	}
+
+	height, err := ws.heightAboveFloor()
+	if err != nil {
+		fail(err.Error(), http.StatusServiceUnavailable)
+		return
+	}
+
+	point, err := projectClick(*req.XNorm, *req.YNorm, height, config)
+	if err != nil {
+		fail("Invalid waypoint: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	x, y, err := ws.flyToWaypoint(manager, progress, point, config)
+	if err != nil {
+		fail("Waypoint rejected: "+err.Error(), http.StatusUnprocessableEntity)
+		return
+	}
+	utils.Logger.Infof("Waypoint at (%.3f, %.3f) from %.0f cm: go %d %d 0", *req.XNorm, *req.YNorm, height, x, y)
+
+	message := "Flying to waypoint"
+	if point.Clamped {
+		message = fmt.Sprintf("Waypoint beyond %d cm, flying %d cm towards it", config.MaxDistance, config.MaxDistance)
+	}
+	response := map[string]interface{}{
+		"message":   message,
+		"x":         fmt.Sprintf("%.3f", *req.XNorm),
+		"y":         fmt.Sprintf("%.3f", *req.YNorm),
+		"forward_m": point.Forward / 100,
+		"right_m":   point.Right / 100,
 	}
 
 	w.Header().Set("Content-Type", "application/json")
@@ -793,16 +1055,7 @@ func (ws *WebServer) getTelemetryData() *TelemetryData {
 	}
 	telemetry.DetectionsTone = toneFromCount(telemetry.DetectionsCount)
 
-	// Get data from commander if available
-	if ws.commander != nil {
-		if height, err := ws.commander.GetHeight(); err == nil {
-			telemetry.AltitudeM = float64(height) / 100 // Convert cm to m
-		}
-
-		if speed, err := ws.commander.GetSpeed(); err == nil {
-			telemetry.SpeedMPS = float64(speed) / 100 // Convert cm/s to m/s
-		}
-	}
+	telemetry.AltitudeM, telemetry.SpeedMPS = ws.altitudeAndSpeed()
 
 	telemetry.SignalTone = toneFromPercentage(telemetry.SignalPct)
 
@@ -831,11 +1084,8 @@ func (ws *WebServer) getSystemStatus() *SystemStatus {
 		}
 	}
 
-	// Get battery data from commander if available
-	if ws.commander != nil {
-		if battery, err := ws.commander.GetBatteryPercentage(); err == nil {
-			status.BatteryPct = battery
-		}
+	if battery, ok := ws.batteryPercentage(); ok {
+		status.BatteryPct = battery
 	}
 
 	status.Battery = batteryIndicator(status.BatteryPct)
@@ -849,11 +1099,16 @@ func (ws *WebServer) getAppChipsData() *AppChipsData {
 		Mode:     "IDLE",
 	}
 
-	// Get battery data from commander if available
-	if ws.commander != nil {
-		if battery, err := ws.commander.GetBatteryPercentage(); err == nil {
-			chips.PowerPct = battery
-		}
+	ws.mu.RLock()
+	if ws.rtlActive {
+		chips.Mode = "RTL"
+	} else if ws.waypoint.active() {
+		chips.Mode = "WAYPOINT"
+	}
+	ws.mu.RUnlock()
+
+	if battery, ok := ws.batteryPercentage(); ok {
+		chips.PowerPct = battery
 	}
 
 	return chips
@@ -880,10 +1135,28 @@ func (ws *WebServer) getModelsData() []ModelState {
 }
 
 func (ws *WebServer) getHUDData() *HUDData {
-	return &HUDData{
+	hud := &HUDData{
 		TimeLocal: time.Now().Format("15:04:05"),
 		GPSLock:   "NO FIX",
 	}
+
+	ws.mu.RLock()
+	odometry := ws.odometry
+	hud.ReturningHome = ws.rtlActive
+	hud.Waypoint = ws.waypoint.hudText(time.Now())
+	ws.mu.RUnlock()
+
+	if odometry != nil {
+		pose := odometry.Pose()
+		hud.HasPose = true
+		hud.PoseXM = pose.X / 100
+		hud.PoseYM = pose.Y / 100
+		hud.PoseZM = pose.Z / 100
+		hud.HeadingDeg = pose.Yaw
+		hud.HomeDistanceM = pose.HomeDistance() / 100
+	}
+
+	return hud
 }
 
 func (ws *WebServer) getMiniStatsData() *MiniStatsData {
@@ -892,18 +1165,56 @@ func (ws *WebServer) getMiniStatsData() *MiniStatsData {
 		SpeedMPS:  0,
 	}
 
-	// Get data from commander if available
+	stats.AltitudeM, stats.SpeedMPS = ws.altitudeAndSpeed()
+
+	return stats
+}
+
+// latestState returns the drone's last reported state, or nil if none has
+// arrived yet
+func (ws *WebServer) latestState() *types.State {
+	if ws.commander == nil {
+		return nil
+	}
+	hub := ws.commander.GetTelemetryHub()
+	if hub == nil {
+		return nil
+	}
+	return hub.Latest()
+}
+
+// batteryPercentage returns the drone's battery level, preferring the state
+// stream over a read command
+func (ws *WebServer) batteryPercentage() (int, bool) {
+	if state := ws.latestState(); state != nil {
+		return state.Bat, true
+	}
+	if ws.commander != nil {
+		if battery, err := ws.commander.GetBatteryPercentage(); err == nil {
+			return battery, true
+		}
+	}
+	return 0, false
+}
+
+// altitudeAndSpeed returns the drone's height in m and speed in m/s,
+// preferring the state stream over read commands
+func (ws *WebServer) altitudeAndSpeed() (altitudeM, speedMPS float64) {
+	if state := ws.latestState(); state != nil {
+		speed := math.Sqrt(float64(state.Vgx*state.Vgx + state.Vgy*state.Vgy + state.Vgz*state.Vgz))
+		return float64(state.H) / 100, speed / 100 // Convert cm to m
+	}
+
 	if ws.commander != nil {
 		if height, err := ws.commander.GetHeight(); err == nil {
-			stats.AltitudeM = float64(height) / 100 // Convert cm to m
+			altitudeM = float64(height) / 100 // Convert cm to m
 		}
 
 		if speed, err := ws.commander.GetSpeed(); err == nil {
-			stats.SpeedMPS = float64(speed) / 100 // Convert cm/s to m/s
+			speedMPS = float64(speed) / 100 // Convert cm/s to m/s
 		}
 	}
-
-	return stats
+	return altitudeM, speedMPS
 }
 
 func newIndicator(label, tone string) StatusIndicator {
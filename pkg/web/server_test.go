@@ -0,0 +1,223 @@
+package web
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/conceptcodes/dji-tello-sdk-go/pkg/navigation"
+	"github.com/conceptcodes/dji-tello-sdk-go/pkg/tello"
+)
+
+// TestMain runs the tests from the repository root, where the server finds
+// its templates
+func TestMain(m *testing.M) {
+	if err := os.Chdir(filepath.Join("..", "..")); err != nil {
+		panic(err)
+	}
+	os.Exit(m.Run())
+}
+
+// fakeCommander records the commands the handlers send. Methods it does not
+// implement panic through the nil embedded interface, so a handler calling
+// one unexpectedly fails the test.
+type fakeCommander struct {
+	tello.TelloCommander
+
+	tof, height int
+	err         error // Returned by the commands
+
+	mu    sync.Mutex
+	calls []string
+}
+
+func (f *fakeCommander) record(call string) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.calls = append(f.calls, call)
+	return f.err
+}
+
+func (f *fakeCommander) commands() []string {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return append([]string(nil), f.calls...)
+}
+
+func (f *fakeCommander) EmergencyNow() error { return f.record("emergency now") }
+func (f *fakeCommander) LandNow() error      { return f.record("land now") }
+func (f *fakeCommander) StopNow() error      { return f.record("stop now") }
+
+func (f *fakeCommander) Go(x, y, z, speed int) error {
+	return f.record(fmt.Sprintf("go %d %d %d %d", x, y, z, speed))
+}
+
+func (f *fakeCommander) GetTof() (int, error)    { return f.tof, nil }
+func (f *fakeCommander) GetHeight() (int, error) { return f.height, nil }
+
+// The safety manager watches the link of commanders that can report it
+func (f *fakeCommander) SetLinkTimeout(timeout time.Duration)           {}
+func (f *fakeCommander) SetLinkCallback(callback func(tello.LinkEvent)) {}
+
+// newTestServer creates a server for commander, which may be nil
+func newTestServer(t *testing.T, commander tello.TelloCommander) *WebServer {
+	t.Helper()
+	return NewWebServer(commander, nil, nil, nil)
+}
+
+// post sends body to handler with a valid CSRF token unless csrf is false
+func post(ws *WebServer, handler http.HandlerFunc, body string, csrf bool) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(http.MethodPost, "/api/controls", strings.NewReader(body))
+	if csrf {
+		ws.mu.Lock()
+		req.Header.Set("X-CSRF-Token", ws.generateCSRFToken())
+		ws.mu.Unlock()
+	}
+	rec := httptest.NewRecorder()
+	handler(rec, req)
+	return rec
+}
+
+func TestHandleStopControl(t *testing.T) {
+	tests := []struct {
+		name     string
+		body     string
+		csrf     bool
+		err      error
+		wantCode int
+		wantCall string
+	}{
+		{name: "emergency", body: `{"action":"emergency"}`, csrf: true, wantCode: http.StatusOK, wantCall: "emergency now"},
+		{name: "land", body: `{"action":"land"}`, csrf: true, wantCode: http.StatusOK, wantCall: "land now"},
+		{name: "hover", body: `{"action":"hover"}`, csrf: true, wantCode: http.StatusOK, wantCall: "stop now"},
+		{name: "unknown action", body: `{"action":"flip"}`, csrf: true, wantCode: http.StatusBadRequest},
+		{name: "invalid body", body: `{`, csrf: true, wantCode: http.StatusBadRequest},
+		{name: "missing CSRF token", body: `{"action":"land"}`, wantCode: http.StatusForbidden},
+		{name: "drone fails", body: `{"action":"land"}`, csrf: true, err: errors.New("timeout"), wantCode: http.StatusBadGateway, wantCall: "land now"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			drone := &fakeCommander{err: tt.err}
+			ws := newTestServer(t, drone)
+
+			rec := post(ws, ws.handleStopControl, tt.body, tt.csrf)
+			if rec.Code != tt.wantCode {
+				t.Fatalf("Expected status %d, got %d: %s", tt.wantCode, rec.Code, rec.Body)
+			}
+			calls := drone.commands()
+			if tt.wantCall == "" && len(calls) != 0 {
+				t.Errorf("Expected no command, got %v", calls)
+			}
+			if tt.wantCall != "" && (len(calls) != 1 || calls[0] != tt.wantCall) {
+				t.Errorf("Expected %q, got %v", tt.wantCall, calls)
+			}
+		})
+	}
+
+	t.Run("cancels return to launch", func(t *testing.T) {
+		ws := newTestServer(t, &fakeCommander{})
+		cancelled := false
+		ws.rtlCancel = func() { cancelled = true }
+
+		if rec := post(ws, ws.handleStopControl, `{"action":"hover"}`, true); rec.Code != http.StatusOK {
+			t.Fatalf("Expected status 200, got %d: %s", rec.Code, rec.Body)
+		}
+		if !cancelled {
+			t.Error("Expected the return to launch to be cancelled")
+		}
+	})
+
+	t.Run("no drone", func(t *testing.T) {
+		ws := newTestServer(t, nil)
+		if rec := post(ws, ws.handleStopControl, `{"action":"land"}`, true); rec.Code != http.StatusServiceUnavailable {
+			t.Errorf("Expected status 503, got %d", rec.Code)
+		}
+	})
+
+	t.Run("wrong method", func(t *testing.T) {
+		ws := newTestServer(t, &fakeCommander{})
+		rec := httptest.NewRecorder()
+		ws.handleStopControl(rec, httptest.NewRequest(http.MethodGet, "/api/controls/stop", nil))
+		if rec.Code != http.StatusMethodNotAllowed {
+			t.Errorf("Expected status 405, got %d", rec.Code)
+		}
+	})
+}
+
+func TestHandleRTLControl(t *testing.T) {
+	flyingOdometry := func() *navigation.Odometry {
+		odometry := navigation.NewOdometry(navigation.DefaultOdometryConfig())
+		odometry.RecordCommand("takeoff", "ok", nil, 0)
+		return odometry
+	}
+
+	tests := []struct {
+		name     string
+		drone    tello.TelloCommander
+		odometry *navigation.Odometry
+		setup    func(ws *WebServer)
+		body     string
+		csrf     bool
+		wantCode int
+	}{
+		{name: "missing confirmation", drone: &fakeCommander{}, odometry: flyingOdometry(), body: `{}`, csrf: true, wantCode: http.StatusBadRequest},
+		{name: "declined", drone: &fakeCommander{}, odometry: flyingOdometry(), body: `{"confirm":false}`, csrf: true, wantCode: http.StatusBadRequest},
+		{name: "missing CSRF token", drone: &fakeCommander{}, odometry: flyingOdometry(), body: `{"confirm":true}`, wantCode: http.StatusForbidden},
+		{name: "no drone", odometry: flyingOdometry(), body: `{"confirm":true}`, csrf: true, wantCode: http.StatusServiceUnavailable},
+		{name: "no odometry", drone: &fakeCommander{}, body: `{"confirm":true}`, csrf: true, wantCode: http.StatusServiceUnavailable},
+		{
+			name:     "not flying",
+			drone:    &fakeCommander{},
+			odometry: navigation.NewOdometry(navigation.DefaultOdometryConfig()),
+			body:     `{"confirm":true}`,
+			csrf:     true,
+			wantCode: http.StatusConflict,
+		},
+		{
+			name:     "already returning",
+			drone:    &fakeCommander{},
+			odometry: flyingOdometry(),
+			setup:    func(ws *WebServer) { ws.rtlActive = true },
+			body:     `{"confirm":true}`,
+			csrf:     true,
+			wantCode: http.StatusConflict,
+		},
+		{
+			name:     "waypoint in progress",
+			drone:    &fakeCommander{},
+			odometry: flyingOdometry(),
+			setup:    func(ws *WebServer) { ws.waypoint = &waypointProgress{state: "flying"} },
+			body:     `{"confirm":true}`,
+			csrf:     true,
+			wantCode: http.StatusConflict,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ws := newTestServer(t, tt.drone)
+			if tt.odometry != nil {
+				ws.SetOdometry(tt.odometry)
+			}
+			if tt.setup != nil {
+				tt.setup(ws)
+			}
+
+			rec := post(ws, ws.handleRTLControl, tt.body, tt.csrf)
+			if rec.Code != tt.wantCode {
+				t.Errorf("Expected status %d, got %d: %s", tt.wantCode, rec.Code, rec.Body)
+			}
+			if drone, ok := tt.drone.(*fakeCommander); ok && len(drone.commands()) != 0 {
+				t.Errorf("Expected no command, got %v", drone.commands())
+			}
+		})
+	}
+}
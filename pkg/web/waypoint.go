@@ -0,0 +1,232 @@
+package web
+
+import (
+	"fmt"
+	"math"
+	"time"
+
+	"github.com/conceptcodes/dji-tello-sdk-go/pkg/safety"
+	"github.com/conceptcodes/dji-tello-sdk-go/pkg/utils"
+)
+
+const (
+	// minGoOffset is the SDK's smallest go move: x and y cannot both be
+	// within this distance when z is 0
+	minGoOffset = 20
+
+	// minTofReading is what the ToF sensor reports when it has no valid
+	// return, e.g. on the ground or above its range
+	minTofReading = 10
+
+	// minDepression is the smallest angle below the horizon, as a tangent,
+	// that a click may select. Points closer to the horizon are too far away
+	// to place reliably on the floor. (about 2°)
+	minDepression = 0.035
+
+	// waypointStatusTTL is how long a finished waypoint stays in the HUD
+	waypointStatusTTL = 10 * time.Second
+)
+
+// WaypointConfig holds the click-to-fly settings
+type WaypointConfig struct {
+	// HorizontalFOV and VerticalFOV are the camera's field of view in
+	// degrees. The Tello's 82.6° diagonal on a 4:3 sensor gives about
+	// 70° by 55°.
+	HorizontalFOV float64 `json:"horizontal_fov"`
+	VerticalFOV   float64 `json:"vertical_fov"`
+
+	// MaxDistance is the furthest a single click sends the drone. Clicks
+	// further away fly this far towards the point. (default: 300 cm)
+	MaxDistance int `json:"max_distance"`
+
+	// Speed is the speed of the go command, limited further by the safety
+	// configuration's maximum horizontal velocity (default: 50 cm/s)
+	Speed int `json:"speed"`
+
+	// Timeout is added to the expected flight time to give the time allowed
+	// for the drone to report arrival (default: 10s)
+	Timeout time.Duration `json:"timeout"`
+}
+
+// DefaultWaypointConfig returns the default click-to-fly configuration
+func DefaultWaypointConfig() WaypointConfig {
+	return WaypointConfig{
+		HorizontalFOV: 70.3,
+		VerticalFOV:   55.4,
+		MaxDistance:   300,
+		Speed:         50,
+		Timeout:       10 * time.Second,
+	}
+}
+
+// groundPoint is a clicked point on the floor relative to the drone, in cm
+type groundPoint struct {
+	Forward float64
+	Right   float64
+	Clamped bool // The point was further than MaxDistance
+}
+
+// projectClick projects a click on the video, with coordinates normalized to
+// 0..1 from the top-left corner, onto the floor. The camera looks straight
+// ahead, so only clicks below the horizon reach the floor.
+func projectClick(xNorm, yNorm, heightCm float64, config WaypointConfig) (groundPoint, error) {
+	if xNorm < 0 || xNorm > 1 || yNorm < 0 || yNorm > 1 {
+		return groundPoint{}, fmt.Errorf("click (%.3f, %.3f) is outside the video", xNorm, yNorm)
+	}
+	if heightCm <= 0 {
+		return groundPoint{}, fmt.Errorf("height above the floor is unknown")
+	}
+
+	// Tangents of the angles right of and below the optical axis
+	right := (2*xNorm - 1) * math.Tan(config.HorizontalFOV*math.Pi/360)
+	down := (2*yNorm - 1) * math.Tan(config.VerticalFOV*math.Pi/360)
+	if down < minDepression {
+		return groundPoint{}, fmt.Errorf("click below the horizon to choose a point on the floor")
+	}
+
+	point := groundPoint{
+		Forward: heightCm / down,
+		Right:   heightCm / down * right,
+	}
+	if distance := math.Hypot(point.Forward, point.Right); distance > float64(config.MaxDistance) {
+		scale := float64(config.MaxDistance) / distance
+		point.Forward *= scale
+		point.Right *= scale
+		point.Clamped = true
+	}
+	return point, nil
+}
+
+// waypointProgress is the state of the last waypoint shown in the HUD
+type waypointProgress struct {
+	state    string // "flying", "arrived" or "failed"
+	detail   string
+	distance float64 // cm
+	finished time.Time
+}
+
+func (p *waypointProgress) active() bool {
+	return p != nil && p.state == "flying"
+}
+
+// hudText returns the HUD line for the waypoint, or "" once it has expired
+func (p *waypointProgress) hudText(now time.Time) string {
+	if p == nil || (!p.active() && now.Sub(p.finished) > waypointStatusTTL) {
+		return ""
+	}
+	switch p.state {
+	case "flying":
+		return fmt.Sprintf("WPT %.1f m · FLYING", p.distance/100)
+	case "arrived":
+		return "WPT ARRIVED"
+	default:
+		return "WPT FAILED: " + p.detail
+	}
+}
+
+// SetSafetyManager sets the safety manager that waypoint moves are flown
+// through, so that its limits, geofence and keep-out boxes apply. It should
+// watch the drone's states and have the odometry as its position source.
+// Click-to-fly is refused without one.
+func (ws *WebServer) SetSafetyManager(manager safety.Manager) {
+	ws.mu.Lock()
+	defer ws.mu.Unlock()
+	ws.safetyManager = manager
+}
+
+// SetWaypointConfig sets the camera and flight settings for click-to-fly
+func (ws *WebServer) SetWaypointConfig(config WaypointConfig) {
+	ws.mu.Lock()
+	defer ws.mu.Unlock()
+	ws.waypointConfig = config
+}
+
+// heightAboveFloor returns the camera's height above the floor, preferring
+// the ToF sensor over the height relative to takeoff
+func (ws *WebServer) heightAboveFloor() (float64, error) {
+	if tof, err := ws.commander.GetTof(); err == nil && tof > minTofReading {
+		return float64(tof), nil
+	}
+	height, err := ws.commander.GetHeight()
+	if err != nil {
+		return 0, fmt.Errorf("failed to read height: %w", err)
+	}
+	return float64(height), nil
+}
+
+// flyToWaypoint sends a go command towards point through the safety manager,
+// at a speed within its limits, then tracks its progress for the HUD. The
+// manager and commander validate the move, whose go offsets are returned
+// (x forward, y left).
+func (ws *WebServer) flyToWaypoint(manager safety.Manager, progress *waypointProgress, point groundPoint, config WaypointConfig) (x, y int, err error) {
+	x, y = int(math.Round(point.Forward)), int(math.Round(-point.Right))
+	if abs(x) <= minGoOffset && abs(y) <= minGoOffset {
+		return 0, 0, fmt.Errorf("waypoint is within %d cm of the drone", minGoOffset)
+	}
+
+	speed := min(config.Speed, manager.GetSafetyConfig().Velocity.MaxHorizontal)
+	ws.mu.RLock()
+	odometry := ws.odometry
+	ws.mu.RUnlock()
+
+	// Register for the drone's answer before sending so it cannot be missed
+	var completed <-chan bool
+	stop := func() {}
+	if odometry != nil {
+		completed, stop = odometry.AwaitCommand(fmt.Sprintf("go %d %d 0 %d", x, y, speed))
+	}
+
+	if err := manager.Go(x, y, 0, speed); err != nil {
+		stop()
+		return 0, 0, err
+	}
+
+	distance := math.Hypot(float64(x), float64(y))
+	ws.mu.Lock()
+	progress.distance = distance
+	ws.mu.Unlock()
+
+	timeout := time.Duration(distance/float64(speed)*float64(time.Second)) + config.Timeout
+	go ws.trackWaypoint(progress, completed, stop, timeout)
+	return x, y, nil
+}
+
+// trackWaypoint waits for the drone to report the end of a waypoint move.
+// Without odometry there is no answer to wait for, so the move is assumed
+// to finish within its timeout.
+func (ws *WebServer) trackWaypoint(progress *waypointProgress, completed <-chan bool, stop func(), timeout time.Duration) {
+	defer stop()
+	timer := time.NewTimer(timeout)
+	defer timer.Stop()
+
+	state, detail := "arrived", ""
+	select {
+	case ok := <-completed:
+		if !ok {
+			state, detail = "failed", "rejected by drone"
+		}
+	case <-timer.C:
+		if completed != nil {
+			state, detail = "failed", "no response"
+		}
+	}
+
+	ws.mu.Lock()
+	progress.state = state
+	progress.detail = detail
+	progress.finished = time.Now()
+	ws.mu.Unlock()
+
+	if state == "failed" {
+		utils.Logger.Warnf("Waypoint failed: %s", detail)
+	} else {
+		utils.Logger.Info("Waypoint reached")
+	}
+}
+
+func abs(v int) int {
+	if v < 0 {
+		return -v
+	}
+	return v
+}
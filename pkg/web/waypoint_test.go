@@ -0,0 +1,213 @@
+package web
+
+import (
+	"encoding/json"
+	"math"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/conceptcodes/dji-tello-sdk-go/pkg/navigation"
+	"github.com/conceptcodes/dji-tello-sdk-go/pkg/safety"
+)
+
+func TestProjectClick(t *testing.T) {
+	tests := []struct {
+		name         string
+		x, y, height float64
+		wantForward  float64
+		wantRight    float64
+		wantClamped  bool
+		wantErr      string
+	}{
+		{name: "bottom centre", x: 0.5, y: 1, height: 100, wantForward: 190.47},
+		{name: "bottom right corner", x: 1, y: 1, height: 100, wantForward: 190.47, wantRight: 134.11},
+		{name: "clamped keeps direction", x: 0, y: 0.8, height: 150, wantForward: 245.29, wantRight: -172.72, wantClamped: true},
+		{name: "just below the cutoff", x: 0.5, y: 0.54, height: 100, wantForward: 300, wantClamped: true},
+		{name: "just above the cutoff", x: 0.5, y: 0.53, height: 100, wantErr: "below the horizon"},
+		{name: "horizon", x: 0.5, y: 0.5, height: 100, wantErr: "below the horizon"},
+		{name: "sky", x: 0.5, y: 0.1, height: 100, wantErr: "below the horizon"},
+		{name: "outside the video", x: 1.2, y: 0.9, height: 100, wantErr: "outside the video"},
+		{name: "unknown height", x: 0.5, y: 1, height: 0, wantErr: "height"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			point, err := projectClick(tt.x, tt.y, tt.height, DefaultWaypointConfig())
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("Expected error containing %q, got %v", tt.wantErr, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			if math.Abs(point.Forward-tt.wantForward) > 0.01 || math.Abs(point.Right-tt.wantRight) > 0.01 {
+				t.Errorf("Expected (%.2f, %.2f), got (%.2f, %.2f)", tt.wantForward, tt.wantRight, point.Forward, point.Right)
+			}
+			if point.Clamped != tt.wantClamped {
+				t.Errorf("Expected clamped %v, got %v", tt.wantClamped, point.Clamped)
+			}
+		})
+	}
+}
+
+// fixedPose is a position source that never moves
+type fixedPose navigation.Pose
+
+func (p fixedPose) Pose() navigation.Pose { return navigation.Pose(p) }
+
+// newWaypointServer creates a server whose clicks go through a real safety
+// manager with a 100 cm geofence around the takeoff point
+func newWaypointServer(t *testing.T, drone *fakeCommander, maxSpeed int) *WebServer {
+	t.Helper()
+	config := safety.DefaultConfig()
+	config.Velocity.MaxHorizontal = maxSpeed
+	config.Geofence = safety.GeofenceLimits{
+		Enabled:      true,
+		Shape:        safety.GeofenceShapeCylinder,
+		Radius:       100,
+		BreachAction: "land",
+	}
+	manager, err := safety.NewManager(drone, config)
+	if err != nil {
+		t.Fatalf("Failed to create safety manager: %v", err)
+	}
+	manager.SetPositionSource(fixedPose{Z: 100})
+
+	ws := newTestServer(t, drone)
+	ws.SetSafetyManager(manager)
+	return ws
+}
+
+func TestHandleWaypointControl(t *testing.T) {
+	t.Run("no safety manager", func(t *testing.T) {
+		drone := &fakeCommander{tof: 100}
+		ws := newTestServer(t, drone)
+
+		rec := post(ws, ws.handleWaypointControl, `{"x_norm":0.5,"y_norm":1}`, true)
+		if rec.Code != http.StatusServiceUnavailable {
+			t.Fatalf("Expected status 503, got %d: %s", rec.Code, rec.Body)
+		}
+		if calls := drone.commands(); len(calls) != 0 {
+			t.Errorf("Expected no command, got %v", calls)
+		}
+		if ws.waypoint != nil {
+			t.Errorf("Expected the waypoint to be released, got %+v", ws.waypoint)
+		}
+	})
+
+	t.Run("no drone", func(t *testing.T) {
+		ws := newTestServer(t, nil)
+		if rec := post(ws, ws.handleWaypointControl, `{"x_norm":0.5,"y_norm":1}`, true); rec.Code != http.StatusServiceUnavailable {
+			t.Errorf("Expected status 503, got %d", rec.Code)
+		}
+	})
+
+	t.Run("missing coordinates", func(t *testing.T) {
+		ws := newWaypointServer(t, &fakeCommander{tof: 100}, 100)
+		if rec := post(ws, ws.handleWaypointControl, `{"x_norm":0.5}`, true); rec.Code != http.StatusBadRequest {
+			t.Errorf("Expected status 400, got %d", rec.Code)
+		}
+	})
+
+	t.Run("above the horizon", func(t *testing.T) {
+		drone := &fakeCommander{tof: 100}
+		ws := newWaypointServer(t, drone, 100)
+		rec := post(ws, ws.handleWaypointControl, `{"x_norm":0.5,"y_norm":0.3}`, true)
+		if rec.Code != http.StatusBadRequest {
+			t.Fatalf("Expected status 400, got %d: %s", rec.Code, rec.Body)
+		}
+		if calls := drone.commands(); len(calls) != 0 {
+			t.Errorf("Expected no command, got %v", calls)
+		}
+	})
+
+	t.Run("outside the geofence", func(t *testing.T) {
+		// 190 cm ahead from 1 m up, beyond the 100 cm fence
+		drone := &fakeCommander{tof: 100}
+		ws := newWaypointServer(t, drone, 100)
+
+		rec := post(ws, ws.handleWaypointControl, `{"x_norm":0.5,"y_norm":1}`, true)
+		if rec.Code != http.StatusUnprocessableEntity {
+			t.Fatalf("Expected status 422, got %d: %s", rec.Code, rec.Body)
+		}
+		if !strings.Contains(rec.Body.String(), "geofence") {
+			t.Errorf("Expected a geofence rejection, got %q", rec.Body)
+		}
+		if calls := drone.commands(); len(calls) != 0 {
+			t.Errorf("Expected no command, got %v", calls)
+		}
+		if ws.waypoint.active() {
+			t.Error("Expected the waypoint to be released")
+		}
+	})
+
+	t.Run("accepted", func(t *testing.T) {
+		// 76 cm ahead from 40 cm up, flown at the safety speed limit
+		drone := &fakeCommander{tof: 40}
+		ws := newWaypointServer(t, drone, 30)
+		odometry := navigation.NewOdometry(navigation.DefaultOdometryConfig())
+		ws.SetOdometry(odometry)
+		config := DefaultWaypointConfig()
+		config.Timeout = time.Second
+		ws.SetWaypointConfig(config)
+
+		rec := post(ws, ws.handleWaypointControl, `{"x_norm":0.5,"y_norm":1}`, true)
+		if rec.Code != http.StatusOK {
+			t.Fatalf("Expected status 200, got %d: %s", rec.Code, rec.Body)
+		}
+		if calls := drone.commands(); len(calls) != 1 || calls[0] != "go 76 0 0 30" {
+			t.Errorf("Expected go 76 0 0 30, got %v", calls)
+		}
+		var response map[string]interface{}
+		if err := json.NewDecoder(rec.Body).Decode(&response); err != nil {
+			t.Fatalf("Failed to decode response: %v", err)
+		}
+		if forward, _ := response["forward_m"].(float64); math.Abs(forward-0.76) > 0.01 {
+			t.Errorf("Expected forward_m 0.76, got %v", response["forward_m"])
+		}
+
+		// A second click waits for the first to finish
+		if rec := post(ws, ws.handleWaypointControl, `{"x_norm":0.5,"y_norm":1}`, true); rec.Code != http.StatusConflict {
+			t.Errorf("Expected status 409 while flying, got %d", rec.Code)
+		}
+
+		odometry.RecordCommand("go 76 0 0 30", "ok", nil, 0)
+		deadline := time.Now().Add(time.Second)
+		for {
+			ws.mu.RLock()
+			state := ws.waypoint.state
+			ws.mu.RUnlock()
+			if state == "arrived" {
+				break
+			}
+			if time.Now().After(deadline) {
+				t.Fatalf("Expected the waypoint to arrive, got %q", state)
+			}
+			time.Sleep(10 * time.Millisecond)
+		}
+	})
+
+	t.Run("clamped", func(t *testing.T) {
+		drone := &fakeCommander{tof: 100}
+		ws := newWaypointServer(t, drone, 100)
+		config := DefaultWaypointConfig()
+		config.MaxDistance = 80
+		config.Timeout = time.Second
+		ws.SetWaypointConfig(config)
+
+		rec := post(ws, ws.handleWaypointControl, `{"x_norm":0.5,"y_norm":1}`, true)
+		if rec.Code != http.StatusOK {
+			t.Fatalf("Expected status 200, got %d: %s", rec.Code, rec.Body)
+		}
+		if !strings.Contains(rec.Body.String(), "beyond 80 cm") {
+			t.Errorf("Expected a clamped message, got %q", rec.Body)
+		}
+		if calls := drone.commands(); len(calls) != 1 || calls[0] != "go 80 0 0 50" {
+			t.Errorf("Expected go 80 0 0 50, got %v", calls)
+		}
+	})
+}
@@ -8,6 +8,7 @@ class MissionControl {
         this.setupKeyboardShortcuts();
         this.setupCardToggles();
         this.setupThemePreferenceWatcher();
+        this.setupLiveEvents();
         this.startPolling();
     }
 
@@ -103,6 +104,11 @@ class MissionControl {
             recordBtn.addEventListener('click', () => this.toggleRecording());
         }
 
+        const photoBtn = document.getElementById('btn-photo');
+        if (photoBtn) {
+            photoBtn.addEventListener('click', () => this.takePhoto());
+        }
+
         if (rtlBtn) {
             rtlBtn.addEventListener('click', () => this.returnToLaunch());
         }
@@ -111,6 +117,21 @@ class MissionControl {
             waypointBtn.addEventListener('click', () => this.toggleWaypointMode());
         }
 
+        const landBtn = document.getElementById('btn-land');
+        const emergencyBtn = document.getElementById('btn-emergency');
+
+        if (landBtn) {
+            landBtn.addEventListener('click', () => this.stopNow('land'));
+        }
+
+        if (emergencyBtn) {
+            emergencyBtn.addEventListener('click', () => {
+                if (confirm('Emergency stop? The motors cut out immediately and the drone will fall.')) {
+                    this.stopNow('emergency');
+                }
+            });
+        }
+
         // Altitude controls
         const altitudeUp = document.getElementById('ctrl-altitude-up');
         const altitudeDown = document.getElementById('ctrl-altitude-down');
@@ -275,13 +296,44 @@ class MissionControl {
         });
     }
 
+    setupLiveEvents() {
+        // Panels swap their fragments in through the HTMX SSE extension;
+        // these are the pushes that need more than a swap
+        document.addEventListener('htmx:afterSettle', (event) => {
+            const target = event.target;
+            if (target.id === 'connection-feed') {
+                try {
+                    const status = JSON.parse(target.textContent);
+                    const previousStatus = this.state.connection || {};
+                    this.state.connection = status;
+                    this.renderConnectionStatus(status, previousStatus);
+                } catch (err) {
+                    console.error('Connection event error', err);
+                }
+                return;
+            }
+
+            if (target.parentElement && target.parentElement.id === 'event-log') {
+                // A safety event
+                const placeholder = this.logContainer.querySelector('.log-entry.muted');
+                if (placeholder) {
+                    placeholder.remove();
+                }
+                this.trimLog();
+                if (target.classList.contains('error')) {
+                    const message = target.querySelector('.log-entry-message');
+                    this.showToast(message ? message.textContent : 'Safety event', 'error');
+                }
+            }
+        });
+    }
+
     startPolling() {
-        // HTMX handles most polling automatically
-        // Add any additional polling logic here
+        // Telemetry, status, detections and connection changes are pushed
+        // over /api/events; see setupLiveEvents
         this.updateTime();
         setInterval(() => this.updateTime(), 1000);
         this.updateConnectionStatus();
-        setInterval(() => this.updateConnectionStatus(), 5000);
         this.updateModels();
         setInterval(() => this.updateModels(), 5000);
         this.updateChips();
@@ -569,6 +621,38 @@ class MissionControl {
         });
     }
 
+    // Waits for the next keyframe, so the button is disabled until it is saved
+    takePhoto() {
+        const photoBtn = document.getElementById('btn-photo');
+        if (photoBtn) {
+            photoBtn.disabled = true;
+        }
+
+        fetch('/api/controls/photo', {
+            method: 'POST',
+            headers: {
+                'X-CSRF-Token': document.csrfToken
+            }
+        })
+        .then(async response => {
+            if (!response.ok) {
+                throw new Error((await response.text()).trim() || response.statusText);
+            }
+            return response.json();
+        })
+        .then(data => {
+            this.showToast(data.message || 'Photo saved', 'success');
+        })
+        .catch(err => {
+            this.showToast('Failed to take photo: ' + err.message, 'error');
+        })
+        .finally(() => {
+            if (photoBtn) {
+                photoBtn.disabled = false;
+            }
+        });
+    }
+
     // Flight Controls
     returnToLaunch() {
         if (!confirm('Return to launch? This will land the drone at its starting position.')) {
@@ -583,7 +667,12 @@ class MissionControl {
             },
             body: JSON.stringify({ confirm: true })
         })
-        .then(response => response.json())
+        .then(async response => {
+            if (!response.ok) {
+                throw new Error((await response.text()).trim() || response.statusText);
+            }
+            return response.json();
+        })
         .then(data => {
             this.state.mode = 'RTL';
             this.showToast('Returning to launch...', 'success');
@@ -594,6 +683,32 @@ class MissionControl {
         });
     }
 
+    // Skips any queued commands, so there is no confirmation for landing
+    stopNow(action) {
+        fetch('/api/controls/stop', {
+            method: 'POST',
+            headers: {
+                'Content-Type': 'application/json',
+                'X-CSRF-Token': document.csrfToken
+            },
+            body: JSON.stringify({ action: action })
+        })
+        .then(async response => {
+            if (!response.ok) {
+                throw new Error((await response.text()).trim() || response.statusText);
+            }
+            return response.json();
+        })
+        .then(data => {
+            this.state.mode = action === 'emergency' ? 'EMERGENCY' : 'LANDING';
+            this.showToast(data.message, action === 'emergency' ? 'error' : 'success');
+            this.updateModeDisplay();
+        })
+        .catch(err => {
+            this.showToast('Failed to stop: ' + err.message, 'error');
+        });
+    }
+
     toggleWaypointMode() {
         this.state.waypointMode = !this.state.waypointMode;
         
@@ -632,9 +747,14 @@ class MissionControl {
             },
             body: JSON.stringify({ x_norm: x, y_norm: y })
         })
-        .then(response => response.json())
+        .then(async response => {
+            if (!response.ok) {
+                throw new Error((await response.text()).trim() || response.statusText);
+            }
+            return response.json();
+        })
         .then(data => {
-            this.showToast('Waypoint set successfully', 'success');
+            this.showToast(data.message || 'Flying to waypoint', 'success');
             this.exitWaypointMode();
         })
         .catch(err => {
@@ -907,7 +1027,10 @@ class MissionControl {
         entry.appendChild(messageEl);
 
         this.logContainer.prepend(entry);
+        this.trimLog();
+    }
 
+    trimLog() {
         const maxEntries = 30;
         while (this.logContainer.children.length > maxEntries) {
             this.logContainer.removeChild(this.logContainer.lastChild);